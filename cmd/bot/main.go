@@ -2,53 +2,284 @@ package main
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 
+	"ym-bot/internal/archive"
+	"ym-bot/internal/cache"
+	"ym-bot/internal/client/musicbrainz"
 	"ym-bot/internal/client/yandex"
 	"ym-bot/internal/config"
+	"ym-bot/internal/metrics"
+	"ym-bot/internal/queue"
+	"ym-bot/internal/ratelimit"
+	"ym-bot/internal/reporting"
+	"ym-bot/internal/services/cover"
 	"ym-bot/internal/services/music"
+	"ym-bot/internal/settings"
+	"ym-bot/internal/storage"
+	"ym-bot/internal/transport/httpapi"
 	"ym-bot/internal/transport/telegram"
 	"ym-bot/internal/utils"
+	"ym-bot/internal/webhook"
 )
 
 func main() {
 	// Load .env when running locally; ignored if file is absent.
 	_ = godotenv.Load()
 
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	cfg, err := config.Load()
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
 		log.Fatalf("config: %v", err)
 	}
 
-	logger, err := utils.NewLogger(cfg.LogLevel)
+	var report zapcore.Core
+	if cfg.SentryDSN != "" {
+		host, _ := os.Hostname()
+		reporter, err := reporting.NewClient(cfg.SentryDSN,
+			reporting.WithEnvironment(cfg.SentryEnvironment),
+			reporting.WithServerName(host),
+			// Delivery failures go to the standard log: reporting them through
+			// the logger would loop back into the tracker.
+			reporting.WithErrorLog(log.Printf),
+		)
+		if err != nil {
+			log.Fatalf("error reporting: %v", err)
+		}
+		report = reporting.NewCore(reporter)
+	}
+
+	logger, levels, err := utils.NewLogger(utils.LogOptions{
+		Level:      cfg.LogLevel,
+		Encoding:   cfg.LogEncoding,
+		Outputs:    cfg.LogOutputs,
+		Sampling:   cfg.LogSampling,
+		MaxSizeMB:  cfg.LogRotation.MaxSizeMB,
+		MaxBackups: cfg.LogRotation.MaxBackups,
+		MaxAgeDays: cfg.LogRotation.MaxAgeDays,
+		Compress:   cfg.LogRotation.Compress,
+		Report:     report,
+	})
 	if err != nil {
 		log.Fatalf("logger: %v", err)
 	}
 	defer logger.Sync() // best-effort flush
 
-	if cfg.TelegramToken == "" {
+	tokens := cfg.BotTokens()
+	if len(tokens) == 0 {
 		logger.Fatal("TELEGRAM_TOKEN is required")
 	}
 
-	httpClient := &http.Client{Timeout: 20 * time.Second}
-	ymClient := yandex.NewClient(httpClient, cfg.YandexToken, logger)
-	musicService := music.NewService(ymClient, logger)
+	httpClient := &http.Client{Timeout: cfg.Timeouts.HTTP}
+	var recordSink io.Writer
+	if cfg.YandexDebugPath != "" {
+		sink := &lumberjack.Logger{
+			Filename:   cfg.YandexDebugPath,
+			MaxSize:    cfg.LogRotation.MaxSizeMB,
+			MaxBackups: cfg.LogRotation.MaxBackups,
+			MaxAge:     cfg.LogRotation.MaxAgeDays,
+			Compress:   cfg.LogRotation.Compress,
+		}
+		defer sink.Close()
+		recordSink = sink
+	}
+	// Yandex API requests get the HTTP timeout from yandex.WithTimeout, which
+	// spares audio transfers: those are bounded by timeouts.download. A host
+	// that never answers is still cut off waiting for the headers.
+	yandexTransport := http.DefaultTransport.(*http.Transport).Clone()
+	yandexTransport.ResponseHeaderTimeout = cfg.Timeouts.HTTP
+	recorder := yandex.NewRecorder(&http.Client{Transport: yandexTransport}, cfg.YandexDebug, recordSink)
+	ymClient := yandex.NewClient(recorder, cfg.YandexToken, levels.Named(logger, "yandex"),
+		yandex.WithBaseURL(cfg.YandexAPIURL),
+		yandex.WithTimeout(cfg.Timeouts.HTTP),
+		yandex.WithSpellCorrection(cfg.SearchCorrection),
+		yandex.WithSignSalt(cfg.YandexSignSalt),
+		yandex.WithParallelDownload(cfg.DownloadConnections, int64(cfg.ChunkedThresholdMB)<<20),
+	)
+	if cfg.TempDir != "" {
+		if err := os.MkdirAll(cfg.TempDir, 0o755); err != nil {
+			logger.Fatal("temp dir init failed", zap.Error(err))
+		}
+	}
+	trackCache := cache.New[yandex.Track](cfg.TrackCacheTTL, 5000)
+	fileNames, err := music.NewFileNames(cfg.FileNameTemplate)
+	if err != nil {
+		logger.Fatal("file names init failed", zap.Error(err))
+	}
+	var archiver *archive.Client
+	if cfg.Archive.Enabled() {
+		// Uploads are bounded by the job timeout, not the per-request one.
+		archiver, err = archive.NewClient(cfg.Archive.Endpoint, cfg.Archive.Bucket, cfg.Archive.AccessKey, cfg.Archive.SecretKey,
+			archive.WithRegion(cfg.Archive.Region),
+			archive.WithPrefix(cfg.Archive.Prefix),
+		)
+		if err != nil {
+			logger.Fatal("archive init failed", zap.Error(err))
+		}
+	}
+	var brainz *musicbrainz.Client
+	if cfg.MusicBrainzContact != "" {
+		brainz = musicbrainz.NewClient(cfg.MusicBrainzContact, musicbrainz.WithHTTPClient(httpClient))
+	}
+	store, err := storage.Open(cfg.StoragePath)
+	if err != nil {
+		logger.Fatal("storage init failed", zap.Error(err))
+	}
+	go store.Run(ctx, 30*time.Second)
+
+	musicService := music.NewService(ymClient,
+		music.WithLogger(levels.Named(logger, "music")),
+		music.WithCache(trackCache),
+		music.WithTempDir(cfg.TempDir),
+		music.WithDownloadTimeout(cfg.Timeouts.Download),
+		music.WithFileNames(fileNames),
+		music.WithTranscoder(cfg.FFmpegPath),
+		music.WithArchive(archiver),
+		music.WithMusicBrainz(brainz),
+		music.WithPicks(store),
+	)
+
+	covers := cover.NewService(httpClient, cover.WithLogger(levels.Named(logger, "cover")))
 
-	bot, err := telegram.NewBot(cfg.TelegramToken, musicService, logger)
+	audit, err := storage.OpenAudit(cfg.AuditLogPath)
 	if err != nil {
-		logger.Fatal("telegram init failed", zap.Error(err))
+		logger.Fatal("audit log init failed", zap.Error(err))
+	}
+	defer audit.Close()
+	// Files kept for redelivery are not orphans, however old.
+	go musicService.RunSweeper(ctx, cfg.TempMaxAge, store.DeadLetterPaths)
+
+	var notifier *webhook.Notifier
+	if len(cfg.Webhooks) > 0 {
+		endpoints := make([]webhook.Endpoint, len(cfg.Webhooks))
+		for i, w := range cfg.Webhooks {
+			endpoints[i] = webhook.Endpoint{URL: w.URL, Secret: w.Secret, Events: w.Events}
+		}
+		notifier = webhook.New(endpoints,
+			webhook.WithLogger(levels.Named(logger, "webhook")),
+			webhook.WithErrorBurst(cfg.WebhookErrorBurst, cfg.WebhookErrorWindow),
+		)
+	}
+
+	limiter := ratelimit.New(cfg.RateLimitPerMinute, cfg.RateLimitBurst)
+	pool := queue.NewPool(cfg.DownloadWorkers, cfg.DownloadQueueSize)
+	pool.Start(ctx)
+
+	reloader := config.NewReloader(os.Args[1:], cfg, levels.Named(logger, "config"))
+	runtime := settings.New(settings.FromConfig(cfg))
+
+	// One transport per token; all share the service, cache, storage, queue and settings.
+	bots := make([]*telegram.Bot, 0, len(tokens))
+	for i, token := range tokens {
+		bot, err := telegram.NewBot(token, musicService,
+			telegram.WithAPIEndpoint(cfg.TelegramAPIURL),
+			telegram.WithStore(store),
+			telegram.WithAuditLog(audit),
+			telegram.WithHTTPRecorder(recorder),
+			telegram.WithCovers(covers),
+			telegram.WithWebhooks(notifier),
+			telegram.WithLogger(levels.Named(logger, "telegram").With(zap.Int("bot", i))),
+			telegram.WithRateLimiter(limiter),
+			telegram.WithWorkerPool(pool),
+			telegram.WithSettings(runtime),
+			telegram.WithReloader(reloader),
+			telegram.WithCallbackSecret(cfg.CallbackSecret),
+			telegram.WithCallbackTTL(cfg.CallbackTTL),
+			telegram.WithInlineTimeout(cfg.Timeouts.Inline),
+			telegram.WithCallbackTimeout(cfg.Timeouts.Callback),
+		)
+		if err != nil {
+			logger.Fatal("telegram init failed", zap.Int("bot", i), zap.Error(err))
+		}
+		bot.ApplyConfig(cfg)
+		reloader.Subscribe(bot.ApplyConfig)
+		bots = append(bots, bot)
+	}
+
+	reloader.Subscribe(func(next config.Config) {
+		if err := levels.Set(next.LogLevel); err != nil {
+			logger.Warn("apply log levels failed", zap.Error(err))
+		}
+		limiter.SetLimits(next.RateLimitPerMinute, next.RateLimitBurst)
+		pool.Resize(next.DownloadWorkers)
+		trackCache.SetTTL(next.TrackCacheTTL)
+		recorder.SetEnabled(next.YandexDebug)
+	})
+	go reloader.WatchSignals(ctx)
+
+	if cfg.APIAddr != "" {
+		api := httpapi.NewServer(musicService, cfg.APIKeys,
+			httpapi.WithLogger(levels.Named(logger, "api")),
+			httpapi.WithSearchTimeout(cfg.Timeouts.Inline),
+			httpapi.WithDownloadTimeout(cfg.Timeouts.Callback),
+		)
+		go func() {
+			if err := api.Run(ctx, cfg.APIAddr); err != nil {
+				logger.Error("http api stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	if cfg.MetricsAddr != "" {
+		collectors := []metrics.Collector{pool.CollectMetrics}
+		for _, bot := range bots {
+			collectors = append(collectors, bot.CollectMetrics)
+		}
+		go func() {
+			if err := metrics.Serve(ctx, cfg.MetricsAddr, metrics.Handler(collectors...), levels.Named(logger, "metrics")); err != nil {
+				logger.Error("metrics server stopped", zap.Error(err))
+			}
+		}()
 	}
 
-	logger.Info("bot is starting")
-	if err := bot.Start(ctx); err != nil {
+	go telegram.RunPodcastAlerts(ctx, bots)
+
+	logger.Info("bot is starting", zap.Int("bots", len(bots)))
+	if err := runBots(ctx, bots); err != nil && !errors.Is(err, context.Canceled) {
 		logger.Fatal("bot stopped with error", zap.Error(err))
 	}
+
+	if notifier != nil && !notifier.Flush(5*time.Second) {
+		logger.Warn("webhook events left unsent")
+	}
+	if err := store.Flush(); err != nil {
+		logger.Warn("storage flush failed", zap.Error(err))
+	}
+	logger.Info("bot stopped")
 }
 
+// runBots polls every bot until ctx is done or one of them fails, which stops the rest.
+func runBots(ctx context.Context, bots []*telegram.Bot) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, len(bots))
+	for _, bot := range bots {
+		go func(bot *telegram.Bot) {
+			errs <- bot.Start(ctx)
+		}(bot)
+	}
+
+	var first error
+	for range bots {
+		if err := <-errs; first == nil {
+			first = err
+			cancel()
+		}
+	}
+	return first
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+)
+
+// audioExts are the extensions a finished download may have, see DownloadLink.Extension.
+var audioExts = []string{".mp3", ".m4a", ".flac"}
+
+type downloader struct {
+	music  *music.Service
+	outDir string
+	names  *music.FileNames
+	// claimed keeps the tracks of one run from overwriting each other.
+	claimed   music.UniqueNames
+	timeout   time.Duration
+	overwrite bool
+}
+
+// all downloads tracks one by one, numbering them when indexed is set. It goes
+// on past failures and reports them together at the end.
+func (d *downloader) all(ctx context.Context, tracks []yandex.Track, indexed bool) error {
+	failed := 0
+	for i, t := range tracks {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		index := 0
+		if indexed {
+			index = i + 1
+		}
+		prefix := fmt.Sprintf("[%d/%d] %s — %s", i+1, len(tracks), t.ArtistsString(), t.FullTitle())
+
+		path, skipped, err := d.one(ctx, t, index)
+		switch {
+		case err != nil:
+			failed++
+			fmt.Fprintf(os.Stderr, "%s: %v\n", prefix, err)
+		case skipped:
+			fmt.Printf("%s: already exists, %s\n", prefix, path)
+		default:
+			fmt.Printf("%s → %s\n", prefix, path)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tracks failed", failed, len(tracks))
+	}
+	return nil
+}
+
+// one downloads t into the output directory under its templated name,
+// creating the directories the template asks for.
+func (d *downloader) one(ctx context.Context, t yandex.Track, index int) (path string, skipped bool, err error) {
+	name, err := d.names.Render(t, index)
+	if err != nil {
+		return "", false, err
+	}
+	// Claiming in track order gives the same suffixes on every run, so
+	// existing files are still recognised.
+	base := filepath.Join(d.outDir, filepath.FromSlash(d.claimed.Claim(name)))
+	if !d.overwrite {
+		for _, ext := range audioExts {
+			existing := base + ext
+			if _, err := os.Stat(existing); err == nil {
+				return existing, true, nil
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	dl, err := d.music.DownloadTrack(ctx, t.ID)
+	if err != nil {
+		return "", false, err
+	}
+	defer dl.Close()
+
+	path = base + filepath.Ext(dl.Path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", false, fmt.Errorf("save: %w", err)
+	}
+	if err := os.Rename(dl.Path, path); err != nil {
+		return "", false, fmt.Errorf("save: %w", err)
+	}
+	return path, false, nil
+}
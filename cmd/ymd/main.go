@@ -0,0 +1,215 @@
+// Command ymd searches and downloads Yandex Music tracks from a terminal,
+// using the same music service as the bot.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/utils"
+)
+
+const usage = `Usage: ymd [flags] <command> [args]
+
+Commands:
+  search <query>     list matching tracks
+  track <id>...      download tracks by id
+  album <id>         download every track of an album
+  playlists          list playlists of the YANDEX_TOKEN account
+  playlist <kind>    download a playlist of that account
+
+Flags:
+`
+
+// options are the global flags shared by all commands.
+type options struct {
+	token     string
+	apiURL    string
+	quality   string
+	outDir    string
+	name      string
+	limit     int
+	order     string
+	replay    string
+	signSalt  string
+	timeout   time.Duration
+	overwrite bool
+	verbose   bool
+}
+
+func main() {
+	// Load .env when running locally; ignored if file is absent.
+	_ = godotenv.Load()
+
+	var opts options
+	fs := flag.NewFlagSet("ymd", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.token, "token", os.Getenv("YANDEX_TOKEN"), "Yandex Music OAuth token (YANDEX_TOKEN)")
+	fs.StringVar(&opts.apiURL, "api-url", os.Getenv("YANDEX_API_URL"), "Yandex Music API base URL (YANDEX_API_URL)")
+	fs.StringVar(&opts.quality, "quality", string(yandex.QualityHigh), "high, low or lossless")
+	fs.StringVar(&opts.outDir, "out", ".", "output directory")
+	fs.StringVar(&opts.name, "name", music.DefaultFileNameTemplate,
+		"file name template without extension, \"/\" makes directories; fields: .Artists .Artist .Title .Album .Genre .Year .Disc .Number .ID .Index")
+	fs.IntVar(&opts.limit, "limit", 10, "search results to list")
+	fs.StringVar(&opts.order, "order", "relevance", "search result order: relevance, popular, new, short or long")
+	fs.StringVar(&opts.signSalt, "sign-salt", os.Getenv("YANDEX_SIGN_SALT"), "salt of XML download URL signatures (YANDEX_SIGN_SALT), empty for the built-in one")
+	fs.StringVar(&opts.replay, "replay", "", "answer API requests from exchanges recorded by YANDEX_DEBUG_PATH instead of the network")
+	fs.DurationVar(&opts.timeout, "timeout", 5*time.Minute, "time limit per track download")
+	fs.BoolVar(&opts.overwrite, "overwrite", false, "download tracks whose file already exists")
+	fs.BoolVar(&opts.verbose, "v", false, "log requests to stderr")
+	_ = fs.Parse(os.Args[1:])
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, opts, fs.Arg(0), fs.Args()[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "ymd:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options, command string, args []string) error {
+	quality, err := yandex.ParseQuality(opts.quality)
+	if err != nil {
+		return err
+	}
+	names, err := music.NewFileNames(opts.name)
+	if err != nil {
+		return err
+	}
+	order, ok := music.ParseOrder(opts.order)
+	if !ok {
+		return fmt.Errorf("unknown order %q, want relevance, popular, new, short or long", opts.order)
+	}
+
+	logger := zap.NewNop()
+	if opts.verbose {
+		if logger, _, err = utils.NewLogger(utils.LogOptions{Level: "debug", Outputs: []string{"stderr"}}); err != nil {
+			return err
+		}
+		defer logger.Sync() // best-effort flush
+	}
+
+	if err := os.MkdirAll(opts.outDir, 0o755); err != nil {
+		return fmt.Errorf("output dir: %w", err)
+	}
+	var httpClient yandex.HTTPClient = &http.Client{Timeout: opts.timeout}
+	if opts.replay != "" {
+		if httpClient, err = yandex.LoadReplay(opts.replay); err != nil {
+			return fmt.Errorf("replay: %w", err)
+		}
+	}
+	client := yandex.NewClient(httpClient, opts.token, logger.Named("yandex"),
+		yandex.WithBaseURL(opts.apiURL),
+		yandex.WithQuality(quality),
+		yandex.WithSignSalt(opts.signSalt),
+	)
+	// Temp files live next to the output so finished tracks are renamed, not copied.
+	svc := music.NewService(client,
+		music.WithLogger(logger.Named("music")),
+		music.WithTempDir(opts.outDir),
+		music.WithDownloadTimeout(opts.timeout),
+		music.WithFileNames(names),
+	)
+	d := &downloader{music: svc, outDir: opts.outDir, names: names, timeout: opts.timeout, overwrite: opts.overwrite}
+
+	switch command {
+	case "search":
+		return search(ctx, svc, strings.Join(args, " "), order, opts.limit)
+	case "track":
+		if len(args) == 0 {
+			return fmt.Errorf("track: at least one id is required")
+		}
+		tracks, err := svc.Tracks(ctx, args)
+		if err != nil {
+			return err
+		}
+		return d.all(ctx, tracks, false)
+	case "album":
+		if len(args) != 1 {
+			return fmt.Errorf("album: exactly one id is required")
+		}
+		album, err := svc.Album(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s — %s, %d tracks\n", strings.Join(album.Artists, ", "), album.Title, len(album.Tracks))
+		return d.all(ctx, album.Tracks, true)
+	case "playlists":
+		return playlists(ctx, svc)
+	case "playlist":
+		if len(args) != 1 {
+			return fmt.Errorf("playlist: exactly one kind is required")
+		}
+		kind, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("playlist: kind must be a number")
+		}
+		pl, tracks, err := svc.PlaylistTracks(ctx, kind)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s, %d tracks\n", pl.Title, len(tracks))
+		return d.all(ctx, tracks, true)
+	default:
+		return fmt.Errorf("unknown command %q, run ymd -h for help", command)
+	}
+}
+
+func search(ctx context.Context, svc *music.Service, query string, order music.Order, limit int) error {
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("search: query is required")
+	}
+	res, err := svc.Search(ctx, query, order, limit, 0)
+	if err != nil {
+		return err
+	}
+	if res.Corrected {
+		fmt.Printf("Showing results for %q\n", res.Correction)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, t := range res.Tracks {
+		fmt.Fprintf(w, "%s\t%s — %s\t%s\t%s\n", t.ID, t.ArtistsString(), t.FullTitle(), t.DurationString(), t.AlbumTitle)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(res.Tracks) == 0 {
+		fmt.Println("Nothing found.")
+	}
+	return nil
+}
+
+func playlists(ctx context.Context, svc *music.Service) error {
+	list, err := svc.Playlists(ctx)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, p := range list {
+		fmt.Fprintf(w, "%d\t%s\t%d\n", p.Kind, p.Title, p.TrackCount)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,246 @@
+// Package archive keeps delivered tracks in S3-compatible object storage
+// (AWS S3, MinIO, Yandex Object Storage) so they can be served again once
+// Yandex stops serving them. It speaks the S3 REST API with SigV4 signing
+// directly, so the bot needs no SDK.
+//
+// Every track takes two objects under the prefix: the audio file as it was
+// sent, "<prefix><track id>/<file name>", and its metadata,
+// "<prefix><track id>/meta.json".
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// metaName is the object holding a track's Meta.
+const metaName = "meta.json"
+
+// ErrNotFound reports a track that is not in the archive.
+var ErrNotFound = errors.New("archive: track not found")
+
+// Meta describes an archived file.
+type Meta struct {
+	Track yandex.Track `json:"track"`
+	// Object is the key of the audio file.
+	Object string `json:"object"`
+	// Name is the file name the track was sent under.
+	Name   string `json:"name"`
+	Codec  string `json:"codec"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// ArchivedAt is when the file was uploaded.
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+// Client uploads to and downloads from one bucket.
+type Client struct {
+	endpoint *url.URL
+	bucket   string
+	prefix   string
+	signer   signer
+	http     *http.Client
+}
+
+// Option customizes a Client.
+type Option func(*Client)
+
+// WithRegion sets the signing region; "us-east-1" by default, which MinIO
+// accepts unless configured otherwise.
+func WithRegion(region string) Option {
+	return func(c *Client) {
+		if region != "" {
+			c.signer.region = region
+		}
+	}
+}
+
+// WithPrefix puts the objects under prefix, e.g. "ym-bot/".
+func WithPrefix(prefix string) Option {
+	return func(c *Client) {
+		c.prefix = strings.TrimPrefix(prefix, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client; it should not time out whole
+// requests, as uploads of lossless files take a while.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		if h != nil {
+			c.http = h
+		}
+	}
+}
+
+// NewClient returns a client for bucket at endpoint, e.g.
+// "https://s3.eu-central-1.amazonaws.com" or "http://minio:9000". Buckets
+// are addressed path-style, which every S3-compatible store understands.
+func NewClient(endpoint, bucket, accessKey, secretKey string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("archive: endpoint must be an absolute URL, got %q", endpoint)
+	}
+	if bucket == "" || accessKey == "" || secretKey == "" {
+		return nil, errors.New("archive: bucket and credentials are required")
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	c := &Client{
+		endpoint: u,
+		bucket:   bucket,
+		signer:   signer{service: "s3", region: "us-east-1", accessKey: accessKey, secretKey: secretKey},
+		http:     &http.Client{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Meta returns the metadata of the archived trackID, or ErrNotFound.
+func (c *Client) Meta(ctx context.Context, trackID string) (Meta, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.key(trackID, metaName), nil, 0, emptyHash, "")
+	if err != nil {
+		return Meta{}, err
+	}
+	defer resp.Body.Close()
+	var m Meta
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return Meta{}, fmt.Errorf("archive: decode %s meta: %w", trackID, err)
+	}
+	return m, nil
+}
+
+// Put uploads the file at src as the archived copy of m.Track, then its
+// metadata; the metadata goes last so that a track is never listed without
+// its file. Object, SHA256 and ArchivedAt of m are filled in.
+func (c *Client) Put(ctx context.Context, src string, m Meta) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return fmt.Errorf("archive: hash %s: %w", src, err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+
+	if m.Name == "" {
+		m.Name = path.Base(src)
+	}
+	m.Object = c.key(m.Track.ID, m.Name)
+	m.Size = size
+	m.SHA256 = hex.EncodeToString(h.Sum(nil))
+	m.ArchivedAt = time.Now().UTC()
+	resp, err := c.do(ctx, http.MethodPut, m.Object, f, size, m.SHA256, "application/octet-stream")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("archive: encode meta: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	resp, err = c.do(ctx, http.MethodPut, c.key(m.Track.ID, metaName), bytes.NewReader(raw),
+		int64(len(raw)), hex.EncodeToString(sum[:]), "application/json")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Fetch downloads the archived file of m to dst and checks its hash; dst is
+// removed when the download fails.
+func (c *Client) Fetch(ctx context.Context, m Meta, dst string) (err error) {
+	resp, err := c.do(ctx, http.MethodGet, m.Object, nil, 0, emptyHash, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("archive: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("archive: %w", cerr)
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+		return fmt.Errorf("archive: fetch %s: %w", m.Object, err)
+	}
+	if m.SHA256 != "" && hex.EncodeToString(h.Sum(nil)) != m.SHA256 {
+		return fmt.Errorf("archive: fetch %s: checksum mismatch", m.Object)
+	}
+	return nil
+}
+
+func (c *Client) key(trackID, name string) string {
+	return c.prefix + trackID + "/" + name
+}
+
+// do sends a signed request for key and returns the response when it is a
+// success; a missing object is ErrNotFound.
+func (c *Client) do(ctx context.Context, method, key string, body io.Reader, size int64, payloadHash, contentType string) (*http.Response, error) {
+	u := *c.endpoint
+	u.Path = c.endpoint.Path + "/" + c.bucket + "/" + key
+	u.RawPath = c.endpoint.EscapedPath() + "/" + uriEncode(c.bucket, false) + "/" + uriEncode(key, true)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("archive: %w", err)
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	c.signer.sign(req, u.RawPath, []string{"x-amz-content-sha256"}, payloadHash, time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("archive: %s %s: %w", method, key, err)
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var s3err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 4<<10)).Decode(&s3err)
+	return nil, fmt.Errorf("archive: %s %s: %s %s %s", method, key, resp.Status, s3err.Code, s3err.Message)
+}
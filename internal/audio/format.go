@@ -0,0 +1,261 @@
+// Package audio recognises audio files by their content rather than by the
+// codec Yandex reports, and converts them when a client cannot play them.
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"strconv"
+)
+
+// SniffLen is how much of a file Sniff needs to tell the format and, for all
+// but very large ID3 tags or MP4 files with the index at the end, the sample rate.
+const SniffLen = 64 << 10
+
+// Containers recognised by Sniff.
+const (
+	ContainerMPEG = "mpeg" // bare MPEG audio frames, optionally behind an ID3 tag
+	ContainerADTS = "adts" // raw AAC frames
+	ContainerMP4  = "mp4"
+	ContainerFLAC = "flac"
+	ContainerOgg  = "ogg"
+)
+
+// Format is what an audio file actually holds.
+type Format struct {
+	// Container is one of the Container constants, "" when not recognised.
+	Container string
+	// Codec is "mp3", "mp2", "aac", "alac", "flac", "opus" or "vorbis";
+	// it may be "" for a recognised container with an unknown stream.
+	Codec string
+	// SampleRate is in Hz, 0 when it was not found in the sniffed header.
+	SampleRate int
+}
+
+// Known reports whether the container was recognised.
+func (f Format) Known() bool {
+	return f.Container != ""
+}
+
+// Lossless reports whether the stream is a lossless encoding.
+func (f Format) Lossless() bool {
+	return f.Codec == "flac" || f.Codec == "alac"
+}
+
+// PlaysInline reports whether Telegram plays the file in its audio player:
+// sendAudio takes MP3 and AAC in MP4 (.m4a), anything else only works as a
+// document.
+func (f Format) PlaysInline() bool {
+	switch f.Container {
+	case ContainerMPEG:
+		return f.Codec == "mp3"
+	case ContainerMP4:
+		return f.Codec == "aac"
+	}
+	return false
+}
+
+// Ext returns the file extension for the format, with the dot.
+func (f Format) Ext() string {
+	switch f.Container {
+	case ContainerMPEG:
+		if f.Codec == "mp2" {
+			return ".mp2"
+		}
+		return ".mp3"
+	case ContainerADTS:
+		return ".aac"
+	case ContainerMP4:
+		return ".m4a"
+	case ContainerFLAC:
+		return ".flac"
+	case ContainerOgg:
+		if f.Codec == "opus" {
+			return ".opus"
+		}
+		return ".ogg"
+	}
+	return ""
+}
+
+// MIME returns the media type for the format, "" when unknown.
+func (f Format) MIME() string {
+	switch f.Container {
+	case ContainerMPEG:
+		return "audio/mpeg"
+	case ContainerADTS:
+		return "audio/aac"
+	case ContainerMP4:
+		return "audio/mp4"
+	case ContainerFLAC:
+		return "audio/flac"
+	case ContainerOgg:
+		return "audio/ogg"
+	}
+	return ""
+}
+
+// SampleRateString renders the sample rate as "44.1 kHz", "" when unknown.
+func (f Format) SampleRateString() string {
+	if f.SampleRate <= 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(f.SampleRate)/1000, 'f', -1, 64) + " kHz"
+}
+
+// SniffFile reads the head of the file at path and sniffs it.
+func SniffFile(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Format{}, err
+	}
+	defer f.Close()
+
+	head := make([]byte, SniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return Format{}, err
+	}
+	return Sniff(head[:n]), nil
+}
+
+// Sniff recognises the format from the first bytes of a file, ideally SniffLen.
+func Sniff(head []byte) Format {
+	switch {
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return sniffID3(head)
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return Format{Container: ContainerFLAC, Codec: "flac", SampleRate: flacRate(head)}
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return sniffOgg(head)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return sniffMP4(head)
+	}
+	if f, ok := sniffFrame(head); ok {
+		return f
+	}
+	return Format{}
+}
+
+// sniffID3 skips an ID3v2 tag and reads the first MPEG frame behind it.
+func sniffID3(head []byte) Format {
+	f := Format{Container: ContainerMPEG, Codec: "mp3"}
+	if len(head) < 10 {
+		return f
+	}
+	// The tag size is a 28-bit "syncsafe" integer, 7 bits per byte.
+	size := int(head[6]&0x7f)<<21 | int(head[7]&0x7f)<<14 | int(head[8]&0x7f)<<7 | int(head[9]&0x7f)
+	off := 10 + size
+	if head[5]&0x10 != 0 {
+		off += 10 // footer
+	}
+	if off < len(head) {
+		if frame, ok := sniffFrame(head[off:]); ok && frame.Container == ContainerMPEG {
+			return frame
+		}
+	}
+	return f
+}
+
+var (
+	mpegRates = [3]int{44100, 48000, 32000}
+	adtsRates = [13]int{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+)
+
+// sniffFrame recognises a bare MPEG audio or ADTS frame header.
+func sniffFrame(head []byte) (Format, bool) {
+	if len(head) < 4 || head[0] != 0xff || head[1]&0xe0 != 0xe0 {
+		return Format{}, false
+	}
+	version, layer := head[1]>>3&3, head[1]>>1&3
+	if layer == 0 {
+		// Layer bits 00 with the MPEG-4 sync are ADTS.
+		if head[1]&0xf0 != 0xf0 {
+			return Format{}, false
+		}
+		f := Format{Container: ContainerADTS, Codec: "aac"}
+		if i := int(head[2] >> 2 & 0xf); i < len(adtsRates) {
+			f.SampleRate = adtsRates[i]
+		}
+		return f, true
+	}
+	if version == 1 {
+		return Format{}, false // reserved
+	}
+	f := Format{Container: ContainerMPEG, Codec: "mp3"}
+	if layer != 1 {
+		f.Codec = "mp2"
+	}
+	if i := head[2] >> 2 & 3; i < 3 {
+		rate := mpegRates[i]
+		switch version {
+		case 2: // MPEG-2
+			rate /= 2
+		case 0: // MPEG-2.5
+			rate /= 4
+		}
+		f.SampleRate = rate
+	}
+	return f, true
+}
+
+// flacRate reads the 20-bit sample rate from the STREAMINFO block, which
+// always comes first after the "fLaC" marker and a 4-byte block header.
+func flacRate(head []byte) int {
+	if len(head) < 21 {
+		return 0
+	}
+	si := head[8:]
+	return int(si[10])<<12 | int(si[11])<<4 | int(si[12])>>4
+}
+
+// sniffOgg tells Opus from Vorbis by the first packet of the first page.
+func sniffOgg(head []byte) Format {
+	f := Format{Container: ContainerOgg}
+	if len(head) < 27 {
+		return f
+	}
+	// The packet follows the 27-byte page header and its segment table.
+	off := 27 + int(head[26])
+	if off >= len(head) {
+		return f
+	}
+	packet := head[off:]
+	switch {
+	case bytes.HasPrefix(packet, []byte("OpusHead")):
+		// Opus always decodes at 48 kHz; the header keeps the input rate.
+		f.Codec, f.SampleRate = "opus", 48000
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")) && len(packet) >= 16:
+		f.Codec = "vorbis"
+		f.SampleRate = int(binary.LittleEndian.Uint32(packet[12:16]))
+	}
+	return f
+}
+
+// mp4Entries are the sample entry types of audio codecs in MP4.
+var mp4Entries = []struct{ box, codec string }{
+	{"mp4a", "aac"},
+	{"alac", "alac"},
+	{"fLaC", "flac"},
+}
+
+// sniffMP4 looks for an audio sample entry in the head. Files written with
+// the index after the media data carry it at the end; those keep the codec
+// unknown.
+func sniffMP4(head []byte) Format {
+	f := Format{Container: ContainerMP4}
+	for _, e := range mp4Entries {
+		i := bytes.Index(head, []byte(e.box))
+		// The type follows the 4-byte box size; the entry is at least 36 bytes.
+		if i < 4 || i+32 > len(head) {
+			continue
+		}
+		f.Codec = e.codec
+		// An AudioSampleEntry keeps the rate as 16.16 fixed point at offset 32.
+		f.SampleRate = int(binary.BigEndian.Uint32(head[i+28:i+32]) >> 16)
+		return f
+	}
+	return f
+}
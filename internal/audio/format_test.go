@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// oggPage wraps packet in the first page of an Ogg stream with one segment.
+func oggPage(packet []byte) []byte {
+	head := append([]byte("OggS"), make([]byte, 22)...)
+	head = append(head, 1, byte(len(packet)))
+	return append(head, packet...)
+}
+
+// mp4Head is the start of an MP4 file with an audio sample entry of box type
+// and sample rate.
+func mp4Head(box string, rate int) []byte {
+	head := []byte("\x00\x00\x00\x18ftypM4A \x00\x00\x02\x00isomM4A ")
+	head = append(head, 0, 0, 0, 36)
+	head = append(head, box...)
+	head = append(head, make([]byte, 24)...)
+	return binary.BigEndian.AppendUint32(head, uint32(rate)<<16)
+}
+
+func TestSniff(t *testing.T) {
+	flac := append([]byte("fLaC\x00\x00\x00\x22"), make([]byte, 18)...)
+	flac[8+10], flac[8+11], flac[8+12] = 0x0a, 0xc4, 0x40 // 44100 Hz
+	vorbis := binary.LittleEndian.AppendUint32([]byte("\x01vorbis\x00\x00\x00\x00\x02"), 44100)
+
+	cases := []struct {
+		name string
+		head []byte
+		want Format
+	}{
+		{"id3 and mpeg-1 layer iii", []byte("ID3\x03\x00\x00\x00\x00\x00\x00\xff\xfb\x90\x00"), Format{ContainerMPEG, "mp3", 44100}},
+		{"id3 without frame", []byte("ID3\x03\x00\x00\x00\x00\x00\x20"), Format{ContainerMPEG, "mp3", 0}},
+		{"bare mpeg-2 layer iii", []byte{0xff, 0xf3, 0x84, 0x00}, Format{ContainerMPEG, "mp3", 24000}},
+		{"mpeg-1 layer ii", []byte{0xff, 0xfd, 0x08, 0x00}, Format{ContainerMPEG, "mp2", 32000}},
+		{"reserved mpeg version", []byte{0xff, 0xeb, 0x90, 0x00}, Format{}},
+		{"adts", []byte{0xff, 0xf1, 0x50, 0x80}, Format{ContainerADTS, "aac", 44100}},
+		{"flac", flac, Format{ContainerFLAC, "flac", 44100}},
+		{"flac truncated", []byte("fLaC"), Format{ContainerFLAC, "flac", 0}},
+		{"ogg opus", oggPage([]byte("OpusHead\x01\x02")), Format{ContainerOgg, "opus", 48000}},
+		{"ogg vorbis", oggPage(vorbis), Format{ContainerOgg, "vorbis", 44100}},
+		{"ogg unknown stream", oggPage([]byte("Speex   ")), Format{ContainerOgg, "", 0}},
+		{"mp4 aac", mp4Head("mp4a", 48000), Format{ContainerMP4, "aac", 48000}},
+		{"mp4 alac", mp4Head("alac", 44100), Format{ContainerMP4, "alac", 44100}},
+		{"mp4 index at the end", []byte("\x00\x00\x00\x18ftypM4A \x00\x00\x02\x00isomM4A "), Format{ContainerMP4, "", 0}},
+		{"unknown", []byte("<html>"), Format{}},
+		{"empty", nil, Format{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Sniff(tc.head); got != tc.want {
+				t.Errorf("Sniff = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatProperties(t *testing.T) {
+	cases := []struct {
+		f        Format
+		ext      string
+		mime     string
+		inline   bool
+		lossless bool
+	}{
+		{Format{Container: ContainerMPEG, Codec: "mp3"}, ".mp3", "audio/mpeg", true, false},
+		{Format{Container: ContainerMPEG, Codec: "mp2"}, ".mp2", "audio/mpeg", false, false},
+		{Format{Container: ContainerADTS, Codec: "aac"}, ".aac", "audio/aac", false, false},
+		{Format{Container: ContainerMP4, Codec: "aac"}, ".m4a", "audio/mp4", true, false},
+		{Format{Container: ContainerMP4, Codec: "alac"}, ".m4a", "audio/mp4", false, true},
+		{Format{Container: ContainerFLAC, Codec: "flac"}, ".flac", "audio/flac", false, true},
+		{Format{Container: ContainerOgg, Codec: "opus"}, ".opus", "audio/ogg", false, false},
+		{Format{Container: ContainerOgg, Codec: "vorbis"}, ".ogg", "audio/ogg", false, false},
+		{Format{}, "", "", false, false},
+	}
+	for _, tc := range cases {
+		if got := tc.f.Ext(); got != tc.ext {
+			t.Errorf("%+v: Ext = %q, want %q", tc.f, got, tc.ext)
+		}
+		if got := tc.f.MIME(); got != tc.mime {
+			t.Errorf("%+v: MIME = %q, want %q", tc.f, got, tc.mime)
+		}
+		if got := tc.f.PlaysInline(); got != tc.inline {
+			t.Errorf("%+v: PlaysInline = %v, want %v", tc.f, got, tc.inline)
+		}
+		if got := tc.f.Lossless(); got != tc.lossless {
+			t.Errorf("%+v: Lossless = %v, want %v", tc.f, got, tc.lossless)
+		}
+	}
+}
+
+func TestSampleRateString(t *testing.T) {
+	cases := map[int]string{0: "", 44100: "44.1 kHz", 48000: "48 kHz", 22050: "22.05 kHz"}
+	for rate, want := range cases {
+		if got := (Format{SampleRate: rate}).SampleRateString(); got != want {
+			t.Errorf("SampleRateString(%d) = %q, want %q", rate, got, want)
+		}
+	}
+}
+
+func TestSniffFileShort(t *testing.T) {
+	// Shorter than SniffLen, which SniffFile must not treat as an error.
+	path := filepath.Join(t.TempDir(), "short.mp3")
+	if err := os.WriteFile(path, []byte("ID3\x03\x00\x00\x00\x00\x00\x00\xff\xfb\x90\x00"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := SniffFile(path)
+	if err != nil {
+		t.Fatalf("SniffFile: %v", err)
+	}
+	if want := (Format{ContainerMPEG, "mp3", 44100}); got != want {
+		t.Errorf("SniffFile = %+v, want %+v", got, want)
+	}
+	if _, err := SniffFile(path + ".missing"); err == nil {
+		t.Error("SniffFile of a missing file succeeded")
+	}
+}
@@ -0,0 +1,203 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Loudness targets of the loudnorm filter, in LUFS and dBTP: the levels
+// streaming services play tracks at.
+const (
+	loudnessTarget = -14
+	truePeakLimit  = -1
+)
+
+// silenceThreshold is the level below which leading and trailing audio
+// counts as silence; silenceKeep is how much of it is left in place.
+const (
+	silenceThreshold = "-60dB"
+	silenceKeep      = 0.1
+)
+
+// Filters selects the processing passes of Process.
+type Filters struct {
+	// Loudnorm brings the loudness to one level (ffmpeg loudnorm), so that
+	// tracks from different albums play equally loud.
+	Loudnorm bool
+	// TrimSilence cuts leading and trailing silence.
+	TrimSilence bool
+}
+
+// Any reports whether any pass is selected.
+func (f Filters) Any() bool {
+	return f.Loudnorm || f.TrimSilence
+}
+
+// chain is the ffmpeg -af filter graph of the selected passes. end, when
+// not 0, is where trailing silence begins, found by silenceEnd: the audio
+// is cut there, before leading silence is removed and shifts the times.
+func (f Filters) chain(end time.Duration) string {
+	var filters []string
+	if f.TrimSilence {
+		if end > 0 {
+			filters = append(filters, fmt.Sprintf("atrim=end=%.3f", end.Seconds()))
+		}
+		filters = append(filters, fmt.Sprintf("silenceremove=start_periods=1:start_threshold=%s:start_silence=%g", silenceThreshold, silenceKeep))
+	}
+	if f.Loudnorm {
+		filters = append(filters, fmt.Sprintf("loudnorm=I=%d:TP=%d:LRA=11", loudnessTarget, truePeakLimit))
+	}
+	return strings.Join(filters, ",")
+}
+
+// ProcessedFormat is the format Process produces for f: lossless files
+// stay FLAC, AAC stays AAC in MP4, everything else becomes MP3.
+func ProcessedFormat(f Format) Format {
+	if f.Lossless() {
+		return Format{Container: ContainerFLAC, Codec: "flac", SampleRate: f.SampleRate}
+	}
+	return InlineFormat(f)
+}
+
+// Process re-encodes src, of format f, into dst through the selected
+// filters; dst should carry the extension of ProcessedFormat(f). Tags and
+// embedded cover art are kept. With no filter selected nothing is
+// re-encoded when the codec stays: the audio is only copied into dst.
+// Trimming silence takes a first pass over src to find where it ends.
+func Process(ctx context.Context, ffmpeg, src, dst string, f Format, filters Filters) error {
+	var end time.Duration
+	if filters.TrimSilence {
+		var err error
+		if end, err = silenceEnd(ctx, ffmpeg, src); err != nil {
+			return err
+		}
+	}
+	out, err := exec.CommandContext(ctx, ffmpeg, processArgs(src, dst, f, filters, end)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// processArgs are the ffmpeg arguments of Process; end is as for chain.
+func processArgs(src, dst string, f Format, filters Filters, end time.Duration) []string {
+	target := ProcessedFormat(f)
+	// The cover, if any, is a video stream of a single picture.
+	args := []string{"-nostdin", "-hide_banner", "-loglevel", "error", "-y", "-i", src, "-map_metadata", "0",
+		"-map", "0:a", "-map", "0:v?", "-c:v", "copy"}
+	if !filters.Any() && target.Codec == f.Codec {
+		args = append(args, "-c:a", "copy")
+		if target.Container == ContainerMP4 {
+			args = append(args, "-movflags", "+faststart")
+		}
+		return append(args, dst)
+	}
+	if filters.Any() {
+		args = append(args, "-af", filters.chain(end))
+	}
+	// loudnorm resamples to 192 kHz; go back to the original rate.
+	rate := f.SampleRate
+	if rate == 0 {
+		rate = 44100
+	}
+	args = append(args, "-ar", strconv.Itoa(rate))
+	args = append(args, encoderArgs(target.Codec)...)
+	return append(args, dst)
+}
+
+// silenceEnd finds where the trailing silence of src begins, plus the
+// silenceKeep left in place; 0 when src does not end in silence. ffmpeg's
+// silencedetect streams through the file, so even hours of audio take
+// little memory.
+func silenceEnd(ctx context.Context, ffmpeg, src string) (time.Duration, error) {
+	cmd := exec.CommandContext(ctx, ffmpeg, "-nostdin", "-hide_banner", "-nostats", "-i", src, "-map", "0:a",
+		"-af", fmt.Sprintf("silencedetect=noise=%s:d=%g", silenceThreshold, silenceKeep),
+		"-progress", "pipe:1", "-f", "null", "-")
+	var progress, log bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &progress, &log
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("ffmpeg silencedetect: %w: %s", err, lastLine(log.String()))
+	}
+	start, ok := trailingSilence(log.String(), progress.String())
+	if !ok {
+		return 0, nil
+	}
+	return start + time.Duration(silenceKeep*float64(time.Second)), nil
+}
+
+// silenceTail is how close to the end a detected silence must stop to
+// count as trailing, for ffmpeg versions that close it at the end.
+const silenceTail = 50 * time.Millisecond
+
+// trailingSilence reads silencedetect's log and the -progress output of its
+// pass and returns where the silence the audio ends in starts. A silence
+// from the very start is the whole track, which is left alone.
+func trailingSilence(log, progress string) (time.Duration, bool) {
+	var start, end time.Duration
+	open, found := false, false
+	for _, line := range strings.Split(log, "\n") {
+		if v, ok := logValue(line, "silence_start: "); ok {
+			start, open, found = v, true, true
+		} else if v, ok := logValue(line, "silence_end: "); ok {
+			end, open = v, false
+		}
+	}
+	if !found || start <= 0 {
+		return 0, false
+	}
+	if open {
+		return start, true
+	}
+	var total time.Duration
+	for _, line := range strings.Split(progress, "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "out_time_us="); ok {
+			if us, err := strconv.ParseInt(v, 10, 64); err == nil {
+				total = time.Duration(us) * time.Microsecond
+			}
+		}
+	}
+	if total == 0 || total-end > silenceTail {
+		return 0, false
+	}
+	return start, true
+}
+
+// logValue parses the seconds following key in a silencedetect log line.
+func logValue(line, key string) (time.Duration, bool) {
+	i := strings.Index(line, key)
+	if i < 0 {
+		return 0, false
+	}
+	field, _, _ := strings.Cut(line[i+len(key):], " ")
+	v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(v * float64(time.Second)), true
+}
+
+// lastLine is the last non-empty line of ffmpeg's log, where it says why it
+// failed.
+func lastLine(log string) string {
+	lines := strings.Split(strings.TrimSpace(log), "\n")
+	return lines[len(lines)-1]
+}
+
+// encoderArgs are the ffmpeg output options of a re-encode to codec. The
+// gapless playback of consecutive album tracks needs nothing on top: ffmpeg
+// writes MP3 a LAME header with the encoder delay and padding, and MP4 an
+// edit list that hides the AAC priming samples, by default.
+func encoderArgs(codec string) []string {
+	switch codec {
+	case "flac":
+		return []string{"-c:a", "flac"}
+	case "aac":
+		return []string{"-c:a", "aac", "-b:a", "256k", "-movflags", "+faststart"}
+	}
+	return []string{"-c:a", "libmp3lame", "-b:a", "320k", "-id3v2_version", "3"}
+}
@@ -0,0 +1,173 @@
+package audio
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFiltersChain(t *testing.T) {
+	const (
+		trimStart = "silenceremove=start_periods=1:start_threshold=-60dB:start_silence=0.1"
+		loudnorm  = "loudnorm=I=-14:TP=-1:LRA=11"
+	)
+	cases := []struct {
+		name    string
+		filters Filters
+		end     time.Duration
+		want    string
+	}{
+		{"none", Filters{}, 0, ""},
+		{"loudnorm", Filters{Loudnorm: true}, 0, loudnorm},
+		{"loudnorm ignores end", Filters{Loudnorm: true}, time.Minute, loudnorm},
+		{"trim without trailing silence", Filters{TrimSilence: true}, 0, trimStart},
+		{"trim", Filters{TrimSilence: true}, 178500 * time.Millisecond, "atrim=end=178.500," + trimStart},
+		{"both", Filters{Loudnorm: true, TrimSilence: true}, 3 * time.Minute, "atrim=end=180.000," + trimStart + "," + loudnorm},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.filters.chain(tc.end)
+			if got != tc.want {
+				t.Errorf("chain = %q, want %q", got, tc.want)
+			}
+			// The whole stream must never be buffered to reach its end.
+			if strings.Contains(got, "areverse") {
+				t.Errorf("chain %q reverses the stream", got)
+			}
+		})
+	}
+}
+
+func TestProcessedFormat(t *testing.T) {
+	cases := []struct {
+		in, want Format
+	}{
+		{Format{ContainerMPEG, "mp3", 44100}, Format{ContainerMPEG, "mp3", 44100}},
+		{Format{ContainerMPEG, "mp2", 32000}, Format{ContainerMPEG, "mp3", 32000}},
+		{Format{ContainerADTS, "aac", 48000}, Format{ContainerMP4, "aac", 48000}},
+		{Format{ContainerMP4, "aac", 44100}, Format{ContainerMP4, "aac", 44100}},
+		{Format{ContainerMP4, "alac", 96000}, Format{ContainerFLAC, "flac", 96000}},
+		{Format{ContainerFLAC, "flac", 44100}, Format{ContainerFLAC, "flac", 44100}},
+		{Format{ContainerOgg, "opus", 48000}, Format{ContainerMPEG, "mp3", 48000}},
+		{Format{ContainerOgg, "vorbis", 44100}, Format{ContainerMPEG, "mp3", 44100}},
+	}
+	for _, tc := range cases {
+		if got := ProcessedFormat(tc.in); got != tc.want {
+			t.Errorf("ProcessedFormat(%+v) = %+v, want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestTrailingSilence(t *testing.T) {
+	const (
+		start   = "[silencedetect @ 0x5581] silence_start: %s\n"
+		end     = "[silencedetect @ 0x5581] silence_end: %s | silence_duration: 1.5\n"
+		done180 = "out_time_us=179000000\nprogress=continue\nout_time_us=180000000\nprogress=end\n"
+	)
+	line := func(format, v string) string { return strings.Replace(format, "%s", v, 1) }
+	cases := []struct {
+		name          string
+		log, progress string
+		want          time.Duration
+		ok            bool
+	}{
+		{"no silence", "", done180, 0, false},
+		{"left open at the end", line(start, "178.5"), done180, 178500 * time.Millisecond, true},
+		{"closed at the end", line(start, "178.5") + line(end, "180"), done180, 178500 * time.Millisecond, true},
+		{"pause in the middle", line(start, "60") + line(end, "61.5"), done180, 0, false},
+		{"pause, then the end", line(start, "60") + line(end, "61.5") + line(start, "179.2"), done180, 179200 * time.Millisecond, true},
+		{"closed without progress", line(start, "178.5") + line(end, "180"), "", 0, false},
+		{"silent throughout", line(start, "0"), done180, 0, false},
+		{"garbled", line(start, "abc"), done180, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := trailingSilence(tc.log, tc.progress)
+			if got != tc.want || ok != tc.ok {
+				t.Errorf("trailingSilence = %s, %v, want %s, %v", got, ok, tc.want, tc.ok)
+			}
+		})
+	}
+}
+
+func TestProcessArgs(t *testing.T) {
+	mp3 := Format{Container: ContainerMPEG, Codec: "mp3", SampleRate: 44100}
+	adts := Format{Container: ContainerADTS, Codec: "aac", SampleRate: 48000}
+	vorbis := Format{Container: ContainerOgg, Codec: "vorbis", SampleRate: 44100}
+	loud := Filters{Loudnorm: true}
+
+	cases := []struct {
+		name     string
+		f        Format
+		filters  Filters
+		copied   bool // the audio is copied, not re-encoded
+		contains []string
+	}{
+		{"mp3 filtered", mp3, loud, false, []string{"-af", "-ar 44100", "-c:a libmp3lame"}},
+		{"mp3 unfiltered", mp3, Filters{}, true, nil},
+		{"adts unfiltered", adts, Filters{}, true, []string{"-movflags +faststart"}},
+		{"adts filtered", adts, loud, false, []string{"-ar 48000", "-c:a aac"}},
+		{"vorbis unfiltered", vorbis, Filters{}, false, []string{"-c:a libmp3lame"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := processArgs("in", "out", tc.f, tc.filters, 0)
+			line := strings.Join(args, " ")
+			if args[len(args)-1] != "out" {
+				t.Errorf("output is not last: %s", line)
+			}
+			// The cover is carried over, not dropped with -vn.
+			if slices.Contains(args, "-vn") || !strings.Contains(line, "-map 0:a -map 0:v? -c:v copy") {
+				t.Errorf("cover not kept: %s", line)
+			}
+			if got := strings.Contains(line, "-c:a copy"); got != tc.copied {
+				t.Errorf("audio copied %v, want %v: %s", got, tc.copied, line)
+			}
+			if tc.copied && strings.Contains(line, "-af") {
+				t.Errorf("copied audio filtered: %s", line)
+			}
+			if !tc.filters.Any() && slices.Contains(args, "-af") {
+				t.Errorf("empty filter graph passed: %s", line)
+			}
+			for _, want := range tc.contains {
+				if !strings.Contains(line, want) {
+					t.Errorf("%q missing from %s", want, line)
+				}
+			}
+		})
+	}
+}
+
+func TestRetagArgs(t *testing.T) {
+	tags := Tags{Title: "Song", AlbumArtist: "Various Artists", ISRC: "USRC17607839", Lyrics: "La la\nLa"}
+	cases := []struct {
+		dst      string
+		contains []string
+		absent   []string
+	}{
+		{"out.mp3", []string{"-metadata TSRC=USRC17607839", "-id3v2_version 3"}, []string{"ISRC="}},
+		{"out.flac", []string{"-metadata ISRC=USRC17607839"}, []string{"TSRC=", "-id3v2_version"}},
+	}
+	for _, tc := range cases {
+		line := strings.Join(retagArgs("in", tc.dst, tags), " ")
+		want := append([]string{"-c copy", "-metadata title=Song", "-metadata album_artist=Various Artists", "-metadata lyrics=La la\nLa"}, tc.contains...)
+		for _, s := range want {
+			if !strings.Contains(line, s) {
+				t.Errorf("%s: %q missing from %s", tc.dst, s, line)
+			}
+		}
+		for _, s := range tc.absent {
+			if strings.Contains(line, s) {
+				t.Errorf("%s: %q in %s", tc.dst, s, line)
+			}
+		}
+		// Empty tags keep their value.
+		if strings.Contains(line, "-metadata artist=") || strings.Contains(line, "-metadata album=") {
+			t.Errorf("%s: empty tag written: %s", tc.dst, line)
+		}
+		if !strings.HasSuffix(line, " "+tc.dst) {
+			t.Errorf("output is not last: %s", line)
+		}
+	}
+}
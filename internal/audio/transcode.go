@@ -0,0 +1,114 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// InlineFormat is the format Transcode produces for f: AAC is only
+// remuxed into MP4, everything else is re-encoded to MP3.
+func InlineFormat(f Format) Format {
+	if f.Codec == "aac" {
+		return Format{Container: ContainerMP4, Codec: "aac", SampleRate: f.SampleRate}
+	}
+	return Format{Container: ContainerMPEG, Codec: "mp3", SampleRate: f.SampleRate}
+}
+
+// Transcode converts src, of format f, into dst with ffmpeg so that Telegram
+// plays it inline; dst should carry the extension of InlineFormat(f).
+// Tags are kept; embedded cover art is dropped, deliveries attach their own.
+func Transcode(ctx context.Context, ffmpeg, src, dst string, f Format) error {
+	args := []string{"-nostdin", "-hide_banner", "-loglevel", "error", "-y", "-i", src, "-map_metadata", "0", "-vn"}
+	if f.Codec == "aac" {
+		args = append(args, "-c:a", "copy", "-movflags", "+faststart")
+	} else {
+		args = append(args, encoderArgs("mp3")...)
+	}
+	args = append(args, dst)
+
+	out, err := exec.CommandContext(ctx, ffmpeg, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Clip cuts length of src, starting at start, into the MP3 dst. Tags are
+// dropped, so the clip tells nothing about the track it comes from.
+func Clip(ctx context.Context, ffmpeg, src, dst string, start, length time.Duration) error {
+	args := []string{"-nostdin", "-hide_banner", "-loglevel", "error", "-y",
+		"-ss", fmt.Sprintf("%.3f", start.Seconds()), "-t", fmt.Sprintf("%.3f", length.Seconds()), "-i", src,
+		"-map_metadata", "-1", "-vn", "-c:a", "libmp3lame", "-b:a", "128k", dst}
+
+	out, err := exec.CommandContext(ctx, ffmpeg, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Split cuts src into consecutive parts of about length each without
+// re-encoding, writing them to dstPattern, a path with a printf verb for the
+// part number counted from 0 (e.g. "part%03d.mp3"). Tags are kept in every part.
+func Split(ctx context.Context, ffmpeg, src, dstPattern string, length time.Duration) error {
+	args := []string{"-nostdin", "-hide_banner", "-loglevel", "error", "-y", "-i", src,
+		"-map", "0:a", "-map_metadata", "0", "-c", "copy",
+		"-f", "segment", "-segment_time", fmt.Sprintf("%.3f", length.Seconds()), "-reset_timestamps", "1", dstPattern}
+
+	out, err := exec.CommandContext(ctx, ffmpeg, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Tags are the text tags Retag writes; empty ones keep their value.
+type Tags struct {
+	Title, Artist, Album, AlbumArtist string
+	// ISRC goes into the TSRC frame of MP3 files and an ISRC tag elsewhere.
+	ISRC string
+	// Lyrics are unsynced words, the USLT frame of MP3 files.
+	Lyrics string
+}
+
+// Retag copies src to dst with tags replaced, without re-encoding; dst
+// should carry the extension of src. MP3 files get ID3v2.3 tags, which
+// every player reads. Embedded cover art is kept.
+func Retag(ctx context.Context, ffmpeg, src, dst string, tags Tags) error {
+	out, err := exec.CommandContext(ctx, ffmpeg, retagArgs(src, dst, tags)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// retagArgs are the ffmpeg arguments of Retag.
+func retagArgs(src, dst string, tags Tags) []string {
+	args := []string{"-nostdin", "-hide_banner", "-loglevel", "error", "-y", "-i", src,
+		"-map", "0", "-map_metadata", "0", "-c", "copy"}
+	mp3 := strings.EqualFold(filepath.Ext(dst), ".mp3")
+	isrcKey := "ISRC"
+	if mp3 {
+		isrcKey = "TSRC"
+	}
+	for _, tag := range []struct{ key, value string }{
+		{"title", tags.Title},
+		{"artist", tags.Artist},
+		{"album", tags.Album},
+		{"album_artist", tags.AlbumArtist},
+		{isrcKey, tags.ISRC},
+		{"lyrics", tags.Lyrics},
+	} {
+		if tag.value != "" {
+			args = append(args, "-metadata", tag.key+"="+tag.value)
+		}
+	}
+	if mp3 {
+		args = append(args, "-id3v2_version", "3")
+	}
+	return append(args, dst)
+}
@@ -0,0 +1,109 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// peakRate is the sample rate Peaks decodes at: plenty for an envelope, and
+// cheap even for hour-long mixes. A window is one envelope value.
+const (
+	peakRate   = 8000
+	peakWindow = peakRate / 10
+)
+
+// Peaks decodes length of src from start with ffmpeg and returns its
+// loudness envelope as n values in [0, 1], each the loudest 100 ms in its
+// stretch, scaled so that the loudest is 1; a zero length decodes to the
+// end. src may be a URL: ffmpeg then seeks with range requests and fetches
+// little more than the stretch. Short stretches may yield fewer values.
+func Peaks(ctx context.Context, ffmpeg, src string, start, length time.Duration, n int) ([]float64, error) {
+	args := []string{"-nostdin", "-hide_banner", "-loglevel", "error"}
+	if start > 0 {
+		args = append(args, "-ss", fmt.Sprintf("%.3f", start.Seconds()))
+	}
+	if length > 0 {
+		args = append(args, "-t", fmt.Sprintf("%.3f", length.Seconds()))
+	}
+	args = append(args, "-i", src, "-vn", "-ac", "1", "-ar", strconv.Itoa(peakRate), "-f", "s16le", "-")
+	cmd := exec.CommandContext(ctx, ffmpeg, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w", err)
+	}
+
+	windows, readErr := rmsWindows(out)
+	if readErr != nil {
+		// Drain the pipe, or ffmpeg blocks writing and never exits.
+		_, _ = io.Copy(io.Discard, out)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	if len(windows) == 0 {
+		return nil, errors.New("no audio decoded")
+	}
+	return envelope(windows, n), nil
+}
+
+// rmsWindows reads 16-bit little-endian PCM and returns the RMS level of
+// every peakWindow samples.
+func rmsWindows(r io.Reader) ([]float64, error) {
+	var windows []float64
+	buf := make([]byte, 2*peakWindow)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if samples := n / 2; samples > 0 {
+			var sum float64
+			for i := 0; i < samples; i++ {
+				v := float64(int16(binary.LittleEndian.Uint16(buf[2*i:]))) / math.MaxInt16
+				sum += v * v
+			}
+			windows = append(windows, math.Sqrt(sum/float64(samples)))
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return windows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// envelope folds windows into at most n values, keeping each stretch's
+// loudest, and scales them to [0, 1].
+func envelope(windows []float64, n int) []float64 {
+	n = min(n, len(windows))
+	out := make([]float64, n)
+	top := 0.0
+	for i := range out {
+		from, to := i*len(windows)/n, (i+1)*len(windows)/n
+		for _, v := range windows[from:to] {
+			out[i] = max(out[i], v)
+		}
+		top = max(top, out[i])
+	}
+	if top > 0 {
+		for i := range out {
+			out[i] /= top
+		}
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// TTL is a concurrency-safe in-memory cache with per-entry expiry and an LRU size bound.
+type TTL[V any] struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	max     int
+	order   *list.List // front = most recently used
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type entry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// New creates a cache keeping up to max entries for ttl each. A non-positive
+// ttl disables caching entirely.
+func New[V any](ttl time.Duration, max int) *TTL[V] {
+	if max <= 0 {
+		max = 1000
+	}
+	return &TTL[V]{
+		ttl:     ttl,
+		max:     max,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached value if present and not expired.
+func (c *TTL[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	e := el.Value.(*entry[V])
+	if c.now().After(e.expires) {
+		c.removeLocked(el)
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return e.value, true
+}
+
+// Set stores value under key, evicting the least recently used entry when full.
+func (c *TTL[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ttl <= 0 {
+		return
+	}
+	expires := c.now().Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry[V])
+		e.value = value
+		e.expires = expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[V]{key: key, value: value, expires: expires})
+	for c.order.Len() > c.max {
+		c.removeLocked(c.order.Back())
+	}
+}
+
+// Delete drops key from the cache.
+func (c *TTL[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.removeLocked(el)
+	}
+}
+
+// SetTTL changes the lifetime for entries stored from now on.
+func (c *TTL[V]) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+	if ttl <= 0 {
+		c.order.Init()
+		c.entries = make(map[string]*list.Element)
+	}
+}
+
+// Len reports the number of stored entries, including not yet evicted expired ones.
+func (c *TTL[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *TTL[V]) removeLocked(el *list.Element) {
+	e := el.Value.(*entry[V])
+	delete(c.entries, e.key)
+	c.order.Remove(el)
+}
@@ -0,0 +1,157 @@
+// Package callback encodes inline-button payloads into Telegram's 64-byte
+// callback_data limit. Payloads are versioned, carry an expiry and are signed
+// with a truncated HMAC-SHA256 so users cannot forge or replay them forever.
+//
+// Wire format (version 1):
+//
+//	1<action>|<arg>|<arg>...|<expiry base36 unix>|<sig base64url>
+package callback
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxLength is Telegram's limit for callback_data.
+const MaxLength = 64
+
+const (
+	version  = '1'
+	sep      = "|"
+	sigBytes = 6 // 8 base64url chars
+)
+
+// Action identifies what a button does.
+type Action byte
+
+// Known actions. New ones must use a fresh byte so old buttons keep their meaning.
+const (
+	ActionDownload     Action = 'd'
+	ActionPage         Action = 'p'
+	ActionSettings     Action = 's'
+	ActionCancel       Action = 'c'
+	ActionImport       Action = 'i'
+	ActionFeedback     Action = 'f'
+	ActionDismiss      Action = 'x'
+	ActionPlaylist     Action = 'l'
+	ActionBrowse       Action = 'b'
+	ActionGenre        Action = 'g'
+	ActionRecent       Action = 'r'
+	ActionVibe         Action = 'v'
+	ActionParty        Action = 'y'
+	ActionChatSettings Action = 'h'
+	ActionPodcast      Action = 'e'
+	ActionDiscover     Action = 'k'
+	ActionForget       Action = 'z'
+	ActionShare        Action = 'a'
+	ActionWaveform     Action = 'w'
+	ActionLyrics       Action = 't'
+	ActionKaraoke      Action = 'o'
+)
+
+var (
+	ErrMalformed   = errors.New("callback: malformed payload")
+	ErrVersion     = errors.New("callback: unsupported version")
+	ErrSignature   = errors.New("callback: bad signature")
+	ErrExpired     = errors.New("callback: payload expired")
+	ErrTooLong     = errors.New("callback: payload exceeds 64 bytes")
+	ErrInvalidArgs = errors.New("callback: argument contains separator")
+)
+
+// Payload is a decoded, verified button action.
+type Payload struct {
+	Action  Action
+	Args    []string
+	Expires time.Time
+}
+
+// Arg returns the i-th argument or "" when absent.
+func (p Payload) Arg(i int) string {
+	if i < 0 || i >= len(p.Args) {
+		return ""
+	}
+	return p.Args[i]
+}
+
+// Codec signs and verifies payloads with a shared secret.
+type Codec struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewCodec creates a codec; payloads expire ttl after encoding.
+func NewCodec(secret []byte, ttl time.Duration) *Codec {
+	return &Codec{secret: secret, ttl: ttl, now: time.Now}
+}
+
+// Encode builds signed callback data for action with args.
+func (c *Codec) Encode(action Action, args ...string) (string, error) {
+	for _, a := range args {
+		if strings.Contains(a, sep) {
+			return "", ErrInvalidArgs
+		}
+	}
+
+	parts := make([]string, 0, len(args)+2)
+	parts = append(parts, string([]byte{version, byte(action)}))
+	parts = append(parts, args...)
+	parts = append(parts, strconv.FormatInt(c.now().Add(c.ttl).Unix(), 36))
+	body := strings.Join(parts, sep)
+
+	data := body + sep + c.sign(body)
+	if len(data) > MaxLength {
+		return "", ErrTooLong
+	}
+	return data, nil
+}
+
+// Decode verifies data and returns its payload.
+func (c *Codec) Decode(data string) (Payload, error) {
+	if len(data) < 2 {
+		return Payload{}, ErrMalformed
+	}
+	if data[0] != version {
+		return Payload{}, ErrVersion
+	}
+
+	cut := strings.LastIndex(data, sep)
+	if cut < 0 {
+		return Payload{}, ErrMalformed
+	}
+	body, sig := data[:cut], data[cut+1:]
+	if !hmac.Equal([]byte(sig), []byte(c.sign(body))) {
+		return Payload{}, ErrSignature
+	}
+
+	parts := strings.Split(body, sep)
+	if len(parts) < 2 || len(parts[0]) != 2 {
+		return Payload{}, ErrMalformed
+	}
+	exp, err := strconv.ParseInt(parts[len(parts)-1], 36, 64)
+	if err != nil {
+		return Payload{}, fmt.Errorf("%w: expiry", ErrMalformed)
+	}
+
+	p := Payload{
+		Action:  Action(parts[0][1]),
+		Args:    parts[1 : len(parts)-1],
+		Expires: time.Unix(exp, 0),
+	}
+	if c.now().After(p.Expires) {
+		return p, ErrExpired
+	}
+	return p, nil
+}
+
+func (c *Codec) sign(body string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:sigBytes])
+}
@@ -0,0 +1,114 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Series is a named sequence of values drawn as bars of one color.
+type Series struct {
+	Name   string
+	Color  color.RGBA
+	Values []int
+}
+
+const (
+	width   = 800
+	height  = 400
+	padding = 40
+)
+
+var (
+	background = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	axisColor  = color.RGBA{R: 0x55, G: 0x55, B: 0x55, A: 0xff}
+)
+
+// Bars renders grouped bar chart PNG: one group per label, one bar per series.
+func Bars(title string, labels []string, series []Series) ([]byte, error) {
+	if len(labels) == 0 || len(series) == 0 {
+		return nil, fmt.Errorf("chart has no data")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	maxVal := 1
+	for _, s := range series {
+		for _, v := range s.Values {
+			if v > maxVal {
+				maxVal = v
+			}
+		}
+	}
+
+	plotW := width - 2*padding
+	plotH := height - 2*padding
+	baseY := height - padding
+
+	fill(img, image.Rect(padding, baseY, width-padding, baseY+1), axisColor)
+	fill(img, image.Rect(padding, padding, padding+1, baseY), axisColor)
+
+	groupW := plotW / len(labels)
+	barW := groupW / (len(series) + 1)
+	if barW < 1 {
+		barW = 1
+	}
+
+	for i, label := range labels {
+		x0 := padding + i*groupW + barW/2
+		for j, s := range series {
+			if i >= len(s.Values) {
+				continue
+			}
+			h := s.Values[i] * plotH / maxVal
+			x := x0 + j*barW
+			fill(img, image.Rect(x, baseY-h, x+barW-1, baseY), s.Color)
+		}
+		// Thin out labels when they would overlap.
+		step := 1
+		if labelW := 7*len(label) + 4; labelW > groupW {
+			step = labelW/groupW + 1
+		}
+		if i%step == 0 {
+			text(img, x0, baseY+15, label, axisColor)
+		}
+	}
+
+	text(img, padding, padding-20, title, axisColor)
+	text(img, width-padding-60, padding-20, "max "+strconv.Itoa(maxVal), axisColor)
+	legendX := padding + 7*len(title) + 20
+	for _, s := range series {
+		fill(img, image.Rect(legendX, padding-29, legendX+10, padding-19), s.Color)
+		text(img, legendX+14, padding-20, s.Name, axisColor)
+		legendX += 14 + 7*len(s.Name) + 16
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	draw.Draw(img, r, &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+func text(img *image.RGBA, x, y int, s string, c color.RGBA) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  &image.Uniform{C: c},
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
@@ -0,0 +1,102 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"golang.org/x/image/font"
+
+	"ym-bot/internal/render"
+)
+
+// Figure is a headline number on a card, e.g. {"1 234", "minutes"}.
+type Figure struct {
+	Value string
+	Label string
+}
+
+// CardSection is a titled list of lines on a card.
+type CardSection struct {
+	Heading string
+	Lines   []string
+}
+
+const (
+	cardWidth   = 800
+	cardHeight  = 1000
+	cardPadding = 56
+)
+
+var (
+	cardTop    = color.RGBA{R: 0x2b, G: 0x1a, B: 0x5e, A: 0xff}
+	cardBottom = color.RGBA{R: 0xd9, G: 0x4f, B: 0x2b, A: 0xff}
+	cardText   = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	cardMuted  = color.RGBA{R: 0xff, G: 0xe2, B: 0xc8, A: 0xff}
+)
+
+// Card renders a portrait PNG summary card: a title and subtitle, a row of
+// figures, then the sections. Lines too wide for the card are cut short;
+// whatever does not fit below is left out.
+func Card(title, subtitle string, figures []Figure, sections []CardSection) ([]byte, error) {
+	faces := make(map[string]font.Face)
+	for name, spec := range map[string]struct {
+		weight render.Weight
+		size   float64
+	}{
+		"title":   {render.Bold, 44},
+		"figure":  {render.Bold, 40},
+		"heading": {render.Bold, 26},
+		"text":    {render.Regular, 22},
+	} {
+		face, err := render.Face(spec.weight, spec.size)
+		if err != nil {
+			return nil, err
+		}
+		faces[name] = face
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	render.Gradient(img, cardTop, cardBottom)
+
+	maxW := cardWidth - 2*cardPadding
+	y := cardPadding + 40
+	render.DrawText(img, faces["title"], cardPadding, y, render.Fit(faces["title"], title, maxW), cardText)
+	y += 40
+	render.DrawText(img, faces["text"], cardPadding, y, render.Fit(faces["text"], subtitle, maxW), cardMuted)
+	y += 80
+
+	if len(figures) > 0 {
+		colW := maxW / len(figures)
+		for i, f := range figures {
+			x := cardPadding + i*colW
+			render.DrawText(img, faces["figure"], x, y, render.Fit(faces["figure"], f.Value, colW-8), cardText)
+			render.DrawText(img, faces["text"], x, y+32, render.Fit(faces["text"], f.Label, colW-8), cardMuted)
+		}
+		y += 96
+	}
+
+	for _, s := range sections {
+		if y+60 > cardHeight-cardPadding {
+			break
+		}
+		render.DrawText(img, faces["heading"], cardPadding, y, render.Fit(faces["heading"], s.Heading, maxW), cardText)
+		y += 38
+		for _, line := range s.Lines {
+			if y > cardHeight-cardPadding {
+				break
+			}
+			render.DrawText(img, faces["text"], cardPadding, y, render.Fit(faces["text"], line, maxW), cardText)
+			y += 32
+		}
+		y += 24
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
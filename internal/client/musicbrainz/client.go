@@ -0,0 +1,303 @@
+// Package musicbrainz looks recordings up in the MusicBrainz database to
+// cross-reference what Yandex does not say: ISRC codes, the first release
+// year and the album artist. It keeps to the API's rules for anonymous
+// clients: at most one request per second and a User-Agent naming the
+// application and a contact.
+package musicbrainz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBaseURL = "https://musicbrainz.org/ws/2"
+	// minInterval is the pause the API asks for between requests.
+	minInterval = time.Second
+	// minScore is the search score below which a recording is not trusted.
+	minScore = 90
+	// maxLengthDiff is how far a recording's length may be from the track's.
+	maxLengthDiff = 5 * time.Second
+)
+
+// ErrBusy means a request would have to wait for its turn past the
+// deadline of its context, so it was not made.
+var ErrBusy = errors.New("musicbrainz: no request slot before the deadline")
+
+// Recording is what MusicBrainz adds to a track.
+type Recording struct {
+	ID string
+	// ISRC is the first of the recording's codes, empty when it has none.
+	ISRC string
+	// Year is the year of the recording's first release, 0 when unknown.
+	Year int
+	// AlbumArtist is the credited artist of the release the track is on,
+	// e.g. "Various Artists" for a compilation; empty when that release is
+	// not among the recording's.
+	AlbumArtist string
+}
+
+// Query describes the track to look up.
+type Query struct {
+	Title  string
+	Artist string
+	// Album, when set, prefers the release with this title.
+	Album    string
+	Duration time.Duration
+}
+
+// Client searches recordings, spacing requests to the API's rate limit.
+type Client struct {
+	baseURL    string
+	userAgent  string
+	httpClient *http.Client
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL points the client at a mirror.
+func WithBaseURL(u string) Option {
+	return func(c *Client) {
+		if u != "" {
+			c.baseURL = strings.TrimSuffix(u, "/")
+		}
+	}
+}
+
+// WithHTTPClient sets the client requests are made with.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) {
+		if h != nil {
+			c.httpClient = h
+		}
+	}
+}
+
+// NewClient returns a client identifying itself with contact, an e-mail or
+// URL, as MusicBrainz requires.
+func NewClient(contact string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    defaultBaseURL,
+		userAgent:  fmt.Sprintf("ym-bot/1.0 ( %s )", contact),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+type searchResponse struct {
+	Recordings []recording `json:"recordings"`
+}
+
+type recording struct {
+	ID               string         `json:"id"`
+	Title            string         `json:"title"`
+	Score            int            `json:"score"`
+	Length           int            `json:"length"`
+	FirstReleaseDate string         `json:"first-release-date"`
+	ISRCs            []string       `json:"isrcs"`
+	ArtistCredit     []artistCredit `json:"artist-credit"`
+	Releases         []release      `json:"releases"`
+}
+
+type release struct {
+	Title        string         `json:"title"`
+	Status       string         `json:"status"`
+	ArtistCredit []artistCredit `json:"artist-credit"`
+}
+
+type artistCredit struct {
+	Name       string `json:"name"`
+	JoinPhrase string `json:"joinphrase"`
+}
+
+func creditString(credits []artistCredit) string {
+	var sb strings.Builder
+	for _, c := range credits {
+		sb.WriteString(c.Name)
+		sb.WriteString(c.JoinPhrase)
+	}
+	return sb.String()
+}
+
+// Lookup finds the recording matching q. It reports false, without an
+// error, when no recording is a confident match.
+func (c *Client) Lookup(ctx context.Context, q Query) (Recording, bool, error) {
+	if q.Title == "" || q.Artist == "" {
+		return Recording{}, false, nil
+	}
+	params := url.Values{}
+	params.Set("query", fmt.Sprintf("recording:%s AND artist:%s", phrase(q.Title), phrase(q.Artist)))
+	params.Set("fmt", "json")
+	params.Set("limit", "5")
+
+	var sr searchResponse
+	if _, err := c.get(ctx, "/recording", params, &sr); err != nil {
+		return Recording{}, false, fmt.Errorf("musicbrainz: search: %w", err)
+	}
+	return match(sr.Recordings, q)
+}
+
+// Song is a recording filed under an ISRC code.
+type Song struct {
+	Title  string
+	Artist string
+	// Duration is 0 when MusicBrainz does not know the length.
+	Duration time.Duration
+}
+
+type isrcResponse struct {
+	Recordings []recording `json:"recordings"`
+}
+
+// ByISRC returns the recordings filed under the ISRC code; none for a code
+// MusicBrainz does not know.
+func (c *Client) ByISRC(ctx context.Context, code string) ([]Song, error) {
+	params := url.Values{}
+	params.Set("fmt", "json")
+	params.Set("inc", "artist-credits")
+
+	var ir isrcResponse
+	found, err := c.get(ctx, "/isrc/"+url.PathEscape(code), params, &ir)
+	if err != nil {
+		return nil, fmt.Errorf("musicbrainz: isrc %s: %w", code, err)
+	}
+	if !found {
+		return nil, nil
+	}
+	songs := make([]Song, 0, len(ir.Recordings))
+	for _, r := range ir.Recordings {
+		songs = append(songs, Song{
+			Title:    r.Title,
+			Artist:   creditString(r.ArtistCredit),
+			Duration: time.Duration(r.Length) * time.Millisecond,
+		})
+	}
+	return songs, nil
+}
+
+// get decodes the JSON answer to a request for path into v, waiting for
+// its turn first. It reports false, without an error, on 404.
+func (c *Client) get(ctx context.Context, path string, params url.Values, v any) (bool, error) {
+	if err := c.wait(ctx); err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("decode: %w", err)
+	}
+	return true, nil
+}
+
+// match picks the best scored recording whose length fits the track's.
+func match(recs []recording, q Query) (Recording, bool, error) {
+	for _, r := range recs {
+		if r.Score < minScore {
+			continue
+		}
+		if q.Duration > 0 && r.Length > 0 {
+			diff := time.Duration(r.Length)*time.Millisecond - q.Duration
+			if diff < -maxLengthDiff || diff > maxLengthDiff {
+				continue
+			}
+		}
+		out := Recording{ID: r.ID}
+		if len(r.ISRCs) > 0 {
+			out.ISRC = r.ISRCs[0]
+		}
+		if len(r.FirstReleaseDate) >= 4 {
+			out.Year, _ = strconv.Atoi(r.FirstReleaseDate[:4])
+		}
+		if rel, ok := pickRelease(r.Releases, q.Album); ok && len(rel.ArtistCredit) > 0 {
+			out.AlbumArtist = creditString(rel.ArtistCredit)
+		}
+		return out, true, nil
+	}
+	return Recording{}, false, nil
+}
+
+// pickRelease finds the release titled album, or with no album the first
+// official one. Another release of the recording, such as a compilation it
+// is also on, says nothing about album's artist.
+func pickRelease(releases []release, album string) (release, bool) {
+	if album != "" {
+		for _, r := range releases {
+			if strings.EqualFold(r.Title, album) {
+				return r, true
+			}
+		}
+		return release{}, false
+	}
+	for _, r := range releases {
+		if r.Status == "Official" {
+			return r, true
+		}
+	}
+	return release{}, false
+}
+
+// wait blocks until the next request is allowed. A turn that comes after
+// ctx's deadline is not taken, so it stays free for a later request, and
+// wait fails with ErrBusy at once.
+func (c *Client) wait(ctx context.Context) error {
+	c.mu.Lock()
+	now := time.Now()
+	at := c.next
+	if at.Before(now) {
+		at = now
+	}
+	if deadline, ok := ctx.Deadline(); ok && at.After(deadline) {
+		c.mu.Unlock()
+		return ErrBusy
+	}
+	c.next = at.Add(minInterval)
+	c.mu.Unlock()
+
+	if d := time.Until(at); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return nil
+}
+
+// phrase quotes s as a Lucene phrase.
+func phrase(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
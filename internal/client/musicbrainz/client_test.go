@@ -0,0 +1,106 @@
+package musicbrainz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	credit := func(names ...string) []artistCredit {
+		out := make([]artistCredit, len(names))
+		for i, n := range names {
+			out[i] = artistCredit{Name: n}
+			if i < len(names)-1 {
+				out[i].JoinPhrase = " & "
+			}
+		}
+		return out
+	}
+	own := release{Title: "Night at the Opera", Status: "Official", ArtistCredit: credit("Queen")}
+	various := release{Title: "Greatest Rock Hits", Status: "Official", ArtistCredit: credit("Various Artists")}
+	song := recording{
+		ID: "r1", Score: 100, Length: 355000, FirstReleaseDate: "1975-10-31",
+		ISRCs: []string{"GBUM71029604", "GBUM71507409"}, ArtistCredit: credit("Queen"),
+		Releases: []release{various, own},
+	}
+
+	cases := []struct {
+		name  string
+		recs  []recording
+		q     Query
+		want  Recording
+		found bool
+	}{
+		{"album release", []recording{song}, Query{Album: "night at the opera", Duration: 354 * time.Second},
+			Recording{ID: "r1", ISRC: "GBUM71029604", Year: 1975, AlbumArtist: "Queen"}, true},
+		{"compilation release", []recording{song}, Query{Album: "Greatest Rock Hits"},
+			Recording{ID: "r1", ISRC: "GBUM71029604", Year: 1975, AlbumArtist: "Various Artists"}, true},
+		// The recording's own artist is not the album artist of an unknown release.
+		{"album not among releases", []recording{song}, Query{Album: "Live Killers"},
+			Recording{ID: "r1", ISRC: "GBUM71029604", Year: 1975}, true},
+		{"no album", []recording{song}, Query{},
+			Recording{ID: "r1", ISRC: "GBUM71029604", Year: 1975, AlbumArtist: "Various Artists"}, true},
+		{"low score", []recording{{ID: "r2", Score: 89}}, Query{}, Recording{}, false},
+		{"length too far", []recording{song}, Query{Duration: 340 * time.Second}, Recording{}, false},
+		{"first fitting", []recording{{ID: "r3", Score: 95, Length: 200000}, {ID: "r4", Score: 92, Length: 355000}},
+			Query{Duration: 355 * time.Second}, Recording{ID: "r4"}, true},
+		{"unknown length fits", []recording{{ID: "r5", Score: 90, FirstReleaseDate: "19"}}, Query{Duration: time.Minute},
+			Recording{ID: "r5"}, true},
+		{"none", nil, Query{}, Recording{}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, found, err := match(tc.recs, tc.q)
+			if err != nil || found != tc.found || got != tc.want {
+				t.Errorf("match = %+v, %v, %v; want %+v, %v", got, found, err, tc.want, tc.found)
+			}
+		})
+	}
+}
+
+func TestPickRelease(t *testing.T) {
+	releases := []release{
+		{Title: "Bootleg", Status: "Bootleg"},
+		{Title: "Hits", Status: "Official"},
+		{Title: "Album", Status: "Promotion"},
+	}
+	cases := []struct {
+		album string
+		want  string
+		ok    bool
+	}{
+		{"ALBUM", "Album", true},
+		{"Bootleg", "Bootleg", true},
+		{"Missing", "", false},
+		{"", "Hits", true},
+	}
+	for _, tc := range cases {
+		got, ok := pickRelease(releases, tc.album)
+		if ok != tc.ok || got.Title != tc.want {
+			t.Errorf("pickRelease(%q) = %q, %v; want %q, %v", tc.album, got.Title, ok, tc.want, tc.ok)
+		}
+	}
+	if _, ok := pickRelease([]release{{Title: "Demo", Status: "Bootleg"}}, ""); ok {
+		t.Error("picked an unofficial release with no album")
+	}
+}
+
+// TestWaitBusy checks that a turn past the deadline is refused without
+// being taken.
+func TestWaitBusy(t *testing.T) {
+	c := NewClient("test@example.org")
+	if err := c.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), minInterval/2)
+	defer cancel()
+	next := c.next
+	if err := c.wait(ctx); !errors.Is(err, ErrBusy) {
+		t.Fatalf("wait = %v, want ErrBusy", err)
+	}
+	if !c.next.Equal(next) {
+		t.Errorf("refused wait moved the next turn by %s", c.next.Sub(next))
+	}
+}
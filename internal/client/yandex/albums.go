@@ -0,0 +1,92 @@
+package yandex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Album is an album with its tracks in disc order.
+type Album struct {
+	ID      string
+	Title   string
+	Artists []string
+	Year    int
+	// Genre is the genre id, e.g. "rock"; may be empty.
+	Genre string
+	// Podcast marks a podcast, whose tracks are its episodes.
+	Podcast bool
+	Tracks  []Track
+}
+
+type albumWithTracksResponse struct {
+	Result struct {
+		ID    json.Number `json:"id"`
+		Title string      `json:"title"`
+		Year  int         `json:"year"`
+		Genre string      `json:"genre"`
+		// Type is "podcast" for podcasts; MetaType says so too on some.
+		Type     string      `json:"type"`
+		MetaType string      `json:"metaType"`
+		Artists  []artistDTO `json:"artists"`
+		// Volumes holds one track list per disc.
+		Volumes [][]trackDTO `json:"volumes"`
+	} `json:"result"`
+}
+
+// AlbumTracks returns album id together with all of its tracks. Tracks get
+// the album's year and genre and their position on it where their own
+// metadata lacks them.
+func (c *APIClient) AlbumTracks(ctx context.Context, id string) (Album, error) {
+	if id == "" {
+		return Album{}, fmt.Errorf("album id is empty")
+	}
+	var payload albumWithTracksResponse
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/albums/%s/with-tracks", c.baseURL, url.PathEscape(id)), &payload); err != nil {
+		return Album{}, fmt.Errorf("get album: %w", err)
+	}
+	r := payload.Result
+	album := Album{ID: r.ID.String(), Title: r.Title, Year: r.Year, Genre: r.Genre,
+		Podcast: r.Type == "podcast" || r.MetaType == "podcast"}
+	for _, a := range r.Artists {
+		if a.Name != "" {
+			album.Artists = append(album.Artists, a.Name)
+		}
+	}
+	for disc, volume := range r.Volumes {
+		for i, dto := range volume {
+			t := mapTrack(dto)
+			if t.TrackNumber == 0 {
+				t.DiscNumber, t.TrackNumber = disc+1, i+1
+			}
+			if t.Year == 0 {
+				t.Year = album.Year
+			}
+			if t.Genre == "" {
+				t.Genre = album.Genre
+			}
+			album.Tracks = append(album.Tracks, t)
+		}
+	}
+	return album, nil
+}
+
+// albumLink matches album pages on any Yandex Music domain, podcasts
+// included, e.g. https://music.yandex.ru/album/1234567.
+var albumLink = regexp.MustCompile(`^(?:https?://)?music\.yandex\.[a-z]{2,3}/album/(\d+)(?:[/?#]|$)`)
+
+// ParseAlbumRef returns the album id in an album link or a bare numeric id.
+func ParseAlbumRef(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if m := albumLink.FindStringSubmatch(s); m != nil {
+		return m[1], true
+	}
+	if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return s, true
+	}
+	return "", false
+}
@@ -0,0 +1,31 @@
+package yandex
+
+import (
+	"context"
+	"fmt"
+)
+
+type chartResponse struct {
+	Result struct {
+		Chart struct {
+			Tracks []struct {
+				Track *trackDTO `json:"track"`
+			} `json:"tracks"`
+		} `json:"chart"`
+	} `json:"result"`
+}
+
+// Chart returns the current Yandex Music chart, top position first.
+func (c *APIClient) Chart(ctx context.Context) ([]Track, error) {
+	var payload chartResponse
+	if err := c.getJSON(ctx, c.baseURL+"/landing3/chart", &payload); err != nil {
+		return nil, fmt.Errorf("get chart: %w", err)
+	}
+	tracks := make([]Track, 0, len(payload.Result.Chart.Tracks))
+	for _, t := range payload.Result.Chart.Tracks {
+		if t.Track != nil {
+			tracks = append(tracks, mapTrack(*t.Track))
+		}
+	}
+	return tracks, nil
+}
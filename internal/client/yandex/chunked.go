@@ -0,0 +1,147 @@
+package yandex
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// WithParallelDownload fetches files of at least threshold bytes over the
+// given number of connections, each requesting one byte range, and merges
+// them in place on disk. Servers without range support get a single GET.
+func WithParallelDownload(connections int, threshold int64) Option {
+	return func(c *APIClient) {
+		if connections > 1 {
+			c.connections = connections
+			c.chunkThreshold = threshold
+		}
+	}
+}
+
+// downloadChunked reports whether the file was fetched in parallel ranges;
+// false with a nil error means the caller should fall back to a single GET.
+func (c *APIClient) downloadChunked(ctx context.Context, downloadURL, destPath string) (bool, error) {
+	size, err := c.rangeSize(ctx, downloadURL)
+	if err != nil {
+		c.logger.Debug("range probe failed", zap.Error(err))
+		return false, nil
+	}
+	if size < c.chunkThreshold {
+		return false, nil
+	}
+
+	out, err := createFile(destPath)
+	if err != nil {
+		return false, err
+	}
+	defer out.Close()
+	if err := out.Truncate(size); err != nil {
+		return false, fmt.Errorf("allocate file: %w", err)
+	}
+
+	chunkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	part := (size + int64(c.connections) - 1) / int64(c.connections)
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		chunkErr error
+	)
+	for start := int64(0); start < size; start += part {
+		end := start + part - 1
+		if end >= size {
+			end = size - 1
+		}
+		wg.Add(1)
+		go func(start, end int64) {
+			defer wg.Done()
+			if err := c.fetchRange(chunkCtx, downloadURL, io.NewOffsetWriter(out, start), start, end); err != nil {
+				once.Do(func() {
+					chunkErr = err
+					cancel()
+				})
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	if chunkErr != nil {
+		if err := ctx.Err(); err != nil {
+			// Cancelled or timed out by the caller; do not retry sequentially.
+			return false, err
+		}
+		c.logger.Warn("chunked download failed, retrying in one piece", zap.Int64("size", size), zap.Error(chunkErr))
+		return false, nil
+	}
+	c.logger.Debug("chunked download done", zap.Int64("size", size), zap.Int("connections", c.connections))
+	return true, nil
+}
+
+// rangeSize asks for the first byte to learn the total size and confirm the
+// server honours Range requests.
+func (c *APIClient) rangeSize(ctx context.Context, downloadURL string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	c.attachHeaders(req)
+	req.Header.Set("Range", "bytes=0-0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("ranges not supported: status=%d", resp.StatusCode)
+	}
+	// Content-Range: bytes 0-0/12345
+	cr := resp.Header.Get("Content-Range")
+	i := strings.LastIndex(cr, "/")
+	if i < 0 {
+		return 0, fmt.Errorf("bad content-range %q", cr)
+	}
+	size, err := strconv.ParseInt(cr[i+1:], 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("bad content-range %q", cr)
+	}
+	return size, nil
+}
+
+// fetchRange writes bytes start..end (inclusive) of the file to w.
+func (c *APIClient) fetchRange(ctx context.Context, downloadURL string, w io.Writer, start, end int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+	c.attachHeaders(req)
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	resp, err := c.downloadClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("range %d-%d: status=%d", start, end, resp.StatusCode)
+	}
+
+	want := end - start + 1
+	n, err := io.Copy(w, io.LimitReader(resp.Body, want))
+	if err != nil {
+		return fmt.Errorf("range %d-%d: %w", start, end, err)
+	}
+	if n != want {
+		return fmt.Errorf("range %d-%d: short read %d of %d bytes", start, end, n, want)
+	}
+	return nil
+}
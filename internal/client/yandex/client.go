@@ -2,6 +2,8 @@ package yandex
 
 import (
 	"context"
+	"crypto/md5" //nolint:gosec // the storage protocol mandates MD5
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
@@ -9,6 +11,8 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -18,24 +22,214 @@ import (
 const (
 	apiBase   = "https://api.music.yandex.net"
 	userAgent = "ym-bot/0.1 (+github.com/ndrewnee/go-yandex-music compatible)"
+
+	// DefaultSignSalt is the secret storage hosts mix into the signature of
+	// XML download URLs; see WithSignSalt.
+	DefaultSignSalt = "XGRlBW9FXlekgbPrRHuSiA"
 )
 
 // Track represents a minimal subset of Yandex Music track fields.
 type Track struct {
-	ID              string
-	Title           string
-	Artists         []string
+	ID string
+	// RealID is the id Yandex now files the track under; it differs from ID
+	// for tracks that were migrated and is empty when not reported. See
+	// CanonicalID.
+	RealID string
+	// ShareURL is the link Yandex offers for sharing the track, if any.
+	ShareURL string
+	Title    string
+	// Version names the recording when there are several, e.g. "Live" or
+	// "Remastered 2011"; empty for the original. See FullTitle.
+	Version string
+	// Artists are the performers, main ones first, then the featured ones.
+	Artists []string
+	// Credits tell the role and id of every artist on the track, composers
+	// included; empty when the source did not say, e.g. in stored history.
+	Credits         []Credit
 	DurationSeconds int
-	CoverURL        string
+	CoverURI        string // size template, see cover.URL
 	AlbumTitle      string
+	AlbumID         string
+	// Genre is the album's genre id, e.g. "rock"; may be empty.
+	Genre string
+	// Year is the album's release year, DiscNumber and TrackNumber the
+	// track's 1-based position on it; 0 when unknown.
+	Year        int
+	DiscNumber  int
+	TrackNumber int
+	// Compilation reports that the album is a collection rather than the
+	// artist's own release.
+	Compilation bool
+	// Popularity is the album's like count, the closest measure of a
+	// track's popularity Yandex returns with it.
+	Popularity int
+	// ReleaseDate is the album's release date, zero when only Year is known.
+	ReleaseDate time.Time
+	// Availability reports licensing restrictions; zero means none known.
+	Availability Availability
+	// Explicit marks tracks with explicit lyrics.
+	Explicit bool
+	// HasLyrics reports that Yandex has the track's words, HasSyncedLyrics
+	// that it has them timed as well. See Client.Lyrics.
+	HasLyrics       bool
+	HasSyncedLyrics bool
+	// Published is when a podcast episode came out; zero for music.
+	Published time.Time
+	// ISRC and AlbumArtist are not returned by Yandex: they are filled in
+	// from MusicBrainz when the bot cross-references downloads there.
+	ISRC        string `json:",omitempty"`
+	AlbumArtist string `json:",omitempty"`
+}
+
+// Availability tells whether a track can be played from the bot's account.
+type Availability int
+
+const (
+	// Available means Yandex reported no restriction.
+	Available Availability = iota
+	// PremiumOnly tracks play in full only with a Yandex Plus subscription.
+	PremiumOnly
+	// RegionLocked tracks are not licensed where the bot runs, or were withdrawn.
+	RegionLocked
+)
+
+// Err returns the error class a download of a track with availability a
+// ends in, nil when it is expected to succeed.
+func (a Availability) Err() error {
+	switch a {
+	case PremiumOnly:
+		return ErrSubscriptionRequired
+	case RegionLocked:
+		return ErrRegionLocked
+	}
+	return nil
+}
+
+// DownloadLink is a resolved audio URL together with its encoding details.
+type DownloadLink struct {
+	// TrackID is the id the link was resolved for, one of the track's ID
+	// and RealID; empty for links not resolved by id.
+	TrackID     string
+	URL         string
+	Codec       string
+	BitrateKbps int
+	// Expires is when storage stops accepting URL; zero when the response
+	// did not say.
+	Expires time.Time
+}
+
+// Quality selects among the formats download-info offers for a track.
+type Quality string
+
+// Supported qualities; lossless falls back to the best mp3 when FLAC is not offered.
+const (
+	QualityHigh     Quality = "high"
+	QualityLow      Quality = "low"
+	QualityLossless Quality = "lossless"
+)
+
+// qualityRank orders qualities from the smallest files up.
+var qualityRank = map[Quality]int{QualityLow: 0, QualityHigh: 1, QualityLossless: 2}
+
+type qualityCapKey struct{}
+
+// WithQualityCap makes downloads resolved with ctx pick at most q, whatever
+// the client's quality; an empty q sets no cap.
+func WithQualityCap(ctx context.Context, q Quality) context.Context {
+	if q == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, qualityCapKey{}, q)
+}
+
+// capQuality lowers q to the cap carried by ctx, if any.
+func capQuality(ctx context.Context, q Quality) Quality {
+	if limit, ok := ctx.Value(qualityCapKey{}).(Quality); ok && qualityRank[limit] < qualityRank[q] {
+		return limit
+	}
+	return q
+}
+
+type skipFormatsKey struct{}
+
+// WithoutFormats makes downloads resolved with ctx skip the download-info
+// entries of links, by codec and bitrate, e.g. ones that failed to download;
+// the next best entry is picked instead. When none is left resolving fails
+// with ErrNoFormatLeft.
+func WithoutFormats(ctx context.Context, links ...DownloadLink) context.Context {
+	skipped, _ := ctx.Value(skipFormatsKey{}).([]DownloadLink)
+	return context.WithValue(ctx, skipFormatsKey{}, append(slices.Clip(skipped), links...))
+}
+
+// skippedFormat reports whether ctx excludes the entry i.
+func skippedFormat(ctx context.Context, i downloadInfoDTO) bool {
+	skipped, _ := ctx.Value(skipFormatsKey{}).([]DownloadLink)
+	return slices.ContainsFunc(skipped, func(l DownloadLink) bool {
+		return strings.EqualFold(l.Codec, i.Codec) && l.BitrateKbps == i.Bitrate
+	})
+}
+
+// ParseQuality validates a user-supplied quality name.
+func ParseQuality(s string) (Quality, error) {
+	switch q := Quality(strings.ToLower(strings.TrimSpace(s))); q {
+	case QualityHigh, QualityLow, QualityLossless:
+		return q, nil
+	}
+	return "", fmt.Errorf("unknown quality %q, want high, low or lossless", s)
+}
+
+// Video is a music video or clip found by search.
+type Video struct {
+	Title           string
+	DurationSeconds int
+	ThumbURI        string // size template or absolute URL, see cover.URL
+	// URL plays the clip on its provider's page (YouTube or an embed player).
+	URL string
+}
+
+// SearchResult is a page of tracks plus Yandex's spelling feedback.
+type SearchResult struct {
+	Tracks []Track
+	// Collapsed counts duplicates and versions excluded by the query that
+	// were dropped from the page; the next page starts after
+	// offset+len(Tracks)+Collapsed results.
+	Collapsed int
+	// Correction is the query Yandex suggests instead of the original, if any.
+	Correction string
+	// Corrected reports that Tracks are already results for Correction.
+	Corrected bool
 }
 
 // Client describes operations the service layer relies on.
 type Client interface {
-	SearchTracks(ctx context.Context, query string, limit, offset int) ([]Track, error)
+	SearchTracks(ctx context.Context, query string, limit, offset int) (SearchResult, error)
+	SearchVideos(ctx context.Context, query string, limit, offset int) ([]Video, error)
+	SearchAll(ctx context.Context, query string, limit int) (Combined, error)
+	SearchSuggest(ctx context.Context, part string) ([]string, error)
 	GetTrack(ctx context.Context, id string) (Track, error)
-	GetDownloadURL(ctx context.Context, id string) (string, error)
+	GetTracks(ctx context.Context, ids []string) ([]Track, error)
+	GetDownloadLink(ctx context.Context, id string) (DownloadLink, error)
+	GetDownloadInfo(ctx context.Context, id string) (DownloadLink, error)
+	Lyrics(ctx context.Context, trackID string, synced bool) (Lyrics, error)
 	DownloadToFile(ctx context.Context, downloadURL, destPath string) error
+	OpenDownload(ctx context.Context, downloadURL string) (io.ReadCloser, int64, error)
+	ProbeDownload(ctx context.Context, downloadURL string) (Probe, error)
+	LikeTracks(ctx context.Context, ids []string) error
+	LikedTrackIDs(ctx context.Context) ([]string, error)
+	CurrentQueue(ctx context.Context) (Queue, error)
+	Genres(ctx context.Context) ([]Genre, error)
+	GenreTracks(ctx context.Context, genreID string) ([]Track, error)
+	Chart(ctx context.Context) ([]Track, error)
+	AlbumTracks(ctx context.Context, id string) (Album, error)
+	ListUserPlaylists(ctx context.Context) ([]Playlist, error)
+	PlaylistTracks(ctx context.Context, kind int) (Playlist, []Track, error)
+	PublicPlaylistTracks(ctx context.Context, owner string, kind int) (Playlist, []Track, error)
+	EditorialPlaylists(ctx context.Context) ([]Playlist, error)
+	TagPlaylists(ctx context.Context, tag string) (string, []Playlist, error)
+	CreatePlaylist(ctx context.Context, title string) (Playlist, error)
+	AddTracksToPlaylist(ctx context.Context, kind int, tracks []Track) (Playlist, error)
+	StationTracks(ctx context.Context, station, lastTrackID string) (StationBatch, error)
+	StationFeedback(ctx context.Context, station string, f StationFeedback) error
 }
 
 // HTTPClient wraps the stdlib client for easier testing.
@@ -47,11 +241,150 @@ type HTTPClient interface {
 type APIClient struct {
 	httpClient HTTPClient
 	token      string
+	baseURL    string
+	userAgent  string
+	headers    http.Header
+	correct    bool
+	quality    Quality
+	signSalt   string
 	logger     *zap.Logger
+
+	// downloadClient fetches audio files: httpClient without WithTimeout,
+	// so a transfer is bounded by its context alone.
+	downloadClient HTTPClient
+
+	connections    int   // parallel ranges per download; <= 1 disables chunking
+	chunkThreshold int64 // smallest file fetched in chunks
+}
+
+// Option customizes an APIClient.
+type Option func(*APIClient)
+
+// WithBaseURL points the client at a different API host (mirror, proxy or fake server).
+func WithBaseURL(baseURL string) Option {
+	return func(c *APIClient) {
+		if baseURL != "" {
+			c.baseURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithUserAgent overrides the User-Agent sent with every request.
+func WithUserAgent(ua string) Option {
+	return func(c *APIClient) {
+		if ua != "" {
+			c.userAgent = ua
+		}
+	}
+}
+
+// WithTimeout bounds each API request, reading the response body included,
+// to d. It puts a deadline on the request's context, so it works whatever
+// the HTTPClient, e.g. a Recorder wrapping an *http.Client. Audio downloads
+// are left to their context: a large file may well take longer.
+func WithTimeout(d time.Duration) Option {
+	return func(c *APIClient) {
+		if d > 0 {
+			c.httpClient = timeoutClient{inner: c.httpClient, timeout: d}
+		}
+	}
+}
+
+// timeoutClient gives each request timeout to complete, body included.
+type timeoutClient struct {
+	inner   HTTPClient
+	timeout time.Duration
+}
+
+func (t timeoutClient) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.inner.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return resp, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's deadline once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// timeoutClassifier classifies the timeouts of requests and of reading their
+// bodies as ErrTimeout, see classifyTimeout. It wraps the clients outermost,
+// so the request's context is the caller's.
+type timeoutClassifier struct {
+	inner HTTPClient
+}
+
+func (t timeoutClassifier) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	resp, err := t.inner.Do(req)
+	if err != nil {
+		return resp, classifyTimeout(ctx, err)
+	}
+	resp.Body = classifyingBody{ReadCloser: resp.Body, ctx: ctx}
+	return resp, nil
+}
+
+// classifyingBody classifies the read errors of a response body.
+type classifyingBody struct {
+	io.ReadCloser
+	ctx context.Context
+}
+
+func (b classifyingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = classifyTimeout(b.ctx, err)
+	}
+	return n, err
+}
+
+// WithHeaders adds custom headers (e.g. proxy gateway auth) to every request.
+func WithHeaders(headers map[string]string) Option {
+	return func(c *APIClient) {
+		for k, v := range headers {
+			c.headers.Set(k, v)
+		}
+	}
+}
+
+// WithSpellCorrection lets Yandex search for its corrected spelling of a
+// query instead of the literal text. Suggestions are reported either way.
+func WithSpellCorrection(enabled bool) Option {
+	return func(c *APIClient) { c.correct = enabled }
+}
+
+// WithQuality chooses which of the offered formats downloads use.
+func WithQuality(q Quality) Option {
+	return func(c *APIClient) {
+		if q != "" {
+			c.quality = q
+		}
+	}
+}
+
+// WithSignSalt replaces DefaultSignSalt, should Yandex rotate it.
+func WithSignSalt(salt string) Option {
+	return func(c *APIClient) {
+		if salt != "" {
+			c.signSalt = salt
+		}
+	}
 }
 
 // NewClient builds a Yandex Music API client.
-func NewClient(httpClient HTTPClient, token string, logger *zap.Logger) *APIClient {
+func NewClient(httpClient HTTPClient, token string, logger *zap.Logger, opts ...Option) *APIClient {
 	if logger == nil {
 		logger = zap.NewNop()
 	}
@@ -60,68 +393,114 @@ func NewClient(httpClient HTTPClient, token string, logger *zap.Logger) *APIClie
 		httpClient = &http.Client{Timeout: 15 * time.Second}
 	}
 
-	return &APIClient{
-		httpClient: httpClient,
-		token:      token,
-		logger:     logger,
+	c := &APIClient{
+		httpClient:     httpClient,
+		downloadClient: httpClient,
+		token:          token,
+		baseURL:        apiBase,
+		userAgent:      userAgent,
+		headers:        make(http.Header),
+		quality:        QualityHigh,
+		signSalt:       DefaultSignSalt,
+		logger:         logger,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	c.httpClient = timeoutClassifier{inner: c.httpClient}
+	c.downloadClient = timeoutClassifier{inner: c.downloadClient}
+	return c
 }
 
 // SearchTracks queries Yandex Music search API for tracks.
-func (c *APIClient) SearchTracks(ctx context.Context, query string, limit, offset int) ([]Track, error) {
-	if strings.TrimSpace(query) == "" {
-		return nil, fmt.Errorf("query is empty")
+func (c *APIClient) SearchTracks(ctx context.Context, query string, limit, offset int) (SearchResult, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	result, err := c.search(ctx, query, "track", limit, offset)
+	if err != nil {
+		return SearchResult{}, err
+	}
+
+	tracks := make([]Track, 0, len(result.Tracks.Results))
+	for i, t := range result.Tracks.Results {
+		if i >= limit {
+			break
+		}
+		tracks = append(tracks, mapTrack(t))
 	}
+
+	return SearchResult{
+		Tracks:     tracks,
+		Correction: result.MisspellResult,
+		Corrected:  result.MisspellCorrected,
+	}, nil
+}
+
+// SearchVideos queries Yandex Music search API for music videos and clips.
+func (c *APIClient) SearchVideos(ctx context.Context, query string, limit, offset int) ([]Video, error) {
 	if limit <= 0 {
 		limit = 10
 	}
+	result, err := c.search(ctx, query, "video", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	videos := make([]Video, 0, len(result.Videos.Results))
+	for _, v := range result.Videos.Results {
+		if len(videos) >= limit {
+			break
+		}
+		if video, ok := mapVideo(v); ok {
+			videos = append(videos, video)
+		}
+	}
+	return videos, nil
+}
+
+// search performs a paged search request for one result type.
+func (c *APIClient) search(ctx context.Context, query, kind string, limit, offset int) (searchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return searchResult{}, fmt.Errorf("query is empty")
+	}
 	if offset < 0 {
 		offset = 0
 	}
 
 	page := offset / limit
 
-	u, _ := url.Parse(apiBase + "/search")
+	u, _ := url.Parse(c.baseURL + "/search")
 	q := u.Query()
 	q.Set("text", query)
-	q.Set("type", "track")
+	q.Set("type", kind)
 	q.Set("page", fmt.Sprintf("%d", page))
-	q.Set("nococrrect", "true")
+	if !c.correct {
+		q.Set("nocorrect", "true")
+	}
 	u.RawQuery = q.Encode()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return nil, err
+		return searchResult{}, err
 	}
 	c.attachHeaders(req)
 
-	
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return searchResult{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
-		return nil, fmt.Errorf("search failed: status=%d body=%s", resp.StatusCode, string(body))
+		return searchResult{}, statusError("search", resp)
 	}
 
 	var payload searchResponse
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return nil, fmt.Errorf("decode search response: %w", err)
+		return searchResult{}, fmt.Errorf("decode search response: %w", err)
 	}
-
-	tracks := make([]Track, 0, len(payload.Result.Tracks.Results))
-	for i, t := range payload.Result.Tracks.Results {
-		if i >= limit {
-			break
-		}
-		tracks = append(tracks, mapTrack(t))
-	}
-
-	return tracks, nil
+	return payload.Result, nil
 }
 
 // GetTrack fetches detailed track metadata by id.
@@ -130,7 +509,7 @@ func (c *APIClient) GetTrack(ctx context.Context, id string) (Track, error) {
 		return Track{}, fmt.Errorf("track id is empty")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/tracks/%s", apiBase, id), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/tracks/%s", c.baseURL, id), nil)
 	if err != nil {
 		return Track{}, err
 	}
@@ -143,8 +522,7 @@ func (c *APIClient) GetTrack(ctx context.Context, id string) (Track, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
-		return Track{}, fmt.Errorf("get track failed: status=%d body=%s", resp.StatusCode, string(body))
+		return Track{}, statusError("get track", resp)
 	}
 
 	var payload trackResponse
@@ -153,84 +531,148 @@ func (c *APIClient) GetTrack(ctx context.Context, id string) (Track, error) {
 	}
 
 	if len(payload.Result) == 0 {
-		return Track{}, fmt.Errorf("track not found")
+		return Track{}, fmt.Errorf("track %s: %w", id, ErrNotFound)
 	}
 
 	return mapTrack(payload.Result[0]), nil
 }
 
-// GetDownloadURL resolves a track id to a downloadable URL.
-// Official clients perform an extra redirect/URL signing step; for the purposes
-// of this demo we reuse the same pattern used by community clients.
-func (c *APIClient) GetDownloadURL(ctx context.Context, id string) (string, error) {
-	if id == "" {
-		return "", fmt.Errorf("track id is empty")
+// GetTracks fetches metadata for several tracks in one request. Unknown ids
+// are left out, so the result may be shorter than ids.
+func (c *APIClient) GetTracks(ctx context.Context, ids []string) ([]Track, error) {
+	if len(ids) == 0 {
+		return nil, nil
 	}
 
-	// Request all available formats and pick the first (usually mp3).
-	u := fmt.Sprintf("%s/tracks/%s/download-info", apiBase, id)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	form := url.Values{"track-ids": {strings.Join(ids, ",")}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/tracks", strings.NewReader(form.Encode()))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	c.attachHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
-		return "", fmt.Errorf("download-info failed: status=%d body=%s", resp.StatusCode, string(body))
+		return nil, statusError("get tracks", resp)
 	}
 
-	var payload downloadInfoResponse
+	var payload trackResponse
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return "", fmt.Errorf("decode download-info: %w", err)
+		return nil, fmt.Errorf("decode tracks response: %w", err)
 	}
 
-	if len(payload.Result) == 0 {
-		return "", fmt.Errorf("download url not found")
+	tracks := make([]Track, 0, len(payload.Result))
+	for _, t := range payload.Result {
+		tracks = append(tracks, mapTrack(t))
 	}
+	return tracks, nil
+}
 
-	info := pickDownloadInfo(payload.Result)
-	if info.URL == "" {
-		return "", fmt.Errorf("download url not found")
+// GetDownloadLink resolves a track id to a downloadable URL.
+// Official clients perform an extra redirect/URL signing step; for the purposes
+// of this demo we reuse the same pattern used by community clients.
+func (c *APIClient) GetDownloadLink(ctx context.Context, id string) (DownloadLink, error) {
+	info, err := c.downloadInfo(ctx, id)
+	if err != nil {
+		return DownloadLink{}, err
 	}
 
 	// Resolve final downloadable URL (handles downloadInfoUrl indirection).
-	finalURL, err := c.resolveDownloadInfoURL(ctx, info.URL, id)
+	finalURL, expires, err := c.resolveDownloadInfoURL(ctx, info.URL, id)
 	if err != nil {
-		return "", err
+		return DownloadLink{}, err
+	}
+	if expires.Before(time.Now()) {
+		// A URL just handed out cannot have expired: the clocks disagree
+		// or ts means something else, so the expiry is not to be trusted.
+		expires = time.Time{}
+	}
+	return DownloadLink{
+		TrackID:     id,
+		URL:         finalURL,
+		Codec:       strings.ToLower(info.Codec),
+		BitrateKbps: info.Bitrate,
+		Expires:     expires,
+	}, nil
+}
+
+// GetDownloadInfo reports the codec and bitrate GetDownloadLink would pick,
+// without resolving the URL. The returned link has an empty URL.
+func (c *APIClient) GetDownloadInfo(ctx context.Context, id string) (DownloadLink, error) {
+	info, err := c.downloadInfo(ctx, id)
+	if err != nil {
+		return DownloadLink{}, err
 	}
-	return finalURL, nil
+	return DownloadLink{TrackID: id, Codec: strings.ToLower(info.Codec), BitrateKbps: info.Bitrate}, nil
 }
 
-// DownloadToFile streams the content into destPath.
-func (c *APIClient) DownloadToFile(ctx context.Context, downloadURL, destPath string) error {
-	if downloadURL == "" {
-		return fmt.Errorf("download url is empty")
+// downloadInfo requests all available formats and picks one (usually mp3).
+func (c *APIClient) downloadInfo(ctx context.Context, id string) (downloadInfoDTO, error) {
+	if id == "" {
+		return downloadInfoDTO{}, fmt.Errorf("track id is empty")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	u := fmt.Sprintf("%s/tracks/%s/download-info", c.baseURL, id)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return err
+		return downloadInfoDTO{}, err
 	}
 	c.attachHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return err
+		return downloadInfoDTO{}, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
-		return fmt.Errorf("download failed: status=%d body=%s", resp.StatusCode, string(body))
+		return downloadInfoDTO{}, statusError("download-info", resp)
+	}
+
+	var payload downloadInfoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return downloadInfoDTO{}, fmt.Errorf("decode download-info: %w", err)
 	}
 
+	if len(payload.Result) == 0 {
+		return downloadInfoDTO{}, fmt.Errorf("download url not found")
+	}
+
+	for _, info := range rankDownloadInfo(payload.Result, capQuality(ctx, c.quality)) {
+		if info.URL != "" && !skippedFormat(ctx, info) {
+			return info, nil
+		}
+	}
+	if ctx.Value(skipFormatsKey{}) != nil {
+		return downloadInfoDTO{}, fmt.Errorf("track %s: %w", id, ErrNoFormatLeft)
+	}
+	return downloadInfoDTO{}, fmt.Errorf("download url not found")
+}
+
+// DownloadToFile streams the content into destPath, in parallel ranges for
+// large files when WithParallelDownload is set.
+func (c *APIClient) DownloadToFile(ctx context.Context, downloadURL, destPath string) error {
+	if downloadURL == "" {
+		return fmt.Errorf("download url is empty")
+	}
+	if c.connections > 1 {
+		if done, err := c.downloadChunked(ctx, downloadURL, destPath); done || err != nil {
+			return err
+		}
+	}
+
+	body, _, err := c.OpenDownload(ctx, downloadURL)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
 	tmpDir := filepath.Dir(destPath)
 	if err := ensureDir(tmpDir); err != nil {
 		return err
@@ -242,12 +684,94 @@ func (c *APIClient) DownloadToFile(ctx context.Context, downloadURL, destPath st
 	}
 	defer out.Close()
 
-	_, err = io.Copy(out, resp.Body)
+	_, err = io.Copy(out, body)
 	return err
 }
 
+// OpenDownload starts fetching the content and returns the response body
+// with its length (-1 when unknown). The caller must close the body.
+func (c *APIClient) OpenDownload(ctx context.Context, downloadURL string) (io.ReadCloser, int64, error) {
+	if downloadURL == "" {
+		return nil, 0, fmt.Errorf("download url is empty")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	c.attachHeaders(req)
+
+	resp, err := c.downloadClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, 0, statusError("download", resp)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+// Probe is what the storage host says about a download URL without sending
+// the file.
+type Probe struct {
+	// URL is where the redirects, if any, ended.
+	URL         string
+	ContentType string
+	// Size is the file's length in bytes, -1 when unknown.
+	Size int64
+}
+
+// ProbeDownload asks for the headers of downloadURL the way a third party,
+// e.g. Telegram fetching an inline result, would: without the client's
+// credentials. Hosts that refuse HEAD get a GET of the first byte instead.
+func (c *APIClient) ProbeDownload(ctx context.Context, downloadURL string) (Probe, error) {
+	if downloadURL == "" {
+		return Probe{}, fmt.Errorf("download url is empty")
+	}
+	resp, err := c.probe(ctx, http.MethodHead, downloadURL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = c.probe(ctx, http.MethodGet, downloadURL)
+	}
+	if err != nil {
+		return Probe{}, err
+	}
+	defer resp.Body.Close()
+
+	p := Probe{URL: resp.Request.URL.String(), ContentType: resp.Header.Get("Content-Type"), Size: resp.ContentLength}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusPartialContent:
+		// "bytes 0-0/4410342"
+		p.Size = -1
+		if _, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/"); ok {
+			if n, err := strconv.ParseInt(total, 10, 64); err == nil {
+				p.Size = n
+			}
+		}
+	default:
+		return Probe{}, statusError("probe", resp)
+	}
+	return p, nil
+}
+
+func (c *APIClient) probe(ctx context.Context, method, downloadURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, downloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	return c.httpClient.Do(req)
+}
+
 func (c *APIClient) attachHeaders(req *http.Request) {
-	req.Header.Set("User-Agent", userAgent)
+	for k, v := range c.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("User-Agent", c.userAgent)
 	if c.token != "" {
 		req.Header.Set("Authorization", "OAuth "+c.token)
 	}
@@ -255,21 +779,22 @@ func (c *APIClient) attachHeaders(req *http.Request) {
 
 // resolveDownloadInfoURL fetches downloadInfoUrl and extracts the final audio URL.
 // Some deployments return JSON {"src": "...mp3"}, some redirect, others return XML
-// with host/path/ts/s which needs to be combined into a final URL.
-func (c *APIClient) resolveDownloadInfoURL(ctx context.Context, infoURL, trackID string) (string, error) {
+// with host/path/ts/s which needs to be combined into a final URL. Only the
+// XML form tells when the URL expires; the time is zero otherwise.
+func (c *APIClient) resolveDownloadInfoURL(ctx context.Context, infoURL, trackID string) (string, time.Time, error) {
 	if infoURL == "" {
-		return "", fmt.Errorf("download info url is empty")
+		return "", time.Time{}, fmt.Errorf("download info url is empty")
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, infoURL, nil)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 	c.attachHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 	defer resp.Body.Close()
 
@@ -280,31 +805,33 @@ func (c *APIClient) resolveDownloadInfoURL(ctx context.Context, infoURL, trackID
 	body, _ := io.ReadAll(io.LimitReader(resp.Body, 8<<10))
 	if len(body) > 0 {
 		if err := json.Unmarshal(body, &payload); err == nil && payload.Src != "" {
-			return payload.Src, nil
+			return payload.Src, time.Time{}, nil
 		}
 	}
 
 	// Try XML response with host/path/ts/s.
-	xmlURL, xmlErr := parseDownloadInfoXML(body, trackID)
+	xmlURL, expires, xmlErr := parseDownloadInfoXML(body, trackID, c.signSalt)
 	if xmlErr == nil && xmlURL != "" {
-		return xmlURL, nil
+		return xmlURL, expires, nil
 	}
 
 	// If not JSON, but redirect is provided.
 	if loc := resp.Header.Get("Location"); loc != "" {
-		return loc, nil
+		return loc, time.Time{}, nil
 	}
 	if resp.Request != nil && resp.Request.URL != nil {
-		return resp.Request.URL.String(), nil
+		return resp.Request.URL.String(), time.Time{}, nil
 	}
 
-	return "", fmt.Errorf("cannot resolve download url: status=%d", resp.StatusCode)
+	return "", time.Time{}, fmt.Errorf("cannot resolve download url: status=%d", resp.StatusCode)
 }
 
-// parseDownloadInfoXML builds the final mp3 URL from XML payload.
-func parseDownloadInfoXML(data []byte, trackID string) (string, error) {
+// parseDownloadInfoXML builds the final mp3 URL from XML payload, signed
+// with salt; storage hosts answer 403 to unsigned or badly signed URLs. The
+// time is the expiry carried in ts, zero when it is not a plausible one.
+func parseDownloadInfoXML(data []byte, trackID, salt string) (string, time.Time, error) {
 	if len(data) == 0 {
-		return "", fmt.Errorf("empty xml body")
+		return "", time.Time{}, fmt.Errorf("empty xml body")
 	}
 
 	type xmlInfo struct {
@@ -317,54 +844,118 @@ func parseDownloadInfoXML(data []byte, trackID string) (string, error) {
 
 	var info xmlInfo
 	if err := xml.Unmarshal(data, &info); err != nil {
-		return "", err
+		return "", time.Time{}, err
 	}
 	if info.Host == "" || info.Path == "" || info.TS == "" || info.S == "" {
-		return "", fmt.Errorf("incomplete xml fields")
+		return "", time.Time{}, fmt.Errorf("incomplete xml fields")
 	}
 
-	final := fmt.Sprintf("https://%s/get-mp3/%s/%s%s", info.Host, info.S, info.TS, info.Path)
+	final := fmt.Sprintf("https://%s/get-mp3/%s/%s%s", info.Host, SignDownloadPath(salt, info.Path, info.S), info.TS, info.Path)
 	if trackID != "" {
 		final = final + "?track-id=" + trackID
 	}
-	return final, nil
+	return final, parseLinkExpiry(info.TS), nil
 }
 
-// pickDownloadInfo chooses the best available download info (prefer mp3).
-func pickDownloadInfo(items []downloadInfoDTO) downloadInfoDTO {
-	if len(items) == 0 {
-		return downloadInfoDTO{}
+// parseLinkExpiry reads the ts field of XML download info: hex microseconds
+// since the epoch. Anything outside a sane range is treated as unknown.
+func parseLinkExpiry(ts string) time.Time {
+	us, err := strconv.ParseInt(ts, 16, 64)
+	if err != nil {
+		return time.Time{}
 	}
-	for _, i := range items {
-		if strings.EqualFold(i.Codec, "mp3") {
-			return i
-		}
+	t := time.UnixMicro(us).UTC()
+	if t.Year() < 2000 || t.Year() > 2100 {
+		return time.Time{}
 	}
-	// fallback to first entry
-	return items[0]
+	return t
 }
 
-// mapTrack converts API model to internal Track.
-func mapTrack(t trackDTO) Track {
-	artists := make([]string, 0, len(t.Artists))
-	for _, a := range t.Artists {
-		if a.Name != "" {
-			artists = append(artists, a.Name)
+// SignDownloadPath computes the signature of an XML download URL: the MD5
+// of the salt, the path without its leading slash and the "s" field.
+func SignDownloadPath(salt, path, s string) string {
+	sum := md5.Sum([]byte(salt + strings.TrimPrefix(path, "/") + s))
+	return hex.EncodeToString(sum[:])
+}
+
+// rankDownloadInfo orders the entries of a download-info response from the
+// one to download for quality q to the last resort: for lossless, FLAC
+// first, then mp3 from the highest bitrate (the lowest for QualityLow), then
+// whatever else is offered, in the order given. Below lossless, FLAC is
+// offered only when there is nothing else, so a quality cap holds.
+func rankDownloadInfo(items []downloadInfoDTO, q Quality) []downloadInfoDTO {
+	var lossless, mp3, other []downloadInfoDTO
+	for _, i := range items {
+		switch {
+		case (DownloadLink{Codec: i.Codec}).Lossless():
+			lossless = append(lossless, i)
+		case strings.EqualFold(i.Codec, "mp3"):
+			mp3 = append(mp3, i)
+		default:
+			other = append(other, i)
 		}
 	}
-
-	cover := ""
-	if t.CoverURI != "" {
-		cover = "https://" + strings.ReplaceAll(t.CoverURI, "%%", "200x200")
+	slices.SortStableFunc(mp3, func(a, b downloadInfoDTO) int {
+		if q == QualityLow {
+			return a.Bitrate - b.Bitrate
+		}
+		return b.Bitrate - a.Bitrate
+	})
+	if q == QualityLossless {
+		return slices.Concat(lossless, mp3, other)
+	}
+	if len(mp3)+len(other) == 0 {
+		return lossless
 	}
+	return slices.Concat(mp3, other)
+}
 
+// mapTrack converts API model to internal Track.
+func mapTrack(t trackDTO) Track {
+	credits := mapCredits(t.Artists)
+	album := t.Albums.first()
 	return Track{
 		ID:              t.ID.String(),
+		RealID:          t.RealID,
+		ShareURL:        t.TrackShare,
 		Title:           t.Title,
-		Artists:         artists,
+		Version:         t.Version,
+		Artists:         performers(credits),
+		Credits:         credits,
 		DurationSeconds: t.DurationMs / 1000,
-		CoverURL:        cover,
+		CoverURI:        t.CoverURI,
 		AlbumTitle:      t.Albums.Title(),
+		AlbumID:         t.Albums.ID(),
+		Genre:           t.Albums.Genre(),
+		Year:            album.Year,
+		DiscNumber:      album.TrackPosition.Volume,
+		TrackNumber:     album.TrackPosition.Index,
+		Compilation:     album.Type == "compilation",
+		Popularity:      album.LikesCount,
+		ReleaseDate:     album.releaseDate(),
+		Availability:    t.availability(),
+		Explicit:        t.ContentWarning == "explicit",
+		HasLyrics:       t.LyricsInfo.HasAvailableTextLyrics || t.LyricsInfo.HasAvailableSyncLyrics,
+		HasSyncedLyrics: t.LyricsInfo.HasAvailableSyncLyrics,
+		Published:       parseDate(t.PubDate),
 	}
 }
 
+func mapVideo(v videoDTO) (Video, bool) {
+	link := v.EmbedURL
+	if v.Provider == "youtube" && v.ProviderVideoID != "" {
+		link = "https://www.youtube.com/watch?v=" + v.ProviderVideoID
+	} else if v.YoutubeURL != "" {
+		link = v.YoutubeURL
+	}
+	if link == "" {
+		return Video{}, false
+	}
+
+	return Video{
+		Title:           v.Title,
+		DurationSeconds: v.Duration,
+		ThumbURI:        v.ThumbnailURL,
+		URL:             link,
+	}, true
+}
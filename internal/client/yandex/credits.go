@@ -0,0 +1,131 @@
+package yandex
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// ArtistRole is how an artist is credited on a track.
+type ArtistRole string
+
+// Artist roles; performers are main or featured.
+const (
+	RoleMain     ArtistRole = "main"
+	RoleFeatured ArtistRole = "featured"
+	RoleComposer ArtistRole = "composer"
+)
+
+// Credit is an artist credited on a track.
+type Credit struct {
+	ID   string
+	Name string
+	Role ArtistRole
+}
+
+// featJoiner matches the joiners Yandex uses in front of featured artists.
+var featJoiner = regexp.MustCompile(`(?i)\b(feat|ft|featuring)\b\.?|при участии`)
+
+// mapCredits assigns roles to the artists of a track. Artists flagged as
+// composers are credited as such, unless nobody else is: a track credited
+// to its composer alone is performed under their name. A joint credit is
+// split along its joiners, the artists after a "feat." being featured.
+func mapCredits(artists []artistDTO) []Credit {
+	performers := 0
+	for _, a := range artists {
+		if !a.Composer {
+			performers++
+		}
+	}
+
+	var credits []Credit
+	seen := make(map[string]bool)
+	add := func(c Credit) {
+		key := c.ID
+		if key == "" {
+			key = "name:" + c.Name
+		}
+		if !seen[key] {
+			seen[key] = true
+			credits = append(credits, c)
+		}
+	}
+	for _, a := range artists {
+		if a.Name == "" {
+			continue
+		}
+		role := RoleMain
+		if a.Composer && performers > 0 {
+			role = RoleComposer
+		}
+		main, joint := decompose(a)
+		add(Credit{ID: a.ID.String(), Name: main, Role: role})
+		for _, c := range joint {
+			if role == RoleComposer {
+				c.Role = RoleComposer
+			}
+			add(c)
+		}
+	}
+	return credits
+}
+
+// decompose splits a joint credit into the name of its first artist and the
+// credits of the others.
+func decompose(a artistDTO) (string, []Credit) {
+	if len(a.Decomposed) == 0 {
+		return a.Name, nil
+	}
+	var (
+		credits []Credit
+		suffix  strings.Builder
+		role    = RoleMain
+	)
+	for _, raw := range a.Decomposed {
+		var joiner string
+		if err := json.Unmarshal(raw, &joiner); err == nil {
+			suffix.WriteString(joiner)
+			if featJoiner.MatchString(joiner) {
+				role = RoleFeatured
+			}
+			continue
+		}
+		var part artistDTO
+		if err := json.Unmarshal(raw, &part); err != nil || part.Name == "" {
+			continue
+		}
+		suffix.WriteString(part.Name)
+		credits = append(credits, Credit{ID: part.ID.String(), Name: part.Name, Role: role})
+	}
+	// The joint artist is named after all of them; its own share is the
+	// part before the joiners.
+	name := a.Name
+	if trimmed := strings.TrimSpace(strings.TrimSuffix(a.Name, suffix.String())); trimmed != "" {
+		name = trimmed
+	}
+	return name, credits
+}
+
+// performers lists the names of main artists, then of featured ones.
+func performers(credits []Credit) []string {
+	names := make([]string, 0, len(credits))
+	for _, role := range []ArtistRole{RoleMain, RoleFeatured} {
+		for _, c := range credits {
+			if c.Role == role {
+				names = append(names, c.Name)
+			}
+		}
+	}
+	return names
+}
+
+// Credited returns the names of the artists credited with role.
+func (t Track) Credited(role ArtistRole) []string {
+	var names []string
+	for _, c := range t.Credits {
+		if c.Role == role {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
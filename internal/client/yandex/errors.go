@@ -0,0 +1,98 @@
+package yandex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Failure classes of API errors; match them with errors.Is.
+var (
+	ErrNotFound             = errors.New("not found")
+	ErrRegionLocked         = errors.New("not available in this region")
+	ErrSubscriptionRequired = errors.New("subscription required")
+	ErrRateLimited          = errors.New("rate limited")
+	// ErrTimeout means Yandex did not answer in time: a request ran past
+	// WithTimeout, the HTTP client's or the transport's timeouts. Deadlines
+	// and cancellations of the caller's context are not classified.
+	ErrTimeout = errors.New("upstream timeout")
+	// ErrNoFormatLeft means every download format of a track was skipped,
+	// see WithoutFormats.
+	ErrNoFormatLeft = errors.New("no download format left")
+)
+
+// APIError is a non-OK response from Yandex Music.
+type APIError struct {
+	// Op names the failed call, e.g. "download-info"; may be empty.
+	Op     string
+	Status int
+	Body   string
+}
+
+func (e *APIError) Error() string {
+	if e.Op == "" {
+		return fmt.Sprintf("status=%d body=%s", e.Status, e.Body)
+	}
+	return fmt.Sprintf("%s failed: status=%d body=%s", e.Op, e.Status, e.Body)
+}
+
+// Unwrap classifies the response by its status. Yandex answers 403 both for
+// tracks licensed elsewhere and for tracks that need Plus; only the former
+// mention the region in the error body.
+func (e *APIError) Unwrap() error {
+	switch e.Status {
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusUnavailableForLegalReasons:
+		return ErrRegionLocked
+	case http.StatusPaymentRequired, http.StatusForbidden:
+		body := strings.ToLower(e.Body)
+		if strings.Contains(body, "region") || strings.Contains(body, "geo") || strings.Contains(body, "country") {
+			return ErrRegionLocked
+		}
+		return ErrSubscriptionRequired
+	}
+	return nil
+}
+
+// statusError turns a non-OK response into an *APIError, reading a bounded
+// part of the body for the log.
+func statusError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	return &APIError{Op: op, Status: resp.StatusCode, Body: string(body)}
+}
+
+// Forbidden reports whether err is a 403 answer. Storage hosts give it for
+// expired or badly signed download URLs, so a fresh URL may succeed.
+func Forbidden(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Status == http.StatusForbidden
+}
+
+// timeoutError is a transport error classified as ErrTimeout.
+type timeoutError struct {
+	err error
+}
+
+func (e timeoutError) Error() string { return e.err.Error() }
+
+func (e timeoutError) Unwrap() []error { return []error{ErrTimeout, e.err} }
+
+// classifyTimeout marks err as ErrTimeout when it is a timeout of the
+// request itself rather than the end of ctx, the caller's context.
+func classifyTimeout(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return timeoutError{err}
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+package yandex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Genre is an entry of the Yandex genre catalog.
+type Genre struct {
+	ID        string
+	Title     string
+	SubGenres []Genre
+}
+
+type genreDTO struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title"`
+	SubGenres []genreDTO `json:"subGenres"`
+	// ShowInMenu is false for technical genres not meant for browsing.
+	ShowInMenu *bool `json:"showInMenu"`
+}
+
+type genresResponse struct {
+	Result []genreDTO `json:"result"`
+}
+
+func mapGenres(in []genreDTO) []Genre {
+	out := make([]Genre, 0, len(in))
+	for _, g := range in {
+		if g.ID == "" || (g.ShowInMenu != nil && !*g.ShowInMenu) {
+			continue
+		}
+		out = append(out, Genre{ID: g.ID, Title: g.Title, SubGenres: mapGenres(g.SubGenres)})
+	}
+	return out
+}
+
+// Genres returns the browsable genre catalog with its subgenres.
+func (c *APIClient) Genres(ctx context.Context) ([]Genre, error) {
+	var payload genresResponse
+	if err := c.getJSON(ctx, c.baseURL+"/genres", &payload); err != nil {
+		return nil, fmt.Errorf("get genres: %w", err)
+	}
+	return mapGenres(payload.Result), nil
+}
+
+// GenreTracks returns popular tracks of a genre, taken from its radio station.
+func (c *APIClient) GenreTracks(ctx context.Context, genreID string) ([]Track, error) {
+	if genreID == "" {
+		return nil, fmt.Errorf("genre id is empty")
+	}
+	var payload stationTracksResponse
+	endpoint := fmt.Sprintf("%s/rotor/station/genre:%s/tracks", c.baseURL, url.PathEscape(genreID))
+	if err := c.getJSON(ctx, endpoint, &payload); err != nil {
+		return nil, fmt.Errorf("get genre tracks: %w", err)
+	}
+	tracks := make([]Track, 0, len(payload.Result.Sequence))
+	for _, s := range payload.Result.Sequence {
+		if s.Track != nil {
+			tracks = append(tracks, mapTrack(*s.Track))
+		}
+	}
+	return tracks, nil
+}
+
+// getJSON performs an authenticated GET and decodes the reply into dst.
+func (c *APIClient) getJSON(ctx context.Context, endpoint string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	c.attachHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError("", resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package yandex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// landingBlocks are the landing blocks that hold editorial playlists.
+const landingBlocks = "new-playlists,playlists"
+
+type landingResponse struct {
+	Result struct {
+		Blocks []struct {
+			Type     string `json:"type"`
+			Entities []struct {
+				Type string          `json:"type"`
+				Data json.RawMessage `json:"data"`
+			} `json:"entities"`
+		} `json:"blocks"`
+	} `json:"result"`
+}
+
+type tagPlaylistsResponse struct {
+	Result struct {
+		Tag struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"tag"`
+		IDs []struct {
+			UID  json.Number `json:"uid"`
+			Kind int         `json:"kind"`
+		} `json:"ids"`
+	} `json:"result"`
+}
+
+// EditorialPlaylists returns the playlists Yandex promotes on its landing
+// page, in landing order without repeats.
+func (c *APIClient) EditorialPlaylists(ctx context.Context) ([]Playlist, error) {
+	var payload landingResponse
+	if err := c.getJSON(ctx, c.baseURL+"/landing3?blocks="+url.QueryEscape(landingBlocks), &payload); err != nil {
+		return nil, fmt.Errorf("get landing: %w", err)
+	}
+	var out []Playlist
+	seen := make(map[string]bool)
+	for _, block := range payload.Result.Blocks {
+		for _, e := range block.Entities {
+			if e.Type != "playlist" {
+				continue
+			}
+			var dto playlistDTO
+			if json.Unmarshal(e.Data, &dto) != nil || dto.Title == "" {
+				continue
+			}
+			p := mapPlaylist(dto)
+			key := p.OwnerUID + ":" + strconv.Itoa(p.Kind)
+			if !seen[key] {
+				seen[key] = true
+				out = append(out, p)
+			}
+		}
+	}
+	return out, nil
+}
+
+// TagPlaylists returns the name of tag, e.g. "workout", and the editorial
+// playlists filed under it.
+func (c *APIClient) TagPlaylists(ctx context.Context, tag string) (string, []Playlist, error) {
+	if tag == "" {
+		return "", nil, fmt.Errorf("tag is empty")
+	}
+	var ids tagPlaylistsResponse
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/tags/%s/playlist-ids", c.baseURL, url.PathEscape(tag)), &ids); err != nil {
+		return "", nil, fmt.Errorf("get tag playlists: %w", err)
+	}
+	name := ids.Result.Tag.Name
+	if len(ids.Result.IDs) == 0 {
+		return name, nil, nil
+	}
+
+	refs := make([]string, 0, len(ids.Result.IDs))
+	for _, id := range ids.Result.IDs {
+		refs = append(refs, id.UID.String()+":"+strconv.Itoa(id.Kind))
+	}
+	form := url.Values{"playlistIds": {strings.Join(refs, ",")}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/playlists/list", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.attachHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, statusError("list playlists", resp)
+	}
+	var payload playlistListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", nil, fmt.Errorf("decode playlists response: %w", err)
+	}
+	out := make([]Playlist, 0, len(payload.Result))
+	for _, p := range payload.Result {
+		out = append(out, mapPlaylist(p))
+	}
+	return name, out, nil
+}
+
+// PublicPlaylistTracks returns playlist kind of any user, given by uid or
+// login, with its tracks in playlist order.
+func (c *APIClient) PublicPlaylistTracks(ctx context.Context, owner string, kind int) (Playlist, []Track, error) {
+	var payload playlistTracksResponse
+	endpoint := fmt.Sprintf("%s/users/%s/playlists/%d?rich-tracks=true", c.baseURL, url.PathEscape(owner), kind)
+	if err := c.getJSON(ctx, endpoint, &payload); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return Playlist{}, nil, ErrPlaylistNotFound
+		}
+		return Playlist{}, nil, fmt.Errorf("get playlist: %w", err)
+	}
+	pl, tracks := payload.mapped()
+	return pl, tracks, nil
+}
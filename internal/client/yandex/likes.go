@@ -0,0 +1,116 @@
+package yandex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type accountStatusResponse struct {
+	Result struct {
+		Account struct {
+			UID json.Number `json:"uid"`
+		} `json:"account"`
+	} `json:"result"`
+}
+
+// LikeTracks adds tracks to the liked list of the account that owns the token.
+func (c *APIClient) LikeTracks(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if c.token == "" {
+		return fmt.Errorf("liking tracks requires an OAuth token")
+	}
+
+	uid, err := c.accountUID(ctx)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{"track-ids": {strings.Join(ids, ",")}}
+	endpoint := fmt.Sprintf("%s/users/%s/likes/tracks/add-multiple", c.baseURL, url.PathEscape(uid))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.attachHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError("like tracks", resp)
+	}
+	return nil
+}
+
+type likedTracksResponse struct {
+	Result struct {
+		Library struct {
+			Tracks []struct {
+				ID json.Number `json:"id"`
+			} `json:"tracks"`
+		} `json:"library"`
+	} `json:"result"`
+}
+
+// LikedTrackIDs lists the tracks liked by the account that owns the token,
+// most recent first.
+func (c *APIClient) LikedTrackIDs(ctx context.Context) ([]string, error) {
+	if c.token == "" {
+		return nil, fmt.Errorf("liked tracks require an OAuth token")
+	}
+
+	uid, err := c.accountUID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload likedTracksResponse
+	endpoint := fmt.Sprintf("%s/users/%s/likes/tracks", c.baseURL, url.PathEscape(uid))
+	if err := c.getJSON(ctx, endpoint, &payload); err != nil {
+		return nil, fmt.Errorf("get liked tracks: %w", err)
+	}
+	ids := make([]string, 0, len(payload.Result.Library.Tracks))
+	for _, t := range payload.Result.Library.Tracks {
+		ids = append(ids, t.ID.String())
+	}
+	return ids, nil
+}
+
+// accountUID resolves the user id behind the OAuth token.
+func (c *APIClient) accountUID(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/account/status", nil)
+	if err != nil {
+		return "", err
+	}
+	c.attachHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError("account status", resp)
+	}
+
+	var payload accountStatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("decode account status: %w", err)
+	}
+	uid := payload.Result.Account.UID.String()
+	if uid == "" {
+		return "", fmt.Errorf("account status: no uid (token lacks account access?)")
+	}
+	return uid, nil
+}
@@ -0,0 +1,180 @@
+package yandex
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lyricsSignKey is the key the official apps sign lyrics requests with.
+const lyricsSignKey = "p93jhgh689SBReK6ghtw62"
+
+// maxLyricsSize bounds the lyrics file read from storage.
+const maxLyricsSize = 256 << 10
+
+// Lyrics are the words of a track.
+type Lyrics struct {
+	// Text is the plain text, one line per sung line.
+	Text string
+	// LRC is the text with [mm:ss.xx] timings; empty when Yandex only has
+	// the plain text.
+	LRC string
+	// Writers are the credited lyricists.
+	Writers []string
+}
+
+// Synced reports whether the lyrics come with timings.
+func (l Lyrics) Synced() bool { return l.LRC != "" }
+
+// LyricLine is a line of synced lyrics and when it is sung.
+type LyricLine struct {
+	At   time.Duration
+	Text string
+}
+
+type lyricsDTO struct {
+	DownloadURL string   `json:"downloadUrl"`
+	Writers     []string `json:"writers"`
+}
+
+type lyricsResponse struct {
+	Result lyricsDTO `json:"result"`
+}
+
+type lyricsInfoDTO struct {
+	HasAvailableSyncLyrics bool `json:"hasAvailableSyncLyrics"`
+	HasAvailableTextLyrics bool `json:"hasAvailableTextLyrics"`
+}
+
+// lrcTimestamp matches the timing tags of an LRC line.
+var lrcTimestamp = regexp.MustCompile(`\[(\d+):(\d+)(?:[.:](\d+))?\]`)
+
+// lrcTag matches LRC header lines such as "[ar:Artist]".
+var lrcTag = regexp.MustCompile(`^\[[a-z]+:.*\]$`)
+
+// Lyrics returns the words of a track, timed when synced is set and Yandex
+// has them so; ask for synced lyrics only when Track.HasSyncedLyrics. A
+// track without lyrics is ErrNotFound.
+func (c *APIClient) Lyrics(ctx context.Context, trackID string, synced bool) (Lyrics, error) {
+	if trackID == "" {
+		return Lyrics{}, fmt.Errorf("track id is empty")
+	}
+	format := "TEXT"
+	if synced {
+		format = "LRC"
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	params := url.Values{}
+	params.Set("format", format)
+	params.Set("timeStamp", ts)
+	params.Set("sign", signLyrics(trackID, ts))
+
+	var payload lyricsResponse
+	endpoint := fmt.Sprintf("%s/tracks/%s/lyrics?%s", c.baseURL, url.PathEscape(trackID), params.Encode())
+	if err := c.getJSON(ctx, endpoint, &payload); err != nil {
+		return Lyrics{}, fmt.Errorf("get lyrics: %w", err)
+	}
+	if payload.Result.DownloadURL == "" {
+		return Lyrics{}, fmt.Errorf("get lyrics: %w", ErrNotFound)
+	}
+	text, err := c.fetchLyrics(ctx, payload.Result.DownloadURL)
+	if err != nil {
+		return Lyrics{}, fmt.Errorf("get lyrics: %w", err)
+	}
+
+	out := Lyrics{Text: text, Writers: payload.Result.Writers}
+	if synced {
+		out.LRC, out.Text = text, PlainLyrics(text)
+	}
+	return out, nil
+}
+
+// fetchLyrics downloads the lyrics file. The URL is presigned storage, so
+// the account's credentials are not sent along.
+func (c *APIClient) fetchLyrics(ctx context.Context, downloadURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError("lyrics file", resp)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxLyricsSize))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(strings.ReplaceAll(string(body), "\r\n", "\n")), nil
+}
+
+// signLyrics signs a lyrics request the way the official apps do.
+func signLyrics(trackID, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(lyricsSignKey))
+	mac.Write([]byte(trackID + timestamp))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// PlainLyrics strips the timings and header tags off LRC text.
+func PlainLyrics(lrc string) string {
+	var lines []string
+	for _, line := range strings.Split(lrc, "\n") {
+		line = strings.TrimSpace(line)
+		if lrcTag.MatchString(line) {
+			continue
+		}
+		lines = append(lines, strings.TrimSpace(lrcTimestamp.ReplaceAllString(line, "")))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// ParseLRC returns the timed lines of LRC text ordered by time. A line with
+// several timings, as choruses are often written, is repeated at each; lines
+// without timings are left out.
+func ParseLRC(lrc string) []LyricLine {
+	var out []LyricLine
+	for _, line := range strings.Split(lrc, "\n") {
+		line = strings.TrimSpace(line)
+		var times []time.Duration
+		for {
+			m := lrcTimestamp.FindStringSubmatchIndex(line)
+			if m == nil || m[0] != 0 {
+				break
+			}
+			mins, _ := strconv.Atoi(line[m[2]:m[3]])
+			secs, _ := strconv.Atoi(line[m[4]:m[5]])
+			at := time.Duration(mins)*time.Minute + time.Duration(secs)*time.Second
+			if m[6] >= 0 {
+				// Hundredths usually, milliseconds in some files.
+				frac := line[m[6]:m[7]]
+				n, _ := strconv.Atoi(frac)
+				scale := time.Second
+				for range frac {
+					scale /= 10
+				}
+				at += time.Duration(n) * scale
+			}
+			times = append(times, at)
+			line = strings.TrimSpace(line[m[1]:])
+		}
+		for _, at := range times {
+			out = append(out, LyricLine{At: at, Text: line})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At < out[j].At })
+	return out
+}
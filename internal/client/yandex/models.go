@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"encoding/json"
 )
@@ -13,32 +14,80 @@ type searchResponse struct {
 }
 
 type searchResult struct {
-	Tracks trackMatches `json:"tracks"`
+	Tracks            trackMatches    `json:"tracks"`
+	Videos            videoMatches    `json:"videos"`
+	Albums            albumMatches    `json:"albums"`
+	Artists           artistMatches   `json:"artists"`
+	Playlists         playlistMatches `json:"playlists"`
+	Best              *bestDTO        `json:"best"`
+	MisspellResult    string          `json:"misspellResult"`
+	MisspellCorrected bool            `json:"misspellCorrected"`
 }
 
 type trackMatches struct {
 	Results []trackDTO `json:"results"`
 }
 
+type videoMatches struct {
+	Results []videoDTO `json:"results"`
+}
+
+type videoDTO struct {
+	Title           string `json:"title"`
+	Duration        int    `json:"duration"`
+	ThumbnailURL    string `json:"thumbnailUrl"`
+	EmbedURL        string `json:"embedUrl"`
+	YoutubeURL      string `json:"youtubeUrl"`
+	Provider        string `json:"provider"`
+	ProviderVideoID string `json:"providerVideoId"`
+}
+
 type trackResponse struct {
 	Result []trackDTO `json:"result"`
 }
 
 type trackDTO struct {
-	ID         json.Number   `json:"id"`
-	Title      string        `json:"title"`
-	DurationMs int           `json:"durationMs"`
-	Artists    []artistDTO   `json:"artists"`
-	Albums     albumListDTO  `json:"albums"`
-	CoverURI   string        `json:"coverUri"`
-	StorageDir string        `json:"storageDir"`
-	RealID     string        `json:"realId"`
-	TrackShare string        `json:"trackShareUrl"`
-	Type       string        `json:"type"`
+	ID         json.Number  `json:"id"`
+	Title      string       `json:"title"`
+	Version    string       `json:"version"`
+	DurationMs int          `json:"durationMs"`
+	Artists    []artistDTO  `json:"artists"`
+	Albums     albumListDTO `json:"albums"`
+	CoverURI   string       `json:"coverUri"`
+	StorageDir string       `json:"storageDir"`
+	RealID     string       `json:"realId"`
+	TrackShare string       `json:"trackShareUrl"`
+	Type       string       `json:"type"`
+	// Available is false when the account cannot play the track; absent in
+	// some responses, hence the pointer.
+	Available                *bool `json:"available"`
+	AvailableForPremiumUsers bool  `json:"availableForPremiumUsers"`
+	// ContentWarning is "explicit" for tracks with explicit lyrics.
+	ContentWarning string `json:"contentWarning"`
+	// PubDate is set on podcast episodes only.
+	PubDate    string        `json:"pubDate"`
+	LyricsInfo lyricsInfoDTO `json:"lyricsInfo"`
+}
+
+// availability derives the restriction from the flags: an unavailable track
+// that Plus subscribers may play needs a subscription, any other is locked.
+func (t trackDTO) availability() Availability {
+	switch {
+	case t.Available == nil || *t.Available:
+		return Available
+	case t.AvailableForPremiumUsers:
+		return PremiumOnly
+	}
+	return RegionLocked
 }
 
 type artistDTO struct {
-	Name string `json:"name"`
+	ID       json.Number `json:"id"`
+	Name     string      `json:"name"`
+	Composer bool        `json:"composer"`
+	// Decomposed splits a joint credit such as "A feat. B" into joiners and
+	// the artists they join: [" feat. ", {artist B}, ...].
+	Decomposed []json.RawMessage `json:"decomposed"`
 }
 
 type albumListDTO []albumDTO
@@ -50,8 +99,58 @@ func (a albumListDTO) Title() string {
 	return a[0].Title
 }
 
+func (a albumListDTO) ID() string {
+	if len(a) == 0 {
+		return ""
+	}
+	return a[0].ID.String()
+}
+
+// first returns the album the track is listed on, zero when there is none.
+func (a albumListDTO) first() albumDTO {
+	if len(a) == 0 {
+		return albumDTO{}
+	}
+	return a[0]
+}
+
+// releaseDate parses ReleaseDate, zero when it is absent or malformed.
+func (a albumDTO) releaseDate() time.Time {
+	return parseDate(a.ReleaseDate)
+}
+
+// parseDate reads an RFC 3339 date, zero when absent or malformed.
+func parseDate(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func (a albumListDTO) Genre() string {
+	if len(a) == 0 {
+		return ""
+	}
+	return a[0].Genre
+}
+
 type albumDTO struct {
-	Title string `json:"title"`
+	ID    json.Number `json:"id"`
+	Title string      `json:"title"`
+	Genre string      `json:"genre"`
+	Year  int         `json:"year"`
+	// Type is "compilation" for collections, "single", "podcast" and so on;
+	// empty for a regular album.
+	Type        string `json:"type"`
+	LikesCount  int    `json:"likesCount"`
+	ReleaseDate string `json:"releaseDate"` // RFC 3339, often absent
+
+	// TrackPosition places the track on the album: disc and number on it.
+	TrackPosition struct {
+		Volume int `json:"volume"`
+		Index  int `json:"index"`
+	} `json:"trackPosition"`
 }
 
 type downloadInfoResponse struct {
@@ -59,8 +158,8 @@ type downloadInfoResponse struct {
 }
 
 type downloadInfoDTO struct {
-	URL    string `json:"downloadInfoUrl"`
-	Codec  string `json:"codec"`
+	URL     string `json:"downloadInfoUrl"`
+	Codec   string `json:"codec"`
 	Bitrate int    `json:"bitrateInKbps"`
 }
 
@@ -79,4 +178,3 @@ func createFile(path string) (*os.File, error) {
 	}
 	return os.Create(path) //nolint:gosec // destination controlled internally
 }
-
@@ -0,0 +1,201 @@
+package yandex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrPlaylistNotFound means the playlist was deleted or never existed.
+var ErrPlaylistNotFound = errors.New("playlist not found")
+
+// Playlist is a playlist of the account behind the token or, in search
+// results and editorial picks, of any user.
+type Playlist struct {
+	Kind       int
+	Title      string
+	TrackCount int
+	Revision   int
+	// Owner is the login of the owner and OwnerName their display name;
+	// OwnerUID is their numeric id, the shorter key for callbacks.
+	Owner     string
+	OwnerName string
+	OwnerUID  string
+}
+
+// URL returns the web page of the playlist, "" when the owner is unknown.
+func (p Playlist) URL() string {
+	if p.Owner == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://music.yandex.ru/users/%s/playlists/%d", url.PathEscape(p.Owner), p.Kind)
+}
+
+type playlistDTO struct {
+	Kind       int    `json:"kind"`
+	Title      string `json:"title"`
+	TrackCount int    `json:"trackCount"`
+	Revision   int    `json:"revision"`
+	Owner      struct {
+		UID   json.Number `json:"uid"`
+		Login string      `json:"login"`
+		Name  string      `json:"name"`
+	} `json:"owner"`
+}
+
+type playlistResponse struct {
+	Result playlistDTO `json:"result"`
+}
+
+type playlistListResponse struct {
+	Result []playlistDTO `json:"result"`
+}
+
+type playlistTracksResponse struct {
+	Result struct {
+		playlistDTO
+		Tracks []struct {
+			Track *trackDTO `json:"track"`
+		} `json:"tracks"`
+	} `json:"result"`
+}
+
+func mapPlaylist(p playlistDTO) Playlist {
+	return Playlist{
+		Kind: p.Kind, Title: p.Title, TrackCount: p.TrackCount, Revision: p.Revision,
+		Owner: p.Owner.Login, OwnerName: p.Owner.Name, OwnerUID: p.Owner.UID.String(),
+	}
+}
+
+// ListUserPlaylists returns the playlists of the account behind the token.
+func (c *APIClient) ListUserPlaylists(ctx context.Context) ([]Playlist, error) {
+	var payload playlistListResponse
+	if err := c.playlistRequest(ctx, http.MethodGet, "list", nil, &payload); err != nil {
+		return nil, fmt.Errorf("list playlists: %w", err)
+	}
+	out := make([]Playlist, 0, len(payload.Result))
+	for _, p := range payload.Result {
+		out = append(out, mapPlaylist(p))
+	}
+	return out, nil
+}
+
+// PlaylistTracks returns playlist kind with its tracks in playlist order.
+// Tracks Yandex no longer serves are skipped.
+func (c *APIClient) PlaylistTracks(ctx context.Context, kind int) (Playlist, []Track, error) {
+	var payload playlistTracksResponse
+	if err := c.playlistRequest(ctx, http.MethodGet, strconv.Itoa(kind)+"?rich-tracks=true", nil, &payload); err != nil {
+		return Playlist{}, nil, fmt.Errorf("get playlist: %w", err)
+	}
+	pl, tracks := payload.mapped()
+	return pl, tracks, nil
+}
+
+// mapped converts the reply, skipping tracks Yandex no longer serves.
+func (r playlistTracksResponse) mapped() (Playlist, []Track) {
+	tracks := make([]Track, 0, len(r.Result.Tracks))
+	for _, t := range r.Result.Tracks {
+		if t.Track != nil {
+			tracks = append(tracks, mapTrack(*t.Track))
+		}
+	}
+	return mapPlaylist(r.Result.playlistDTO), tracks
+}
+
+// CreatePlaylist creates an empty private playlist named title.
+func (c *APIClient) CreatePlaylist(ctx context.Context, title string) (Playlist, error) {
+	if strings.TrimSpace(title) == "" {
+		return Playlist{}, fmt.Errorf("playlist title is empty")
+	}
+	form := url.Values{"title": {title}, "visibility": {"private"}}
+
+	var payload playlistResponse
+	if err := c.playlistRequest(ctx, http.MethodPost, "create", form, &payload); err != nil {
+		return Playlist{}, fmt.Errorf("create playlist: %w", err)
+	}
+	return mapPlaylist(payload.Result), nil
+}
+
+// AddTracksToPlaylist appends tracks to the end of playlist kind and returns
+// the updated playlist. Tracks need an AlbumID; Yandex rejects them otherwise.
+func (c *APIClient) AddTracksToPlaylist(ctx context.Context, kind int, tracks []Track) (Playlist, error) {
+	if len(tracks) == 0 {
+		return Playlist{}, fmt.Errorf("no tracks to add")
+	}
+
+	// The change is applied against a revision, so fetch the current one first.
+	var current playlistResponse
+	if err := c.playlistRequest(ctx, http.MethodGet, strconv.Itoa(kind), nil, &current); err != nil {
+		return Playlist{}, fmt.Errorf("get playlist: %w", err)
+	}
+
+	refs := make([]map[string]string, 0, len(tracks))
+	for _, t := range tracks {
+		if t.AlbumID == "" {
+			return Playlist{}, fmt.Errorf("track %s has no album id", t.ID)
+		}
+		refs = append(refs, map[string]string{"id": t.ID, "albumId": t.AlbumID})
+	}
+	diff, err := json.Marshal([]map[string]any{{"op": "insert", "at": current.Result.TrackCount, "tracks": refs}})
+	if err != nil {
+		return Playlist{}, err
+	}
+	form := url.Values{
+		"diff":     {string(diff)},
+		"revision": {strconv.Itoa(current.Result.Revision)},
+	}
+
+	var payload playlistResponse
+	if err := c.playlistRequest(ctx, http.MethodPost, strconv.Itoa(kind)+"/change-relative", form, &payload); err != nil {
+		return Playlist{}, fmt.Errorf("add tracks to playlist: %w", err)
+	}
+	return mapPlaylist(payload.Result), nil
+}
+
+// playlistRequest calls /users/{uid}/playlists/{path} and decodes the reply into dst.
+func (c *APIClient) playlistRequest(ctx context.Context, method, path string, form url.Values, dst any) error {
+	if c.token == "" {
+		return fmt.Errorf("playlists require an OAuth token")
+	}
+	uid, err := c.accountUID(ctx)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/users/%s/playlists/%s", c.baseURL, url.PathEscape(uid), path)
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	c.attachHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrPlaylistNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return statusError("", resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	return nil
+}
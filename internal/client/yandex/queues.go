@@ -0,0 +1,121 @@
+package yandex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// ErrNothingPlaying means the account has no play queue with a current track.
+var ErrNothingPlaying = errors.New("nothing is playing")
+
+// queueDevice identifies the bot to the queues API, which requires a device header.
+const queueDevice = "os=Linux; os_version=; manufacturer=ym-bot; model=ym-bot; clid=; device_id=ym-bot; uuid=ym-bot"
+
+// Queue is a play queue of the account, as synced between its devices.
+type Queue struct {
+	ID           string
+	TrackIDs     []string
+	CurrentIndex int
+	// Context describes where playback started, e.g. a playlist or album title.
+	Context  string
+	Modified time.Time
+}
+
+// Current returns the id of the track the queue is on.
+func (q Queue) Current() (string, bool) {
+	if q.CurrentIndex < 0 || q.CurrentIndex >= len(q.TrackIDs) {
+		return "", false
+	}
+	return q.TrackIDs[q.CurrentIndex], true
+}
+
+type queueContextDTO struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+type queuesListResponse struct {
+	Result struct {
+		Queues []struct {
+			ID       string    `json:"id"`
+			Modified time.Time `json:"modified"`
+		} `json:"queues"`
+	} `json:"result"`
+}
+
+type queueResponse struct {
+	Result struct {
+		ID     string `json:"id"`
+		Tracks []struct {
+			TrackID json.Number `json:"trackId"`
+		} `json:"tracks"`
+		CurrentIndex *int            `json:"currentIndex"`
+		Context      queueContextDTO `json:"context"`
+		Modified     time.Time       `json:"modified"`
+	} `json:"result"`
+}
+
+// CurrentQueue returns the most recently modified play queue of the account
+// behind the token, or ErrNothingPlaying when there is none.
+func (c *APIClient) CurrentQueue(ctx context.Context) (Queue, error) {
+	if c.token == "" {
+		return Queue{}, fmt.Errorf("play queues require an OAuth token")
+	}
+
+	var list queuesListResponse
+	if err := c.getQueueJSON(ctx, c.baseURL+"/queues", &list); err != nil {
+		return Queue{}, fmt.Errorf("list queues: %w", err)
+	}
+	queues := list.Result.Queues
+	if len(queues) == 0 {
+		return Queue{}, ErrNothingPlaying
+	}
+	sort.SliceStable(queues, func(i, j int) bool { return queues[i].Modified.After(queues[j].Modified) })
+
+	var payload queueResponse
+	if err := c.getQueueJSON(ctx, c.baseURL+"/queues/"+url.PathEscape(queues[0].ID), &payload); err != nil {
+		return Queue{}, fmt.Errorf("get queue: %w", err)
+	}
+
+	r := payload.Result
+	q := Queue{ID: r.ID, CurrentIndex: -1, Context: r.Context.Description, Modified: r.Modified}
+	for _, t := range r.Tracks {
+		q.TrackIDs = append(q.TrackIDs, t.TrackID.String())
+	}
+	if r.CurrentIndex != nil {
+		q.CurrentIndex = *r.CurrentIndex
+	}
+	if _, ok := q.Current(); !ok {
+		return q, ErrNothingPlaying
+	}
+	return q, nil
+}
+
+func (c *APIClient) getQueueJSON(ctx context.Context, endpoint string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	c.attachHeaders(req)
+	req.Header.Set("X-Yandex-Music-Device", queueDevice)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError("", resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package yandex
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// recorderCapacity is how many exchanges the ring buffer keeps.
+	recorderCapacity = 200
+	// recorderBodyLimit is how much of each body an exchange kept in memory
+	// keeps.
+	recorderBodyLimit = 4 << 10
+	// recorderSinkBodyLimit is how much of each body an exchange written to
+	// the sink keeps: enough for whole API responses, so that a recording
+	// replays them.
+	recorderSinkBodyLimit = 1 << 20
+	redacted              = "[redacted]"
+)
+
+// sensitiveQuery are query parameters whose values never get recorded.
+var sensitiveQuery = []string{"token", "access_token", "oauth_token", "sign", "key", "X-Amz-Signature"}
+
+// signedPathPrefix starts the path of a signed download URL, whose next
+// segment is the signature: /get-mp3/<sign>/<ts>/<path>.
+const signedPathPrefix = "/get-mp3/"
+
+// Exchange is a recorded request and its response, with credentials redacted
+// and bodies cut to a few kilobytes in memory and to a megabyte in the sink.
+type Exchange struct {
+	At              time.Time     `json:"at"`
+	Duration        time.Duration `json:"duration"`
+	Method          string        `json:"method"`
+	URL             string        `json:"url"`
+	RequestHeaders  http.Header   `json:"requestHeaders,omitempty"`
+	RequestBody     string        `json:"requestBody,omitempty"`
+	Status          int           `json:"status,omitempty"`
+	ResponseHeaders http.Header   `json:"responseHeaders,omitempty"`
+	ResponseBody    string        `json:"responseBody,omitempty"`
+	Truncated       bool          `json:"truncated,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+// Recorder is an HTTPClient that keeps sanitized copies of the exchanges it
+// makes while enabled: the latest ones in memory, and all of them in an
+// optional sink as JSON lines. It exists to diagnose API contract drift,
+// such as a new download info format, without a packet capture.
+type Recorder struct {
+	inner HTTPClient
+
+	mu      sync.Mutex
+	enabled bool
+	ring    []Exchange
+	next    int
+	full    bool
+	sink    io.Writer
+}
+
+// NewRecorder wraps inner; exchanges are also written to sink when it is not nil.
+func NewRecorder(inner HTTPClient, enabled bool, sink io.Writer) *Recorder {
+	if inner == nil {
+		inner = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &Recorder{inner: inner, enabled: enabled, sink: sink, ring: make([]Exchange, recorderCapacity)}
+}
+
+// SetEnabled turns recording on or off; requests pass through either way.
+func (r *Recorder) SetEnabled(enabled bool) {
+	r.mu.Lock()
+	r.enabled = enabled
+	r.mu.Unlock()
+}
+
+// Enabled reports whether exchanges are being recorded.
+func (r *Recorder) Enabled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.enabled
+}
+
+// Recent returns up to n latest exchanges, newest first.
+func (r *Recorder) Recent(n int) []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	size := r.next
+	if r.full {
+		size = len(r.ring)
+	}
+	n = min(n, size)
+	out := make([]Exchange, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, r.ring[(r.next-i+len(r.ring))%len(r.ring)])
+	}
+	return out
+}
+
+// Do performs req and records the exchange when recording is on.
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	if !r.Enabled() {
+		return r.inner.Do(req)
+	}
+
+	ex := Exchange{
+		At:             time.Now(),
+		Method:         req.Method,
+		URL:            redactURL(req.URL),
+		RequestHeaders: redactHeaders(req.Header),
+	}
+	limit := r.bodyLimit()
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			ex.RequestBody, ex.Truncated = readPrefix(body, limit)
+			body.Close()
+		}
+	}
+
+	resp, err := r.inner.Do(req)
+	ex.Duration = time.Since(ex.At)
+	if err != nil {
+		ex.Error = err.Error()
+		r.record(ex)
+		return resp, err
+	}
+
+	ex.Status = resp.StatusCode
+	ex.ResponseHeaders = redactHeaders(resp.Header)
+	if textual(resp.Header.Get("Content-Type")) {
+		// The caller still reads the whole body: the recorded prefix is
+		// put back in front of the rest.
+		prefix, readErr := io.ReadAll(io.LimitReader(resp.Body, int64(limit)+1))
+		var rest io.Reader = resp.Body
+		if readErr != nil {
+			// The caller gets the failure, e.g. a timeout, where it happened.
+			rest = errReader{readErr}
+		}
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(prefix), rest), resp.Body}
+		truncated := len(prefix) > limit
+		ex.ResponseBody = string(prefix[:min(len(prefix), limit)])
+		ex.Truncated = ex.Truncated || truncated
+	} else if resp.ContentLength != 0 {
+		ex.ResponseBody = "[binary body omitted]"
+	}
+	r.record(ex)
+	return resp, nil
+}
+
+// errReader fails every read with err.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// bodyLimit is how much of a body Do reads for the record: the sink's
+// larger limit when there is a sink.
+func (r *Recorder) bodyLimit() int {
+	if r.sink != nil {
+		return recorderSinkBodyLimit
+	}
+	return recorderBodyLimit
+}
+
+// record writes ex to the sink as is and keeps it in memory with its bodies
+// cut to recorderBodyLimit.
+func (r *Recorder) record(ex Exchange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.sink != nil {
+		if line, err := json.Marshal(ex); err == nil {
+			_, _ = r.sink.Write(append(line, '\n'))
+		}
+	}
+	var cut bool
+	ex.RequestBody, cut = truncateBody(ex.RequestBody, recorderBodyLimit)
+	ex.Truncated = ex.Truncated || cut
+	ex.ResponseBody, cut = truncateBody(ex.ResponseBody, recorderBodyLimit)
+	ex.Truncated = ex.Truncated || cut
+	r.ring[r.next] = ex
+	r.next = (r.next + 1) % len(r.ring)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// readPrefix reads at most limit bytes of body, reporting whether there was
+// more.
+func readPrefix(body io.Reader, limit int) (string, bool) {
+	prefix, _ := io.ReadAll(io.LimitReader(body, int64(limit)+1))
+	return truncateBody(string(prefix), limit)
+}
+
+// truncateBody cuts body to limit bytes, reporting whether it did.
+func truncateBody(body string, limit int) (string, bool) {
+	if len(body) > limit {
+		return body[:limit], true
+	}
+	return body, false
+}
+
+// textual reports whether a body of contentType is worth recording; audio
+// and images are not.
+func textual(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return contentType == ""
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+func redactURL(u *url.URL) string {
+	clone := *u
+	clone.User = nil
+	if rest, ok := strings.CutPrefix(clone.Path, signedPathPrefix); ok {
+		if _, tail, found := strings.Cut(rest, "/"); found {
+			clone.Path = signedPathPrefix + redacted + "/" + tail
+			// Keeps the brackets readable; String escapes it instead when
+			// the path needs escaping anyway.
+			clone.RawPath = clone.Path
+		}
+	}
+	q := clone.Query()
+	changed := false
+	for _, key := range sensitiveQuery {
+		if q.Has(key) {
+			q.Set(key, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		clone.RawQuery = q.Encode()
+	}
+	return clone.String()
+}
+
+// redactHeaders copies h with credentials replaced, including custom
+// gateway headers that look like them.
+func redactHeaders(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		lower := strings.ToLower(name)
+		if lower == "cookie" || lower == "set-cookie" || strings.Contains(lower, "auth") ||
+			strings.Contains(lower, "token") || strings.Contains(lower, "secret") || strings.Contains(lower, "key") {
+			out[name] = []string{redacted}
+			continue
+		}
+		out[name] = append([]string(nil), values...)
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+package yandex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// MyWave is the personal station ("Моя волна") of the account behind the token.
+const MyWave = "user:onyourwave"
+
+// Station feedback events, see StationFeedback.
+const (
+	FeedbackRadioStarted  = "radioStarted"
+	FeedbackTrackStarted  = "trackStarted"
+	FeedbackTrackFinished = "trackFinished"
+	FeedbackSkip          = "skip"
+)
+
+// feedbackSource tells the rotor where playback happens.
+const feedbackSource = "ym-bot"
+
+// StationBatch is a portion of tracks a station recommends; feedback on them
+// refers to the batch.
+type StationBatch struct {
+	BatchID string
+	Tracks  []Track
+}
+
+// StationFeedback is one playback event reported to a station, so that its
+// next batches follow what was listened to and what was skipped.
+type StationFeedback struct {
+	Type    string // one of the Feedback constants
+	BatchID string
+	// Track is empty for FeedbackRadioStarted.
+	Track Track
+	// Played is how long the track was listened to, for finished and skipped tracks.
+	Played time.Duration
+}
+
+type stationTracksResponse struct {
+	Result struct {
+		BatchID  string `json:"batchId"`
+		Sequence []struct {
+			Track *trackDTO `json:"track"`
+		} `json:"sequence"`
+	} `json:"result"`
+}
+
+// StationTracks returns the next batch of station; lastTrackID, the track
+// played last, lets the rotor continue from it and may be empty at the start.
+func (c *APIClient) StationTracks(ctx context.Context, station, lastTrackID string) (StationBatch, error) {
+	if c.token == "" {
+		return StationBatch{}, fmt.Errorf("stations require an OAuth token")
+	}
+
+	q := url.Values{"settings2": {"true"}}
+	if lastTrackID != "" {
+		q.Set("queue", lastTrackID)
+	}
+	var payload stationTracksResponse
+	endpoint := fmt.Sprintf("%s/rotor/station/%s/tracks?%s", c.baseURL, url.PathEscape(station), q.Encode())
+	if err := c.getJSON(ctx, endpoint, &payload); err != nil {
+		return StationBatch{}, fmt.Errorf("get station tracks: %w", err)
+	}
+	batch := StationBatch{BatchID: payload.Result.BatchID}
+	for _, s := range payload.Result.Sequence {
+		if s.Track != nil {
+			batch.Tracks = append(batch.Tracks, mapTrack(*s.Track))
+		}
+	}
+	return batch, nil
+}
+
+// StationFeedback reports a playback event to station.
+func (c *APIClient) StationFeedback(ctx context.Context, station string, f StationFeedback) error {
+	if c.token == "" {
+		return fmt.Errorf("stations require an OAuth token")
+	}
+
+	body := map[string]any{
+		"type":      f.Type,
+		"timestamp": time.Now().Format(time.RFC3339),
+		"from":      feedbackSource,
+	}
+	if f.Track.ID != "" {
+		trackID := f.Track.ID
+		if f.Track.AlbumID != "" {
+			trackID += ":" + f.Track.AlbumID
+		}
+		body["trackId"] = trackID
+	}
+	if f.Type == FeedbackTrackFinished || f.Type == FeedbackSkip {
+		body["totalPlayedSeconds"] = f.Played.Seconds()
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/rotor/station/%s/feedback", c.baseURL, url.PathEscape(station))
+	if f.BatchID != "" {
+		endpoint += "?" + url.Values{"batch-id": {f.BatchID}}.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	c.attachHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError("station feedback", resp)
+	}
+	return nil
+}
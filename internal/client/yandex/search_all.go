@@ -0,0 +1,148 @@
+package yandex
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Result types of a combined search, see SearchAll.
+const (
+	ResultTrack    = "track"
+	ResultAlbum    = "album"
+	ResultArtist   = "artist"
+	ResultPlaylist = "playlist"
+)
+
+// Artist is an artist found by search.
+type Artist struct {
+	ID   string
+	Name string
+}
+
+// BestMatch is the result Yandex ranks first across all types; only the
+// field matching Type is set.
+type BestMatch struct {
+	Type     string // one of the Result constants, "" when there is none
+	Track    Track
+	Album    Album
+	Artist   Artist
+	Playlist Playlist
+}
+
+// Combined is the first page of every result type for one query.
+type Combined struct {
+	Best      BestMatch
+	Tracks    []Track
+	Albums    []Album // without tracks
+	Artists   []Artist
+	Playlists []Playlist
+	// Correction and Corrected are as in SearchResult.
+	Correction string
+	Corrected  bool
+}
+
+// Empty reports whether nothing at all was found.
+func (c Combined) Empty() bool {
+	return len(c.Tracks) == 0 && len(c.Albums) == 0 && len(c.Artists) == 0 && len(c.Playlists) == 0
+}
+
+type bestDTO struct {
+	Type   string          `json:"type"`
+	Result json.RawMessage `json:"result"`
+}
+
+type albumMatches struct {
+	Results []searchAlbumDTO `json:"results"`
+}
+
+type searchAlbumDTO struct {
+	ID      json.Number `json:"id"`
+	Title   string      `json:"title"`
+	Year    int         `json:"year"`
+	Artists []artistDTO `json:"artists"`
+}
+
+type artistMatches struct {
+	Results []artistDTO `json:"results"`
+}
+
+type playlistMatches struct {
+	Results []playlistDTO `json:"results"`
+}
+
+// SearchAll queries every result type at once (type=all), keeping at most
+// limit results of each.
+func (c *APIClient) SearchAll(ctx context.Context, query string, limit int) (Combined, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	result, err := c.search(ctx, query, "all", limit, 0)
+	if err != nil {
+		return Combined{}, err
+	}
+
+	out := Combined{Correction: result.MisspellResult, Corrected: result.MisspellCorrected}
+	for _, t := range result.Tracks.Results[:min(limit, len(result.Tracks.Results))] {
+		out.Tracks = append(out.Tracks, mapTrack(t))
+	}
+	for _, a := range result.Albums.Results[:min(limit, len(result.Albums.Results))] {
+		out.Albums = append(out.Albums, mapSearchAlbum(a))
+	}
+	for _, a := range result.Artists.Results[:min(limit, len(result.Artists.Results))] {
+		out.Artists = append(out.Artists, Artist{ID: a.ID.String(), Name: a.Name})
+	}
+	for _, p := range result.Playlists.Results[:min(limit, len(result.Playlists.Results))] {
+		out.Playlists = append(out.Playlists, mapPlaylist(p))
+	}
+	out.Best = mapBest(result.Best)
+	return out, nil
+}
+
+func mapSearchAlbum(a searchAlbumDTO) Album {
+	album := Album{ID: a.ID.String(), Title: a.Title, Year: a.Year}
+	for _, artist := range a.Artists {
+		if artist.Name != "" {
+			album.Artists = append(album.Artists, artist.Name)
+		}
+	}
+	return album
+}
+
+// mapBest decodes the best match by its type; unknown types and malformed
+// results leave it empty rather than failing the whole search.
+func mapBest(b *bestDTO) BestMatch {
+	if b == nil || len(b.Result) == 0 {
+		return BestMatch{}
+	}
+	var best BestMatch
+	switch b.Type {
+	case ResultTrack:
+		var t trackDTO
+		if json.Unmarshal(b.Result, &t) != nil {
+			return BestMatch{}
+		}
+		best.Track = mapTrack(t)
+	case ResultAlbum:
+		var a searchAlbumDTO
+		if json.Unmarshal(b.Result, &a) != nil {
+			return BestMatch{}
+		}
+		best.Album = mapSearchAlbum(a)
+	case ResultArtist:
+		var a artistDTO
+		if json.Unmarshal(b.Result, &a) != nil {
+			return BestMatch{}
+		}
+		best.Artist = Artist{ID: a.ID.String(), Name: a.Name}
+	case ResultPlaylist:
+		var p playlistDTO
+		if json.Unmarshal(b.Result, &p) != nil {
+			return BestMatch{}
+		}
+		best.Playlist = mapPlaylist(p)
+	default:
+		return BestMatch{}
+	}
+	best.Type = b.Type
+	return best
+}
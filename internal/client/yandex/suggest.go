@@ -0,0 +1,38 @@
+package yandex
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type suggestResponse struct {
+	Result struct {
+		Best struct {
+			Text string `json:"text"`
+		} `json:"best"`
+		Suggestions []string `json:"suggestions"`
+	} `json:"result"`
+}
+
+// SearchSuggest returns Yandex's completions of a partly typed query, the
+// best match first and without repeats.
+func (c *APIClient) SearchSuggest(ctx context.Context, part string) ([]string, error) {
+	var payload suggestResponse
+	if err := c.getJSON(ctx, c.baseURL+"/search/suggest?part="+url.QueryEscape(part), &payload); err != nil {
+		return nil, fmt.Errorf("search suggest: %w", err)
+	}
+	seen := make(map[string]bool)
+	var out []string
+	for _, s := range append([]string{payload.Result.Best.Text}, payload.Result.Suggestions...) {
+		s = strings.TrimSpace(s)
+		key := strings.ToLower(s)
+		if s == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, s)
+	}
+	return out, nil
+}
@@ -1,12 +1,71 @@
 package yandex
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
-// ArtistsString renders joined artist names.
+// ArtistsString renders the performers: "A, B feat. C" when the credits
+// tell featured artists apart, the joined names otherwise.
 func (t Track) ArtistsString() string {
-	if len(t.Artists) == 0 {
-		return ""
+	featured := t.Credited(RoleFeatured)
+	if len(featured) == 0 {
+		return strings.Join(t.Artists, ", ")
 	}
-	return strings.Join(t.Artists, ", ")
+	return strings.Join(t.Credited(RoleMain), ", ") + " feat. " + strings.Join(featured, ", ")
 }
 
+// Extension returns the file extension matching the link codec.
+func (l DownloadLink) Extension() string {
+	switch strings.ToLower(l.Codec) {
+	case "flac", "flac-mp4":
+		return ".flac"
+	case "aac", "he-aac", "aac-mp4", "he-aac-mp4":
+		return ".m4a"
+	default:
+		return ".mp3"
+	}
+}
+
+// Lossless reports whether the link points at a lossless encoding.
+func (l DownloadLink) Lossless() bool {
+	return strings.HasPrefix(strings.ToLower(l.Codec), "flac")
+}
+
+// Expired reports whether storage no longer accepts the link at t; links
+// without a known expiry never count as expired.
+func (l DownloadLink) Expired(t time.Time) bool {
+	return !l.Expires.IsZero() && !t.Before(l.Expires)
+}
+
+// CanonicalID is the id that keeps working after Yandex migrates a track:
+// RealID when reported, ID otherwise.
+func (t Track) CanonicalID() string {
+	if t.RealID != "" {
+		return t.RealID
+	}
+	return t.ID
+}
+
+// URL returns the web page of the track: the share link Yandex gave, or
+// one built from the album and the canonical id.
+func (t Track) URL() string {
+	switch {
+	case t.ShareURL != "" && strings.Contains(t.ShareURL, "://"):
+		return t.ShareURL
+	case t.ShareURL != "":
+		return "https://" + t.ShareURL
+	case t.AlbumID != "":
+		return fmt.Sprintf("https://music.yandex.ru/album/%s/track/%s", t.AlbumID, t.CanonicalID())
+	}
+	return "https://music.yandex.ru/track/" + t.CanonicalID()
+}
+
+// DurationString renders the track duration as m:ss.
+func (t Track) DurationString() string {
+	if t.DurationSeconds <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%02d", t.DurationSeconds/60, t.DurationSeconds%60)
+}
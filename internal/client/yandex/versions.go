@@ -0,0 +1,72 @@
+package yandex
+
+import (
+	"regexp"
+	"strings"
+)
+
+// VersionKind classifies a track version.
+type VersionKind string
+
+// Version kinds; the zero value is the original recording.
+const (
+	VersionOriginal VersionKind = ""
+	VersionLive     VersionKind = "live"
+	VersionRemix    VersionKind = "remix"
+	VersionRemaster VersionKind = "remaster"
+	// VersionOther is any other named version, e.g. "Acoustic" or "Radio Edit".
+	VersionOther VersionKind = "other"
+)
+
+// versionPatterns recognise kinds in version names, first match winning: a
+// "Live Remix" is a remix.
+var versionPatterns = []struct {
+	kind    VersionKind
+	pattern *regexp.Regexp
+}{
+	{VersionRemix, regexp.MustCompile(`(?i)\b(remix(ed)?|rmx|rework|bootleg|(club|extended|dub|vip) mix)\b|ремикс`)},
+	{VersionLive, regexp.MustCompile(`(?i)\b(live|unplugged)\b|концерт|живьём|живое исполнение`)},
+	{VersionRemaster, regexp.MustCompile(`(?i)remaster|ремастер`)},
+}
+
+// titleVersion matches a version spelled in the title itself, as on tracks
+// uploaded without one: "Song (Live)", "Song [Remix]" or "Song - Live".
+var titleVersion = regexp.MustCompile(`\(([^()]*)\)\s*$|\[([^\[\]]*)\]\s*$|\s[-–—]\s(.+)$`)
+
+// FullTitle renders the title with its version, e.g. "Help! (Remastered
+// 2009)", unless the title already names it.
+func (t Track) FullTitle() string {
+	v := strings.TrimSpace(t.Version)
+	if v == "" || strings.Contains(strings.ToLower(t.Title), strings.ToLower(v)) {
+		return t.Title
+	}
+	return t.Title + " (" + v + ")"
+}
+
+// VersionKind classifies the version of t, falling back to a version named
+// at the end of the title.
+func (t Track) VersionKind() VersionKind {
+	v := strings.TrimSpace(t.Version)
+	if v == "" {
+		m := titleVersion.FindStringSubmatch(t.Title)
+		if m == nil {
+			return VersionOriginal
+		}
+		v = m[1] + m[2] + m[3]
+		if kind := classifyVersion(v); kind != VersionOther {
+			return kind
+		}
+		// Other parentheses are as likely part of the name as a version.
+		return VersionOriginal
+	}
+	return classifyVersion(v)
+}
+
+func classifyVersion(v string) VersionKind {
+	for _, p := range versionPatterns {
+		if p.pattern.MatchString(v) {
+			return p.kind
+		}
+	}
+	return VersionOther
+}
@@ -1,34 +1,480 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"net/url"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
+
+	"ym-bot/internal/utils"
+	"ym-bot/internal/webhook"
 )
 
-// Config holds application settings sourced from environment variables.
+// Config holds application settings.
+// Values are layered: defaults < YAML file (--config) < environment < CLI flags.
 type Config struct {
-	TelegramToken string
-	YandexToken   string
-	LogLevel      string
+	TelegramToken string `yaml:"telegram_token"`
+	YandexToken   string `yaml:"yandex_token"`
+	LogLevel      string `yaml:"log_level"`
+	StoragePath   string `yaml:"storage_path"`
+	// AuditLogPath is the append-only download audit log (JSON lines); empty keeps it in memory.
+	AuditLogPath string  `yaml:"audit_log_path"`
+	AdminIDs     []int64 `yaml:"admin_ids"`
+	// FeedbackChatIDs receive /feedback messages; AdminIDs are used when empty.
+	FeedbackChatIDs []int64 `yaml:"feedback_chat_ids"`
+
+	// TelegramTokens lists additional bots; each runs its own transport sharing
+	// the music service, cache and storage to spread Telegram upload limits.
+	TelegramTokens []string `yaml:"telegram_tokens"`
+
+	// TelegramAPIURL points at a self-hosted Bot API server, which lifts the upload limit to 2 GB.
+	TelegramAPIURL string `yaml:"telegram_api_url"`
+	// YandexAPIURL overrides the Yandex Music API base (regional mirror or proxy gateway).
+	YandexAPIURL string `yaml:"yandex_api_url"`
+	// YandexSignSalt overrides the salt of XML download URL signatures,
+	// should Yandex rotate it; empty uses the built-in one.
+	YandexSignSalt string `yaml:"yandex_sign_salt"`
+	// YandexDebug records sanitized Yandex requests and responses for /httplog.
+	YandexDebug bool `yaml:"yandex_debug"`
+	// YandexDebugPath also writes them to a file rotated like the log outputs.
+	YandexDebugPath string `yaml:"yandex_debug_path"`
+	// SearchCorrection lets Yandex search its corrected spelling of a query;
+	// when off, empty results offer a "did you mean" button instead.
+	SearchCorrection bool `yaml:"search_correction"`
+
+	LogEncoding string      `yaml:"log_encoding"`
+	LogOutputs  []string    `yaml:"log_outputs"`
+	LogSampling bool        `yaml:"log_sampling"`
+	LogRotation LogRotation `yaml:"log_rotation"`
+	// SentryDSN enables shipping warnings, errors and panics to Sentry.
+	SentryDSN string `yaml:"sentry_dsn"`
+	// SentryEnvironment tags reported events, e.g. "production".
+	SentryEnvironment string `yaml:"sentry_environment"`
+
+	// StatsChart attaches a PNG chart to the /stats admin report.
+	StatsChart bool `yaml:"stats_chart"`
+	// DailyDownloadLimit caps tracks per user per UTC day; 0 disables the quota.
+	DailyDownloadLimit int `yaml:"daily_download_limit"`
+	// PreflightThresholdMB asks users to confirm downloads estimated at this
+	// size or more; 0 disables the confirmation.
+	PreflightThresholdMB int `yaml:"preflight_threshold_mb"`
+	// SplitLongerThan offers to send tracks longer than this (DJ mixes,
+	// audiobooks) as a series of parts of at most SplitPartLength; 0 never
+	// offers. Splitting needs FFmpegPath.
+	SplitLongerThan time.Duration `yaml:"split_longer_than"`
+	SplitPartLength time.Duration `yaml:"split_part_length"`
+
+	// RateLimitPerMinute throttles updates per user; 0 disables limiting.
+	RateLimitPerMinute int `yaml:"rate_limit_per_minute"`
+	RateLimitBurst     int `yaml:"rate_limit_burst"`
+	// DownloadWorkers bounds concurrent downloads; DownloadQueueSize bounds the backlog.
+	DownloadWorkers   int `yaml:"download_workers"`
+	DownloadQueueSize int `yaml:"download_queue_size"`
+	// DownloadConnections fetches files of ChunkedThresholdMB or more over this
+	// many parallel ranged requests; 1 keeps a single GET.
+	DownloadConnections int `yaml:"download_connections"`
+	ChunkedThresholdMB  int `yaml:"chunked_threshold_mb"`
+	// StreamUploadMaxMB pipes files up to this size from Yandex straight into
+	// the Telegram upload without a temp file; 0 always downloads to disk.
+	StreamUploadMaxMB int `yaml:"stream_upload_max_mb"`
+	// PlaylistButton offers "save to Yandex playlist" under sent tracks to
+	// every user, not only admins; playlists live on the YANDEX_TOKEN account.
+	PlaylistButton bool `yaml:"playlist_button"`
+	// FFmpegPath converts downloads Telegram cannot play inline (raw AAC, Ogg)
+	// to MP3 or M4A; empty sends them as documents.
+	FFmpegPath string `yaml:"ffmpeg_path"`
+	// TempDir holds in-progress downloads; empty means the system temp dir.
+	TempDir string `yaml:"tmp_dir"`
+	// TempMaxAge is the age past which leftover download dirs are swept at
+	// startup and periodically; 0 disables the sweep.
+	TempMaxAge time.Duration `yaml:"tmp_max_age"`
+	// MusicBrainzContact, an e-mail or URL MusicBrainz can reach the operator
+	// at, enables looking downloads up there for their ISRC, album artist and
+	// a year Yandex does not know; empty disables lookups.
+	MusicBrainzContact string `yaml:"musicbrainz_contact"`
+	// Archive uploads delivered tracks to S3-compatible storage; off without a bucket.
+	Archive Archive `yaml:"archive"`
+	// TrackCacheTTL is how long track metadata is cached; 0 disables the cache.
+	TrackCacheTTL time.Duration `yaml:"track_cache_ttl"`
+	// InlineCacheTime is how long Telegram may cache inline answers that are
+	// the same for everyone; InlinePersonalCacheTime caches answers that
+	// depend on the user (their history, their search order) for that user
+	// only. 0 disables caching. Both are cut short to the expiry of the audio
+	// URLs in the answer.
+	InlineCacheTime         time.Duration `yaml:"inline_cache_time"`
+	InlinePersonalCacheTime time.Duration `yaml:"inline_personal_cache_time"`
+	// InlineMaxResults is how many results inline answers offer in all,
+	// over the pages Telegram asks for as the user scrolls; 0 pages on as
+	// long as Yandex finds more.
+	InlineMaxResults int `yaml:"inline_max_results"`
+	// InlineProbe checks every audio URL with a HEAD request before offering
+	// it inline and leaves out those Telegram could not fetch.
+	InlineProbe bool `yaml:"inline_probe"`
+	// InlineMode is "rich" to answer inline queries with audio Telegram
+	// fetches from direct URLs, or "fast" to answer with track cards and
+	// resolve the audio only for the card that is picked.
+	InlineMode string `yaml:"inline_mode"`
+	// InlineUpgradeChat is the chat picked inline audio is uploaded to in
+	// full quality, so the sent message can be switched to the uploaded
+	// file; 0 keeps the audio Telegram fetched from the URL.
+	InlineUpgradeChat int64 `yaml:"inline_upgrade_chat"`
+	// Timeouts bound each stage of request handling; tune them for slow networks.
+	Timeouts Timeouts `yaml:"timeouts"`
+
+	// CallbackSecret signs inline button payloads; derived from the bot token when empty.
+	CallbackSecret string `yaml:"callback_secret"`
+	// CallbackTTL is how long inline buttons remain valid.
+	CallbackTTL time.Duration `yaml:"callback_ttl"`
+
+	// CaptionTemplate is a text/template for sent audio captions with fields
+	// Title, Artists, Album, Duration, Link, Bot, Codec, SizeMB, SampleRate,
+	// ISRC, AlbumArtist.
+	CaptionTemplate    string `yaml:"caption_template"`
+	CaptionAttribution bool   `yaml:"caption_attribution"`
+	// FileNameTemplate is a text/template for names of sent files with fields
+	// Artists, Artist, Title, Album, Genre, ID; empty means "Artists - Title".
+	FileNameTemplate string `yaml:"file_name_template"`
+
+	// MaintenanceMessage is the reply to users while /maintenance is on.
+	MaintenanceMessage string `yaml:"maintenance_message"`
+	// MaintenanceDownloads is "queue" to hold new downloads until maintenance
+	// ends or "reject" to refuse them.
+	MaintenanceDownloads string `yaml:"maintenance_downloads"`
+
+	// APIAddr is the listen address of the internal HTTP API, e.g. ":8080";
+	// empty disables it. Requests must present one of APIKeys.
+	APIAddr string   `yaml:"api_addr"`
+	APIKeys []string `yaml:"api_keys"`
+	// MetricsAddr is the listen address of the Prometheus /metrics endpoint,
+	// e.g. ":9090"; empty disables it.
+	MetricsAddr string `yaml:"metrics_addr"`
+
+	// Webhooks are notified of bot events, see package webhook.
+	Webhooks []Webhook `yaml:"webhooks"`
+	// WebhookErrorBurst failed downloads within WebhookErrorWindow fire an
+	// errors.burst event; 0 never fires.
+	WebhookErrorBurst  int           `yaml:"webhook_error_burst"`
+	WebhookErrorWindow time.Duration `yaml:"webhook_error_window"`
+}
+
+// Webhook is an outbound notification endpoint. Requests are signed with
+// Secret; Events limits them to these event types, all when empty.
+type Webhook struct {
+	URL    string   `yaml:"url"`
+	Secret string   `yaml:"secret"`
+	Events []string `yaml:"events"`
+}
+
+// LogRotation configures lumberjack rotation for file log outputs.
+type LogRotation struct {
+	MaxSizeMB  int  `yaml:"max_size_mb"`
+	MaxBackups int  `yaml:"max_backups"`
+	MaxAgeDays int  `yaml:"max_age_days"`
+	Compress   bool `yaml:"compress"`
+}
+
+// Archive configures the S3-compatible bucket delivered tracks are kept in
+// and served from once Yandex stops serving them.
+type Archive struct {
+	// Endpoint is the storage URL, e.g. https://s3.eu-central-1.amazonaws.com
+	// or http://minio:9000; buckets are addressed path-style.
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	Prefix    string `yaml:"prefix"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+}
+
+// Enabled reports whether a bucket is configured.
+func (a Archive) Enabled() bool {
+	return a.Bucket != ""
+}
+
+// Timeouts caps how long each kind of work may take.
+type Timeouts struct {
+	// Inline bounds answering an inline query or a chat search/browse request.
+	Inline time.Duration `yaml:"inline"`
+	// Callback bounds a whole download job: fetching the track and uploading it.
+	Callback time.Duration `yaml:"callback"`
+	// Download bounds transferring the audio file from Yandex to disk.
+	Download time.Duration `yaml:"download"`
+	// HTTP bounds every single outgoing HTTP request; for audio downloads,
+	// bounded by Download, it only caps the wait for the response headers.
+	HTTP time.Duration `yaml:"http"`
+}
+
+// Values of MaintenanceDownloads.
+const (
+	MaintenanceQueue  = "queue"
+	MaintenanceReject = "reject"
+)
+
+// Values of InlineMode.
+const (
+	InlineRich = "rich"
+	InlineFast = "fast"
+)
+
+// Defaults returns the baseline configuration.
+func Defaults() Config {
+	return Config{
+		LogLevel:    "info",
+		LogEncoding: "console",
+		LogOutputs:  []string{"stdout"},
+		LogRotation: LogRotation{
+			MaxSizeMB:  100,
+			MaxBackups: 5,
+			MaxAgeDays: 30,
+		},
+		DailyDownloadLimit: 50,
+		RateLimitPerMinute: 30,
+		RateLimitBurst:     10,
+		DownloadWorkers:    4,
+		DownloadQueueSize:  100,
+		TrackCacheTTL:      10 * time.Minute,
+		InlineCacheTime:    time.Minute,
+		InlineMode:         InlineRich,
+		CallbackTTL:        48 * time.Hour,
+		Timeouts: Timeouts{
+			Inline:   12 * time.Second,
+			Callback: 90 * time.Second,
+			Download: 60 * time.Second,
+			HTTP:     20 * time.Second,
+		},
+
+		PreflightThresholdMB: 10,
+		SplitLongerThan:      time.Hour,
+		SplitPartLength:      30 * time.Minute,
+		DownloadConnections:  1,
+		ChunkedThresholdMB:   20,
+		StreamUploadMaxMB:    10,
+		TempMaxAge:           30 * time.Minute,
+
+		MaintenanceMessage:   "🛠 Идут технические работы, бот скоро вернётся.",
+		MaintenanceDownloads: MaintenanceQueue,
+
+		WebhookErrorBurst:  10,
+		WebhookErrorWindow: 5 * time.Minute,
+	}
+}
+
+// Load builds configuration from all sources; args are CLI arguments without the program name.
+func Load(args []string) (Config, error) {
+	cfg := Defaults()
+
+	fs, flags := newFlagSet()
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if flags.configPath != "" {
+		if err := applyFile(&cfg, flags.configPath); err != nil {
+			return cfg, err
+		}
+	}
+	errs := applyEnv(&cfg)
+	errs = append(errs, applyFlags(&cfg, fs, flags)...)
+
+	return cfg, combine(append(errs, cfg.problems()...))
+}
+
+// Validate reports every missing or invalid field at once.
+func (c Config) Validate() error {
+	return combine(c.problems())
+}
+
+// IsAdmin reports whether the Telegram user id is listed in AdminIDs.
+func (c Config) IsAdmin(userID int64) bool {
+	for _, id := range c.AdminIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
 }
 
-// Load reads configuration from the environment.
-func Load() (Config, error) {
-	cfg := Config{
-		TelegramToken: strings.TrimSpace(os.Getenv("TELEGRAM_TOKEN")),
-		YandexToken:   strings.TrimSpace(os.Getenv("YANDEX_TOKEN")),
-		LogLevel:      strings.TrimSpace(os.Getenv("LOG_LEVEL")),
+// FeedbackChats returns the chats that receive /feedback messages.
+func (c Config) FeedbackChats() []int64 {
+	if len(c.FeedbackChatIDs) > 0 {
+		return c.FeedbackChatIDs
 	}
+	return c.AdminIDs
+}
 
-	if cfg.LogLevel == "" {
-		cfg.LogLevel = "info"
+// BotTokens returns TelegramToken followed by TelegramTokens, without blanks or duplicates.
+func (c Config) BotTokens() []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, t := range append([]string{c.TelegramToken}, c.TelegramTokens...) {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tokens = append(tokens, t)
 	}
+	return tokens
+}
+
+func (c Config) problems() []error {
+	var errs []error
 
-	if cfg.TelegramToken == "" {
-		return cfg, fmt.Errorf("TELEGRAM_TOKEN is not set")
+	if len(c.BotTokens()) == 0 {
+		errs = append(errs, fmt.Errorf("telegram_token: is required (TELEGRAM_TOKEN or TELEGRAM_TOKENS)"))
+	}
+	if _, _, err := utils.ParseLevelSpec(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("log_level: %w", err))
+	}
+	switch strings.ToLower(c.LogEncoding) {
+	case "console", "json":
+	default:
+		errs = append(errs, fmt.Errorf("log_encoding: must be console or json, got %q", c.LogEncoding))
+	}
+	if c.LogRotation.MaxSizeMB < 0 || c.LogRotation.MaxBackups < 0 || c.LogRotation.MaxAgeDays < 0 {
+		errs = append(errs, fmt.Errorf("log_rotation: values must not be negative"))
+	}
+	if c.SentryDSN != "" {
+		if u, err := url.Parse(c.SentryDSN); err != nil || u.Scheme == "" || u.Host == "" || u.User == nil {
+			errs = append(errs, fmt.Errorf("sentry_dsn: must look like https://key@host/project"))
+		}
+	}
+	if c.Archive.Enabled() {
+		if u, err := url.Parse(c.Archive.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("archive.endpoint: must be an absolute URL, got %q", c.Archive.Endpoint))
+		}
+		if c.Archive.AccessKey == "" || c.Archive.SecretKey == "" {
+			errs = append(errs, fmt.Errorf("archive: access_key and secret_key are required with a bucket"))
+		}
+	}
+	if c.TelegramAPIURL != "" {
+		if u, err := url.Parse(c.TelegramAPIURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("telegram_api_url: must be an absolute URL, got %q", c.TelegramAPIURL))
+		}
+	}
+	if c.YandexAPIURL != "" {
+		if u, err := url.Parse(c.YandexAPIURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("yandex_api_url: must be an absolute URL, got %q", c.YandexAPIURL))
+		}
+	}
+	if c.CaptionTemplate != "" {
+		if _, err := template.New("caption").Parse(c.CaptionTemplate); err != nil {
+			errs = append(errs, fmt.Errorf("caption_template: %w", err))
+		}
+	}
+	if c.FileNameTemplate != "" {
+		if _, err := template.New("filename").Parse(c.FileNameTemplate); err != nil {
+			errs = append(errs, fmt.Errorf("file_name_template: %w", err))
+		}
+	}
+	if c.DailyDownloadLimit < 0 {
+		errs = append(errs, fmt.Errorf("daily_download_limit: must not be negative"))
+	}
+	if c.PreflightThresholdMB < 0 {
+		errs = append(errs, fmt.Errorf("preflight_threshold_mb: must not be negative"))
+	}
+	if c.SplitLongerThan < 0 {
+		errs = append(errs, fmt.Errorf("split_longer_than: must not be negative"))
+	}
+	if c.SplitLongerThan > 0 && (c.SplitPartLength < time.Minute || c.SplitPartLength > c.SplitLongerThan) {
+		errs = append(errs, fmt.Errorf("split_part_length: must be between 1m and split_longer_than"))
+	}
+	if c.RateLimitPerMinute < 0 || c.RateLimitBurst < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit: values must not be negative"))
+	}
+	if c.DownloadWorkers < 1 {
+		errs = append(errs, fmt.Errorf("download_workers: must be at least 1"))
+	}
+	if c.DownloadConnections < 1 || c.DownloadConnections > 16 {
+		errs = append(errs, fmt.Errorf("download_connections: must be between 1 and 16"))
 	}
+	if c.ChunkedThresholdMB < 0 {
+		errs = append(errs, fmt.Errorf("chunked_threshold_mb: must not be negative"))
+	}
+	if c.StreamUploadMaxMB < 0 {
+		errs = append(errs, fmt.Errorf("stream_upload_max_mb: must not be negative"))
+	}
+	// Younger dirs may belong to downloads still in flight.
+	if c.TempMaxAge != 0 && c.TempMaxAge < 5*time.Minute {
+		errs = append(errs, fmt.Errorf("tmp_max_age: must be 0 or at least 5m"))
+	}
+	if c.DownloadQueueSize < 0 {
+		errs = append(errs, fmt.Errorf("download_queue_size: must not be negative"))
+	}
+	if c.CallbackTTL <= 0 {
+		errs = append(errs, fmt.Errorf("callback_ttl: must be positive"))
+	}
+	if c.TrackCacheTTL < 0 {
+		errs = append(errs, fmt.Errorf("track_cache_ttl: must not be negative"))
+	}
+	if c.InlineCacheTime < 0 || c.InlinePersonalCacheTime < 0 {
+		errs = append(errs, fmt.Errorf("inline_cache_time: values must not be negative"))
+	}
+	if c.InlineMaxResults < 0 {
+		errs = append(errs, fmt.Errorf("inline_max_results: must not be negative"))
+	}
+	switch c.InlineMode {
+	case InlineRich, InlineFast:
+	default:
+		errs = append(errs, fmt.Errorf("inline_mode: must be rich or fast, got %q", c.InlineMode))
+	}
+	errs = append(errs, c.Timeouts.problems()...)
+	if c.APIAddr != "" && len(c.APIKeys) == 0 {
+		errs = append(errs, fmt.Errorf("api_keys: required when api_addr is set"))
+	}
+	for i, w := range c.Webhooks {
+		if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("webhooks[%d].url: must be an http(s) URL, got %q", i, w.URL))
+		}
+		for _, ev := range w.Events {
+			if !slices.Contains(webhook.EventTypes, ev) {
+				errs = append(errs, fmt.Errorf("webhooks[%d].events: unknown event %q, want one of %s", i, ev, strings.Join(webhook.EventTypes, ", ")))
+			}
+		}
+	}
+	if c.WebhookErrorBurst < 0 {
+		errs = append(errs, fmt.Errorf("webhook_error_burst: must not be negative"))
+	}
+	if c.WebhookErrorBurst > 0 && c.WebhookErrorWindow <= 0 {
+		errs = append(errs, fmt.Errorf("webhook_error_window: must be positive"))
+	}
+	switch c.MaintenanceDownloads {
+	case MaintenanceQueue, MaintenanceReject:
+	default:
+		errs = append(errs, fmt.Errorf("maintenance_downloads: must be queue or reject, got %q", c.MaintenanceDownloads))
+	}
+
+	return errs
+}
 
-	return cfg, nil
+func (t Timeouts) problems() []error {
+	var errs []error
+	for _, f := range []struct {
+		name string
+		d    time.Duration
+	}{
+		{"inline", t.Inline},
+		{"callback", t.Callback},
+		{"download", t.Download},
+		{"http", t.HTTP},
+	} {
+		if f.d <= 0 {
+			errs = append(errs, fmt.Errorf("timeouts.%s: must be positive", f.name))
+		}
+	}
+	// The download runs inside the job, so a longer one could never finish.
+	if t.Download > 0 && t.Callback > 0 && t.Download > t.Callback {
+		errs = append(errs, fmt.Errorf("timeouts.download: must not exceed timeouts.callback (%s)", t.Callback))
+	}
+	return errs
 }
 
+func combine(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// Reloader re-reads configuration from the original sources and hands the new
+// snapshot to subscribers, which re-apply only the runtime-tunable parts.
+type Reloader struct {
+	args   []string
+	logger *zap.Logger
+
+	mu          sync.Mutex
+	current     Config
+	subscribers []func(Config)
+}
+
+// NewReloader keeps the CLI args so reloads see the same --config file and flags.
+func NewReloader(args []string, initial Config, logger *zap.Logger) *Reloader {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Reloader{
+		args:    args,
+		logger:  logger,
+		current: initial,
+	}
+}
+
+// Current returns the latest applied configuration.
+func (r *Reloader) Current() Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Subscribe registers fn to be called with every successfully reloaded config.
+func (r *Reloader) Subscribe(fn func(Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// Reload loads and validates a fresh config; on failure the current one stays in effect.
+func (r *Reloader) Reload() (Config, error) {
+	next, err := Load(r.args)
+	if err != nil {
+		return r.Current(), err
+	}
+
+	r.mu.Lock()
+	prev := r.current
+	r.current = next
+	subs := append([]func(Config){}, r.subscribers...)
+	r.mu.Unlock()
+
+	if restartRequired(prev, next) {
+		r.logger.Warn("config reload: tokens, storage, log sinks, queue size and callback signing require a restart to take effect")
+	}
+
+	for _, fn := range subs {
+		fn(next)
+	}
+	r.logger.Info("config reloaded")
+	return next, nil
+}
+
+// WatchSignals reloads on every SIGHUP until ctx is done.
+func (r *Reloader) WatchSignals(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			if _, err := r.Reload(); err != nil {
+				r.logger.Warn("config reload failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// restartRequired reports changes to settings that are only read at startup.
+func restartRequired(prev, next Config) bool {
+	return strings.Join(prev.BotTokens(), ",") != strings.Join(next.BotTokens(), ",") ||
+		prev.YandexToken != next.YandexToken ||
+		prev.TelegramAPIURL != next.TelegramAPIURL ||
+		prev.YandexAPIURL != next.YandexAPIURL ||
+		prev.YandexSignSalt != next.YandexSignSalt ||
+		prev.YandexDebugPath != next.YandexDebugPath ||
+		prev.SearchCorrection != next.SearchCorrection ||
+		prev.StoragePath != next.StoragePath ||
+		prev.AuditLogPath != next.AuditLogPath ||
+		prev.LogEncoding != next.LogEncoding ||
+		strings.Join(prev.LogOutputs, ",") != strings.Join(next.LogOutputs, ",") ||
+		prev.LogSampling != next.LogSampling ||
+		prev.LogRotation != next.LogRotation ||
+		prev.SentryDSN != next.SentryDSN ||
+		prev.SentryEnvironment != next.SentryEnvironment ||
+		prev.DownloadQueueSize != next.DownloadQueueSize ||
+		prev.DownloadConnections != next.DownloadConnections ||
+		prev.ChunkedThresholdMB != next.ChunkedThresholdMB ||
+		prev.TempDir != next.TempDir ||
+		prev.FileNameTemplate != next.FileNameTemplate ||
+		prev.FFmpegPath != next.FFmpegPath ||
+		prev.Archive != next.Archive ||
+		prev.MusicBrainzContact != next.MusicBrainzContact ||
+		prev.TempMaxAge != next.TempMaxAge ||
+		prev.CallbackSecret != next.CallbackSecret ||
+		prev.CallbackTTL != next.CallbackTTL ||
+		prev.APIAddr != next.APIAddr ||
+		strings.Join(prev.APIKeys, ",") != strings.Join(next.APIKeys, ",") ||
+		prev.MetricsAddr != next.MetricsAddr ||
+		!slices.EqualFunc(prev.Webhooks, next.Webhooks, func(a, b Webhook) bool {
+			return a.URL == b.URL && a.Secret == b.Secret && slices.Equal(a.Events, b.Events)
+		}) ||
+		prev.WebhookErrorBurst != next.WebhookErrorBurst ||
+		prev.WebhookErrorWindow != next.WebhookErrorWindow
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// cliFlags holds raw flag values before they are merged into Config.
+type cliFlags struct {
+	configPath    string
+	telegramToken string
+	yandexToken   string
+	logLevel      string
+	storagePath   string
+	adminIDs      string
+	logEncoding   string
+	logOutputs    string
+}
+
+func newFlagSet() (*flag.FlagSet, *cliFlags) {
+	f := &cliFlags{}
+	fs := flag.NewFlagSet("ym-bot", flag.ContinueOnError)
+	fs.StringVar(&f.configPath, "config", "", "path to YAML config file")
+	fs.StringVar(&f.telegramToken, "telegram-token", "", "Telegram bot token")
+	fs.StringVar(&f.yandexToken, "yandex-token", "", "Yandex Music OAuth token")
+	fs.StringVar(&f.logLevel, "log-level", "", "log level (debug|info|warn|error)")
+	fs.StringVar(&f.storagePath, "storage-path", "", "path to JSON state file")
+	fs.StringVar(&f.adminIDs, "admin-ids", "", "comma-separated Telegram user ids with admin rights")
+	fs.StringVar(&f.logEncoding, "log-encoding", "", "log encoding (console|json)")
+	fs.StringVar(&f.logOutputs, "log-outputs", "", "comma-separated log outputs: stdout, stderr or file paths")
+	return fs, f
+}
+
+// applyFile overlays values from a YAML file; absent keys keep current values.
+func applyFile(cfg *Config, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(raw, cfg); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overlays non-empty environment variables.
+func applyEnv(cfg *Config) []error {
+	var errs []error
+	setFromEnv(&cfg.TelegramToken, "TELEGRAM_TOKEN")
+	setListFromEnv(&cfg.TelegramTokens, "TELEGRAM_TOKENS")
+	setFromEnv(&cfg.YandexToken, "YANDEX_TOKEN")
+	setFromEnv(&cfg.TelegramAPIURL, "TELEGRAM_API_URL")
+	setFromEnv(&cfg.YandexAPIURL, "YANDEX_API_URL")
+	errs = appendErr(errs, setBoolFromEnv(&cfg.SearchCorrection, "SEARCH_CORRECTION", "search_correction"))
+	setFromEnv(&cfg.LogLevel, "LOG_LEVEL")
+	setFromEnv(&cfg.StoragePath, "STORAGE_PATH")
+	setFromEnv(&cfg.AuditLogPath, "AUDIT_LOG_PATH")
+	if v := strings.TrimSpace(os.Getenv("ADMIN_IDS")); v != "" {
+		ids, err := parseIDs(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("admin_ids: %w (ADMIN_IDS)", err))
+		} else {
+			cfg.AdminIDs = ids
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("FEEDBACK_CHAT_IDS")); v != "" {
+		ids, err := parseIDs(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("feedback_chat_ids: %w (FEEDBACK_CHAT_IDS)", err))
+		} else {
+			cfg.FeedbackChatIDs = ids
+		}
+	}
+	setFromEnv(&cfg.LogEncoding, "LOG_ENCODING")
+	setListFromEnv(&cfg.LogOutputs, "LOG_OUTPUTS")
+	errs = appendErr(errs, setBoolFromEnv(&cfg.LogSampling, "LOG_SAMPLING", "log_sampling"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.LogRotation.MaxSizeMB, "LOG_MAX_SIZE_MB", "log_rotation.max_size_mb"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.LogRotation.MaxBackups, "LOG_MAX_BACKUPS", "log_rotation.max_backups"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.LogRotation.MaxAgeDays, "LOG_MAX_AGE_DAYS", "log_rotation.max_age_days"))
+	errs = appendErr(errs, setBoolFromEnv(&cfg.LogRotation.Compress, "LOG_COMPRESS", "log_rotation.compress"))
+	setFromEnv(&cfg.YandexSignSalt, "YANDEX_SIGN_SALT")
+	errs = appendErr(errs, setBoolFromEnv(&cfg.YandexDebug, "YANDEX_DEBUG", "yandex_debug"))
+	setFromEnv(&cfg.YandexDebugPath, "YANDEX_DEBUG_PATH")
+	setFromEnv(&cfg.SentryDSN, "SENTRY_DSN")
+	setFromEnv(&cfg.SentryEnvironment, "SENTRY_ENVIRONMENT")
+	errs = appendErr(errs, setBoolFromEnv(&cfg.StatsChart, "STATS_CHART", "stats_chart"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.DailyDownloadLimit, "DAILY_DOWNLOAD_LIMIT", "daily_download_limit"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.PreflightThresholdMB, "PREFLIGHT_THRESHOLD_MB", "preflight_threshold_mb"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.SplitLongerThan, "SPLIT_LONGER_THAN", "split_longer_than"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.SplitPartLength, "SPLIT_PART_LENGTH", "split_part_length"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.RateLimitPerMinute, "RATE_LIMIT_PER_MINUTE", "rate_limit_per_minute"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.RateLimitBurst, "RATE_LIMIT_BURST", "rate_limit_burst"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.DownloadWorkers, "DOWNLOAD_WORKERS", "download_workers"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.DownloadQueueSize, "DOWNLOAD_QUEUE_SIZE", "download_queue_size"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.DownloadConnections, "DOWNLOAD_CONNECTIONS", "download_connections"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.ChunkedThresholdMB, "CHUNKED_THRESHOLD_MB", "chunked_threshold_mb"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.StreamUploadMaxMB, "STREAM_UPLOAD_MAX_MB", "stream_upload_max_mb"))
+	setFromEnv(&cfg.FFmpegPath, "FFMPEG_PATH")
+	setFromEnv(&cfg.TempDir, "TMP_DIR")
+	setFromEnv(&cfg.MusicBrainzContact, "MUSICBRAINZ_CONTACT")
+	setFromEnv(&cfg.Archive.Endpoint, "ARCHIVE_ENDPOINT")
+	setFromEnv(&cfg.Archive.Region, "ARCHIVE_REGION")
+	setFromEnv(&cfg.Archive.Bucket, "ARCHIVE_BUCKET")
+	setFromEnv(&cfg.Archive.Prefix, "ARCHIVE_PREFIX")
+	setFromEnv(&cfg.Archive.AccessKey, "ARCHIVE_ACCESS_KEY")
+	setFromEnv(&cfg.Archive.SecretKey, "ARCHIVE_SECRET_KEY")
+	errs = appendErr(errs, setBoolFromEnv(&cfg.PlaylistButton, "PLAYLIST_BUTTON", "playlist_button"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.TempMaxAge, "TMP_MAX_AGE", "tmp_max_age"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.TrackCacheTTL, "TRACK_CACHE_TTL", "track_cache_ttl"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.InlineCacheTime, "INLINE_CACHE_TIME", "inline_cache_time"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.InlinePersonalCacheTime, "INLINE_PERSONAL_CACHE_TIME", "inline_personal_cache_time"))
+	errs = appendErr(errs, setIntFromEnv(&cfg.InlineMaxResults, "INLINE_MAX_RESULTS", "inline_max_results"))
+	errs = appendErr(errs, setBoolFromEnv(&cfg.InlineProbe, "INLINE_PROBE", "inline_probe"))
+	setFromEnv(&cfg.InlineMode, "INLINE_MODE")
+	errs = appendErr(errs, setInt64FromEnv(&cfg.InlineUpgradeChat, "INLINE_UPGRADE_CHAT", "inline_upgrade_chat"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.Timeouts.Inline, "TIMEOUT_INLINE", "timeouts.inline"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.Timeouts.Callback, "TIMEOUT_CALLBACK", "timeouts.callback"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.Timeouts.Download, "TIMEOUT_DOWNLOAD", "timeouts.download"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.Timeouts.HTTP, "TIMEOUT_HTTP", "timeouts.http"))
+	setFromEnv(&cfg.CallbackSecret, "CALLBACK_SECRET")
+	errs = appendErr(errs, setDurationFromEnv(&cfg.CallbackTTL, "CALLBACK_TTL", "callback_ttl"))
+	if v := os.Getenv("CAPTION_TEMPLATE"); strings.TrimSpace(v) != "" {
+		// Inner whitespace is significant; a literal "\n" in env becomes a newline.
+		cfg.CaptionTemplate = strings.ReplaceAll(v, `\n`, "\n")
+	}
+	errs = appendErr(errs, setBoolFromEnv(&cfg.CaptionAttribution, "CAPTION_ATTRIBUTION", "caption_attribution"))
+	setFromEnv(&cfg.FileNameTemplate, "FILE_NAME_TEMPLATE")
+	setFromEnv(&cfg.MaintenanceMessage, "MAINTENANCE_MESSAGE")
+	setFromEnv(&cfg.MaintenanceDownloads, "MAINTENANCE_DOWNLOADS")
+	setFromEnv(&cfg.APIAddr, "API_ADDR")
+	setListFromEnv(&cfg.APIKeys, "API_KEYS")
+	setFromEnv(&cfg.MetricsAddr, "METRICS_ADDR")
+	// The environment configures a single webhook, replacing the file's.
+	if v := strings.TrimSpace(os.Getenv("WEBHOOK_URL")); v != "" {
+		w := Webhook{URL: v}
+		setFromEnv(&w.Secret, "WEBHOOK_SECRET")
+		setListFromEnv(&w.Events, "WEBHOOK_EVENTS")
+		cfg.Webhooks = []Webhook{w}
+	}
+	errs = appendErr(errs, setIntFromEnv(&cfg.WebhookErrorBurst, "WEBHOOK_ERROR_BURST", "webhook_error_burst"))
+	errs = appendErr(errs, setDurationFromEnv(&cfg.WebhookErrorWindow, "WEBHOOK_ERROR_WINDOW", "webhook_error_window"))
+	return errs
+}
+
+// applyFlags overlays only the flags explicitly passed on the command line.
+func applyFlags(cfg *Config, fs *flag.FlagSet, f *cliFlags) []error {
+	var errs []error
+	fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "telegram-token":
+			cfg.TelegramToken = strings.TrimSpace(f.telegramToken)
+		case "yandex-token":
+			cfg.YandexToken = strings.TrimSpace(f.yandexToken)
+		case "log-level":
+			cfg.LogLevel = strings.TrimSpace(f.logLevel)
+		case "storage-path":
+			cfg.StoragePath = strings.TrimSpace(f.storagePath)
+		case "admin-ids":
+			ids, err := parseIDs(f.adminIDs)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("admin_ids: %w (--admin-ids)", err))
+				return
+			}
+			cfg.AdminIDs = ids
+		case "log-encoding":
+			cfg.LogEncoding = strings.TrimSpace(f.logEncoding)
+		case "log-outputs":
+			cfg.LogOutputs = splitList(f.logOutputs)
+		}
+	})
+	return errs
+}
+
+// parseIDs parses a comma-separated list of int64 ids.
+func parseIDs(raw string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func setFromEnv(dst *string, key string) {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		*dst = v
+	}
+}
+
+func setListFromEnv(dst *[]string, key string) {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		*dst = splitList(v)
+	}
+}
+
+func setIntFromEnv(dst *int, key, field string) error {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s: invalid integer %q (%s)", field, v, key)
+	}
+	*dst = n
+	return nil
+}
+
+func setInt64FromEnv(dst *int64, key, field string) error {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s: invalid integer %q (%s)", field, v, key)
+	}
+	*dst = n
+	return nil
+}
+
+func setDurationFromEnv(dst *time.Duration, key, field string) error {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%s: invalid duration %q (%s)", field, v, key)
+	}
+	*dst = d
+	return nil
+}
+
+func setBoolFromEnv(dst *bool, key, field string) error {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%s: invalid boolean %q (%s)", field, v, key)
+	}
+	*dst = b
+	return nil
+}
+
+func appendErr(errs []error, err error) []error {
+	if err != nil {
+		return append(errs, err)
+	}
+	return errs
+}
+
+// splitList splits a comma-separated value, dropping empty items.
+func splitList(raw string) []string {
+	var out []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
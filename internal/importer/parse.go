@@ -0,0 +1,184 @@
+// Package importer reads track lists exported from other music services.
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxRows bounds how many tracks one import may contain.
+	MaxRows = 500
+	// maxFileSize bounds the uploaded file read into memory.
+	maxFileSize = 4 << 20
+)
+
+// ErrNoRows is returned when a file contains no recognizable tracks.
+var ErrNoRows = errors.New("importer: no tracks found")
+
+// Row is a track reference from an external library export.
+type Row struct {
+	Artist          string
+	Title           string
+	DurationSeconds int
+}
+
+// Column names recognized in CSV headers (lowercased), covering plain
+// artist,title files and Exportify-style Spotify exports.
+var (
+	artistColumns   = []string{"artist", "artists", "artist name", "artist name(s)", "исполнитель", "артист"}
+	titleColumns    = []string{"title", "track", "track name", "name", "song", "название", "трек"}
+	durationColumns = []string{"duration (ms)", "duration_ms"}
+)
+
+// Parse reads a CSV (artist,title with optional header) or a Spotify
+// library JSON export. name is only used to pick the format. Rows beyond
+// MaxRows are dropped and reported via truncated.
+func Parse(name string, r io.Reader) (rows []Row, truncated bool, err error) {
+	raw, err := io.ReadAll(io.LimitReader(r, maxFileSize+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("read import: %w", err)
+	}
+	if len(raw) > maxFileSize {
+		return nil, false, fmt.Errorf("import file exceeds %d MB", maxFileSize>>20)
+	}
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf")) // UTF-8 BOM from spreadsheet exports
+
+	trimmed := bytes.TrimSpace(raw)
+	if strings.HasSuffix(strings.ToLower(name), ".json") || bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")) {
+		rows, err = parseJSON(trimmed)
+	} else {
+		rows, err = parseCSV(raw)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if len(rows) == 0 {
+		return nil, false, ErrNoRows
+	}
+	if len(rows) > MaxRows {
+		return rows[:MaxRows], true, nil
+	}
+	return rows, false, nil
+}
+
+// spotifyLibrary is the shape of Spotify's YourLibrary.json privacy export.
+type spotifyLibrary struct {
+	Tracks []struct {
+		Artist string `json:"artist"`
+		Track  string `json:"track"`
+	} `json:"tracks"`
+}
+
+// plainTrack is the generic [{"artist": ..., "title": ...}] shape.
+type plainTrack struct {
+	Artist string `json:"artist"`
+	Title  string `json:"title"`
+	Track  string `json:"track"`
+}
+
+func parseJSON(raw []byte) ([]Row, error) {
+	var rows []Row
+	if bytes.HasPrefix(raw, []byte("[")) {
+		var list []plainTrack
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, fmt.Errorf("parse json: %w", err)
+		}
+		for _, t := range list {
+			title := t.Title
+			if title == "" {
+				title = t.Track
+			}
+			rows = appendRow(rows, t.Artist, title, 0)
+		}
+		return rows, nil
+	}
+
+	var lib spotifyLibrary
+	if err := json.Unmarshal(raw, &lib); err != nil {
+		return nil, fmt.Errorf("parse json: %w", err)
+	}
+	for _, t := range lib.Tracks {
+		rows = appendRow(rows, t.Artist, t.Track, 0)
+	}
+	return rows, nil
+}
+
+func parseCSV(raw []byte) ([]Row, error) {
+	r := csv.NewReader(bytes.NewReader(raw))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	if first, _, _ := bytes.Cut(raw, []byte("\n")); bytes.Count(first, []byte(";")) > bytes.Count(first, []byte(",")) {
+		r.Comma = ';'
+	}
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse csv: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	artistCol, titleCol, durationCol := 0, 1, -1
+	if a, t, d, ok := headerColumns(records[0]); ok {
+		artistCol, titleCol, durationCol = a, t, d
+		records = records[1:]
+	}
+
+	var rows []Row
+	for _, rec := range records {
+		if artistCol >= len(rec) || titleCol >= len(rec) {
+			continue
+		}
+		duration := 0
+		if durationCol >= 0 && durationCol < len(rec) {
+			if ms, err := strconv.Atoi(strings.TrimSpace(rec[durationCol])); err == nil {
+				duration = ms / 1000
+			}
+		}
+		rows = appendRow(rows, rec[artistCol], rec[titleCol], duration)
+	}
+	return rows, nil
+}
+
+// headerColumns locates artist/title/duration columns in a header record.
+func headerColumns(header []string) (artist, title, duration int, ok bool) {
+	artist, title, duration = -1, -1, -1
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		switch {
+		case artist < 0 && contains(artistColumns, h):
+			artist = i
+		case title < 0 && contains(titleColumns, h):
+			title = i
+		case duration < 0 && contains(durationColumns, h):
+			duration = i
+		}
+	}
+	return artist, title, duration, artist >= 0 && title >= 0
+}
+
+func appendRow(rows []Row, artist, title string, duration int) []Row {
+	artist, title = strings.TrimSpace(artist), strings.TrimSpace(title)
+	if title == "" {
+		return rows
+	}
+	return append(rows, Row{Artist: artist, Title: title, DurationSeconds: duration})
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+// Package match scores how well catalog candidates fit an external track
+// reference, so imports can pick the best candidate and reject weak ones.
+package match
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Threshold is the lowest score accepted as a match.
+	Threshold = 0.6
+	// Confident marks matches that need no manual review.
+	Confident = 0.85
+)
+
+// Track is the (artist, title, duration) tuple being compared. A zero
+// DurationSeconds means unknown and is left out of the score.
+type Track struct {
+	Artist          string
+	Title           string
+	DurationSeconds int
+}
+
+// Score rates got as a match for want in [0, 1]. Title weighs most, then
+// artist, then duration closeness.
+func Score(want, got Track) float64 {
+	title := titleSimilarity(want.Title, got.Title)
+	if want.Artist == "" {
+		return title
+	}
+	artist := Similarity(want.Artist, got.Artist)
+
+	if want.DurationSeconds <= 0 || got.DurationSeconds <= 0 {
+		return 0.65*title + 0.35*artist
+	}
+	return 0.6*title + 0.3*artist + 0.1*durationCloseness(want.DurationSeconds, got.DurationSeconds)
+}
+
+// Best returns the index and score of the highest-scoring candidate, or
+// index -1 when none reaches Threshold.
+func Best(want Track, candidates []Track) (int, float64) {
+	best, bestScore := -1, 0.0
+	for i, c := range candidates {
+		if s := Score(want, c); s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	if bestScore < Threshold {
+		return -1, bestScore
+	}
+	return best, bestScore
+}
+
+// Similarity is the larger of the normalized Levenshtein ratio and the
+// token-set ratio, so both typos and reordered words ("B, A" vs "A & B") score well.
+func Similarity(a, b string) float64 {
+	na, nb := Normalize(a), Normalize(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+	lev := Ratio(na, nb)
+	if ts := TokenSetRatio(na, nb); ts > lev {
+		return ts
+	}
+	return lev
+}
+
+// titleSimilarity compares full titles strictly, so an extra word like
+// "Live" costs something, and falls back to titles without version
+// qualifiers so "Song (Remastered 2011)" still matches "Song".
+func titleSimilarity(a, b string) float64 {
+	full := Ratio(Normalize(a), Normalize(b))
+	if core := Similarity(stripQualifiers(a), stripQualifiers(b)); core*0.95 > full {
+		// Slightly below an exact match: the versions may really differ.
+		return core * 0.95
+	}
+	return full
+}
+
+// Normalize lowercases s, drops punctuation and "feat." credits, and collapses spaces.
+func Normalize(s string) string {
+	s = strings.ToLower(s)
+	s = strings.NewReplacer("ё", "е", "&", " ", " and ", " ", " и ", " ").Replace(s)
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := words[:0]
+	for _, w := range words {
+		if w == "feat" || w == "ft" || w == "featuring" {
+			continue
+		}
+		out = append(out, w)
+	}
+	return strings.Join(out, " ")
+}
+
+// Ratio is 1 minus the Levenshtein distance over the longer length, by runes.
+func Ratio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// TokenSetRatio compares the shared words of a and b against each side's
+// remainder, ignoring word order and duplicates.
+func TokenSetRatio(a, b string) float64 {
+	ta, tb := tokenSet(a), tokenSet(b)
+	var common, onlyA, onlyB []string
+	for w := range ta {
+		if tb[w] {
+			common = append(common, w)
+		} else {
+			onlyA = append(onlyA, w)
+		}
+	}
+	for w := range tb {
+		if !ta[w] {
+			onlyB = append(onlyB, w)
+		}
+	}
+	if len(common) == 0 {
+		return 0
+	}
+	sort.Strings(common)
+	sort.Strings(onlyA)
+	sort.Strings(onlyB)
+
+	base := strings.Join(common, " ")
+	withA := strings.TrimSpace(base + " " + strings.Join(onlyA, " "))
+	withB := strings.TrimSpace(base + " " + strings.Join(onlyB, " "))
+
+	best := Ratio(base, withA)
+	if r := Ratio(base, withB); r > best {
+		best = r
+	}
+	if r := Ratio(withA, withB); r > best {
+		best = r
+	}
+	return best
+}
+
+// durationCloseness is 1 within 3 seconds, falling to 0 at 30 seconds apart.
+func durationCloseness(a, b int) float64 {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+	switch {
+	case diff <= 3:
+		return 1
+	case diff >= 30:
+		return 0
+	}
+	return 1 - float64(diff-3)/27
+}
+
+// stripQualifiers drops bracketed parts and " - Remastered"-style suffixes.
+func stripQualifiers(s string) string {
+	var sb strings.Builder
+	depth := 0
+	for _, r := range s {
+		switch r {
+		case '(', '[':
+			depth++
+			continue
+		case ')', ']':
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+		if depth == 0 {
+			sb.WriteRune(r)
+		}
+	}
+	out := sb.String()
+	if i := strings.Index(out, " - "); i > 0 {
+		out = out[:i]
+	}
+	return out
+}
+
+func tokenSet(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
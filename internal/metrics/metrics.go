@@ -0,0 +1,143 @@
+// Package metrics serves gauges and counters in the Prometheus text
+// exposition format. Values are not kept here: collectors read them from
+// their owners (the download queue, the store, the bots) on every scrape, so
+// a scrape always shows the current state and costs nothing in between.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Collector writes its samples to w on every scrape.
+type Collector func(w *Writer)
+
+// Writer collects the samples of a scrape. Samples of one metric are
+// rendered together, under a single HELP and TYPE, whatever order the
+// collectors write them in.
+type Writer struct {
+	families map[string]*family
+	order    []string
+}
+
+type family struct {
+	help, kind string
+	samples    []string
+}
+
+// Gauge adds a value that goes up and down. labels are name/value pairs.
+func (w *Writer) Gauge(name, help string, value float64, labels ...string) {
+	w.sample(name, "gauge", help, value, labels)
+}
+
+// Counter adds a value that only grows while the process lives.
+func (w *Writer) Counter(name, help string, value float64, labels ...string) {
+	w.sample(name, "counter", help, value, labels)
+}
+
+func (w *Writer) sample(name, kind, help string, value float64, labels []string) {
+	f := w.families[name]
+	if f == nil {
+		f = &family{help: help, kind: kind}
+		w.families[name] = f
+		w.order = append(w.order, name)
+	}
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 1 {
+		sb.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			fmt.Fprintf(&sb, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		sb.WriteByte('}')
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	f.samples = append(f.samples, sb.String())
+}
+
+// writeTo renders the samples in the order their metrics first appeared.
+func (w *Writer) writeTo(out io.Writer) error {
+	bw := bufio.NewWriter(out)
+	for _, name := range w.order {
+		f := w.families[name]
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(f.help), name, f.kind)
+		for _, s := range f.samples {
+			bw.WriteString(s)
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// Bool is 1 for true and 0 for false, for gauges of flags.
+func Bool(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// escapeHelp escapes what the format does not allow in HELP text.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// escapeLabel escapes a label value the way the format requires, which
+// differs from Go quoting: only backslashes, quotes and newlines are escaped.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// Handler serves the samples of collectors at /metrics.
+func Handler(collectors ...Collector) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w := &Writer{families: make(map[string]*family)}
+		for _, c := range collectors {
+			c(w)
+		}
+		_ = w.writeTo(rw)
+	})
+	return mux
+}
+
+// Serve listens on addr until ctx is done, then shuts down gracefully.
+func Serve(ctx context.Context, addr string, h http.Handler, logger *zap.Logger) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() { errs <- srv.ListenAndServe() }()
+	logger.Info("metrics listening", zap.String("addr", addr))
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var (
+	metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	// sampleLine is a sample with optional labels whose values use only the
+	// escapes the format defines.
+	sampleLine = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{(?:[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\\n]|\\[\\"n])*"(?:,[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\\n]|\\[\\"n])*")*)?\})? (\S+)$`)
+)
+
+// checkExposition fails t unless text follows the Prometheus text format:
+// each metric has one HELP and one TYPE line before its samples, which
+// are not split up by other metrics, and every value parses.
+func checkExposition(t *testing.T, text string) {
+	t.Helper()
+	if !strings.HasSuffix(text, "\n") {
+		t.Error("exposition does not end with a newline")
+	}
+	seen := make(map[string]bool)
+	current, typed := "", false
+	for n, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "# HELP "):
+			name, _, _ := strings.Cut(strings.TrimPrefix(line, "# HELP "), " ")
+			if !metricName.MatchString(name) || seen[name] {
+				t.Errorf("line %d: HELP of %q, invalid or repeated", n+1, name)
+			}
+			seen[name], current, typed = true, name, false
+		case strings.HasPrefix(line, "# TYPE "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# TYPE "))
+			if len(fields) != 2 || fields[0] != current || typed {
+				t.Errorf("line %d: %q does not follow the HELP of %s", n+1, line, current)
+			}
+			if len(fields) == 2 && fields[1] != "gauge" && fields[1] != "counter" {
+				t.Errorf("line %d: type %q", n+1, fields[1])
+			}
+			typed = true
+		default:
+			m := sampleLine.FindStringSubmatch(line)
+			if m == nil {
+				t.Errorf("line %d: %q is not a sample", n+1, line)
+				continue
+			}
+			if m[1] != current || !typed {
+				t.Errorf("line %d: sample of %s under %s", n+1, m[1], current)
+			}
+			if _, err := strconv.ParseFloat(m[3], 64); err != nil {
+				t.Errorf("line %d: value %q: %v", n+1, m[3], err)
+			}
+		}
+	}
+}
+
+// TestHandler checks a scrape of collectors that write samples of the same
+// metrics in turn.
+func TestHandler(t *testing.T) {
+	srv := httptest.NewServer(Handler(
+		func(w *Writer) {
+			w.Gauge("ymbot_queue_length", "Jobs waiting.", 3)
+			w.Counter("ymbot_downloads_total", "Downloads by bot.\nCounted \\ once.", 12, "bot", "first_bot")
+		},
+		func(w *Writer) {
+			w.Counter("ymbot_downloads_total", "", 1e6, "bot", `odd "name"`+"\n\t\\бот", "state", "done")
+			w.Gauge("ymbot_paused", "1 while paused.", Bool(true))
+			w.Gauge("ymbot_ratio", "Not a number yet.", math.NaN())
+			w.Gauge("ymbot_limit", "No limit.", math.Inf(1), "odd")
+		},
+	))
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("content type %q", ct)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# HELP ymbot_queue_length Jobs waiting.
+# TYPE ymbot_queue_length gauge
+ymbot_queue_length 3
+# HELP ymbot_downloads_total Downloads by bot.\nCounted \\ once.
+# TYPE ymbot_downloads_total counter
+ymbot_downloads_total{bot="first_bot"} 12
+ymbot_downloads_total{bot="odd \"name\"\n` + "\t" + `\\бот",state="done"} 1e+06
+# HELP ymbot_paused 1 while paused.
+# TYPE ymbot_paused gauge
+ymbot_paused 1
+# HELP ymbot_ratio Not a number yet.
+# TYPE ymbot_ratio gauge
+ymbot_ratio NaN
+# HELP ymbot_limit No limit.
+# TYPE ymbot_limit gauge
+ymbot_limit +Inf
+`
+	if string(body) != want {
+		t.Errorf("scrape:\n%s\nwant:\n%s", body, want)
+	}
+	checkExposition(t, string(body))
+
+	if resp, err := http.Post(srv.URL+"/metrics", "text/plain", nil); err == nil {
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusMethodNotAllowed {
+			t.Errorf("POST answered %d", resp.StatusCode)
+		}
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	cases := map[string]string{
+		"plain":            "plain",
+		`a"b`:              `a\"b`,
+		`a\b`:              `a\\b`,
+		"two\nlines":       `two\nlines`,
+		"tab\tи кириллица": "tab\tи кириллица",
+	}
+	for in, want := range cases {
+		if got := escapeLabel(in); got != want {
+			t.Errorf("escapeLabel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package queue
+
+import "ym-bot/internal/metrics"
+
+// CollectMetrics adds the pool's depth, workers and counters.
+func (p *Pool) CollectMetrics(w *metrics.Writer) {
+	st := p.Stats()
+	w.Gauge("ymbot_queue_waiting", "Download jobs waiting for a worker.", float64(st.Waiting))
+	w.Gauge("ymbot_queue_backlog", "Jobs that may wait before submissions are rejected.", float64(st.Backlog))
+	w.Gauge("ymbot_queue_workers", "Configured download workers.", float64(st.Workers))
+	w.Gauge("ymbot_queue_workers_busy", "Download workers running a job.", float64(st.Busy))
+	w.Gauge("ymbot_queue_paused", "1 while the queue does not start jobs.", metrics.Bool(st.Paused))
+	w.Counter("ymbot_queue_duplicates_total", "Submissions refused because a job with the same key was active.", float64(st.Duplicates))
+	w.Counter("ymbot_queue_rejected_total", "Submissions refused because the backlog was full.", float64(st.Rejected))
+	w.Counter("ymbot_queue_jobs_total", "Jobs run, by outcome.", float64(st.Completed), "outcome", "completed")
+	w.Counter("ymbot_queue_jobs_total", "Jobs run, by outcome.", float64(st.Interrupted), "outcome", "interrupted")
+}
@@ -0,0 +1,376 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when the backlog is at capacity.
+	ErrQueueFull = errors.New("queue is full")
+	// ErrDuplicateKey is returned by Submit when a job with the same key is active.
+	ErrDuplicateKey = errors.New("job key already in use")
+)
+
+// CancelResult reports what Cancel did.
+type CancelResult int
+
+const (
+	// NotFound means no waiting or running job has the key.
+	NotFound CancelResult = iota
+	// Dequeued means the job was removed before it started.
+	Dequeued
+	// Interrupted means the running job's context was cancelled.
+	Interrupted
+)
+
+const (
+	// durationWindow is how many recent job durations feed the ETA average.
+	durationWindow = 20
+	// defaultJobDuration seeds ETA estimates before any job has finished.
+	defaultJobDuration = 10 * time.Second
+)
+
+// Ticket tracks a submitted job while it waits in line.
+type Ticket struct {
+	key     string
+	job     func(context.Context)
+	updates chan int
+	pos     int // 1-based while waiting, 0 once started
+	cancel  context.CancelFunc
+}
+
+// Updates delivers the ticket's new position whenever the line moves; it is
+// closed when the job starts or is dequeued. Only the latest position is kept if the
+// receiver lags behind.
+func (t *Ticket) Updates() <-chan int {
+	return t.updates
+}
+
+// notify publishes pos, replacing an unread stale value.
+func (t *Ticket) notify(pos int) {
+	select {
+	case <-t.updates:
+	default:
+	}
+	t.updates <- pos
+}
+
+// Pool runs submitted jobs in FIFO order on a resizable set of workers with a
+// bounded backlog, and estimates waiting time from recent job durations.
+type Pool struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	ctx     context.Context
+	backlog int
+	pending []*Ticket
+	active  map[string]*Ticket // waiting and running jobs by key
+	target  int                // desired worker count
+	running int                // live worker goroutines
+	busy    int                // workers currently executing a job
+	paused  bool               // jobs are accepted but not started
+	stopped bool
+
+	// Lifetime counts of submissions turned away and jobs run.
+	duplicates  int
+	rejected    int
+	completed   int
+	interrupted int
+
+	durations []time.Duration
+	wg        sync.WaitGroup
+}
+
+// NewPool creates a pool with the given worker count and backlog size.
+// Workers start with Start.
+func NewPool(workers, backlog int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if backlog < 0 {
+		backlog = 0
+	}
+	p := &Pool{target: workers, backlog: backlog, active: make(map[string]*Ticket)}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// Start launches workers; they stop when ctx is done.
+func (p *Pool) Start(ctx context.Context) {
+	p.mu.Lock()
+	p.ctx = ctx
+	for p.running < p.target {
+		p.spawnLocked()
+	}
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		p.stopped = true
+		p.mu.Unlock()
+		p.cond.Broadcast()
+	}()
+}
+
+// Submit enqueues job under key without blocking, failing with ErrQueueFull
+// when saturated. The key identifies the job for Cancel until it finishes.
+func (p *Pool) Submit(key string, job func(context.Context)) (*Ticket, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.active[key]; ok {
+		p.duplicates++
+		return nil, ErrDuplicateKey
+	}
+	if len(p.pending) >= p.backlog+p.idleLocked() {
+		p.rejected++
+		return nil, ErrQueueFull
+	}
+
+	t := &Ticket{key: key, job: job, updates: make(chan int, 1)}
+	p.active[key] = t
+	p.pending = append(p.pending, t)
+	t.pos = len(p.pending)
+	t.notify(t.pos)
+	p.cond.Signal()
+	return t, nil
+}
+
+// Cancel stops the job with key: a waiting job is dropped, a running one has
+// its context cancelled.
+func (p *Pool) Cancel(key string) CancelResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	t, ok := p.active[key]
+	if !ok {
+		return NotFound
+	}
+	if t.cancel != nil {
+		t.cancel()
+		return Interrupted
+	}
+
+	delete(p.active, key)
+	for i, waiting := range p.pending {
+		if waiting == t {
+			p.pending = append(p.pending[:i], p.pending[i+1:]...)
+			break
+		}
+	}
+	close(t.updates)
+	p.renumberLocked()
+	return Dequeued
+}
+
+// Position reports t's 1-based place among jobs waiting for a busy worker,
+// or 0 once it has started or is about to be picked up by an idle one.
+func (p *Pool) Position(t *Ticket) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pos := t.pos - p.idleLocked(); pos > 0 {
+		return pos
+	}
+	return 0
+}
+
+// ETA estimates how long a job at position waits before starting.
+func (p *Pool) ETA(position int) time.Duration {
+	if position <= 0 {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	avg := p.avgLocked()
+	workers := p.target
+	// Each "round" of workers clears `workers` jobs ahead of us.
+	rounds := (position-1)/workers + 1
+	return time.Duration(rounds) * avg
+}
+
+// AvgDuration returns the rolling average job duration.
+func (p *Pool) AvgDuration() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.avgLocked()
+}
+
+// Len reports the number of jobs waiting for a worker.
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
+}
+
+// Workers reports the configured worker count.
+func (p *Pool) Workers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.target
+}
+
+// Stats is a snapshot of the pool's load and lifetime counters.
+type Stats struct {
+	// Waiting jobs have no worker yet; Busy workers run one each.
+	Waiting int
+	Busy    int
+	Workers int
+	Backlog int
+	Paused  bool
+	// Duplicates were refused because a job with their key was active,
+	// Rejected because the backlog was full.
+	Duplicates int
+	Rejected   int
+	// Completed jobs ran to the end, Interrupted ones were cancelled.
+	Completed   int
+	Interrupted int
+}
+
+// Stats reports the pool's current load and counters.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return Stats{
+		Waiting:     len(p.pending),
+		Busy:        p.busy,
+		Workers:     p.target,
+		Backlog:     p.backlog,
+		Paused:      p.paused,
+		Duplicates:  p.duplicates,
+		Rejected:    p.rejected,
+		Completed:   p.completed,
+		Interrupted: p.interrupted,
+	}
+}
+
+// Resize grows or shrinks the worker set at runtime. Surplus workers exit
+// after finishing their current job.
+func (p *Pool) Resize(n int) {
+	if n < 1 {
+		n = 1
+	}
+	p.mu.Lock()
+	p.target = n
+	if p.ctx != nil {
+		for p.running < p.target {
+			p.spawnLocked()
+		}
+	}
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+// Pause stops workers from starting new jobs; running ones finish and
+// submissions keep queueing until Resume.
+func (p *Pool) Pause() {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+}
+
+// Resume lets workers pick up queued jobs again.
+func (p *Pool) Resume() {
+	p.mu.Lock()
+	p.paused = false
+	p.renumberLocked()
+	p.mu.Unlock()
+	p.cond.Broadcast()
+}
+
+// Paused reports whether the pool is paused.
+func (p *Pool) Paused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.paused
+}
+
+// Wait blocks until all workers have exited (after ctx cancellation).
+func (p *Pool) Wait() {
+	p.wg.Wait()
+}
+
+// idleLocked counts workers free to take a job; none while paused.
+func (p *Pool) idleLocked() int {
+	if p.paused {
+		return 0
+	}
+	if idle := p.target - p.busy; idle > 0 {
+		return idle
+	}
+	return 0
+}
+
+func (p *Pool) avgLocked() time.Duration {
+	if len(p.durations) == 0 {
+		return defaultJobDuration
+	}
+	var sum time.Duration
+	for _, d := range p.durations {
+		sum += d
+	}
+	return sum / time.Duration(len(p.durations))
+}
+
+// renumberLocked refreshes positions after the line changes.
+func (p *Pool) renumberLocked() {
+	for i, waiting := range p.pending {
+		waiting.pos = i + 1
+		waiting.notify(waiting.pos)
+	}
+}
+
+func (p *Pool) spawnLocked() {
+	p.running++
+	p.wg.Add(1)
+	go p.work()
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for (len(p.pending) == 0 || p.paused) && !p.stopped && p.running <= p.target {
+			p.cond.Wait()
+		}
+		if p.stopped || p.running > p.target {
+			p.running--
+			p.mu.Unlock()
+			return
+		}
+
+		t := p.pending[0]
+		p.pending = p.pending[1:]
+		p.renumberLocked()
+		ctx, cancel := context.WithCancel(p.ctx)
+		t.pos = 0
+		t.cancel = cancel
+		p.busy++
+		p.mu.Unlock()
+
+		close(t.updates)
+		started := time.Now()
+		t.job(ctx)
+		elapsed := time.Since(started)
+		// Aborted jobs would skew the ETA average.
+		interrupted := ctx.Err() != nil
+		cancel()
+
+		p.mu.Lock()
+		p.busy--
+		delete(p.active, t.key)
+		if interrupted {
+			p.interrupted++
+		} else {
+			p.completed++
+			p.durations = append(p.durations, elapsed)
+			if len(p.durations) > durationWindow {
+				p.durations = p.durations[1:]
+			}
+		}
+		p.mu.Unlock()
+	}
+}
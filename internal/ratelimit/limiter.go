@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// maxIdleBuckets bounds memory: beyond it, refilled (idle) buckets are dropped.
+const maxIdleBuckets = 10000
+
+// Limiter is a per-key token bucket (e.g. keyed by Telegram user id).
+type Limiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens per second
+	burst   float64
+	buckets map[int64]*bucket
+	now     func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New allows perMinute events per key with bursts up to burst.
+// A non-positive perMinute disables limiting.
+func New(perMinute, burst int) *Limiter {
+	l := &Limiter{
+		buckets: make(map[int64]*bucket),
+		now:     time.Now,
+	}
+	l.SetLimits(perMinute, burst)
+	return l
+}
+
+// SetLimits changes the rate at runtime; existing buckets keep their tokens.
+func (l *Limiter) SetLimits(perMinute, burst int) {
+	if burst <= 0 {
+		burst = 1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = float64(perMinute) / 60
+	l.burst = float64(burst)
+}
+
+// Allow consumes a token for key and reports whether the event may proceed.
+func (l *Limiter) Allow(key int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return true
+	}
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxIdleBuckets {
+			l.pruneLocked(now)
+		}
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// pruneLocked drops buckets that would be full by now, i.e. idle keys.
+func (l *Limiter) pruneLocked(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// decodeSize decodes a rendered PNG and returns its size.
+func decodeSize(t *testing.T, raw []byte) image.Point {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	return img.Bounds().Size()
+}
+
+func testCover(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	img.Set(0, 0, color.RGBA{R: 0xff, A: 0xff})
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestTrackCardSize(t *testing.T) {
+	cases := map[string]Track{
+		"cover":         {Title: "Song", Artists: "Band", Duration: "3:45", Cover: testCover(t), Watermark: "@ym_bot"},
+		"no cover":      {Title: "Song", Artists: "Band"},
+		"broken cover":  {Title: "Song", Artists: "Band", Cover: []byte("not an image")},
+		"long and wide": {Title: strings.Repeat("Очень длинное название ", 10), Artists: strings.Repeat("W", 200)},
+		"empty":         {},
+	}
+	for name, tr := range cases {
+		t.Run(name, func(t *testing.T) {
+			raw, err := TrackCard(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := decodeSize(t, raw), image.Pt(trackWidth, trackHeight); got != want {
+				t.Errorf("card is %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestWaveformCardSize(t *testing.T) {
+	peaks := make([]float64, WaveformBars)
+	for i := range peaks {
+		peaks[i] = float64(i) / WaveformBars
+	}
+	for name, w := range map[string]Waveform{
+		"full":     {Title: "Song", Artists: "Band", Peaks: peaks, Duration: 3 * time.Minute, Watermark: "@ym_bot"},
+		"no peaks": {Title: "Song"},
+		"clipped":  {Peaks: []float64{-1, 0, 2}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			raw, err := WaveformCard(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := decodeSize(t, raw), image.Pt(waveWidth, waveHeight); got != want {
+				t.Errorf("card is %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// TestConcurrentCards renders cards in parallel, for the race detector:
+// faces keep caches and must not be shared between images.
+func TestConcurrentCards(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := TrackCard(Track{Title: "Song", Artists: "Band", Duration: "1:00"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFit(t *testing.T) {
+	face, err := Face(Regular, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	width := func(s string) fixed.Int26_6 { return font.MeasureString(face, s) }
+
+	short := "Song"
+	if got := Fit(face, short, 400); got != short {
+		t.Errorf("Fit(%q) = %q, want it unchanged", short, got)
+	}
+	long := strings.Repeat("Длинное название ", 20)
+	got := Fit(face, long, 400)
+	if !strings.HasSuffix(got, "…") || !strings.HasPrefix(long, strings.TrimSuffix(got, "…")) {
+		t.Errorf("Fit(long) = %q, want a prefix with an ellipsis", got)
+	}
+	if width(got) > fixed.I(400) {
+		t.Errorf("Fit(long) is %d px, want at most 400", width(got).Ceil())
+	}
+	if got := Fit(face, long, 1); got != "…" {
+		t.Errorf("Fit into 1 px = %q, want just the ellipsis", got)
+	}
+}
@@ -0,0 +1,95 @@
+// Package render draws the bot's shareable PNG images.
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Weight selects the typeface of a Face.
+type Weight int
+
+const (
+	Regular Weight = iota
+	Bold
+)
+
+// fonts are the parsed Go fonts, which unlike basicfont cover Cyrillic.
+var fonts = sync.OnceValues(func() (map[Weight]*opentype.Font, error) {
+	parsed := make(map[Weight]*opentype.Font)
+	for w, ttf := range map[Weight][]byte{Regular: goregular.TTF, Bold: gobold.TTF} {
+		f, err := opentype.Parse(ttf)
+		if err != nil {
+			return nil, fmt.Errorf("parse font: %w", err)
+		}
+		parsed[w] = f
+	}
+	return parsed, nil
+})
+
+// Face returns the font face of weight at size points. Faces keep glyph
+// caches and are not safe for concurrent use, so every image gets its own.
+func Face(weight Weight, size float64) (font.Face, error) {
+	parsed, err := fonts()
+	if err != nil {
+		return nil, err
+	}
+	face, err := opentype.NewFace(parsed[weight], &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("load font: %w", err)
+	}
+	return face, nil
+}
+
+// Fit shortens s with an ellipsis until it is at most maxW pixels wide.
+func Fit(face font.Face, s string, maxW int) string {
+	limit := fixed.I(maxW)
+	if font.MeasureString(face, s) <= limit {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && font.MeasureString(face, string(r)+"…") > limit {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
+
+// DrawText draws s with its baseline starting at (x, y).
+func DrawText(img draw.Image, face font.Face, x, y int, s string, c color.Color) {
+	d := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: face, Dot: fixed.P(x, y)}
+	d.DrawString(s)
+}
+
+// DrawCentered draws s centered horizontally within [left, right).
+func DrawCentered(img draw.Image, face font.Face, left, right, y int, s string, c color.Color) {
+	DrawText(img, face, left+(right-left-measure(face, s))/2, y, s, c)
+}
+
+// measure is the width of s in pixels.
+func measure(face font.Face, s string) int {
+	return font.MeasureString(face, s).Ceil()
+}
+
+// Blend mixes a into b by t in [0, 1].
+func Blend(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 { return uint8(float64(x) + (float64(y)-float64(x))*t) }
+	return color.RGBA{R: mix(a.R, b.R), G: mix(a.G, b.G), B: mix(a.B, b.B), A: 0xff}
+}
+
+// Gradient fills img top to bottom from top to bottom.
+func Gradient(img *image.RGBA, top, bottom color.RGBA) {
+	r := img.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		c := Blend(top, bottom, float64(y-r.Min.Y)/float64(r.Dy()))
+		draw.Draw(img, image.Rect(r.Min.X, y, r.Max.X, y+1), image.NewUniform(c), image.Point{}, draw.Src)
+	}
+}
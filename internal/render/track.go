@@ -0,0 +1,119 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // covers come as JPEG from the cover service
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// Track is what a track card shows.
+type Track struct {
+	Title   string
+	Artists string
+	// Duration is preformatted, e.g. "3:45"; empty leaves it out.
+	Duration string
+	// Cover is the encoded album art; without it a placeholder is drawn.
+	Cover []byte
+	// Watermark is printed small at the bottom, e.g. the bot's @username.
+	Watermark string
+}
+
+const (
+	trackWidth   = 800
+	trackHeight  = 1000
+	trackCover   = 560
+	trackPadding = 48
+)
+
+var (
+	trackTop         = color.RGBA{R: 0x30, G: 0x30, B: 0x38, A: 0xff}
+	trackBottom      = color.RGBA{R: 0x0c, G: 0x0c, B: 0x10, A: 0xff}
+	trackText        = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	trackMuted       = color.RGBA{R: 0xc8, G: 0xc8, B: 0xd0, A: 0xff}
+	trackPlaceholder = color.RGBA{R: 0x44, G: 0x44, B: 0x50, A: 0xff}
+)
+
+// TrackCard renders a portrait PNG for sharing a track: the cover on a
+// background tinted by it, then title, artists, duration and the watermark.
+// A cover that fails to decode is replaced by the placeholder.
+func TrackCard(t Track) ([]byte, error) {
+	title, err := Face(Bold, 42)
+	if err != nil {
+		return nil, err
+	}
+	text, err := Face(Regular, 30)
+	if err != nil {
+		return nil, err
+	}
+	small, err := Face(Regular, 22)
+	if err != nil {
+		return nil, err
+	}
+
+	var art image.Image
+	if len(t.Cover) > 0 {
+		art, _, _ = image.Decode(bytes.NewReader(t.Cover))
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, trackWidth, trackHeight))
+	top := trackTop
+	if art != nil {
+		top = Blend(average(art), trackBottom, 0.35)
+	}
+	Gradient(img, top, trackBottom)
+
+	x := (trackWidth - trackCover) / 2
+	y := 80
+	frame := image.Rect(x, y, x+trackCover, y+trackCover)
+	if art != nil {
+		draw.CatmullRom.Scale(img, frame, art, art.Bounds(), draw.Src, nil)
+	} else {
+		draw.Draw(img, frame, image.NewUniform(trackPlaceholder), image.Point{}, draw.Src)
+		note, err := Face(Bold, 160)
+		if err != nil {
+			return nil, err
+		}
+		DrawCentered(img, note, frame.Min.X, frame.Max.X, frame.Min.Y+trackCover/2+56, "♪", trackMuted)
+	}
+
+	maxW := trackWidth - 2*trackPadding
+	y = frame.Max.Y + 80
+	DrawCentered(img, title, 0, trackWidth, y, Fit(title, t.Title, maxW), trackText)
+	y += 50
+	DrawCentered(img, text, 0, trackWidth, y, Fit(text, t.Artists, maxW), trackMuted)
+	if t.Duration != "" {
+		y += 46
+		DrawCentered(img, text, 0, trackWidth, y, t.Duration, trackMuted)
+	}
+	if t.Watermark != "" {
+		DrawCentered(img, small, 0, trackWidth, trackHeight-trackPadding, Fit(small, t.Watermark, maxW), trackMuted)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// average is the mean color of a sparse grid of img's pixels.
+func average(img image.Image) color.RGBA {
+	const step = 16
+	b := img.Bounds()
+	var r, g, bl, n uint64
+	for y := b.Min.Y; y < b.Max.Y; y += max(b.Dy()/step, 1) {
+		for x := b.Min.X; x < b.Max.X; x += max(b.Dx()/step, 1) {
+			cr, cg, cb, _ := img.At(x, y).RGBA()
+			r, g, bl, n = r+uint64(cr>>8), g+uint64(cg>>8), bl+uint64(cb>>8), n+1
+		}
+	}
+	if n == 0 {
+		return trackTop
+	}
+	return color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(bl / n), A: 0xff}
+}
@@ -0,0 +1,118 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+// WaveformBars is how many bars a waveform card draws; ask for as many peaks.
+const WaveformBars = 120
+
+// Waveform is what a waveform card shows.
+type Waveform struct {
+	Title   string
+	Artists string
+	// Peaks is the loudness envelope, one bar per value in [0, 1].
+	Peaks []float64
+	// Start and Duration label the time axis with the stretch of the track
+	// the peaks cover; a zero Duration leaves the labels out.
+	Start, Duration time.Duration
+	// Watermark is printed small in the corner, e.g. the bot's @username.
+	Watermark string
+}
+
+const (
+	waveWidth   = 1200
+	waveHeight  = 520
+	wavePadding = 48
+	// waveTop and waveBottom bound the bars.
+	waveTop    = 150
+	waveBottom = 430
+)
+
+var (
+	waveBarTop    = color.RGBA{R: 0xff, G: 0xcc, B: 0x33, A: 0xff}
+	waveBarBottom = color.RGBA{R: 0xff, G: 0x66, B: 0x33, A: 0xff}
+	waveAxis      = color.RGBA{R: 0x50, G: 0x50, B: 0x5a, A: 0xff}
+)
+
+// WaveformCard renders a landscape PNG of a track's loudness over time:
+// title and artists on top, the bars mirrored around their middle line and
+// the time axis below, so verses, choruses and drops stand out.
+func WaveformCard(w Waveform) ([]byte, error) {
+	title, err := Face(Bold, 36)
+	if err != nil {
+		return nil, err
+	}
+	text, err := Face(Regular, 26)
+	if err != nil {
+		return nil, err
+	}
+	small, err := Face(Regular, 20)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, waveWidth, waveHeight))
+	Gradient(img, trackTop, trackBottom)
+	maxW := waveWidth - 2*wavePadding
+	DrawText(img, title, wavePadding, 70, Fit(title, w.Title, maxW), trackText)
+	DrawText(img, text, wavePadding, 110, Fit(text, w.Artists, maxW), trackMuted)
+
+	mid := (waveTop + waveBottom) / 2
+	draw.Draw(img, image.Rect(wavePadding, mid, waveWidth-wavePadding, mid+1), image.NewUniform(waveAxis), image.Point{}, draw.Src)
+	if n := len(w.Peaks); n > 0 {
+		step := float64(maxW) / float64(n)
+		gap := max(int(step/4), 1)
+		for i, p := range w.Peaks {
+			x0 := wavePadding + int(float64(i)*step)
+			x1 := max(wavePadding+int(float64(i+1)*step)-gap, x0+1)
+			// Quiet stretches keep a sliver so the track's length stays visible.
+			h := max(int(p*float64(waveBottom-waveTop)/2), 1)
+			c := Blend(waveBarTop, waveBarBottom, float64(i)/float64(n))
+			draw.Draw(img, image.Rect(x0, mid-h, x1, mid+h), image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+
+	if w.Duration > 0 {
+		const marks = 4
+		for i := 0; i <= marks; i++ {
+			x := wavePadding + maxW*i/marks
+			draw.Draw(img, image.Rect(x, waveBottom+8, x+1, waveBottom+18), image.NewUniform(waveAxis), image.Point{}, draw.Src)
+			label := clock(w.Start + w.Duration*time.Duration(i)/marks)
+			switch i {
+			case 0:
+				DrawText(img, small, x, waveBottom+44, label, trackMuted)
+			case marks:
+				DrawText(img, small, x-measure(small, label), waveBottom+44, label, trackMuted)
+			default:
+				DrawCentered(img, small, x-60, x+60, waveBottom+44, label, trackMuted)
+			}
+		}
+	}
+	if w.Watermark != "" {
+		mark := Fit(small, w.Watermark, maxW/2)
+		DrawText(img, small, waveWidth-wavePadding-measure(small, mark), 70, mark, trackMuted)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// clock formats d as "m:ss", or "h:mm:ss" from an hour on.
+func clock(d time.Duration) string {
+	s := int(d.Round(time.Second) / time.Second)
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
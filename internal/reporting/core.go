@@ -0,0 +1,118 @@
+package reporting
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// tagFields are log fields promoted to searchable tags; the rest of the
+// context goes to the event's extra data.
+var tagFields = map[string]bool{
+	"chatID":  true,
+	"trackID": true,
+	"albumID": true,
+	"bot":     true,
+	"code":    true,
+	"kind":    true,
+}
+
+// Core is a zap core that turns warnings and errors into events. It is meant
+// to be teed with the regular output cores so every Warn, Error and
+// recovered panic logged by the bot is reported with its fields: the user
+// the log concerns, the track, chat and logger name as tags, the error as
+// the exception.
+type Core struct {
+	client *Client
+	level  zapcore.LevelEnabler
+	fields []zapcore.Field
+}
+
+// NewCore reports entries at Warn level and above through client.
+func NewCore(client *Client) *Core {
+	return &Core{client: client, level: zapcore.WarnLevel}
+}
+
+func (c *Core) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl)
+}
+
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	merged := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &Core{client: c.client, level: c.level, fields: merged}
+}
+
+func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	ev := Event{
+		Timestamp: ent.Time,
+		Level:     eventLevel(ent.Level),
+		Logger:    ent.LoggerName,
+		Message:   ent.Message,
+		Tags:      map[string]string{},
+		Extra:     map[string]any{},
+	}
+	if ent.LoggerName != "" {
+		ev.Tags["component"] = ent.LoggerName
+	}
+	if ent.Caller.Defined {
+		ev.Extra["caller"] = ent.Caller.TrimmedPath()
+	}
+	if ent.Stack != "" {
+		ev.Extra["stack"] = ent.Stack
+	}
+	for key, v := range enc.Fields {
+		switch {
+		case key == "userID":
+			ev.User = &User{ID: fmt.Sprint(v)}
+		case key == "error":
+			ev.Exception = &Exceptions{Values: []Exception{{Type: ent.Message, Value: fmt.Sprint(v)}}}
+		case key == "panic":
+			ev.Exception = &Exceptions{Values: []Exception{{Type: "panic", Value: fmt.Sprint(v)}}}
+			ev.Tags["panic"] = "true"
+		case tagFields[key]:
+			ev.Tags[key] = fmt.Sprint(v)
+		default:
+			ev.Extra[key] = v
+		}
+	}
+	c.client.Capture(ev)
+	return nil
+}
+
+// Sync waits briefly for queued events, so a fatal log still gets reported
+// before the process exits. Like Client.Flush, it ends reporting.
+func (c *Core) Sync() error {
+	c.client.Flush(flushTimeout)
+	return nil
+}
+
+func eventLevel(lvl zapcore.Level) string {
+	switch {
+	case lvl >= zapcore.FatalLevel:
+		return "fatal"
+	case lvl >= zapcore.ErrorLevel:
+		return "error"
+	case lvl == zapcore.WarnLevel:
+		return "warning"
+	default:
+		return strings.ToLower(lvl.String())
+	}
+}
@@ -0,0 +1,274 @@
+// Package reporting ships warnings, errors and recovered panics to a Sentry
+// compatible error tracker. It speaks the envelope protocol directly, so the
+// bot needs no SDK: events are queued and posted in the background, and a
+// full queue or an unreachable tracker only costs reports, never log calls.
+package reporting
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clientName identifies the bot in the auth header and in events.
+const clientName = "ym-bot/1.0"
+
+// flushTimeout bounds how long a logger sync waits for queued events.
+const flushTimeout = 2 * time.Second
+
+// Event is one report in the shape of a Sentry event.
+type Event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger,omitempty"`
+	Message     string            `json:"message"`
+	Platform    string            `json:"platform"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	User        *User             `json:"user,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Exception   *Exceptions       `json:"exception,omitempty"`
+}
+
+// User is the Telegram user an event happened for.
+type User struct {
+	ID string `json:"id"`
+}
+
+// Exceptions holds the error an event reports.
+type Exceptions struct {
+	Values []Exception `json:"values"`
+}
+
+// Exception is an error with its type and message.
+type Exception struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// dsn is a parsed "https://key@host/project" address.
+type dsn struct {
+	endpoint string
+	key      string
+}
+
+func parseDSN(raw string) (dsn, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return dsn{}, fmt.Errorf("parse dsn: %w", err)
+	}
+	project := strings.Trim(u.Path, "/")
+	if u.Scheme == "" || u.Host == "" || u.User == nil || u.User.Username() == "" || project == "" {
+		return dsn{}, fmt.Errorf("dsn must look like https://key@host/project, got %q", raw)
+	}
+	// A DSN may carry a path prefix before the project id.
+	prefix := ""
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	return dsn{
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, project),
+		key:      u.User.Username(),
+	}, nil
+}
+
+// Client posts events to the tracker from a background goroutine.
+type Client struct {
+	dsn         dsn
+	httpClient  *http.Client
+	environment string
+	release     string
+	serverName  string
+
+	queue  chan Event
+	errorf func(format string, args ...any)
+
+	// mu orders Capture's pending.Add against Flush's pending.Wait: once
+	// closed is set, no more events are added.
+	mu      sync.Mutex
+	closed  bool
+	pending sync.WaitGroup
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sets the client events are posted with.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) {
+		if c != nil {
+			cl.httpClient = c
+		}
+	}
+}
+
+// WithEnvironment tags events with the deployment they come from.
+func WithEnvironment(env string) Option {
+	return func(c *Client) { c.environment = env }
+}
+
+// WithRelease tags events with the bot version.
+func WithRelease(release string) Option {
+	return func(c *Client) { c.release = release }
+}
+
+// WithServerName tags events with the host they come from.
+func WithServerName(name string) Option {
+	return func(c *Client) { c.serverName = name }
+}
+
+// WithQueueSize sets how many events may wait to be sent; later ones are dropped.
+func WithQueueSize(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.queue = make(chan Event, n)
+		}
+	}
+}
+
+// WithErrorLog sets where failed deliveries are reported. It must not log
+// through a logger that reports back to the client.
+func WithErrorLog(f func(format string, args ...any)) Option {
+	return func(c *Client) {
+		if f != nil {
+			c.errorf = f
+		}
+	}
+}
+
+// NewClient starts a client for the tracker at rawDSN.
+func NewClient(rawDSN string, opts ...Option) (*Client, error) {
+	d, err := parseDSN(rawDSN)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		dsn:        d,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		queue:      make(chan Event, 100),
+		errorf:     func(string, ...any) {},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	go c.run()
+	return c, nil
+}
+
+// Capture queues ev, filling in the fields the client knows. It never
+// blocks: when the queue is full, or the client was flushed, the event is
+// dropped.
+func (c *Client) Capture(ev Event) {
+	if ev.EventID == "" {
+		ev.EventID = newEventID()
+	}
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+	ev.Timestamp = ev.Timestamp.UTC()
+	ev.Platform = "go"
+	if ev.Environment == "" {
+		ev.Environment = c.environment
+	}
+	if ev.Release == "" {
+		ev.Release = c.release
+	}
+	if ev.ServerName == "" {
+		ev.ServerName = c.serverName
+	}
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	c.pending.Add(1)
+	c.mu.Unlock()
+	select {
+	case c.queue <- ev:
+	default:
+		c.pending.Done()
+		c.errorf("reporting: queue full, event dropped: %s", ev.Message)
+	}
+}
+
+// Flush stops accepting events, waits up to timeout for the queued ones to
+// be sent and reports whether they all were. It is meant for shutdown.
+func (c *Client) Flush(timeout time.Duration) bool {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (c *Client) run() {
+	for ev := range c.queue {
+		if err := c.send(ev); err != nil {
+			c.errorf("reporting: send event %s: %v", ev.EventID, err)
+		}
+		c.pending.Done()
+	}
+}
+
+// send posts ev as a single-item envelope.
+func (c *Client) send(ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	var body bytes.Buffer
+	header, _ := json.Marshal(map[string]any{"event_id": ev.EventID, "sent_at": time.Now().UTC()})
+	body.Write(header)
+	body.WriteByte('\n')
+	item, _ := json.Marshal(map[string]any{"type": "event", "length": len(payload)})
+	body.Write(item)
+	body.WriteByte('\n')
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.dsn.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", clientName, c.dsn.key))
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,185 @@
+// Package cover fetches album art in the size each Telegram context needs
+// and re-encodes it as JPEG within Telegram's limits.
+package cover
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // covers are usually JPEG, sometimes PNG or WebP
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"ym-bot/internal/cache"
+)
+
+// Sizes Yandex renders covers in for each place the bot shows them.
+const (
+	// Inline is the thumbnail next to inline results.
+	Inline = 100
+	// Audio is the thumbnail embedded in sent audio; Telegram caps it at 320px.
+	Audio = 320
+	// Card is the large image on track cards.
+	Card = 700
+)
+
+// Telegram rejects audio/document thumbnails over 200 kB; photos may be larger.
+const (
+	maxThumbBytes = 200 << 10
+	maxPhotoBytes = 5 << 20
+	maxSourceSize = 10 << 20
+)
+
+// URL expands a Yandex cover template ("avatars.yandex.net/…/%%") to size.
+func URL(uri string, size int) string {
+	if uri == "" {
+		return ""
+	}
+	px := strconv.Itoa(size)
+	uri = strings.ReplaceAll(uri, "%%", px+"x"+px)
+	if !strings.Contains(uri, "://") {
+		uri = "https://" + uri
+	}
+	return uri
+}
+
+// HTTPClient is the part of http.Client the service needs.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Service downloads covers and caches the converted images.
+type Service struct {
+	client HTTPClient
+	cache  *cache.TTL[[]byte]
+	logger *zap.Logger
+}
+
+// Option customizes a Service.
+type Option func(*Service)
+
+// WithLogger sets the service logger.
+func WithLogger(logger *zap.Logger) Option {
+	return func(s *Service) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithCache overrides how long and how many converted covers are kept.
+func WithCache(ttl time.Duration, max int) Option {
+	return func(s *Service) {
+		s.cache = cache.New[[]byte](ttl, max)
+	}
+}
+
+// NewService builds a cover service; a nil client uses a 10s-timeout default.
+func NewService(client HTTPClient, opts ...Option) *Service {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	s := &Service{
+		client: client,
+		cache:  cache.New[[]byte](6*time.Hour, 500),
+		logger: zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Fetch returns the cover behind uri as a JPEG of at most size×size pixels,
+// small enough to be sent as a thumbnail (Audio and below) or a photo.
+func (s *Service) Fetch(ctx context.Context, uri string, size int) ([]byte, error) {
+	if uri == "" {
+		return nil, fmt.Errorf("no cover")
+	}
+	key := strconv.Itoa(size) + "|" + uri
+	if b, ok := s.cache.Get(key); ok {
+		return b, nil
+	}
+
+	raw, err := s.download(ctx, URL(uri, size))
+	if err != nil {
+		return nil, err
+	}
+	limit := maxPhotoBytes
+	if size <= Audio {
+		limit = maxThumbBytes
+	}
+	out, err := convert(raw, size, limit)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Set(key, out)
+	return out, nil
+}
+
+func (s *Service) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("cover download failed: status=%d", resp.StatusCode)
+	}
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxSourceSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read cover: %w", err)
+	}
+	if len(raw) > maxSourceSize {
+		return nil, fmt.Errorf("cover exceeds %d bytes", maxSourceSize)
+	}
+	return raw, nil
+}
+
+// convert decodes any supported image, scales it down to fit size×size and
+// encodes JPEG, lowering quality until the result fits limit bytes.
+func convert(raw []byte, size, limit int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("decode cover: %w", err)
+	}
+
+	img := src
+	if b := src.Bounds(); b.Dx() > size || b.Dy() > size {
+		w, h := size, size
+		if b.Dx() > b.Dy() {
+			h = b.Dy() * size / b.Dx()
+		} else {
+			w = b.Dx() * size / b.Dy()
+		}
+		dst := image.NewRGBA(image.Rect(0, 0, max(w, 1), max(h, 1)))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+		img = dst
+	}
+
+	var buf bytes.Buffer
+	for quality := 90; quality >= 40; quality -= 10 {
+		buf.Reset()
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("encode cover: %w", err)
+		}
+		if buf.Len() <= limit {
+			return buf.Bytes(), nil
+		}
+	}
+	return nil, fmt.Errorf("cover does not fit %d bytes", limit)
+}
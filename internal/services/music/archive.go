@@ -0,0 +1,106 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/archive"
+	"ym-bot/internal/audio"
+	"ym-bot/internal/client/yandex"
+)
+
+// WithArchive keeps delivered downloads in a, and serves them from it when
+// Yandex no longer does.
+func WithArchive(a *archive.Client) Option {
+	return func(s *Service) {
+		s.archive = a
+	}
+}
+
+// Archives reports whether an archive is configured.
+func (s *Service) Archives() bool {
+	return s.archive != nil
+}
+
+// Archive uploads dl, which must be on disk, to the archive unless the same
+// file is there already. It does nothing without an archive.
+func (s *Service) Archive(ctx context.Context, dl Download) error {
+	if s.archive == nil {
+		return nil
+	}
+	if dl.Path == "" {
+		return errors.New("archive: streamed download has no file")
+	}
+	m, err := s.archive.Meta(ctx, dl.Track.ID)
+	if err == nil && m.Codec == dl.Codec && m.Size == dl.Size {
+		return nil
+	}
+	if err != nil && !errors.Is(err, archive.ErrNotFound) {
+		return err
+	}
+	if err := s.archive.Put(ctx, dl.Path, archive.Meta{Track: dl.Track, Name: filepath.Base(dl.Path), Codec: dl.Codec}); err != nil {
+		return err
+	}
+	s.logger.Debug("download archived", zap.String("trackID", dl.Track.ID), zap.Int64("size", dl.Size))
+	return nil
+}
+
+// unavailable reports whether err means Yandex does not serve the track:
+// it is gone or restricted, rather than Yandex failing to answer.
+func unavailable(err error) bool {
+	return errors.Is(err, yandex.ErrNotFound) || errors.Is(err, yandex.ErrRegionLocked) || errors.Is(err, yandex.ErrSubscriptionRequired)
+}
+
+// restore answers a download of id that Yandex refused with cause from the
+// archive, and returns cause when the track is not archived either. Only
+// unavailable tracks are restored; other failures are Yandex's to retry.
+func (s *Service) restore(ctx context.Context, id string, cause error) (Download, error) {
+	if s.archive == nil || ctx.Err() != nil || !unavailable(cause) {
+		return Download{}, cause
+	}
+	dl, err := s.Restore(ctx, id)
+	if err != nil {
+		if !errors.Is(err, archive.ErrNotFound) {
+			s.logger.Warn("archive restore failed", zap.String("trackID", id), zap.Error(err))
+		}
+		return Download{}, cause
+	}
+	s.logger.Info("download restored from archive", zap.String("trackID", id), zap.NamedError("cause", cause))
+	return dl, nil
+}
+
+// Restore downloads the archived copy of id into a temp file, as
+// DownloadTrack does. It fails with archive.ErrNotFound when there is no
+// archive or the track is not in it.
+func (s *Service) Restore(ctx context.Context, id string) (Download, error) {
+	if s.archive == nil {
+		return Download{}, archive.ErrNotFound
+	}
+	m, err := s.archive.Meta(ctx, id)
+	if err != nil {
+		return Download{}, err
+	}
+
+	tmpDir, err := os.MkdirTemp(s.tempDir, tempPrefix+"*")
+	if err != nil {
+		return Download{}, fmt.Errorf("temp dir: %w", err)
+	}
+	dest := filepath.Join(tmpDir, filepath.Base(m.Name))
+	if err := s.archive.Fetch(ctx, m, dest); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return Download{}, fmt.Errorf("archive fetch: %w", err)
+	}
+	format, _ := audio.SniffFile(dest)
+	return Download{
+		Track:  m.Track,
+		Path:   dest,
+		Codec:  m.Codec,
+		Size:   m.Size,
+		Format: format,
+	}, nil
+}
@@ -0,0 +1,34 @@
+package music
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// chartTTL is how long the chart is cached; Yandex updates it daily.
+const chartTTL = 30 * time.Minute
+
+// chartCache keeps the last fetched chart for empty inline queries.
+type chartCache struct {
+	mu     sync.Mutex
+	tracks []yandex.Track
+	at     time.Time
+}
+
+// Chart returns up to limit top chart tracks, cached for chartTTL.
+func (s *Service) Chart(ctx context.Context, limit int) ([]yandex.Track, error) {
+	s.chart.mu.Lock()
+	defer s.chart.mu.Unlock()
+	if s.chart.tracks == nil || time.Since(s.chart.at) >= chartTTL {
+		tracks, err := s.client.Chart(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.remember(tracks)
+		s.chart.tracks, s.chart.at = tracks, time.Now()
+	}
+	return page(s.chart.tracks, limit, 0), nil
+}
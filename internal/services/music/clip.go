@@ -0,0 +1,50 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"ym-bot/internal/audio"
+)
+
+// ErrNoTranscoder is returned by Clip when no ffmpeg is configured, see
+// WithTranscoder.
+var ErrNoTranscoder = errors.New("no transcoder configured")
+
+// Clip downloads id and cuts length of it, a little before the middle where
+// the chorus usually is, into a tagless MP3. The caller must Close the result.
+func (s *Service) Clip(ctx context.Context, id string, length time.Duration) (Download, error) {
+	if s.ffmpeg == "" {
+		return Download{}, ErrNoTranscoder
+	}
+	dl, err := s.DownloadTrack(ctx, id)
+	if err != nil {
+		return Download{}, err
+	}
+
+	start := clipStart(time.Duration(dl.Track.DurationSeconds)*time.Second, length)
+	dst := filepath.Join(filepath.Dir(dl.Path), "clip.mp3")
+	if err := audio.Clip(ctx, s.ffmpeg, dl.Path, dst, start, length); err != nil {
+		_ = dl.Close()
+		return Download{}, err
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		_ = dl.Close()
+		return Download{}, err
+	}
+	_ = os.Remove(dl.Path)
+
+	format := audio.Format{Container: audio.ContainerMPEG, Codec: "mp3"}
+	return Download{Track: dl.Track, Path: dst, Codec: format.Codec, Size: info.Size(), Format: format}, nil
+}
+
+// clipStart is where a clip of length starts in a track of duration: a
+// little before the middle, where the chorus usually is, and early enough
+// for the clip to fit.
+func clipStart(duration, length time.Duration) time.Duration {
+	return max(0, min(duration*2/5, duration-length))
+}
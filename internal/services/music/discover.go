@@ -0,0 +1,65 @@
+package music
+
+import (
+	"context"
+	"time"
+
+	"ym-bot/internal/cache"
+	"ym-bot/internal/client/yandex"
+)
+
+// discoverTTL is how long editorial playlist lists are cached: menus fetch
+// them on every button press, and Yandex changes them daily at most.
+const discoverTTL = 30 * time.Minute
+
+// editorialKey caches the landing playlists next to the tags' ones.
+const editorialKey = "\x00landing"
+
+// tagPlaylists is a tag's name with its playlists.
+type tagPlaylists struct {
+	name      string
+	playlists []yandex.Playlist
+}
+
+func newDiscoverCache() *cache.TTL[tagPlaylists] {
+	return cache.New[tagPlaylists](discoverTTL, 100)
+}
+
+// EditorialPlaylists returns the playlists Yandex currently promotes,
+// cached for discoverTTL.
+func (s *Service) EditorialPlaylists(ctx context.Context) ([]yandex.Playlist, error) {
+	if cached, ok := s.discover.Get(editorialKey); ok {
+		return cached.playlists, nil
+	}
+	playlists, err := s.client.EditorialPlaylists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.discover.Set(editorialKey, tagPlaylists{playlists: playlists})
+	return playlists, nil
+}
+
+// TagPlaylists returns the name of a mood, activity or genre tag and its
+// editorial playlists, cached for discoverTTL.
+func (s *Service) TagPlaylists(ctx context.Context, tag string) (string, []yandex.Playlist, error) {
+	if cached, ok := s.discover.Get(tag); ok {
+		return cached.name, cached.playlists, nil
+	}
+	name, playlists, err := s.client.TagPlaylists(ctx, tag)
+	if err != nil {
+		return "", nil, err
+	}
+	s.discover.Set(tag, tagPlaylists{name: name, playlists: playlists})
+	return name, playlists, nil
+}
+
+// PublicPlaylistTracks returns playlist kind of owner with its tracks,
+// caching their metadata for the downloads that usually follow.
+func (s *Service) PublicPlaylistTracks(ctx context.Context, owner string, kind int) (yandex.Playlist, []yandex.Track, error) {
+	pl, tracks, err := s.client.PublicPlaylistTracks(ctx, owner, kind)
+	if err != nil {
+		return yandex.Playlist{}, nil, err
+	}
+	s.remember(tracks)
+	return pl, tracks, nil
+}
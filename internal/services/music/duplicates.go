@@ -0,0 +1,68 @@
+package music
+
+import (
+	"strings"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// durationSlack is how far durations of the same recording may differ
+// between releases, in seconds.
+const durationSlack = 2
+
+// collapseDuplicates keeps one track per recording: the same artists, title
+// and version with durations within durationSlack, as Yandex returns a song once
+// per compilation it appears on. The kept track takes the place of the first
+// copy; a playable one wins over a restricted one, then the original album
+// over a compilation. It returns the tracks kept and how many were dropped.
+func collapseDuplicates(tracks []yandex.Track) ([]yandex.Track, int) {
+	out := make([]yandex.Track, 0, len(tracks))
+	groups := make(map[string][]int) // recording key → indexes into out
+	for _, t := range tracks {
+		key := recordingKey(t)
+		dup := -1
+		for _, i := range groups[key] {
+			if abs(out[i].DurationSeconds-t.DurationSeconds) <= durationSlack {
+				dup = i
+				break
+			}
+		}
+		if dup < 0 {
+			groups[key] = append(groups[key], len(out))
+			out = append(out, t)
+			continue
+		}
+		if preferred(t, out[dup]) {
+			out[dup] = t
+		}
+	}
+	return out, len(tracks) - len(out)
+}
+
+// preferred reports whether a is a better copy of a recording than b.
+func preferred(a, b yandex.Track) bool {
+	aPlayable, bPlayable := a.Availability == yandex.Available, b.Availability == yandex.Available
+	if aPlayable != bPlayable {
+		return aPlayable
+	}
+	return b.Compilation && !a.Compilation
+}
+
+// recordingKey identifies a recording regardless of case and spacing.
+func recordingKey(t yandex.Track) string {
+	normalize := func(s string) string {
+		return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	}
+	artists := make([]string, 0, len(t.Artists))
+	for _, a := range t.Artists {
+		artists = append(artists, normalize(a))
+	}
+	return strings.Join(artists, ",") + "\x00" + normalize(t.Title) + "\x00" + normalize(t.Version)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
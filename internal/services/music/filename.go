@@ -0,0 +1,93 @@
+package music
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// DefaultFileNameTemplate renders "Artist - Title".
+const DefaultFileNameTemplate = "{{.Artists}} - {{.Title}}"
+
+// FileNameFields feed file name templates.
+type FileNameFields struct {
+	ID      string
+	Title   string
+	Artists string
+	// Artist is the first artist only, handy for directory names.
+	Artist string
+	Album  string
+	// AlbumArtist is the release's artist from MusicBrainz, e.g. "Various
+	// Artists"; empty without it.
+	AlbumArtist string
+	Genre       string
+	// Year is the album's release year; Disc and Number are the track's
+	// position on the album. All are 0 when unknown.
+	Year   int
+	Disc   int
+	Number int
+	// Index is the 1-based position in an album or playlist, 0 when unknown.
+	Index int
+}
+
+// FileNames builds file names from a text/template. A "/" written in the
+// template separates directories; the same characters coming from track
+// metadata ("AC/DC") are replaced, so they never nest.
+type FileNames struct {
+	tmpl *template.Template
+}
+
+// NewFileNames parses pattern; an empty pattern means DefaultFileNameTemplate.
+func NewFileNames(pattern string) (*FileNames, error) {
+	if strings.TrimSpace(pattern) == "" {
+		pattern = DefaultFileNameTemplate
+	}
+	tmpl, err := template.New("filename").Parse(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("file name template: %w", err)
+	}
+	return &FileNames{tmpl: tmpl}, nil
+}
+
+// Render returns the slash-separated relative path, without extension, for
+// track t at position index (0 when unknown). Every element is sanitized.
+func (f *FileNames) Render(t yandex.Track, index int) (string, error) {
+	artist := ""
+	if len(t.Artists) > 0 {
+		artist = t.Artists[0]
+	}
+	fields := FileNameFields{
+		ID:          cleanValue(t.ID),
+		Title:       cleanValue(t.FullTitle()),
+		Artists:     cleanValue(t.ArtistsString()),
+		Artist:      cleanValue(artist),
+		Album:       cleanValue(t.AlbumTitle),
+		AlbumArtist: cleanValue(t.AlbumArtist),
+		Genre:       cleanValue(t.Genre),
+		Year:        t.Year,
+		Disc:        t.DiscNumber,
+		Number:      t.TrackNumber,
+		Index:       index,
+	}
+
+	var sb strings.Builder
+	if err := f.tmpl.Execute(&sb, fields); err != nil {
+		return "", fmt.Errorf("file name template: %w", err)
+	}
+
+	var parts []string
+	for _, p := range strings.FieldsFunc(strings.ReplaceAll(sb.String(), `\`, "/"), func(r rune) bool { return r == '/' }) {
+		// Dropping empty elements also drops "." and "..", which trim to "".
+		if p = SanitizeName(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 0 {
+		return "", errors.New("file name template produced an empty file name")
+	}
+	return path.Join(parts...), nil
+}
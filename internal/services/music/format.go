@@ -0,0 +1,109 @@
+package music
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/audio"
+)
+
+// streamSniffLen is how much of a stream is peeked to recognise it; enough
+// for the container, not always for the sample rate.
+const streamSniffLen = 16 << 10
+
+// WithTranscoder converts downloads Telegram cannot play inline (raw AAC,
+// Ogg) with the ffmpeg binary at path; lossless files are left alone. Empty
+// disables conversion.
+func WithTranscoder(ffmpeg string) Option {
+	return func(s *Service) {
+		s.ffmpeg = ffmpeg
+	}
+}
+
+// needsTranscode reports whether a file of format f is to be converted.
+func (s *Service) needsTranscode(f audio.Format) bool {
+	return s.ffmpeg != "" && f.Codec != "" && !f.PlaysInline() && !f.Lossless()
+}
+
+// identify sniffs the downloaded file, gives it the extension of what it
+// really holds and converts it when configured to. A file that is not
+// recognised keeps the name derived from the reported codec.
+func (s *Service) identify(ctx context.Context, dl Download) Download {
+	f, err := audio.SniffFile(dl.Path)
+	if err != nil || !f.Known() {
+		s.logger.Debug("audio format not recognised", zap.String("trackID", dl.Track.ID), zap.String("codec", dl.Codec), zap.Error(err))
+		return dl
+	}
+	dl.Format = f
+
+	if ext := filepath.Ext(dl.Path); ext != f.Ext() {
+		s.logger.Debug("audio format differs from codec", zap.String("trackID", dl.Track.ID),
+			zap.String("codec", dl.Codec), zap.String("container", f.Container), zap.String("format", f.Codec))
+		path := strings.TrimSuffix(dl.Path, ext) + f.Ext()
+		if err := os.Rename(dl.Path, path); err != nil {
+			s.logger.Warn("rename download failed", zap.String("path", dl.Path), zap.Error(err))
+		} else {
+			dl.Path = path
+		}
+	}
+
+	if s.needsTranscode(f) {
+		dl = s.transcode(ctx, dl)
+	}
+	return dl
+}
+
+// transcode converts dl for inline playback; on failure the original is kept
+// and goes out as a document.
+func (s *Service) transcode(ctx context.Context, dl Download) Download {
+	target := audio.InlineFormat(dl.Format)
+	dst := strings.TrimSuffix(dl.Path, filepath.Ext(dl.Path)) + target.Ext()
+	if err := audio.Transcode(ctx, s.ffmpeg, dl.Path, dst, dl.Format); err != nil {
+		_ = os.Remove(dst)
+		s.logger.Warn("transcode failed", zap.String("trackID", dl.Track.ID), zap.Error(err))
+		return dl
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		s.logger.Warn("stat transcoded file failed", zap.String("trackID", dl.Track.ID), zap.Error(err))
+		return dl
+	}
+	_ = os.Remove(dl.Path)
+
+	if f, err := audio.SniffFile(dst); err == nil && f.Known() {
+		target = f
+	}
+	s.logger.Debug("transcoded download", zap.String("trackID", dl.Track.ID),
+		zap.String("from", dl.Format.Codec), zap.String("to", target.Codec))
+	dl.Path, dl.Size, dl.Format = dst, info.Size(), target
+	dl.Codec = target.Codec
+	return dl
+}
+
+// sniffedBody keeps the peeked head of a stream in front of the rest.
+type sniffedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// sniffStream recognises the format of a stream without consuming it.
+func sniffStream(body io.ReadCloser) (io.ReadCloser, audio.Format) {
+	br := bufio.NewReaderSize(body, streamSniffLen)
+	// A short read is fine: the head is all there is.
+	head, _ := br.Peek(streamSniffLen)
+	return sniffedBody{Reader: br, Closer: body}, audio.Sniff(head)
+}
+
+// withExt replaces the extension of name with that of f, when f is known.
+func withExt(name string, f audio.Format) string {
+	if !f.Known() {
+		return name
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + f.Ext()
+}
@@ -0,0 +1,139 @@
+package music
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+)
+
+const (
+	// genresTTL is how long the genre catalog is cached; it rarely changes.
+	genresTTL = time.Hour
+	// genreScanPages bounds the search pages scanned to fill a genre-filtered page.
+	genreScanPages = 5
+)
+
+// genreOperator matches the "genre:<id or title>" search operator.
+var genreOperator = regexp.MustCompile(`(?i)(?:^|\s)genre:(\S+)`)
+
+// genreCatalog caches the genre list shared by /genres and the search operator.
+type genreCatalog struct {
+	mu     sync.Mutex
+	genres []yandex.Genre
+	at     time.Time
+}
+
+// Genres returns the genre catalog, cached for genresTTL.
+func (s *Service) Genres(ctx context.Context) ([]yandex.Genre, error) {
+	s.genres.mu.Lock()
+	defer s.genres.mu.Unlock()
+	if s.genres.genres != nil && time.Since(s.genres.at) < genresTTL {
+		return s.genres.genres, nil
+	}
+	genres, err := s.client.Genres(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.genres.genres, s.genres.at = genres, time.Now()
+	return genres, nil
+}
+
+// FindGenre looks a genre or subgenre up by id or title, case-insensitively.
+func (s *Service) FindGenre(ctx context.Context, name string) (yandex.Genre, bool) {
+	genres, err := s.Genres(ctx)
+	if err != nil {
+		s.logger.Debug("genre catalog unavailable", zap.Error(err))
+		return yandex.Genre{}, false
+	}
+	return findGenre(genres, name)
+}
+
+func findGenre(genres []yandex.Genre, name string) (yandex.Genre, bool) {
+	for _, g := range genres {
+		if strings.EqualFold(g.ID, name) || strings.EqualFold(g.Title, name) {
+			return g, true
+		}
+		if sub, ok := findGenre(g.SubGenres, name); ok {
+			return sub, true
+		}
+	}
+	return yandex.Genre{}, false
+}
+
+// GenreTracks returns popular tracks of genre id and caches their metadata.
+func (s *Service) GenreTracks(ctx context.Context, id string) ([]yandex.Track, error) {
+	tracks, err := s.client.GenreTracks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.remember(tracks)
+	return tracks, nil
+}
+
+// splitGenre cuts the genre operator out of query.
+func splitGenre(query string) (rest, genre string) {
+	m := genreOperator.FindStringSubmatchIndex(query)
+	if m == nil {
+		return query, ""
+	}
+	rest = strings.TrimSpace(query[:m[0]] + " " + query[m[1]:])
+	return rest, query[m[2]:m[3]]
+}
+
+// searchGenre serves queries with the genre operator: top tracks of the genre
+// alone, or search results limited to the genre and its subgenres. Offsets
+// count filtered results, so pages are rebuilt by scanning from the start.
+func (s *Service) searchGenre(ctx context.Context, query, name string, limit, offset int) (yandex.SearchResult, error) {
+	g, ok := s.FindGenre(ctx, name)
+	if !ok {
+		return yandex.SearchResult{}, nil
+	}
+
+	if query == "" {
+		tracks, err := s.GenreTracks(ctx, g.ID)
+		if err != nil {
+			return yandex.SearchResult{}, err
+		}
+		return yandex.SearchResult{Tracks: page(tracks, limit, offset)}, nil
+	}
+
+	ids := map[string]struct{}{}
+	var collect func(yandex.Genre)
+	collect = func(g yandex.Genre) {
+		ids[g.ID] = struct{}{}
+		for _, sub := range g.SubGenres {
+			collect(sub)
+		}
+	}
+	collect(g)
+
+	var matched []yandex.Track
+	for p := 0; p < genreScanPages && len(matched) < offset+limit; p++ {
+		res, err := s.client.SearchTracks(ctx, query, limit, p*limit)
+		if err != nil {
+			return yandex.SearchResult{}, err
+		}
+		for _, t := range res.Tracks {
+			if _, ok := ids[t.Genre]; ok {
+				matched = append(matched, t)
+			}
+		}
+		if len(res.Tracks) < limit {
+			break
+		}
+	}
+	return yandex.SearchResult{Tracks: page(matched, limit, offset)}, nil
+}
+
+func page(tracks []yandex.Track, limit, offset int) []yandex.Track {
+	if offset >= len(tracks) {
+		return nil
+	}
+	return tracks[offset:min(offset+limit, len(tracks))]
+}
@@ -0,0 +1,80 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+)
+
+var (
+	// ErrNoISRCMatch means no catalog track was found for an ISRC code.
+	ErrNoISRCMatch = errors.New("no track for isrc")
+	// ErrNoISRCLookup means ISRC codes cannot be looked up without
+	// MusicBrainz, see WithMusicBrainz.
+	ErrNoISRCLookup = errors.New("isrc lookups need musicbrainz")
+)
+
+// NormalizeISRC uppercases code and drops the hyphens and spaces it is often
+// written with, e.g. "us-rc1-76-07839" for USRC17607839. It reports false
+// unless the result has the ISRC shape: a country code, three letters or
+// digits of the registrant, then seven digits.
+func NormalizeISRC(code string) (string, bool) {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != 12 {
+		return "", false
+	}
+	for i, r := range code {
+		letter, digit := r >= 'A' && r <= 'Z', r >= '0' && r <= '9'
+		switch {
+		case i < 2 && !letter,
+			i >= 2 && i < 5 && !letter && !digit,
+			i >= 5 && !digit:
+			return "", false
+		}
+	}
+	return code, true
+}
+
+// FindISRC finds the catalog track of a normalized ISRC code. Yandex does
+// not tell ISRCs, so the code is looked up in MusicBrainz: the recordings it
+// files under the code are matched by artist, title and length, and failing
+// that the top search hit for the code itself is taken if MusicBrainz gives
+// it that ISRC as well. Without MusicBrainz it returns ErrNoISRCLookup; a
+// track it cannot verify is ErrNoISRCMatch.
+func (s *Service) FindISRC(ctx context.Context, code string) (yandex.Track, error) {
+	if s.brainz == nil {
+		return yandex.Track{}, ErrNoISRCLookup
+	}
+	songs, err := s.brainz.ByISRC(ctx, code)
+	if err != nil {
+		return yandex.Track{}, fmt.Errorf("musicbrainz isrc %s: %w", code, err)
+	}
+	if len(songs) == 0 {
+		return yandex.Track{}, ErrNoISRCMatch
+	}
+	for _, song := range songs {
+		m, err := s.MatchTrack(ctx, song.Artist, song.Title, int(song.Duration.Seconds()))
+		if err != nil {
+			s.logger.Debug("isrc recording not matched", zap.String("isrc", code), zap.String("title", song.Title), zap.Error(err))
+			continue
+		}
+		if m.Found() {
+			return m.Track, nil
+		}
+	}
+
+	res, err := s.client.SearchTracks(ctx, code, 1, 0)
+	if err != nil {
+		return yandex.Track{}, err
+	}
+	if len(res.Tracks) == 0 || !strings.EqualFold(s.crossReference(ctx, res.Tracks[0]).ISRC, code) {
+		return yandex.Track{}, ErrNoISRCMatch
+	}
+	s.remember(res.Tracks[:1])
+	return res.Tracks[0], nil
+}
@@ -0,0 +1,49 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ym-bot/internal/cache"
+	"ym-bot/internal/client/yandex"
+)
+
+// lyricsTTL is how long fetched lyrics are kept; they hardly ever change.
+const lyricsTTL = 6 * time.Hour
+
+// ErrNoLyrics reports a track Yandex has no words for.
+var ErrNoLyrics = errors.New("track has no lyrics")
+
+func newLyricsCache() *cache.TTL[yandex.Lyrics] {
+	return cache.New[yandex.Lyrics](lyricsTTL, 500)
+}
+
+// Lyrics returns the track id with its words, timed when Yandex has them so,
+// cached for lyricsTTL.
+func (s *Service) Lyrics(ctx context.Context, id string) (yandex.Track, yandex.Lyrics, error) {
+	tracks, err := s.Tracks(ctx, []string{id})
+	if err != nil {
+		return yandex.Track{}, yandex.Lyrics{}, err
+	}
+	if len(tracks) == 0 {
+		return yandex.Track{}, yandex.Lyrics{}, fmt.Errorf("lyrics %s: %w", id, yandex.ErrNotFound)
+	}
+	t := tracks[0]
+	if l, ok := s.lyrics.Get(t.ID); ok {
+		return t, l, nil
+	}
+	l, err := s.client.Lyrics(ctx, t.ID, t.HasSyncedLyrics)
+	if errors.Is(err, yandex.ErrNotFound) {
+		return t, yandex.Lyrics{}, ErrNoLyrics
+	}
+	if err != nil {
+		return t, yandex.Lyrics{}, err
+	}
+	if l.Text == "" {
+		return t, yandex.Lyrics{}, ErrNoLyrics
+	}
+	s.lyrics.Set(t.ID, l)
+	return t, l, nil
+}
@@ -0,0 +1,51 @@
+package music
+
+import (
+	"context"
+	"strings"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/match"
+)
+
+// matchCandidates is how many search results are scored per reference.
+const matchCandidates = 5
+
+// Match is the best catalog candidate for an external track reference.
+// A zero Track means nothing reached match.Threshold.
+type Match struct {
+	Track yandex.Track
+	Score float64
+}
+
+// Found reports whether the match passed match.Threshold.
+func (m Match) Found() bool {
+	return m.Track.ID != ""
+}
+
+// Confident reports whether the match needs no manual review.
+func (m Match) Confident() bool {
+	return m.Found() && m.Score >= match.Confident
+}
+
+// MatchTrack searches the catalog for artist and title and returns the
+// best-scoring candidate; durationSeconds (0 if unknown) refines the score.
+func (s *Service) MatchTrack(ctx context.Context, artist, title string, durationSeconds int) (Match, error) {
+	res, err := s.client.SearchTracks(ctx, strings.TrimSpace(artist+" "+title), matchCandidates, 0)
+	if err != nil {
+		return Match{}, err
+	}
+
+	want := match.Track{Artist: artist, Title: title, DurationSeconds: durationSeconds}
+	candidates := make([]match.Track, len(res.Tracks))
+	for i, t := range res.Tracks {
+		candidates[i] = match.Track{Artist: t.ArtistsString(), Title: t.Title, DurationSeconds: t.DurationSeconds}
+	}
+
+	best, score := match.Best(want, candidates)
+	if best < 0 {
+		return Match{Score: score}, nil
+	}
+	s.remember(res.Tracks[best : best+1])
+	return Match{Track: res.Tracks[best], Score: score}, nil
+}
@@ -0,0 +1,100 @@
+package music
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/cache"
+	"ym-bot/internal/client/musicbrainz"
+	"ym-bot/internal/client/yandex"
+)
+
+const (
+	// musicBrainzTTL is how long lookups are cached, misses included: the
+	// API allows a request a second, and recordings rarely change.
+	musicBrainzTTL = 24 * time.Hour
+	// musicBrainzTimeout bounds the delay a lookup adds to a download; a
+	// lookup that would wait longer for its turn is not made.
+	musicBrainzTimeout = 2 * time.Second
+	// musicBrainzRetry is how long a failed lookup is not repeated, so an
+	// outage or a busy API does not slow every download.
+	musicBrainzRetry = 10 * time.Minute
+)
+
+// brainzResult is a cached lookup; found is false for tracks MusicBrainz
+// has no confident match for. failed is when the lookup failed, zero for
+// answered ones.
+type brainzResult struct {
+	rec    musicbrainz.Recording
+	found  bool
+	failed time.Time
+}
+
+// WithMusicBrainz fills the ISRC, album artist and a missing year of
+// downloaded tracks from MusicBrainz, and with ffmpeg (see WithTranscoder)
+// writes the ISRC and album artist into the files.
+func WithMusicBrainz(c *musicbrainz.Client) Option {
+	return func(s *Service) {
+		if c != nil {
+			s.brainz = c
+			s.brainzCache = cache.New[brainzResult](musicBrainzTTL, 5000)
+		}
+	}
+}
+
+// crossReference completes t with what MusicBrainz knows about it. Lookups
+// are cached by track id. A slow or failed lookup leaves t as it is and is
+// retried after musicBrainzRetry.
+func (s *Service) crossReference(ctx context.Context, t yandex.Track) yandex.Track {
+	if s.brainz == nil {
+		return t
+	}
+	res, ok := s.brainzCache.Get(t.ID)
+	if ok && !res.failed.IsZero() {
+		if time.Since(res.failed) < musicBrainzRetry {
+			return t
+		}
+		ok = false
+	}
+	if !ok {
+		ctx, cancel := context.WithTimeout(ctx, musicBrainzTimeout)
+		defer cancel()
+		artist := ""
+		if len(t.Artists) > 0 {
+			artist = t.Artists[0]
+		}
+		rec, found, err := s.brainz.Lookup(ctx, musicbrainz.Query{
+			Title:    t.Title,
+			Artist:   artist,
+			Album:    t.AlbumTitle,
+			Duration: time.Duration(t.DurationSeconds) * time.Second,
+		})
+		if err != nil {
+			s.logger.Debug("musicbrainz lookup failed", zap.String("trackID", t.ID), zap.Error(err))
+			s.brainzCache.Set(t.ID, brainzResult{failed: time.Now()})
+			return t
+		}
+		res = brainzResult{rec: rec, found: found}
+		s.brainzCache.Set(t.ID, res)
+	}
+	return res.complete(t)
+}
+
+// complete fills what t lacks from a found recording.
+func (r brainzResult) complete(t yandex.Track) yandex.Track {
+	if !r.found {
+		return t
+	}
+	if t.ISRC == "" {
+		t.ISRC = r.rec.ISRC
+	}
+	if t.AlbumArtist == "" {
+		t.AlbumArtist = r.rec.AlbumArtist
+	}
+	if t.Year == 0 {
+		t.Year = r.rec.Year
+	}
+	return t
+}
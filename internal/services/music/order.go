@@ -0,0 +1,95 @@
+package music
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// Order is how search results are sorted. Yandex pages by relevance, so
+// other orders sort each page on its own.
+type Order string
+
+// Known orders; the zero value keeps Yandex's relevance ranking.
+const (
+	OrderRelevance Order = ""
+	OrderPopular   Order = "popular"
+	OrderNew       Order = "new"
+	OrderShort     Order = "short"
+	OrderLong      Order = "long"
+)
+
+// Orders lists the orders in the sequence settings cycle through them.
+var Orders = []Order{OrderRelevance, OrderPopular, OrderNew, OrderShort, OrderLong}
+
+// orderOperator matches a "!<order>" word in a query, e.g. "queen !new".
+var orderOperator = regexp.MustCompile(`(?i)(?:^|\s)!(\S+)`)
+
+// ParseOrder recognises an order by name; "relevance" is accepted for the
+// default one.
+func ParseOrder(name string) (Order, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "relevance" {
+		return OrderRelevance, true
+	}
+	for _, o := range Orders {
+		if o != OrderRelevance && string(o) == name {
+			return o, true
+		}
+	}
+	return OrderRelevance, false
+}
+
+// splitOrder cuts the order operator out of query; ok is false when query
+// has none, words with an unknown order stay in the query.
+func splitOrder(query string) (rest string, order Order, ok bool) {
+	for _, m := range orderOperator.FindAllStringSubmatchIndex(query, -1) {
+		if o, known := ParseOrder(query[m[2]:m[3]]); known {
+			rest = strings.Join(strings.Fields(query[:m[0]]+" "+query[m[1]:]), " ")
+			return rest, o, true
+		}
+	}
+	return query, OrderRelevance, false
+}
+
+// sortTracks orders tracks in place; ties keep their relevance ranking.
+func sortTracks(tracks []yandex.Track, order Order) {
+	var less func(a, b yandex.Track) bool
+	switch order {
+	case OrderPopular:
+		less = func(a, b yandex.Track) bool { return a.Popularity > b.Popularity }
+	case OrderNew:
+		less = func(a, b yandex.Track) bool { return released(a) > released(b) }
+	case OrderShort:
+		less = func(a, b yandex.Track) bool { return a.DurationSeconds < b.DurationSeconds }
+	case OrderLong:
+		less = func(a, b yandex.Track) bool { return a.DurationSeconds > b.DurationSeconds }
+	default:
+		return
+	}
+	sort.SliceStable(tracks, func(i, j int) bool { return less(tracks[i], tracks[j]) })
+}
+
+// rankPicked moves tracks picked more often ahead, in place; the others
+// keep their relevance ranking.
+func rankPicked(tracks []yandex.Track, picks map[string]int) {
+	if len(picks) == 0 {
+		return
+	}
+	sort.SliceStable(tracks, func(i, j int) bool { return picks[tracks[i].ID] > picks[tracks[j].ID] })
+}
+
+// released is a sortable release date, down to the year when that is all
+// Yandex gave; "" when unknown, which sorts last.
+func released(t yandex.Track) string {
+	if !t.ReleaseDate.IsZero() {
+		return t.ReleaseDate.Format("2006-01-02")
+	}
+	if t.Year > 0 {
+		return fmt.Sprintf("%04d", t.Year)
+	}
+	return ""
+}
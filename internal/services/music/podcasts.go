@@ -0,0 +1,30 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// ErrNotPodcast is returned by Podcast for albums that are music.
+var ErrNotPodcast = errors.New("album is not a podcast")
+
+// Podcast returns podcast id with its episodes, newest first, and caches
+// their metadata for the downloads that usually follow.
+func (s *Service) Podcast(ctx context.Context, id string) (yandex.Album, error) {
+	album, err := s.client.AlbumTracks(ctx, id)
+	if err != nil {
+		return yandex.Album{}, err
+	}
+	if !album.Podcast {
+		return yandex.Album{}, ErrNotPodcast
+	}
+	// Episodes without a date keep their place after the dated ones.
+	sort.SliceStable(album.Tracks, func(i, j int) bool {
+		return album.Tracks[i].Published.After(album.Tracks[j].Published)
+	})
+	s.remember(album.Tracks)
+	return album, nil
+}
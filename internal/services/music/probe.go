@@ -0,0 +1,49 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// maxURLAudioSize is the largest file Telegram fetches by URL.
+const maxURLAudioSize = 20 << 20
+
+// ErrUnfetchable means a direct link points at something Telegram would not
+// take as audio: an error page, another format or an oversized file.
+var ErrUnfetchable = errors.New("link is not fetchable audio")
+
+// ProbeLink checks with the storage host that Telegram can fetch link as an
+// MP3: the answer must be audio/mpeg (or a generic binary type for an mp3
+// link) of a size Telegram accepts. A link that redirects is returned with
+// the URL it ends at, so Telegram has no chain to follow. Error answers
+// are ErrUnfetchable too; a probe that could not be made is not.
+func (s *Service) ProbeLink(ctx context.Context, link yandex.DownloadLink) (yandex.DownloadLink, error) {
+	p, err := s.client.ProbeDownload(ctx, link.URL)
+	var apiErr *yandex.APIError
+	if errors.As(err, &apiErr) {
+		return link, fmt.Errorf("probe %s: %w: %w", link.TrackID, ErrUnfetchable, err)
+	}
+	if err != nil {
+		return link, fmt.Errorf("probe %s: %w", link.TrackID, err)
+	}
+	mediaType, _, _ := mime.ParseMediaType(p.ContentType)
+	switch mediaType {
+	case "audio/mpeg", "audio/mp3":
+	case "application/octet-stream", "binary/octet-stream":
+		if !strings.EqualFold(link.Codec, "mp3") {
+			return link, fmt.Errorf("probe %s: %s content for codec %q: %w", link.TrackID, mediaType, link.Codec, ErrUnfetchable)
+		}
+	default:
+		return link, fmt.Errorf("probe %s: content type %q: %w", link.TrackID, p.ContentType, ErrUnfetchable)
+	}
+	if p.Size == 0 || p.Size > maxURLAudioSize {
+		return link, fmt.Errorf("probe %s: size %d: %w", link.TrackID, p.Size, ErrUnfetchable)
+	}
+	link.URL = p.URL
+	return link, nil
+}
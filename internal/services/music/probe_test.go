@@ -0,0 +1,82 @@
+package music_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+)
+
+// TestProbeLink checks which answers of the storage host make a link
+// ErrUnfetchable, and that a probe that could not be made does not.
+func TestProbeLink(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved.mp3":
+			http.Redirect(w, r, "/song.mp3", http.StatusFound)
+		case "/song.mp3":
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Header().Set("Content-Length", "4410342")
+		case "/binary":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Length", "4410342")
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Content-Length", "512")
+		case "/huge.mp3":
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Header().Set("Content-Length", "52428800")
+		case "/empty.mp3":
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Header().Set("Content-Length", "0")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	svc := music.NewService(yandex.NewClient(srv.Client(), "", nil))
+
+	cases := []struct {
+		path, codec string
+		unfetchable bool
+		url         string // where a fetchable link ends up
+	}{
+		{"/song.mp3", "mp3", false, "/song.mp3"},
+		{"/moved.mp3", "mp3", false, "/song.mp3"},
+		{"/binary", "mp3", false, "/binary"},
+		{"/binary", "aac", true, ""},
+		{"/page.html", "mp3", true, ""},
+		{"/huge.mp3", "mp3", true, ""},
+		{"/empty.mp3", "mp3", true, ""},
+		{"/gone.mp3", "mp3", true, ""},
+	}
+	for _, tc := range cases {
+		link := yandex.DownloadLink{TrackID: "1", Codec: tc.codec, URL: srv.URL + tc.path}
+		got, err := svc.ProbeLink(context.Background(), link)
+		if tc.unfetchable {
+			if !errors.Is(err, music.ErrUnfetchable) {
+				t.Errorf("ProbeLink(%s, %s) = %v, want ErrUnfetchable", tc.path, tc.codec, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ProbeLink(%s, %s): %v", tc.path, tc.codec, err)
+			continue
+		}
+		if got.URL != srv.URL+tc.url {
+			t.Errorf("ProbeLink(%s) URL = %s, want %s", tc.path, got.URL, srv.URL+tc.url)
+		}
+	}
+
+	// Our side failing to reach the host says nothing about the link.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := svc.ProbeLink(ctx, yandex.DownloadLink{TrackID: "1", Codec: "mp3", URL: srv.URL + "/song.mp3"})
+	if err == nil || errors.Is(err, music.ErrUnfetchable) {
+		t.Errorf("ProbeLink with a cancelled context = %v, want an error other than ErrUnfetchable", err)
+	}
+}
@@ -0,0 +1,50 @@
+package music
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/audio"
+)
+
+// Process re-encodes a downloaded file through the selected filters, e.g.
+// loudness normalization or silence trimming (see audio.Process). Streamed
+// downloads and unrecognised files are returned as they are, and so is the
+// original when the pass fails.
+func (s *Service) Process(ctx context.Context, dl Download, filters audio.Filters) Download {
+	if s.ffmpeg == "" || !filters.Any() || dl.Path == "" || !dl.Format.Known() {
+		return dl
+	}
+	target := audio.ProcessedFormat(dl.Format)
+	tmp := filepath.Join(filepath.Dir(dl.Path), "processed"+target.Ext())
+	if err := audio.Process(ctx, s.ffmpeg, dl.Path, tmp, dl.Format, filters); err != nil {
+		_ = os.Remove(tmp)
+		s.logger.Warn("process download failed", zap.String("trackID", dl.Track.ID), zap.Error(err))
+		return dl
+	}
+	// Keep the file name: documents are sent under it.
+	dst := strings.TrimSuffix(dl.Path, filepath.Ext(dl.Path)) + target.Ext()
+	_ = os.Remove(dl.Path)
+	if err := os.Rename(tmp, dst); err != nil {
+		s.logger.Warn("rename processed file failed", zap.String("trackID", dl.Track.ID), zap.Error(err))
+		dst = tmp
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		s.logger.Warn("stat processed file failed", zap.String("trackID", dl.Track.ID), zap.Error(err))
+		return dl
+	}
+
+	if f, err := audio.SniffFile(dst); err == nil && f.Known() {
+		target = f
+	}
+	s.logger.Debug("processed download", zap.String("trackID", dl.Track.ID), zap.String("format", target.Codec),
+		zap.Bool("loudnorm", filters.Loudnorm), zap.Bool("trimSilence", filters.TrimSilence))
+	dl.Path, dl.Size, dl.Format = dst, info.Size(), target
+	dl.Codec = target.Codec
+	return dl
+}
@@ -0,0 +1,90 @@
+package music
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxNameBytes is the file name limit of ext4, NTFS, APFS and most others.
+	maxNameBytes = 255
+	// extReserve keeps room for an extension (".flac") within maxNameBytes.
+	extReserve = 8
+)
+
+// reservedNames are device names Windows refuses as a file name, with or
+// without an extension.
+var reservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// cleanValue replaces what may not appear anywhere in a file name: path
+// separators, characters Windows rejects, control characters and invalid
+// UTF-8. Bidi controls are dropped so a title cannot visually reverse the
+// extension.
+func cleanValue(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.Is(unicode.Bidi_Control, r):
+			return -1
+		case unicode.IsControl(r) || r == utf8.RuneError:
+			return '_'
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, strings.ToValidUTF8(s, "_"))
+}
+
+// SanitizeName makes name safe as a single path element without extension:
+// see cleanValue, plus leading and trailing spaces and dots are trimmed,
+// reserved device names get a "_" prefix and the result is cut to leave room
+// for an extension. It returns "" when nothing usable is left.
+func SanitizeName(name string) string {
+	name = strings.Trim(cleanValue(name), " .")
+	stem, _, _ := strings.Cut(name, ".")
+	if reservedNames[strings.ToUpper(strings.TrimSpace(stem))] {
+		name = "_" + name
+	}
+	return cutName(name, maxNameBytes-extReserve)
+}
+
+// cutName shortens name to at most limit bytes on a rune boundary.
+func cutName(name string, limit int) string {
+	if len(name) <= limit {
+		return name
+	}
+	for limit > 0 && !utf8.RuneStart(name[limit]) {
+		limit--
+	}
+	return strings.TrimRight(name[:limit], " .")
+}
+
+// UniqueNames hands out distinct names within one batch, such as the tracks
+// of an album: a repeated name gets a " (2)", " (3)"… suffix. Names are
+// compared case-insensitively, as on the file systems of Windows and macOS.
+// The zero value is ready to use; it is not safe for concurrent use.
+type UniqueNames struct {
+	taken map[string]bool
+}
+
+// Claim returns p, a slash-separated path without extension, or p with the
+// lowest free numeric suffix on its last element.
+func (u *UniqueNames) Claim(p string) string {
+	if u.taken == nil {
+		u.taken = make(map[string]bool)
+	}
+	name := p
+	dir, base := path.Split(p)
+	for n := 2; u.taken[strings.ToLower(name)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		name = dir + cutName(base, maxNameBytes-extReserve-len(suffix)) + suffix
+	}
+	u.taken[strings.ToLower(name)] = true
+	return name
+}
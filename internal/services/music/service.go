@@ -2,82 +2,797 @@ package music
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"go.uber.org/zap"
 
+	"ym-bot/internal/archive"
+	"ym-bot/internal/audio"
+	"ym-bot/internal/cache"
+	"ym-bot/internal/client/musicbrainz"
 	"ym-bot/internal/client/yandex"
 )
 
+// Download describes a track fetched to local disk.
+type Download struct {
+	Track yandex.Track
+	Path  string
+	Codec string
+	Size  int64
+
+	// Body streams the file straight from Yandex when Path is empty (see
+	// StreamTrack); it can be read only once.
+	Body io.ReadCloser
+	Name string
+
+	// Format is sniffed from the content; zero when not recognised.
+	Format audio.Format
+}
+
+// Lossless reports whether the downloaded file holds a lossless encoding.
+func (d Download) Lossless() bool {
+	if d.Format.Codec != "" {
+		return d.Format.Lossless()
+	}
+	return yandex.DownloadLink{Codec: d.Codec}.Lossless()
+}
+
+// PlaysInline reports whether Telegram can play the file in its audio player;
+// when the stream was not recognised the reported codec is trusted.
+func (d Download) PlaysInline() bool {
+	if d.Format.Codec != "" {
+		return d.Format.PlaysInline()
+	}
+	return !d.Lossless()
+}
+
+// Close releases the download: closes the stream or removes the temp dir.
+func (d Download) Close() error {
+	if d.Body != nil {
+		return d.Body.Close()
+	}
+	if d.Path != "" {
+		return os.RemoveAll(filepath.Dir(d.Path))
+	}
+	return nil
+}
+
+// TrackCache stores track metadata by id (see cache.TTL).
+type TrackCache interface {
+	Get(id string) (yandex.Track, bool)
+	Set(id string, t yandex.Track)
+}
+
+// PickCounter tells how often users picked each track from the results of
+// a query (see storage.Store.Picks).
+type PickCounter interface {
+	Picks(query string) map[string]int
+}
+
 // Service orchestrates music search and download workflow.
 type Service struct {
-	client yandex.Client
-	logger *zap.Logger
+	client  yandex.Client
+	cache   TrackCache
+	logger  *zap.Logger
+	tempDir string
+	genres  genreCatalog
+	chart   chartCache
+	// discover caches editorial playlist lists, see EditorialPlaylists.
+	discover *cache.TTL[tagPlaylists]
+	// suggest caches search completions, see Suggest.
+	suggest *cache.TTL[[]string]
+	// waveforms caches loudness envelopes, see Waveform.
+	waveforms *cache.TTL[[]float64]
+	// lyrics caches track words, see Lyrics.
+	lyrics *cache.TTL[yandex.Lyrics]
+	// albums caches albums with their tracks, see album.
+	albums *cache.TTL[yandex.Album]
+	names  *FileNames
+	ffmpeg string
+	// archive keeps delivered files, see Archive.
+	archive *archive.Client
+	// brainz cross-references downloads, see crossReference.
+	brainz      *musicbrainz.Client
+	brainzCache *cache.TTL[brainzResult]
+	// picks ranks search results, see WithPicks.
+	picks PickCounter
+
+	downloadTimeout time.Duration
+}
+
+// Option customizes a Service.
+type Option func(*Service)
+
+// WithLogger sets the service logger.
+func WithLogger(logger *zap.Logger) Option {
+	return func(s *Service) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithCache caches track metadata to save Yandex round trips.
+func WithCache(c TrackCache) Option {
+	return func(s *Service) {
+		s.cache = c
+	}
+}
+
+// WithPicks ranks the tracks users pick more often for a query ahead of
+// the rest when results are in relevance order.
+func WithPicks(p PickCounter) Option {
+	return func(s *Service) {
+		s.picks = p
+	}
+}
+
+// WithDownloadTimeout bounds transferring one audio file from Yandex.
+func WithDownloadTimeout(d time.Duration) Option {
+	return func(s *Service) {
+		if d > 0 {
+			s.downloadTimeout = d
+		}
+	}
+}
+
+// WithFileNames names downloaded files after f instead of DefaultFileNameTemplate.
+// Only the last path element is used: downloads are handed out as single files.
+func WithFileNames(f *FileNames) Option {
+	return func(s *Service) {
+		if f != nil {
+			s.names = f
+		}
+	}
 }
 
 // NewService constructs a music service instance.
-func NewService(client yandex.Client, logger *zap.Logger) *Service {
-	if logger == nil {
-		logger = zap.NewNop()
+func NewService(client yandex.Client, opts ...Option) *Service {
+	s := &Service{
+		client:          client,
+		logger:          zap.NewNop(),
+		downloadTimeout: 60 * time.Second,
+		discover:        newDiscoverCache(),
+		suggest:         newSuggestCache(),
+		waveforms:       newWaveformCache(),
+		lyrics:          newLyricsCache(),
+		albums:          cache.New[yandex.Album](albumTTL, 500),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.names == nil {
+		s.names, _ = NewFileNames(DefaultFileNameTemplate)
+	}
+	return s
+}
+
+// Search proxies query to Yandex Music with pagination support. A
+// "genre:<name>" operator limits results to that genre; a "!<order>" one,
+// e.g. "!new", overrides order (see Order); "-live", "-remix" and
+// "-remaster" leave such versions out.
+func (s *Service) Search(ctx context.Context, query string, order Order, limit, offset int) (yandex.SearchResult, error) {
+	asked := query
+	if rest, o, ok := splitOrder(query); ok {
+		query, order = rest, o
+	}
+	query, exclude := splitVersions(query)
+	var res yandex.SearchResult
+	var err error
+	if rest, genre := splitGenre(query); genre != "" {
+		res, err = s.searchGenre(ctx, rest, genre, limit, offset)
+	} else {
+		res, err = s.client.SearchTracks(ctx, query, limit, offset)
+		// Results usually lead to a download; spare it the metadata request.
+		s.remember(res.Tracks)
+	}
+	if err != nil {
+		return yandex.SearchResult{}, err
+	}
+	var dropped int
+	res.Tracks, dropped = excludeVersions(res.Tracks, exclude)
+	res.Tracks, res.Collapsed = collapseDuplicates(res.Tracks)
+	res.Collapsed += dropped
+	sortTracks(res.Tracks, order)
+	if order == OrderRelevance && s.picks != nil {
+		rankPicked(res.Tracks, s.picks.Picks(asked))
+	}
+	return res, nil
+}
+
+// SearchAll finds tracks, albums, artists and playlists for query at once,
+// with tracks sorted and filtered as Search does. The genre: operator
+// narrows tracks only, so such queries return just those.
+func (s *Service) SearchAll(ctx context.Context, query string, order Order, limit int) (yandex.Combined, error) {
+	if rest, o, ok := splitOrder(query); ok {
+		query, order = rest, o
+	}
+	query, exclude := splitVersions(query)
+	var res yandex.Combined
+	if rest, genre := splitGenre(query); genre != "" {
+		tracks, err := s.searchGenre(ctx, rest, genre, limit, 0)
+		if err != nil {
+			return yandex.Combined{}, err
+		}
+		res = yandex.Combined{Tracks: tracks.Tracks, Correction: tracks.Correction, Corrected: tracks.Corrected}
+	} else {
+		var err error
+		if res, err = s.client.SearchAll(ctx, query, limit); err != nil {
+			return yandex.Combined{}, err
+		}
+		s.remember(res.Tracks)
+		if res.Best.Type == yandex.ResultTrack {
+			s.remember([]yandex.Track{res.Best.Track})
+		}
 	}
-	return &Service{
-		client: client,
-		logger: logger,
+	res.Tracks, _ = excludeVersions(res.Tracks, exclude)
+	if res.Best.Type == yandex.ResultTrack {
+		if kept, _ := excludeVersions([]yandex.Track{res.Best.Track}, exclude); len(kept) == 0 {
+			res.Best = yandex.BestMatch{}
+		}
 	}
+	res.Tracks, _ = collapseDuplicates(res.Tracks)
+	sortTracks(res.Tracks, order)
+	return res, nil
 }
 
-// Search proxies query to Yandex Music with pagination support.
-func (s *Service) Search(ctx context.Context, query string, limit, offset int) ([]yandex.Track, error) {
-	return s.client.SearchTracks(ctx, query, limit, offset)
+// SearchVideos finds music videos and clips for query.
+func (s *Service) SearchVideos(ctx context.Context, query string, limit, offset int) ([]yandex.Video, error) {
+	return s.client.SearchVideos(ctx, query, limit, offset)
 }
 
-// StreamURL returns track meta and a direct URL for inline playback/download.
-func (s *Service) StreamURL(ctx context.Context, id string) (yandex.Track, string, error) {
-	meta, err := s.client.GetTrack(ctx, id)
+// DirectLink returns a direct audio URL for inline playback, with its
+// expiry when known. Callers already hold the track metadata from search,
+// so none is fetched.
+func (s *Service) DirectLink(ctx context.Context, id string) (yandex.DownloadLink, error) {
+	ids := []string{id}
+	if s.cache != nil {
+		if t, ok := s.cache.Get(id); ok {
+			ids = downloadIDs(t, id)
+		}
+	}
+	link, err := s.freshLink(ctx, ids)
+	if err != nil {
+		return yandex.DownloadLink{}, fmt.Errorf("get download url: %w", err)
+	}
+	return link, nil
+}
+
+// linkMargin is how long before its expiry a download URL is resolved anew:
+// the fetch must start, and Telegram must fetch inline results, in time.
+const linkMargin = 15 * time.Second
+
+// downloadIDs lists the ids to resolve meta's file by, most likely first:
+// the real id, then the track's id and the one it was requested by. They
+// differ for migrated tracks, and download-info may know only one of them.
+func downloadIDs(meta yandex.Track, requested string) []string {
+	var ids []string
+	for _, id := range []string{meta.RealID, meta.ID, requested} {
+		if id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// byTrackID calls get with each of ids in turn while Yandex answers 404.
+func byTrackID[T any](ids []string, get func(id string) (T, error)) (T, error) {
+	var v T
+	err := errors.New("track id is empty")
+	for _, id := range ids {
+		if v, err = get(id); !errors.Is(err, yandex.ErrNotFound) {
+			return v, err
+		}
+	}
+	return v, err
+}
+
+// freshLink resolves the download URL by the first of ids Yandex knows,
+// once more when the first URL is about to expire.
+func (s *Service) freshLink(ctx context.Context, ids []string) (yandex.DownloadLink, error) {
+	link, err := byTrackID(ids, func(id string) (yandex.DownloadLink, error) {
+		return s.client.GetDownloadLink(ctx, id)
+	})
+	if err != nil || !link.Expired(time.Now().Add(linkMargin)) {
+		return link, err
+	}
+	s.logger.Debug("download url expires too soon, resolving again", zap.String("trackID", link.TrackID), zap.Time("expires", link.Expires))
+	return s.client.GetDownloadLink(ctx, link.TrackID)
+}
+
+// fetchLink runs fetch with the URL of link. Resolved URLs are short-lived
+// and a queued or slow download may outlive one, so an expired link is
+// resolved anew first, and a link storage rejects with 403 is resolved anew
+// and retried once, by the id the link was resolved for. link is updated to
+// the one last used.
+func (s *Service) fetchLink(ctx context.Context, link *yandex.DownloadLink, fetch func(url string) error) error {
+	id := link.TrackID
+	if link.Expired(time.Now().Add(linkMargin)) {
+		fresh, err := s.client.GetDownloadLink(ctx, id)
+		if err != nil {
+			return fmt.Errorf("refresh download url: %w", err)
+		}
+		*link = fresh
+	}
+	err := fetch(link.URL)
+	if !yandex.Forbidden(err) {
+		return err
+	}
+	s.logger.Info("download url rejected, retrying with a fresh one", zap.String("trackID", id), zap.Error(err))
+	fresh, rerr := s.client.GetDownloadLink(ctx, id)
+	if rerr != nil {
+		s.logger.Warn("refresh download url failed", zap.String("trackID", id), zap.Error(rerr))
+		return err
+	}
+	*link = fresh
+	return fetch(link.URL)
+}
+
+// losslessKbps approximates FLAC bitrate when download-info reports none.
+const losslessKbps = 900
+
+// Preflight is what a download would fetch, known before fetching it.
+type Preflight struct {
+	Track       yandex.Track
+	Codec       string
+	BitrateKbps int
+	// Size is an estimate from duration and bitrate; 0 when either is unknown.
+	Size int64
+}
+
+// Preflight estimates the file DownloadTrack would fetch for id.
+func (s *Service) Preflight(ctx context.Context, id string) (Preflight, error) {
+	meta, err := s.track(ctx, id)
 	if err != nil {
-		return yandex.Track{}, "", fmt.Errorf("get track meta: %w", err)
+		return Preflight{}, fmt.Errorf("get track meta: %w", err)
 	}
 
-	downloadURL, err := s.client.GetDownloadURL(ctx, id)
+	info, err := byTrackID(downloadIDs(meta, id), func(id string) (yandex.DownloadLink, error) {
+		return s.client.GetDownloadInfo(ctx, id)
+	})
 	if err != nil {
-		return yandex.Track{}, "", fmt.Errorf("get download url: %w", err)
+		return Preflight{}, fmt.Errorf("get download info: %w", err)
 	}
 
-	return meta, downloadURL, nil
+	kbps := bitrate(info)
+	return Preflight{
+		Track:       meta,
+		Codec:       info.Codec,
+		BitrateKbps: kbps,
+		Size:        estimateSize(meta, kbps),
+	}, nil
+}
+
+func bitrate(link yandex.DownloadLink) int {
+	if link.BitrateKbps <= 0 && link.Lossless() {
+		return losslessKbps
+	}
+	return link.BitrateKbps
+}
+
+func estimateSize(meta yandex.Track, kbps int) int64 {
+	return int64(meta.DurationSeconds) * int64(kbps) * 1000 / 8
 }
 
 // DownloadTrack downloads the audio file for the given track id into a temp file.
-// Returns track meta and local file path that caller must remove.
-func (s *Service) DownloadTrack(ctx context.Context, id string) (yandex.Track, string, error) {
-	meta, err := s.client.GetTrack(ctx, id)
+// The returned Download.Path lives in a temp dir that caller must remove.
+// A track Yandex no longer serves comes from the archive, when there is one.
+func (s *Service) DownloadTrack(ctx context.Context, id string) (Download, error) {
+	meta, link, err := s.resolve(ctx, id)
 	if err != nil {
-		return yandex.Track{}, "", fmt.Errorf("get track meta: %w", err)
+		return s.restore(ctx, id, err)
 	}
+	return s.download(ctx, meta, link)
+}
 
-	downloadURL, err := s.client.GetDownloadURL(ctx, id)
+// resolve fetches the metadata and download link of id, the metadata
+// cross-referenced with MusicBrainz when configured. Tracks Yandex marks
+// as region-locked fail without asking for a link; when resolving the link of
+// a restricted track fails, the error carries the restriction's class.
+func (s *Service) resolve(ctx context.Context, id string) (yandex.Track, yandex.DownloadLink, error) {
+	meta, err := s.track(ctx, id)
 	if err != nil {
-		return yandex.Track{}, "", fmt.Errorf("get download url: %w", err)
+		return yandex.Track{}, yandex.DownloadLink{}, fmt.Errorf("get track meta: %w", err)
+	}
+	meta = s.enrich(ctx, meta)
+	restriction := meta.Availability.Err()
+	if meta.Availability == yandex.RegionLocked {
+		return yandex.Track{}, yandex.DownloadLink{}, fmt.Errorf("track %s: %w", id, restriction)
 	}
 
-	tmpDir, err := os.MkdirTemp("", "ym-bot-*")
+	link, err := s.freshLink(ctx, downloadIDs(meta, id))
 	if err != nil {
-		return yandex.Track{}, "", fmt.Errorf("temp dir: %w", err)
+		if restriction != nil && !errors.Is(err, yandex.ErrRegionLocked) && !errors.Is(err, yandex.ErrSubscriptionRequired) {
+			err = fmt.Errorf("%w: %w", restriction, err)
+		}
+		return yandex.Track{}, yandex.DownloadLink{}, fmt.Errorf("get download url: %w", err)
 	}
+	return s.crossReference(ctx, meta), link, nil
+}
 
-	filename := fmt.Sprintf("%s - %s.mp3", meta.ArtistsString(), meta.Title)
-	dest := filepath.Join(tmpDir, filename)
+// StreamTrack opens the audio for id without writing it to disk when the file
+// is at most maxSize bytes; larger files, any file when maxSize is 0 and
+// tracks with tags to write (see tag) go through DownloadTrack. The caller
+// must Close the result.
+func (s *Service) StreamTrack(ctx context.Context, id string, maxSize int64) (Download, error) {
+	meta, link, err := s.resolve(ctx, id)
+	if err != nil {
+		return s.restore(ctx, id, err)
+	}
+	// The estimate spares a request for files that are clearly too large.
+	// Tags are written into a file.
+	if est := estimateSize(meta, bitrate(link)); maxSize <= 0 || est <= 0 || est > maxSize || s.writesTags(meta) {
+		return s.download(ctx, meta, link)
+	}
 
-	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	var body io.ReadCloser
+	var size int64
+	err = s.fetchLink(ctx, &link, func(url string) (err error) {
+		body, size, err = s.client.OpenDownload(ctx, url)
+		return err
+	})
+	if err != nil {
+		err = fmt.Errorf("download: %w", err)
+		tried := []yandex.DownloadLink{link}
+		next, ok := s.nextFormat(ctx, tried, err)
+		if !ok {
+			return Download{}, err
+		}
+		return s.downloadFormats(ctx, meta, next, tried)
+	}
+	if size < 0 || size > maxSize {
+		_ = body.Close()
+		return s.download(ctx, meta, link)
+	}
+	body, format := sniffStream(body)
+	if s.needsTranscode(format) {
+		// Conversion needs the whole file on disk.
+		_ = body.Close()
+		return s.download(ctx, meta, link)
+	}
+
+	return Download{
+		Track:  meta,
+		Codec:  link.Codec,
+		Size:   size,
+		Body:   body,
+		Name:   withExt(s.fileName(meta, link), format),
+		Format: format,
+	}, nil
+}
+
+// download fetches link into a temp file. When that fails it moves on to the
+// next best format of the same download-info response, e.g. a lower mp3
+// bitrate, and reports the first failure only when every format failed.
+func (s *Service) download(ctx context.Context, meta yandex.Track, link yandex.DownloadLink) (Download, error) {
+	return s.downloadFormats(ctx, meta, link, nil)
+}
+
+// downloadFormats is download with the formats already tried.
+func (s *Service) downloadFormats(ctx context.Context, meta yandex.Track, link yandex.DownloadLink, tried []yandex.DownloadLink) (Download, error) {
+	var first error
+	for {
+		dl, err := s.downloadFile(ctx, meta, link)
+		if err == nil {
+			return s.tag(ctx, dl), nil
+		}
+		if first == nil {
+			first = err
+		}
+		tried = append(tried, link)
+		next, ok := s.nextFormat(ctx, tried, err)
+		if !ok {
+			return Download{}, first
+		}
+		link = next
+	}
+}
+
+// nextFormat resolves the best format not in tried, the last of which failed
+// with cause. It reports false when ctx is done or no format is left.
+func (s *Service) nextFormat(ctx context.Context, tried []yandex.DownloadLink, cause error) (yandex.DownloadLink, bool) {
+	if ctx.Err() != nil {
+		return yandex.DownloadLink{}, false
+	}
+	failed := tried[len(tried)-1]
+	next, err := s.client.GetDownloadLink(yandex.WithoutFormats(ctx, tried...), failed.TrackID)
+	if err != nil {
+		if !errors.Is(err, yandex.ErrNoFormatLeft) {
+			s.logger.Debug("resolve fallback format failed", zap.String("trackID", failed.TrackID), zap.Error(err))
+		}
+		return yandex.DownloadLink{}, false
+	}
+	s.logger.Warn("download failed, falling back to another format",
+		zap.String("trackID", failed.TrackID),
+		zap.String("failed", fmt.Sprintf("%s/%d", failed.Codec, failed.BitrateKbps)),
+		zap.String("next", fmt.Sprintf("%s/%d", next.Codec, next.BitrateKbps)),
+		zap.Error(cause))
+	return next, true
+}
+
+// downloadFile fetches link into a temp file.
+func (s *Service) downloadFile(ctx context.Context, meta yandex.Track, link yandex.DownloadLink) (Download, error) {
+	tmpDir, err := os.MkdirTemp(s.tempDir, tempPrefix+"*")
+	if err != nil {
+		return Download{}, fmt.Errorf("temp dir: %w", err)
+	}
+
+	dest := filepath.Join(tmpDir, s.fileName(meta, link))
+
+	ctx, cancel := context.WithTimeout(ctx, s.downloadTimeout)
 	defer cancel()
 
-	if err := s.client.DownloadToFile(ctx, downloadURL, dest); err != nil {
+	err = s.fetchLink(ctx, &link, func(url string) error {
+		return s.client.DownloadToFile(ctx, url, dest)
+	})
+	if err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return Download{}, fmt.Errorf("download: %w", err)
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
 		_ = os.RemoveAll(tmpDir)
-		return yandex.Track{}, "", fmt.Errorf("download: %w", err)
+		return Download{}, fmt.Errorf("stat download: %w", err)
+	}
+
+	return s.identify(ctx, Download{
+		Track: meta,
+		Path:  dest,
+		Codec: link.Codec,
+		Size:  info.Size(),
+	}), nil
+}
+
+// fileName is the base name of the templated path plus the codec extension;
+// a template that renders nothing usable falls back to the track id.
+func (s *Service) fileName(meta yandex.Track, link yandex.DownloadLink) string {
+	return s.baseName(meta) + link.Extension()
+}
+
+// baseName is the last element of the file name template rendered for t,
+// or the track id when the template fails.
+func (s *Service) baseName(t yandex.Track) string {
+	name, err := s.names.Render(t, 0)
+	if err != nil {
+		s.logger.Debug("render file name failed", zap.String("trackID", t.ID), zap.Error(err))
+		return SanitizeName(t.ID)
+	}
+	return path.Base(name)
+}
+
+// Reopen rebuilds the Download of a file kept from an earlier DownloadTrack.
+func (s *Service) Reopen(ctx context.Context, id, path, codec string) (Download, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Download{}, fmt.Errorf("stat download: %w", err)
+	}
+
+	meta, err := s.track(ctx, id)
+	if err != nil {
+		return Download{}, fmt.Errorf("get track meta: %w", err)
+	}
+
+	// The file was identified when downloaded; only the format is recovered.
+	format, _ := audio.SniffFile(path)
+	return Download{
+		Track:  meta,
+		Path:   path,
+		Codec:  codec,
+		Size:   info.Size(),
+		Format: format,
+	}, nil
+}
+
+// track returns metadata for id, consulting the cache first when configured.
+func (s *Service) track(ctx context.Context, id string) (yandex.Track, error) {
+	if s.cache != nil {
+		if t, ok := s.cache.Get(id); ok {
+			return t, nil
+		}
+	}
+
+	t, err := s.client.GetTrack(ctx, id)
+	if err != nil {
+		return yandex.Track{}, err
+	}
+	if s.cache != nil {
+		s.cache.Set(id, t)
+	}
+	return t, nil
+}
+
+// albumTTL is how long albums fetched for their tracks are kept.
+const albumTTL = time.Hour
+
+// album returns album id with its tracks, cached for albumTTL so downloads
+// of several of its tracks fetch it once. The tracks are remembered too.
+func (s *Service) album(ctx context.Context, id string) (yandex.Album, error) {
+	if album, ok := s.albums.Get(id); ok {
+		return album, nil
+	}
+	album, err := s.client.AlbumTracks(ctx, id)
+	if err != nil {
+		return yandex.Album{}, err
+	}
+	s.albums.Set(id, album)
+	s.remember(album.Tracks)
+	return album, nil
+}
+
+// enrich fills the year, genre and album position of t from its album when
+// t's own metadata, e.g. from search, lacks them. The album is cached, see
+// album; a failed lookup keeps t as is.
+func (s *Service) enrich(ctx context.Context, t yandex.Track) yandex.Track {
+	// Genre is left out: some albums have none, and would be fetched every time.
+	if t.AlbumID == "" || (t.Year != 0 && t.TrackNumber != 0) {
+		return t
+	}
+	album, err := s.album(ctx, t.AlbumID)
+	if err != nil {
+		s.logger.Debug("album lookup failed", zap.String("trackID", t.ID), zap.String("albumID", t.AlbumID), zap.Error(err))
+		return t
+	}
+	for _, at := range album.Tracks {
+		if at.ID == t.ID {
+			t.DiscNumber, t.TrackNumber = at.DiscNumber, at.TrackNumber
+			break
+		}
 	}
+	if t.Year == 0 {
+		t.Year = album.Year
+	}
+	if t.Genre == "" {
+		t.Genre = album.Genre
+	}
+	if s.cache != nil {
+		s.cache.Set(t.ID, t)
+	}
+	return t
+}
+
+// Tracks returns metadata for ids in their order, fetching everything the
+// cache misses in a single request. Unknown ids are left out.
+func (s *Service) Tracks(ctx context.Context, ids []string) ([]yandex.Track, error) {
+	found := make(map[string]yandex.Track, len(ids))
+	var missing []string
+	for _, id := range ids {
+		if s.cache != nil {
+			if t, ok := s.cache.Get(id); ok {
+				found[id] = t
+				continue
+			}
+		}
+		missing = append(missing, id)
+	}
+
+	if len(missing) > 0 {
+		fetched, err := s.client.GetTracks(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		s.remember(fetched)
+		for _, t := range fetched {
+			found[t.ID] = t
+		}
+	}
+
+	tracks := make([]yandex.Track, 0, len(ids))
+	for _, id := range ids {
+		if t, ok := found[id]; ok {
+			tracks = append(tracks, t)
+		}
+	}
+	return tracks, nil
+}
+
+// remember caches metadata that arrived as a side effect of another call.
+func (s *Service) remember(tracks []yandex.Track) {
+	if s.cache == nil {
+		return
+	}
+	for _, t := range tracks {
+		s.cache.Set(t.ID, t)
+	}
+}
 
-	return meta, dest, nil
+// NowPlaying is the track the linked account is currently on.
+type NowPlaying struct {
+	Track yandex.Track
+	// Source is where playback started (playlist, album…); may be empty.
+	Source string
+}
+
+// NowPlaying reports what the Yandex account behind the token is playing;
+// it wraps yandex.ErrNothingPlaying when there is no current track.
+func (s *Service) NowPlaying(ctx context.Context) (NowPlaying, error) {
+	q, err := s.client.CurrentQueue(ctx)
+	if err != nil {
+		return NowPlaying{}, err
+	}
+	id, _ := q.Current()
+	meta, err := s.track(ctx, id)
+	if err != nil {
+		return NowPlaying{}, fmt.Errorf("get track meta: %w", err)
+	}
+	return NowPlaying{Track: meta, Source: q.Context}, nil
 }
 
+// Wave returns the next batch of the account's personal station ("Моя
+// волна") after lastTrackID, caching the tracks for the downloads to come.
+func (s *Service) Wave(ctx context.Context, lastTrackID string) (yandex.StationBatch, error) {
+	batch, err := s.client.StationTracks(ctx, yandex.MyWave, lastTrackID)
+	if err != nil {
+		return yandex.StationBatch{}, err
+	}
+	s.remember(batch.Tracks)
+	return batch, nil
+}
+
+// WaveFeedback reports a playback event of the personal station.
+func (s *Service) WaveFeedback(ctx context.Context, f yandex.StationFeedback) error {
+	return s.client.StationFeedback(ctx, yandex.MyWave, f)
+}
+
+// Album returns album id with its tracks, which are cached for later downloads.
+func (s *Service) Album(ctx context.Context, id string) (yandex.Album, error) {
+	return s.album(ctx, id)
+}
+
+// Playlists lists the playlists of the linked account.
+func (s *Service) Playlists(ctx context.Context) ([]yandex.Playlist, error) {
+	return s.client.ListUserPlaylists(ctx)
+}
+
+// PlaylistTracks returns playlist kind with its tracks, caching their metadata
+// for the downloads that usually follow.
+func (s *Service) PlaylistTracks(ctx context.Context, kind int) (yandex.Playlist, []yandex.Track, error) {
+	p, tracks, err := s.client.PlaylistTracks(ctx, kind)
+	if err != nil {
+		return yandex.Playlist{}, nil, err
+	}
+	s.remember(tracks)
+	return p, tracks, nil
+}
+
+// SaveToPlaylist appends track id to playlist kind of the linked account,
+// creating a playlist named title first when kind is 0 or the playlist is
+// gone. The returned playlist tells the caller which kind to reuse.
+func (s *Service) SaveToPlaylist(ctx context.Context, kind int, title, id string) (yandex.Playlist, error) {
+	meta, err := s.track(ctx, id)
+	if err != nil {
+		return yandex.Playlist{}, fmt.Errorf("get track meta: %w", err)
+	}
+
+	if kind != 0 {
+		p, err := s.client.AddTracksToPlaylist(ctx, kind, []yandex.Track{meta})
+		if !errors.Is(err, yandex.ErrPlaylistNotFound) {
+			return p, err
+		}
+		s.logger.Info("playlist gone, creating a new one", zap.Int("kind", kind))
+	}
+
+	p, err := s.client.CreatePlaylist(ctx, title)
+	if err != nil {
+		return yandex.Playlist{}, err
+	}
+	return s.client.AddTracksToPlaylist(ctx, p.Kind, []yandex.Track{meta})
+}
+
+// LikeTracks adds tracks to the liked list of the Yandex account behind the token.
+func (s *Service) LikeTracks(ctx context.Context, ids []string) error {
+	return s.client.LikeTracks(ctx, ids)
+}
+
+// LikedTrackIDs lists the tracks liked by the Yandex account behind the
+// token, most recent first.
+func (s *Service) LikedTrackIDs(ctx context.Context) ([]string, error) {
+	return s.client.LikedTrackIDs(ctx)
+}
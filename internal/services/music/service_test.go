@@ -0,0 +1,252 @@
+package music_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/testfixtures"
+	"ym-bot/internal/translit"
+)
+
+// TestEnrichCachesAlbum checks that downloads of a track whose metadata
+// lacks its album position fetch the album once.
+func TestEnrichCachesAlbum(t *testing.T) {
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "2001", Title: "Song", Artists: []string{"Band"}, Album: "Record", Year: 2001, DurationMs: 180000,
+	})
+	defer env.Close()
+	svc := music.NewService(env.YandexClient(zap.NewNop()), music.WithTempDir(t.TempDir()))
+
+	for i := 0; i < 3; i++ {
+		dl, err := svc.DownloadTrack(context.Background(), "2001")
+		if err != nil {
+			t.Fatalf("download %d: %v", i, err)
+		}
+		if dl.Track.Year != 2001 {
+			t.Errorf("download %d: year %d, want the album's 2001", i, dl.Track.Year)
+		}
+		dl.Close()
+	}
+	if hits := env.Yandex.Hits("/albums/"); hits != 1 {
+		t.Errorf("album requested %d times, want 1", hits)
+	}
+}
+
+// TestDownloadRealIDFallback checks that a track whose realId download-info
+// does not know is downloaded by its id instead.
+func TestDownloadRealIDFallback(t *testing.T) {
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "2002", RealID: "2003", Title: "Moved", Artists: []string{"Band"}, DurationMs: 180000,
+	})
+	defer env.Close()
+	svc := music.NewService(env.YandexClient(zap.NewNop()), music.WithTempDir(t.TempDir()))
+
+	pre, err := svc.Preflight(context.Background(), "2002")
+	if err != nil {
+		t.Fatalf("preflight: %v", err)
+	}
+	if pre.Codec != "mp3" {
+		t.Errorf("preflight codec %q, want mp3", pre.Codec)
+	}
+	dl, err := svc.DownloadTrack(context.Background(), "2002")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	dl.Close()
+	// Each resolution tries the real id first, then falls back.
+	if hits := env.Yandex.Hits("/tracks/2003/download-info"); hits != 2 {
+		t.Errorf("download-info by the real id requested %d times, want 2", hits)
+	}
+	if hits := env.Yandex.Hits("/tracks/2002/download-info"); hits != 2 {
+		t.Errorf("download-info by the id requested %d times, want 2", hits)
+	}
+}
+
+func TestSplitParts(t *testing.T) {
+	cases := []struct {
+		length, part time.Duration
+		want         int
+	}{
+		{3 * time.Hour, time.Hour, 3},
+		{3*time.Hour + time.Second, time.Hour, 4},
+		{59 * time.Minute, time.Hour, 1},
+		{time.Hour, time.Hour, 1},
+		{90 * time.Minute, 45 * time.Minute, 2},
+		{100 * time.Minute, 45 * time.Minute, 3},
+		{0, time.Hour, 1},
+		// Splitting switched off.
+		{3 * time.Hour, 0, 1},
+		{3 * time.Hour, -time.Hour, 1},
+	}
+	for _, tc := range cases {
+		if got := music.SplitParts(tc.length, tc.part); got != tc.want {
+			t.Errorf("SplitParts(%s, %s) = %d, want %d", tc.length, tc.part, got, tc.want)
+		}
+	}
+}
+
+func TestPreviewClip(t *testing.T) {
+	cases := []struct {
+		duration, start, length time.Duration
+	}{
+		{3 * time.Minute, 72 * time.Second, 30 * time.Second},
+		// Late enough in a short track for the clip to fit.
+		{40 * time.Second, 10 * time.Second, 30 * time.Second},
+		{20 * time.Second, 0, 20 * time.Second},
+		// Unknown length: from the start.
+		{0, 0, 30 * time.Second},
+	}
+	for _, tc := range cases {
+		start, length := music.PreviewClip(tc.duration)
+		if start != tc.start || length != tc.length {
+			t.Errorf("PreviewClip(%s) = %s, %s, want %s, %s", tc.duration, start, length, tc.start, tc.length)
+		}
+	}
+}
+
+// TestFileName checks that files sent along with a track, such as lyrics,
+// are named as its download, in the script it is labelled in.
+func TestFileName(t *testing.T) {
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "2004", Title: "Песня", Artists: []string{"Группа"}, Album: "Альбом", Year: 1999, DurationMs: 180000,
+	})
+	defer env.Close()
+	names, err := music.NewFileNames("{{.Year}} {{.Artists}} - {{.Title}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := music.NewService(env.YandexClient(zap.NewNop()), music.WithTempDir(t.TempDir()), music.WithFileNames(names))
+	ctx := context.Background()
+
+	// Search results and lyrics carry the track without its album's year.
+	tracks, err := svc.Tracks(ctx, []string{"2004"})
+	if err != nil || len(tracks) != 1 {
+		t.Fatalf("tracks: %v, %v", tracks, err)
+	}
+	dl, err := svc.DownloadTrack(ctx, "2004")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	defer dl.Close()
+
+	name := filepath.Base(dl.Path)
+	if got := svc.FileName(ctx, tracks[0], translit.None) + filepath.Ext(name); got != name {
+		t.Errorf("FileName = %q, want the download's %q", got, name)
+	}
+	latin := svc.Relabel(ctx, dl, music.Transliterate(dl.Track, translit.ToLatin))
+	if got := svc.FileName(ctx, tracks[0], translit.ToLatin) + filepath.Ext(latin.Name); got != latin.Name {
+		t.Errorf("latin FileName = %q, want the relabelled %q", got, latin.Name)
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	track := yandex.Track{
+		ID: "2005", Title: "Звезда", Version: "Live", AlbumTitle: "Группа крови", AlbumArtist: "Кино",
+		Artists: []string{"Кино", "Sting"},
+		Credits: []yandex.Credit{{ID: "7", Name: "Виктор Цой", Role: yandex.RoleComposer}},
+	}
+	got := music.Transliterate(track, translit.ToLatin)
+	want := yandex.Track{
+		ID: "2005", Title: "Zvezda", Version: "Live", AlbumTitle: "Gruppa krovi", AlbumArtist: "Kino",
+		Artists: []string{"Kino", "Sting"},
+		Credits: []yandex.Credit{{ID: "7", Name: "Viktor Tsoy", Role: yandex.RoleComposer}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Transliterate = %+v, want %+v", got, want)
+	}
+	// The caller's track keeps its names.
+	if track.Artists[0] != "Кино" || track.Credits[0].Name != "Виктор Цой" {
+		t.Errorf("Transliterate changed its argument: %+v", track)
+	}
+	if got := music.Transliterate(track, translit.None); !reflect.DeepEqual(got, track) {
+		t.Errorf("Transliterate(None) = %+v, want the track unchanged", got)
+	}
+	if got := music.Transliterate(track, translit.ToCyrillic).Artists; !reflect.DeepEqual(got, []string{"Кино", "Стинг"}) {
+		t.Errorf("Transliterate(ToCyrillic) artists = %q", got)
+	}
+}
+
+// TestRelabel checks that a download is renamed after the track it is
+// shown as while keeping the catalog's track.
+func TestRelabel(t *testing.T) {
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "2006", Title: "Звезда", Artists: []string{"Кино"}, DurationMs: 180000,
+	})
+	defer env.Close()
+	svc := music.NewService(env.YandexClient(zap.NewNop()), music.WithTempDir(t.TempDir()))
+	ctx := context.Background()
+
+	dl, err := svc.DownloadTrack(ctx, "2006")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	defer dl.Close()
+	relabelled := svc.Relabel(ctx, dl, music.Transliterate(dl.Track, translit.ToLatin))
+	if relabelled.Name != "Kino - Zvezda.mp3" || filepath.Base(relabelled.Path) != relabelled.Name {
+		t.Errorf("relabelled to name %q, path %q; want Kino - Zvezda.mp3", relabelled.Name, relabelled.Path)
+	}
+	if relabelled.Track.Title != "Звезда" {
+		t.Errorf("relabelled track title %q, want the catalog's", relabelled.Track.Title)
+	}
+	if _, err := os.Stat(relabelled.Path); err != nil {
+		t.Errorf("relabelled file: %v", err)
+	}
+
+	// A streamed download only gets the name.
+	streamed := music.Download{Track: dl.Track, Name: "Кино - Звезда.mp3", Body: io.NopCloser(strings.NewReader("audio"))}
+	streamed = svc.Relabel(ctx, streamed, music.Transliterate(dl.Track, translit.ToLatin))
+	if streamed.Name != "Kino - Zvezda.mp3" || streamed.Path != "" {
+		t.Errorf("streamed download relabelled to name %q, path %q", streamed.Name, streamed.Path)
+	}
+}
+
+func TestNormalizeISRC(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"USRC17607839", "USRC17607839", true},
+		{"us-rc1-76-07839", "USRC17607839", true},
+		{"GB AYE 64 00112", "GBAYE6400112", true},
+		{"USRC1760783", "", false},
+		{"USRC176078390", "", false},
+		// A digit in the country code.
+		{"U1RC17607839", "", false},
+		// A letter among the last seven.
+		{"USRC1760783X", "", false},
+		{"US_C17607839", "", false},
+		{"", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := music.NormalizeISRC(tc.in)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("NormalizeISRC(%q) = %q, %t, want %q, %t", tc.in, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+// TestFindISRCWithoutMusicBrainz checks that codes are not guessed from a
+// plain catalog search when nothing can verify the hit.
+func TestFindISRCWithoutMusicBrainz(t *testing.T) {
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "2007", Title: "USRC17607839", Artists: []string{"Band"}, DurationMs: 180000,
+	})
+	defer env.Close()
+	svc := music.NewService(env.YandexClient(zap.NewNop()), music.WithTempDir(t.TempDir()))
+
+	if _, err := svc.FindISRC(context.Background(), "USRC17607839"); !errors.Is(err, music.ErrNoISRCLookup) {
+		t.Errorf("FindISRC = %v, want ErrNoISRCLookup", err)
+	}
+}
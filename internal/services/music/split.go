@@ -0,0 +1,82 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ym-bot/internal/audio"
+)
+
+// SplitParts is how many parts of at most part a track of length splits into.
+func SplitParts(length, part time.Duration) int {
+	if part <= 0 || length <= part {
+		return 1
+	}
+	return int((length + part - 1) / part)
+}
+
+// CanTranscode reports whether an ffmpeg is configured for Clip, Split,
+// Process and Waveform.
+func (s *Service) CanTranscode() bool {
+	return s.ffmpeg != ""
+}
+
+// Split cuts a downloaded file, without re-encoding, into SplitParts parts
+// of equal length, in order; long mixes and audiobooks are unwieldy as one
+// file and may exceed upload limits. The parts share the temp dir of dl:
+// closing any of them removes them all. On failure dl is closed.
+func (s *Service) Split(ctx context.Context, dl Download, part time.Duration) ([]Download, error) {
+	id := dl.Track.ID
+	if s.ffmpeg == "" {
+		_ = dl.Close()
+		return nil, ErrNoTranscoder
+	}
+	if dl.Path == "" {
+		_ = dl.Close()
+		return nil, fmt.Errorf("split %s: no file to split", id)
+	}
+
+	length := time.Duration(dl.Track.DurationSeconds) * time.Second
+	n := SplitParts(length, part)
+	if n < 2 {
+		return []Download{dl}, nil
+	}
+	// A second of slack keeps a rounded-down duration from leaving a sliver of a last part.
+	segment := length/time.Duration(n) + time.Second
+	ext := filepath.Ext(dl.Path)
+	dir := filepath.Dir(dl.Path)
+	if err := audio.Split(ctx, s.ffmpeg, dl.Path, filepath.Join(dir, "part%03d"+ext), segment); err != nil {
+		_ = dl.Close()
+		return nil, err
+	}
+	_ = os.Remove(dl.Path)
+
+	files, err := filepath.Glob(filepath.Join(dir, "part[0-9][0-9][0-9]"+ext))
+	if err != nil || len(files) == 0 {
+		_ = dl.Close()
+		return nil, fmt.Errorf("split %s: no parts written", id)
+	}
+	base := strings.TrimSuffix(dl.Path, ext)
+	parts := make([]Download, 0, len(files))
+	for i, file := range files {
+		path := fmt.Sprintf("%s (%d из %d)%s", base, i+1, len(files), ext)
+		if err := os.Rename(file, path); err != nil {
+			_ = dl.Close()
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			_ = dl.Close()
+			return nil, err
+		}
+		p := dl
+		p.Path, p.Size = path, info.Size()
+		p.Track.DurationSeconds = int(min(segment, length-time.Duration(i)*segment) / time.Second)
+		parts = append(parts, p)
+	}
+	return parts, nil
+}
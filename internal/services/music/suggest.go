@@ -0,0 +1,35 @@
+package music
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"ym-bot/internal/cache"
+)
+
+// suggestTTL is how long completions are cached. Short prefixes are few and
+// typed by everyone, so most typeahead queries never reach Yandex.
+const suggestTTL = 10 * time.Minute
+
+func newSuggestCache() *cache.TTL[[]string] {
+	return cache.New[[]string](suggestTTL, 1000)
+}
+
+// Suggest returns up to limit completions of a partly typed query, cached
+// for suggestTTL by its lowercase form.
+func (s *Service) Suggest(ctx context.Context, part string, limit int) ([]string, error) {
+	key := strings.ToLower(strings.TrimSpace(part))
+	if key == "" {
+		return nil, nil
+	}
+	suggestions, ok := s.suggest.Get(key)
+	if !ok {
+		var err error
+		if suggestions, err = s.client.SearchSuggest(ctx, key); err != nil {
+			return nil, err
+		}
+		s.suggest.Set(key, suggestions)
+	}
+	return suggestions[:min(limit, len(suggestions))], nil
+}
@@ -0,0 +1,67 @@
+package music
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/audio"
+	"ym-bot/internal/client/yandex"
+)
+
+// lyricsTagTimeout bounds fetching the words tag embeds.
+const lyricsTagTimeout = 5 * time.Second
+
+// writesTags reports whether tag has anything to write into a file of t:
+// the ISRC and album artist from MusicBrainz, or the words of a track
+// Yandex has lyrics for.
+func (s *Service) writesTags(t yandex.Track) bool {
+	if s.ffmpeg == "" {
+		return false
+	}
+	return t.HasLyrics || s.brainz != nil && (t.ISRC != "" || t.AlbumArtist != "")
+}
+
+// tag writes into dl's file what Yandex's files lack: the ISRC and album
+// artist MusicBrainz gave its track, and the plain words, which go into
+// the USLT frame of MP3 files. A failed rewrite keeps the file as
+// downloaded.
+func (s *Service) tag(ctx context.Context, dl Download) Download {
+	if dl.Path == "" || !dl.Format.Known() || !s.writesTags(dl.Track) {
+		return dl
+	}
+	var tags audio.Tags
+	if s.brainz != nil {
+		tags.ISRC, tags.AlbumArtist = dl.Track.ISRC, dl.Track.AlbumArtist
+	}
+	if dl.Track.HasLyrics {
+		lctx, cancel := context.WithTimeout(ctx, lyricsTagTimeout)
+		_, l, err := s.Lyrics(lctx, dl.Track.ID)
+		cancel()
+		if err != nil && !errors.Is(err, ErrNoLyrics) {
+			s.logger.Debug("lyrics for tags failed", zap.String("trackID", dl.Track.ID), zap.Error(err))
+		}
+		tags.Lyrics = l.Text
+	}
+	if tags == (audio.Tags{}) {
+		return dl
+	}
+
+	tmp := filepath.Join(filepath.Dir(dl.Path), "tagged"+filepath.Ext(dl.Path))
+	err := audio.Retag(ctx, s.ffmpeg, dl.Path, tmp, tags)
+	if err == nil {
+		err = os.Rename(tmp, dl.Path)
+	}
+	info, statErr := os.Stat(dl.Path)
+	if err != nil || statErr != nil {
+		_ = os.Remove(tmp)
+		s.logger.Warn("write tags failed", zap.String("trackID", dl.Track.ID), zap.Error(err))
+		return dl
+	}
+	dl.Size = info.Size()
+	return dl
+}
@@ -0,0 +1,86 @@
+package music
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tempPrefix names the temp dirs of downloads so Sweep can tell them apart.
+const tempPrefix = "ym-bot-"
+
+// WithTempDir keeps downloads under dir instead of the system temp directory.
+func WithTempDir(dir string) Option {
+	return func(s *Service) {
+		s.tempDir = dir
+	}
+}
+
+// Sweep removes download temp dirs older than maxAge, left behind when the
+// process died mid-download, except those holding one of the keep files.
+// It returns how many dirs were removed.
+func (s *Service) Sweep(maxAge time.Duration, keep []string) int {
+	root := s.tempDir
+	if root == "" {
+		root = os.TempDir()
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		s.logger.Warn("read temp dir failed", zap.String("dir", root), zap.Error(err))
+		return 0
+	}
+
+	kept := make(map[string]struct{}, len(keep))
+	for _, path := range keep {
+		kept[filepath.Dir(path)] = struct{}{}
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), tempPrefix) {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		if _, ok := kept[dir]; ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			s.logger.Warn("remove orphaned temp dir failed", zap.String("dir", dir), zap.Error(err))
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		s.logger.Info("orphaned temp dirs removed", zap.Int("count", removed))
+	}
+	return removed
+}
+
+// RunSweeper calls Sweep right away and then every maxAge/2 until ctx is
+// done; keep lists the files that must survive each pass.
+func (s *Service) RunSweeper(ctx context.Context, maxAge time.Duration, keep func() []string) {
+	if maxAge <= 0 {
+		return
+	}
+	s.Sweep(maxAge, keep())
+
+	ticker := time.NewTicker(maxAge / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sweep(maxAge, keep())
+		}
+	}
+}
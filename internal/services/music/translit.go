@@ -0,0 +1,99 @@
+package music
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/audio"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/translit"
+)
+
+// Transliterate returns t with its title, artists and album converted to
+// the script of d (see translit); ids and everything else are kept.
+func Transliterate(t yandex.Track, d translit.Direction) yandex.Track {
+	if d == translit.None {
+		return t
+	}
+	t.Title = translit.Convert(t.Title, d)
+	t.Version = translit.Convert(t.Version, d)
+	t.AlbumTitle = translit.Convert(t.AlbumTitle, d)
+	t.AlbumArtist = translit.Convert(t.AlbumArtist, d)
+	t.Artists = slices.Clone(t.Artists)
+	for i, a := range t.Artists {
+		t.Artists[i] = translit.Convert(a, d)
+	}
+	t.Credits = slices.Clone(t.Credits)
+	for i := range t.Credits {
+		t.Credits[i].Name = translit.Convert(t.Credits[i].Name, d)
+	}
+	return t
+}
+
+// FileName is the name, without extension, a download of t labelled in
+// script d is sent under (see Relabel), for files that go along with it
+// such as lyrics. Like a download, t is completed with its album position
+// and the MusicBrainz data known for it first; MusicBrainz is not asked.
+func (s *Service) FileName(ctx context.Context, t yandex.Track, d translit.Direction) string {
+	t = s.enrich(ctx, t)
+	if s.brainz != nil {
+		if res, ok := s.brainzCache.Get(t.ID); ok {
+			t = res.complete(t)
+		}
+	}
+	return s.baseName(Transliterate(t, d))
+}
+
+// Relabel names the download after shown, e.g. its transliterated track,
+// and with ffmpeg rewrites the file's tags to match. dl.Track is kept, so
+// history and the archive go on with Yandex's metadata. A streamed download
+// only gets the new name, and a failed rewrite keeps the old tags.
+func (s *Service) Relabel(ctx context.Context, dl Download, shown yandex.Track) Download {
+	current := dl.Name
+	if dl.Path != "" {
+		current = filepath.Base(dl.Path)
+	}
+	name, err := s.names.Render(shown, 0)
+	if err != nil {
+		s.logger.Debug("render file name failed", zap.String("trackID", shown.ID), zap.Error(err))
+		return dl
+	}
+	name = path.Base(name) + filepath.Ext(current)
+	if dl.Path == "" {
+		dl.Name = name
+		return dl
+	}
+
+	dir := filepath.Dir(dl.Path)
+	if s.ffmpeg != "" && dl.Format.Known() {
+		tmp := filepath.Join(dir, "retagged"+filepath.Ext(current))
+		err := audio.Retag(ctx, s.ffmpeg, dl.Path, tmp, audio.Tags{
+			Title:       shown.FullTitle(),
+			Artist:      shown.ArtistsString(),
+			Album:       shown.AlbumTitle,
+			AlbumArtist: shown.AlbumArtist,
+		})
+		if info, statErr := os.Stat(tmp); err == nil && statErr == nil {
+			_ = os.Remove(dl.Path)
+			dl.Path, dl.Size = tmp, info.Size()
+		} else {
+			_ = os.Remove(tmp)
+			s.logger.Warn("retag download failed", zap.String("trackID", dl.Track.ID), zap.Error(err))
+		}
+	}
+	dst := filepath.Join(dir, name)
+	if dst != dl.Path {
+		if err := os.Rename(dl.Path, dst); err != nil {
+			s.logger.Warn("rename download failed", zap.String("trackID", dl.Track.ID), zap.Error(err))
+		} else {
+			dl.Path = dst
+		}
+	}
+	dl.Name = name
+	return dl
+}
@@ -0,0 +1,41 @@
+package music
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// versionOperator matches a "-<version>" word in a query, e.g.
+// "queen -live -remix".
+var versionOperator = regexp.MustCompile(`(?i)(?:^|\s)-(live|remix|remaster)\b`)
+
+// splitVersions cuts the version operators out of query and returns the
+// kinds of versions they exclude.
+func splitVersions(query string) (rest string, exclude []yandex.VersionKind) {
+	rest = versionOperator.ReplaceAllStringFunc(query, func(m string) string {
+		exclude = append(exclude, yandex.VersionKind(strings.ToLower(strings.TrimSpace(m)[1:])))
+		return " "
+	})
+	if exclude == nil {
+		return query, nil
+	}
+	return strings.Join(strings.Fields(rest), " "), exclude
+}
+
+// excludeVersions drops tracks whose version is one of exclude and returns
+// the tracks kept and how many were dropped.
+func excludeVersions(tracks []yandex.Track, exclude []yandex.VersionKind) ([]yandex.Track, int) {
+	if len(exclude) == 0 {
+		return tracks, 0
+	}
+	out := tracks[:0:0]
+	for _, t := range tracks {
+		if !slices.Contains(exclude, t.VersionKind()) {
+			out = append(out, t)
+		}
+	}
+	return out, len(tracks) - len(out)
+}
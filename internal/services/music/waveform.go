@@ -0,0 +1,67 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"ym-bot/internal/audio"
+	"ym-bot/internal/cache"
+	"ym-bot/internal/client/yandex"
+)
+
+// waveformTTL is how long loudness envelopes are cached.
+const waveformTTL = 6 * time.Hour
+
+// previewLength is how much of a track Waveform draws: the half minute a
+// preview plays.
+const previewLength = 30 * time.Second
+
+func newWaveformCache() *cache.TTL[[]float64] {
+	return cache.New[[]float64](waveformTTL, 200)
+}
+
+// PreviewClip is the stretch of a track of duration that Waveform draws:
+// previewLength, or the whole of a shorter track, from where Clip cuts.
+func PreviewClip(duration time.Duration) (start, length time.Duration) {
+	length = previewLength
+	if duration > 0 {
+		length = min(length, duration)
+	}
+	return clipStart(duration, length), length
+}
+
+// Waveform returns the track id with the loudness envelope of its preview
+// clip (see PreviewClip) as n values, cached for waveformTTL. ffmpeg reads
+// the clip straight from the audio URL, fetching only that part of the
+// file (see audio.Peaks), so the track is not downloaded.
+func (s *Service) Waveform(ctx context.Context, id string, n int) (yandex.Track, []float64, error) {
+	if s.ffmpeg == "" {
+		return yandex.Track{}, nil, ErrNoTranscoder
+	}
+	tracks, err := s.Tracks(ctx, []string{id})
+	if err != nil {
+		return yandex.Track{}, nil, fmt.Errorf("waveform %s: %w", id, err)
+	}
+	if len(tracks) == 0 {
+		return yandex.Track{}, nil, fmt.Errorf("waveform %s: %w", id, yandex.ErrNotFound)
+	}
+	t := tracks[0]
+	key := id + "/" + strconv.Itoa(n)
+	if peaks, ok := s.waveforms.Get(key); ok {
+		return t, peaks, nil
+	}
+
+	link, err := s.freshLink(ctx, downloadIDs(t, id))
+	if err != nil {
+		return yandex.Track{}, nil, fmt.Errorf("waveform %s: %w", id, err)
+	}
+	start, length := PreviewClip(time.Duration(t.DurationSeconds) * time.Second)
+	peaks, err := audio.Peaks(ctx, s.ffmpeg, link.URL, start, length, n)
+	if err != nil {
+		return yandex.Track{}, nil, fmt.Errorf("waveform %s: %w", id, err)
+	}
+	s.waveforms.Set(key, peaks)
+	return t, peaks, nil
+}
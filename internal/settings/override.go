@@ -0,0 +1,145 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrUnknownKey is returned by Set for a key Keys does not list.
+var ErrUnknownKey = errors.New("unknown setting")
+
+// override is a setting admins may change at runtime, by its configuration
+// name.
+type override struct {
+	key string
+	get func(r *Runtime) string
+	set func(r *Runtime, value string) error
+}
+
+// overrides are the settings Set changes, in the order Keys lists them.
+var overrides = []override{
+	intKnob("daily_download_limit", func(r *Runtime) *int { return &r.DailyLimit }),
+	sizeKnob("preflight_threshold_mb", func(r *Runtime) *int64 { return &r.PreflightSize }),
+	durationKnob("split_longer_than", 0, func(r *Runtime) *time.Duration { return &r.SplitAfter }),
+	durationKnob("split_part_length", time.Minute, func(r *Runtime) *time.Duration { return &r.SplitPart }),
+	sizeKnob("stream_upload_max_mb", func(r *Runtime) *int64 { return &r.StreamMax }),
+	boolKnob("playlist_button", func(r *Runtime) *bool { return &r.PlaylistButton }),
+	boolKnob("stats_chart", func(r *Runtime) *bool { return &r.StatsChart }),
+	durationKnob("inline_cache_time", 0, func(r *Runtime) *time.Duration { return &r.InlineCache }),
+	durationKnob("inline_personal_cache_time", 0, func(r *Runtime) *time.Duration { return &r.InlinePersonalCache }),
+	intKnob("inline_max_results", func(r *Runtime) *int { return &r.InlineMax }),
+	boolKnob("inline_probe", func(r *Runtime) *bool { return &r.InlineProbe }),
+	{
+		key: "maintenance_message",
+		get: func(r *Runtime) string { return r.MaintenanceMessage },
+		set: func(r *Runtime, v string) error { r.MaintenanceMessage = v; return nil },
+	},
+}
+
+// Keys lists the settings Set changes, by their configuration names.
+func Keys() []string {
+	keys := make([]string, len(overrides))
+	for i, o := range overrides {
+		keys[i] = o.key
+	}
+	return keys
+}
+
+// Get returns the setting key of r written as Set takes it.
+func (r *Runtime) Get(key string) (string, bool) {
+	for _, o := range overrides {
+		if o.key == key {
+			return o.get(r), true
+		}
+	}
+	return "", false
+}
+
+// Set parses value into the setting key of r, which is left unchanged when
+// value is out of range. Sizes are in megabytes, durations as in
+// time.ParseDuration.
+func (r *Runtime) Set(key, value string) error {
+	for _, o := range overrides {
+		if o.key == key {
+			if err := o.set(r, value); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w %q", ErrUnknownKey, key)
+}
+
+// intKnob is a count that is not negative.
+func intKnob(key string, field func(*Runtime) *int) override {
+	return override{
+		key: key,
+		get: func(r *Runtime) string { return strconv.Itoa(*field(r)) },
+		set: func(r *Runtime, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return errors.New("not a number")
+			}
+			if n < 0 {
+				return errors.New("must not be negative")
+			}
+			*field(r) = n
+			return nil
+		},
+	}
+}
+
+// sizeKnob is a byte size set in whole megabytes.
+func sizeKnob(key string, field func(*Runtime) *int64) override {
+	return override{
+		key: key,
+		get: func(r *Runtime) string { return strconv.FormatInt(*field(r)>>20, 10) },
+		set: func(r *Runtime, v string) error {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return errors.New("not a number")
+			}
+			if n < 0 || n > 1<<20 {
+				return errors.New("must be between 0 and 1048576 MB")
+			}
+			*field(r) = n << 20
+			return nil
+		},
+	}
+}
+
+// durationKnob is a duration of at least min, or 0 to switch it off.
+func durationKnob(key string, min time.Duration, field func(*Runtime) *time.Duration) override {
+	return override{
+		key: key,
+		get: func(r *Runtime) string { return field(r).String() },
+		set: func(r *Runtime, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return errors.New("not a duration, e.g. 90s or 1h30m")
+			}
+			if d < 0 || (d > 0 && d < min) {
+				return fmt.Errorf("must be 0 or at least %s", min)
+			}
+			*field(r) = d
+			return nil
+		},
+	}
+}
+
+func boolKnob(key string, field func(*Runtime) *bool) override {
+	return override{
+		key: key,
+		get: func(r *Runtime) string { return strconv.FormatBool(*field(r)) },
+		set: func(r *Runtime, v string) error {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return errors.New("not true or false")
+			}
+			*field(r) = b
+			return nil
+		},
+	}
+}
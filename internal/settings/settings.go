@@ -0,0 +1,123 @@
+// Package settings holds the runtime-tunable knobs of the bot (admins,
+// limits, inline caching, maintenance behaviour) as immutable snapshots.
+// Handlers read the current snapshot without locking; a config reload or an
+// admin override builds a new one and swaps it in atomically, so a handler
+// never sees half of an update.
+package settings
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ym-bot/internal/config"
+)
+
+// Runtime is one snapshot of the settings. It is shared between
+// goroutines: treat it as read-only, and its slices too.
+type Runtime struct {
+	Admins []int64
+	// FeedbackChats receive /feedback messages.
+	FeedbackChats []int64
+	StatsChart    bool
+	// DailyLimit caps downloads per user and day; 0 is unlimited.
+	DailyLimit int
+	// PreflightSize is the estimated size, in bytes, from which downloads
+	// are confirmed first; 0 never asks.
+	PreflightSize int64
+	// SplitAfter is the length from which tracks are offered in parts of
+	// SplitPart; 0 never offers.
+	SplitAfter time.Duration
+	SplitPart  time.Duration
+	// StreamMax is the size, in bytes, up to which downloads are streamed
+	// to Telegram without a temp file.
+	StreamMax      int64
+	PlaylistButton bool
+	// InlineCache and InlinePersonalCache are the cache times of anonymous
+	// and personal inline answers; InlineMax caps inline paging.
+	InlineCache         time.Duration
+	InlinePersonalCache time.Duration
+	InlineMax           int
+	// InlineProbe checks audio URLs before they are offered inline.
+	InlineProbe bool
+	// InlineFast answers inline queries with track cards; the audio is
+	// resolved once a card is picked.
+	InlineFast bool
+	// InlineUpgradeChat receives full-quality uploads of picked inline
+	// audio; 0 leaves the audio as Telegram fetched it.
+	InlineUpgradeChat  int64
+	MaintenanceMessage string
+	// MaintenanceHold keeps downloads in the paused queue during
+	// maintenance rather than rejecting them.
+	MaintenanceHold bool
+}
+
+// FromConfig takes the runtime-tunable part of cfg.
+func FromConfig(cfg config.Config) Runtime {
+	return Runtime{
+		Admins:              slices.Clone(cfg.AdminIDs),
+		FeedbackChats:       slices.Clone(cfg.FeedbackChats()),
+		StatsChart:          cfg.StatsChart,
+		DailyLimit:          cfg.DailyDownloadLimit,
+		PreflightSize:       int64(cfg.PreflightThresholdMB) << 20,
+		SplitAfter:          cfg.SplitLongerThan,
+		SplitPart:           cfg.SplitPartLength,
+		StreamMax:           int64(cfg.StreamUploadMaxMB) << 20,
+		PlaylistButton:      cfg.PlaylistButton,
+		InlineCache:         cfg.InlineCacheTime,
+		InlinePersonalCache: cfg.InlinePersonalCacheTime,
+		InlineMax:           cfg.InlineMaxResults,
+		InlineProbe:         cfg.InlineProbe,
+		InlineFast:          cfg.InlineMode == config.InlineFast,
+		InlineUpgradeChat:   cfg.InlineUpgradeChat,
+		MaintenanceMessage:  cfg.MaintenanceMessage,
+		MaintenanceHold:     cfg.MaintenanceDownloads != config.MaintenanceReject,
+	}
+}
+
+// IsAdmin reports whether userID administers the bot.
+func (r *Runtime) IsAdmin(userID int64) bool {
+	return slices.Contains(r.Admins, userID)
+}
+
+// Store holds the current snapshot. Reads are lock-free; writers are
+// serialized, so concurrent Updates do not lose each other's changes.
+type Store struct {
+	current atomic.Pointer[Runtime]
+	// mu serializes writers.
+	mu sync.Mutex
+}
+
+// New returns a store starting with initial.
+func New(initial Runtime) *Store {
+	s := &Store{}
+	s.current.Store(&initial)
+	return s
+}
+
+// Load returns the current snapshot; it stays valid, and unchanged, after
+// later swaps.
+func (s *Store) Load() *Runtime {
+	return s.current.Load()
+}
+
+// Swap replaces the snapshot with r and returns the previous one.
+func (s *Store) Swap(r Runtime) *Runtime {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current.Swap(&r)
+}
+
+// Update applies fn to a copy of the current snapshot and swaps the result
+// in. The copy's slices are its own, so fn may change them in place.
+func (s *Store) Update(fn func(*Runtime)) *Runtime {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := *s.current.Load()
+	next.Admins = slices.Clone(next.Admins)
+	next.FeedbackChats = slices.Clone(next.FeedbackChats)
+	fn(&next)
+	s.current.Store(&next)
+	return &next
+}
@@ -0,0 +1,150 @@
+package settings
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"ym-bot/internal/config"
+)
+
+func TestFromConfig(t *testing.T) {
+	cfg := config.Config{
+		AdminIDs:             []int64{1, 2},
+		DailyDownloadLimit:   20,
+		PreflightThresholdMB: 10,
+		StreamUploadMaxMB:    5,
+		InlineMode:           config.InlineFast,
+		MaintenanceDownloads: config.MaintenanceReject,
+	}
+	r := FromConfig(cfg)
+	if r.DailyLimit != 20 || r.PreflightSize != 10<<20 || r.StreamMax != 5<<20 {
+		t.Errorf("limits %d, %d, %d", r.DailyLimit, r.PreflightSize, r.StreamMax)
+	}
+	if !r.InlineFast || r.MaintenanceHold {
+		t.Errorf("inline fast %v, maintenance hold %v; want true, false", r.InlineFast, r.MaintenanceHold)
+	}
+	if !r.IsAdmin(2) || r.IsAdmin(3) {
+		t.Errorf("admins %v", r.Admins)
+	}
+	// The snapshot does not share the config's slices.
+	cfg.AdminIDs[0] = 9
+	if r.Admins[0] != 1 {
+		t.Error("snapshot admins changed with the config")
+	}
+}
+
+// TestUpdateCopies checks that an update leaves earlier snapshots as they
+// were, slices included.
+func TestUpdateCopies(t *testing.T) {
+	s := New(Runtime{Admins: []int64{1}, DailyLimit: 5})
+	before := s.Load()
+	after := s.Update(func(r *Runtime) {
+		r.Admins[0] = 2
+		r.Admins = append(r.Admins, 3)
+		r.DailyLimit = 7
+	})
+	if before.DailyLimit != 5 || !slices.Equal(before.Admins, []int64{1}) {
+		t.Errorf("earlier snapshot changed: %+v", before)
+	}
+	if s.Load() != after || after.DailyLimit != 7 || !slices.Equal(after.Admins, []int64{2, 3}) {
+		t.Errorf("current snapshot %+v, want the update", s.Load())
+	}
+	if prev := s.Swap(Runtime{DailyLimit: 1}); prev != after {
+		t.Error("Swap did not return the replaced snapshot")
+	}
+}
+
+// TestConcurrentUpdates checks that updates racing each other and a swap
+// are all kept, for the race detector too.
+func TestConcurrentUpdates(t *testing.T) {
+	s := New(Runtime{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				s.Update(func(r *Runtime) { r.DailyLimit++ })
+				_ = s.Load().IsAdmin(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := s.Load().DailyLimit; got != 400 {
+		t.Errorf("DailyLimit = %d after 400 increments", got)
+	}
+}
+
+func TestSet(t *testing.T) {
+	cases := []struct {
+		key, value string
+		want       string // as Get returns it; empty for a rejected value
+		check      func(r Runtime) bool
+	}{
+		{"daily_download_limit", "20", "20", func(r Runtime) bool { return r.DailyLimit == 20 }},
+		{"daily_download_limit", "-1", "", nil},
+		{"daily_download_limit", "many", "", nil},
+		{"preflight_threshold_mb", "15", "15", func(r Runtime) bool { return r.PreflightSize == 15<<20 }},
+		{"stream_upload_max_mb", "-2", "", nil},
+		{"split_longer_than", "1h30m", "1h30m0s", func(r Runtime) bool { return r.SplitAfter == 90*time.Minute }},
+		{"split_part_length", "30s", "", nil},
+		{"split_part_length", "0", "0s", func(r Runtime) bool { return r.SplitPart == 0 }},
+		{"inline_cache_time", "soon", "", nil},
+		{"inline_max_results", "30", "30", func(r Runtime) bool { return r.InlineMax == 30 }},
+		{"inline_max_results", "-30", "", nil},
+		{"inline_probe", "true", "true", func(r Runtime) bool { return r.InlineProbe }},
+		{"playlist_button", "maybe", "", nil},
+		{"maintenance_message", "Скоро вернёмся", "Скоро вернёмся", func(r Runtime) bool { return r.MaintenanceMessage == "Скоро вернёмся" }},
+	}
+	for _, tc := range cases {
+		r := Runtime{DailyLimit: 5, InlineMax: 10, SplitPart: time.Hour}
+		before := r
+		err := r.Set(tc.key, tc.value)
+		if tc.want == "" {
+			if err == nil {
+				t.Errorf("Set(%s, %q) succeeded, want an error", tc.key, tc.value)
+			}
+			if !reflect.DeepEqual(r, before) {
+				t.Errorf("rejected Set(%s, %q) changed the settings to %+v", tc.key, tc.value, r)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Set(%s, %q): %v", tc.key, tc.value, err)
+			continue
+		}
+		if !tc.check(r) {
+			t.Errorf("Set(%s, %q) gave %+v", tc.key, tc.value, r)
+		}
+		if got, ok := r.Get(tc.key); !ok || got != tc.want {
+			t.Errorf("Get(%s) = %q, %v; want %q", tc.key, got, ok, tc.want)
+		}
+	}
+
+	var r Runtime
+	if err := r.Set("admins", "1"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Set of an unknown key = %v, want ErrUnknownKey", err)
+	}
+	if _, ok := r.Get("admins"); ok {
+		t.Error("Get of an unknown key succeeded")
+	}
+}
+
+// TestKeys checks that every listed key reads back what it was set to.
+func TestKeys(t *testing.T) {
+	var r Runtime
+	for _, key := range Keys() {
+		value, ok := r.Get(key)
+		if !ok {
+			t.Errorf("Get(%s) failed for a listed key", key)
+			continue
+		}
+		if err := r.Set(key, value); err != nil {
+			t.Errorf("Set(%s, %q) of its own value: %v", key, value, err)
+		}
+	}
+}
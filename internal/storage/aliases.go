@@ -0,0 +1,41 @@
+package storage
+
+// maxAliasHops bounds ResolveTrack in case aliases ever form a cycle.
+const maxAliasHops = 4
+
+// SetTrackAlias records that Yandex now files track id under realID, so
+// links made with either id keep resolving. Equal or empty ids are ignored.
+// It is called on every track lookup, so the alias is only marked for the
+// next flush.
+func (s *Store) SetTrackAlias(id, realID string) {
+	if id == "" || realID == "" || id == realID {
+		return
+	}
+	s.mu.RLock()
+	known := s.data.TrackAliases[id] == realID
+	s.mu.RUnlock()
+	if known {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.TrackAliases[id] = realID
+	delete(s.data.TrackAliases, realID)
+	s.dirty = true
+}
+
+// ResolveTrack follows recorded aliases from id to the track's current id;
+// unknown ids resolve to themselves.
+func (s *Store) ResolveTrack(id string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for range maxAliasHops {
+		next, ok := s.data.TrackAliases[id]
+		if !ok {
+			break
+		}
+		id = next
+	}
+	return id
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveTrack(t *testing.T) {
+	cases := []struct {
+		name    string
+		aliases [][2]string // id, realID in the order recorded
+		id      string
+		want    string
+	}{
+		{"unknown", nil, "1", "1"},
+		{"migrated", [][2]string{{"1", "2"}}, "1", "2"},
+		{"current id", [][2]string{{"1", "2"}}, "2", "2"},
+		{"migrated twice", [][2]string{{"1", "2"}, {"2", "3"}}, "1", "3"},
+		{"empty real id", [][2]string{{"1", ""}}, "1", "1"},
+		{"same id", [][2]string{{"1", "1"}}, "1", "1"},
+		// Recording the way back drops the alias it would loop through.
+		{"migrated back", [][2]string{{"1", "2"}, {"2", "1"}}, "1", "1"},
+		{"migrated back, old id", [][2]string{{"1", "2"}, {"2", "1"}}, "2", "1"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Open("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, a := range tc.aliases {
+				s.SetTrackAlias(a[0], a[1])
+			}
+			if got := s.ResolveTrack(tc.id); got != tc.want {
+				t.Errorf("ResolveTrack(%s) = %s, want %s", tc.id, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveTrackCycle(t *testing.T) {
+	s, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SetTrackAlias never leaves a cycle, but a hand-edited store may.
+	s.data.TrackAliases["1"] = "2"
+	s.data.TrackAliases["2"] = "1"
+	if got := s.ResolveTrack("1"); got != "1" {
+		t.Errorf("ResolveTrack(1) = %s after %d hops, want 1", got, maxAliasHops)
+	}
+}
+
+// TestTrackAliasDeferred checks that aliases are written with the next
+// flush rather than on every lookup.
+func TestTrackAliasDeferred(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetTrackAlias("1", "2")
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("store written before the flush: %v", err)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `"trackAliases":{"1":"2"}`) {
+		t.Errorf("flushed store lacks the alias: %s", raw)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.ResolveTrack("1"); got != "2" {
+		t.Errorf("ResolveTrack(1) after reopening = %s, want 2", got)
+	}
+}
@@ -0,0 +1,235 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// memoryAuditLimit bounds entries kept by an in-memory audit log.
+const memoryAuditLimit = 10000
+
+// AuditOutcome is how a download attempt ended.
+type AuditOutcome string
+
+// Audit outcomes.
+const (
+	AuditDelivered   AuditOutcome = "delivered"
+	AuditRedelivered AuditOutcome = "redelivered"
+	// AuditDeferred means the upload failed and the file was kept for redelivery.
+	AuditDeferred   AuditOutcome = "deferred"
+	AuditSendFailed AuditOutcome = "send_failed"
+	AuditFailed     AuditOutcome = "failed"
+	AuditTooLarge   AuditOutcome = "too_large"
+	AuditCancelled  AuditOutcome = "cancelled"
+)
+
+// AuditEntry records one download attempt.
+type AuditEntry struct {
+	At       time.Time     `json:"at"`
+	Bot      string        `json:"bot"`
+	UserID   int64         `json:"userId"`
+	ChatID   int64         `json:"chatId"`
+	TrackID  string        `json:"trackId"`
+	Title    string        `json:"title,omitempty"`
+	Bytes    int64         `json:"bytes,omitempty"`
+	Duration time.Duration `json:"durationNs"`
+	Outcome  AuditOutcome  `json:"outcome"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// AuditFilter selects entries for Query; zero fields match everything.
+type AuditFilter struct {
+	UserID  int64
+	TrackID string
+	// Limit keeps only the newest matches; 0 means no limit.
+	Limit int
+}
+
+func (f AuditFilter) match(e AuditEntry) bool {
+	return (f.UserID == 0 || e.UserID == f.UserID) && (f.TrackID == "" || e.TrackID == f.TrackID)
+}
+
+// AuditLog is an append-only record of downloads kept as JSON lines, one
+// entry per line, so it never has to be rewritten. An empty path keeps the
+// newest entries in memory only.
+type AuditLog struct {
+	mu     sync.Mutex
+	path   string
+	file   *os.File
+	memory []AuditEntry
+}
+
+// OpenAudit opens (creating if needed) the audit log at path for appending.
+func OpenAudit(path string) (*AuditLog, error) {
+	l := &AuditLog{path: path}
+	if path == "" {
+		return l, nil
+	}
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// Append records e.
+func (l *AuditLog) Append(e AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		l.memory = append(l.memory, e)
+		if len(l.memory) > memoryAuditLimit {
+			l.memory = l.memory[len(l.memory)-memoryAuditLimit:]
+		}
+		return nil
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(raw, '\n')); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+// Query returns the entries matching f, oldest first.
+func (l *AuditLog) Query(f AuditFilter) ([]AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var out []AuditEntry
+	keep := func(e AuditEntry) {
+		if !f.match(e) {
+			return
+		}
+		out = append(out, e)
+		if f.Limit > 0 && len(out) > 2*f.Limit {
+			// Trim now and then instead of holding every match of a long log.
+			out = append(out[:0], out[len(out)-f.Limit:]...)
+		}
+	}
+
+	if l.file == nil {
+		for _, e := range l.memory {
+			keep(e)
+		}
+	} else {
+		rf, err := os.Open(l.path)
+		if err != nil {
+			return nil, fmt.Errorf("read audit log: %w", err)
+		}
+		defer rf.Close()
+		sc := bufio.NewScanner(rf)
+		sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+		for sc.Scan() {
+			var e AuditEntry
+			// A line torn by a crash is skipped rather than failing the query.
+			if json.Unmarshal(sc.Bytes(), &e) == nil {
+				keep(e)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("read audit log: %w", err)
+		}
+	}
+
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[len(out)-f.Limit:]
+	}
+	return out, nil
+}
+
+// Forget anonymises the entries of userID: their user id, and the chat id
+// of downloads to their private chat, become 0, so download counts survive
+// /forgetme while nothing points at the user. It reports how many entries
+// changed. The log file is rewritten, which an append-only log otherwise
+// never is.
+func (l *AuditLog) Forget(userID int64) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	forget := func(e *AuditEntry) bool {
+		if e.UserID != userID {
+			return false
+		}
+		e.UserID = 0
+		if e.ChatID == userID {
+			e.ChatID = 0
+		}
+		return true
+	}
+
+	if l.file == nil {
+		n := 0
+		for i := range l.memory {
+			if forget(&l.memory[i]) {
+				n++
+			}
+		}
+		return n, nil
+	}
+
+	raw, err := os.ReadFile(l.path)
+	if err != nil {
+		return 0, fmt.Errorf("read audit log: %w", err)
+	}
+	var out bytes.Buffer
+	n := 0
+	for _, line := range bytes.SplitAfter(raw, []byte{'\n'}) {
+		var e AuditEntry
+		// Torn lines are kept as they are, like Query skips them.
+		if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &e) != nil || !forget(&e) {
+			out.Write(line)
+			continue
+		}
+		enc, err := json.Marshal(e)
+		if err != nil {
+			return 0, err
+		}
+		out.Write(append(enc, '\n'))
+		n++
+	}
+	if n == 0 {
+		return 0, nil
+	}
+
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), 0o600); err != nil {
+		return 0, fmt.Errorf("write audit log: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return 0, fmt.Errorf("replace audit log: %w", err)
+	}
+	// Appends go on to the new file.
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return n, fmt.Errorf("reopen audit log: %w", err)
+	}
+	l.file.Close()
+	l.file = f
+	return n, nil
+}
+
+// Close closes the underlying file; later entries are kept in memory.
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
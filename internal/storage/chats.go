@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"slices"
+	"time"
+)
+
+// ChatSettings configures the bot in a group chat. The zero value restricts
+// nothing.
+type ChatSettings struct {
+	// Language of the bot's help in the chat: "" for Russian, "en" for English.
+	Language string `json:"language,omitempty"`
+	// DisabledCommands are commands (without the slash) the bot ignores in the chat.
+	DisabledCommands []string `json:"disabledCommands,omitempty"`
+	// QualityCap is the best yandex.Quality delivered to the chat; empty sets no cap.
+	QualityCap string `json:"qualityCap,omitempty"`
+	// QuietFrom and QuietTo are the hours, in the bot's local time, between
+	// which the bot takes no commands or downloads; equal hours turn quiet
+	// hours off.
+	QuietFrom int `json:"quietFrom,omitempty"`
+	QuietTo   int `json:"quietTo,omitempty"`
+	// HideExplicit refuses to deliver tracks with explicit lyrics.
+	HideExplicit bool `json:"hideExplicit,omitempty"`
+	// HidePreviews strips link previews from the bot's messages in the chat.
+	HidePreviews bool `json:"hidePreviews,omitempty"`
+}
+
+// CommandAllowed reports whether command may be used in the chat.
+func (c ChatSettings) CommandAllowed(command string) bool {
+	return !slices.Contains(c.DisabledCommands, command)
+}
+
+// Quiet reports whether t falls within the chat's quiet hours; the range
+// may span midnight.
+func (c ChatSettings) Quiet(t time.Time) bool {
+	if c.QuietFrom == c.QuietTo {
+		return false
+	}
+	h := t.Hour()
+	if c.QuietFrom < c.QuietTo {
+		return h >= c.QuietFrom && h < c.QuietTo
+	}
+	return h >= c.QuietFrom || h < c.QuietTo
+}
+
+// ChatSettings returns chatID's settings (zero value if never changed).
+func (s *Store) ChatSettings(chatID int64) ChatSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c := s.data.Chats[chatID]
+	c.DisabledCommands = slices.Clone(c.DisabledCommands)
+	return c
+}
+
+// UpdateChatSettings applies fn to chatID's settings and persists the result.
+func (s *Store) UpdateChatSettings(chatID int64, fn func(*ChatSettings)) (ChatSettings, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.data.Chats[chatID]
+	c.DisabledCommands = slices.Clone(c.DisabledCommands)
+	fn(&c)
+	s.data.Chats[chatID] = c
+	s.settingsRev.Add(1)
+
+	return c, s.flushLocked()
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"time"
+)
+
+// DeadLetter is a downloaded track whose upload to Telegram failed and is
+// waiting for a retry. Path points at the kept file; it may be gone after a
+// restart (e.g. a tmpfs temp dir), in which case the track is downloaded again.
+type DeadLetter struct {
+	ID          string    `json:"id"`
+	Bot         string    `json:"bot"`
+	UserID      int64     `json:"userId"`
+	ChatID      int64     `json:"chatId"`
+	TrackID     string    `json:"trackId"`
+	Title       string    `json:"title"`
+	Path        string    `json:"path"`
+	Codec       string    `json:"codec"`
+	ReservedAt  time.Time `json:"reservedAt"`
+	FailedAt    time.Time `json:"failedAt"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"lastError"`
+	NextAttempt time.Time `json:"nextAttempt"`
+	// InlineID is the message sent inline the download was requested from,
+	// which gets the file once delivered; Group as in Job.
+	InlineID string `json:"inlineId,omitempty"`
+	Group    bool   `json:"group,omitempty"`
+}
+
+// AddDeadLetter stores e under a fresh id and persists it right away.
+func (s *Store) AddDeadLetter(e DeadLetter) (DeadLetter, error) {
+	var raw [4]byte
+	_, _ = rand.Read(raw[:])
+	e.ID = hex.EncodeToString(raw[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.DeadLetters[e.ID] = e
+	return e, s.flushLocked()
+}
+
+// DeadLetters returns the pending entries of bot, oldest failure first.
+func (s *Store) DeadLetters(bot string) []DeadLetter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []DeadLetter
+	for _, e := range s.data.DeadLetters {
+		if e.Bot == bot {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].FailedAt.Before(out[j].FailedAt) })
+	return out
+}
+
+// DeadLetterPaths returns the kept files of all bots' pending entries.
+func (s *Store) DeadLetterPaths() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]string, 0, len(s.data.DeadLetters))
+	for _, e := range s.data.DeadLetters {
+		if e.Path != "" {
+			out = append(out, e.Path)
+		}
+	}
+	return out
+}
+
+// UpdateDeadLetter applies fn to the entry with id; it reports false if absent.
+func (s *Store) UpdateDeadLetter(id string, fn func(*DeadLetter)) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.data.DeadLetters[id]
+	if !ok {
+		return false, nil
+	}
+	fn(&e)
+	s.data.DeadLetters[id] = e
+	return true, s.flushLocked()
+}
+
+// RemoveDeadLetter drops the entry with id, e.g. once it has been delivered.
+func (s *Store) RemoveDeadLetter(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.DeadLetters[id]; !ok {
+		return nil
+	}
+	delete(s.data.DeadLetters, id)
+	return s.flushLocked()
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"time"
+)
+
+// feedbackLimit caps stored feedback messages; the oldest are dropped first.
+const feedbackLimit = 1000
+
+// Feedback is a message a user sent to the bot admins with /feedback.
+type Feedback struct {
+	ID         string    `json:"id"`
+	UserID     int64     `json:"userId"`
+	Username   string    `json:"username"`
+	ChatID     int64     `json:"chatId"`
+	MessageID  int       `json:"messageId"`
+	Text       string    `json:"text"`
+	At         time.Time `json:"at"`
+	AnsweredAt time.Time `json:"answeredAt"`
+}
+
+// AddFeedback stores f under a fresh id and persists it right away.
+func (s *Store) AddFeedback(f Feedback) (Feedback, error) {
+	var raw [4]byte
+	_, _ = rand.Read(raw[:])
+	f.ID = hex.EncodeToString(raw[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Feedback[f.ID] = f
+	if len(s.data.Feedback) > feedbackLimit {
+		s.trimFeedbackLocked()
+	}
+	return f, s.flushLocked()
+}
+
+// Feedback returns the feedback message with id.
+func (s *Store) Feedback(id string) (Feedback, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.data.Feedback[id]
+	return f, ok
+}
+
+// MarkFeedbackAnswered records that an admin replied to the message with id.
+func (s *Store) MarkFeedbackAnswered(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.data.Feedback[id]
+	if !ok {
+		return nil
+	}
+	f.AnsweredAt = at
+	s.data.Feedback[id] = f
+	return s.flushLocked()
+}
+
+func (s *Store) trimFeedbackLocked() {
+	all := make([]Feedback, 0, len(s.data.Feedback))
+	for _, f := range s.data.Feedback {
+		all = append(all, f)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].At.Before(all[j].At) })
+	for _, f := range all[:len(all)-feedbackLimit] {
+		delete(s.data.Feedback, f.ID)
+	}
+}
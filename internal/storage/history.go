@@ -0,0 +1,48 @@
+package storage
+
+import "time"
+
+// historyLimit caps stored entries per user; the oldest are dropped first.
+const historyLimit = 5000
+
+// HistoryEntry is one delivered track in a user's download history.
+type HistoryEntry struct {
+	At      time.Time `json:"at"`
+	TrackID string    `json:"trackId"`
+	Title   string    `json:"title"`
+	Artists string    `json:"artists"`
+	// MainArtists are the performers credited as main artists; older
+	// entries only have the rendered Artists.
+	MainArtists []string `json:"mainArtists,omitempty"`
+	DurationSec int      `json:"durationSec,omitempty"`
+}
+
+// DisplayTitle renders the entry as "Artists — Title".
+func (e HistoryEntry) DisplayTitle() string {
+	switch {
+	case e.Artists == "":
+		return e.Title
+	case e.Title == "":
+		return e.Artists
+	}
+	return e.Artists + " — " + e.Title
+}
+
+// History returns userID's downloads, oldest first.
+func (s *Store) History(userID int64) []HistoryEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := s.data.History[userID]
+	out := make([]HistoryEntry, len(entries))
+	copy(out, entries)
+	return out
+}
+
+func (s *Store) appendHistoryLocked(userID int64, e HistoryEntry) {
+	entries := append(s.data.History[userID], e)
+	if len(entries) > historyLimit {
+		entries = append([]HistoryEntry(nil), entries[len(entries)-historyLimit:]...)
+	}
+	s.data.History[userID] = entries
+}
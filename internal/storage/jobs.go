@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// jobRetention is how long finished jobs are kept before they are pruned.
+const jobRetention = 24 * time.Hour
+
+// JobState is where a download job is in its lifecycle.
+type JobState string
+
+// Job states. Queued and running jobs are resumed after a restart.
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobDone      JobState = "done"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// JobStates lists the states in lifecycle order.
+var JobStates = []JobState{JobQueued, JobRunning, JobDone, JobFailed, JobCancelled}
+
+// Finished reports whether the job needs no further work.
+func (s JobState) Finished() bool {
+	return s != JobQueued && s != JobRunning
+}
+
+// Job is a download accepted from a user, persisted so it survives a restart.
+// Its quota was reserved at ReservedAt.
+type Job struct {
+	Key        string    `json:"key"`
+	Bot        string    `json:"bot"`
+	UserID     int64     `json:"userId"`
+	ChatID     int64     `json:"chatId"`
+	TrackID    string    `json:"trackId"`
+	Split      bool      `json:"split,omitempty"`
+	Quiet      bool      `json:"quiet,omitempty"`
+	InlineID   string    `json:"inlineId,omitempty"`
+	Group      bool      `json:"group,omitempty"`
+	ReservedAt time.Time `json:"reservedAt"`
+	State      JobState  `json:"state"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+	// StatusMessageID is the chat message showing the job's place in line,
+	// removed when the job is recovered after a restart.
+	StatusMessageID int `json:"statusMessageId,omitempty"`
+}
+
+// SaveJob stores j under its key, replacing any previous entry, and persists
+// it right away: an accepted download must survive a crash. Later changes
+// (SetJobState, RemoveJob) ride on the periodic flush.
+func (s *Store) SaveJob(j Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Jobs[j.Key] = j
+	return s.flushLocked()
+}
+
+// Job returns the job stored under key.
+func (s *Store) Job(key string) (Job, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	j, ok := s.data.Jobs[key]
+	return j, ok
+}
+
+// SetJobState moves the job with key to state; unknown keys are ignored.
+// Finishing a job also prunes jobs that finished more than jobRetention ago.
+// After a crash the state may lag a flush interval behind, so a job that had
+// just finished can run once more.
+func (s *Store) SetJobState(key string, state JobState, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.data.Jobs[key]
+	if !ok {
+		return
+	}
+	j.State, j.UpdatedAt = state, at
+	s.data.Jobs[key] = j
+	if state.Finished() {
+		for k, old := range s.data.Jobs {
+			if old.State.Finished() && at.Sub(old.UpdatedAt) > jobRetention {
+				delete(s.data.Jobs, k)
+			}
+		}
+	}
+	s.dirty = true
+}
+
+// SetJobStatusMessage records the status message of the job with key;
+// unknown keys are ignored.
+func (s *Store) SetJobStatusMessage(key string, msgID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.data.Jobs[key]
+	if !ok {
+		return
+	}
+	j.StatusMessageID = msgID
+	s.data.Jobs[key] = j
+	s.dirty = true
+}
+
+// RemoveJob drops the job with key, e.g. one the queue never accepted.
+func (s *Store) RemoveJob(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Jobs[key]; !ok {
+		return
+	}
+	delete(s.data.Jobs, key)
+	s.dirty = true
+}
+
+// JobCounts counts bot's jobs by state, every bot's when bot is empty.
+// Finished jobs are counted until they are pruned, see jobRetention.
+func (s *Store) JobCounts(bot string) map[JobState]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[JobState]int, len(JobStates))
+	for _, j := range s.data.Jobs {
+		if bot == "" || j.Bot == bot {
+			counts[j.State]++
+		}
+	}
+	return counts
+}
+
+// PendingJobs returns bot's queued and running jobs, oldest reservation first.
+func (s *Store) PendingJobs(bot string) []Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Job
+	for _, j := range s.data.Jobs {
+		if j.Bot == bot && !j.State.Finished() {
+			out = append(out, j)
+		}
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].ReservedAt.Before(out[k].ReservedAt) })
+	return out
+}
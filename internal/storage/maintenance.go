@@ -0,0 +1,44 @@
+package storage
+
+import "time"
+
+// Maintenance is the admin-controlled maintenance state shared by all bots
+// on the store, so it survives the restarts it usually announces.
+type Maintenance struct {
+	On    bool      `json:"on"`
+	Since time.Time `json:"since"`
+	// Until is the announced end; zero when unknown.
+	Until time.Time `json:"until"`
+	// Note replaces the configured downtime message when set.
+	Note string `json:"note"`
+}
+
+// Maintenance returns the current maintenance state.
+func (s *Store) Maintenance() Maintenance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.Maintenance
+}
+
+// SetMaintenance replaces the maintenance state and persists it.
+func (s *Store) SetMaintenance(m Maintenance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Maintenance = m
+	return s.flushLocked()
+}
+
+// QueuePaused reports whether an admin paused the download queue.
+func (s *Store) QueuePaused() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.QueuePaused
+}
+
+// SetQueuePaused records whether the download queue is paused and persists it.
+func (s *Store) SetQueuePaused(paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.QueuePaused = paused
+	return s.flushLocked()
+}
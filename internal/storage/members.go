@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"slices"
+	"time"
+)
+
+// SetChatInactive records that chatID blocked or removed bot (inactive) or
+// has it back, and reports whether that changed anything; bot sends such
+// chats nothing on its own. Other bots keep their own record of the chat.
+func (s *Store) SetChatInactive(bot string, chatID int64, inactive bool) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, was := s.data.Inactive[bot][chatID]
+	if was == inactive {
+		return false, nil
+	}
+	if inactive {
+		if s.data.Inactive[bot] == nil {
+			s.data.Inactive[bot] = make(map[int64]time.Time)
+		}
+		s.data.Inactive[bot][chatID] = time.Now()
+	} else {
+		delete(s.data.Inactive[bot], chatID)
+		if len(s.data.Inactive[bot]) == 0 {
+			delete(s.data.Inactive, bot)
+		}
+	}
+	return true, s.flushLocked()
+}
+
+// ChatActive reports whether bot may message chatID unprompted.
+func (s *Store) ChatActive(bot string, chatID int64) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, inactive := s.data.Inactive[bot][chatID]
+	return !inactive
+}
+
+// RegisterUser reports whether userID is new, with no state in the store
+// (see KnownUsers), and if so records them as registered, so that they are
+// registered once, until /forgetme. The record is kept apart from user
+// state and does not make them a known user.
+func (s *Store) RegisterUser(userID int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, registered := s.data.Registered[userID]
+	_, prefs := s.data.Users[userID]
+	_, history := s.data.History[userID]
+	_, quota := s.data.Quota[userID]
+	_, searches := s.data.Searches[userID]
+	if registered || prefs || history || quota || searches {
+		return false, nil
+	}
+	s.data.Registered[userID] = time.Now()
+	return true, s.flushLocked()
+}
+
+// AddBotUser records that userID wrote to bot in private, so bot can message
+// them. Like counters it is persisted by Run or Flush.
+func (s *Store) AddBotUser(bot string, userID int64) {
+	s.mu.RLock()
+	_, known := s.data.BotUsers[bot][userID]
+	s.mu.RUnlock()
+	if known {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.BotUsers[bot] == nil {
+		s.data.BotUsers[bot] = make(map[int64]time.Time)
+	}
+	if _, known := s.data.BotUsers[bot][userID]; !known {
+		s.data.BotUsers[bot][userID] = time.Now()
+		s.dirty = true
+	}
+}
+
+// BotUsers returns, in ascending order, the users bot can message: those who
+// wrote to it and the known users recorded for no bot, who used the bot
+// before users were recorded per bot.
+func (s *Store) BotUsers(bot string) []int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recorded := make(map[int64]bool)
+	for _, users := range s.data.BotUsers {
+		for id := range users {
+			recorded[id] = true
+		}
+	}
+	var out []int64
+	for id := range s.data.BotUsers[bot] {
+		out = append(out, id)
+	}
+	for _, id := range s.knownUsersLocked() {
+		if !recorded[id] {
+			out = append(out, id)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// KnownUsers returns, in ascending order, the users the bot has state for:
+// preferences, downloads, quota or saved searches.
+func (s *Store) KnownUsers() []int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.knownUsersLocked()
+}
+
+func (s *Store) knownUsersLocked() []int64 {
+	seen := make(map[int64]struct{}, len(s.data.Users))
+	for id := range s.data.Users {
+		seen[id] = struct{}{}
+	}
+	for id := range s.data.History {
+		seen[id] = struct{}{}
+	}
+	for id := range s.data.Quota {
+		seen[id] = struct{}{}
+	}
+	for id := range s.data.Searches {
+		seen[id] = struct{}{}
+	}
+	out := make([]int64, 0, len(seen))
+	for id := range seen {
+		if id > 0 {
+			out = append(out, id)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
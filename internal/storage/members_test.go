@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBotUsers(t *testing.T) {
+	s, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1 wrote to a, 2 to b, 3 to both; 4 has state from before bots were
+	// recorded.
+	s.AddBotUser("a", 1)
+	s.AddBotUser("b", 2)
+	s.AddBotUser("a", 3)
+	s.AddBotUser("b", 3)
+	s.SaveSearch(4, "queen", time.Now())
+
+	if got, want := s.BotUsers("a"), []int64{1, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("BotUsers(a) = %v, want %v", got, want)
+	}
+	if got, want := s.BotUsers("b"), []int64{2, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("BotUsers(b) = %v, want %v", got, want)
+	}
+
+	// Once a bot reaches 4, the others no longer count them.
+	s.AddBotUser("a", 4)
+	if got, want := s.BotUsers("b"), []int64{2, 3}; !slices.Equal(got, want) {
+		t.Errorf("BotUsers(b) = %v, want %v", got, want)
+	}
+}
+
+func TestChatInactivePerBot(t *testing.T) {
+	s, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := s.SetChatInactive("a", 1, false); changed {
+		t.Error("activating an active chat reported a change")
+	}
+	if changed, _ := s.SetChatInactive("a", 1, true); !changed {
+		t.Error("deactivating reported no change")
+	}
+	if s.ChatActive("a", 1) || !s.ChatActive("b", 1) {
+		t.Errorf("active for a %v, for b %v; want false, true", s.ChatActive("a", 1), s.ChatActive("b", 1))
+	}
+	if changed, _ := s.SetChatInactive("a", 1, false); !changed || !s.ChatActive("a", 1) {
+		t.Error("reactivating failed")
+	}
+}
+
+// TestRegisterUser checks that a user is registered once, until forgotten,
+// and that registering does not make them a known user.
+func TestRegisterUser(t *testing.T) {
+	s, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.RegisterUser(1); !ok || err != nil {
+		t.Fatalf("first RegisterUser = %v, %v; want true", ok, err)
+	}
+	if ok, _ := s.RegisterUser(1); ok {
+		t.Error("second RegisterUser reported a new user")
+	}
+	if got := s.KnownUsers(); len(got) != 0 {
+		t.Errorf("KnownUsers = %v after registering, want none", got)
+	}
+
+	s.SaveSearch(2, "queen", time.Now())
+	if ok, _ := s.RegisterUser(2); ok {
+		t.Error("a user with saved searches registered as new")
+	}
+
+	if _, err := s.ForgetUser(1); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.RegisterUser(1); !ok {
+		t.Error("a forgotten user did not register again")
+	}
+}
@@ -0,0 +1,21 @@
+package storage
+
+// UpdateOffset returns the id of the last update bot has taken from Telegram, 0 if none.
+func (s *Store) UpdateOffset(bot string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.UpdateOffsets[bot]
+}
+
+// SetUpdateOffset records updateID as taken by bot; older ids are ignored.
+// Like counters it is persisted by Run or Flush, so a crash may replay the
+// last few updates but a clean shutdown replays none.
+func (s *Store) SetUpdateOffset(bot string, updateID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if updateID <= s.data.UpdateOffsets[bot] {
+		return
+	}
+	s.data.UpdateOffsets[bot] = updateID
+	s.dirty = true
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// pickQueryLimit caps the queries whose inline picks are remembered; the
+// ones picked from longest ago are forgotten first.
+const pickQueryLimit = 2000
+
+// QueryPicks counts the tracks users picked from the inline results of a
+// query.
+type QueryPicks struct {
+	Tracks map[string]int `json:"tracks"`
+	// At is when a track was last picked for the query.
+	At time.Time `json:"at"`
+}
+
+// RecordPick counts trackID picked by userID from the inline results of
+// query; an empty query, e.g. suggestions, only counts towards the day.
+func (s *Store) RecordPick(userID int64, query, trackID string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := s.dayLocked(at)
+	day.Picks++
+	day.Users[userID] = true
+	s.dirty = true
+
+	key := pickKey(query)
+	if key == "" {
+		return
+	}
+	qp := s.data.Picks[key]
+	if qp == nil {
+		qp = &QueryPicks{Tracks: make(map[string]int)}
+		s.data.Picks[key] = qp
+	}
+	qp.Tracks[trackID]++
+	qp.At = at
+	s.prunePicksLocked()
+}
+
+// Picks returns how often each track was picked for query.
+func (s *Store) Picks(query string) map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	qp := s.data.Picks[pickKey(query)]
+	if qp == nil {
+		return nil
+	}
+	out := make(map[string]int, len(qp.Tracks))
+	for id, n := range qp.Tracks {
+		out[id] = n
+	}
+	return out
+}
+
+// pickKey folds case and spacing, so "Queen  Live" counts as "queen live".
+func pickKey(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// prunePicksLocked forgets the least recently picked queries past
+// pickQueryLimit; callers must hold s.mu.
+func (s *Store) prunePicksLocked() {
+	for len(s.data.Picks) > pickQueryLimit {
+		oldest := ""
+		for key, qp := range s.data.Picks {
+			if oldest == "" || qp.At.Before(s.data.Picks[oldest].At) {
+				oldest = key
+			}
+		}
+		delete(s.data.Picks, oldest)
+	}
+}
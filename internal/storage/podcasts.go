@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// maxPodcastSubscriptions caps the podcasts one chat follows.
+const maxPodcastSubscriptions = 20
+
+// ErrTooManySubscriptions is returned when a chat follows too many podcasts.
+var ErrTooManySubscriptions = errors.New("too many podcast subscriptions")
+
+// PodcastSubscription is a podcast a chat gets new-episode alerts for.
+type PodcastSubscription struct {
+	PodcastID string `json:"podcastId"`
+	Title     string `json:"title"`
+	// Seen is the publication time of the newest episode the chat knows of;
+	// episodes published later are new.
+	Seen time.Time `json:"seen"`
+	// Bot is the username of the bot the chat subscribed through, which
+	// sends the alerts; empty for subscriptions older than the field.
+	Bot string `json:"bot,omitempty"`
+}
+
+// Subscribe adds a subscription for chatID, or updates its title and bot
+// when the chat follows the podcast already; it reports whether it was added.
+func (s *Store) Subscribe(chatID int64, sub PodcastSubscription) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.data.Podcasts[chatID]
+	if i := slices.IndexFunc(subs, func(p PodcastSubscription) bool { return p.PodcastID == sub.PodcastID }); i >= 0 {
+		subs[i].Title, subs[i].Bot = sub.Title, sub.Bot
+		return false, s.flushLocked()
+	}
+	if len(subs) >= maxPodcastSubscriptions {
+		return false, ErrTooManySubscriptions
+	}
+	s.data.Podcasts[chatID] = append(subs, sub)
+	return true, s.flushLocked()
+}
+
+// Unsubscribe removes chatID's subscription to podcastID and reports
+// whether there was one.
+func (s *Store) Unsubscribe(chatID int64, podcastID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.data.Podcasts[chatID]
+	kept := slices.DeleteFunc(slices.Clone(subs), func(p PodcastSubscription) bool { return p.PodcastID == podcastID })
+	if len(kept) == len(subs) {
+		return false, nil
+	}
+	if len(kept) == 0 {
+		delete(s.data.Podcasts, chatID)
+	} else {
+		s.data.Podcasts[chatID] = kept
+	}
+	return true, s.flushLocked()
+}
+
+// Subscriptions returns the podcasts chatID follows, in subscription order.
+func (s *Store) Subscriptions(chatID int64) []PodcastSubscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.data.Podcasts[chatID])
+}
+
+// Subscribed reports whether chatID follows podcastID.
+func (s *Store) Subscribed(chatID int64, podcastID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.ContainsFunc(s.data.Podcasts[chatID], func(p PodcastSubscription) bool { return p.PodcastID == podcastID })
+}
+
+// AllSubscriptions returns every chat's subscriptions, keyed by chat.
+func (s *Store) AllSubscriptions() map[int64][]PodcastSubscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[int64][]PodcastSubscription, len(s.data.Podcasts))
+	for chatID, subs := range s.data.Podcasts {
+		out[chatID] = slices.Clone(subs)
+	}
+	return out
+}
+
+// MarkPodcastSeen records that chatID knows the episodes of podcastID
+// published up to seen. Earlier times are ignored.
+func (s *Store) MarkPodcastSeen(chatID int64, podcastID string, seen time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, p := range s.data.Podcasts[chatID] {
+		if p.PodcastID == podcastID && seen.After(p.Seen) {
+			s.data.Podcasts[chatID][i].Seen = seen
+			s.dirty = true
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// UserData is everything the store keeps about one user, see ExportUser.
+type UserData struct {
+	UserID      int64          `json:"userId"`
+	Preferences *UserPrefs     `json:"preferences,omitempty"`
+	History     []HistoryEntry `json:"history,omitempty"`
+	Searches    []SavedSearch  `json:"searches,omitempty"`
+	Feedback    []Feedback     `json:"feedback,omitempty"`
+	Jobs        []Job          `json:"jobs,omitempty"`
+	DeadLetters []DeadLetter   `json:"deadLetters,omitempty"`
+	// QuotaDay and QuotaUsed are the day's download count; QuotaOverride is
+	// the limit an admin set for the user, if any.
+	QuotaDay      string `json:"quotaDay,omitempty"`
+	QuotaUsed     int    `json:"quotaUsed,omitempty"`
+	QuotaOverride *int   `json:"quotaOverride,omitempty"`
+	// QuizScores are the user's /quiz standings by chat.
+	QuizScores map[int64]QuizScore `json:"quizScores,omitempty"`
+	// Bots, Podcasts, ChatSettings and InactiveBots describe the user's
+	// private chats: Bots are the bots the user wrote to, InactiveBots those
+	// the user blocked.
+	Bots         []string              `json:"bots,omitempty"`
+	Podcasts     []PodcastSubscription `json:"podcasts,omitempty"`
+	ChatSettings *ChatSettings         `json:"chatSettings,omitempty"`
+	InactiveBots []string              `json:"inactiveBots,omitempty"`
+	// StatsDays are the days the user counts among the daily users of /stats.
+	StatsDays []string `json:"statsDays,omitempty"`
+	// Registered is when the user was first reported as registered.
+	Registered *time.Time `json:"registered,omitempty"`
+}
+
+// ExportUser collects what the store keeps about userID.
+func (s *Store) ExportUser(userID int64) UserData {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.exportUserLocked(userID)
+}
+
+func (s *Store) exportUserLocked(userID int64) UserData {
+	d := UserData{UserID: userID}
+	if p, ok := s.data.Users[userID]; ok {
+		d.Preferences = &p
+	}
+	d.History = slices.Clone(s.data.History[userID])
+	d.Searches = slices.Clone(s.data.Searches[userID])
+	for _, f := range s.data.Feedback {
+		if f.UserID == userID {
+			d.Feedback = append(d.Feedback, f)
+		}
+	}
+	sort.Slice(d.Feedback, func(i, j int) bool { return d.Feedback[i].At.Before(d.Feedback[j].At) })
+	for _, j := range s.data.Jobs {
+		if j.UserID == userID {
+			d.Jobs = append(d.Jobs, j)
+		}
+	}
+	sort.Slice(d.Jobs, func(i, j int) bool { return d.Jobs[i].ReservedAt.Before(d.Jobs[j].ReservedAt) })
+	for _, e := range s.data.DeadLetters {
+		if e.UserID == userID {
+			d.DeadLetters = append(d.DeadLetters, e)
+		}
+	}
+	sort.Slice(d.DeadLetters, func(i, j int) bool { return d.DeadLetters[i].FailedAt.Before(d.DeadLetters[j].FailedAt) })
+	if q, ok := s.data.Quota[userID]; ok {
+		d.QuotaDay, d.QuotaUsed = q.Day, q.Used
+	}
+	if limit, ok := s.data.QuotaOverrides[userID]; ok {
+		d.QuotaOverride = &limit
+	}
+	for chatID, scores := range s.data.Quiz {
+		if score, ok := scores[userID]; ok {
+			if d.QuizScores == nil {
+				d.QuizScores = make(map[int64]QuizScore)
+			}
+			d.QuizScores[chatID] = score
+		}
+	}
+	d.Podcasts = slices.Clone(s.data.Podcasts[userID])
+	if c, ok := s.data.Chats[userID]; ok {
+		c.DisabledCommands = slices.Clone(c.DisabledCommands)
+		d.ChatSettings = &c
+	}
+	if at, ok := s.data.Registered[userID]; ok {
+		d.Registered = &at
+	}
+	for bot, users := range s.data.BotUsers {
+		if _, ok := users[userID]; ok {
+			d.Bots = append(d.Bots, bot)
+		}
+	}
+	sort.Strings(d.Bots)
+	for bot, chats := range s.data.Inactive {
+		if _, ok := chats[userID]; ok {
+			d.InactiveBots = append(d.InactiveBots, bot)
+		}
+	}
+	sort.Strings(d.InactiveBots)
+	for day, st := range s.data.Stats {
+		if st.Users[userID] {
+			d.StatsDays = append(d.StatsDays, day)
+		}
+	}
+	sort.Strings(d.StatsDays)
+	return d
+}
+
+// ForgetUser erases what the store keeps about userID and returns it, so
+// the caller can clean up files the entries point at. Two things stay: the
+// day's quota count, so erasing does not lift the daily limit, and queued
+// or running jobs, which finish and then expire like any other. The audit
+// log is separate, see AuditLog.Forget.
+func (s *Store) ForgetUser(userID int64) (UserData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Read under the same lock as the deletes, so nothing written in between
+	// is erased without being returned.
+	d := s.exportUserLocked(userID)
+	delete(s.data.Users, userID)
+	delete(s.data.History, userID)
+	delete(s.data.Searches, userID)
+	delete(s.data.QuotaOverrides, userID)
+	delete(s.data.Podcasts, userID)
+	delete(s.data.Chats, userID)
+	delete(s.data.Registered, userID)
+	s.settingsRev.Add(1)
+	for bot, users := range s.data.BotUsers {
+		delete(users, userID)
+		if len(users) == 0 {
+			delete(s.data.BotUsers, bot)
+		}
+	}
+	for bot, chats := range s.data.Inactive {
+		delete(chats, userID)
+		if len(chats) == 0 {
+			delete(s.data.Inactive, bot)
+		}
+	}
+	for id, f := range s.data.Feedback {
+		if f.UserID == userID {
+			delete(s.data.Feedback, id)
+		}
+	}
+	for key, j := range s.data.Jobs {
+		if j.UserID == userID && j.State.Finished() {
+			delete(s.data.Jobs, key)
+		}
+	}
+	for id, e := range s.data.DeadLetters {
+		if e.UserID == userID {
+			delete(s.data.DeadLetters, id)
+		}
+	}
+	for chatID, scores := range s.data.Quiz {
+		delete(scores, userID)
+		if len(scores) == 0 {
+			delete(s.data.Quiz, chatID)
+		}
+	}
+	for _, st := range s.data.Stats {
+		delete(st.Users, userID)
+	}
+	return d, s.flushLocked()
+}
@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// seedUser gives user 7 state of every kind ForgetUser erases, and user 8 a
+// little to keep.
+func seedUser(t *testing.T, s *Store) {
+	t.Helper()
+	now := time.Now()
+	if _, err := s.UpdatePrefs(7, func(p *UserPrefs) { p.SendAsDocument = true }); err != nil {
+		t.Fatal(err)
+	}
+	s.RecordDownload(7, HistoryEntry{At: now, TrackID: "1", Title: "Song"})
+	s.RecordDownload(8, HistoryEntry{At: now, TrackID: "2", Title: "Other"})
+	s.SaveSearch(7, "queen", now)
+	if _, err := s.AddFeedback(Feedback{UserID: 7, Text: "hi", At: now}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ConsumeQuota(7, 10, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetQuotaOverride(7, 50); err != nil {
+		t.Fatal(err)
+	}
+	s.RecordQuizAnswer(-100, 7, "Seven", true)
+	s.RecordQuizAnswer(-100, 8, "Eight", false)
+	if _, err := s.Subscribe(7, PodcastSubscription{PodcastID: "p1", Title: "Talk"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SetChatInactive("bot", 7, true); err != nil {
+		t.Fatal(err)
+	}
+	s.AddBotUser("bot", 7)
+	if err := s.SaveJob(Job{Key: "done", UserID: 7, State: JobDone}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveJob(Job{Key: "queued", UserID: 7, State: JobQueued}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddDeadLetter(DeadLetter{UserID: 7, TrackID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportUser(t *testing.T) {
+	s, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedUser(t, s)
+
+	d := s.ExportUser(7)
+	switch {
+	case d.Preferences == nil || !d.Preferences.SendAsDocument:
+		t.Errorf("preferences %+v", d.Preferences)
+	case len(d.History) != 1 || d.History[0].TrackID != "1":
+		t.Errorf("history %+v", d.History)
+	case len(d.Searches) != 1 || len(d.Feedback) != 1 || len(d.Jobs) != 2 || len(d.DeadLetters) != 1:
+		t.Errorf("searches %d, feedback %d, jobs %d, dead letters %d; want 1, 1, 2, 1",
+			len(d.Searches), len(d.Feedback), len(d.Jobs), len(d.DeadLetters))
+	case d.QuotaUsed != 1 || d.QuotaOverride == nil || *d.QuotaOverride != 50:
+		t.Errorf("quota used %d, override %v", d.QuotaUsed, d.QuotaOverride)
+	case len(d.QuizScores) != 1 || d.QuizScores[-100].Correct != 1:
+		t.Errorf("quiz scores %+v", d.QuizScores)
+	case len(d.Podcasts) != 1 || len(d.Bots) != 1 || len(d.InactiveBots) != 1 || len(d.StatsDays) != 1:
+		t.Errorf("podcasts %+v, bots %v, inactive %v, stats days %v", d.Podcasts, d.Bots, d.InactiveBots, d.StatsDays)
+	}
+	if empty := s.ExportUser(9); empty.Preferences != nil || empty.History != nil || empty.QuizScores != nil {
+		t.Errorf("unknown user exported %+v", empty)
+	}
+}
+
+func TestForgetUser(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "store.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seedUser(t, s)
+
+	d, err := s.ForgetUser(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.History) != 1 || len(d.DeadLetters) != 1 {
+		t.Errorf("ForgetUser returned %+v, want what it erased", d)
+	}
+
+	left := s.ExportUser(7)
+	if left.Preferences != nil || left.History != nil || left.Searches != nil || left.Feedback != nil ||
+		left.DeadLetters != nil || left.QuotaOverride != nil || left.QuizScores != nil || left.Podcasts != nil ||
+		left.Bots != nil || left.InactiveBots != nil || left.StatsDays != nil {
+		t.Errorf("left after ForgetUser: %+v", left)
+	}
+	// The day's count and unfinished jobs stay.
+	if left.QuotaUsed != 1 {
+		t.Errorf("quota used %d after ForgetUser, want 1", left.QuotaUsed)
+	}
+	if len(left.Jobs) != 1 || left.Jobs[0].Key != "queued" {
+		t.Errorf("jobs %+v after ForgetUser, want the queued one", left.Jobs)
+	}
+
+	other := s.ExportUser(8)
+	if len(other.History) != 1 || len(other.QuizScores) != 1 {
+		t.Errorf("another user's data changed: %+v", other)
+	}
+	if got := s.QuizScores(-100); len(got) != 1 || got[0].UserID != 8 {
+		t.Errorf("quiz scores %+v", got)
+	}
+}
+
+func TestAuditForget(t *testing.T) {
+	entries := []AuditEntry{
+		{UserID: 7, ChatID: 7, TrackID: "1", Outcome: AuditDelivered},
+		{UserID: 8, ChatID: 8, TrackID: "2", Outcome: AuditDelivered},
+		{UserID: 7, ChatID: -100, TrackID: "3", Outcome: AuditFailed},
+	}
+	for _, path := range []string{"", filepath.Join(t.TempDir(), "audit.jsonl")} {
+		l, err := OpenAudit(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if err := l.Append(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if path != "" {
+			// A torn line survives the rewrite.
+			if _, err := l.file.WriteString("{\"userId\":7,\n"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		n, err := l.Forget(7)
+		if err != nil {
+			t.Fatalf("%q: Forget: %v", path, err)
+		}
+		if n != 2 {
+			t.Errorf("%q: Forget changed %d entries, want 2", path, n)
+		}
+		if mine, _ := l.Query(AuditFilter{UserID: 7}); len(mine) != 0 {
+			t.Errorf("%q: entries of the forgotten user left: %+v", path, mine)
+		}
+		// Appending goes on after the rewrite.
+		if err := l.Append(AuditEntry{UserID: 8, ChatID: 8, TrackID: "4"}); err != nil {
+			t.Fatal(err)
+		}
+		all, err := l.Query(AuditFilter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 4 {
+			t.Fatalf("%q: %d entries after Forget, want 4", path, len(all))
+		}
+		if all[0].UserID != 0 || all[0].ChatID != 0 || all[2].ChatID != -100 || all[2].TrackID != "3" {
+			t.Errorf("%q: anonymised entries %+v and %+v", path, all[0], all[2])
+		}
+		if path != "" {
+			raw, _ := os.ReadFile(path)
+			if !strings.Contains(string(raw), "{\"userId\":7,\n") {
+				t.Errorf("%q: torn line dropped", path)
+			}
+		}
+		l.Close()
+	}
+}
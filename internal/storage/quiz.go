@@ -0,0 +1,53 @@
+package storage
+
+import "sort"
+
+// QuizScore is a player's standing in a chat's /quiz games.
+type QuizScore struct {
+	UserID  int64  `json:"userId"`
+	Name    string `json:"name"`
+	Correct int    `json:"correct"`
+	Answers int    `json:"answers"`
+}
+
+// RecordQuizAnswer counts an answer of userID in chatID's quiz, keeping name
+// up to date for the score table.
+func (s *Store) RecordQuizAnswer(chatID, userID int64, name string, correct bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scores := s.data.Quiz[chatID]
+	if scores == nil {
+		scores = make(map[int64]QuizScore)
+		s.data.Quiz[chatID] = scores
+	}
+	score := scores[userID]
+	score.UserID, score.Name = userID, name
+	score.Answers++
+	if correct {
+		score.Correct++
+	}
+	scores[userID] = score
+	s.dirty = true
+}
+
+// QuizScores returns chatID's score table, best players first.
+func (s *Store) QuizScores(chatID int64) []QuizScore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]QuizScore, 0, len(s.data.Quiz[chatID]))
+	for _, score := range s.data.Quiz[chatID] {
+		out = append(out, score)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Correct != out[j].Correct {
+			return out[i].Correct > out[j].Correct
+		}
+		if out[i].Answers != out[j].Answers {
+			return out[i].Answers < out[j].Answers
+		}
+		return out[i].UserID < out[j].UserID
+	})
+	return out
+}
@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrQuotaExceeded is returned when the user has no downloads left today.
+var ErrQuotaExceeded = errors.New("daily download quota exceeded")
+
+// Unlimited marks a quota override that disables the limit for a user.
+const Unlimited = 0
+
+// QuotaStatus describes a user's quota after an operation.
+type QuotaStatus struct {
+	Used       int
+	Limit      int // 0 means unlimited
+	ResetAt    time.Time
+	Overridden bool
+}
+
+// Remaining returns downloads left today, or -1 when unlimited.
+func (q QuotaStatus) Remaining() int {
+	if q.Limit == Unlimited {
+		return -1
+	}
+	if left := q.Limit - q.Used; left > 0 {
+		return left
+	}
+	return 0
+}
+
+// quotaUsage is the per-user counter for a single UTC day.
+type quotaUsage struct {
+	Day  string `json:"day"`
+	Used int    `json:"used"`
+}
+
+// ConsumeQuota reserves one download for userID against defaultLimit
+// (or the user's override). It returns ErrQuotaExceeded with the current
+// status when nothing is left. A defaultLimit of 0 disables the quota.
+func (s *Store) ConsumeQuota(userID int64, defaultLimit int, at time.Time) (QuotaStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := s.quotaStatusLocked(userID, defaultLimit, at)
+	if status.Limit != Unlimited && status.Used >= status.Limit {
+		return status, ErrQuotaExceeded
+	}
+
+	s.data.Quota[userID] = quotaUsage{Day: at.UTC().Format(dayLayout), Used: status.Used + 1}
+	s.dirty = true
+	status.Used++
+	return status, nil
+}
+
+// ReleaseQuota gives back a download reserved by ConsumeQuota (e.g. after a failure).
+func (s *Store) ReleaseQuota(userID int64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	usage, ok := s.data.Quota[userID]
+	if !ok || usage.Day != at.UTC().Format(dayLayout) || usage.Used == 0 {
+		return
+	}
+	usage.Used--
+	s.data.Quota[userID] = usage
+	s.dirty = true
+}
+
+// QuotaStatus reports the user's quota without consuming it.
+func (s *Store) QuotaStatus(userID int64, defaultLimit int, at time.Time) QuotaStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.quotaStatusLocked(userID, defaultLimit, at)
+}
+
+// SetQuotaOverride sets a per-user daily limit (Unlimited disables it).
+func (s *Store) SetQuotaOverride(userID int64, limit int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.QuotaOverrides[userID] = limit
+	return s.flushLocked()
+}
+
+// ClearQuotaOverride restores the default limit and today's counter for the user.
+func (s *Store) ClearQuotaOverride(userID int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data.QuotaOverrides, userID)
+	delete(s.data.Quota, userID)
+	return s.flushLocked()
+}
+
+func (s *Store) quotaStatusLocked(userID int64, defaultLimit int, at time.Time) QuotaStatus {
+	day := at.UTC().Truncate(24 * time.Hour)
+	status := QuotaStatus{
+		Limit:   defaultLimit,
+		ResetAt: day.Add(24 * time.Hour),
+	}
+	if override, ok := s.data.QuotaOverrides[userID]; ok {
+		status.Limit = override
+		status.Overridden = true
+	}
+	if usage, ok := s.data.Quota[userID]; ok && usage.Day == day.Format(dayLayout) {
+		status.Used = usage.Used
+	}
+	return status
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// recentSearchLimit caps remembered queries per user.
+const recentSearchLimit = 10
+
+// SavedSearch is a query a user ran recently.
+type SavedSearch struct {
+	Query string    `json:"query"`
+	At    time.Time `json:"at"`
+}
+
+// SaveSearch puts query at the top of userID's recent searches. Repeating a
+// query moves it up instead of adding a duplicate.
+func (s *Store) SaveSearch(userID int64, query string, at time.Time) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	recent := []SavedSearch{{Query: query, At: at}}
+	for _, r := range s.data.Searches[userID] {
+		if !strings.EqualFold(r.Query, query) && len(recent) < recentSearchLimit {
+			recent = append(recent, r)
+		}
+	}
+	s.data.Searches[userID] = recent
+	s.dirty = true
+}
+
+// RecentSearches returns userID's recent searches, newest first.
+func (s *Store) RecentSearches(userID int64) []SavedSearch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	recent := s.data.Searches[userID]
+	out := make([]SavedSearch, len(recent))
+	copy(out, recent)
+	return out
+}
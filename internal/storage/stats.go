@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	dayLayout = "2006-01-02"
+	// statsRetention bounds how many days of counters are kept.
+	statsRetention = 90
+)
+
+// DayStats aggregates usage counters for a single UTC day.
+type DayStats struct {
+	Searches  int               `json:"searches"`
+	Downloads int               `json:"downloads"`
+	Users     map[int64]bool    `json:"users"`
+	Tracks    map[string]int    `json:"tracks"`
+	Titles    map[string]string `json:"titles"`
+	// Panics counts handler crashes that were recovered.
+	Panics int `json:"panics,omitempty"`
+	// Picks counts inline results users sent.
+	Picks int `json:"picks,omitempty"`
+}
+
+// DayTotals is a per-day row of a stats summary.
+type DayTotals struct {
+	Date      time.Time
+	Searches  int
+	Downloads int
+	Users     int
+}
+
+// TrackCount is a track with its download count.
+type TrackCount struct {
+	ID    string
+	Title string
+	Count int
+}
+
+// StatsSummary aggregates counters over a period.
+type StatsSummary struct {
+	Days        []DayTotals
+	Searches    int
+	Downloads   int
+	UniqueUsers int
+	Panics      int
+	Picks       int
+	TopTracks   []TrackCount
+}
+
+// RecordSearch counts a search performed by userID.
+func (s *Store) RecordSearch(userID int64, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := s.dayLocked(at)
+	day.Searches++
+	day.Users[userID] = true
+	s.dirty = true
+}
+
+// RecordDownload counts a delivered track and appends it to the user's history.
+func (s *Store) RecordDownload(userID int64, e HistoryEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	day := s.dayLocked(e.At)
+	day.Downloads++
+	day.Users[userID] = true
+	day.Tracks[e.TrackID]++
+	if title := e.DisplayTitle(); title != "" {
+		day.Titles[e.TrackID] = title
+	}
+	s.appendHistoryLocked(userID, e)
+	s.dirty = true
+}
+
+// RecordPanic counts a recovered handler panic.
+func (s *Store) RecordPanic(at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dayLocked(at).Panics++
+	s.dirty = true
+}
+
+// Stats summarizes the last `days` days up to and including now.
+func (s *Store) Stats(now time.Time, days, top int) StatsSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sum StatsSummary
+	users := make(map[int64]bool)
+	tracks := make(map[string]*TrackCount)
+
+	start := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+	for i := 0; i < days; i++ {
+		date := start.AddDate(0, 0, i)
+		row := DayTotals{Date: date}
+		if day, ok := s.data.Stats[date.Format(dayLayout)]; ok {
+			row.Searches = day.Searches
+			row.Downloads = day.Downloads
+			row.Users = len(day.Users)
+			sum.Panics += day.Panics
+			sum.Picks += day.Picks
+			for id := range day.Users {
+				users[id] = true
+			}
+			for id, n := range day.Tracks {
+				tc, ok := tracks[id]
+				if !ok {
+					tc = &TrackCount{ID: id}
+					tracks[id] = tc
+				}
+				tc.Count += n
+				if t := day.Titles[id]; t != "" {
+					tc.Title = t
+				}
+			}
+		}
+		sum.Searches += row.Searches
+		sum.Downloads += row.Downloads
+		sum.Days = append(sum.Days, row)
+	}
+	sum.UniqueUsers = len(users)
+
+	for _, tc := range tracks {
+		sum.TopTracks = append(sum.TopTracks, *tc)
+	}
+	sort.Slice(sum.TopTracks, func(i, j int) bool {
+		if sum.TopTracks[i].Count != sum.TopTracks[j].Count {
+			return sum.TopTracks[i].Count > sum.TopTracks[j].Count
+		}
+		return sum.TopTracks[i].ID < sum.TopTracks[j].ID
+	})
+	if len(sum.TopTracks) > top {
+		sum.TopTracks = sum.TopTracks[:top]
+	}
+	return sum
+}
+
+// dayLocked returns (creating if needed) the bucket for at; callers must hold s.mu.
+func (s *Store) dayLocked(at time.Time) *DayStats {
+	key := at.UTC().Format(dayLayout)
+	day, ok := s.data.Stats[key]
+	if !ok {
+		day = &DayStats{}
+		s.data.Stats[key] = day
+		s.pruneStatsLocked(at)
+	}
+	if day.Users == nil {
+		day.Users = make(map[int64]bool)
+	}
+	if day.Tracks == nil {
+		day.Tracks = make(map[string]int)
+	}
+	if day.Titles == nil {
+		day.Titles = make(map[string]string)
+	}
+	return day
+}
+
+// pruneStatsLocked drops buckets older than statsRetention days.
+func (s *Store) pruneStatsLocked(now time.Time) {
+	cutoff := now.UTC().AddDate(0, 0, -statsRetention).Format(dayLayout)
+	for key := range s.data.Stats {
+		if key < cutoff {
+			delete(s.data.Stats, key)
+		}
+	}
+}
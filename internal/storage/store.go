@@ -0,0 +1,259 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// UserPrefs holds per-user delivery preferences.
+type UserPrefs struct {
+	SendAsDocument bool `json:"sendAsDocument"`
+	// SkipPreflight downloads large files without asking first.
+	SkipPreflight bool `json:"skipPreflight"`
+	// PlaylistKind is the Yandex playlist the user's saved tracks go to; 0 until the first save.
+	PlaylistKind int `json:"playlistKind,omitempty"`
+	// SearchOrder sorts search results, see music.Order; empty keeps relevance.
+	SearchOrder string `json:"searchOrder,omitempty"`
+	// HidePreviews strips link previews from the bot's messages in the
+	// user's private chat.
+	HidePreviews bool `json:"hidePreviews,omitempty"`
+	// Normalize evens out the loudness of the tracks sent to the user.
+	Normalize bool `json:"normalize,omitempty"`
+	// TrimSilence cuts leading and trailing silence off those tracks.
+	TrimSilence bool `json:"trimSilence,omitempty"`
+	// Transliterate spells artists and titles in another script in captions,
+	// file names and tags, see translit.Direction; empty keeps them as is.
+	Transliterate string `json:"transliterate,omitempty"`
+}
+
+// snapshot is the on-disk representation of the store.
+type snapshot struct {
+	Users          map[int64]UserPrefs      `json:"users"`
+	Stats          map[string]*DayStats     `json:"stats"`
+	Quota          map[int64]quotaUsage     `json:"quota"`
+	QuotaOverrides map[int64]int            `json:"quotaOverrides"`
+	History        map[int64][]HistoryEntry `json:"history"`
+	DeadLetters    map[string]DeadLetter    `json:"deadLetters"`
+	Maintenance    Maintenance              `json:"maintenance"`
+	Feedback       map[string]Feedback      `json:"feedback"`
+	Searches       map[int64][]SavedSearch  `json:"searches"`
+	Jobs           map[string]Job           `json:"jobs"`
+	UpdateOffsets  map[string]int           `json:"updateOffsets"`
+	Chats          map[int64]ChatSettings   `json:"chats"`
+	// Quiz holds /quiz score tables by chat, then by user.
+	Quiz map[int64]map[int64]QuizScore `json:"quiz"`
+	// Podcasts holds podcast subscriptions by chat.
+	Podcasts map[int64][]PodcastSubscription `json:"podcasts"`
+	// BotUsers holds, per bot username, the users who wrote to that bot in
+	// private, with when they first did.
+	BotUsers map[string]map[int64]time.Time `json:"botUsers"`
+	// Inactive holds, per bot username, the chats that blocked or removed
+	// that bot, with when.
+	Inactive map[string]map[int64]time.Time `json:"inactiveChats"`
+	// TrackAliases maps track ids Yandex has migrated to their current ids.
+	TrackAliases map[string]string `json:"trackAliases"`
+	// QueuePaused holds the download queue paused, independently of maintenance.
+	QueuePaused bool `json:"queuePaused,omitempty"`
+	// Picks counts the inline results picked, by normalized query.
+	Picks map[string]*QueryPicks `json:"picks"`
+	// Uploads maps bots' tracks to the file ids of their uploads that
+	// replace picked inline audio.
+	Uploads map[string]string `json:"uploads"`
+	// Registered holds the users RegisterUser took as new, with when. It
+	// is not state about them, so KnownUsers ignores it.
+	Registered map[int64]time.Time `json:"registered"`
+}
+
+// init allocates maps missing from older or empty snapshots.
+func (d *snapshot) init() {
+	if d.Users == nil {
+		d.Users = make(map[int64]UserPrefs)
+	}
+	if d.Stats == nil {
+		d.Stats = make(map[string]*DayStats)
+	}
+	if d.Quota == nil {
+		d.Quota = make(map[int64]quotaUsage)
+	}
+	if d.QuotaOverrides == nil {
+		d.QuotaOverrides = make(map[int64]int)
+	}
+	if d.History == nil {
+		d.History = make(map[int64][]HistoryEntry)
+	}
+	if d.DeadLetters == nil {
+		d.DeadLetters = make(map[string]DeadLetter)
+	}
+	if d.Feedback == nil {
+		d.Feedback = make(map[string]Feedback)
+	}
+	if d.Searches == nil {
+		d.Searches = make(map[int64][]SavedSearch)
+	}
+	if d.Jobs == nil {
+		d.Jobs = make(map[string]Job)
+	}
+	if d.UpdateOffsets == nil {
+		d.UpdateOffsets = make(map[string]int)
+	}
+	if d.Chats == nil {
+		d.Chats = make(map[int64]ChatSettings)
+	}
+	if d.Quiz == nil {
+		d.Quiz = make(map[int64]map[int64]QuizScore)
+	}
+	if d.Podcasts == nil {
+		d.Podcasts = make(map[int64][]PodcastSubscription)
+	}
+	if d.BotUsers == nil {
+		d.BotUsers = make(map[string]map[int64]time.Time)
+	}
+	if d.Inactive == nil {
+		d.Inactive = make(map[string]map[int64]time.Time)
+	}
+	if d.TrackAliases == nil {
+		d.TrackAliases = make(map[string]string)
+	}
+	if d.Picks == nil {
+		d.Picks = make(map[string]*QueryPicks)
+	}
+	if d.Uploads == nil {
+		d.Uploads = make(map[string]string)
+	}
+	if d.Registered == nil {
+		d.Registered = make(map[int64]time.Time)
+	}
+}
+
+// Store keeps bot state in memory and persists it to a JSON file.
+// An empty path yields a purely in-memory store. Frequent writes (counters)
+// only mark the store dirty and are persisted by Run or Flush.
+type Store struct {
+	mu    sync.RWMutex
+	path  string
+	data  snapshot
+	dirty bool
+	// settingsRev counts the changes to user preferences and chat settings,
+	// see SettingsRevision.
+	settingsRev atomic.Uint64
+}
+
+// Open loads the store from path, creating an empty one if the file is absent.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	s.data.init()
+	if path == "" {
+		return s, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read store: %w", err)
+	}
+	if len(raw) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("decode store: %w", err)
+	}
+	s.data.init()
+	return s, nil
+}
+
+// Run periodically persists pending changes until ctx is done, then flushes once more.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			_ = s.Flush()
+			return
+		case <-ticker.C:
+			_ = s.Flush()
+		}
+	}
+}
+
+// Flush persists pending changes, if any.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	return s.flushLocked()
+}
+
+// Prefs returns stored preferences for the user (zero value if unknown).
+func (s *Store) Prefs(userID int64) UserPrefs {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.Users[userID]
+}
+
+// UpdatePrefs applies fn to the user's preferences and persists the result.
+func (s *Store) UpdatePrefs(userID int64, fn func(*UserPrefs)) (UserPrefs, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs := s.data.Users[userID]
+	fn(&prefs)
+	s.data.Users[userID] = prefs
+	s.settingsRev.Add(1)
+
+	return prefs, s.flushLocked()
+}
+
+// SettingsRevision changes whenever a user's preferences or a chat's
+// settings may have, so callers can cache what they derive from them and
+// drop the cache when it does. Reading it takes no lock.
+func (s *Store) SettingsRevision() uint64 {
+	return s.settingsRev.Load()
+}
+
+// flushLocked writes the snapshot atomically; callers must hold s.mu.
+func (s *Store) flushLocked() error {
+	if s.path == "" {
+		s.dirty = false
+		return nil
+	}
+
+	raw, err := json.Marshal(s.data)
+	if err != nil {
+		return fmt.Errorf("encode store: %w", err)
+	}
+
+	if err := ensureDir(filepath.Dir(s.path)); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return fmt.Errorf("write store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("replace store: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// ensureDir creates a directory if missing.
+func ensureDir(dir string) error {
+	if dir == "" || dir == "." {
+		return nil
+	}
+	return os.MkdirAll(dir, 0o755)
+}
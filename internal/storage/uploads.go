@@ -0,0 +1,28 @@
+package storage
+
+// Upload returns the file id of the upload kept for key: the uploading bot,
+// a track and the script it is labelled in.
+func (s *Store) Upload(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.data.Uploads[key]
+	return id, ok
+}
+
+// SetUpload keeps fileID as the upload of key.
+func (s *Store) SetUpload(key, fileID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Uploads[key] = fileID
+	s.dirty = true
+}
+
+// ForgetUpload drops the upload of key, after Telegram rejected its file id.
+func (s *Store) ForgetUpload(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Uploads[key]; ok {
+		delete(s.data.Uploads, key)
+		s.dirty = true
+	}
+}
@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// NameCount is an artist with how many of a user's downloads credit them.
+type NameCount struct {
+	Name  string
+	Count int
+}
+
+// UserStats summarizes a user's download history over a period.
+type UserStats struct {
+	Downloads int
+	// Minutes is the total duration of the downloads; entries recorded
+	// before durations were kept add nothing.
+	Minutes    int
+	Artists    int // distinct main artists
+	TopArtists []NameCount
+	TopTracks  []TrackCount
+	// First and Last are the times of the earliest and latest download.
+	First, Last time.Time
+}
+
+// UserStats summarizes userID's downloads made in [from, to); zero times
+// leave that side open. Top lists hold up to top entries, most downloaded
+// first, ties broken by the most recent download.
+func (s *Store) UserStats(userID int64, from, to time.Time, top int) UserStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var st UserStats
+	seconds := 0
+	tracks := make(map[string]*TrackCount)
+	artists := make(map[string]*NameCount)
+	last := make(map[string]time.Time) // by "t:"+track id and "a:"+artist
+	for _, e := range s.data.History[userID] {
+		if (!from.IsZero() && e.At.Before(from)) || (!to.IsZero() && !e.At.Before(to)) {
+			continue
+		}
+		st.Downloads++
+		seconds += e.DurationSec
+		if st.First.IsZero() {
+			st.First = e.At
+		}
+		st.Last = e.At
+
+		t := tracks[e.TrackID]
+		if t == nil {
+			t = &TrackCount{ID: e.TrackID}
+			tracks[e.TrackID] = t
+		}
+		t.Count++
+		t.Title = e.DisplayTitle()
+		last["t:"+e.TrackID] = e.At
+
+		for _, name := range e.mainArtists() {
+			a := artists[name]
+			if a == nil {
+				a = &NameCount{Name: name}
+				artists[name] = a
+			}
+			a.Count++
+			last["a:"+name] = e.At
+		}
+	}
+	st.Minutes = seconds / 60
+	st.Artists = len(artists)
+
+	for _, t := range tracks {
+		st.TopTracks = append(st.TopTracks, *t)
+	}
+	sort.Slice(st.TopTracks, func(i, j int) bool {
+		a, b := st.TopTracks[i], st.TopTracks[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return last["t:"+a.ID].After(last["t:"+b.ID])
+	})
+	for _, a := range artists {
+		st.TopArtists = append(st.TopArtists, *a)
+	}
+	sort.Slice(st.TopArtists, func(i, j int) bool {
+		a, b := st.TopArtists[i], st.TopArtists[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return last["a:"+a.Name].After(last["a:"+b.Name])
+	})
+	if len(st.TopTracks) > top {
+		st.TopTracks = st.TopTracks[:top]
+	}
+	if len(st.TopArtists) > top {
+		st.TopArtists = st.TopArtists[:top]
+	}
+	return st
+}
+
+// mainArtists returns the entry's main artists, recovered from the rendered
+// line for entries that predate MainArtists.
+func (e HistoryEntry) mainArtists() []string {
+	if len(e.MainArtists) > 0 {
+		return e.MainArtists
+	}
+	main, _, _ := strings.Cut(e.Artists, " feat. ")
+	var out []string
+	for _, name := range strings.Split(main, ", ") {
+		if name = strings.TrimSpace(name); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUserStats(t *testing.T) {
+	s, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, e := range []HistoryEntry{
+		{TrackID: "1", Title: "One", Artists: "Band", DurationSec: 200},
+		{TrackID: "2", Title: "Two", Artists: "Band feat. Guest", DurationSec: 100},
+		{TrackID: "3", Title: "Three", Artists: "Solo", MainArtists: []string{"Solo"}, DurationSec: 60},
+		{TrackID: "1", Title: "One", Artists: "Band", DurationSec: 200},
+		{TrackID: "3", Title: "Three", Artists: "Solo", MainArtists: []string{"Solo"}},
+		// Outside the period.
+		{TrackID: "4", Title: "Four", Artists: "Other", DurationSec: 600},
+	} {
+		e.At = day.Add(time.Duration(i) * time.Hour)
+		if e.TrackID == "4" {
+			e.At = day.AddDate(1, 0, 0)
+		}
+		s.RecordDownload(7, e)
+	}
+
+	st := s.UserStats(7, day, day.AddDate(0, 1, 0), 2)
+	if st.Downloads != 5 || st.Minutes != 9 || st.Artists != 2 {
+		t.Errorf("downloads %d, minutes %d, artists %d; want 5, 9, 2", st.Downloads, st.Minutes, st.Artists)
+	}
+	if !st.First.Equal(day) || !st.Last.Equal(day.Add(4*time.Hour)) {
+		t.Errorf("period %v – %v", st.First, st.Last)
+	}
+	// Band is credited three times, with Guest only featured; Solo twice.
+	wantArtists := []NameCount{{"Band", 3}, {"Solo", 2}}
+	if !slices.Equal(st.TopArtists, wantArtists) {
+		t.Errorf("top artists %v, want %v", st.TopArtists, wantArtists)
+	}
+	// One and Three were downloaded twice, Three last.
+	wantTracks := []TrackCount{{ID: "3", Title: "Solo — Three", Count: 2}, {ID: "1", Title: "Band — One", Count: 2}}
+	if !slices.Equal(st.TopTracks, wantTracks) {
+		t.Errorf("top tracks %v, want %v", st.TopTracks, wantTracks)
+	}
+
+	if all := s.UserStats(7, time.Time{}, time.Time{}, 10); all.Downloads != 6 || len(all.TopTracks) != 4 {
+		t.Errorf("open period: %d downloads, %d tracks; want 6, 4", all.Downloads, len(all.TopTracks))
+	}
+	if none := s.UserStats(8, time.Time{}, time.Time{}, 10); none.Downloads != 0 || none.TopArtists != nil {
+		t.Errorf("user without history: %+v", none)
+	}
+}
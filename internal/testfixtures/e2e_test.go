@@ -0,0 +1,645 @@
+package testfixtures_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/settings"
+	"ym-bot/internal/storage"
+	"ym-bot/internal/testfixtures"
+	"ym-bot/internal/transport/telegram"
+)
+
+const (
+	e2eUser = 42
+	e2eWait = 10 * time.Second
+)
+
+// startBot runs a bot against env until the test ends and returns its store.
+func startBot(t *testing.T, env *testfixtures.Env, opts ...telegram.Option) *storage.Store {
+	t.Helper()
+	bot, store, err := env.Bot(zap.NewNop(), opts...)
+	if err != nil {
+		t.Fatalf("bot: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = bot.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return store
+}
+
+// inlineResult is the part of an answered inline result the test reads.
+type inlineResult struct {
+	ID          string `json:"id"`
+	ReplyMarkup struct {
+		InlineKeyboard [][]struct {
+			Text         string `json:"text"`
+			CallbackData string `json:"callback_data"`
+		} `json:"inline_keyboard"`
+	} `json:"reply_markup"`
+}
+
+// downloadData finds the signed data of the first result's download button.
+func downloadData(t *testing.T, answer testfixtures.Call) string {
+	t.Helper()
+	var results []inlineResult
+	if err := json.Unmarshal([]byte(answer.Params.Get("results")), &results); err != nil {
+		t.Fatalf("decode inline results: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("inline query answered with no results")
+	}
+	for _, row := range results[0].ReplyMarkup.InlineKeyboard {
+		for _, button := range row {
+			if button.CallbackData != "" {
+				return button.CallbackData
+			}
+		}
+	}
+	t.Fatalf("result %s has no callback button", results[0].ID)
+	return ""
+}
+
+// TestInlineDownload drives an inline query, the press of the download
+// button under the sent card and the delivery of the file to the user.
+func TestInlineDownload(t *testing.T) {
+	audio := bytes.Repeat([]byte("fake mp3 frame "), 512)
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "1001", Title: "Song", Artists: []string{"Band"}, DurationMs: 180000, Audio: audio,
+	})
+	// Registered before startBot's cleanup, so it runs after the bot stopped.
+	t.Cleanup(env.Close)
+	startBot(t, env, telegram.WithSettings(settings.New(settings.Runtime{InlineFast: true})))
+
+	user := &tgbotapi.User{ID: e2eUser, FirstName: "Test"}
+	env.Telegram.PushUpdate(tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{ID: "q1", From: user, Query: "Band Song"}})
+	answer, ok := env.Telegram.WaitForCall("answerInlineQuery", e2eWait)
+	if !ok {
+		t.Fatal("inline query not answered")
+	}
+
+	env.Telegram.PushUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:              "cb1",
+		From:            user,
+		InlineMessageID: "inline1",
+		ChatInstance:    "instance1",
+		Data:            downloadData(t, answer),
+	}})
+
+	deadline := time.Now().Add(e2eWait)
+	for time.Now().Before(deadline) {
+		for _, c := range env.Telegram.Calls() {
+			if c.Method != "sendAudio" && c.Method != "sendDocument" {
+				continue
+			}
+			if got := c.Params.Get("chat_id"); got != "42" {
+				t.Fatalf("%s to chat %s, want the user's chat 42", c.Method, got)
+			}
+			for field, f := range c.Files {
+				if field != "thumb" && field != "thumbnail" && bytes.Contains(f.Data, audio) {
+					return
+				}
+			}
+			t.Fatalf("%s carries no file with the downloaded audio", c.Method)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("downloaded track not sent")
+}
+
+// TestSlowDownload checks that a transfer outlasting the per-request HTTP
+// timeout is bounded by the download timeout only.
+func TestSlowDownload(t *testing.T) {
+	audio := bytes.Repeat([]byte("slow mp3 frame "), 512)
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "1002", Title: "Slow", Artists: []string{"Band"}, DurationMs: 180000, Audio: audio, Trickle: time.Second,
+	})
+	defer env.Close()
+	svc := music.NewService(env.YandexClient(zap.NewNop(), yandex.WithTimeout(200*time.Millisecond)),
+		music.WithTempDir(t.TempDir()),
+		music.WithDownloadTimeout(10*time.Second),
+	)
+
+	dl, err := svc.DownloadTrack(context.Background(), "1002")
+	if err != nil {
+		t.Fatalf("slow download: %v", err)
+	}
+	defer dl.Close()
+	data, err := os.ReadFile(dl.Path)
+	if err != nil {
+		t.Fatalf("read download: %v", err)
+	}
+	if !bytes.Contains(data, audio) {
+		t.Fatalf("downloaded %d bytes, not the %d served", len(data), len(audio))
+	}
+}
+
+// TestSlowChunkedDownload checks that ranges of a parallel download are,
+// like a single transfer, not cut off by the per-request HTTP timeout.
+func TestSlowChunkedDownload(t *testing.T) {
+	audio := bytes.Repeat([]byte("slow flac frame "), 2048)
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "1003", Title: "Long", Artists: []string{"Band"}, DurationMs: 600000, Audio: audio, Trickle: 2 * time.Second,
+	})
+	defer env.Close()
+	client := env.YandexClient(zap.NewNop(),
+		yandex.WithTimeout(200*time.Millisecond),
+		yandex.WithParallelDownload(4, 1),
+	)
+	svc := music.NewService(client, music.WithTempDir(t.TempDir()), music.WithDownloadTimeout(10*time.Second))
+
+	dl, err := svc.DownloadTrack(context.Background(), "1003")
+	if err != nil {
+		t.Fatalf("slow chunked download: %v", err)
+	}
+	defer dl.Close()
+	data, err := os.ReadFile(dl.Path)
+	if err != nil {
+		t.Fatalf("read download: %v", err)
+	}
+	if !bytes.Contains(data, audio) {
+		t.Fatalf("downloaded %d bytes, not the %d served", len(data), len(audio))
+	}
+	// The size probe and four ranges, with no fallback to a single GET.
+	if hits := env.Yandex.Hits("/audio/"); hits != 5 {
+		t.Errorf("audio requested %d times, want 5", hits)
+	}
+}
+
+// TestCommandMenus checks that bot admin commands are registered in the
+// admins' own chats only.
+func TestCommandMenus(t *testing.T) {
+	env := testfixtures.NewEnv()
+	t.Cleanup(env.Close)
+	const admin = 7
+	startBot(t, env, telegram.WithSettings(settings.New(settings.Runtime{Admins: []int64{admin}})))
+
+	// Two languages for each of the three shared scopes and the admin chat.
+	var calls []testfixtures.Call
+	deadline := time.Now().Add(e2eWait)
+	for len(calls) < 8 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+		calls = calls[:0]
+		for _, c := range env.Telegram.Calls() {
+			if c.Method == "setMyCommands" {
+				calls = append(calls, c)
+			}
+		}
+	}
+	if len(calls) != 8 {
+		t.Fatalf("%d command menus registered, want 8", len(calls))
+	}
+	for _, c := range calls {
+		var scope struct {
+			Type   string `json:"type"`
+			ChatID int64  `json:"chat_id"`
+		}
+		var commands []tgbotapi.BotCommand
+		if err := json.Unmarshal([]byte(c.Params.Get("scope")), &scope); err != nil {
+			t.Fatalf("decode scope: %v", err)
+		}
+		if err := json.Unmarshal([]byte(c.Params.Get("commands")), &commands); err != nil {
+			t.Fatalf("decode commands: %v", err)
+		}
+		listed := func(name string) bool {
+			for _, cmd := range commands {
+				if cmd.Command == name {
+					return true
+				}
+			}
+			return false
+		}
+		isAdminChat := scope.Type == "chat" && scope.ChatID == admin
+		for _, name := range []string{"broadcast", "nowplaying", "myplaylists", "vibe"} {
+			if listed(name) != isAdminChat {
+				t.Errorf("/%s listed %v in scope %+v", name, listed(name), scope)
+			}
+		}
+	}
+}
+
+// TestWelcomeBack checks that only a user who had blocked the bot is
+// welcomed back when they start it again.
+func TestWelcomeBack(t *testing.T) {
+	env := testfixtures.NewEnv()
+	t.Cleanup(env.Close)
+	store := startBot(t, env)
+
+	user := &tgbotapi.User{ID: e2eUser, FirstName: "Test"}
+	chat := &tgbotapi.Chat{ID: e2eUser, Type: "private"}
+	status := func(old, new string) {
+		env.Telegram.PushUpdate(tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+			Chat: *chat, From: *user,
+			OldChatMember: tgbotapi.ChatMember{User: user, Status: old},
+			NewChatMember: tgbotapi.ChatMember{User: user, Status: new},
+		}})
+	}
+	welcomes := func() int {
+		n := 0
+		for _, c := range env.Telegram.Calls() {
+			if c.Method == "sendMessage" && strings.Contains(c.Params.Get("text"), "С возвращением") {
+				n++
+			}
+		}
+		return n
+	}
+
+	waitFor := func(what string, cond func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(e2eWait)
+		for !cond() {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	// Updates are handled concurrently: each waits for the one before.
+	status("kicked", "member") // the first start
+	time.Sleep(200 * time.Millisecond)
+	if n := welcomes(); n != 0 {
+		t.Fatalf("first start welcomed back %d times", n)
+	}
+	status("member", "kicked")
+	waitFor("the block", func() bool { return !store.ChatActive(testfixtures.FakeBotUsername, e2eUser) })
+	if !store.ChatActive("other_bot", e2eUser) {
+		t.Error("blocking one bot deactivated the chat for another")
+	}
+	status("kicked", "member")
+	waitFor("the welcome", func() bool { return welcomes() == 1 })
+	if !store.ChatActive(testfixtures.FakeBotUsername, e2eUser) {
+		t.Error("chat still inactive after the unblock")
+	}
+}
+
+// privateMessage is a message from the test user in their private chat;
+// a leading slash command is marked as one.
+func privateMessage(text string) *tgbotapi.Message {
+	m := &tgbotapi.Message{
+		From: &tgbotapi.User{ID: e2eUser, FirstName: "Test"},
+		Chat: &tgbotapi.Chat{ID: e2eUser, Type: "private"},
+		Date: int(time.Now().Unix()),
+		Text: text,
+	}
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		m.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Length: len(command)}}
+	}
+	return m
+}
+
+// waitForCall waits for the first call after the first since ones that
+// match accepts, and returns it with its index. The fake numbers the
+// messages it sends by call, so a sent message's id is the index plus one.
+func waitForCall(t *testing.T, env *testfixtures.Env, since int, what string, match func(testfixtures.Call) bool) (int, testfixtures.Call) {
+	t.Helper()
+	deadline := time.Now().Add(e2eWait)
+	for time.Now().Before(deadline) {
+		calls := env.Telegram.Calls()
+		for i := since; i < len(calls); i++ {
+			if match(calls[i]) {
+				return i, calls[i]
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+	return 0, testfixtures.Call{}
+}
+
+func isMethod(method string) func(testfixtures.Call) bool {
+	return func(c testfixtures.Call) bool { return c.Method == method }
+}
+
+// TestLinkPreviews checks that a track link is answered with the track page
+// previewed, and that switching previews off in /settings takes effect on
+// the next message.
+func TestLinkPreviews(t *testing.T) {
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "1004", Title: "Song", Artists: []string{"Band"}, DurationMs: 180000,
+	})
+	t.Cleanup(env.Close)
+	store := startBot(t, env)
+
+	start := func(payload string) testfixtures.Call {
+		t.Helper()
+		sent := len(env.Telegram.Calls())
+		env.Telegram.PushUpdate(tgbotapi.Update{Message: privateMessage("/start " + payload)})
+		_, c := waitForCall(t, env, sent, "the answer to /start "+payload, isMethod("sendMessage"))
+		return c
+	}
+
+	c := start("t1004")
+	if c.Params.Get("parse_mode") != "HTML" || !strings.Contains(c.Params.Get("text"), `<a href="`) {
+		t.Errorf("track link answered with %q, want the linked track", c.Params.Get("text"))
+	}
+	if c.Params.Get("disable_web_page_preview") != "" {
+		t.Error("track page preview disabled by default")
+	}
+
+	if _, err := store.UpdatePrefs(e2eUser, func(p *storage.UserPrefs) { p.HidePreviews = true }); err != nil {
+		t.Fatal(err)
+	}
+	if c := start("t1004"); c.Params.Get("disable_web_page_preview") != "true" {
+		t.Error("track page previewed after previews were switched off")
+	}
+	if c := start(""); c.Params.Get("disable_web_page_preview") != "true" {
+		t.Error("reply previews links after previews were switched off")
+	}
+}
+
+// TestInlineTypeahead checks that a query of a letter or two is answered
+// with completions, unless it is a whole name itself.
+func TestInlineTypeahead(t *testing.T) {
+	env := testfixtures.NewEnv(
+		testfixtures.FakeTrack{ID: "1005", Title: "One", Artists: []string{"U2"}, DurationMs: 180000},
+		testfixtures.FakeTrack{ID: "1006", Title: "Under Pressure", Artists: []string{"Queen"}, DurationMs: 180000},
+	)
+	t.Cleanup(env.Close)
+	startBot(t, env)
+
+	user := &tgbotapi.User{ID: e2eUser, FirstName: "Test"}
+	answer := func(id, query string) []string {
+		t.Helper()
+		sent := len(env.Telegram.Calls())
+		env.Telegram.PushUpdate(tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{ID: id, From: user, Query: query}})
+		_, c := waitForCall(t, env, sent, "the answer to "+query, func(c testfixtures.Call) bool {
+			return c.Method == "answerInlineQuery" && c.Params.Get("inline_query_id") == id
+		})
+		var results []struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(c.Params.Get("results")), &results); err != nil {
+			t.Fatalf("decode inline results: %v", err)
+		}
+		types := make([]string, 0, len(results))
+		for _, r := range results {
+			types = append(types, r.Type)
+		}
+		return types
+	}
+
+	if types := answer("q1", "u"); len(types) != 2 || types[0] != "article" || types[1] != "article" {
+		t.Errorf("%q answered with %v, want two completions", "u", types)
+	}
+	if types := answer("q2", "U2"); len(types) != 1 || types[0] == "article" {
+		t.Errorf("%q answered with %v, want the track", "U2", types)
+	}
+}
+
+// TestSearchPicks checks that a number sent after a search downloads the
+// track it numbers, that a number outside the list is searched for, and
+// that a reply to an older list is turned down.
+func TestSearchPicks(t *testing.T) {
+	env := testfixtures.NewEnv(
+		testfixtures.FakeTrack{ID: "1007", Title: "First Song", Artists: []string{"Band"}, DurationMs: 180000},
+		testfixtures.FakeTrack{ID: "1008", Title: "Second Song", Artists: []string{"Band"}, DurationMs: 180000},
+	)
+	t.Cleanup(env.Close)
+	startBot(t, env)
+
+	// send pushes a message and waits for the bot's first call after it.
+	send := func(m *tgbotapi.Message, what string, match func(testfixtures.Call) bool) (int, testfixtures.Call) {
+		t.Helper()
+		sent := len(env.Telegram.Calls())
+		env.Telegram.PushUpdate(tgbotapi.Update{Message: m})
+		return waitForCall(t, env, sent, what, match)
+	}
+
+	i, list := send(privateMessage("Band Song"), "the results", isMethod("sendMessage"))
+	if !strings.Contains(list.Params.Get("text"), "Second Song") {
+		t.Fatalf("results %q lack the second track", list.Params.Get("text"))
+	}
+	oldList := &tgbotapi.Message{MessageID: i + 1, Chat: &tgbotapi.Chat{ID: e2eUser, Type: "private"}, Text: list.Params.Get("text")}
+
+	_, audio := send(privateMessage("2"), "the picked track", isMethod("sendAudio"))
+	if title := audio.Params.Get("title"); !strings.Contains(title, "Second Song") {
+		t.Errorf("picked %q, want the second track", title)
+	}
+
+	// Numbers past the list may be titles, such as "1979".
+	_, c := send(privateMessage("#7"), "the search for #7", isMethod("sendMessage"))
+	if !strings.Contains(c.Params.Get("text"), "Ничего не нашлось") {
+		t.Errorf("#7 answered with %q, want a search", c.Params.Get("text"))
+	}
+
+	send(privateMessage("Band"), "the second results", isMethod("sendMessage"))
+	stale := privateMessage("1")
+	stale.ReplyToMessage = oldList
+	_, c = send(stale, "the answer to the stale pick", isMethod("sendMessage"))
+	if !strings.Contains(c.Params.Get("text"), "устарел") {
+		t.Errorf("reply to an old list answered with %q", c.Params.Get("text"))
+	}
+}
+
+// TestAdminSet checks that an admin's /set takes effect in the bot's
+// settings and that bad keys and values are turned down.
+func TestAdminSet(t *testing.T) {
+	env := testfixtures.NewEnv()
+	t.Cleanup(env.Close)
+	runtime := settings.New(settings.Runtime{Admins: []int64{e2eUser}, DailyLimit: 5})
+	startBot(t, env, telegram.WithSettings(runtime))
+
+	send := func(text string) string {
+		t.Helper()
+		sent := len(env.Telegram.Calls())
+		env.Telegram.PushUpdate(tgbotapi.Update{Message: privateMessage(text)})
+		_, c := waitForCall(t, env, sent, "the answer to "+text, isMethod("sendMessage"))
+		return c.Params.Get("text")
+	}
+
+	if text := send("/set"); !strings.Contains(text, "daily_download_limit = 5") {
+		t.Errorf("/set listed %q, want the current limit", text)
+	}
+	if text := send("/set daily_download_limit 1"); !strings.Contains(text, "daily_download_limit = 1") {
+		t.Errorf("/set answered %q", text)
+	}
+	if got := runtime.Load().DailyLimit; got != 1 {
+		t.Errorf("daily limit %d after /set, want 1", got)
+	}
+	if text := send("/set daily_download_limit -1"); !strings.Contains(text, "не принято") {
+		t.Errorf("negative limit answered with %q", text)
+	}
+	if text := send("/set admins 1"); !strings.Contains(text, "Нет такого параметра") {
+		t.Errorf("unknown key answered with %q", text)
+	}
+	if got := runtime.Load(); got.DailyLimit != 1 || !got.IsAdmin(e2eUser) {
+		t.Errorf("settings %+v after rejected overrides", got)
+	}
+}
+
+// TestInlineProbeDeadline checks that the audio URLs of an answer are
+// probed at once under one deadline: storage that stalls every probe
+// delays the answer by one probe timeout, not one per result, and the
+// unprobed URLs are still offered.
+func TestInlineProbeDeadline(t *testing.T) {
+	var tracks []testfixtures.FakeTrack
+	for i := 0; i < 5; i++ {
+		tracks = append(tracks, testfixtures.FakeTrack{
+			ID: strconv.Itoa(1010 + i), Title: "Song " + strconv.Itoa(i), Artists: []string{"Band"}, DurationMs: 180000,
+			Delay: 5 * time.Second,
+		})
+	}
+	env := testfixtures.NewEnv(tracks...)
+	t.Cleanup(env.Close)
+	startBot(t, env, telegram.WithSettings(settings.New(settings.Runtime{InlineProbe: true})))
+
+	start := time.Now()
+	env.Telegram.PushUpdate(tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{
+		ID: "q1", From: &tgbotapi.User{ID: e2eUser, FirstName: "Test"}, Query: "Band Song",
+	}})
+	answer, ok := env.Telegram.WaitForCall("answerInlineQuery", e2eWait)
+	if !ok {
+		t.Fatal("inline query not answered")
+	}
+	if d := time.Since(start); d > 4*time.Second {
+		t.Errorf("answered after %v, want about one probe timeout", d)
+	}
+	var results []inlineResult
+	if err := json.Unmarshal([]byte(answer.Params.Get("results")), &results); err != nil {
+		t.Fatalf("decode results: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("%d results, want all 5", len(results))
+	}
+	for i, r := range results {
+		if want := strconv.Itoa(1010 + i); r.ID != want {
+			t.Errorf("result %d is %s, want %s in search order", i, r.ID, want)
+		}
+	}
+}
+
+// TestInlineCardChosen checks fast mode from the card to the audio: the
+// answer offers a card, the card's message gets the audio from the
+// track's URL once Telegram reports it sent, and its download button then
+// delivers the file. A card whose audio cannot be found says so.
+func TestInlineCardChosen(t *testing.T) {
+	audio := bytes.Repeat([]byte("card mp3 frame "), 512)
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "1020", Title: "Card Song", Artists: []string{"Band"}, DurationMs: 180000, Audio: audio,
+	})
+	t.Cleanup(env.Close)
+	startBot(t, env, telegram.WithSettings(settings.New(settings.Runtime{InlineFast: true})))
+	user := &tgbotapi.User{ID: e2eUser, FirstName: "Test"}
+
+	env.Telegram.PushUpdate(tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{ID: "q1", From: user, Query: "Card Song"}})
+	answer, ok := env.Telegram.WaitForCall("answerInlineQuery", e2eWait)
+	if !ok {
+		t.Fatal("inline query not answered")
+	}
+	var results []inlineResult
+	if err := json.Unmarshal([]byte(answer.Params.Get("results")), &results); err != nil {
+		t.Fatalf("decode results: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "card:1020" {
+		t.Fatalf("results %+v, want the card of 1020", results)
+	}
+
+	sent := len(env.Telegram.Calls())
+	env.Telegram.PushUpdate(tgbotapi.Update{ChosenInlineResult: &tgbotapi.ChosenInlineResult{
+		ResultID: "card:1020", From: user, Query: "Card Song", InlineMessageID: "inline1",
+	}})
+	_, edit := waitForCall(t, env, sent, "the card's audio", isMethod("editMessageMedia"))
+	if got := edit.Params.Get("inline_message_id"); got != "inline1" {
+		t.Errorf("audio put into message %q, want inline1", got)
+	}
+	var media struct {
+		Type  string `json:"type"`
+		Media string `json:"media"`
+		Title string `json:"title"`
+	}
+	if err := json.Unmarshal([]byte(edit.Params.Get("media")), &media); err != nil {
+		t.Fatalf("decode media: %v", err)
+	}
+	if media.Type != "audio" || !strings.HasPrefix(media.Media, env.Yandex.URL()) || media.Title != "Card Song" {
+		t.Errorf("card filled with %+v, want the audio URL of Card Song", media)
+	}
+	// The audio keeps the card's buttons, or the message could not be edited again.
+	if !strings.Contains(edit.Params.Get("reply_markup"), "callback_data") {
+		t.Errorf("filled card keyboard %s lacks the download button", edit.Params.Get("reply_markup"))
+	}
+
+	sent = len(env.Telegram.Calls())
+	env.Telegram.PushUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID: "cb1", From: user, InlineMessageID: "inline1", ChatInstance: "instance1", Data: downloadData(t, answer),
+	}})
+	_, delivered := waitForCall(t, env, sent, "the downloaded track", func(c testfixtures.Call) bool {
+		return c.Method == "sendAudio" || c.Method == "sendDocument"
+	})
+	found := false
+	for field, f := range delivered.Files {
+		found = found || (field != "thumb" && field != "thumbnail" && bytes.Contains(f.Data, audio))
+	}
+	if !found {
+		t.Errorf("%s carries no file with the downloaded audio", delivered.Method)
+	}
+
+	sent = len(env.Telegram.Calls())
+	env.Telegram.PushUpdate(tgbotapi.Update{ChosenInlineResult: &tgbotapi.ChosenInlineResult{
+		ResultID: "card:9999", From: user, Query: "Card Song", InlineMessageID: "inline2",
+	}})
+	_, failed := waitForCall(t, env, sent, "the failed card", isMethod("editMessageText"))
+	if failed.Params.Get("inline_message_id") != "inline2" || !strings.Contains(failed.Params.Get("text"), "Не удалось") {
+		t.Errorf("missing track's card edited to %q in %q", failed.Params.Get("text"), failed.Params.Get("inline_message_id"))
+	}
+}
+
+// TestInlineRedelivery checks that a download requested from a message sent
+// inline, whose upload failed, still gets into that message once it is
+// redelivered.
+func TestInlineRedelivery(t *testing.T) {
+	env := testfixtures.NewEnv(testfixtures.FakeTrack{
+		ID: "1030", Title: "Late Song", Artists: []string{"Band"}, DurationMs: 180000,
+	})
+	t.Cleanup(env.Close)
+	store := startBot(t, env, telegram.WithSettings(settings.New(settings.Runtime{Admins: []int64{e2eUser}, InlineFast: true})))
+	user := &tgbotapi.User{ID: e2eUser, FirstName: "Test"}
+
+	env.Telegram.PushUpdate(tgbotapi.Update{InlineQuery: &tgbotapi.InlineQuery{ID: "q1", From: user, Query: "Late Song"}})
+	answer, ok := env.Telegram.WaitForCall("answerInlineQuery", e2eWait)
+	if !ok {
+		t.Fatal("inline query not answered")
+	}
+	env.Telegram.FailNext("sendAudio", 1, http.StatusInternalServerError)
+	sent := len(env.Telegram.Calls())
+	env.Telegram.PushUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID: "cb1", From: user, InlineMessageID: "inline1", ChatInstance: "instance1", Data: downloadData(t, answer),
+	}})
+	waitForCall(t, env, sent, "the deferred delivery notice", func(c testfixtures.Call) bool {
+		return c.Method == "answerCallbackQuery" && strings.Contains(c.Params.Get("text"), "повторю")
+	})
+	letters := store.DeadLetters(testfixtures.FakeBotUsername)
+	if len(letters) != 1 || letters[0].InlineID != "inline1" {
+		t.Fatalf("dead letters %+v, want one for inline1", letters)
+	}
+
+	sent = len(env.Telegram.Calls())
+	env.Telegram.PushUpdate(tgbotapi.Update{Message: privateMessage("/redeliver all")})
+	_, edit := waitForCall(t, env, sent, "the file put into the inline message", isMethod("editMessageMedia"))
+	if got := edit.Params.Get("inline_message_id"); got != "inline1" {
+		t.Errorf("redelivered file put into %q, want inline1", got)
+	}
+	if !strings.Contains(edit.Params.Get("reply_markup"), "callback_data") {
+		t.Errorf("inline message keyboard %s lost its download button", edit.Params.Get("reply_markup"))
+	}
+}
@@ -0,0 +1,72 @@
+// Package testfixtures provides fake Yandex Music and Telegram Bot API servers
+// for driving the bot end to end without network access.
+package testfixtures
+
+import (
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/cover"
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/storage"
+	"ym-bot/internal/transport/telegram"
+)
+
+const (
+	// FakeToken is the bot token used against FakeTelegram.
+	FakeToken = "123:fake"
+	// FakeYandexToken is the OAuth token presented to FakeYandex.
+	FakeYandexToken = "fake-oauth"
+)
+
+// Env bundles both fakes and builds real components wired to them.
+type Env struct {
+	Yandex   *FakeYandex
+	Telegram *FakeTelegram
+}
+
+// NewEnv starts both fake servers with the given catalog.
+func NewEnv(tracks ...FakeTrack) *Env {
+	return &Env{
+		Yandex:   NewFakeYandex(tracks...),
+		Telegram: NewFakeTelegram(),
+	}
+}
+
+// Close stops both servers.
+func (e *Env) Close() {
+	e.Yandex.Close()
+	e.Telegram.Close()
+}
+
+// YandexClient returns a real API client pointed at FakeYandex; opts are
+// applied after the base URL.
+func (e *Env) YandexClient(logger *zap.Logger, opts ...yandex.Option) *yandex.APIClient {
+	return yandex.NewClient(e.Yandex.Client(), FakeYandexToken, logger,
+		append([]yandex.Option{yandex.WithBaseURL(e.Yandex.URL())}, opts...)...)
+}
+
+// Bot returns a fully wired bot using an in-memory store; opts are applied
+// after the fakes' wiring, e.g. to turn on settings.
+func (e *Env) Bot(logger *zap.Logger, opts ...telegram.Option) (*telegram.Bot, *storage.Store, error) {
+	api, err := e.Telegram.BotAPI(FakeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := storage.Open("")
+	if err != nil {
+		return nil, nil, err
+	}
+	svc := music.NewService(e.YandexClient(logger), music.WithLogger(logger), music.WithPicks(store))
+	bot, err := telegram.NewBot(FakeToken, svc, append([]telegram.Option{
+		telegram.WithAPI(api),
+		telegram.WithAPIEndpoint(e.Telegram.Server.URL),
+		telegram.WithStore(store),
+		telegram.WithCovers(cover.NewService(e.Yandex.Client(), cover.WithLogger(logger))),
+		telegram.WithLogger(logger),
+	}, opts...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bot, store, nil
+}
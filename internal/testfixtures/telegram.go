@@ -0,0 +1,293 @@
+package testfixtures
+
+import (
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// FakeBotUsername is returned by the fake getMe.
+const FakeBotUsername = "fake_ym_bot"
+
+// PollID is the id of the poll the fake sends in message msgID.
+func PollID(msgID int) string { return "poll" + strconv.Itoa(msgID) }
+
+// AudioFileID is the file id FakeTelegram gives the audio of message msgID.
+func AudioFileID(msgID int) string { return "audio" + strconv.Itoa(msgID) }
+
+// Call is a recorded Bot API request.
+type Call struct {
+	Method string
+	Params url.Values
+	Files  map[string]UploadedFile
+}
+
+// UploadedFile is a multipart file received by the fake server.
+type UploadedFile struct {
+	Name string
+	Size int
+	Data []byte
+}
+
+// FakeTelegram is an httptest-based Bot API server. Tests push updates that
+// the bot receives via getUpdates and inspect the calls it makes in response.
+type FakeTelegram struct {
+	Server *httptest.Server
+
+	mu       sync.Mutex
+	updates  []tgbotapi.Update
+	nextID   int
+	calls    []Call
+	notify   chan struct{}
+	message  int
+	files    map[string][]byte
+	failures map[string]failure
+	admins   map[[2]int64]bool // chat id, user id
+}
+
+// failure makes the next count calls to a method return an API error.
+type failure struct {
+	count int
+	code  int
+}
+
+// NewFakeTelegram starts a fake Bot API server.
+func NewFakeTelegram() *FakeTelegram {
+	f := &FakeTelegram{nextID: 1, notify: make(chan struct{}, 1), files: make(map[string][]byte), failures: make(map[string]failure), admins: make(map[[2]int64]bool)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+// Endpoint returns the format string for tgbotapi.NewBotAPIWithAPIEndpoint.
+func (f *FakeTelegram) Endpoint() string { return f.Server.URL + "/bot%s/%s" }
+
+// BotAPI builds a client talking to the fake server.
+func (f *FakeTelegram) BotAPI(token string) (*tgbotapi.BotAPI, error) {
+	return tgbotapi.NewBotAPIWithAPIEndpoint(token, f.Endpoint())
+}
+
+// AddFile makes fileID downloadable through getFile, as if a user uploaded it.
+// Bots must be built with telegram.WithAPIEndpoint(f.Server.URL) to fetch it.
+func (f *FakeTelegram) AddFile(fileID string, data []byte) {
+	f.mu.Lock()
+	f.files[fileID] = data
+	f.mu.Unlock()
+}
+
+// FailNext makes the next count calls to method fail with the given Bot API
+// error code (e.g. 500 for a transient error, 403 for a blocked bot). Failed
+// calls are still recorded.
+func (f *FakeTelegram) FailNext(method string, count, code int) {
+	f.mu.Lock()
+	f.failures[method] = failure{count: count, code: code}
+	f.mu.Unlock()
+}
+
+// AddChatAdmin makes getChatMember report userID as an administrator of chatID.
+func (f *FakeTelegram) AddChatAdmin(chatID, userID int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.admins[[2]int64{chatID, userID}] = true
+}
+
+// Close shuts the server down.
+func (f *FakeTelegram) Close() { f.Server.Close() }
+
+// PushUpdate queues an update for delivery; UpdateID is assigned automatically.
+func (f *FakeTelegram) PushUpdate(u tgbotapi.Update) {
+	f.mu.Lock()
+	u.UpdateID = f.nextID
+	f.nextID++
+	f.updates = append(f.updates, u)
+	f.mu.Unlock()
+
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Calls returns a copy of all recorded calls.
+func (f *FakeTelegram) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// WaitForCall polls until a call to method is recorded or timeout elapses.
+func (f *FakeTelegram) WaitForCall(method string, timeout time.Duration) (Call, bool) {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		for _, c := range f.Calls() {
+			if c.Method == method {
+				return c, true
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return Call{}, false
+}
+
+func (f *FakeTelegram) handle(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/file/") {
+		f.serveFile(w, r)
+		return
+	}
+
+	// Path: /bot<token>/<method>
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "bot") {
+		http.NotFound(w, r)
+		return
+	}
+	method := parts[1]
+
+	call := Call{Method: method, Files: make(map[string]UploadedFile)}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		if err := r.ParseMultipartForm(64 << 20); err == nil {
+			call.Params = r.MultipartForm.Value
+			for field, headers := range r.MultipartForm.File {
+				for _, h := range headers {
+					call.Files[field] = UploadedFile{Name: h.Filename, Size: int(h.Size), Data: readUpload(h)}
+				}
+			}
+		}
+	} else {
+		_ = r.ParseForm()
+		call.Params = r.PostForm
+	}
+
+	switch method {
+	case "getMe":
+		respond(w, tgbotapi.User{ID: 1, IsBot: true, UserName: FakeBotUsername, FirstName: "Fake"})
+		return
+	case "getUpdates":
+		respond(w, f.pendingUpdates(call.Params))
+		return
+	case "getChatMember":
+		chatID, _ := strconv.ParseInt(call.Params.Get("chat_id"), 10, 64)
+		userID, _ := strconv.ParseInt(call.Params.Get("user_id"), 10, 64)
+		status := "member"
+		f.mu.Lock()
+		if f.admins[[2]int64{chatID, userID}] {
+			status = "administrator"
+		}
+		f.mu.Unlock()
+		respond(w, tgbotapi.ChatMember{User: &tgbotapi.User{ID: userID}, Status: status})
+		return
+	case "getFile":
+		id := call.Params.Get("file_id")
+		respond(w, tgbotapi.File{FileID: id, FilePath: "documents/" + id})
+		return
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, call)
+	f.message++
+	msgID := f.message
+	failCode := 0
+	if fail := f.failures[method]; fail.count > 0 {
+		fail.count--
+		f.failures[method] = fail
+		failCode = fail.code
+	}
+	f.mu.Unlock()
+
+	if failCode != 0 {
+		respondError(w, failCode)
+		return
+	}
+
+	if strings.HasPrefix(method, "send") {
+		chatID, _ := strconv.ParseInt(call.Params.Get("chat_id"), 10, 64)
+		msg := tgbotapi.Message{
+			MessageID: msgID,
+			Date:      int(time.Now().Unix()),
+			Chat:      &tgbotapi.Chat{ID: chatID, Type: "private"},
+		}
+		if method == "sendPoll" {
+			// Poll ids follow message ids, so tests can answer with PollID.
+			msg.Poll = &tgbotapi.Poll{ID: PollID(msgID), Question: call.Params.Get("question")}
+		}
+		if method == "sendAudio" {
+			msg.Audio = &tgbotapi.Audio{FileID: AudioFileID(msgID)}
+		}
+		respond(w, msg)
+		return
+	}
+	respond(w, true)
+}
+
+// readUpload returns the content of an uploaded file, nil if unreadable.
+func readUpload(h *multipart.FileHeader) []byte {
+	f, err := h.Open()
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(f)
+	return data
+}
+
+// serveFile answers /file/bot<token>/documents/<fileID>.
+func (f *FakeTelegram) serveFile(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	f.mu.Lock()
+	data, ok := f.files[id]
+	f.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = w.Write(data)
+}
+
+// pendingUpdates returns updates at or after offset, waiting briefly for new ones.
+func (f *FakeTelegram) pendingUpdates(params url.Values) []tgbotapi.Update {
+	offset, _ := strconv.Atoi(params.Get("offset"))
+
+	collect := func() []tgbotapi.Update {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		var out []tgbotapi.Update
+		for _, u := range f.updates {
+			if u.UpdateID >= offset {
+				out = append(out, u)
+			}
+		}
+		return out
+	}
+
+	if out := collect(); len(out) > 0 {
+		return out
+	}
+	select {
+	case <-f.notify:
+	case <-time.After(200 * time.Millisecond):
+	}
+	return collect()
+}
+
+func respond(w http.ResponseWriter, result any) {
+	raw, _ := json.Marshal(result)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: raw})
+}
+
+func respondError(w http.ResponseWriter, code int) {
+	resp := tgbotapi.APIResponse{Ok: false, ErrorCode: code, Description: http.StatusText(code)}
+	if code == http.StatusTooManyRequests {
+		resp.Parameters = &tgbotapi.ResponseParameters{RetryAfter: 1}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
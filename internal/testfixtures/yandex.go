@@ -0,0 +1,973 @@
+package testfixtures
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// Download-info resolution variants served by FakeYandex.
+const (
+	VariantJSON     = "json"
+	VariantXML      = "xml"
+	VariantRedirect = "redirect"
+)
+
+// fakeSignature is the "s" field of XML download info.
+const fakeSignature = "fakesig"
+
+// fakeUID is the account id behind any token presented to FakeYandex.
+const fakeUID = "1000"
+
+// editorialUID owns the editorial playlists of FakeYandex.
+const editorialUID = "2000"
+
+// FakeTrack is a catalog entry served by FakeYandex.
+type FakeTrack struct {
+	ID string // numeric, as in the real API
+	// RealID is reported as the track's realId but, as for some migrated
+	// tracks, unknown to download-info, which answers 404 for it.
+	RealID     string
+	Title      string
+	Artists    []string
+	Album      string
+	Genre      string // album genre id, see FakeGenres
+	Year       int    // album year; like the album position, only the album endpoint serves it
+	DurationMs int
+	Codec      string        // defaults to "mp3"
+	Variant    string        // download-info variant, defaults to VariantJSON
+	Audio      []byte        // defaults to a small fake payload
+	Delay      time.Duration // stalls the audio response, e.g. to exercise queueing and cancellation
+	// Trickle paces sending the audio, ranges included, to a tenth of it per
+	// tenth of this long, e.g. to exercise timeouts on slow transfers.
+	Trickle time.Duration
+	// Availability flags the track as restricted; download-info then answers 403.
+	Availability yandex.Availability
+	// Compilation puts the track on a compilation album.
+	Compilation bool
+	// Explicit flags the track's lyrics as explicit.
+	Explicit bool
+	// Podcast makes the track an episode, and its album a podcast.
+	Podcast bool
+	// Published is the episode's publication time.
+	Published time.Time
+	// Lyrics are the track's words; lines starting with an LRC timing make
+	// them synced lyrics.
+	Lyrics string
+}
+
+// FakeGenres is the genre catalog served by FakeYandex.
+var FakeGenres = []any{
+	map[string]any{"id": "rock", "title": "Рок", "subGenres": []any{
+		map[string]any{"id": "rusrock", "title": "Русский рок"},
+		map[string]any{"id": "metal", "title": "Метал"},
+	}},
+	map[string]any{"id": "pop", "title": "Поп"},
+	map[string]any{"id": "service", "title": "Служебный", "showInMenu": false},
+}
+
+// FakeYandex is an httptest-based stand-in for the Yandex Music API. It runs
+// over TLS because XML download-info URLs are always built with https.
+type FakeYandex struct {
+	Server *httptest.Server
+
+	mu          sync.Mutex
+	tracks      []FakeTrack
+	corrections map[string]string
+	likes       []string
+	hits        map[string]int
+
+	queue        []string // track ids of the account's play queue
+	queueCurrent int
+	playlists    []*fakePlaylist
+	editorial    []*fakePlaylist
+	waveBatches  int
+	waveFeedback []WaveEvent
+}
+
+// WaveEvent is a feedback event reported to the fake's personal station.
+type WaveEvent struct {
+	Type    string
+	BatchID string
+	TrackID string // "id:albumId", empty for radioStarted
+	Played  float64
+}
+
+// waveBatchLen is how many tracks each batch of the personal station holds.
+const waveBatchLen = 2
+
+type fakePlaylist struct {
+	Kind     int
+	Title    string
+	Revision int
+	Tracks   []string
+	Owner    string // uid, the account's when empty
+	Landing  bool
+	Tags     []string
+}
+
+// NewFakeYandex starts a fake API serving the given catalog.
+func NewFakeYandex(tracks ...FakeTrack) *FakeYandex {
+	f := &FakeYandex{hits: make(map[string]int), corrections: make(map[string]string)}
+	for _, t := range tracks {
+		f.Add(t)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", f.handleSearch)
+	mux.HandleFunc("/search/suggest", f.handleSuggest)
+	mux.HandleFunc("/tracks", f.handleTrackBatch)
+	mux.HandleFunc("/tracks/", f.handleTracks)
+	mux.HandleFunc("/download-info/", f.handleDownloadInfo)
+	mux.HandleFunc("/audio/", f.handleAudio)
+	mux.HandleFunc("/get-mp3/", f.handleAudio)
+	mux.HandleFunc("/account/status", f.handleAccount)
+	mux.HandleFunc("/users/", f.handleLikes)
+	mux.HandleFunc("/queues", f.handleQueues)
+	mux.HandleFunc("/genres", f.handleGenres)
+	mux.HandleFunc("/landing3/chart", f.handleChart)
+	mux.HandleFunc("/landing3", f.handleLanding)
+	mux.HandleFunc("/tags/", f.handleTagPlaylists)
+	mux.HandleFunc("/playlists/list", f.handlePlaylistList)
+	mux.HandleFunc("/albums/", f.handleAlbum)
+	mux.HandleFunc("/covers/", f.handleCover)
+	mux.HandleFunc("/lyrics/", f.handleLyricsFile)
+	mux.HandleFunc("/rotor/station/", f.handleStation)
+	mux.HandleFunc("/queues/", f.handleQueues)
+	f.Server = httptest.NewTLSServer(f.count(mux))
+	return f
+}
+
+// URL returns the base URL to pass to yandex.WithBaseURL.
+func (f *FakeYandex) URL() string { return f.Server.URL }
+
+// Client returns an HTTP client trusting the fake's TLS certificate.
+func (f *FakeYandex) Client() *http.Client { return f.Server.Client() }
+
+// Close shuts the server down.
+func (f *FakeYandex) Close() { f.Server.Close() }
+
+// Add appends a track to the catalog, filling defaults.
+func (f *FakeYandex) Add(t FakeTrack) {
+	if t.Codec == "" {
+		t.Codec = "mp3"
+	}
+	if t.Variant == "" {
+		t.Variant = VariantJSON
+	}
+	if t.Audio == nil {
+		t.Audio = fakeAudio(t.Codec, t.ID)
+	}
+
+	f.mu.Lock()
+	f.tracks = append(f.tracks, t)
+	f.mu.Unlock()
+}
+
+// AddCorrection makes search suggest corrected for query, as Yandex does for typos.
+func (f *FakeYandex) AddCorrection(query, corrected string) {
+	f.mu.Lock()
+	f.corrections[strings.ToLower(query)] = corrected
+	f.mu.Unlock()
+}
+
+// SetQueue makes the account play trackIDs, currently at index current;
+// no ids means nothing is playing.
+func (f *FakeYandex) SetQueue(current int, trackIDs ...string) {
+	f.mu.Lock()
+	f.queue = trackIDs
+	f.queueCurrent = current
+	f.mu.Unlock()
+}
+
+// Likes returns track ids liked through the API, in order.
+func (f *FakeYandex) Likes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.likes...)
+}
+
+// WaveFeedback returns the events reported to the personal station, in order.
+func (f *FakeYandex) WaveFeedback() []WaveEvent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]WaveEvent(nil), f.waveFeedback...)
+}
+
+// Hits reports how many requests hit paths starting with prefix.
+func (f *FakeYandex) Hits(prefix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for path, c := range f.hits {
+		if strings.HasPrefix(path, prefix) {
+			n += c
+		}
+	}
+	return n
+}
+
+func (f *FakeYandex) count(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.hits[r.URL.Path]++
+		f.mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (f *FakeYandex) find(id string) (FakeTrack, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, t := range f.tracks {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return FakeTrack{}, false
+}
+
+func (f *FakeYandex) handleSearch(w http.ResponseWriter, r *http.Request) {
+	text := strings.ToLower(r.URL.Query().Get("text"))
+
+	f.mu.Lock()
+	correction := f.corrections[text]
+	corrected := correction != "" && r.URL.Query().Get("nocorrect") != "true"
+	if corrected {
+		text = strings.ToLower(correction)
+	}
+	var results []map[string]any
+	var matched []FakeTrack
+	for _, t := range f.tracks {
+		if matchesAll(strings.ToLower(t.Title+" "+strings.Join(t.Artists, " ")), text) {
+			results = append(results, f.trackJSON(t))
+			matched = append(matched, t)
+		}
+	}
+	result := map[string]any{
+		"tracks":            map[string]any{"results": results},
+		"misspellResult":    correction,
+		"misspellCorrected": corrected,
+	}
+	if r.URL.Query().Get("type") == "all" {
+		f.addSections(result, text, matched)
+	}
+	f.mu.Unlock()
+
+	writeJSON(w, map[string]any{"result": result})
+}
+
+// addSections fills a type=all search: albums and artists of the matched
+// tracks, account playlists whose title matches, and the first track, album
+// or playlist as the best match. The caller holds f.mu.
+func (f *FakeYandex) addSections(result map[string]any, text string, matched []FakeTrack) {
+	albums, artists := []any{}, []any{}
+	seenAlbums, seenArtists := map[string]bool{}, map[string]bool{}
+	for _, t := range matched {
+		if t.Album != "" && !seenAlbums[t.Album] {
+			seenAlbums[t.Album] = true
+			albums = append(albums, map[string]any{
+				"id": json.Number("1" + t.ID), "title": t.Album, "year": 2020, "artists": artistsJSON(t.Artists),
+			})
+		}
+		for _, a := range t.Artists {
+			if !seenArtists[a] {
+				seenArtists[a] = true
+				artists = append(artists, artistsJSON([]string{a})[0])
+			}
+		}
+	}
+	playlists := []any{}
+	for _, p := range f.playlists {
+		if matchesAll(strings.ToLower(p.Title), text) {
+			playlists = append(playlists, map[string]any{
+				"kind": p.Kind, "title": p.Title, "trackCount": len(p.Tracks),
+				"owner": map[string]any{"login": "fake-user", "name": "Fake User"},
+			})
+		}
+	}
+	result["albums"] = map[string]any{"results": albums}
+	result["artists"] = map[string]any{"results": artists}
+	result["playlists"] = map[string]any{"results": playlists}
+	switch {
+	case len(matched) > 0:
+		result["best"] = map[string]any{"type": "track", "result": f.trackJSON(matched[0])}
+	case len(playlists) > 0:
+		result["best"] = map[string]any{"type": "playlist", "result": playlists[0]}
+	}
+}
+
+// artistsJSON renders artists with ids derived from their names.
+func artistsJSON(names []string) []map[string]any {
+	out := make([]map[string]any, 0, len(names))
+	for _, name := range names {
+		out = append(out, map[string]any{"id": json.Number(strconv.Itoa(int(crc32.ChecksumIEEE([]byte(name))))), "name": name})
+	}
+	return out
+}
+
+// handleTrackBatch serves POST /tracks with a comma-separated track-ids form.
+func (f *FakeYandex) handleTrackBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, `{"error":"method"}`, http.StatusMethodNotAllowed)
+		return
+	}
+	results := []any{}
+	for _, id := range strings.Split(r.PostFormValue("track-ids"), ",") {
+		if t, ok := f.find(id); ok {
+			results = append(results, f.trackJSON(t))
+		}
+	}
+	writeJSON(w, map[string]any{"result": results})
+}
+
+func (f *FakeYandex) handleTracks(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/tracks/")
+	id, sub, _ := strings.Cut(rest, "/")
+
+	t, ok := f.find(id)
+	if !ok {
+		http.Error(w, `{"error":"not-found"}`, http.StatusNotFound)
+		return
+	}
+
+	switch sub {
+	case "":
+		writeJSON(w, map[string]any{"result": []any{f.trackJSON(t)}})
+	case "download-info":
+		switch t.Availability {
+		case yandex.PremiumOnly:
+			http.Error(w, `{"error":{"name":"no-rights","message":"subscription required"}}`, http.StatusForbidden)
+			return
+		case yandex.RegionLocked:
+			http.Error(w, `{"error":{"name":"not-available","message":"not available in your region"}}`, http.StatusForbidden)
+			return
+		}
+		writeJSON(w, map[string]any{"result": []any{map[string]any{
+			"codec":           t.Codec,
+			"bitrateInKbps":   320,
+			"downloadInfoUrl": f.URL() + "/download-info/" + url.PathEscape(t.ID),
+		}}})
+	case "lyrics":
+		f.handleLyrics(w, r, t)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleLyrics serves GET /tracks/{id}/lyrics, checking the request is
+// signed as the real API does.
+func (f *FakeYandex) handleLyrics(w http.ResponseWriter, r *http.Request, t FakeTrack) {
+	q := r.URL.Query()
+	if q.Get("timeStamp") == "" || q.Get("sign") == "" {
+		http.Error(w, `{"error":{"name":"validate","message":"sign required"}}`, http.StatusBadRequest)
+		return
+	}
+	format := q.Get("format")
+	if t.Lyrics == "" || (format == "LRC" && !t.syncedLyrics()) {
+		http.Error(w, `{"error":{"name":"not-found","message":"lyrics not found"}}`, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]any{"result": map[string]any{
+		"downloadUrl": f.URL() + "/lyrics/" + url.PathEscape(t.ID) + "?format=" + url.QueryEscape(format),
+		"writers":     t.Artists,
+	}})
+}
+
+// handleLyricsFile serves the presigned lyrics file: as it is for LRC, with
+// the timings stripped for TEXT.
+func (f *FakeYandex) handleLyricsFile(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "" {
+		http.Error(w, "presigned urls take no credentials", http.StatusBadRequest)
+		return
+	}
+	t, ok := f.find(strings.TrimPrefix(r.URL.Path, "/lyrics/"))
+	if !ok || t.Lyrics == "" {
+		http.NotFound(w, r)
+		return
+	}
+	text := t.Lyrics
+	if r.URL.Query().Get("format") != "LRC" {
+		text = yandex.PlainLyrics(text)
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, text)
+}
+
+func (f *FakeYandex) handleDownloadInfo(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/download-info/")
+	t, ok := f.find(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	audioPath := "/audio/" + url.PathEscape(t.ID) + t.extension()
+	switch t.Variant {
+	case VariantXML:
+		type info struct {
+			XMLName xml.Name `xml:"download-info"`
+			Host    string   `xml:"host"`
+			Path    string   `xml:"path"`
+			TS      string   `xml:"ts"`
+			S       string   `xml:"s"`
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		_ = xml.NewEncoder(w).Encode(info{
+			Host: strings.TrimPrefix(f.URL(), "https://"),
+			Path: audioPath,
+			TS:   "0000",
+			S:    fakeSignature,
+		})
+	case VariantRedirect:
+		http.Redirect(w, r, f.URL()+audioPath, http.StatusFound)
+	default:
+		writeJSON(w, map[string]any{"src": f.URL() + audioPath})
+	}
+}
+
+func (f *FakeYandex) handleAudio(w http.ResponseWriter, r *http.Request) {
+	// Like the real storage, signed XML URLs must carry the right signature.
+	if rest, ok := strings.CutPrefix(r.URL.Path, "/get-mp3/"); ok {
+		parts := strings.SplitN(rest, "/", 3)
+		if len(parts) < 3 || parts[0] != yandex.SignDownloadPath(yandex.DefaultSignSalt, "/"+parts[2], fakeSignature) {
+			http.Error(w, "bad signature", http.StatusForbidden)
+			return
+		}
+	}
+	name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	id := strings.TrimSuffix(name, name[strings.LastIndex(name, "."):])
+	t, ok := f.find(id)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if t.Delay > 0 {
+		select {
+		case <-time.After(t.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "audio/mpeg")
+	if t.Trickle > 0 {
+		w = trickleWriter{ResponseWriter: w, ctx: r.Context(), piece: max(len(t.Audio)/10, 1), pause: t.Trickle / 10}
+	}
+	// ServeContent answers Range requests, as the real storage does.
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(t.Audio))
+}
+
+// trickleWriter sends what is written in pieces, pausing after each; see
+// FakeTrack.Trickle.
+type trickleWriter struct {
+	http.ResponseWriter
+	ctx   context.Context
+	piece int
+	pause time.Duration
+}
+
+func (w trickleWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n, err := w.ResponseWriter.Write(p[:min(w.piece, len(p))])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+		w.ResponseWriter.(http.Flusher).Flush()
+		select {
+		case <-time.After(w.pause):
+		case <-w.ctx.Done():
+			return written, w.ctx.Err()
+		}
+	}
+	return written, nil
+}
+
+func (f *FakeYandex) handleAccount(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{"result": map[string]any{"account": map[string]any{"uid": json.Number(fakeUID)}}})
+}
+
+// AddPlaylist gives the account a playlist holding trackIDs.
+func (f *FakeYandex) AddPlaylist(title string, trackIDs ...string) {
+	f.mu.Lock()
+	f.playlists = append(f.playlists, &fakePlaylist{Kind: 1000 + len(f.playlists), Title: title, Revision: 1, Tracks: trackIDs})
+	f.mu.Unlock()
+}
+
+// AddEditorialPlaylist adds a playlist of another user, promoted on the
+// landing page when landing is set and filed under tags, and returns its kind.
+func (f *FakeYandex) AddEditorialPlaylist(title string, landing bool, tags []string, trackIDs ...string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := &fakePlaylist{Kind: 2000 + len(f.editorial), Title: title, Revision: 1, Tracks: trackIDs,
+		Owner: editorialUID, Landing: landing, Tags: tags}
+	f.editorial = append(f.editorial, p)
+	return p.Kind
+}
+
+// Playlists returns the track ids of each playlist created through the API, by title.
+func (f *FakeYandex) Playlists() map[string][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[string][]string, len(f.playlists))
+	for _, p := range f.playlists {
+		out[p.Title] = append([]string(nil), p.Tracks...)
+	}
+	return out
+}
+
+func (f *FakeYandex) handleLikes(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/users/"+fakeUID+"/playlists/") {
+		f.handlePlaylists(w, r)
+		return
+	}
+	if kind, ok := strings.CutPrefix(r.URL.Path, "/users/"+editorialUID+"/playlists/"); ok && r.Method == http.MethodGet {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, p := range f.editorial {
+			if fmt.Sprint(p.Kind) == kind {
+				writeJSON(w, map[string]any{"result": f.playlistWithTracks(p)})
+				return
+			}
+		}
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == http.MethodGet && r.URL.Path == "/users/"+fakeUID+"/likes/tracks" {
+		likes := f.Likes()
+		tracks := make([]any, 0, len(likes))
+		for i := len(likes) - 1; i >= 0; i-- {
+			tracks = append(tracks, map[string]any{"id": likes[i]})
+		}
+		writeJSON(w, map[string]any{"result": map[string]any{"library": map[string]any{"uid": fakeUID, "tracks": tracks}}})
+		return
+	}
+	if r.Method != http.MethodPost || r.URL.Path != "/users/"+fakeUID+"/likes/tracks/add-multiple" {
+		http.NotFound(w, r)
+		return
+	}
+	_ = r.ParseForm()
+	f.mu.Lock()
+	f.likes = append(f.likes, strings.Split(r.PostForm.Get("track-ids"), ",")...)
+	f.mu.Unlock()
+	writeJSON(w, map[string]any{"result": map[string]any{"revision": len(f.Likes())}})
+}
+
+func (f *FakeYandex) handlePlaylists(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/users/"+fakeUID+"/playlists/")
+	_ = r.ParseForm()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if path == "list" {
+		list := make([]any, 0, len(f.playlists))
+		for _, p := range f.playlists {
+			list = append(list, p.json())
+		}
+		writeJSON(w, map[string]any{"result": list})
+		return
+	}
+	if path == "create" && r.Method == http.MethodPost {
+		p := &fakePlaylist{Kind: 1000 + len(f.playlists), Title: r.PostForm.Get("title"), Revision: 1}
+		f.playlists = append(f.playlists, p)
+		writeJSON(w, map[string]any{"result": p.json()})
+		return
+	}
+
+	kind, op, _ := strings.Cut(path, "/")
+	var p *fakePlaylist
+	for _, candidate := range f.playlists {
+		if fmt.Sprint(candidate.Kind) == kind {
+			p = candidate
+		}
+	}
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch {
+	case op == "" && r.Method == http.MethodGet:
+		writeJSON(w, map[string]any{"result": f.playlistWithTracks(p)})
+	case op == "change-relative" && r.Method == http.MethodPost:
+		if r.PostForm.Get("revision") != fmt.Sprint(p.Revision) {
+			http.Error(w, `{"error":"wrong-revision"}`, http.StatusPreconditionFailed)
+			return
+		}
+		var diff []struct {
+			Tracks []struct {
+				ID string `json:"id"`
+			} `json:"tracks"`
+		}
+		if err := json.Unmarshal([]byte(r.PostForm.Get("diff")), &diff); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, d := range diff {
+			for _, t := range d.Tracks {
+				p.Tracks = append(p.Tracks, t.ID)
+			}
+		}
+		p.Revision++
+		writeJSON(w, map[string]any{"result": p.json()})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (p *fakePlaylist) json() map[string]any {
+	owner := p.Owner
+	if owner == "" {
+		owner = fakeUID
+	}
+	return map[string]any{"kind": p.Kind, "title": p.Title, "trackCount": len(p.Tracks), "revision": p.Revision,
+		"owner": map[string]any{"uid": json.Number(owner)}}
+}
+
+// playlistWithTracks is the rich-tracks form of p; f.mu must be held.
+func (f *FakeYandex) playlistWithTracks(p *fakePlaylist) map[string]any {
+	result := p.json()
+	tracks := make([]any, 0, len(p.Tracks))
+	for _, id := range p.Tracks {
+		for _, t := range f.tracks {
+			if t.ID == id {
+				tracks = append(tracks, map[string]any{"id": json.Number(id), "track": f.trackJSON(t)})
+			}
+		}
+	}
+	result["tracks"] = tracks
+	return result
+}
+
+// handleLanding serves the landing playlists block, other blocks ignored.
+func (f *FakeYandex) handleLanding(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entities := make([]any, 0, len(f.editorial))
+	for _, p := range f.editorial {
+		if p.Landing {
+			entities = append(entities, map[string]any{"type": "playlist", "data": p.json()})
+		}
+	}
+	writeJSON(w, map[string]any{"result": map[string]any{"blocks": []any{
+		map[string]any{"type": "new-playlists", "entities": entities},
+	}}})
+}
+
+func (f *FakeYandex) handleTagPlaylists(w http.ResponseWriter, r *http.Request) {
+	tag, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/tags/"), "/playlist-ids")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := []any{}
+	for _, p := range f.editorial {
+		if slices.Contains(p.Tags, tag) {
+			ids = append(ids, map[string]any{"uid": json.Number(p.Owner), "kind": p.Kind})
+		}
+	}
+	writeJSON(w, map[string]any{"result": map[string]any{"tag": map[string]any{"id": tag, "name": tag}, "ids": ids}})
+}
+
+func (f *FakeYandex) handlePlaylistList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+	_ = r.ParseForm()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	list := []any{}
+	for _, ref := range strings.Split(r.PostForm.Get("playlistIds"), ",") {
+		for _, p := range f.editorial {
+			if ref == p.Owner+":"+fmt.Sprint(p.Kind) {
+				list = append(list, p.json())
+			}
+		}
+	}
+	writeJSON(w, map[string]any{"result": list})
+}
+
+// handleCover serves a 1000×1000 PNG whatever size is asked for, so callers
+// have to scale it down themselves.
+func (f *FakeYandex) handleCover(w http.ResponseWriter, r *http.Request) {
+	img := image.NewRGBA(image.Rect(0, 0, 1000, 1000))
+	for y := 0; y < 1000; y++ {
+		for x := 0; x < 1000; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_ = png.Encode(w, img)
+}
+
+func (f *FakeYandex) handleGenres(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{"result": FakeGenres})
+}
+
+// handleSuggest completes part with catalog artists and titles that start with it.
+func (f *FakeYandex) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	part := strings.ToLower(r.URL.Query().Get("part"))
+	f.mu.Lock()
+	suggestions := []string{}
+	for _, t := range f.tracks {
+		for _, s := range append([]string{t.Title}, t.Artists...) {
+			if part != "" && strings.HasPrefix(strings.ToLower(s), part) {
+				suggestions = append(suggestions, s)
+			}
+		}
+	}
+	f.mu.Unlock()
+	writeJSON(w, map[string]any{"result": map[string]any{"suggestions": suggestions}})
+}
+
+// handleChart ranks the whole catalog in the order tracks were added.
+func (f *FakeYandex) handleChart(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	tracks := []any{}
+	for i, t := range f.tracks {
+		tracks = append(tracks, map[string]any{
+			"track":         f.trackJSON(t),
+			"chartPosition": map[string]any{"position": i + 1},
+		})
+	}
+	f.mu.Unlock()
+	writeJSON(w, map[string]any{"result": map[string]any{"chart": map[string]any{"tracks": tracks}}})
+}
+
+// handleAlbum serves /albums/<id>/with-tracks. Every track's album id is "1"
+// followed by its own id; catalog tracks sharing its Album title join it.
+func (f *FakeYandex) handleAlbum(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/albums/"), "/with-tracks")
+	first, found := f.find(strings.TrimPrefix(id, "1"))
+	if !ok || !strings.HasPrefix(id, "1") || !found {
+		http.NotFound(w, r)
+		return
+	}
+
+	f.mu.Lock()
+	volume := []any{}
+	for _, t := range f.tracks {
+		if t.ID == first.ID || (first.Album != "" && t.Album == first.Album) {
+			volume = append(volume, f.trackJSON(t))
+		}
+	}
+	f.mu.Unlock()
+	artists := make([]map[string]any, 0, len(first.Artists))
+	for _, a := range first.Artists {
+		artists = append(artists, map[string]any{"name": a})
+	}
+	albumType := ""
+	if first.Podcast {
+		albumType = "podcast"
+	}
+	writeJSON(w, map[string]any{"result": map[string]any{
+		"id":      json.Number(id),
+		"title":   first.Album,
+		"year":    first.Year,
+		"genre":   first.Genre,
+		"type":    albumType,
+		"artists": artists,
+		"volumes": []any{volume},
+	}})
+}
+
+// handleStation serves genre:<id> stations with the catalog tracks of that
+// genre; "rock" also plays its subgenres. The personal station cycles through
+// the catalog in batches, continuing after the queue parameter, and records
+// its feedback.
+func (f *FakeYandex) handleStation(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/rotor/station/")
+	if station, ok := strings.CutSuffix(path, "/feedback"); ok && station == yandex.MyWave {
+		f.handleWaveFeedback(w, r)
+		return
+	}
+	station, ok := strings.CutSuffix(path, "/tracks")
+	if ok && station == yandex.MyWave {
+		f.handleWave(w, r)
+		return
+	}
+	genre, isGenre := strings.CutPrefix(station, "genre:")
+	if !ok || !isGenre {
+		http.NotFound(w, r)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sequence := []any{}
+	for _, t := range f.tracks {
+		if t.Genre == genre || (genre == "rock" && (t.Genre == "rusrock" || t.Genre == "metal")) {
+			sequence = append(sequence, map[string]any{"type": "track", "track": f.trackJSON(t)})
+		}
+	}
+	writeJSON(w, map[string]any{"result": map[string]any{"sequence": sequence}})
+}
+
+func (f *FakeYandex) handleWave(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	start := 0
+	if last := r.URL.Query().Get("queue"); last != "" {
+		for i, t := range f.tracks {
+			if t.ID == last {
+				start = i + 1
+			}
+		}
+	}
+	sequence := []any{}
+	for i := 0; i < waveBatchLen && len(f.tracks) > 0; i++ {
+		t := f.tracks[(start+i)%len(f.tracks)]
+		sequence = append(sequence, map[string]any{"type": "track", "track": f.trackJSON(t)})
+	}
+	f.waveBatches++
+	batchID := fmt.Sprintf("batch-%d", f.waveBatches)
+	writeJSON(w, map[string]any{"result": map[string]any{"batchId": batchID, "sequence": sequence}})
+}
+
+func (f *FakeYandex) handleWaveFeedback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Type               string  `json:"type"`
+		TrackID            string  `json:"trackId"`
+		TotalPlayedSeconds float64 `json:"totalPlayedSeconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	f.waveFeedback = append(f.waveFeedback, WaveEvent{
+		Type: body.Type, BatchID: r.URL.Query().Get("batch-id"), TrackID: body.TrackID, Played: body.TotalPlayedSeconds,
+	})
+	f.mu.Unlock()
+	writeJSON(w, map[string]any{"result": "ok"})
+}
+
+func (f *FakeYandex) handleQueues(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	ids, current := f.queue, f.queueCurrent
+	f.mu.Unlock()
+
+	modified := time.Now().UTC().Format(time.RFC3339)
+	if r.URL.Path == "/queues" {
+		queues := []any{}
+		if len(ids) > 0 {
+			queues = append(queues, map[string]any{"id": "q1", "modified": modified})
+		}
+		writeJSON(w, map[string]any{"result": map[string]any{"queues": queues}})
+		return
+	}
+	if r.URL.Path != "/queues/q1" || len(ids) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	tracks := make([]any, 0, len(ids))
+	for _, id := range ids {
+		tracks = append(tracks, map[string]any{"trackId": id, "albumId": "1"})
+	}
+	writeJSON(w, map[string]any{"result": map[string]any{
+		"id":           "q1",
+		"tracks":       tracks,
+		"currentIndex": current,
+		"context":      map[string]any{"type": "playlist", "description": "Мне нравится"},
+		"modified":     modified,
+	}})
+}
+
+// fakeAudio returns a file whose header matches codec, so format sniffing
+// agrees with what the fake reported.
+func fakeAudio(codec, id string) []byte {
+	switch {
+	case strings.HasPrefix(codec, "flac"):
+		return []byte("fLaCfake-audio-" + id)
+	case strings.Contains(codec, "aac"):
+		return []byte("\x00\x00\x00\x10ftypM4A fake-audio-" + id)
+	}
+	return []byte("ID3fake-audio-" + id)
+}
+
+func (t FakeTrack) extension() string {
+	if strings.HasPrefix(t.Codec, "flac") {
+		return ".flac"
+	}
+	return ".mp3"
+}
+
+// syncedLyrics reports whether the track's lyrics are timed.
+func (t FakeTrack) syncedLyrics() bool {
+	return yandex.PlainLyrics(t.Lyrics) != strings.TrimSpace(t.Lyrics)
+}
+
+func (f *FakeYandex) trackJSON(t FakeTrack) map[string]any {
+	album := map[string]any{"id": json.Number("1" + t.ID), "title": t.Album, "genre": t.Genre}
+	if t.Compilation {
+		album["type"] = "compilation"
+	}
+	track := map[string]any{
+		"id":                       json.Number(t.ID),
+		"title":                    t.Title,
+		"durationMs":               t.DurationMs,
+		"artists":                  artistsJSON(t.Artists),
+		"albums":                   []any{album},
+		"coverUri":                 strings.TrimPrefix(f.URL(), "https://") + "/covers/" + t.ID + "/%%",
+		"available":                t.Availability == yandex.Available,
+		"availableForPremiumUsers": t.Availability != yandex.RegionLocked,
+	}
+	if t.RealID != "" {
+		track["realId"] = t.RealID
+	}
+	if t.Explicit {
+		track["contentWarning"] = "explicit"
+	}
+	if t.Podcast {
+		track["type"] = "podcast-episode"
+		album["type"] = "podcast"
+	}
+	if !t.Published.IsZero() {
+		track["pubDate"] = t.Published.Format(time.RFC3339)
+	}
+	if t.Lyrics != "" {
+		track["lyricsInfo"] = map[string]any{"hasAvailableTextLyrics": true, "hasAvailableSyncLyrics": t.syncedLyrics()}
+	}
+	return track
+}
+
+// matchesAll reports whether every word of query occurs in haystack.
+func matchesAll(haystack, query string) bool {
+	for _, word := range strings.Fields(query) {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, fmt.Sprintf("encode: %v", err), http.StatusInternalServerError)
+	}
+}
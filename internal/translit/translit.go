@@ -0,0 +1,194 @@
+// Package translit spells Cyrillic text in Latin letters and Latin text in
+// Cyrillic ones, for users who cannot read or type the other script.
+//
+// Cyrillic goes to Latin by a BGN/PCGN-style romanization without
+// apostrophes, the one most Russian artists use abroad: "Цой" is "Tsoy",
+// "Ёлка" is "Yolka", "Земфира" is "Zemfira". Ukrainian and Belarusian
+// letters are covered too. The other way is a phonetic approximation of
+// English spelling ("Metallica" becomes "Металлика"), as there is no
+// standard for it. Both keep the case of words, so "ЩИТ" gives "SHCHIT"
+// and "Щит" gives "Shchit", and leave every other character alone.
+package translit
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Direction is the script text is converted to.
+type Direction string
+
+// Directions; None leaves text as it is.
+const (
+	None       Direction = ""
+	ToLatin    Direction = "latin"
+	ToCyrillic Direction = "cyrillic"
+)
+
+// Directions lists the conversions in the order settings cycle through them.
+var Directions = []Direction{None, ToLatin, ToCyrillic}
+
+// Convert converts s in direction d.
+func Convert(s string, d Direction) string {
+	switch d {
+	case ToLatin:
+		return Latin(s)
+	case ToCyrillic:
+		return Cyrillic(s)
+	}
+	return s
+}
+
+// cyrillicToLatin maps lowercase Cyrillic letters to Latin ones, the soft
+// and hard signs to nothing.
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	// Ukrainian and Belarusian.
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "w",
+}
+
+// cyrillicVowels are the letters after which "е" is spelled "ye".
+const cyrillicVowels = "аеёиоуыэюяіїєъь"
+
+// Latin romanizes the Cyrillic letters of s.
+func Latin(s string) string {
+	rs := []rune(s)
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i, r := range rs {
+		lower := unicode.ToLower(r)
+		out, ok := cyrillicToLatin[lower]
+		if !ok {
+			sb.WriteRune(r)
+			continue
+		}
+		// "Елена" is "Yelena", "Мое" is "Moye", but "Лена" is "Lena".
+		if lower == 'е' && (i == 0 || !unicode.IsLetter(rs[i-1]) || strings.ContainsRune(cyrillicVowels, unicode.ToLower(rs[i-1]))) {
+			out = "ye"
+		}
+		sb.WriteString(matchCase(out, rs, i))
+	}
+	return sb.String()
+}
+
+// latinToCyrillic holds the letter groups read as one sound, longest first,
+// then the single letters; "c", "e" and "y" depend on their neighbours and
+// are handled in Cyrillic.
+var latinToCyrillic = []struct{ from, to string }{
+	{"shch", "щ"}, {"sch", "ш"},
+	{"zh", "ж"}, {"kh", "х"}, {"ts", "ц"}, {"ch", "ч"}, {"sh", "ш"},
+	{"ya", "я"}, {"yu", "ю"}, {"yo", "ё"}, {"ph", "ф"}, {"th", "т"},
+	{"ck", "к"}, {"ee", "и"}, {"oo", "у"}, {"qu", "кв"},
+	{"a", "а"}, {"b", "б"}, {"d", "д"}, {"f", "ф"}, {"g", "г"}, {"h", "х"},
+	{"i", "и"}, {"j", "дж"}, {"k", "к"}, {"l", "л"}, {"m", "м"}, {"n", "н"},
+	{"o", "о"}, {"p", "п"}, {"q", "к"}, {"r", "р"}, {"s", "с"}, {"t", "т"},
+	{"u", "у"}, {"v", "в"}, {"w", "в"}, {"x", "кс"}, {"z", "з"},
+}
+
+// latinVowels are the letters after which "y" is read as "й".
+const latinVowels = "aeiou"
+
+// Cyrillic spells the Latin letters of s in Cyrillic.
+func Cyrillic(s string) string {
+	rs := []rune(s)
+	var sb strings.Builder
+	sb.Grow(2 * len(s))
+	for i := 0; i < len(rs); {
+		lower := unicode.ToLower(rs[i])
+		if lower > unicode.MaxASCII || !unicode.IsLetter(lower) {
+			sb.WriteRune(rs[i])
+			i++
+			continue
+		}
+		out, n := cyrillicGroup(rs, i)
+		sb.WriteString(matchCase(out, rs, i))
+		i += n
+	}
+	return sb.String()
+}
+
+// cyrillicGroup returns the Cyrillic spelling of the Latin letters at i and
+// how many of them it covers.
+func cyrillicGroup(rs []rune, i int) (string, int) {
+	lower := unicode.ToLower(rs[i])
+	prev, next := rune(0), rune(0)
+	if i > 0 {
+		prev = unicode.ToLower(rs[i-1])
+	}
+	if i+1 < len(rs) {
+		next = unicode.ToLower(rs[i+1])
+	}
+	wordStart := prev == 0 || !unicode.IsLetter(prev)
+	switch lower {
+	case 'c':
+		if next == 'h' || next == 'k' {
+			break
+		}
+		// Soft before e, i and y, as in "Cecilia"; hard otherwise.
+		if next == 'e' || next == 'i' || next == 'y' {
+			return "ц", 1
+		}
+		return "к", 1
+	case 'e':
+		if next == 'e' {
+			break
+		}
+		if wordStart {
+			return "э", 1
+		}
+		return "е", 1
+	case 'y':
+		// "York" reads "Йорк" rather than "Ёрк".
+		if wordStart && next == 'o' {
+			return "й", 1
+		}
+		if next == 'a' || next == 'u' || next == 'o' {
+			break
+		}
+		if wordStart && next == 'e' {
+			return "е", 2
+		}
+		if strings.ContainsRune(latinVowels, prev) || (wordStart && strings.ContainsRune(latinVowels, next)) {
+			return "й", 1
+		}
+		return "и", 1
+	}
+	for _, g := range latinToCyrillic {
+		if hasPrefixFold(rs[i:], g.from) {
+			return g.to, len(g.from)
+		}
+	}
+	return string(rs[i]), 1
+}
+
+// hasPrefixFold reports whether rs starts with the lowercase ASCII prefix.
+func hasPrefixFold(rs []rune, prefix string) bool {
+	if len(rs) < len(prefix) {
+		return false
+	}
+	for j := 0; j < len(prefix); j++ {
+		if unicode.ToLower(rs[j]) != rune(prefix[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCase gives out, the spelling of the letter at src[i], that letter's
+// case. A capital spelled with several letters is capitalized unless its
+// word is written in capitals: "Щи" gives "Shchi", "ЩИ" gives "SHCHI".
+func matchCase(out string, src []rune, i int) string {
+	if out == "" || !unicode.IsUpper(src[i]) {
+		return out
+	}
+	if (i+1 < len(src) && unicode.IsUpper(src[i+1])) || (i > 0 && unicode.IsUpper(src[i-1])) {
+		return strings.ToUpper(out)
+	}
+	r := []rune(out)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,88 @@
+package translit
+
+import "testing"
+
+func TestLatin(t *testing.T) {
+	cases := []struct {
+		name, in, want string
+	}{
+		{"plain", "Земфира", "Zemfira"},
+		{"digraphs", "Жизнь Цоя и Чайф", "Zhizn Tsoya i Chayf"},
+		{"shch", "Щедрин", "Shchedrin"},
+		{"yo", "Ёлка", "Yolka"},
+		{"ye at word start", "Елена", "Yelena"},
+		{"ye after a vowel", "Мое", "Moye"},
+		{"e after a consonant", "Лена", "Lena"},
+		{"soft sign", "Мальчик", "Malchik"},
+		{"soft sign before e", "Пьеса", "Pyesa"},
+		{"hard sign", "Объём", "Obyom"},
+		{"hard sign before e", "Подъезд", "Podyezd"},
+		{"capital digraph", "Щит", "Shchit"},
+		{"capitals", "ЩИТ", "SHCHIT"},
+		{"single capital", "Ж", "Zh"},
+		{"abbreviation", "ДДТ", "DDT"},
+		{"ukrainian", "Київ і Євген", "Kiyiv i Yevgen"},
+		{"ukrainian g", "Ґанок", "Ganok"},
+		{"belarusian", "Ўзвышша", "Wzvyshsha"},
+		{"mixed", "Би-2 feat. Земфира", "Bi-2 feat. Zemfira"},
+		{"latin untouched", "Metallica (Live)", "Metallica (Live)"},
+		{"empty", "", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Latin(tc.in); got != tc.want {
+				t.Errorf("Latin(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCyrillic(t *testing.T) {
+	cases := []struct {
+		name, in, want string
+	}{
+		{"plain", "Metallica", "Металлика"},
+		{"e at word start", "Eminem", "Эминем"},
+		{"soft c", "Cecilia", "Цецилиа"},
+		{"hard c", "AC/DC", "АК/ДК"},
+		{"ck", "Jack", "Джак"},
+		{"digraphs", "Zhanna Shchedrin", "Жанна Щедрин"},
+		{"ph and th", "Phil Thomas", "Фил Томас"},
+		{"doubled vowels", "Queen Wood", "Квин Вуд"},
+		{"y before o at word start", "York", "Йорк"},
+		{"y before a", "Maya", "Мая"},
+		{"y after a vowel", "Boy", "Бой"},
+		{"y after a consonant", "Mary", "Мари"},
+		{"ye at word start", "Yes", "Ес"},
+		{"x", "Xenia", "Ксениа"},
+		{"capitals", "ABBA", "АББА"},
+		{"capital digraph", "JJ", "ДЖДЖ"},
+		{"mixed", "Кино feat. Sting", "Кино феат. Стинг"},
+		{"digits and marks", "Blink-182!", "Блинк-182!"},
+		{"cyrillic untouched", "Земфира", "Земфира"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Cyrillic(tc.in); got != tc.want {
+				t.Errorf("Cyrillic(%q) = %q, want %q", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		d    Direction
+		want string
+	}{
+		{None, "Кино & Sting"},
+		{ToLatin, "Kino & Sting"},
+		{ToCyrillic, "Кино & Стинг"},
+		{Direction("unknown"), "Кино & Sting"},
+	}
+	for _, tc := range cases {
+		if got := Convert("Кино & Sting", tc.d); got != tc.want {
+			t.Errorf("Convert(%q) = %q, want %q", tc.d, got, tc.want)
+		}
+	}
+}
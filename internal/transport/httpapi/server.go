@@ -0,0 +1,355 @@
+// Package httpapi exposes the music service over an internal HTTP/JSON API so
+// tools other than Telegram (a web frontend, a CLI) can search and fetch tracks.
+package httpapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/cover"
+	"ym-bot/internal/services/music"
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 50
+)
+
+// Server serves /api/v1 to clients presenting one of its API keys.
+type Server struct {
+	music           *music.Service
+	keys            [][]byte
+	logger          *zap.Logger
+	searchTimeout   time.Duration
+	downloadTimeout time.Duration
+}
+
+// Option configures Server.
+type Option func(*Server)
+
+// WithLogger sets the logger for request errors.
+func WithLogger(logger *zap.Logger) Option {
+	return func(s *Server) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithSearchTimeout bounds metadata requests (search, track lookup).
+func WithSearchTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		if d > 0 {
+			s.searchTimeout = d
+		}
+	}
+}
+
+// WithDownloadTimeout bounds fetching and sending one audio file.
+func WithDownloadTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		if d > 0 {
+			s.downloadTimeout = d
+		}
+	}
+}
+
+// NewServer builds the API over svc; blank keys are ignored.
+func NewServer(svc *music.Service, keys []string, opts ...Option) *Server {
+	s := &Server{
+		music:           svc,
+		logger:          zap.NewNop(),
+		searchTimeout:   12 * time.Second,
+		downloadTimeout: 90 * time.Second,
+	}
+	for _, k := range keys {
+		if k = strings.TrimSpace(k); k != "" {
+			s.keys = append(s.keys, []byte(k))
+		}
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Handler returns the routed and authenticated API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/search", s.handleSearch)
+	mux.HandleFunc("GET /api/v1/tracks/{id}", s.handleTrack)
+	mux.HandleFunc("GET /api/v1/tracks/{id}/download", s.handleDownload)
+	return s.authenticate(mux)
+}
+
+// Run listens on addr until ctx is done, then shuts down gracefully.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() { errs <- srv.ListenAndServe() }()
+	s.logger.Info("http api listening", zap.String("addr", addr))
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// authenticate accepts "Authorization: Bearer <key>" or "X-API-Key: <key>".
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if auth := r.Header.Get("Authorization"); key == "" && strings.HasPrefix(auth, "Bearer ") {
+			key = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if !s.validKey(key) {
+			writeError(w, http.StatusUnauthorized, "invalid or missing API key")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) validKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	ok := false
+	for _, k := range s.keys {
+		// Every key is compared so timing does not reveal which one matched.
+		if subtle.ConstantTimeCompare(k, []byte(key)) == 1 {
+			ok = true
+		}
+	}
+	return ok
+}
+
+// trackJSON is the API view of a track.
+type trackJSON struct {
+	ID      string   `json:"id"`
+	Title   string   `json:"title"`
+	Version string   `json:"version,omitempty"`
+	Artists []string `json:"artists"`
+	// Credits tell main, featured and composing artists apart, with their ids.
+	Credits         []creditJSON `json:"credits,omitempty"`
+	Album           string       `json:"album,omitempty"`
+	AlbumID         string       `json:"albumId,omitempty"`
+	Genre           string       `json:"genre,omitempty"`
+	Year            int          `json:"year,omitempty"`
+	DiscNumber      int          `json:"discNumber,omitempty"`
+	TrackNumber     int          `json:"trackNumber,omitempty"`
+	DurationSeconds int          `json:"durationSeconds"`
+	CoverURL        string       `json:"coverUrl,omitempty"`
+}
+
+type creditJSON struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+func newTrackJSON(t yandex.Track) trackJSON {
+	credits := make([]creditJSON, 0, len(t.Credits))
+	for _, c := range t.Credits {
+		credits = append(credits, creditJSON{ID: c.ID, Name: c.Name, Role: string(c.Role)})
+	}
+	return trackJSON{
+		ID:              t.ID,
+		Title:           t.Title,
+		Version:         t.Version,
+		Artists:         t.Artists,
+		Credits:         credits,
+		Album:           t.AlbumTitle,
+		AlbumID:         t.AlbumID,
+		Genre:           t.Genre,
+		Year:            t.Year,
+		DiscNumber:      t.DiscNumber,
+		TrackNumber:     t.TrackNumber,
+		DurationSeconds: t.DurationSeconds,
+		CoverURL:        cover.URL(t.CoverURI, cover.Card),
+	}
+}
+
+type searchResponse struct {
+	Tracks []trackJSON `json:"tracks"`
+	// NextOffset skips the duplicates collapsed out of this page as well.
+	NextOffset int    `json:"nextOffset"`
+	Correction string `json:"correction,omitempty"`
+	Corrected  bool   `json:"corrected,omitempty"`
+}
+
+// handleSearch answers GET /api/v1/search?q=&limit=&offset=.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	limit, ok := queryInt(r, "limit", defaultLimit)
+	if !ok || limit < 1 || limit > maxLimit {
+		writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxLimit))
+		return
+	}
+	offset, ok := queryInt(r, "offset", 0)
+	if !ok || offset < 0 {
+		writeError(w, http.StatusBadRequest, "offset must not be negative")
+		return
+	}
+
+	order, ok := music.ParseOrder(r.URL.Query().Get("order"))
+	if !ok && r.URL.Query().Get("order") != "" {
+		writeError(w, http.StatusBadRequest, "order must be relevance, popular, new, short or long")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.searchTimeout)
+	defer cancel()
+	res, err := s.music.Search(ctx, q, order, limit, offset)
+	if err != nil {
+		s.logger.Warn("api search failed", zap.String("query", q), zap.Error(err))
+		writeError(w, http.StatusBadGateway, "search failed")
+		return
+	}
+
+	out := searchResponse{
+		Tracks:     make([]trackJSON, 0, len(res.Tracks)),
+		NextOffset: offset + len(res.Tracks) + res.Collapsed,
+		Correction: res.Correction,
+		Corrected:  res.Corrected,
+	}
+	for _, t := range res.Tracks {
+		out.Tracks = append(out.Tracks, newTrackJSON(t))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleTrack answers GET /api/v1/tracks/{id} with the track's metadata.
+func (s *Server) handleTrack(w http.ResponseWriter, r *http.Request) {
+	id, ok := trackID(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.searchTimeout)
+	defer cancel()
+	tracks, err := s.music.Tracks(ctx, []string{id})
+	if err != nil {
+		s.logger.Warn("api track lookup failed", zap.String("trackID", id), zap.Error(err))
+		writeError(w, http.StatusBadGateway, "track lookup failed")
+		return
+	}
+	if len(tracks) == 0 {
+		writeError(w, http.StatusNotFound, "track not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, newTrackJSON(tracks[0]))
+}
+
+// handleDownload answers GET /api/v1/tracks/{id}/download with the audio file.
+func (s *Server) handleDownload(w http.ResponseWriter, r *http.Request) {
+	id, ok := trackID(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), s.downloadTimeout)
+	defer cancel()
+
+	// Files of known size are piped through; the rest go via a temp file.
+	dl, err := s.music.StreamTrack(ctx, id, math.MaxInt64)
+	if err != nil {
+		s.logger.Warn("api download failed", zap.String("trackID", id), zap.Error(err))
+		writeError(w, http.StatusBadGateway, "download failed")
+		return
+	}
+	defer func() {
+		if err := dl.Close(); err != nil {
+			s.logger.Warn("cleanup failed", zap.String("trackID", id), zap.Error(err))
+		}
+	}()
+
+	name := dl.Name
+	if dl.Path != "" {
+		name = filepath.Base(dl.Path)
+	}
+	contentType := dl.Format.MIME()
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(name))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+
+	if dl.Path != "" {
+		f, err := os.Open(dl.Path)
+		if err != nil {
+			s.logger.Warn("open download failed", zap.String("trackID", id), zap.Error(err))
+			writeError(w, http.StatusInternalServerError, "download failed")
+			return
+		}
+		defer f.Close()
+		http.ServeContent(w, r, name, time.Time{}, f)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.FormatInt(dl.Size, 10))
+	if _, err := io.Copy(w, dl.Body); err != nil {
+		s.logger.Debug("api stream interrupted", zap.String("trackID", id), zap.Error(err))
+	}
+}
+
+// trackID reads the numeric {id} path value, answering 400 when it is malformed.
+func trackID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.PathValue("id")
+	if _, err := strconv.ParseUint(id, 10, 64); err != nil {
+		writeError(w, http.StatusBadRequest, "track id must be numeric")
+		return "", false
+	}
+	return id, true
+}
+
+func queryInt(r *http.Request, name string, def int) (int, bool) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	return n, err == nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
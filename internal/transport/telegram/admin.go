@@ -0,0 +1,100 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/config"
+	"ym-bot/internal/settings"
+)
+
+// ConfigReloader re-applies configuration on demand (see config.Reloader).
+type ConfigReloader interface {
+	Reload() (config.Config, error)
+}
+
+// ApplyConfig swaps in the runtime-tunable settings of cfg (admins, stats
+// chart, quota, captions, maintenance behaviour). An invalid caption
+// template keeps the previous one.
+func (b *Bot) ApplyConfig(cfg config.Config) {
+	capt, err := newCaptioner(cfg.CaptionTemplate, cfg.CaptionAttribution)
+	if err != nil {
+		b.logger.Warn("caption template rejected", zap.Error(err))
+	} else {
+		b.captioner.Store(&capt)
+	}
+
+	b.settings.Swap(settings.FromConfig(cfg))
+	b.syncMaintenance()
+}
+
+func (b *Bot) isAdmin(userID int64) bool {
+	return b.settings.Load().IsAdmin(userID)
+}
+
+func (b *Bot) handleReload(_ context.Context, m *tgbotapi.Message) {
+	b.mu.RLock()
+	r := b.reloader
+	b.mu.RUnlock()
+
+	if r == nil {
+		b.reply(m.Chat.ID, "Перезагрузка конфигурации не настроена.")
+		return
+	}
+
+	cfg, err := r.Reload()
+	if err != nil {
+		b.logger.Warn("admin reload failed", zap.Int64("userID", m.From.ID), zap.Error(err))
+		b.reply(m.Chat.ID, fmt.Sprintf("Конфигурация не применена:\n%v", err))
+		return
+	}
+	b.reply(m.Chat.ID, fmt.Sprintf("Конфигурация перезагружена (log_level=%s).", cfg.LogLevel))
+}
+
+const setUsage = "Использование: /set <параметр> <значение>, например /set daily_download_limit 20. " +
+	"Размеры — в МБ, длительности — как 90s или 1h30m. Значения действуют до /reload или перезапуска."
+
+// handleSet shows the settings admins can override at runtime, or with a
+// key and a value changes one (see settings.Runtime.Set). A config reload
+// brings back the configured values.
+func (b *Bot) handleSet(_ context.Context, m *tgbotapi.Message) {
+	key, value, _ := strings.Cut(strings.TrimSpace(m.CommandArguments()), " ")
+	value = strings.TrimSpace(value)
+	if key == "" {
+		current := b.settings.Load()
+		var sb strings.Builder
+		sb.WriteString("Текущие настройки:\n")
+		for _, k := range settings.Keys() {
+			v, _ := current.Get(k)
+			fmt.Fprintf(&sb, "%s = %s\n", k, v)
+		}
+		sb.WriteString("\n" + setUsage)
+		b.reply(m.Chat.ID, sb.String())
+		return
+	}
+	if value == "" && key != "maintenance_message" {
+		b.reply(m.Chat.ID, setUsage)
+		return
+	}
+
+	var err error
+	next := b.settings.Update(func(r *settings.Runtime) {
+		err = r.Set(key, value)
+	})
+	if errors.Is(err, settings.ErrUnknownKey) {
+		b.reply(m.Chat.ID, fmt.Sprintf("Нет такого параметра: %s. Список — /set без аргументов.", key))
+		return
+	}
+	if err != nil {
+		b.reply(m.Chat.ID, fmt.Sprintf("Значение не принято: %v", err))
+		return
+	}
+	b.logger.Info("setting overridden", zap.Int64("userID", m.From.ID), zap.String("key", key), zap.String("value", value))
+	v, _ := next.Get(key)
+	b.reply(m.Chat.ID, fmt.Sprintf("%s = %s (до /reload или перезапуска).", key, v))
+}
@@ -0,0 +1,122 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/storage"
+)
+
+const (
+	defaultAuditRows = 10
+	maxAuditRows     = 50
+	auditUsage       = "Использование: /audit user <id> [n] | /audit track <id> [n]"
+	// maxAuditText keeps the reply under Telegram's 4096 limit.
+	maxAuditText = 4000
+)
+
+var auditOutcomeText = map[storage.AuditOutcome]string{
+	storage.AuditDelivered:   "✅ доставлен",
+	storage.AuditRedelivered: "✅ доставлен повторно",
+	storage.AuditDeferred:    "⏳ отложен",
+	storage.AuditSendFailed:  "❌ не отправлен",
+	storage.AuditFailed:      "❌ не скачан",
+	storage.AuditTooLarge:    "❌ слишком большой",
+	storage.AuditCancelled:   "✖️ отменён",
+}
+
+// recordAudit completes e with the outcome and the time spent since e.At and
+// appends it to the audit log.
+func (b *Bot) recordAudit(e storage.AuditEntry, outcome storage.AuditOutcome, err error) {
+	e.Duration = time.Since(e.At)
+	e.Outcome = outcome
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if err := b.audit.Append(e); err != nil {
+		b.logger.Warn("append audit log failed", zap.String("trackID", e.TrackID), zap.Error(err))
+	}
+	b.notifyAudit(e, err)
+}
+
+// handleAudit lists recent downloads of a user or of a track.
+func (b *Bot) handleAudit(_ context.Context, m *tgbotapi.Message) {
+	f, err := parseAuditFilter(m.CommandArguments())
+	if err != nil {
+		b.reply(m.Chat.ID, auditUsage)
+		return
+	}
+	entries, err := b.audit.Query(f)
+	if err != nil {
+		b.logger.Warn("query audit log failed", zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось прочитать журнал загрузок.")
+		return
+	}
+	b.reply(m.Chat.ID, truncate(renderAudit(entries), maxAuditText))
+}
+
+// parseAuditFilter accepts "user <id> [n]" or "track <id> [n]".
+func parseAuditFilter(arg string) (storage.AuditFilter, error) {
+	f := storage.AuditFilter{Limit: defaultAuditRows}
+	args := strings.Fields(arg)
+	if len(args) < 2 || len(args) > 3 {
+		return f, fmt.Errorf("invalid audit arguments %q", arg)
+	}
+	switch strings.ToLower(args[0]) {
+	case "user":
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || id <= 0 {
+			return f, fmt.Errorf("invalid user id %q", args[1])
+		}
+		f.UserID = id
+	case "track":
+		f.TrackID = args[1]
+	default:
+		return f, fmt.Errorf("unknown audit key %q", args[0])
+	}
+	if len(args) == 3 {
+		n, err := strconv.Atoi(args[2])
+		if err != nil || n <= 0 || n > maxAuditRows {
+			return f, fmt.Errorf("invalid row count %q", args[2])
+		}
+		f.Limit = n
+	}
+	return f, nil
+}
+
+// renderAudit lists entries newest first.
+func renderAudit(entries []storage.AuditEntry) string {
+	if len(entries) == 0 {
+		return "Записей не найдено."
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Последние загрузки (%d):\n", len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		title := e.Title
+		if title == "" {
+			title = e.TrackID
+		}
+		outcome, ok := auditOutcomeText[e.Outcome]
+		if !ok {
+			outcome = string(e.Outcome)
+		}
+		fmt.Fprintf(&sb, "\n%s UTC · user %d · chat %d\n%s (%s)\n%s", e.At.UTC().Format("02.01 15:04"),
+			e.UserID, e.ChatID, title, e.TrackID, outcome)
+		if e.Bytes > 0 {
+			fmt.Fprintf(&sb, ", %.1f МБ", float64(e.Bytes)/(1<<20))
+		}
+		fmt.Fprintf(&sb, ", %s", e.Duration.Round(100*time.Millisecond))
+		if e.Error != "" {
+			fmt.Fprintf(&sb, "\n%s", e.Error)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
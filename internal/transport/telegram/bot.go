@@ -2,80 +2,271 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"go.uber.org/zap"
 
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/cover"
 	"ym-bot/internal/services/music"
+	"ym-bot/internal/settings"
+	"ym-bot/internal/storage"
+	"ym-bot/internal/translit"
+	"ym-bot/internal/webhook"
 )
 
 const (
-	callbackPrefix = "download:"
-	searchLimit    = 10
+	searchLimit = 10
+
+	// maxAudioSize is the largest file still delivered as Audio; bigger ones go as Document.
+	maxAudioSize = 20 << 20
+
+	// Upload limits of the public Bot API and of a self-hosted server.
+	publicUploadLimit = 50 << 20
+	localUploadLimit  = 2000 << 20
+
+	defaultInlineTimeout   = 12 * time.Second
+	defaultCallbackTimeout = 90 * time.Second
+
+	// archiveUploads bounds the archive uploads running in the background;
+	// deliveries beyond it are not archived.
+	archiveUploads = 2
+	// archiveDrain is how long Start waits for running archive uploads when
+	// it stops before cancelling them.
+	archiveDrain = 30 * time.Second
 )
 
 // Bot wraps Telegram API interactions.
 type Bot struct {
 	api          *tgbotapi.BotAPI
+	apiURL       string
+	uploadLimit  int64
+	sender       Sender
 	musicService *music.Service
+	covers       *cover.Service
+	webhooks     *webhook.Notifier
+	store        *storage.Store
+	audit        *storage.AuditLog
+	recorder     *yandex.Recorder
+	limiter      RateLimiter
+	pool         WorkerPool
+	codec        *callback.Codec
+	menus        map[callback.Action]menuRenderer
+	callbacks    map[callback.Action]callbackHandler
+	commands     map[string]commandRoute
+	handle       updateHandler
+	presses      *pressGuard
+	updates      *updateLog
+	secret       []byte
+	callbackTTL  time.Duration
 	logger       *zap.Logger
+
+	inlineTimeout   time.Duration
+	callbackTimeout time.Duration
+
+	// runCtx is the context Start runs on; work that outlives an update,
+	// such as party timers, stops with it.
+	runCtx context.Context
+
+	jobsMu sync.Mutex
+	jobs   map[string]*jobStatus
+
+	importsMu sync.Mutex
+	imports   map[string]importSession
+
+	vibesMu sync.Mutex
+	vibes   map[int64]*vibeSession // by user id
+
+	picksMu sync.Mutex
+	picks   map[int64]searchPicks // by chat id
+
+	partiesMu sync.Mutex
+	parties   map[int64]*partySession // by chat id
+
+	quizMu    sync.Mutex
+	quizzes   map[string]quizRound // open rounds by poll id
+	quizChats map[int64]struct{}   // chats with a round being prepared or open
+
+	karaokeMu sync.Mutex
+	karaokes  map[int64]*karaokeSession // by chat id
+
+	// previewsMu guards previews, linkPreviews' answers by chat id, which
+	// hold while the store's settings revision is previewsRev.
+	previewsMu  sync.Mutex
+	previews    map[int64]bool
+	previewsRev uint64
+
+	// archiveMu guards archiveStopped, set once Start stops taking archive
+	// uploads. archiving holds a slot per running upload, archives waits for
+	// them and archiveCancel ends them on archiveCtx.
+	archiveMu      sync.Mutex
+	archiveStopped bool
+	archiving      chan struct{}
+	archives       sync.WaitGroup
+	archiveCtx     context.Context
+	archiveCancel  context.CancelFunc
+
+	// redeliverMu serializes dead-letter retries from the worker and /redeliver.
+	redeliverMu sync.Mutex
+	// playlistMu guards playlistLocks, which serialize the saves to each
+	// user's playlist so it is created once (see lockPlaylist).
+	playlistMu    sync.Mutex
+	playlistLocks map[int64]*playlistLock
+	// broadcastMu is held while a /broadcast runs.
+	broadcastMu sync.Mutex
+
+	// settings are the runtime-tunable knobs, swapped whole on reload.
+	settings *settings.Store
+
+	// duplicatePresses and duplicateUpdates count work not done twice: download
+	// presses swallowed by presses and updates redelivered by Telegram.
+	duplicatePresses atomic.Int64
+	duplicateUpdates atomic.Int64
+
+	// captioner renders captions; ApplyConfig swaps it, like settings.
+	captioner atomic.Pointer[captioner]
+
+	mu       sync.RWMutex
+	reloader ConfigReloader
 }
 
-// NewBot constructs a bot instance with inline mode enabled.
-func NewBot(token string, musicService *music.Service, logger *zap.Logger) (*Bot, error) {
+// NewBot constructs a bot instance with inline mode enabled. Without WithAPI
+// it connects to the public Bot API (or the WithAPIEndpoint server) using
+// token; without WithStore it keeps state in memory.
+func NewBot(token string, musicService *music.Service, opts ...Option) (*Bot, error) {
 	if musicService == nil {
 		return nil, fmt.Errorf("music service is nil")
 	}
-	if logger == nil {
-		logger = zap.NewNop()
+
+	b := &Bot{
+		musicService:    musicService,
+		callbackTTL:     defaultCallbackTTL,
+		inlineTimeout:   defaultInlineTimeout,
+		callbackTimeout: defaultCallbackTimeout,
+		runCtx:          context.Background(),
+		jobs:            make(map[string]*jobStatus),
+		imports:         make(map[string]importSession),
+		vibes:           make(map[int64]*vibeSession),
+		playlistLocks:   make(map[int64]*playlistLock),
+		picks:           make(map[int64]searchPicks),
+		parties:         make(map[int64]*partySession),
+		quizzes:         make(map[string]quizRound),
+		quizChats:       make(map[int64]struct{}),
+		karaokes:        make(map[int64]*karaokeSession),
+		previews:        make(map[int64]bool),
+		archiving:       make(chan struct{}, archiveUploads),
+		logger:          zap.NewNop(),
+		settings:        settings.New(settings.Runtime{}),
+	}
+	b.archiveCtx, b.archiveCancel = context.WithCancel(context.Background())
+	b.captioner.Store(&captioner{})
+	for _, opt := range opts {
+		opt(b)
 	}
 
-	api, err := tgbotapi.NewBotAPI(token)
-	if err != nil {
-		return nil, err
+	b.uploadLimit = publicUploadLimit
+	if b.apiURL != "" {
+		b.uploadLimit = localUploadLimit
+	}
+	if b.api == nil {
+		endpoint := tgbotapi.APIEndpoint
+		if b.apiURL != "" {
+			endpoint = b.apiURL + "/bot%s/%s"
+		}
+		api, err := tgbotapi.NewBotAPIWithAPIEndpoint(token, endpoint)
+		if err != nil {
+			return nil, err
+		}
+		b.api = api
+	}
+	b.api.Debug = false
+	if b.sender == nil {
+		b.sender = b.api
+	}
+	if len(b.secret) == 0 {
+		b.secret = deriveSecret(b.api.Token)
+	}
+	b.codec = callback.NewCodec(b.secret, b.callbackTTL)
+	b.menus = b.menuRenderers()
+	b.callbacks = b.callbackRoutes()
+	b.commands = b.commandRoutes()
+	b.handle = chain(b.route, b.recoverPanics, b.logUpdates, b.rateLimit, b.chatPolicy)
+	b.presses = newPressGuard(pressWindow)
+	b.updates = newUpdateLog(seenUpdates)
+	if b.store == nil {
+		store, err := storage.Open("")
+		if err != nil {
+			return nil, err
+		}
+		b.store = store
+	}
+	if b.audit == nil {
+		b.audit, _ = storage.OpenAudit("")
 	}
-	api.Debug = false
 
-	return &Bot{
-		api:          api,
-		musicService: musicService,
-		logger:       logger,
-	}, nil
+	return b, nil
 }
 
 // Start begins long polling and handles incoming updates.
 func (b *Bot) Start(ctx context.Context) error {
-	u := tgbotapi.NewUpdate(0)
+	b.runCtx = ctx
+	// Resume after the last update taken before a restart, so Telegram neither
+	// replays updates handled already nor the new instance skips pending ones.
+	name := b.api.Self.UserName
+	offset := 0
+	skipUpTo := b.store.UpdateOffset(name)
+	if skipUpTo > 0 {
+		offset = skipUpTo + 1
+		b.logger.Info("resuming updates", zap.Int("offset", offset))
+	}
+	u := tgbotapi.NewUpdate(offset)
 	u.Timeout = 10
 
 	updates := b.api.GetUpdatesChan(u)
+	b.syncMaintenance()
+	// Menus take a few dozen Bot API calls; polling does not wait for them.
+	go b.registerCommands(ctx)
+	b.recoverJobs(ctx)
+	go b.runRedelivery(ctx)
 
 	for {
 		select {
 		case <-ctx.Done():
+			b.drainArchives(archiveDrain)
 			return ctx.Err()
 		case update := <-updates:
-			if update.InlineQuery != nil {
-				go b.handleInlineQuery(ctx, update.InlineQuery)
-			} else if update.CallbackQuery != nil {
-				go b.handleCallback(ctx, update.CallbackQuery)
+			// Ids up to the stored offset were handled before the restart.
+			if update.UpdateID <= skipUpTo || !b.updates.first(update.UpdateID) {
+				b.logger.Debug("duplicate update skipped", zap.Int("updateID", update.UpdateID))
+				b.duplicateUpdates.Add(1)
+				continue
 			}
+			b.store.SetUpdateOffset(name, update.UpdateID)
+			go b.handle(ctx, update)
 		}
 	}
 }
 
 func (b *Bot) handleInlineQuery(ctx context.Context, q *tgbotapi.InlineQuery) {
-	ctx, cancel := context.WithTimeout(ctx, 12*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, b.inlineTimeout)
 	defer cancel()
 
+	if state, ok := b.underMaintenance(q.From.ID); ok {
+		b.answerInlineMaintenance(q, state)
+		return
+	}
+
 	query := strings.TrimSpace(q.Query)
 	if query == "" {
+		b.answerInlineSuggestions(ctx, q)
 		return
 	}
 
@@ -86,46 +277,153 @@ func (b *Bot) handleInlineQuery(ctx context.Context, q *tgbotapi.InlineQuery) {
 		}
 	}
 
-	tracks, err := b.musicService.Search(ctx, query, searchLimit, offset)
+	if rest, ok := cutPrefixFold(query, videoPrefix); ok {
+		b.answerInlineVideos(ctx, q, strings.TrimSpace(rest), offset)
+		return
+	}
+	if offset == 0 && b.answerInlineTypeahead(ctx, q, query) {
+		return
+	}
+
+	res, err := b.musicService.Search(ctx, query, b.searchOrder(q.From.ID), searchLimit, offset)
 	if err != nil {
 		b.logger.Warn("search failed", zap.String("query", query), zap.Error(err))
 		return
 	}
+	tracks := b.inlinePage(res.Tracks, offset)
+	if offset == 0 {
+		now := time.Now()
+		b.store.RecordSearch(q.From.ID, now)
+		if len(tracks) > 0 {
+			b.store.SaveSearch(q.From.ID, query, now)
+		}
+	}
 
-	results := make([]interface{}, 0, len(tracks))
+	group := q.ChatType == "group" || q.ChatType == "supergroup"
+	available := make([]yandex.Track, 0, len(tracks))
 	for _, track := range tracks {
-		// Fetch meta + direct url; Telegram will send audio directly from URL.
-		meta, url, err := b.musicService.StreamURL(ctx, track.ID)
-		if err != nil || url == "" {
-			b.logger.Debug("skip track: no direct url", zap.String("trackID", track.ID), zap.Error(err))
-			continue
+		// Telegram could not fetch the audio of region-locked tracks either.
+		if track.Availability != yandex.RegionLocked {
+			available = append(available, track)
 		}
-
-		audio := tgbotapi.NewInlineQueryResultAudio(meta.ID, url, meta.Title)
-		audio.Performer = meta.ArtistsString()
-		//	audio.Caption = fmt.Sprintf("%s — %s", meta.Title, meta.ArtistsString())
-		results = append(results, audio)
 	}
+	results, expires := b.inlineResults(ctx, q.From.ID, available, group)
 
+	// A search order or script of one's own, or the party buttons of groups,
+	// make the answer differ from what others get for the same query.
+	personal := group || b.searchOrder(q.From.ID) != music.OrderRelevance || b.transliteration(q.From.ID) != translit.None
 	ans := tgbotapi.InlineConfig{
 		InlineQueryID: q.ID,
-		IsPersonal:    true,
-		CacheTime:     0,
+		IsPersonal:    personal,
+		CacheTime:     b.inlineCacheTime(personal, expires),
 		Results:       results,
-		NextOffset:    strconv.Itoa(offset + len(results)),
+		// Skipped and collapsed tracks still count, or the next page would repeat them.
+		NextOffset: b.inlineNextOffset(offset+len(tracks)+res.Collapsed, len(tracks) == 0),
 	}
 
-	if _, err := b.api.Request(ans); err != nil {
+	if _, err := b.sender.Request(ans); err != nil {
 		b.logger.Warn("answer inline failed", zap.String("query", query), zap.Error(err))
 	}
 }
 
+// inlineTrack builds the inline result for track in the configured mode: a
+// card in fast mode, audio otherwise. In groups it carries the party button;
+// with upgrades on, audio elsewhere gets the card's keyboard, without which
+// the sent message could not be edited. It tells when the result's audio
+// URL expires, zero for cards; the URL's probe must be done by probeBy.
+func (b *Bot) inlineTrack(ctx context.Context, userID int64, track yandex.Track, group bool, probeBy time.Time) (interface{}, time.Time, bool) {
+	s := b.settings.Load()
+	if s.InlineFast {
+		return b.inlineCard(userID, track, group), time.Time{}, true
+	}
+	audio, exp, ok := b.inlineAudio(ctx, userID, track, probeBy)
+	if ok && group {
+		audio.ID = groupAudioPrefix + track.ID
+	}
+	if ok && (group || s.InlineUpgradeChat != 0) {
+		audio.ReplyMarkup = b.cardMarkup(track, group)
+	}
+	return audio, exp, ok
+}
+
+// inlineAudio builds an inline result Telegram sends straight from the
+// track's direct URL, and tells when that URL expires. The metadata is
+// already at hand (search, chart or history), so only the URL is fetched.
+// Title and caption are in the script userID chose; the file keeps its tags.
+func (b *Bot) inlineAudio(ctx context.Context, userID int64, track yandex.Track, probeBy time.Time) (tgbotapi.InlineQueryResultAudio, time.Time, bool) {
+	link, ok := b.inlineLink(ctx, track.ID, probeBy)
+	if !ok {
+		return tgbotapi.InlineQueryResultAudio{}, time.Time{}, false
+	}
+
+	track = music.Transliterate(track, b.transliteration(userID))
+	audio := tgbotapi.NewInlineQueryResultAudio(track.ID, link.URL, track.FullTitle())
+	audio.Performer = track.ArtistsString()
+	audio.Caption = b.caption(captionData(track, b.api.Self.UserName), "")
+	return audio, link.Expires, true
+}
+
+// inlineLink resolves the direct URL Telegram fetches the track from,
+// probing it by probeBy first when configured; false means Telegram could
+// not use it. A probe that runs out of time keeps the URL.
+func (b *Bot) inlineLink(ctx context.Context, trackID string, probeBy time.Time) (yandex.DownloadLink, bool) {
+	link, err := b.musicService.DirectLink(ctx, trackID)
+	if err != nil || link.URL == "" {
+		b.logger.Debug("skip track: no direct url", zap.String("trackID", trackID), zap.Error(err))
+		return yandex.DownloadLink{}, false
+	}
+	if b.settings.Load().InlineProbe {
+		probeCtx, cancel := context.WithDeadline(ctx, probeBy)
+		probed, err := b.musicService.ProbeLink(probeCtx, link)
+		cancel()
+		switch {
+		case errors.Is(err, music.ErrUnfetchable):
+			b.logger.Info("skip track: url not fetchable", zap.String("trackID", trackID), zap.Error(err))
+			return yandex.DownloadLink{}, false
+		case err != nil:
+			// Our side failing to probe says nothing about Telegram's.
+			b.logger.Debug("probe direct url failed", zap.String("trackID", trackID), zap.Error(err))
+		default:
+			link = probed
+		}
+	}
+	return link, true
+}
+
 func (b *Bot) handleCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
-	if cb.Data == "" || !strings.HasPrefix(cb.Data, callbackPrefix) {
+	p, err := b.codec.Decode(cb.Data)
+	if err != nil {
+		b.logger.Debug("reject callback", zap.Int64("userID", cb.From.ID), zap.Error(err))
+		b.sendAlert(cb, "Кнопка устарела, повторите запрос.")
+		return
+	}
+	if state, ok := b.underMaintenance(cb.From.ID); ok {
+		switch {
+		case p.Action == callback.ActionCancel:
+		case p.Action == callback.ActionDownload && b.holdingDownloads():
+		case p.Action == callback.ActionDownload:
+			b.sendAlert(cb, b.maintenanceText(state)+"\nНовые загрузки временно не принимаются.")
+			return
+		default:
+			b.sendAlert(cb, b.maintenanceText(state))
+			return
+		}
+	}
+
+	if render, ok := b.menus[p.Action]; ok {
+		b.navigateMenu(ctx, cb, p, render)
 		return
 	}
+	if handle, ok := b.callbacks[p.Action]; ok {
+		handle(ctx, cb, p)
+	}
+}
 
-	trackID := strings.TrimPrefix(cb.Data, callbackPrefix)
+func (b *Bot) handleDownloadCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	trackID := p.Arg(0)
+	if trackID == "" {
+		return
+	}
 
 	var chatID int64
 	if cb.Message != nil && cb.Message.Chat != nil {
@@ -134,40 +432,423 @@ func (b *Bot) handleCallback(ctx context.Context, cb *tgbotapi.CallbackQuery) {
 		// Inline keyboard callbacks may omit message; fall back to sender.
 		chatID = cb.From.ID
 	}
+	split := p.Arg(1) == downloadSplit
+	confirmed := p.Arg(1) == downloadConfirmed || split
+	press := pressKey(cb.From.ID, trackID)
+	if !b.presses.claim(press, confirmed) {
+		// A double tap: the first press is already taking care of it.
+		b.duplicatePresses.Add(1)
+		if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, "Этот трек уже загружается.")); err != nil {
+			b.logger.Warn("callback ack failed", zap.Error(err))
+		}
+		return
+	}
+	// A message sent inline has no chat to ask in.
+	if !confirmed && cb.InlineMessageID == "" && b.askBeforeDownload(ctx, cb.From.ID, trackID, chatID) {
+		b.presses.done(press)
+		if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, "")); err != nil {
+			b.logger.Warn("callback ack failed", zap.Error(err))
+		}
+		return
+	}
+
+	restore := func() {}
+	req := downloadRequest{
+		// The callback id is unique per press, so it doubles as the job key.
+		key:      cb.ID,
+		userID:   cb.From.ID,
+		chatID:   chatID,
+		trackID:  trackID,
+		split:    split,
+		inlineID: cb.InlineMessageID,
+		notify:   func(text string) { b.sendAlert(cb, text) },
+		release: func() {
+			b.presses.done(press)
+			restore()
+		},
+	}
+	var ackText string
+	quota, err := b.reserveDownload(req.userID, func(reservedAt time.Time) error {
+		if !confirmed {
+			restore = b.showPressed(cb)
+		}
+		req.reservedAt = reservedAt
+		var err error
+		ackText, err = b.submitDownload(ctx, req)
+		return err
+	})
+	if errors.Is(err, storage.ErrQuotaExceeded) {
+		b.presses.done(press)
+		b.sendAlert(cb, quotaExceededText(quota, time.Now()))
+		return
+	}
+	if err != nil {
+		req.release()
+		b.logger.Warn("download queue rejected job", zap.String("trackID", trackID), zap.Error(err))
+		b.sendAlert(cb, "Сейчас слишком много загрузок, попробуйте через минуту.")
+		return
+	}
 
 	// Immediately acknowledge to avoid Telegram timeout.
-	ack := tgbotapi.NewCallback(cb.ID, "Готовим ваш трек…")
-	if _, err := b.api.Request(ack); err != nil {
+	ack := tgbotapi.NewCallback(cb.ID, ackText+quotaHint(quota))
+	if _, err := b.sender.Request(ack); err != nil {
 		b.logger.Warn("callback ack failed", zap.Error(err))
 	}
+	if confirmed {
+		b.deleteMessage(cb.Message)
+	}
+}
 
-	ctx, cancel := context.WithTimeout(ctx, 90*time.Second)
+// downloadRequest is one track to fetch and deliver to a chat.
+type downloadRequest struct {
+	key     string // unique job key, also carried by the Cancel button
+	userID  int64
+	chatID  int64
+	trackID string
+	// split sends the track as a series of parts, see deliverParts.
+	split      bool
+	reservedAt time.Time
+	// quiet skips the per-job status message, e.g. for bulk downloads.
+	quiet bool
+	// inlineID is the message sent inline whose button requested the
+	// download: it shows the status and gets the delivered file. group
+	// tells it was sent to a group, where its keyboard is the party button.
+	inlineID string
+	group    bool
+	// notify reports failures: a callback alert or a chat message.
+	notify func(text string)
+	// release, if set, runs once the job is over, however it ended.
+	release func()
+}
+
+func (r downloadRequest) finish() {
+	if r.release != nil {
+		r.release()
+	}
+}
+
+// submitDownload queues req on the pool, or starts it right away without one.
+// The returned acknowledgement carries the queue position when the user has to wait.
+func (b *Bot) submitDownload(ctx context.Context, req downloadRequest) (string, error) {
+	const ready = "Готовим ваш трек…"
+
+	b.saveJob(req, storage.JobQueued)
+
+	var status *jobStatus
+	job := func(ctx context.Context) {
+		defer req.finish()
+		defer b.finishJob(status)
+		defer func() {
+			// Pool workers run jobs in their own goroutines; a crash would kill the bot.
+			if r := recover(); r != nil {
+				b.logPanic(r, "download job", zap.String("key", req.key), zap.String("trackID", req.trackID),
+					zap.Int64("userID", req.userID), zap.Int64("chatID", req.chatID))
+				b.store.ReleaseQuota(req.userID, req.reservedAt)
+				b.setJobState(req.key, storage.JobFailed)
+				req.notify(panicText)
+			}
+		}()
+		b.setJobState(req.key, storage.JobRunning)
+		status.show(downloadingText)
+		// A shutdown leaves the job running in the store so it resumes on restart.
+		if state := b.deliver(ctx, req); state != storage.JobRunning {
+			b.setJobState(req.key, state)
+		}
+	}
+	if b.pool == nil {
+		go job(ctx)
+		return ready, nil
+	}
+
+	if !req.quiet {
+		status = b.trackJob(req.key, req.userID, req.chatID, req.reservedAt)
+		status.release = req.finish
+		status.inlineID = req.inlineID
+		status.group = req.group
+		status.trackID = req.trackID
+	}
+	ticket, err := b.pool.Submit(req.key, job)
+	if err != nil {
+		b.finishJob(status)
+		b.store.RemoveJob(req.key)
+		return "", err
+	}
+	// Only a saturated or paused pool makes the user wait; show the line then.
+	if pos := b.pool.Position(ticket); pos > 0 {
+		if status != nil {
+			go status.follow(ticket)
+		}
+		return b.queueText(pos), nil
+	}
+	return ready, nil
+}
+
+// deliver downloads the track and sends it to the chat; quota is released on
+// failure. It returns the job's final state, or JobRunning when a shutdown
+// interrupted it.
+func (b *Bot) deliver(ctx context.Context, req downloadRequest) storage.JobState {
+	if req.split {
+		return b.deliverParts(ctx, req)
+	}
+	ctx, cancel := context.WithTimeout(b.chatContext(ctx, req.chatID), b.callbackTimeout)
 	defer cancel()
 
-	meta, path, err := b.musicService.DownloadTrack(ctx, trackID)
+	trackID := req.trackID
+	streamMax := b.settings.Load().StreamMax
+	filters := b.audioFilters(req.userID)
+	processed := filters.Any() && b.musicService.CanTranscode()
+	// Transliterated tags are written into the file.
+	script := b.transliteration(req.userID)
+	retagged := script != translit.None && b.musicService.CanTranscode()
+	// Processing, retagging and archiving work on a file.
+	if processed || retagged || b.musicService.Archives() {
+		streamMax = 0
+	}
+
+	entry := storage.AuditEntry{At: time.Now(), Bot: b.api.Self.UserName, UserID: req.userID, ChatID: req.chatID, TrackID: trackID}
+	hidden, err := b.explicitHidden(ctx, req)
+	if hidden {
+		b.refuseExplicit(req, entry)
+		return storage.JobFailed
+	}
+	var dl music.Download
+	if err == nil {
+		dl, err = b.musicService.StreamTrack(ctx, trackID, streamMax)
+	}
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// The partial file is already removed either way.
+		if s := b.jobFor(req.key); s == nil || !s.abortedByUser() {
+			// Shutdown: keep the reservation, the job resumes after restart.
+			b.logger.Debug("download interrupted", zap.String("trackID", trackID))
+			return storage.JobRunning
+		}
+		b.store.ReleaseQuota(req.userID, req.reservedAt)
+		b.logger.Debug("download cancelled", zap.String("trackID", trackID))
+		b.recordAudit(entry, storage.AuditCancelled, nil)
+		return storage.JobCancelled
+	}
 	if err != nil {
-		b.logger.Warn("download failed", zap.String("trackID", trackID), zap.Error(err))
-		b.sendAlert(cb, "Не удалось скачать трек :(")
-		return
+		b.store.ReleaseQuota(req.userID, req.reservedAt)
+		ue := presentError(err, errDownloadFailed)
+		b.logger.Warn("download failed", zap.String("trackID", trackID), zap.String("code", ue.code), zap.Error(err))
+		b.recordAudit(entry, storage.AuditFailed, err)
+		req.notify(ue.String())
+		return storage.JobFailed
+	}
+	dl = b.musicService.Process(ctx, dl, filters)
+	if script != translit.None {
+		dl = b.musicService.Relabel(ctx, dl, music.Transliterate(dl.Track, script))
 	}
-	defer os.RemoveAll(filepath.Dir(path))
+	entry.Title = fmt.Sprintf("%s — %s", dl.Track.ArtistsString(), dl.Track.FullTitle())
+	entry.Bytes = dl.Size
+	kept := false
+	defer func() {
+		if !kept {
+			_ = dl.Close()
+		}
+	}()
 
-	audio := tgbotapi.NewAudio(chatID, tgbotapi.FilePath(path))
-	audio.Duration = meta.DurationSeconds
-	audio.Performer = meta.ArtistsString()
-	audio.Title = meta.Title
-	//audio.Caption = fmt.Sprintf("%s — %s", meta.Title, meta.ArtistsString())
+	if dl.Size > b.uploadLimit {
+		b.store.ReleaseQuota(req.userID, req.reservedAt)
+		b.logger.Warn("file exceeds upload limit", zap.String("trackID", trackID), zap.Int64("size", dl.Size))
+		b.recordAudit(entry, storage.AuditTooLarge, nil)
+		req.notify(presentError(tooLargeError{size: dl.Size, limit: b.uploadLimit}, errGeneric).String())
+		return storage.JobFailed
+	}
 
-	if _, err := b.api.Send(audio); err != nil {
+	sent, err := b.sender.Send(b.buildDelivery(ctx, req.chatID, req.userID, dl, true))
+	if err != nil {
 		b.logger.Warn("send audio failed", zap.String("trackID", trackID), zap.Error(err))
-		b.sendAlert(cb, "Не удалось отправить аудио :(")
-		return
+		// The download is kept and retried instead of being thrown away.
+		if kept = b.deadLetter(req, dl, err); kept {
+			b.recordAudit(entry, storage.AuditDeferred, err)
+			req.notify("Не удалось отправить аудио, повторю попытку позже.")
+			return storage.JobDone
+		}
+		b.store.ReleaseQuota(req.userID, req.reservedAt)
+		b.recordAudit(entry, storage.AuditSendFailed, err)
+		req.notify(presentError(err, errSendFailed).String())
+		return storage.JobFailed
+	}
+	b.recordAudit(entry, storage.AuditDelivered, nil)
+	b.store.RecordDownload(req.userID, historyEntry(trackID, dl.Track))
+	if req.inlineID != "" {
+		b.replaceInline(req.inlineID, sent, trackID, req.group)
+	}
+	// The archive keeps the files as Yandex serves them.
+	if !processed && script == translit.None && b.musicService.Archives() {
+		kept = b.archiveDelivery(dl)
+	}
+	return storage.JobDone
+}
+
+// explicitHidden reports whether req is for an explicit track and its chat
+// hides those. It looks at the track's metadata, usually cached, so a
+// refused track is never downloaded.
+func (b *Bot) explicitHidden(ctx context.Context, req downloadRequest) (bool, error) {
+	if !b.store.ChatSettings(req.chatID).HideExplicit {
+		return false, nil
+	}
+	tracks, err := b.musicService.Tracks(ctx, []string{req.trackID})
+	if err != nil {
+		return false, err
+	}
+	return len(tracks) > 0 && tracks[0].Explicit, nil
+}
+
+// refuseExplicit ends req refused by explicitHidden: the quota is returned
+// and the user told why.
+func (b *Bot) refuseExplicit(req downloadRequest, entry storage.AuditEntry) {
+	b.store.ReleaseQuota(req.userID, req.reservedAt)
+	b.logger.Debug("explicit track refused", zap.String("trackID", req.trackID), zap.Int64("chatID", req.chatID))
+	b.recordAudit(entry, storage.AuditFailed, errExplicitHidden)
+	req.notify(presentError(errExplicitHidden, errGeneric).String())
+}
+
+// archiveDelivery uploads a delivered download to the archive in the
+// background, so the job's worker is free, and then removes it. It reports
+// false, leaving dl to the caller, when all upload slots are taken or Start
+// has stopped.
+func (b *Bot) archiveDelivery(dl music.Download) bool {
+	b.archiveMu.Lock()
+	defer b.archiveMu.Unlock()
+	if b.archiveStopped {
+		return false
+	}
+	select {
+	case b.archiving <- struct{}{}:
+	default:
+		b.logger.Debug("archive upload skipped: all slots busy", zap.String("trackID", dl.Track.ID))
+		return false
+	}
+	b.archives.Add(1)
+	go func() {
+		defer b.archives.Done()
+		defer func() { <-b.archiving }()
+		defer dl.Close()
+		ctx, cancel := context.WithTimeout(b.archiveCtx, b.callbackTimeout)
+		defer cancel()
+		if err := b.musicService.Archive(ctx, dl); err != nil {
+			b.logger.Warn("archive upload failed", zap.String("trackID", dl.Track.ID), zap.Error(err))
+		}
+	}()
+	return true
+}
+
+// drainArchives stops taking archive uploads and waits up to timeout for
+// the running ones, then cancels those left.
+func (b *Bot) drainArchives(timeout time.Duration) {
+	b.archiveMu.Lock()
+	b.archiveStopped = true
+	b.archiveMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.archives.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		b.logger.Warn("archive uploads cancelled at shutdown")
+		b.archiveCancel()
+		<-done
+	}
+}
+
+// buildDelivery picks Audio or Document depending on the file and user preference.
+// Lossless and oversized files are sent as documents so the original bytes are kept.
+// A whole track sent to the user's private chat gets deliveryKeyboard; parts
+// of a split track and tracks sent to groups only the playlist button.
+func (b *Bot) buildDelivery(ctx context.Context, chatID, userID int64, dl music.Download, whole bool) tgbotapi.Chattable {
+	meta := dl.Track
+	// Captions and tags show the names in the user's script; the buttons
+	// and history go on with the track as Yandex knows it.
+	shown := music.Transliterate(meta, b.transliteration(userID))
+	thumb := b.coverFile(ctx, meta, cover.Audio)
+	data := captionData(shown, b.api.Self.UserName)
+	data.Codec = strings.ToUpper(dl.Codec)
+	if dl.Format.Codec != "" {
+		data.Codec = strings.ToUpper(dl.Format.Codec)
+	}
+	data.SizeMB = fmt.Sprintf("%.1f", float64(dl.Size)/(1<<20))
+	data.SampleRate = dl.Format.SampleRateString()
+	b.rememberTrack(meta)
+	markup, withMarkup := b.playlistKeyboard(userID, meta.ID)
+	if whole && chatID == userID {
+		markup, withMarkup = b.deliveryKeyboard(userID, meta)
+	}
+
+	if b.store.Prefs(userID).SendAsDocument || !dl.PlaysInline() || dl.Size > maxAudioSize {
+		doc := tgbotapi.NewDocument(chatID, uploadFile(dl))
+		doc.Caption = b.caption(data, documentCaption(data))
+		doc.Thumb = thumb
+		if withMarkup {
+			doc.ReplyMarkup = markup
+		}
+		return doc
+	}
+
+	audio := tgbotapi.NewAudio(chatID, uploadFile(dl))
+	audio.Duration = meta.DurationSeconds
+	audio.Performer = shown.ArtistsString()
+	audio.Title = shown.FullTitle()
+	audio.Caption = b.caption(data, "")
+	audio.Thumb = thumb
+	if withMarkup {
+		audio.ReplyMarkup = markup
+	}
+	return audio
+}
+
+// coverFile returns the track's album art at size, or nil when there is none
+// or it cannot be fetched; a missing cover never blocks a delivery.
+func (b *Bot) coverFile(ctx context.Context, t yandex.Track, size int) tgbotapi.RequestFileData {
+	if b.covers == nil || t.CoverURI == "" {
+		return nil
+	}
+	img, err := b.covers.Fetch(ctx, t.CoverURI, size)
+	if err != nil {
+		b.logger.Debug("cover unavailable", zap.String("trackID", t.ID), zap.Error(err))
+		return nil
+	}
+	return tgbotapi.FileBytes{Name: "cover.jpg", Bytes: img}
+}
+
+// uploadFile sends a streamed download straight from its body, without a
+// temp file, and anything else from disk.
+func uploadFile(dl music.Download) tgbotapi.RequestFileData {
+	if dl.Body != nil {
+		return tgbotapi.FileReader{Name: dl.Name, Reader: dl.Body}
+	}
+	return tgbotapi.FilePath(dl.Path)
+}
+
+// caption renders the configured template, logging and falling back on errors.
+func (b *Bot) caption(data CaptionData, fallback string) string {
+	text, err := b.captioner.Load().render(data, fallback)
+	if err != nil {
+		b.logger.Warn("caption template failed", zap.Error(err))
+	}
+	return text
+}
+
+// documentCaption describes the file since documents lack audio metadata.
+func documentCaption(data CaptionData) string {
+	caption := fmt.Sprintf("%s — %s", data.Title, data.Artists)
+	if data.Codec != "" {
+		caption += "\n" + data.Codec
+		if data.SampleRate != "" {
+			caption += " • " + data.SampleRate
+		}
+		caption += fmt.Sprintf(" • %s MB", data.SizeMB)
 	}
+	return caption
 }
 
 func (b *Bot) sendAlert(cb *tgbotapi.CallbackQuery, text string) {
 	alert := tgbotapi.NewCallbackWithAlert(cb.ID, text)
-	if _, err := b.api.Request(alert); err != nil {
+	if _, err := b.sender.Request(alert); err != nil {
 		b.logger.Warn("callback alert failed", zap.Error(err))
 	}
 }
@@ -0,0 +1,116 @@
+package telegram
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/storage"
+)
+
+// commandScope is where Telegram lists a command in its menu; routing does
+// not depend on it.
+type commandScope int
+
+const (
+	scopeEverywhere commandScope = iota
+	scopePrivate
+	scopeGroup
+	scopeGroupAdmin
+	scopeHidden
+)
+
+// menuLanguages are the description languages registered besides the
+// default Russian one, picked by Telegram from the user's app language.
+var menuLanguages = []string{"en"}
+
+// commandList returns the routes shown in the given scopes, sorted by name,
+// with descriptions in lang.
+func (b *Bot) commandList(lang string, disabled func(string) bool, scopes ...commandScope) []tgbotapi.BotCommand {
+	var out []tgbotapi.BotCommand
+	for name, r := range b.commands {
+		if r.admin || !slices.Contains(scopes, r.scope) || (disabled != nil && disabled(name)) {
+			continue
+		}
+		out = append(out, tgbotapi.BotCommand{Command: name, Description: r.describe(lang)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Command < out[j].Command })
+	return out
+}
+
+// adminCommandList is the private list plus the bot admin commands.
+func (b *Bot) adminCommandList(lang string) []tgbotapi.BotCommand {
+	out := b.commandList(lang, nil, scopeEverywhere, scopePrivate)
+	for name, r := range b.commands {
+		if r.admin {
+			out = append(out, tgbotapi.BotCommand{Command: name, Description: r.describe(lang)})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Command < out[j].Command })
+	return out
+}
+
+// describe returns the menu description in lang, Russian by default.
+func (r commandRoute) describe(lang string) string {
+	if lang == "en" && r.descriptionEN != "" {
+		return r.descriptionEN
+	}
+	return r.description
+}
+
+// registerCommands publishes the command menus: the default list (what
+// groups see), the private chat list, the group admin list and, in the
+// private chats of bot admins, the admin commands too: they are listed in no
+// scope other users see. Each is registered in Russian and in menuLanguages.
+// Failures are logged; commands keep working without a menu.
+func (b *Bot) registerCommands(ctx context.Context) {
+	admins := b.settings.Load().Admins
+
+	for _, lang := range append([]string{""}, menuLanguages...) {
+		b.setCommands(tgbotapi.NewBotCommandScopeDefault(), lang,
+			b.commandList(lang, nil, scopeEverywhere, scopeGroup))
+		b.setCommands(tgbotapi.NewBotCommandScopeAllPrivateChats(), lang,
+			b.commandList(lang, nil, scopeEverywhere, scopePrivate))
+		b.setCommands(tgbotapi.NewBotCommandScopeAllChatAdministrators(), lang,
+			b.commandList(lang, nil, scopeEverywhere, scopeGroup, scopeGroupAdmin))
+		for _, id := range admins {
+			if ctx.Err() != nil {
+				return
+			}
+			b.setCommands(tgbotapi.NewBotCommandScopeChat(id), lang, b.adminCommandList(lang))
+		}
+	}
+}
+
+// syncChatCommands gives a group its own menus once its settings differ
+// from the defaults: descriptions in the chat's language, without the
+// commands it turned off. A chat back on the defaults drops them again.
+func (b *Bot) syncChatCommands(chatID int64, c storage.ChatSettings) {
+	members := tgbotapi.NewBotCommandScopeChat(chatID)
+	admins := tgbotapi.NewBotCommandScopeChatAdministrators(chatID)
+	if c.Language == "" && len(c.DisabledCommands) == 0 {
+		for _, scope := range []tgbotapi.BotCommandScope{members, admins} {
+			if _, err := b.sender.Request(tgbotapi.NewDeleteMyCommandsWithScope(scope)); err != nil {
+				b.logger.Warn("delete chat commands failed", zap.Int64("chatID", chatID), zap.Error(err))
+			}
+		}
+		return
+	}
+	disabled := func(name string) bool { return !c.CommandAllowed(name) }
+	b.setCommands(members, "", b.commandList(c.Language, disabled, scopeEverywhere, scopeGroup))
+	b.setCommands(admins, "", b.commandList(c.Language, disabled, scopeEverywhere, scopeGroup, scopeGroupAdmin))
+}
+
+func (b *Bot) setCommands(scope tgbotapi.BotCommandScope, lang string, commands []tgbotapi.BotCommand) {
+	cfg := tgbotapi.NewSetMyCommandsWithScope(scope, commands...)
+	if lang != "" {
+		cfg = tgbotapi.NewSetMyCommandsWithScopeAndLanguage(scope, lang, commands...)
+	}
+	if _, err := b.sender.Request(cfg); err != nil {
+		b.logger.Warn("set bot commands failed", zap.String("scope", scope.Type), zap.Int64("chatID", scope.ChatID),
+			zap.String("language", lang), zap.Error(err))
+	}
+}
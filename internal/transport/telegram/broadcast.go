@@ -0,0 +1,149 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/storage"
+)
+
+// broadcastInterval spaces broadcast messages, staying under Telegram's
+// limit of about 30 messages a second.
+const broadcastInterval = time.Second / 25
+
+const broadcastUsage = "Использование: /broadcast <текст> — всем пользователям бота; /broadcast test <текст> — сначала только администраторам."
+
+// broadcastReport counts the outcome of a broadcast.
+type broadcastReport struct {
+	Sent, Failed, Blocked int
+	Took                  time.Duration
+}
+
+func (r broadcastReport) String() string {
+	return fmt.Sprintf("отправлено %d, заблокировали бота %d, ошибок %d (за %s)", r.Sent, r.Blocked, r.Failed, r.Took.Round(time.Second))
+}
+
+// broadcaster sends one text to many private chats through bot. Chats
+// marked inactive for it are skipped, chats that turn out to have blocked it
+// are marked so, and the users it reaches are recorded as the bot's.
+type broadcaster struct {
+	bot      string
+	sender   Sender
+	store    *storage.Store
+	logger   *zap.Logger
+	interval time.Duration
+}
+
+// run sends text to recipients one at a time until done or ctx ends.
+func (bc *broadcaster) run(ctx context.Context, recipients []int64, text string) broadcastReport {
+	start := time.Now()
+	var r broadcastReport
+	ticker := time.NewTicker(bc.interval)
+	defer ticker.Stop()
+
+	for _, chatID := range recipients {
+		if !bc.store.ChatActive(bc.bot, chatID) {
+			r.Blocked++
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			r.Took = time.Since(start)
+			return r
+		case <-ticker.C:
+		}
+		err := bc.send(ctx, chatID, text)
+		switch {
+		case err == nil:
+			r.Sent++
+			bc.store.AddBotUser(bc.bot, chatID)
+		case blockedError(err):
+			r.Blocked++
+			if _, err := bc.store.SetChatInactive(bc.bot, chatID, true); err != nil {
+				bc.logger.Warn("save chat activity failed", zap.Int64("chatID", chatID), zap.Error(err))
+			}
+		default:
+			r.Failed++
+			bc.logger.Warn("broadcast message failed", zap.Int64("chatID", chatID), zap.Error(err))
+		}
+	}
+	r.Took = time.Since(start)
+	return r
+}
+
+// send delivers one message, waiting out a 429 once.
+func (bc *broadcaster) send(ctx context.Context, chatID int64, text string) error {
+	_, err := bc.sender.Send(tgbotapi.NewMessage(chatID, text))
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) || tgErr.Code != http.StatusTooManyRequests {
+		return err
+	}
+	wait := time.Duration(tgErr.RetryAfter) * time.Second
+	if wait <= 0 {
+		wait = time.Second
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+	}
+	_, err = bc.sender.Send(tgbotapi.NewMessage(chatID, text))
+	return err
+}
+
+// blockedError reports whether the user blocked the bot or deleted their
+// account, which Telegram answers with 403.
+func blockedError(err error) bool {
+	var tgErr *tgbotapi.Error
+	return errors.As(err, &tgErr) && tgErr.Code == http.StatusForbidden
+}
+
+// handleBroadcast sends a text to every user of this bot (see
+// storage.BotUsers), or with "test" to the admins only so it can be checked
+// first. One broadcast runs at a time; the
+// admin gets a report when it ends.
+func (b *Bot) handleBroadcast(ctx context.Context, m *tgbotapi.Message) {
+	text := strings.TrimSpace(m.CommandArguments())
+	test := false
+	if rest, ok := strings.CutPrefix(text, "test"); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\n') {
+		test, text = true, strings.TrimSpace(rest)
+	}
+	if text == "" {
+		b.reply(m.Chat.ID, broadcastUsage)
+		return
+	}
+
+	var recipients []int64
+	if test {
+		recipients = slices.Clone(b.settings.Load().Admins)
+	} else {
+		recipients = b.store.BotUsers(b.api.Self.UserName)
+	}
+	if !b.broadcastMu.TryLock() {
+		b.reply(m.Chat.ID, "Рассылка уже идёт, дождитесь отчёта.")
+		return
+	}
+	b.logger.Info("broadcast started", zap.Int64("userID", m.From.ID), zap.Bool("test", test), zap.Int("recipients", len(recipients)))
+	b.reply(m.Chat.ID, fmt.Sprintf("📣 Рассылка начата, получателей: %d.", len(recipients)))
+
+	go func() {
+		defer b.broadcastMu.Unlock()
+		bc := &broadcaster{bot: b.api.Self.UserName, sender: b.sender, store: b.store, logger: b.logger, interval: broadcastInterval}
+		report := bc.run(ctx, recipients, text)
+		b.logger.Info("broadcast finished", zap.Bool("test", test), zap.Int("sent", report.Sent),
+			zap.Int("blocked", report.Blocked), zap.Int("failed", report.Failed), zap.Duration("took", report.Took))
+		title := "📣 Рассылка завершена"
+		if test {
+			title = "📣 Тестовая рассылка завершена"
+		}
+		b.reply(m.Chat.ID, title+": "+report.String()+".")
+	}()
+}
@@ -0,0 +1,30 @@
+package telegram
+
+import (
+	"crypto/sha256"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+)
+
+// defaultCallbackTTL is how long inline buttons stay valid.
+const defaultCallbackTTL = 48 * time.Hour
+
+// button builds an inline button carrying a signed callback payload.
+func (b *Bot) button(label string, action callback.Action, args ...string) tgbotapi.InlineKeyboardButton {
+	data, err := b.codec.Encode(action, args...)
+	if err != nil {
+		// Only reachable with oversized args; log loudly so it is fixed at the source.
+		b.logger.Error("encode callback failed", zap.String("action", string(action)), zap.Strings("args", args), zap.Error(err))
+	}
+	return tgbotapi.NewInlineKeyboardButtonData(label, data)
+}
+
+// deriveSecret produces a signing key from the bot token when none is configured.
+func deriveSecret(token string) []byte {
+	sum := sha256.Sum256([]byte("ym-bot/callback:" + token))
+	return sum[:]
+}
@@ -0,0 +1,105 @@
+package telegram
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"ym-bot/internal/client/yandex"
+)
+
+const (
+	// maxCaptionLength is Telegram's limit for media captions.
+	maxCaptionLength = 1024
+)
+
+// CaptionData is the set of fields available to caption templates.
+type CaptionData struct {
+	// Title includes the version, e.g. "Help! (Remastered 2009)"; Version
+	// is that alone, empty for the original recording.
+	Title   string
+	Version string
+	// Artists are the performers as "A, B feat. C"; Composers are credited
+	// separately, mostly on classical tracks, and empty elsewhere.
+	Artists   string
+	Composers string
+	Album     string
+	// Genre is the album's genre id; Year, Disc and Number are 0 when unknown.
+	Genre    string
+	Year     int
+	Disc     int
+	Number   int
+	Duration string
+	// ISRC and AlbumArtist come from MusicBrainz and are empty without it.
+	ISRC        string
+	AlbumArtist string
+	// Link is the track's page on Yandex Music, DeepLink the bot link that
+	// fetches it again.
+	Link     string
+	DeepLink string
+	Bot      string
+	Codec    string
+	SizeMB   string
+	// SampleRate is "44.1 kHz" and the like, empty when unknown.
+	SampleRate string
+}
+
+// captioner renders captions from the configured template plus an optional attribution line.
+type captioner struct {
+	tmpl        *template.Template
+	attribution bool
+}
+
+// newCaptioner compiles tmpl; an empty template disables custom captions.
+func newCaptioner(tmpl string, attribution bool) (captioner, error) {
+	c := captioner{attribution: attribution}
+	if strings.TrimSpace(tmpl) == "" {
+		return c, nil
+	}
+	t, err := template.New("caption").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return c, fmt.Errorf("parse caption template: %w", err)
+	}
+	c.tmpl = t
+	return c, nil
+}
+
+// render returns the caption for data, or fallback when no template is configured.
+func (c captioner) render(data CaptionData, fallback string) (string, error) {
+	caption := fallback
+	if c.tmpl != nil {
+		var buf bytes.Buffer
+		if err := c.tmpl.Execute(&buf, data); err != nil {
+			return fallback, fmt.Errorf("render caption: %w", err)
+		}
+		caption = strings.TrimSpace(buf.String())
+	}
+	if c.attribution && data.Bot != "" {
+		if caption != "" {
+			caption += "\n"
+		}
+		caption += "via @" + data.Bot
+	}
+	return truncate(caption, maxCaptionLength), nil
+}
+
+func captionData(t yandex.Track, bot string) CaptionData {
+	return CaptionData{
+		Title:       t.FullTitle(),
+		Version:     t.Version,
+		Artists:     t.ArtistsString(),
+		Composers:   strings.Join(t.Credited(yandex.RoleComposer), ", "),
+		Album:       t.AlbumTitle,
+		Genre:       t.Genre,
+		Year:        t.Year,
+		Disc:        t.DiscNumber,
+		Number:      t.TrackNumber,
+		Duration:    t.DurationString(),
+		ISRC:        t.ISRC,
+		AlbumArtist: t.AlbumArtist,
+		Link:        t.URL(),
+		DeepLink:    trackDeepLink(bot, t),
+		Bot:         bot,
+	}
+}
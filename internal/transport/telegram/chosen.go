@@ -0,0 +1,303 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/cover"
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/translit"
+)
+
+// Result id prefixes of fast-mode cards, for chats and for groups, where
+// the card carries the party button, and of audio offered in groups. Audio
+// elsewhere goes by the bare track id.
+const (
+	cardPrefix       = "card:"
+	partyCardPrefix  = "party:"
+	groupAudioPrefix = "group:"
+)
+
+// inlineCard builds the fast-mode result for track: a card with the track's
+// metadata only, whose message gets the audio once it is sent (see
+// handleChosenInlineResult). The card needs a keyboard, or Telegram would
+// not tell which message to put the audio into.
+func (b *Bot) inlineCard(userID int64, track yandex.Track, group bool) tgbotapi.InlineQueryResultArticle {
+	id := cardPrefix + track.ID
+	if group {
+		id = partyCardPrefix + track.ID
+	}
+	track = music.Transliterate(track, b.transliteration(userID))
+	card := tgbotapi.NewInlineQueryResultArticle(id, track.FullTitle(), cardText(track, "⏳ Загружаем аудио…"))
+	card.Description = strings.TrimSuffix(track.ArtistsString()+" · "+track.DurationString(), " · ")
+	card.ThumbURL = cover.URL(track.CoverURI, cover.Inline)
+	card.ReplyMarkup = b.cardMarkup(track, group)
+	return card
+}
+
+// cardMarkup is the keyboard of a card and of the audio that replaces it:
+// the party button in groups, elsewhere a link to the track and a download
+// that puts the full file into the message (see replaceInline).
+func (b *Bot) cardMarkup(track yandex.Track, group bool) *tgbotapi.InlineKeyboardMarkup {
+	if group {
+		return b.partyMarkup(track.ID)
+	}
+	kb := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonURL("🎵 Яндекс Музыка", track.URL()),
+		b.button("⬇️ Скачать", callback.ActionDownload, track.ID),
+	))
+	return &kb
+}
+
+// cardText is the text of a card's message: the track and its status.
+func cardText(track yandex.Track, status string) string {
+	return fmt.Sprintf("🎵 %s — %s\n%s", track.ArtistsString(), track.FullTitle(), status)
+}
+
+// handleChosenInlineResult handles a result a user sent: it counts the pick
+// for ranking, puts the audio into a fast-mode card and, with an upgrade
+// chat configured, swaps the audio Telegram fetched from a URL for a
+// full-quality upload. Only messages with a keyboard can be edited later.
+// Telegram reports picks only with inline feedback enabled in BotFather.
+func (b *Bot) handleChosenInlineResult(ctx context.Context, r *tgbotapi.ChosenInlineResult) {
+	trackID, card, group := chosenTrack(r.ResultID)
+	if trackID == "" {
+		return
+	}
+	b.store.RecordPick(r.From.ID, r.Query, trackID, time.Now())
+	if r.InlineMessageID == "" {
+		return
+	}
+	upgrade := b.settings.Load().InlineUpgradeChat
+	if !card && upgrade == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+	defer cancel()
+	tracks, err := b.musicService.Tracks(ctx, []string{trackID})
+	if err != nil || len(tracks) == 0 {
+		b.logger.Warn("chosen track unavailable", zap.String("trackID", trackID), zap.Error(err))
+		if card {
+			b.failCard(r.InlineMessageID, yandex.Track{ID: trackID}, group)
+		}
+		return
+	}
+	track := tracks[0]
+	if card && !b.fillCard(ctx, r, track, group) {
+		return
+	}
+	if upgrade != 0 {
+		b.upgradeInline(upgrade, r, track, group)
+	}
+}
+
+// chosenTrack tells the track of a chosen result, whether it was a card and
+// whether it was offered in a group. Track ids are numeric; typeahead,
+// recent search, clip and maintenance results are no tracks and give "".
+func chosenTrack(resultID string) (trackID string, card, group bool) {
+	if id, ok := strings.CutPrefix(resultID, cardPrefix); ok {
+		return id, true, false
+	}
+	if id, ok := strings.CutPrefix(resultID, partyCardPrefix); ok {
+		return id, true, true
+	}
+	if id, ok := strings.CutPrefix(resultID, groupAudioPrefix); ok {
+		return id, false, true
+	}
+	if resultID == "" || strings.Trim(resultID, "0123456789") != "" {
+		return "", false, false
+	}
+	return resultID, false, false
+}
+
+// fillCard puts the audio into the message a card was sent as: the direct
+// URL is resolved only now, for the one track picked, and Telegram replaces
+// the card's text with the audio fetched from it. If that fails, the
+// message says so.
+func (b *Bot) fillCard(ctx context.Context, r *tgbotapi.ChosenInlineResult, track yandex.Track, group bool) bool {
+	link, ok := b.inlineLink(ctx, track.ID, time.Now().Add(inlineProbeTimeout))
+	if !ok {
+		b.failCard(r.InlineMessageID, track, group)
+		return false
+	}
+	shown := music.Transliterate(track, b.transliteration(r.From.ID))
+	audio := tgbotapi.NewInputMediaAudio(tgbotapi.FileURL(link.URL))
+	audio.Caption = b.caption(captionData(shown, b.api.Self.UserName), "")
+	audio.Performer = shown.ArtistsString()
+	audio.Title = shown.FullTitle()
+	audio.Duration = track.DurationSeconds
+	if err := b.editInlineAudio(r.InlineMessageID, audio, track, group); err != nil {
+		b.logger.Warn("put chosen audio failed", zap.String("trackID", track.ID), zap.Error(err))
+		b.failCard(r.InlineMessageID, track, group)
+		return false
+	}
+	b.logger.Debug("chosen audio sent", zap.String("trackID", track.ID), zap.Int64("userID", r.From.ID))
+	return true
+}
+
+// upgradeInline replaces the audio of a sent inline message with a
+// full-quality file the bot uploads to chatID. File ids are the uploading
+// bot's own, so the upload is kept per bot, track and script, and each is
+// downloaded and uploaded once: that download is a worker pool job under the
+// same key, on the picking user's quota. Out of quota, or while the track is
+// being uploaded for another pick, the audio stays as Telegram fetched it.
+// A kept upload Telegram no longer knows is uploaded again, from the archive
+// when the track is there.
+func (b *Bot) upgradeInline(chatID int64, r *tgbotapi.ChosenInlineResult, track yandex.Track, group bool) {
+	userID := r.From.ID
+	script := b.transliteration(userID)
+	key := track.ID
+	if script != translit.None {
+		key += "/" + string(script)
+	}
+	uploadKey := b.api.Self.UserName + "/" + key
+	reupload := false
+	if fileID, ok := b.store.Upload(uploadKey); ok {
+		if err := b.swapInlineAudio(r, fileID, track, script, group); !invalidFileID(err) {
+			return
+		}
+		b.logger.Info("kept upload invalidated", zap.String("trackID", track.ID), zap.String("fileID", fileID))
+		b.store.ForgetUpload(uploadKey)
+		reupload = true
+	}
+
+	_, err := b.reserveDownload(userID, func(reservedAt time.Time) error {
+		job := func(ctx context.Context) {
+			defer func() {
+				// Pool workers run jobs in their own goroutines; a crash would kill the bot.
+				if p := recover(); p != nil {
+					b.logPanic(p, "inline upgrade", zap.String("trackID", track.ID), zap.Int64("userID", userID))
+					b.store.ReleaseQuota(userID, reservedAt)
+				}
+			}()
+			ctx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+			defer cancel()
+			fileID, ok := b.uploadForInline(ctx, chatID, track.ID, script, reupload)
+			if !ok {
+				b.store.ReleaseQuota(userID, reservedAt)
+				return
+			}
+			b.store.SetUpload(uploadKey, fileID)
+			_ = b.swapInlineAudio(r, fileID, track, script, group)
+		}
+		if b.pool == nil {
+			go job(b.runCtx)
+			return nil
+		}
+		_, err := b.pool.Submit("upgrade:"+uploadKey, job)
+		return err
+	})
+	if err != nil {
+		b.logger.Debug("inline upgrade skipped", zap.String("trackID", track.ID), zap.Int64("userID", userID), zap.Error(err))
+	}
+}
+
+// swapInlineAudio puts the uploaded fileID into the inline message of r.
+func (b *Bot) swapInlineAudio(r *tgbotapi.ChosenInlineResult, fileID string, track yandex.Track, script translit.Direction, group bool) error {
+	shown := music.Transliterate(track, script)
+	audio := tgbotapi.NewInputMediaAudio(tgbotapi.FileID(fileID))
+	audio.Caption = b.caption(captionData(shown, b.api.Self.UserName), "")
+	if err := b.editInlineAudio(r.InlineMessageID, audio, track, group); err != nil {
+		b.logger.Warn("upgrade inline audio failed", zap.String("trackID", track.ID), zap.Error(err))
+		return err
+	}
+	b.logger.Debug("inline audio upgraded", zap.String("trackID", track.ID), zap.Int64("userID", r.From.ID))
+	return nil
+}
+
+// invalidFileID reports whether Telegram rejected a file id it no longer
+// knows, such as an upload of a bot whose files were reset.
+func invalidFileID(err error) bool {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return false
+	}
+	msg := strings.ToLower(tgErr.Message)
+	return strings.Contains(msg, "file identifier") || strings.Contains(msg, "file_reference")
+}
+
+// uploadForInline downloads a track and uploads it to chatID as audio,
+// returning its file id. Files that would not go as audio, such as lossless
+// or oversized ones, are not uploaded: an inline message cannot switch to a
+// document. With archived, the archive's copy is tried before Yandex.
+func (b *Bot) uploadForInline(ctx context.Context, chatID int64, trackID string, script translit.Direction, archived bool) (string, bool) {
+	var dl music.Download
+	var err error
+	restored := false
+	if archived && b.musicService.Archives() {
+		dl, err = b.musicService.Restore(ctx, trackID)
+		restored = err == nil
+		if !restored {
+			b.logger.Debug("inline upgrade not restored from archive", zap.String("trackID", trackID), zap.Error(err))
+		}
+	}
+	if !restored {
+		dl, err = b.musicService.StreamTrack(ctx, trackID, 0)
+	}
+	if err != nil {
+		b.logger.Warn("download for inline upgrade failed", zap.String("trackID", trackID), zap.Error(err))
+		return "", false
+	}
+	if script != translit.None {
+		dl = b.musicService.Relabel(ctx, dl, music.Transliterate(dl.Track, script))
+	}
+	defer dl.Close()
+	if !dl.PlaysInline() || dl.Size > maxAudioSize {
+		b.logger.Debug("inline upgrade skipped", zap.String("trackID", trackID), zap.String("codec", dl.Codec), zap.Int64("size", dl.Size))
+		return "", false
+	}
+
+	shown := music.Transliterate(dl.Track, script)
+	audio := tgbotapi.NewAudio(chatID, uploadFile(dl))
+	audio.Duration = dl.Track.DurationSeconds
+	audio.Performer = shown.ArtistsString()
+	audio.Title = shown.FullTitle()
+	audio.Thumb = b.coverFile(ctx, dl.Track, cover.Audio)
+	msg, err := b.sender.Send(audio)
+	if err != nil || msg.Audio == nil {
+		b.logger.Warn("upload for inline upgrade failed", zap.String("trackID", trackID), zap.Int64("chatID", chatID), zap.Error(err))
+		return "", false
+	}
+	return msg.Audio.FileID, true
+}
+
+// editInlineAudio replaces the content of an inline message with audio,
+// keeping the card's keyboard.
+func (b *Bot) editInlineAudio(inlineMessageID string, audio tgbotapi.InputMediaAudio, track yandex.Track, group bool) error {
+	edit := tgbotapi.EditMessageMediaConfig{
+		BaseEdit: tgbotapi.BaseEdit{
+			InlineMessageID: inlineMessageID,
+			ReplyMarkup:     b.cardMarkup(track, group),
+		},
+		Media: audio,
+	}
+	_, err := b.sender.Request(edit)
+	return err
+}
+
+// failCard tells in a card's message that its audio could not be sent.
+func (b *Bot) failCard(inlineMessageID string, track yandex.Track, group bool) {
+	text := "⚠️ Не удалось загрузить трек, попробуйте ещё раз."
+	if track.Title != "" {
+		text = cardText(track, text)
+	}
+	edit := tgbotapi.EditMessageTextConfig{
+		BaseEdit: tgbotapi.BaseEdit{
+			InlineMessageID: inlineMessageID,
+			ReplyMarkup:     b.cardMarkup(track, group),
+		},
+		Text: text,
+	}
+	if _, err := b.sender.Request(edit); err != nil {
+		b.logger.Warn("edit chosen card failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,26 @@
+package telegram
+
+import "testing"
+
+func TestChosenTrack(t *testing.T) {
+	cases := []struct {
+		resultID    string
+		trackID     string
+		card, group bool
+	}{
+		{"33311009", "33311009", false, false},
+		{"card:33311009", "33311009", true, false},
+		{"party:33311009", "33311009", true, true},
+		{"group:33311009", "33311009", false, true},
+		// Typeahead, recent searches, clips and maintenance notices.
+		{"s:band", "", false, false},
+		{"v3", "", false, false},
+		{"", "", false, false},
+	}
+	for _, tc := range cases {
+		id, card, group := chosenTrack(tc.resultID)
+		if id != tc.trackID || card != tc.card || group != tc.group {
+			t.Errorf("chosenTrack(%q) = %q, %v, %v; want %q, %v, %v", tc.resultID, id, card, group, tc.trackID, tc.card, tc.group)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+)
+
+// Tabs of the search results message, the second ActionPage argument. An
+// empty tab is the paged track list, as on buttons sent before the tabs.
+const (
+	tabOverview  = "o"
+	tabTracks    = "t"
+	tabAlbums    = "a"
+	tabArtists   = "r"
+	tabPlaylists = "p"
+	// tabAlbum lists the tracks of the album in the third argument.
+	tabAlbum = "A"
+	// tabArtist searches the tracks of the artist in the third argument.
+	tabArtist = "R"
+)
+
+// overviewTop is how many results of each section the overview shows.
+const overviewTop = 3
+
+// maxCreditButtons is how many performers of the best match get a button.
+const maxCreditButtons = 3
+
+// searchTabs lists the switchable sections in order.
+var searchTabs = []struct{ tab, icon, title string }{
+	{tabOverview, "⭐", "Всё"},
+	{tabTracks, "🎵", "Треки"},
+	{tabAlbums, "💿", "Альбомы"},
+	{tabArtists, "👤", "Артисты"},
+	{tabPlaylists, "📃", "Плейлисты"},
+}
+
+// tabRow switches between sections; the current one is spelled out, the
+// rest are icons to fit one row.
+func (b *Bot) tabRow(current string) []tgbotapi.InlineKeyboardButton {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(searchTabs))
+	for _, t := range searchTabs {
+		label := t.icon
+		if t.tab == current {
+			label = "· " + t.icon + " " + t.title + " ·"
+		}
+		row = append(row, b.button(label, callback.ActionPage, "0", t.tab))
+	}
+	return row
+}
+
+// sectionMenu renders a tab of the combined results of the user for query.
+func (b *Bot) sectionMenu(ctx context.Context, req menuRequest, query, tab string) (menuScreen, error) {
+	res, err := b.musicService.SearchAll(ctx, query, b.searchOrder(req.userID), searchLimit)
+	if err != nil {
+		b.logger.Warn("combined search failed", zap.String("query", query), zap.String("tab", tab), zap.Error(err))
+		return menuScreen{}, menuAlert("Не удалось загрузить результаты :(")
+	}
+	if res.Corrected && res.Correction != "" {
+		query = res.Correction
+	}
+
+	var (
+		screen   menuScreen
+		numbered []yandex.Track
+	)
+	switch tab {
+	case tabAlbums:
+		screen.text, screen.keyboard = b.renderAlbums(query, res.Albums)
+	case tabArtists:
+		screen.text, screen.keyboard = b.renderArtists(query, res.Artists)
+	case tabPlaylists:
+		screen.text, screen.keyboard = b.renderSearchPlaylists(query, res.Playlists)
+	default:
+		screen.text, screen.keyboard = b.renderOverview(query, res)
+		numbered = res.Tracks[:min(overviewTop, len(res.Tracks))]
+	}
+	b.rememberPicks(req.message, 0, numbered)
+	return screen, nil
+}
+
+// renderOverview shows the best match and the top of every section.
+func (b *Bot) renderOverview(query string, res yandex.Combined) (string, tgbotapi.InlineKeyboardMarkup) {
+	var sb strings.Builder
+	sb.WriteString(searchHeader + query + "\n")
+	var rows [][]tgbotapi.InlineKeyboardButton
+
+	if line, button, ok := b.bestMatch(res.Best); ok {
+		sb.WriteString("\n⭐ Лучшее совпадение: " + line + "\n")
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(button))
+		if res.Best.Type == yandex.ResultTrack {
+			if row := b.creditRow(res.Best.Track); len(row) > 0 {
+				rows = append(rows, row)
+			}
+		}
+	}
+	restricted := false
+	if len(res.Tracks) > 0 {
+		sb.WriteString("\n🎵 Треки:")
+		for i, t := range res.Tracks[:min(overviewTop, len(res.Tracks))] {
+			fmt.Fprintf(&sb, "\n%d. %s", i+1, trackLine(t))
+			if mark := restrictionMark(t); mark != "" {
+				sb.WriteString(" " + mark)
+				restricted = true
+			}
+		}
+		sb.WriteString("\n")
+	}
+	if len(res.Albums) > 0 {
+		sb.WriteString("\n💿 Альбомы:")
+		for _, a := range res.Albums[:min(overviewTop, len(res.Albums))] {
+			sb.WriteString("\n• " + albumLine(a))
+		}
+		sb.WriteString("\n")
+	}
+	if len(res.Artists) > 0 {
+		sb.WriteString("\n👤 Артисты:")
+		for _, a := range res.Artists[:min(overviewTop, len(res.Artists))] {
+			sb.WriteString("\n• " + a.Name)
+		}
+		sb.WriteString("\n")
+	}
+	if len(res.Playlists) > 0 {
+		sb.WriteString("\n📃 Плейлисты:")
+		for _, p := range res.Playlists[:min(overviewTop, len(res.Playlists))] {
+			sb.WriteString("\n• " + playlistLine(p))
+		}
+		sb.WriteString("\n")
+	}
+	if restricted {
+		sb.WriteString("\n" + restrictionLegend)
+	}
+
+	rows = append(rows, b.tabRow(tabOverview))
+	return strings.TrimRight(sb.String(), "\n"), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// bestMatch renders the best match as a line and a button that opens it.
+func (b *Bot) bestMatch(best yandex.BestMatch) (string, tgbotapi.InlineKeyboardButton, bool) {
+	switch best.Type {
+	case yandex.ResultTrack:
+		line := "🎵 " + trackLine(best.Track)
+		return line, b.button(truncate("⬇️ "+best.Track.ArtistsString()+" — "+best.Track.FullTitle(), maxButtonLabel), callback.ActionDownload, best.Track.ID), true
+	case yandex.ResultAlbum:
+		line := "💿 " + albumLine(best.Album)
+		return line, b.button(truncate("💿 "+best.Album.Title, maxButtonLabel), callback.ActionPage, "0", tabAlbum, best.Album.ID), true
+	case yandex.ResultArtist:
+		line := "👤 " + best.Artist.Name
+		return line, b.button(truncate("👤 "+best.Artist.Name, maxButtonLabel), callback.ActionPage, "0", tabArtist, best.Artist.ID), true
+	case yandex.ResultPlaylist:
+		if best.Playlist.URL() == "" {
+			return "", tgbotapi.InlineKeyboardButton{}, false
+		}
+		line := "📃 " + playlistLine(best.Playlist)
+		return line, tgbotapi.NewInlineKeyboardButtonURL(truncate("📃 "+best.Playlist.Title, maxButtonLabel), best.Playlist.URL()), true
+	}
+	return "", tgbotapi.InlineKeyboardButton{}, false
+}
+
+// creditRow opens the performers of t, featured ones included, up to
+// maxCreditButtons of them.
+func (b *Bot) creditRow(t yandex.Track) []tgbotapi.InlineKeyboardButton {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, c := range t.Credits {
+		if c.ID == "" || c.Role == yandex.RoleComposer || len(row) == maxCreditButtons {
+			continue
+		}
+		row = append(row, b.button(truncate("👤 "+c.Name, maxButtonLabel), callback.ActionPage, "0", tabArtist, c.ID))
+	}
+	return row
+}
+
+func (b *Bot) renderAlbums(query string, albums []yandex.Album) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := searchHeader + query + "\n\n💿 Альбомы:"
+	if len(albums) == 0 {
+		text += "\n\nАльбомов не нашлось."
+	}
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(albums)+1)
+	for _, a := range albums {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate(albumLine(a), maxButtonLabel), callback.ActionPage, "0", tabAlbum, a.ID),
+		))
+	}
+	rows = append(rows, b.tabRow(tabAlbums))
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (b *Bot) renderArtists(query string, artists []yandex.Artist) (string, tgbotapi.InlineKeyboardMarkup) {
+	text := searchHeader + query + "\n\n👤 Артисты:"
+	if len(artists) == 0 {
+		text += "\n\nАртистов не нашлось."
+	}
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(artists)+1)
+	for _, a := range artists {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate(a.Name, maxButtonLabel), callback.ActionPage, "0", tabArtist, a.ID),
+		))
+	}
+	rows = append(rows, b.tabRow(tabArtists))
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// renderSearchPlaylists links playlists to their web pages: they belong to
+// other users, so the bot cannot list their tracks.
+func (b *Bot) renderSearchPlaylists(query string, playlists []yandex.Playlist) (string, tgbotapi.InlineKeyboardMarkup) {
+	var sb strings.Builder
+	sb.WriteString(searchHeader + query + "\n\n📃 Плейлисты:")
+	if len(playlists) == 0 {
+		sb.WriteString("\n\nПлейлистов не нашлось.")
+	}
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(playlists)+1)
+	for _, p := range playlists {
+		sb.WriteString("\n• " + playlistLine(p))
+		if link := p.URL(); link != "" {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonURL(truncate("📃 "+p.Title, maxButtonLabel), link),
+			))
+		}
+	}
+	rows = append(rows, b.tabRow(tabPlaylists))
+	return sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// albumMenu pages through the tracks of an album found by query, shown in msg.
+func (b *Bot) albumMenu(ctx context.Context, msg *tgbotapi.Message, query, albumID string, offset int) (menuScreen, error) {
+	album, err := b.musicService.Album(ctx, albumID)
+	if err != nil {
+		b.logger.Warn("album failed", zap.String("albumID", albumID), zap.Error(err))
+		return menuScreen{}, menuAlert(presentError(err, errGeneric).String())
+	}
+	offset = min(offset, max(len(album.Tracks)-1, 0))
+	page := album.Tracks[offset:min(offset+searchLimit, len(album.Tracks))]
+	b.rememberPicks(msg, offset, page)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s%s\n\n💿 %s — треков: %d", searchHeader, query, albumLine(album), len(album.Tracks))
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(page)+2)
+	for i, t := range page {
+		label := fmt.Sprintf("%d. %s", offset+i+1, t.FullTitle())
+		if mark := restrictionMark(t); mark != "" {
+			label = mark + " " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate(label, maxButtonLabel), callback.ActionDownload, t.ID),
+		))
+	}
+	var nav []tgbotapi.InlineKeyboardButton
+	if offset > 0 {
+		nav = append(nav, b.button("◀ Назад", callback.ActionPage, strconv.Itoa(max(offset-searchLimit, 0)), tabAlbum, albumID))
+	}
+	if offset+searchLimit < len(album.Tracks) {
+		nav = append(nav, b.button("Далее ▶", callback.ActionPage, strconv.Itoa(offset+searchLimit), tabAlbum, albumID))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, b.tabRow(tabAlbums))
+	return menuScreen{text: sb.String(), keyboard: tgbotapi.NewInlineKeyboardMarkup(rows...)}, nil
+}
+
+// artistQuery resolves an artist of query's results to a search for their
+// tracks; the results message then carries the artist's name as its query.
+func (b *Bot) artistQuery(ctx context.Context, query, artistID string) (string, error) {
+	res, err := b.musicService.SearchAll(ctx, query, music.OrderRelevance, searchLimit)
+	if err != nil {
+		b.logger.Warn("combined search failed", zap.String("query", query), zap.Error(err))
+		return "", menuAlert("Не удалось загрузить результаты :(")
+	}
+	artists := res.Artists
+	if res.Best.Type == yandex.ResultArtist {
+		artists = append(artists, res.Best.Artist)
+	}
+	for _, a := range artists {
+		if a.ID == artistID {
+			return a.Name, nil
+		}
+	}
+	// Performers of a track, featured ones too, are opened from its credits.
+	tracks := res.Tracks
+	if res.Best.Type == yandex.ResultTrack {
+		tracks = append(tracks, res.Best.Track)
+	}
+	for _, t := range tracks {
+		for _, c := range t.Credits {
+			if c.ID == artistID {
+				return c.Name, nil
+			}
+		}
+	}
+	return "", menuAlert("Артист пропал из результатов, повторите поиск.")
+}
+
+func trackLine(t yandex.Track) string {
+	line := t.ArtistsString() + " — " + t.FullTitle()
+	if d := t.DurationString(); d != "" {
+		line += " (" + d + ")"
+	}
+	return line
+}
+
+func albumLine(a yandex.Album) string {
+	line := a.Title
+	if len(a.Artists) > 0 {
+		line += " — " + strings.Join(a.Artists, ", ")
+	}
+	if a.Year > 0 {
+		line += fmt.Sprintf(" (%d)", a.Year)
+	}
+	return line
+}
+
+func playlistLine(p yandex.Playlist) string {
+	owner := p.OwnerName
+	if owner == "" {
+		owner = p.Owner
+	}
+	if owner == "" {
+		return fmt.Sprintf("%s (%d)", p.Title, p.TrackCount)
+	}
+	return fmt.Sprintf("%s — %s (%d)", p.Title, owner, p.TrackCount)
+}
@@ -0,0 +1,102 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const startText = "Привет! Набери @%s <трек или артист> в любом чате, чтобы найти музыку.\n" +
+	"Или просто пришли мне название — покажу результаты здесь; номер из списка (например, 3) скачает трек.\n" +
+	"/settings — настройки отправки и сортировки поиска; разово — !new, !popular, !short, !long в запросе.\n" +
+	"-live, -remix, -remaster в запросе убирают концертные версии, ремиксы и ремастеры.\n" +
+	"/recent — последние поиски с кнопками повтора.\n" +
+	"/quota — сколько треков осталось на сегодня.\n" +
+	"/export [csv|json] — история загрузок файлом.\n" +
+	"/mystats [год] — ваша статистика; с годом — итоги года картинкой.\n" +
+	"/playlists — подборки редакции и плейлисты по настроению, занятиям и жанрам.\n" +
+	"/genres — жанры и их популярные треки; в поиске работает genre:<жанр>.\n" +
+	"/party — в группе: общая очередь треков из inline-поиска с голосованием за пропуск.\n" +
+	"/quiz — угадай мелодию по 15-секундному фрагменту; /quiz top — счёт чата.\n" +
+	"/karaoke <трек> — строки песни в такт музыке после отсчёта; /karaoke stop — остановить.\n" +
+	"/podcast <ссылка> — выпуски подкаста и подписка на новые; без ссылки — подписки чата.\n" +
+	"/id <id трека>, /isrc <код> — скачать трек по id Яндекс Музыки или коду ISRC.\n" +
+	"/groupsettings — в группе: язык справки, доступные команды, качество, тихие часы и фильтр 18+ (для администраторов чата).\n" +
+	"/feedback <текст> — написать администраторам.\n" +
+	"/forgetme — удалить свои данные из бота.\n" +
+	"Пришли CSV (исполнитель,название) или экспорт Spotify — найду эти треки."
+
+// startTextEN is the help for group chats that chose English in /groupsettings.
+const startTextEN = "Hi! Type @%s <track or artist> in any chat to find music.\n" +
+	"Or message me a title in private and I'll show the results there; reply with a number from the list (e.g. 3) to download that track.\n" +
+	"/settings — delivery settings and search order; !new, !popular, !short, !long in a query sort just that search.\n" +
+	"-live, -remix, -remaster in a query leave out live versions, remixes and remasters.\n" +
+	"/recent — recent searches with buttons to repeat them.\n" +
+	"/quota — how many tracks are left for today.\n" +
+	"/export [csv|json] — download history as a file.\n" +
+	"/mystats [year] — your stats; with a year, a year-in-review card.\n" +
+	"/playlists — editorial picks and playlists by mood, activity and genre.\n" +
+	"/genres — genres and their top tracks; genre:<genre> works in searches.\n" +
+	"/party — in groups: a shared queue of tracks picked via inline search, with vote-to-skip.\n" +
+	"/quiz — guess the song from a 15-second clip; /quiz top — the chat's scores.\n" +
+	"/karaoke <track> — the song's lines in time with the music after a countdown; /karaoke stop — stop.\n" +
+	"/podcast <link> — a podcast's episodes and alerts on new ones; without a link — the chat's subscriptions.\n" +
+	"/id <track id>, /isrc <code> — download a track by Yandex Music id or ISRC.\n" +
+	"/groupsettings — in groups: help language, allowed commands, quality, quiet hours and explicit filter (chat admins only).\n" +
+	"/feedback <text> — write to the admins.\n" +
+	"/forgetme — erase your data from the bot.\n" +
+	"Send me a CSV (artist,title) or a Spotify export and I'll find those tracks."
+
+func (b *Bot) handleMessage(ctx context.Context, m *tgbotapi.Message) {
+	if m.From == nil {
+		return
+	}
+	if m.Chat.IsPrivate() {
+		b.store.AddBotUser(b.api.Self.UserName, m.From.ID)
+	}
+	if state, ok := b.underMaintenance(m.From.ID); ok {
+		// Groups only hear back on commands, as with searches.
+		if m.Chat.IsPrivate() || m.IsCommand() {
+			b.reply(m.Chat.ID, b.maintenanceText(state))
+		}
+		return
+	}
+	if m.Document != nil && m.Chat.IsPrivate() {
+		b.handleImport(ctx, m)
+		return
+	}
+	if m.ReplyToMessage != nil && b.handleFeedbackReply(m) {
+		return
+	}
+	if !m.Chat.IsPrivate() && b.handlePartyPick(ctx, m) {
+		return
+	}
+	if !m.IsCommand() {
+		// Plain text is treated as a search only in private chats to keep groups quiet.
+		if m.Chat.IsPrivate() && !b.handlePick(ctx, m) {
+			b.handleTextSearch(ctx, m)
+		}
+		return
+	}
+
+	b.runCommand(ctx, m)
+}
+
+func (b *Bot) handleStart(ctx context.Context, m *tgbotapi.Message) {
+	b.notifyRegistered(m)
+	if id, ok := trackIDFromStart(m.CommandArguments()); ok {
+		b.handleTrackLink(ctx, m, id)
+		return
+	}
+	b.reply(m.Chat.ID, fmt.Sprintf(b.startTextFor(m.Chat.ID), b.api.Self.UserName))
+}
+
+func (b *Bot) reply(chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.DisableWebPagePreview = !b.linkPreviews(chatID)
+	if _, err := b.sender.Send(msg); err != nil {
+		b.logger.Warn("send message failed", zap.Int64("chatID", chatID), zap.Error(err))
+	}
+}
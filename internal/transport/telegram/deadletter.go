@@ -0,0 +1,248 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/storage"
+)
+
+const (
+	// redeliverInterval is the retry worker tick and the first backoff step.
+	redeliverInterval = time.Minute
+	// maxRedeliverBackoff caps the doubling delay between attempts.
+	maxRedeliverBackoff = time.Hour
+	// maxDeliveryAttempts bounds uploads (the first one included) before giving up.
+	maxDeliveryAttempts = 6
+
+	redeliverUsage = "Использование: /redeliver [id|all]"
+)
+
+// deadLetter keeps a download whose upload failed for a later retry. It
+// reports false when the error is permanent and the download should be dropped.
+func (b *Bot) deadLetter(req downloadRequest, dl music.Download, sendErr error) bool {
+	if permanentSendError(sendErr) {
+		return false
+	}
+	now := time.Now()
+	e, err := b.store.AddDeadLetter(storage.DeadLetter{
+		Bot:         b.api.Self.UserName,
+		UserID:      req.userID,
+		ChatID:      req.chatID,
+		TrackID:     req.trackID,
+		Title:       fmt.Sprintf("%s — %s", dl.Track.ArtistsString(), dl.Track.FullTitle()),
+		Path:        dl.Path,
+		Codec:       dl.Codec,
+		ReservedAt:  req.reservedAt,
+		FailedAt:    now,
+		Attempts:    1,
+		LastError:   sendErr.Error(),
+		NextAttempt: now.Add(redeliverBackoff(1)),
+		InlineID:    req.inlineID,
+		Group:       req.group,
+	})
+	if err != nil {
+		b.logger.Warn("store dead letter failed", zap.String("trackID", req.trackID), zap.Error(err))
+		return false
+	}
+	b.logger.Info("delivery deferred", zap.String("id", e.ID), zap.String("trackID", req.trackID), zap.Error(sendErr))
+	return true
+}
+
+// runRedelivery retries dead letters as their backoff expires until ctx is done.
+func (b *Bot) runRedelivery(ctx context.Context) {
+	ticker := time.NewTicker(redeliverInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Deploys usually happen under maintenance; retry once it ends.
+			if !b.store.Maintenance().On {
+				b.redeliverPending(ctx, "", false)
+			}
+		}
+	}
+}
+
+// redeliverPending retries due entries (all of them with force, or only the
+// one with id when set) and returns how many were delivered and how many failed.
+func (b *Bot) redeliverPending(ctx context.Context, id string, force bool) (delivered, failed int) {
+	b.redeliverMu.Lock()
+	defer b.redeliverMu.Unlock()
+
+	now := time.Now()
+	for _, e := range b.store.DeadLetters(b.api.Self.UserName) {
+		if ctx.Err() != nil {
+			break
+		}
+		if id != "" && e.ID != id {
+			continue
+		}
+		if !force && now.Before(e.NextAttempt) {
+			continue
+		}
+		if b.redeliver(ctx, e) {
+			delivered++
+		} else {
+			failed++
+		}
+	}
+	return delivered, failed
+}
+
+// redeliver uploads the kept file again, downloading the track anew if the
+// file is gone (e.g. the temp dir did not survive a restart) or the first
+// attempt was streamed.
+func (b *Bot) redeliver(ctx context.Context, e storage.DeadLetter) bool {
+	ctx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+	defer cancel()
+
+	entry := storage.AuditEntry{At: time.Now(), Bot: e.Bot, UserID: e.UserID, ChatID: e.ChatID, TrackID: e.TrackID, Title: e.Title}
+	dl, err := b.musicService.Reopen(ctx, e.TrackID, e.Path, e.Codec)
+	if err != nil {
+		dl, err = b.musicService.DownloadTrack(ctx, e.TrackID)
+		if err != nil {
+			b.recordAudit(entry, storage.AuditFailed, err)
+			b.retryLater(e, err)
+			return false
+		}
+		dl = b.musicService.Process(ctx, dl, b.audioFilters(e.UserID))
+		e.Path = dl.Path
+	}
+	entry.Bytes = dl.Size
+
+	sent, err := b.sender.Send(b.buildDelivery(ctx, e.ChatID, e.UserID, dl, true))
+	if err != nil {
+		b.recordAudit(entry, storage.AuditSendFailed, err)
+		b.retryLater(e, err)
+		return false
+	}
+	b.recordAudit(entry, storage.AuditRedelivered, nil)
+	if e.InlineID != "" {
+		b.replaceInline(e.InlineID, sent, e.TrackID, e.Group)
+	}
+
+	_ = dl.Close()
+	if err := b.store.RemoveDeadLetter(e.ID); err != nil {
+		b.logger.Warn("remove dead letter failed", zap.String("id", e.ID), zap.Error(err))
+	}
+	b.store.RecordDownload(e.UserID, historyEntry(e.TrackID, dl.Track))
+	b.logger.Info("redelivered", zap.String("id", e.ID), zap.String("trackID", e.TrackID), zap.Int("attempts", e.Attempts+1))
+	return true
+}
+
+// retryLater backs the entry off, or drops it and returns the quota once
+// attempts run out or the error is permanent.
+func (b *Bot) retryLater(e storage.DeadLetter, cause error) {
+	e.Attempts++
+	if e.Attempts >= maxDeliveryAttempts || permanentSendError(cause) {
+		// Streamed uploads leave no file behind.
+		if e.Path != "" {
+			_ = os.RemoveAll(filepath.Dir(e.Path))
+		}
+		if err := b.store.RemoveDeadLetter(e.ID); err != nil {
+			b.logger.Warn("remove dead letter failed", zap.String("id", e.ID), zap.Error(err))
+		}
+		b.store.ReleaseQuota(e.UserID, e.ReservedAt)
+		b.logger.Warn("redelivery abandoned", zap.String("id", e.ID), zap.String("trackID", e.TrackID),
+			zap.Int("attempts", e.Attempts), zap.Error(cause))
+		b.reply(e.ChatID, fmt.Sprintf("Не удалось отправить «%s». Запросите трек заново.", e.Title))
+		return
+	}
+
+	_, err := b.store.UpdateDeadLetter(e.ID, func(d *storage.DeadLetter) {
+		d.Path = e.Path
+		d.Attempts = e.Attempts
+		d.LastError = cause.Error()
+		d.NextAttempt = time.Now().Add(redeliverBackoff(e.Attempts))
+	})
+	if err != nil {
+		b.logger.Warn("update dead letter failed", zap.String("id", e.ID), zap.Error(err))
+	}
+	b.logger.Debug("redelivery failed", zap.String("id", e.ID), zap.Int("attempts", e.Attempts), zap.Error(cause))
+}
+
+// handleRedeliver lists pending dead letters or retries them right away.
+func (b *Bot) handleRedeliver(ctx context.Context, m *tgbotapi.Message) {
+	args := strings.Fields(m.CommandArguments())
+	if len(args) > 1 {
+		b.reply(m.Chat.ID, redeliverUsage)
+		return
+	}
+
+	if len(args) == 0 {
+		b.reply(m.Chat.ID, renderDeadLetters(b.store.DeadLetters(b.api.Self.UserName)))
+		return
+	}
+
+	id := args[0]
+	if id == "all" {
+		id = ""
+	}
+	delivered, failed := b.redeliverPending(ctx, id, true)
+	if delivered+failed == 0 {
+		b.reply(m.Chat.ID, "Нечего доставлять.")
+		return
+	}
+	b.reply(m.Chat.ID, fmt.Sprintf("Доставлено: %d, не удалось: %d.", delivered, failed))
+}
+
+func renderDeadLetters(entries []storage.DeadLetter) string {
+	if len(entries) == 0 {
+		return "Недоставленных загрузок нет."
+	}
+
+	const shown = 20
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Недоставленных загрузок: %d\n", len(entries))
+	for i, e := range entries {
+		if i == shown {
+			fmt.Fprintf(&sb, "…и ещё %d\n", len(entries)-shown)
+			break
+		}
+		reason := e.LastError
+		if r := []rune(reason); len(r) > 60 {
+			reason = string(r[:60]) + "…"
+		}
+		fmt.Fprintf(&sb, "%s · %s · user %d · попыток %d · %s\n", e.ID, e.Title, e.UserID, e.Attempts, reason)
+	}
+	sb.WriteString(redeliverUsage)
+	return sb.String()
+}
+
+// redeliverBackoff doubles the delay with every failed attempt.
+func redeliverBackoff(attempts int) time.Duration {
+	d := redeliverInterval << (attempts - 1)
+	if d <= 0 || d > maxRedeliverBackoff {
+		return maxRedeliverBackoff
+	}
+	return d
+}
+
+// permanentSendError reports failures a retry cannot fix, such as a user
+// who blocked the bot or a request Telegram rejects outright. Uploads lose
+// the error code in tgbotapi, so the description prefix is checked too.
+func permanentSendError(err error) bool {
+	var tgErr *tgbotapi.Error
+	if !errors.As(err, &tgErr) {
+		return false
+	}
+	switch tgErr.Code {
+	case http.StatusForbidden, http.StatusBadRequest:
+		return true
+	}
+	return strings.HasPrefix(tgErr.Message, "Forbidden") || strings.HasPrefix(tgErr.Message, "Bad Request")
+}
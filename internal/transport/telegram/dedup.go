@@ -0,0 +1,37 @@
+package telegram
+
+import "sync"
+
+// seenUpdates is how many recent update ids are remembered for deduplication.
+const seenUpdates = 1024
+
+// updateLog remembers the last ids of processed updates in a ring buffer so
+// updates Telegram redelivers after a reconnect are handled only once.
+type updateLog struct {
+	mu   sync.Mutex
+	ring []int
+	next int
+	ids  map[int]struct{}
+}
+
+func newUpdateLog(size int) *updateLog {
+	return &updateLog{ring: make([]int, 0, size), ids: make(map[int]struct{}, size)}
+}
+
+// first records id and reports whether it was not seen before.
+func (l *updateLog) first(id int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.ids[id]; ok {
+		return false
+	}
+	if len(l.ring) < cap(l.ring) {
+		l.ring = append(l.ring, id)
+	} else {
+		delete(l.ids, l.ring[l.next])
+		l.ring[l.next] = id
+		l.next = (l.next + 1) % len(l.ring)
+	}
+	l.ids[id] = struct{}{}
+	return true
+}
@@ -0,0 +1,227 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+)
+
+// Tabs of /playlists, the first ActionDiscover argument. The others are the
+// tag, the list offset and, once a playlist is open, its owner uid and kind.
+const (
+	discoverEditorial = "e"
+	discoverMood      = "m"
+	discoverActivity  = "a"
+	discoverGenre     = "g"
+)
+
+// discoverTag is a Yandex playlist tag offered in a category tab.
+type discoverTag struct{ id, title string }
+
+// discoverTabs lists the tabs in order; editorial picks come from the
+// landing page, the categories from the playlists filed under their tags.
+var discoverTabs = []struct {
+	tab, icon, title string
+	tags             []discoverTag
+}{
+	{discoverEditorial, "⭐", "Подборки", nil},
+	{discoverMood, "😌", "Настроение", []discoverTag{
+		{"chill", "Чилл"}, {"sad", "Грусть"}, {"romantic", "Романтика"}, {"party", "Вечеринка"}, {"relax", "Расслабиться"}, {"energetic", "Энергия"},
+	}},
+	{discoverActivity, "🏃", "Занятия", []discoverTag{
+		{"workout", "Тренировка"}, {"focus", "Работа и учёба"}, {"road", "В дороге"}, {"morning", "Утро"}, {"sleep", "Сон"}, {"cooking", "Готовка"},
+	}},
+	{discoverGenre, "🎸", "Жанры", []discoverTag{
+		{"rock", "Рок"}, {"pop", "Поп"}, {"hip-hop", "Хип-хоп"}, {"electronics", "Электроника"},
+		{"indie", "Инди"}, {"jazz", "Джаз"}, {"classical", "Классика"}, {"rusrock", "Русский рок"},
+	}},
+}
+
+const discoverStale = "Кнопка устарела, откройте /playlists заново."
+
+// handleDiscover opens the editorial playlists.
+func (b *Bot) handleDiscover(ctx context.Context, m *tgbotapi.Message) {
+	b.openMenu(ctx, m.Chat.ID, m.From.ID, callback.ActionDiscover, discoverEditorial, "", "0")
+}
+
+// discoverMenu drills down: tab → tag → playlist → tracks to download.
+func (b *Bot) discoverMenu(ctx context.Context, req menuRequest) (menuScreen, error) {
+	p := req.payload
+	tab, tag := p.Arg(0), p.Arg(1)
+	offset, err := strconv.Atoi(p.Arg(2))
+	if err != nil || offset < 0 {
+		return menuScreen{}, menuAlert(discoverStale)
+	}
+	if owner := p.Arg(3); owner != "" {
+		kind, err := strconv.Atoi(p.Arg(4))
+		if err != nil {
+			return menuScreen{}, menuAlert(discoverStale)
+		}
+		return b.discoverPlaylist(ctx, tab, tag, owner, kind, offset)
+	}
+
+	var (
+		title     string
+		playlists []yandex.Playlist
+	)
+	switch {
+	case tab == discoverEditorial:
+		title = "⭐ Подборки Яндекс Музыки"
+		playlists, err = b.musicService.EditorialPlaylists(ctx)
+	case tag == "":
+		return b.discoverTags(tab), nil
+	default:
+		var name string
+		name, playlists, err = b.musicService.TagPlaylists(ctx, tag)
+		title = "🏷 " + tagTitle(tab, tag, name)
+	}
+	if err != nil {
+		b.logger.Warn("discover playlists failed", zap.String("tab", tab), zap.String("tag", tag), zap.Error(err))
+		return menuScreen{}, menuAlert("Не удалось загрузить подборки, попробуйте позже.")
+	}
+
+	offset = min(offset, max(len(playlists)-1, 0))
+	page := playlists[offset:min(offset+searchLimit, len(playlists))]
+	text := title + ":"
+	if len(playlists) == 0 {
+		text += "\n\nПлейлистов пока нет."
+	}
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(page)+2)
+	for _, pl := range page {
+		if pl.OwnerUID == "" {
+			continue
+		}
+		label := pl.Title
+		if pl.TrackCount > 0 {
+			label = fmt.Sprintf("%s (%d)", pl.Title, pl.TrackCount)
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate(label, maxButtonLabel), callback.ActionDiscover, tab, tag, "0", pl.OwnerUID, strconv.Itoa(pl.Kind)),
+		))
+	}
+	if nav := b.discoverNav(offset, len(playlists), tab, tag); len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	if tag != "" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(b.button("⬆ Назад", callback.ActionDiscover, tab, "", "0")))
+	}
+	rows = append(rows, b.discoverTabRow(tab))
+	return menuScreen{text: text, keyboard: tgbotapi.NewInlineKeyboardMarkup(rows...)}, nil
+}
+
+// discoverTags lists the tags of a category tab two per row.
+func (b *Bot) discoverTags(tab string) menuScreen {
+	for _, t := range discoverTabs {
+		if t.tab != tab || t.tags == nil {
+			continue
+		}
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for i := 0; i < len(t.tags); i += 2 {
+			var row []tgbotapi.InlineKeyboardButton
+			for _, tag := range t.tags[i:min(i+2, len(t.tags))] {
+				row = append(row, b.button(tag.title, callback.ActionDiscover, tab, tag.id, "0"))
+			}
+			rows = append(rows, row)
+		}
+		rows = append(rows, b.discoverTabRow(tab))
+		return menuScreen{text: t.icon + " " + t.title + ":", keyboard: tgbotapi.NewInlineKeyboardMarkup(rows...)}
+	}
+	return b.discoverTags(discoverMood)
+}
+
+// discoverPlaylist pages through the tracks of an open playlist.
+func (b *Bot) discoverPlaylist(ctx context.Context, tab, tag, owner string, kind, offset int) (menuScreen, error) {
+	pl, tracks, err := b.musicService.PublicPlaylistTracks(ctx, owner, kind)
+	if err != nil {
+		b.logger.Warn("discover playlist failed", zap.String("owner", owner), zap.Int("kind", kind), zap.Error(err))
+		if errors.Is(err, yandex.ErrPlaylistNotFound) {
+			return menuScreen{}, menuAlert("Плейлист больше недоступен.")
+		}
+		return menuScreen{}, menuAlert("Не удалось открыть плейлист :(")
+	}
+	offset = min(offset, max(len(tracks)-1, 0))
+	page := tracks[offset:min(offset+searchLimit, len(tracks))]
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📃 %s — треков: %d", pl.Title, len(tracks))
+	if len(tracks) == 0 {
+		sb.WriteString("\n\nПлейлист пуст.")
+	}
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(page)+3)
+	for i, t := range page {
+		label := fmt.Sprintf("%d. %s — %s", offset+i+1, t.ArtistsString(), t.FullTitle())
+		if mark := restrictionMark(t); mark != "" {
+			label = mark + " " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate(label, maxButtonLabel), callback.ActionDownload, t.ID),
+		))
+	}
+	kindArg := strconv.Itoa(kind)
+	var nav []tgbotapi.InlineKeyboardButton
+	if offset > 0 {
+		nav = append(nav, b.button("◀ Назад", callback.ActionDiscover, tab, tag, strconv.Itoa(max(offset-searchLimit, 0)), owner, kindArg))
+	}
+	if offset+searchLimit < len(tracks) {
+		nav = append(nav, b.button("Далее ▶", callback.ActionDiscover, tab, tag, strconv.Itoa(offset+searchLimit), owner, kindArg))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	if link := pl.URL(); link != "" {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL("🌐 Открыть в Яндекс Музыке", link)))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(b.button("⬆ К подборкам", callback.ActionDiscover, tab, tag, "0")))
+	return menuScreen{text: sb.String(), keyboard: tgbotapi.NewInlineKeyboardMarkup(rows...)}, nil
+}
+
+// discoverNav renders the ◀/▶ row of a playlist list.
+func (b *Bot) discoverNav(offset, total int, tab, tag string) []tgbotapi.InlineKeyboardButton {
+	var nav []tgbotapi.InlineKeyboardButton
+	if offset > 0 {
+		nav = append(nav, b.button("◀ Назад", callback.ActionDiscover, tab, tag, strconv.Itoa(max(offset-searchLimit, 0))))
+	}
+	if offset+searchLimit < total {
+		nav = append(nav, b.button("Далее ▶", callback.ActionDiscover, tab, tag, strconv.Itoa(offset+searchLimit)))
+	}
+	return nav
+}
+
+// discoverTabRow switches between tabs, spelling out the current one.
+func (b *Bot) discoverTabRow(current string) []tgbotapi.InlineKeyboardButton {
+	row := make([]tgbotapi.InlineKeyboardButton, 0, len(discoverTabs))
+	for _, t := range discoverTabs {
+		label := t.icon
+		if t.tab == current {
+			label = "· " + t.icon + " " + t.title + " ·"
+		}
+		row = append(row, b.button(label, callback.ActionDiscover, t.tab, "", "0"))
+	}
+	return row
+}
+
+// tagTitle names a tag by the menu's own title, then by the one Yandex gave.
+func tagTitle(tab, tag, name string) string {
+	for _, t := range discoverTabs {
+		if t.tab != tab {
+			continue
+		}
+		for _, dt := range t.tags {
+			if dt.id == tag {
+				return dt.title
+			}
+		}
+	}
+	if name != "" {
+		return name
+	}
+	return tag
+}
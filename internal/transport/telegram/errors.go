@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// userError is what a user is told about a failure: what happened and what to
+// do about it, plus a short code to quote when asking admins for help. Texts
+// fit a callback alert (200 characters) together with the code.
+type userError struct {
+	code string
+	text string
+}
+
+func (e userError) String() string {
+	return fmt.Sprintf("%s\nКод: %s", e.text, e.code)
+}
+
+// Fallbacks for errors that are not classified below.
+var (
+	errDownloadFailed = userError{"DL-01", "Не удалось скачать трек. Попробуйте ещё раз через пару минут."}
+	errSendFailed     = userError{"TG-01", "Не удалось отправить аудио. Попробуйте ещё раз через пару минут."}
+	errGeneric        = userError{"GEN-01", "Что-то пошло не так, попробуйте ещё раз."}
+	errThrottled      = userError{"BOT-429", "Слишком много запросов, подождите минуту и повторите."}
+)
+
+// errExplicitHidden refuses a track with explicit lyrics in a chat that hides them.
+var errExplicitHidden = errors.New("explicit tracks are hidden in this chat")
+
+// tooLargeError reports a file above the upload limit of the Bot API.
+type tooLargeError struct {
+	size, limit int64
+}
+
+func (e tooLargeError) Error() string {
+	return fmt.Sprintf("file of %d bytes exceeds upload limit of %d bytes", e.size, e.limit)
+}
+
+// presentError maps err to a user-facing message, fallback when it is not
+// one of the known failure classes.
+func presentError(err error, fallback userError) userError {
+	var tooLarge tooLargeError
+	var tgErr *tgbotapi.Error
+	switch {
+	case errors.Is(err, yandex.ErrRegionLocked):
+		return userError{"YM-451", "Трек недоступен в регионе, где работает бот. Поищите другую версию или исполнение."}
+	case errors.Is(err, yandex.ErrSubscriptionRequired):
+		return userError{"YM-402", "Этот трек доступен только по подписке Яндекс Плюс, а у аккаунта бота её нет."}
+	case errors.Is(err, yandex.ErrNotFound):
+		return userError{"YM-404", "Трек не найден: возможно, его удалили из каталога. Попробуйте найти его заново."}
+	case errors.Is(err, yandex.ErrRateLimited):
+		return userError{"YM-429", "Яндекс Музыка временно ограничила запросы бота. Попробуйте через несколько минут."}
+	case errors.Is(err, yandex.ErrTimeout):
+		return userError{"YM-504", "Яндекс Музыка слишком долго отвечает. Попробуйте ещё раз чуть позже."}
+	case errors.Is(err, errExplicitHidden):
+		return userError{"CHAT-18", "В этом чате треки с ненормативной лексикой отключены администраторами."}
+	case errors.As(err, &tooLarge):
+		return userError{"TG-413", fmt.Sprintf("Файл слишком большой (%d МБ): Telegram принимает от ботов файлы до %d МБ.",
+			tooLarge.size>>20, tooLarge.limit>>20)}
+	case errors.As(err, &tgErr) && tgErr.Code == http.StatusTooManyRequests:
+		return userError{"TG-429", "Telegram временно ограничил отправку. Попробуйте через минуту."}
+	case errors.As(err, &tgErr) && tgErr.Code == http.StatusRequestEntityTooLarge:
+		return userError{"TG-413", "Telegram не принял файл: он слишком большой."}
+	}
+	return fallback
+}
@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/storage"
+)
+
+// handleExport sends the user's download history: /export [csv|json].
+func (b *Bot) handleExport(_ context.Context, m *tgbotapi.Message) {
+	if !m.Chat.IsPrivate() {
+		b.reply(m.Chat.ID, "Экспорт истории доступен только в личном чате с ботом.")
+		return
+	}
+
+	format := strings.ToLower(strings.TrimSpace(m.CommandArguments()))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		b.reply(m.Chat.ID, "Использование: /export [csv|json]")
+		return
+	}
+
+	history := b.store.History(m.From.ID)
+	if len(history) == 0 {
+		b.reply(m.Chat.ID, "История загрузок пуста.")
+		return
+	}
+
+	data, err := encodeHistory(history, format)
+	if err != nil {
+		b.logger.Warn("encode history failed", zap.Int64("userID", m.From.ID), zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось подготовить файл :(")
+		return
+	}
+
+	doc := tgbotapi.NewDocument(m.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("ym-history-%s.%s", time.Now().UTC().Format("2006-01-02"), format),
+		Bytes: data,
+	})
+	doc.Caption = fmt.Sprintf("Треков в истории: %d.", len(history))
+	if _, err := b.sender.Send(doc); err != nil {
+		b.logger.Warn("send history failed", zap.Int64("userID", m.From.ID), zap.Error(err))
+	}
+}
+
+// historyEntry records a delivery of t, requested as trackID, now.
+func historyEntry(trackID string, t yandex.Track) storage.HistoryEntry {
+	main := t.Credited(yandex.RoleMain)
+	if len(main) == 0 {
+		main = t.Artists
+	}
+	return storage.HistoryEntry{
+		At:          time.Now(),
+		TrackID:     trackID,
+		Title:       t.FullTitle(),
+		Artists:     t.ArtistsString(),
+		MainArtists: main,
+		DurationSec: t.DurationSeconds,
+	}
+}
+
+// encodeHistory renders entries as CSV (with a header row) or a JSON array.
+func encodeHistory(entries []storage.HistoryEntry, format string) ([]byte, error) {
+	if format == "json" {
+		return json.MarshalIndent(entries, "", "  ")
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"downloaded_at", "track_id", "title", "artists"})
+	for _, e := range entries {
+		_ = w.Write([]string{e.At.UTC().Format(time.RFC3339), e.TrackID, e.Title, e.Artists})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
@@ -0,0 +1,144 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/storage"
+)
+
+// maxFeedbackLen keeps the forwarded message under Telegram's 4096 limit.
+const maxFeedbackLen = 3500
+
+// feedbackRef finds the feedback id in the bot messages admins reply to:
+// the forwarded feedback itself or the "Ответить" prompt.
+var feedbackRef = regexp.MustCompile(`(?i)отзыв #([0-9a-f]{8})`)
+
+// handleFeedback stores the user's message and forwards it to the feedback chats.
+func (b *Bot) handleFeedback(_ context.Context, m *tgbotapi.Message) {
+	text := strings.TrimSpace(m.CommandArguments())
+	if text == "" {
+		b.reply(m.Chat.ID, "Напишите сообщение после команды: /feedback <текст>.")
+		return
+	}
+	if r := []rune(text); len(r) > maxFeedbackLen {
+		text = string(r[:maxFeedbackLen]) + "…"
+	}
+
+	chats := b.feedbackChatIDs()
+	if len(chats) == 0 {
+		b.reply(m.Chat.ID, "Обратная связь не настроена.")
+		return
+	}
+
+	f, err := b.store.AddFeedback(storage.Feedback{
+		UserID:    m.From.ID,
+		Username:  displayName(m.From),
+		ChatID:    m.Chat.ID,
+		MessageID: m.MessageID,
+		Text:      text,
+		At:        time.Now(),
+	})
+	if err != nil {
+		b.logger.Warn("store feedback failed", zap.Int64("userID", m.From.ID), zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось отправить сообщение, попробуйте позже.")
+		return
+	}
+
+	delivered := 0
+	for _, chatID := range chats {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✉️ Отзыв #%s от %s (ID %d):\n\n%s", f.ID, f.Username, f.UserID, f.Text))
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			b.button("↩️ Ответить", callback.ActionFeedback, f.ID),
+		))
+		if _, err := b.sender.Send(msg); err != nil {
+			b.logger.Warn("forward feedback failed", zap.Int64("chatID", chatID), zap.Error(err))
+			continue
+		}
+		delivered++
+	}
+	if delivered == 0 {
+		b.reply(m.Chat.ID, "Не удалось отправить сообщение, попробуйте позже.")
+		return
+	}
+	b.reply(m.Chat.ID, "Спасибо! Сообщение передано администраторам.")
+}
+
+// handleFeedbackCallback asks the admin for the answer text with a forced reply.
+func (b *Bot) handleFeedbackCallback(_ context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	if !b.isAdmin(cb.From.ID) || cb.Message == nil {
+		return
+	}
+	f, ok := b.store.Feedback(p.Arg(0))
+	if !ok {
+		b.sendAlert(cb, "Отзыв не найден.")
+		return
+	}
+
+	prompt := tgbotapi.NewMessage(cb.Message.Chat.ID, fmt.Sprintf("↩️ Ответ на отзыв #%s от %s — напишите текст ответом на это сообщение.", f.ID, f.Username))
+	prompt.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+	prompt.ReplyToMessageID = cb.Message.MessageID
+	if _, err := b.sender.Send(prompt); err != nil {
+		b.logger.Warn("send feedback prompt failed", zap.Error(err))
+	}
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, "")); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+}
+
+// handleFeedbackReply sends an admin's reply to a forwarded feedback (or to
+// the prompt) back to the user. It reports whether m was such a reply.
+func (b *Bot) handleFeedbackReply(m *tgbotapi.Message) bool {
+	orig := m.ReplyToMessage
+	if orig == nil || orig.From == nil || orig.From.ID != b.api.Self.ID || !b.isAdmin(m.From.ID) {
+		return false
+	}
+	ref := feedbackRef.FindStringSubmatch(orig.Text)
+	if ref == nil {
+		return false
+	}
+
+	f, ok := b.store.Feedback(ref[1])
+	if !ok {
+		b.reply(m.Chat.ID, "Отзыв не найден.")
+		return true
+	}
+	text := strings.TrimSpace(m.Text)
+	if text == "" {
+		b.reply(m.Chat.ID, "Ответ должен быть текстом.")
+		return true
+	}
+
+	answer := tgbotapi.NewMessage(f.ChatID, "💬 Ответ на ваш отзыв:\n\n"+text)
+	answer.ReplyToMessageID = f.MessageID
+	answer.AllowSendingWithoutReply = true
+	if _, err := b.sender.Send(answer); err != nil {
+		b.logger.Warn("send feedback answer failed", zap.String("id", f.ID), zap.Error(err))
+		b.reply(m.Chat.ID, fmt.Sprintf("Не удалось доставить ответ: %v", err))
+		return true
+	}
+	if err := b.store.MarkFeedbackAnswered(f.ID, time.Now()); err != nil {
+		b.logger.Warn("mark feedback answered failed", zap.String("id", f.ID), zap.Error(err))
+	}
+	b.reply(m.Chat.ID, fmt.Sprintf("Ответ на отзыв #%s отправлен.", f.ID))
+	return true
+}
+
+func (b *Bot) feedbackChatIDs() []int64 {
+	return b.settings.Load().FeedbackChats
+}
+
+// displayName renders a user as @username, falling back to the full name.
+func displayName(u *tgbotapi.User) string {
+	if u.UserName != "" {
+		return "@" + u.UserName
+	}
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
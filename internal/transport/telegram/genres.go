@@ -0,0 +1,129 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+)
+
+// genreTracks is the ActionGenre mode that lists a genre's top tracks.
+const genreTracks = "t"
+
+// handleGenres opens the genre catalog.
+func (b *Bot) handleGenres(ctx context.Context, m *tgbotapi.Message) {
+	b.openMenu(ctx, m.Chat.ID, m.From.ID, callback.ActionGenre, "")
+}
+
+// genresMenu drills down: catalog → genre → subgenre → top tracks.
+func (b *Bot) genresMenu(ctx context.Context, req menuRequest) (menuScreen, error) {
+	p := req.payload
+	genres, err := b.musicService.Genres(ctx)
+	if err != nil || len(genres) == 0 {
+		b.logger.Warn("list genres failed", zap.Error(err))
+		return menuScreen{}, menuAlert("Каталог жанров сейчас недоступен, попробуйте позже.")
+	}
+
+	id := p.Arg(0)
+	if id == "" {
+		return menuScreen{text: "🎼 Жанры:", keyboard: b.genreKeyboard(genres, nil)}, nil
+	}
+	path := genrePath(genres, id)
+	if path == nil {
+		return menuScreen{}, menuAlert("Жанр не найден.")
+	}
+	g := path[len(path)-1]
+	if len(g.SubGenres) > 0 && p.Arg(1) != genreTracks {
+		return menuScreen{text: "🎼 " + genreTitle(path) + ":", keyboard: b.genreKeyboard(g.SubGenres, path)}, nil
+	}
+
+	tracks, err := b.musicService.GenreTracks(ctx, g.ID)
+	if err != nil {
+		b.logger.Warn("genre tracks failed", zap.String("genre", g.ID), zap.Error(err))
+		return menuScreen{}, menuAlert("Не удалось загрузить треки жанра :(")
+	}
+	back := path[:len(path)-1]
+	if len(g.SubGenres) > 0 {
+		back = path
+	}
+	var screen menuScreen
+	screen.text, screen.keyboard = b.renderGenreTracks(path, tracks, back)
+	return screen, nil
+}
+
+// genreKeyboard lists genres two per row. Inside a genre (path set) it adds
+// the genre's own top tracks and a way back up.
+func (b *Bot) genreKeyboard(genres []yandex.Genre, path []yandex.Genre) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	if len(path) > 0 {
+		g := path[len(path)-1]
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate("🔥 Топ: "+g.Title, maxButtonLabel), callback.ActionGenre, g.ID, genreTracks),
+		))
+	}
+	for i := 0; i < len(genres); i += 2 {
+		var row []tgbotapi.InlineKeyboardButton
+		for _, g := range genres[i:min(i+2, len(genres))] {
+			row = append(row, b.button(truncate(g.Title, maxButtonLabel/2), callback.ActionGenre, g.ID))
+		}
+		rows = append(rows, row)
+	}
+	if len(path) > 0 {
+		rows = append(rows, b.genreBack(path[:len(path)-1]))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (b *Bot) renderGenreTracks(path []yandex.Genre, tracks []yandex.Track, back []yandex.Genre) (string, tgbotapi.InlineKeyboardMarkup) {
+	tracks = tracks[:min(len(tracks), searchLimit)]
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🔥 Топ: %s", genreTitle(path))
+	if len(tracks) == 0 {
+		sb.WriteString("\n\nТреков не нашлось.")
+	}
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(tracks)+1)
+	for i, t := range tracks {
+		label := truncate(fmt.Sprintf("%d. %s — %s", i+1, t.ArtistsString(), t.FullTitle()), maxButtonLabel)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(label, callback.ActionDownload, t.ID),
+		))
+	}
+	rows = append(rows, b.genreBack(back))
+	return sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// genreBack returns to the genre at the end of path, or to the catalog.
+func (b *Bot) genreBack(path []yandex.Genre) []tgbotapi.InlineKeyboardButton {
+	if len(path) == 0 {
+		return tgbotapi.NewInlineKeyboardRow(b.button("⬆ Все жанры", callback.ActionGenre, ""))
+	}
+	g := path[len(path)-1]
+	return tgbotapi.NewInlineKeyboardRow(b.button(truncate("⬆ "+g.Title, maxButtonLabel), callback.ActionGenre, g.ID))
+}
+
+// genrePath returns the genres from the catalog root down to id.
+func genrePath(genres []yandex.Genre, id string) []yandex.Genre {
+	for _, g := range genres {
+		if g.ID == id {
+			return []yandex.Genre{g}
+		}
+		if sub := genrePath(g.SubGenres, id); sub != nil {
+			return append([]yandex.Genre{g}, sub...)
+		}
+	}
+	return nil
+}
+
+func genreTitle(path []yandex.Genre) string {
+	titles := make([]string, 0, len(path))
+	for _, g := range path {
+		titles = append(titles, g.Title)
+	}
+	return strings.Join(titles, " › ")
+}
@@ -0,0 +1,234 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/storage"
+)
+
+// ActionChatSettings keys; the first payload argument is always the chat id.
+const (
+	chatKeyLanguage = "l"
+	chatKeyQuality  = "q"
+	chatKeyQuiet    = "h"
+	chatKeyExplicit = "e"
+	chatKeyPreviews = "p"
+	chatKeyCommands = "c" // opens the command list
+	chatKeyCommand  = "t" // toggles the command in the next argument
+)
+
+// chatLanguages lists the help languages in the order the menu cycles them.
+var chatLanguages = []string{"", "en"}
+
+var chatLanguageLabels = map[string]string{"": "русский", "en": "English"}
+
+// chatQualities lists the quality caps in the order the menu cycles them.
+var chatQualities = []yandex.Quality{"", yandex.QualityHigh, yandex.QualityLow}
+
+var chatQualityLabels = map[yandex.Quality]string{
+	"":                 "без ограничений",
+	yandex.QualityHigh: "без lossless",
+	yandex.QualityLow:  "экономное",
+}
+
+// chatQuietHours are the quiet hour ranges the menu offers; the first is off.
+var chatQuietHours = [][2]int{{0, 0}, {22, 8}, {23, 7}, {0, 9}}
+
+// handleGroupSettings opens the chat's settings for its administrators.
+func (b *Bot) handleGroupSettings(ctx context.Context, m *tgbotapi.Message) {
+	if m.Chat.IsPrivate() {
+		b.reply(m.Chat.ID, "Настройки чата меняются в группе: отправьте /groupsettings там. Личные настройки — /settings.")
+		return
+	}
+	if !b.isChatAdmin(m.Chat.ID, m.From.ID) {
+		b.reply(m.Chat.ID, "Настройки чата меняют только его администраторы.")
+		return
+	}
+	b.openMenu(ctx, m.Chat.ID, m.From.ID, callback.ActionChatSettings, strconv.FormatInt(m.Chat.ID, 10))
+}
+
+// chatSettingsMenu shows a chat's settings; a key argument changes that one
+// first. Every step re-checks that the user administers the chat.
+func (b *Bot) chatSettingsMenu(_ context.Context, req menuRequest) (menuScreen, error) {
+	chatID, err := strconv.ParseInt(req.payload.Arg(0), 10, 64)
+	if err != nil {
+		return menuScreen{}, menuAlert("Кнопка устарела, откройте /groupsettings заново.")
+	}
+	if !b.isChatAdmin(chatID, req.userID) {
+		return menuScreen{}, menuAlert("Настройки чата меняют только его администраторы.")
+	}
+
+	key := req.payload.Arg(1)
+	if key == "" || key == chatKeyCommands {
+		settings := b.store.ChatSettings(chatID)
+		return b.chatSettingsScreen(chatID, settings, key == chatKeyCommands, ""), nil
+	}
+	settings, err := b.store.UpdateChatSettings(chatID, func(c *storage.ChatSettings) {
+		switch key {
+		case chatKeyLanguage:
+			c.Language = cycle(chatLanguages, c.Language)
+		case chatKeyQuality:
+			c.QualityCap = string(cycle(chatQualities, yandex.Quality(c.QualityCap)))
+		case chatKeyQuiet:
+			next := cycle(chatQuietHours, [2]int{c.QuietFrom, c.QuietTo})
+			c.QuietFrom, c.QuietTo = next[0], next[1]
+		case chatKeyExplicit:
+			c.HideExplicit = !c.HideExplicit
+		case chatKeyPreviews:
+			c.HidePreviews = !c.HidePreviews
+		case chatKeyCommand:
+			command := req.payload.Arg(2)
+			if i := slices.Index(c.DisabledCommands, command); i >= 0 {
+				c.DisabledCommands = slices.Delete(c.DisabledCommands, i, i+1)
+			} else {
+				c.DisabledCommands = append(c.DisabledCommands, command)
+			}
+		}
+	})
+	if err != nil {
+		b.logger.Warn("update chat settings failed", zap.Int64("chatID", chatID), zap.Error(err))
+		return menuScreen{}, menuAlert("Не удалось сохранить настройки :(")
+	}
+	if key == chatKeyLanguage || key == chatKeyCommand {
+		b.syncChatCommands(chatID, settings)
+	}
+	return b.chatSettingsScreen(chatID, settings, key == chatKeyCommand, "Сохранено"), nil
+}
+
+// chatSettingsScreen renders the main screen, or the command list.
+func (b *Bot) chatSettingsScreen(chatID int64, c storage.ChatSettings, commands bool, notice string) menuScreen {
+	id := strconv.FormatInt(chatID, 10)
+	if commands {
+		var rows [][]tgbotapi.InlineKeyboardButton
+		for _, command := range b.chatCommands() {
+			mark := "✅"
+			if !c.CommandAllowed(command) {
+				mark = "🚫"
+			}
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				b.button(mark+" /"+command, callback.ActionChatSettings, id, chatKeyCommand, command)))
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(b.button("« Назад", callback.ActionChatSettings, id)))
+		return menuScreen{
+			text:     "Команды, доступные в чате. Отключённые бот молча игнорирует.",
+			keyboard: tgbotapi.NewInlineKeyboardMarkup(rows...),
+			notice:   notice,
+		}
+	}
+
+	quiet := "выкл"
+	if c.QuietFrom != c.QuietTo {
+		quiet = fmt.Sprintf("%02d:00–%02d:00", c.QuietFrom, c.QuietTo)
+	}
+	kb := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(b.button("Язык справки: "+chatLanguageLabels[c.Language], callback.ActionChatSettings, id, chatKeyLanguage)),
+		tgbotapi.NewInlineKeyboardRow(b.button("Качество: "+chatQualityLabels[yandex.Quality(c.QualityCap)], callback.ActionChatSettings, id, chatKeyQuality)),
+		tgbotapi.NewInlineKeyboardRow(b.button("Тихие часы: "+quiet, callback.ActionChatSettings, id, chatKeyQuiet)),
+		tgbotapi.NewInlineKeyboardRow(b.button("Скрывать треки 18+: "+onOff(c.HideExplicit), callback.ActionChatSettings, id, chatKeyExplicit)),
+		tgbotapi.NewInlineKeyboardRow(b.button("Превью ссылок: "+onOff(!c.HidePreviews), callback.ActionChatSettings, id, chatKeyPreviews)),
+		tgbotapi.NewInlineKeyboardRow(b.button(fmt.Sprintf("Команды (отключено: %d) »", len(c.DisabledCommands)), callback.ActionChatSettings, id, chatKeyCommands)),
+	)
+	return menuScreen{text: "Настройки чата", keyboard: kb, notice: notice}
+}
+
+// chatCommands are the commands chat admins may turn off: everything
+// anyone may use, except the help and the settings themselves.
+func (b *Bot) chatCommands() []string {
+	var out []string
+	for name, r := range b.commands {
+		if r.admin || name == "start" || name == "help" || name == "groupsettings" {
+			continue
+		}
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// cycle returns the element after cur in list, the first one when cur is
+// last or unknown.
+func cycle[T comparable](list []T, cur T) T {
+	i := slices.Index(list, cur)
+	return list[(i+1)%len(list)]
+}
+
+// isChatAdmin reports whether userID administers chatID; bot admins count
+// as administrators of every chat.
+func (b *Bot) isChatAdmin(chatID, userID int64) bool {
+	if b.isAdmin(userID) {
+		return true
+	}
+	resp, err := b.sender.Request(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: chatID, UserID: userID},
+	})
+	if err != nil {
+		b.logger.Warn("get chat member failed", zap.Int64("chatID", chatID), zap.Int64("userID", userID), zap.Error(err))
+		return false
+	}
+	var member tgbotapi.ChatMember
+	if err := json.Unmarshal(resp.Result, &member); err != nil {
+		b.logger.Warn("decode chat member failed", zap.Int64("chatID", chatID), zap.Error(err))
+		return false
+	}
+	return member.IsCreator() || member.IsAdministrator()
+}
+
+// chatPolicy enforces the settings of group chats: disabled commands are
+// ignored, and during quiet hours commands and buttons are turned down.
+// /groupsettings and its menu always work, so admins can change them.
+func (b *Bot) chatPolicy(next updateHandler) updateHandler {
+	return func(ctx context.Context, u tgbotapi.Update) {
+		chat := updateChat(u)
+		if chat == nil || chat.IsPrivate() {
+			next(ctx, u)
+			return
+		}
+		settings := b.store.ChatSettings(chat.ID)
+		quiet := settings.Quiet(time.Now())
+		switch {
+		case u.Message != nil && u.Message.IsCommand() && u.Message.Command() != "groupsettings":
+			if !settings.CommandAllowed(u.Message.Command()) {
+				return
+			}
+			if quiet {
+				b.reply(chat.ID, quietText(settings))
+				return
+			}
+		case u.CallbackQuery != nil && quiet:
+			p, err := b.codec.Decode(u.CallbackQuery.Data)
+			if err != nil || (p.Action != callback.ActionChatSettings && p.Action != callback.ActionCancel) {
+				b.sendAlert(u.CallbackQuery, quietText(settings))
+				return
+			}
+		}
+		next(ctx, u)
+	}
+}
+
+func quietText(c storage.ChatSettings) string {
+	return fmt.Sprintf("🌙 В чате тихие часы до %02d:00, бот пока отдыхает.", c.QuietTo)
+}
+
+// chatContext applies the quality cap of chatID to downloads made with ctx.
+func (b *Bot) chatContext(ctx context.Context, chatID int64) context.Context {
+	return yandex.WithQualityCap(ctx, yandex.Quality(b.store.ChatSettings(chatID).QualityCap))
+}
+
+// startTextFor is the help in the language chosen for chatID.
+func (b *Bot) startTextFor(chatID int64) string {
+	if b.store.ChatSettings(chatID).Language == "en" {
+		return startTextEN
+	}
+	return startText
+}
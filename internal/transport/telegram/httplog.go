@@ -0,0 +1,90 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultHTTPLogRows = 10
+	maxHTTPLogRows     = 200
+	httpLogUsage       = "Использование: /httplog [n] | /httplog on | /httplog off"
+)
+
+// handleHTTPLog shows the latest recorded Yandex exchanges: a summary in the
+// chat and the sanitized requests and responses as a JSON Lines file.
+// "/httplog on" and "/httplog off" toggle recording until the next restart
+// or config reload.
+func (b *Bot) handleHTTPLog(_ context.Context, m *tgbotapi.Message) {
+	if b.recorder == nil {
+		b.reply(m.Chat.ID, "Запись запросов к Яндексу не подключена.")
+		return
+	}
+	arg := strings.ToLower(strings.TrimSpace(m.CommandArguments()))
+	switch arg {
+	case "on", "off":
+		b.recorder.SetEnabled(arg == "on")
+		b.logger.Info("yandex http recording toggled", zap.Int64("userID", m.From.ID), zap.String("state", arg))
+		if arg == "on" {
+			b.reply(m.Chat.ID, "🔴 Запись запросов к Яндексу включена.")
+		} else {
+			b.reply(m.Chat.ID, "⏹ Запись запросов к Яндексу выключена.")
+		}
+		return
+	}
+
+	n := defaultHTTPLogRows
+	if arg != "" {
+		v, err := strconv.Atoi(arg)
+		if err != nil || v <= 0 || v > maxHTTPLogRows {
+			b.reply(m.Chat.ID, httpLogUsage)
+			return
+		}
+		n = v
+	}
+
+	state := "выключена"
+	if b.recorder.Enabled() {
+		state = "включена"
+	}
+	exchanges := b.recorder.Recent(n)
+	if len(exchanges) == 0 {
+		b.reply(m.Chat.ID, fmt.Sprintf("Запись %s, записанных запросов нет.", state))
+		return
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Запись %s. Последние запросы (%d):", state, len(exchanges))
+	var data bytes.Buffer
+	for _, ex := range exchanges {
+		outcome := strconv.Itoa(ex.Status)
+		if ex.Error != "" {
+			outcome = "ошибка"
+		}
+		fmt.Fprintf(&sb, "\n%s %s %s → %s, %s", ex.At.Format("15:04:05"), ex.Method, truncate(ex.URL, 80),
+			outcome, ex.Duration.Round(time.Millisecond))
+		line, err := json.Marshal(ex)
+		if err != nil {
+			continue
+		}
+		data.Write(line)
+		data.WriteByte('\n')
+	}
+	b.reply(m.Chat.ID, truncate(sb.String(), maxAuditText))
+
+	doc := tgbotapi.NewDocument(m.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("yandex-http-%s.jsonl", time.Now().UTC().Format("2006-01-02T15-04-05")),
+		Bytes: data.Bytes(),
+	})
+	if _, err := b.sender.Send(doc); err != nil {
+		b.logger.Warn("send http log failed", zap.Int64("chatID", m.Chat.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,75 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/services/music"
+)
+
+const (
+	idUsage   = "Использование: /id <id трека в Яндекс Музыке>, например /id 33311009"
+	isrcUsage = "Использование: /isrc <код ISRC>, например /isrc USRC17607839"
+)
+
+// isrcTimeout allows for MusicBrainz's one request a second on top of the
+// catalog searches.
+const isrcTimeout = 20 * time.Second
+
+// handleTrackID downloads a track by its Yandex Music id, for those who
+// already know it. "id:album", as in the track's links, is taken too.
+func (b *Bot) handleTrackID(ctx context.Context, m *tgbotapi.Message) {
+	id, _, _ := strings.Cut(strings.TrimSpace(m.CommandArguments()), ":")
+	if _, ok := trackIDFromStart(deepLinkTrack + id); !ok {
+		b.reply(m.Chat.ID, idUsage)
+		return
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tracks, err := b.musicService.Tracks(lookupCtx, []string{b.store.ResolveTrack(id)})
+	if err != nil {
+		b.logger.Warn("track id lookup failed", zap.String("trackID", id), zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось найти трек, попробуйте позже.")
+		return
+	}
+	if len(tracks) == 0 {
+		b.reply(m.Chat.ID, "Трека с таким id нет.")
+		return
+	}
+	b.rememberTrack(tracks[0])
+	b.downloadPick(ctx, m, tracks[0].ID)
+}
+
+// handleISRC downloads the track of an ISRC code, see music.FindISRC.
+func (b *Bot) handleISRC(ctx context.Context, m *tgbotapi.Message) {
+	code, ok := music.NormalizeISRC(m.CommandArguments())
+	if !ok {
+		b.reply(m.Chat.ID, isrcUsage)
+		return
+	}
+	lookupCtx, cancel := context.WithTimeout(ctx, isrcTimeout)
+	defer cancel()
+
+	t, err := b.musicService.FindISRC(lookupCtx, code)
+	if errors.Is(err, music.ErrNoISRCMatch) {
+		b.reply(m.Chat.ID, "Трек с таким ISRC не найден.")
+		return
+	}
+	if errors.Is(err, music.ErrNoISRCLookup) {
+		b.reply(m.Chat.ID, "Поиск по ISRC недоступен: боту не настроен MusicBrainz.")
+		return
+	}
+	if err != nil {
+		b.logger.Warn("isrc lookup failed", zap.String("isrc", code), zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось найти трек, попробуйте позже.")
+		return
+	}
+	b.logger.Debug("isrc resolved", zap.String("isrc", code), zap.String("trackID", t.ID))
+	b.downloadPick(ctx, m, t.ID)
+}
@@ -0,0 +1,291 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/importer"
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/storage"
+)
+
+const (
+	// maxImportFile bounds uploaded import files.
+	maxImportFile = 4 << 20
+	// importSessionTTL is how long bulk actions stay available after an import.
+	importSessionTTL = 24 * time.Hour
+	// likeBatch is how many track ids go into one likes request.
+	likeBatch = 100
+
+	importDownload = "d"
+	importLike     = "l"
+)
+
+// importSession keeps matched tracks until the user picks a bulk action.
+type importSession struct {
+	userID   int64
+	chatID   int64
+	trackIDs []string
+	created  time.Time
+}
+
+type importedRow struct {
+	row   importer.Row
+	match music.Match
+}
+
+// handleImport matches an uploaded CSV/Spotify export against the catalog,
+// sends a confidence report and offers bulk actions for the matches.
+func (b *Bot) handleImport(ctx context.Context, m *tgbotapi.Message) {
+	doc := m.Document
+	if doc.FileSize > maxImportFile {
+		b.reply(m.Chat.ID, fmt.Sprintf("Файл слишком большой, максимум %d МБ.", maxImportFile>>20))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	raw, err := b.downloadFile(ctx, doc.FileID)
+	if err != nil {
+		b.logger.Warn("fetch import file failed", zap.Int64("userID", m.From.ID), zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось получить файл :(")
+		return
+	}
+	rows, truncated, err := importer.Parse(doc.FileName, bytes.NewReader(raw))
+	if err != nil {
+		b.logger.Debug("parse import failed", zap.String("file", doc.FileName), zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось разобрать файл. Поддерживаются CSV (исполнитель,название) и экспорт Spotify (YourLibrary.json).")
+		return
+	}
+
+	status := newProgress(b, m.Chat.ID)
+	status.show(fmt.Sprintf("📥 Сопоставляю треки: 0 из %d…", len(rows)))
+
+	results := make([]importedRow, 0, len(rows))
+	for i, row := range rows {
+		if ctx.Err() != nil {
+			break
+		}
+		searchCtx, cancelSearch := context.WithTimeout(ctx, b.inlineTimeout)
+		match, err := b.musicService.MatchTrack(searchCtx, row.Artist, row.Title, row.DurationSeconds)
+		cancelSearch()
+		if err != nil {
+			b.logger.Debug("import match failed", zap.String("title", row.Title), zap.Error(err))
+		}
+		results = append(results, importedRow{row: row, match: match})
+		if (i+1)%10 == 0 {
+			status.show(fmt.Sprintf("📥 Сопоставляю треки: %d из %d…", i+1, len(rows)))
+		}
+	}
+	b.store.RecordSearch(m.From.ID, time.Now())
+
+	var ids []string
+	confident, doubtful := 0, 0
+	for _, r := range results {
+		if !r.match.Found() {
+			continue
+		}
+		ids = append(ids, r.match.Track.ID)
+		if r.match.Confident() {
+			confident++
+		} else {
+			doubtful++
+		}
+	}
+
+	summary := fmt.Sprintf("Импорт: найдено %d из %d.\n✅ уверенно: %d\n⚠️ стоит проверить: %d\n❌ не найдено: %d",
+		len(ids), len(rows), confident, doubtful, len(results)-len(ids))
+	if truncated {
+		summary += fmt.Sprintf("\nОбработаны первые %d строк файла.", importer.MaxRows)
+	}
+	if len(results) < len(rows) {
+		summary += "\nСопоставление прервано по таймауту."
+	}
+	status.close()
+
+	report := tgbotapi.NewDocument(m.Chat.ID, tgbotapi.FileBytes{Name: "import-report.csv", Bytes: importReport(results)})
+	report.Caption = summary
+	if len(ids) > 0 {
+		id := b.saveImport(importSession{userID: m.From.ID, chatID: m.Chat.ID, trackIDs: ids, created: time.Now()})
+		row := []tgbotapi.InlineKeyboardButton{
+			b.button(fmt.Sprintf("⬇️ Скачать все (%d)", len(ids)), callback.ActionImport, id, importDownload),
+		}
+		if b.isAdmin(m.From.ID) {
+			// Likes go to the Yandex account behind the bot token, so only its owners may use them.
+			row = append(row, b.button("❤️ Лайкнуть все", callback.ActionImport, id, importLike))
+		}
+		report.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(row)
+	}
+	if _, err := b.sender.Send(report); err != nil {
+		b.logger.Warn("send import report failed", zap.Int64("userID", m.From.ID), zap.Error(err))
+	}
+}
+
+// handleImportCallback runs a bulk action on a finished import.
+func (b *Bot) handleImportCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	session, ok := b.importFor(p.Arg(0))
+	if !ok || session.userID != cb.From.ID {
+		b.sendAlert(cb, "Импорт устарел, загрузите файл заново.")
+		return
+	}
+
+	switch p.Arg(1) {
+	case importDownload:
+		b.sendAlert(cb, b.bulkDownload(ctx, p.Arg(0), session))
+	case importLike:
+		if !b.isAdmin(cb.From.ID) {
+			return
+		}
+		b.sendAlert(cb, b.bulkLike(ctx, session))
+	}
+}
+
+// bulkDownload queues every matched track until the quota or the queue runs out.
+func (b *Bot) bulkDownload(ctx context.Context, importID string, s importSession) string {
+	// One batched lookup warms the metadata cache for all queued jobs.
+	if _, err := b.musicService.Tracks(ctx, s.trackIDs); err != nil {
+		b.logger.Debug("prefetch import tracks failed", zap.Error(err))
+	}
+
+	queued := 0
+	stop := ""
+	for i, trackID := range s.trackIDs {
+		req := downloadRequest{
+			key:     fmt.Sprintf("%s:%d", importID, i),
+			userID:  s.userID,
+			chatID:  s.chatID,
+			trackID: trackID,
+			quiet:   true,
+			notify:  func(text string) { b.reply(s.chatID, text) },
+		}
+		_, quota, err := b.queueDownload(ctx, req)
+		if errors.Is(err, storage.ErrQuotaExceeded) {
+			stop = "\n" + quotaExceededText(quota, time.Now())
+			break
+		}
+		if err != nil {
+			stop = "\nОчередь загрузок заполнена, остальные треки запросите позже."
+			break
+		}
+		queued++
+	}
+	return fmt.Sprintf("Поставлено в очередь: %d из %d.%s", queued, len(s.trackIDs), stop)
+}
+
+// bulkLike adds the matches to the liked tracks of the bot's Yandex account.
+func (b *Bot) bulkLike(ctx context.Context, s importSession) string {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	for start := 0; start < len(s.trackIDs); start += likeBatch {
+		end := start + likeBatch
+		if end > len(s.trackIDs) {
+			end = len(s.trackIDs)
+		}
+		if err := b.musicService.LikeTracks(ctx, s.trackIDs[start:end]); err != nil {
+			b.logger.Warn("bulk like failed", zap.Int("done", start), zap.Error(err))
+			return fmt.Sprintf("Лайкнуто %d из %d, дальше ошибка: %v", start, len(s.trackIDs), err)
+		}
+	}
+	return fmt.Sprintf("❤️ Лайкнуто треков: %d.", len(s.trackIDs))
+}
+
+// importReport renders per-row match results as CSV.
+func importReport(results []importedRow) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"artist", "title", "status", "confidence", "yandex_id", "yandex_artists", "yandex_title"})
+	for _, r := range results {
+		status := "not_found"
+		switch {
+		case r.match.Confident():
+			status = "ok"
+		case r.match.Found():
+			status = "check"
+		}
+		_ = w.Write([]string{
+			r.row.Artist,
+			r.row.Title,
+			status,
+			fmt.Sprintf("%.2f", r.match.Score),
+			r.match.Track.ID,
+			r.match.Track.ArtistsString(),
+			r.match.Track.FullTitle(),
+		})
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+func (b *Bot) saveImport(s importSession) string {
+	var raw [4]byte
+	_, _ = rand.Read(raw[:])
+	id := hex.EncodeToString(raw[:])
+
+	b.importsMu.Lock()
+	defer b.importsMu.Unlock()
+	for key, old := range b.imports {
+		if time.Since(old.created) > importSessionTTL {
+			delete(b.imports, key)
+		}
+	}
+	b.imports[id] = s
+	return id
+}
+
+func (b *Bot) importFor(id string) (importSession, bool) {
+	b.importsMu.Lock()
+	defer b.importsMu.Unlock()
+	s, ok := b.imports[id]
+	if !ok || time.Since(s.created) > importSessionTTL {
+		return importSession{}, false
+	}
+	return s, true
+}
+
+// downloadFile fetches an uploaded file from the Bot API. A local server in
+// --local mode returns absolute paths that are read from disk directly.
+func (b *Bot) downloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	file, err := b.api.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return nil, err
+	}
+	if b.apiURL != "" && filepath.IsAbs(file.FilePath) {
+		return os.ReadFile(file.FilePath)
+	}
+
+	base := "https://api.telegram.org"
+	if b.apiURL != "" {
+		base = b.apiURL
+	}
+	link := fmt.Sprintf("%s/file/bot%s/%s", base, b.api.Token, strings.TrimPrefix(file.FilePath, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := b.api.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download file: status=%d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxImportFile))
+}
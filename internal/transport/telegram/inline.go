@@ -0,0 +1,104 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// inlineLinkMargin keeps cached inline answers from outliving the audio URLs
+// in them: Telegram fetches a URL only once a result is picked.
+const inlineLinkMargin = 15 * time.Second
+
+// inlineProbeTimeout bounds the checks of the audio URLs of an answer: they
+// run at once and share it, so a page of slow hosts costs one timeout.
+const inlineProbeTimeout = 2 * time.Second
+
+// inlineLookups is how many results of an answer resolve their audio URLs
+// at once.
+const inlineLookups = 10
+
+// inlineCacheTime is the cache_time, in seconds, of a personal or anonymous
+// answer, cut short so the earliest audio URL in it (zero when there is none
+// or its expiry is unknown) does not expire while cached.
+func (b *Bot) inlineCacheTime(personal bool, expires time.Time) int {
+	s := b.settings.Load()
+	d := s.InlineCache
+	if personal {
+		d = s.InlinePersonalCache
+	}
+
+	if !expires.IsZero() {
+		d = min(d, time.Until(expires)-inlineLinkMargin)
+	}
+	return max(int(d/time.Second), 0)
+}
+
+// inlineNextOffset is the offset of the page after one that ends at next,
+// "" when the page was empty or the configured maximum is reached.
+func (b *Bot) inlineNextOffset(next int, empty bool) string {
+	limit := b.settings.Load().InlineMax
+
+	if empty || (limit > 0 && next >= limit) {
+		return ""
+	}
+	return strconv.Itoa(next)
+}
+
+// inlinePage cuts the tracks of the page at offset to the configured
+// number of results in all. Pages are searched whole, as Yandex pages by
+// searchLimit.
+func (b *Bot) inlinePage(tracks []yandex.Track, offset int) []yandex.Track {
+	limit := b.settings.Load().InlineMax
+	if limit > 0 && offset+len(tracks) > limit {
+		return tracks[:max(limit-offset, 0)]
+	}
+	return tracks
+}
+
+// inlineResults builds the results of tracks concurrently, keeping their
+// order and dropping those without one, and tells when the earliest audio
+// URL among them expires.
+func (b *Bot) inlineResults(ctx context.Context, userID int64, tracks []yandex.Track, group bool) ([]interface{}, time.Time) {
+	type built struct {
+		result  interface{}
+		expires time.Time
+		ok      bool
+	}
+	probeBy := time.Now().Add(inlineProbeTimeout)
+	out := make([]built, len(tracks))
+	slots := make(chan struct{}, inlineLookups)
+	var wg sync.WaitGroup
+	for i, track := range tracks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			r, exp, ok := b.inlineTrack(ctx, userID, track, group, probeBy)
+			out[i] = built{r, exp, ok}
+		}()
+	}
+	wg.Wait()
+
+	var expires time.Time
+	results := make([]interface{}, 0, len(tracks))
+	for _, r := range out {
+		if r.ok {
+			results = append(results, r.result)
+			expires = earliest(expires, r.expires)
+		}
+	}
+	return results, expires
+}
+
+// earliest returns the earlier of two expiries, ignoring unknown (zero) ones.
+func earliest(a, b time.Time) time.Time {
+	if a.IsZero() || (!b.IsZero() && b.Before(a)) {
+		return b
+	}
+	return a
+}
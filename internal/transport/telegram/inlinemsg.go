@@ -0,0 +1,75 @@
+package telegram
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+)
+
+// Messages sent inline on the bot's behalf belong to chats the bot may not
+// be in: Telegram gives them no chat or message id, only an inline message
+// id, and the callbacks of their buttons come without a message. They are
+// edited by that id, and only their keyboard and content can change; a new
+// file cannot be uploaded into them, one sent elsewhere is reused by id.
+
+// showInline puts the status into the keyboard of the inline message, as a
+// button that cancels the download; the caller holds s.mu.
+func (s *jobStatus) showInline(text string) {
+	kb := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		s.b.button(text+" ✖", callback.ActionCancel, s.key),
+	))
+	s.b.editInlineMarkup(s.inlineID, kb)
+}
+
+// restoreInline gives the inline message its keyboard back once the status
+// is over; the caller holds s.mu.
+func (s *jobStatus) restoreInline() {
+	if s.inlineID == "" || s.text == "" {
+		return
+	}
+	s.b.editInlineMarkup(s.inlineID, *s.b.cardMarkup(yandex.Track{ID: s.trackID}, s.group))
+	s.text = ""
+}
+
+// editInlineMarkup replaces the keyboard of an inline message.
+func (b *Bot) editInlineMarkup(inlineMessageID string, kb tgbotapi.InlineKeyboardMarkup) {
+	edit := tgbotapi.EditMessageReplyMarkupConfig{
+		BaseEdit: tgbotapi.BaseEdit{InlineMessageID: inlineMessageID, ReplyMarkup: &kb},
+	}
+	if _, err := b.sender.Request(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		b.logger.Debug("inline keyboard edit failed", zap.Error(err))
+	}
+}
+
+// replaceInline puts the file just delivered in sent into the inline message
+// the download was requested from, in place of its card or URL audio, under
+// the keyboard it was sent with.
+func (b *Bot) replaceInline(inlineMessageID string, sent tgbotapi.Message, trackID string, group bool) {
+	var media interface{}
+	switch {
+	case sent.Audio != nil:
+		audio := tgbotapi.NewInputMediaAudio(tgbotapi.FileID(sent.Audio.FileID))
+		audio.Caption = sent.Caption
+		media = audio
+	case sent.Document != nil:
+		doc := tgbotapi.NewInputMediaDocument(tgbotapi.FileID(sent.Document.FileID))
+		doc.Caption = sent.Caption
+		media = doc
+	default:
+		return
+	}
+	edit := tgbotapi.EditMessageMediaConfig{
+		BaseEdit: tgbotapi.BaseEdit{
+			InlineMessageID: inlineMessageID,
+			ReplyMarkup:     b.cardMarkup(yandex.Track{ID: trackID}, group),
+		},
+		Media: media,
+	}
+	if _, err := b.sender.Request(edit); err != nil {
+		b.logger.Warn("replace inline message failed", zap.String("trackID", trackID), zap.Error(err))
+	}
+}
@@ -0,0 +1,229 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/queue"
+	"ym-bot/internal/storage"
+)
+
+const (
+	downloadingText = "⬇️ Загружаем трек…"
+	cancelledText   = "❌ Загрузка отменена."
+)
+
+// jobStatus is the chat message that follows a queued download: its place in
+// line, then progress, with a Cancel button. It is created lazily and removed
+// once delivery ends.
+type jobStatus struct {
+	b          *Bot
+	key        string
+	userID     int64
+	chatID     int64
+	reservedAt time.Time
+	// release frees what the download held, for jobs dropped before they ran.
+	release func()
+	// inlineID is set for downloads requested from a message sent inline,
+	// which shows the status in its keyboard instead, see showInline; group
+	// tells its keyboard is the party button.
+	inlineID string
+	group    bool
+	trackID  string
+
+	mu     sync.Mutex
+	msgID  int
+	text   string
+	closed bool
+	// aborted is set when the user cancels, telling it apart from a shutdown.
+	aborted bool
+}
+
+// trackJob registers a status for the download keyed by key.
+func (b *Bot) trackJob(key string, userID, chatID int64, reservedAt time.Time) *jobStatus {
+	s := &jobStatus{b: b, key: key, userID: userID, chatID: chatID, reservedAt: reservedAt}
+	b.jobsMu.Lock()
+	b.jobs[key] = s
+	b.jobsMu.Unlock()
+	return s
+}
+
+// newProgress returns a status message without a Cancel button, for long
+// tasks that do not run on the download queue.
+func newProgress(b *Bot, chatID int64) *jobStatus {
+	return &jobStatus{b: b, chatID: chatID}
+}
+
+// finishJob forgets the download and removes its status message.
+func (b *Bot) finishJob(s *jobStatus) {
+	if s == nil {
+		return
+	}
+	b.jobsMu.Lock()
+	delete(b.jobs, s.key)
+	b.jobsMu.Unlock()
+	s.close()
+}
+
+func (b *Bot) jobFor(key string) *jobStatus {
+	b.jobsMu.Lock()
+	defer b.jobsMu.Unlock()
+	return b.jobs[key]
+}
+
+// handleCancelCallback aborts a waiting or running download owned by the user.
+func (b *Bot) handleCancelCallback(_ context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	key := p.Arg(0)
+	s := b.jobFor(key)
+	if s == nil || s.userID != cb.From.ID || b.pool == nil {
+		b.sendAlert(cb, "Загрузка уже завершена.")
+		return
+	}
+
+	s.abort()
+	switch b.pool.Cancel(key) {
+	case queue.NotFound:
+		b.sendAlert(cb, "Загрузка уже завершена.")
+		return
+	case queue.Dequeued:
+		// The job never ran, so nobody else will return the reserved quota.
+		b.store.ReleaseQuota(s.userID, s.reservedAt)
+		b.finishJob(s)
+		if s.release != nil {
+			s.release()
+		}
+		b.setJobState(key, storage.JobCancelled)
+	case queue.Interrupted:
+		// deliver sees the cancelled context, releases quota and cleans up the files.
+	}
+	s.cancelled()
+
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, cancelledText)); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+}
+
+// abort marks the job as cancelled by its owner.
+func (s *jobStatus) abort() {
+	s.mu.Lock()
+	s.aborted = true
+	s.mu.Unlock()
+}
+
+func (s *jobStatus) abortedByUser() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aborted
+}
+
+// show sends or edits the status message; it is a no-op after close.
+func (s *jobStatus) show(text string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || text == s.text {
+		return
+	}
+	s.text = text
+	if s.inlineID != "" {
+		s.showInline(text)
+		return
+	}
+	var markup *tgbotapi.InlineKeyboardMarkup
+	if s.key != "" {
+		kb := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			s.b.button("✖ Отменить", callback.ActionCancel, s.key),
+		))
+		markup = &kb
+	}
+
+	if s.msgID == 0 {
+		msg := tgbotapi.NewMessage(s.chatID, text)
+		if markup != nil {
+			msg.ReplyMarkup = *markup
+		}
+		sent, err := s.b.sender.Send(msg)
+		if err != nil {
+			s.b.logger.Debug("job status send failed", zap.Int64("chatID", s.chatID), zap.Error(err))
+			return
+		}
+		s.msgID = sent.MessageID
+		if s.key != "" {
+			// Kept so a restart can remove the message, see recoverJobs.
+			s.b.store.SetJobStatusMessage(s.key, s.msgID)
+		}
+		return
+	}
+	edit := tgbotapi.NewEditMessageText(s.chatID, s.msgID, text)
+	edit.ReplyMarkup = markup
+	if _, err := s.b.sender.Request(edit); err != nil {
+		s.b.logger.Debug("job status edit failed", zap.Int64("chatID", s.chatID), zap.Error(err))
+	}
+}
+
+// cancelled replaces the status with a confirmation that stays in the chat.
+func (s *jobStatus) cancelled() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.restoreInline()
+	if s.msgID == 0 {
+		return
+	}
+	if _, err := s.b.sender.Request(tgbotapi.NewEditMessageText(s.chatID, s.msgID, cancelledText)); err != nil {
+		s.b.logger.Debug("job status edit failed", zap.Int64("chatID", s.chatID), zap.Error(err))
+	}
+	s.msgID = 0
+}
+
+// close deletes the status message and stops further updates.
+func (s *jobStatus) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.restoreInline()
+	if s.msgID == 0 {
+		return
+	}
+	if _, err := s.b.sender.Request(tgbotapi.NewDeleteMessage(s.chatID, s.msgID)); err != nil {
+		s.b.logger.Debug("job status delete failed", zap.Int64("chatID", s.chatID), zap.Error(err))
+	}
+	s.msgID = 0
+}
+
+// follow mirrors the ticket's position into the status message until the
+// job starts or is dequeued.
+func (s *jobStatus) follow(t *queue.Ticket) {
+	for range t.Updates() {
+		if pos := s.b.pool.Position(t); pos > 0 {
+			s.show(s.b.queueText(pos))
+		}
+	}
+}
+
+// queueText describes pos in line; a queue held for maintenance has no ETA.
+func (b *Bot) queueText(pos int) string {
+	if b.store.Maintenance().On && b.holdingDownloads() {
+		return fmt.Sprintf("🛠 Вы в очереди: №%d. Загрузка начнётся после технических работ.", pos)
+	}
+	return fmt.Sprintf("⏳ Вы в очереди: №%d, ожидание %s.", pos, etaText(b.pool.ETA(pos)))
+}
+
+func etaText(d time.Duration) string {
+	if d < time.Minute {
+		secs := int((d + time.Second - 1) / time.Second)
+		if secs < 1 {
+			secs = 1
+		}
+		return fmt.Sprintf("~%d с", secs)
+	}
+	return "~" + humanDuration(d)
+}
@@ -0,0 +1,250 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+)
+
+const (
+	// karaokeCountdown is how many seconds are counted down before the song.
+	karaokeCountdown = 3
+	// karaokePrivateInterval and karaokeGroupInterval space the messages of
+	// a song: Telegram lets a bot post about a message a second to a chat and
+	// some twenty a minute to a group. Lines that fall due in between are
+	// sent together.
+	karaokePrivateInterval = time.Second
+	karaokeGroupInterval   = 3 * time.Second
+	// karaokeSearchSize is how many search results are looked through for a
+	// track with timed lyrics.
+	karaokeSearchSize = 10
+)
+
+const karaokeUsage = "Использование: /karaoke <трек> — бот отсчитает «3, 2, 1» и будет присылать строки песни в такт; " +
+	"включите трек на «Поехали!». /karaoke stop — остановить."
+
+// karaokeSession is a song being sung in a chat.
+type karaokeSession struct {
+	// id tells the stop buttons of this song from those of earlier ones.
+	id     string
+	userID int64
+	cancel context.CancelFunc
+}
+
+// handleKaraoke finds a track with timed lyrics, counts down and posts its
+// lines as they are sung; "/karaoke stop" ends the song early. A chat sings
+// one song at a time.
+func (b *Bot) handleKaraoke(ctx context.Context, m *tgbotapi.Message) {
+	query := strings.TrimSpace(m.CommandArguments())
+	if strings.EqualFold(query, "stop") {
+		b.reply(m.Chat.ID, b.stopKaraoke(m.Chat.ID, m.From.ID, ""))
+		return
+	}
+	if query == "" {
+		b.reply(m.Chat.ID, karaokeUsage)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &karaokeSession{id: strconv.Itoa(m.MessageID), userID: m.From.ID, cancel: cancel}
+	b.karaokeMu.Lock()
+	busy := b.karaokes[m.Chat.ID] != nil
+	if !busy {
+		b.karaokes[m.Chat.ID] = s
+	}
+	b.karaokeMu.Unlock()
+	if busy {
+		cancel()
+		b.reply(m.Chat.ID, "Караоке уже идёт. /karaoke stop — остановить.")
+		return
+	}
+	started := false
+	defer func() {
+		if !started {
+			b.endKaraoke(m.Chat.ID, s)
+		}
+	}()
+
+	t, lines, err := b.karaokeTrack(ctx, m.Chat.ID, m.From.ID, query)
+	if err != nil {
+		b.logger.Warn("karaoke lookup failed", zap.String("query", query), zap.Error(err))
+		if errors.Is(err, music.ErrNoLyrics) {
+			b.reply(m.Chat.ID, "Не нашлось трека с синхронизированным текстом, попробуйте уточнить запрос.")
+			return
+		}
+		b.reply(m.Chat.ID, presentError(err, errGeneric).String())
+		return
+	}
+
+	started = true
+	go b.runKaraoke(ctx, m.Chat.ID, s, t, lines)
+}
+
+// karaokeTrack picks the first search result with timed lyrics, skipping
+// explicit tracks in chats that hide them, and returns its lines.
+func (b *Bot) karaokeTrack(ctx context.Context, chatID, userID int64, query string) (yandex.Track, []yandex.LyricLine, error) {
+	ctx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+	defer cancel()
+
+	res, err := b.musicService.Search(ctx, query, b.searchOrder(userID), karaokeSearchSize, 0)
+	if err != nil {
+		return yandex.Track{}, nil, err
+	}
+	hideExplicit := b.store.ChatSettings(chatID).HideExplicit
+	for _, t := range res.Tracks {
+		if !t.HasSyncedLyrics || (hideExplicit && t.Explicit) {
+			continue
+		}
+		t, l, err := b.musicService.Lyrics(ctx, t.ID)
+		if errors.Is(err, music.ErrNoLyrics) {
+			continue
+		}
+		if err != nil {
+			return yandex.Track{}, nil, err
+		}
+		if lines := yandex.ParseLRC(l.LRC); len(lines) > 0 {
+			return t, lines, nil
+		}
+	}
+	return yandex.Track{}, nil, music.ErrNoLyrics
+}
+
+// runKaraoke counts down in a message with a stop button and then posts the
+// lines at their times, until the song ends or is stopped.
+func (b *Bot) runKaraoke(ctx context.Context, chatID int64, s *karaokeSession, t yandex.Track, lines []yandex.LyricLine) {
+	defer b.endKaraoke(chatID, s)
+
+	title := fmt.Sprintf("🎤 Караоке: %s — %s (%s)", t.ArtistsString(), t.FullTitle(), t.DurationString())
+	stop := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		b.button("⏹ Стоп", callback.ActionKaraoke, s.id)))
+	msg := tgbotapi.NewMessage(chatID, title+"\nВключайте трек на «Поехали!»")
+	msg.ReplyMarkup = stop
+	control, err := b.sender.Send(msg)
+	if err != nil {
+		b.logger.Warn("send karaoke failed", zap.Int64("chatID", chatID), zap.Error(err))
+		return
+	}
+	defer b.editMarkup(&control, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+
+	for n := karaokeCountdown; n > 0; n-- {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, control.MessageID, fmt.Sprintf("%s\n%d…", title, n), stop)
+		if _, err := b.sender.Request(edit); err != nil {
+			b.logger.Debug("karaoke countdown failed", zap.Int64("chatID", chatID), zap.Error(err))
+		}
+		if !sleepCtx(ctx, time.Second) {
+			return
+		}
+	}
+	edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, control.MessageID, title+"\n▶️ Поехали!", stop)
+	if _, err := b.sender.Request(edit); err != nil {
+		b.logger.Debug("karaoke countdown failed", zap.Int64("chatID", chatID), zap.Error(err))
+	}
+
+	interval := karaokeGroupInterval
+	if chatID > 0 {
+		interval = karaokePrivateInterval
+	}
+	start := time.Now()
+	var last time.Time
+	for i := 0; i < len(lines); {
+		if !sleepCtx(ctx, time.Until(start.Add(lines[i].At))) || !sleepCtx(ctx, time.Until(last.Add(interval))) {
+			return
+		}
+		// Everything due by now goes in one message.
+		var due []string
+		for ; i < len(lines) && time.Since(start) >= lines[i].At; i++ {
+			if lines[i].Text != "" {
+				due = append(due, lines[i].Text)
+			}
+		}
+		if len(due) == 0 {
+			continue
+		}
+		wait, err := b.sendKaraokeLine(chatID, "🎶 "+strings.Join(due, "\n🎶 "))
+		last = time.Now().Add(wait)
+		if err != nil {
+			b.logger.Warn("send karaoke line failed", zap.Int64("chatID", chatID), zap.Error(err))
+			if wait == 0 {
+				return
+			}
+		}
+	}
+	if sleepCtx(ctx, time.Until(last.Add(interval))) {
+		b.reply(chatID, "👏 Вот и песне конец!")
+	}
+}
+
+// sendKaraokeLine posts text; when Telegram asks to slow down it returns how
+// long to wait, the lines due meanwhile being merged into the next message.
+func (b *Bot) sendKaraokeLine(chatID int64, text string) (time.Duration, error) {
+	_, err := b.sender.Send(tgbotapi.NewMessage(chatID, text))
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) && tgErr.Code == http.StatusTooManyRequests {
+		return max(time.Duration(tgErr.RetryAfter)*time.Second, time.Second), err
+	}
+	return 0, err
+}
+
+// handleKaraokeCallback stops the song the button belongs to.
+func (b *Bot) handleKaraokeCallback(_ context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	if cb.Message == nil || cb.Message.Chat == nil {
+		return
+	}
+	text := b.stopKaraoke(cb.Message.Chat.ID, cb.From.ID, p.Arg(0))
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, text)); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+}
+
+// stopKaraoke ends the song of chatID, or only song id when it is set, if
+// userID started it or administers the bot or the chat; it returns what to
+// tell the user.
+func (b *Bot) stopKaraoke(chatID, userID int64, id string) string {
+	b.karaokeMu.Lock()
+	s := b.karaokes[chatID]
+	b.karaokeMu.Unlock()
+	if s == nil || (id != "" && s.id != id) {
+		return "Караоке в этом чате не идёт."
+	}
+	if userID != s.userID && !b.isAdmin(userID) && !b.isChatAdmin(chatID, userID) {
+		return "Остановить караоке может только тот, кто его начал."
+	}
+	s.cancel()
+	return "⏹ Караоке остановлено."
+}
+
+// endKaraoke frees the chat for the next song.
+func (b *Bot) endKaraoke(chatID int64, s *karaokeSession) {
+	s.cancel()
+	b.karaokeMu.Lock()
+	if b.karaokes[chatID] == s {
+		delete(b.karaokes, chatID)
+	}
+	b.karaokeMu.Unlock()
+}
+
+// sleepCtx waits for d and reports false if ctx ended first.
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
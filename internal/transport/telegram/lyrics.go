@@ -0,0 +1,138 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+)
+
+// maxLyricsPart keeps every part of long lyrics under Telegram's 4096 limit,
+// leaving room for the heading.
+const maxLyricsPart = 3800
+
+// handleLyricsCallback sends the words of a track in reply to it: the plain
+// text as messages and, when Yandex has them timed, an .lrc file players
+// show in sync with the audio.
+func (b *Bot) handleLyricsCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	trackID := p.Arg(0)
+	if trackID == "" {
+		return
+	}
+	chatID := cb.From.ID
+	if cb.Message != nil && cb.Message.Chat != nil {
+		chatID = cb.Message.Chat.ID
+	}
+	press := "lyrics:" + pressKey(cb.From.ID, trackID)
+	if !b.presses.claim(press, false) {
+		b.sendAlert(cb, "Текст уже загружается.")
+		return
+	}
+	defer b.presses.done(press)
+
+	ctx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+	defer cancel()
+
+	t, l, err := b.musicService.Lyrics(ctx, trackID)
+	if err != nil {
+		b.logger.Warn("lyrics failed", zap.String("trackID", trackID), zap.Error(err))
+		if errors.Is(err, music.ErrNoLyrics) {
+			b.sendAlert(cb, "У этого трека нет текста.")
+			return
+		}
+		b.sendAlert(cb, presentError(err, errGeneric).String())
+		return
+	}
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, "")); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+
+	replyTo := 0
+	if cb.Message != nil {
+		replyTo = cb.Message.MessageID
+	}
+	heading := fmt.Sprintf("📝 %s — %s", t.ArtistsString(), t.FullTitle())
+	if len(l.Writers) > 0 {
+		heading += "\nСлова: " + strings.Join(l.Writers, ", ")
+	}
+	for i, part := range splitLyrics(l.Text, maxLyricsPart) {
+		text := part
+		if i == 0 {
+			text = heading + "\n\n" + part
+		}
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ReplyToMessageID = replyTo
+		if _, err := b.sender.Send(msg); err != nil {
+			b.logger.Warn("send lyrics failed", zap.Int64("chatID", chatID), zap.Error(err))
+			return
+		}
+	}
+	if !l.Synced() {
+		return
+	}
+
+	// Named as the audio file the user got, so players pair the two.
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{
+		Name:  b.musicService.FileName(ctx, t, b.transliteration(cb.From.ID)) + ".lrc",
+		Bytes: []byte(lrcFile(t, l)),
+	})
+	doc.Caption = "Синхронизированный текст: положите файл рядом с треком под тем же именем."
+	doc.ReplyToMessageID = replyTo
+	if _, err := b.sender.Send(doc); err != nil {
+		b.logger.Warn("send lrc failed", zap.Int64("chatID", chatID), zap.Error(err))
+	}
+}
+
+// lrcFile returns the LRC text of l with the title, artist and album tags
+// players display added where Yandex left them out.
+func lrcFile(t yandex.Track, l yandex.Lyrics) string {
+	var sb strings.Builder
+	for _, tag := range []struct{ key, value string }{
+		{"ti", t.FullTitle()},
+		{"ar", t.ArtistsString()},
+		{"al", t.AlbumTitle},
+	} {
+		if tag.value != "" && !strings.Contains(l.LRC, "["+tag.key+":") {
+			fmt.Fprintf(&sb, "[%s:%s]\n", tag.key, tag.value)
+		}
+	}
+	sb.WriteString(l.LRC)
+	sb.WriteByte('\n')
+	return sb.String()
+}
+
+// splitLyrics cuts text into parts of at most limit runes, between stanzas
+// where it can and between lines otherwise.
+func splitLyrics(text string, limit int) []string {
+	var parts []string
+	var cur strings.Builder
+	size := 0
+	flush := func() {
+		if s := strings.TrimSpace(cur.String()); s != "" {
+			parts = append(parts, s)
+		}
+		cur.Reset()
+		size = 0
+	}
+	for _, line := range strings.Split(text, "\n") {
+		n := len([]rune(line)) + 1
+		if size+n > limit || (line == "" && size > limit*3/4) {
+			flush()
+		}
+		if n > limit {
+			line, n = truncate(line, limit-1), limit
+		}
+		cur.WriteString(line)
+		cur.WriteByte('\n')
+		size += n
+	}
+	flush()
+	return parts
+}
@@ -0,0 +1,127 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/storage"
+)
+
+const maintenanceUsage = "Использование: /maintenance [on [длительность] [сообщение]|off]"
+
+// underMaintenance returns the maintenance state when it applies to userID;
+// admins keep full access to check the bot during works.
+func (b *Bot) underMaintenance(userID int64) (storage.Maintenance, bool) {
+	m := b.store.Maintenance()
+	if !m.On || b.isAdmin(userID) {
+		return m, false
+	}
+	return m, true
+}
+
+// holdingDownloads reports whether downloads wait in the paused queue
+// during maintenance rather than being rejected.
+func (b *Bot) holdingDownloads() bool {
+	return b.settings.Load().MaintenanceHold && b.pool != nil
+}
+
+// syncMaintenance pauses the download queue while maintenance holds downloads
+// or an admin paused it with /queue, and resumes it otherwise.
+func (b *Bot) syncMaintenance() {
+	if b.pool == nil {
+		return
+	}
+	if (b.store.Maintenance().On && b.holdingDownloads()) || b.store.QueuePaused() {
+		b.pool.Pause()
+		return
+	}
+	b.pool.Resume()
+}
+
+// maintenanceText renders the downtime message with the announced end, if any.
+func (b *Bot) maintenanceText(m storage.Maintenance) string {
+	text := m.Note
+	if text == "" {
+		text = b.settings.Load().MaintenanceMessage
+	}
+	if left := time.Until(m.Until); left > 0 {
+		text += fmt.Sprintf("\nОриентировочно до %s UTC (через %s).", m.Until.UTC().Format("15:04"), humanDuration(left))
+	}
+	return text
+}
+
+// answerInlineMaintenance replaces inline results with the downtime notice.
+func (b *Bot) answerInlineMaintenance(q *tgbotapi.InlineQuery, m storage.Maintenance) {
+	text := b.maintenanceText(m)
+	article := tgbotapi.NewInlineQueryResultArticle("maintenance", "🛠 Технические работы", text)
+	article.Description = text
+	ans := tgbotapi.InlineConfig{
+		InlineQueryID: q.ID,
+		IsPersonal:    true,
+		Results:       []interface{}{article},
+	}
+	if _, err := b.sender.Request(ans); err != nil {
+		b.logger.Warn("answer inline failed", zap.String("query", q.Query), zap.Error(err))
+	}
+}
+
+// handleMaintenance shows or toggles maintenance mode.
+func (b *Bot) handleMaintenance(_ context.Context, m *tgbotapi.Message) {
+	args := strings.Fields(m.CommandArguments())
+	if len(args) == 0 {
+		state := b.store.Maintenance()
+		if !state.On {
+			b.reply(m.Chat.ID, "Режим обслуживания выключен.\n"+maintenanceUsage)
+			return
+		}
+		b.reply(m.Chat.ID, fmt.Sprintf("Режим обслуживания включён с %s UTC. Пользователи видят:\n\n%s",
+			state.Since.UTC().Format("02.01 15:04"), b.maintenanceText(state)))
+		return
+	}
+
+	var state storage.Maintenance
+	switch strings.ToLower(args[0]) {
+	case "on":
+		state = storage.Maintenance{On: true, Since: time.Now()}
+		rest := args[1:]
+		if len(rest) > 0 {
+			if d, err := time.ParseDuration(rest[0]); err == nil && d > 0 {
+				state.Until = state.Since.Add(d)
+				rest = rest[1:]
+			}
+		}
+		state.Note = strings.Join(rest, " ")
+	case "off":
+	default:
+		b.reply(m.Chat.ID, maintenanceUsage)
+		return
+	}
+
+	if err := b.store.SetMaintenance(state); err != nil {
+		b.logger.Warn("save maintenance failed", zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось сохранить режим обслуживания.")
+		return
+	}
+	b.syncMaintenance()
+	b.logger.Info("maintenance toggled", zap.Int64("userID", m.From.ID), zap.Bool("on", state.On))
+
+	if !state.On {
+		if b.store.QueuePaused() {
+			b.reply(m.Chat.ID, "Режим обслуживания выключен, очередь загрузок остаётся на паузе (/queue resume).")
+			return
+		}
+		b.reply(m.Chat.ID, "Режим обслуживания выключен, очередь загрузок возобновлена.")
+		return
+	}
+	downloads := "новые загрузки отклоняются"
+	if b.holdingDownloads() {
+		downloads = "новые загрузки ждут в очереди"
+	}
+	b.reply(m.Chat.ID, fmt.Sprintf("Режим обслуживания включён, %s. Пользователи видят:\n\n%s",
+		downloads, b.maintenanceText(state)))
+}
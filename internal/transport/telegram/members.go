@@ -0,0 +1,62 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const welcomeBackText = "👋 С возвращением! Пришли название трека или набери @%s в любом чате. Все команды — /help."
+
+// handleMyChatMember follows the bot's own membership: a user who blocks it
+// or a group that removes it is marked inactive, so podcast alerts stop; a
+// user who unblocks it is welcomed back and a group that adds it gets the
+// help. A user starting the bot for the first time is greeted by /start. Groups that take away sending messages or media are left, as the
+// bot could not answer there anyway.
+func (b *Bot) handleMyChatMember(_ context.Context, u *tgbotapi.ChatMemberUpdated) {
+	chat, member := u.Chat, u.NewChatMember
+	if chat.IsChannel() {
+		return
+	}
+	fields := []zap.Field{zap.Int64("chatID", chat.ID), zap.Int64("userID", u.From.ID), zap.String("status", member.Status)}
+
+	if member.HasLeft() || member.WasKicked() {
+		b.logger.Info("bot removed from chat", fields...)
+		b.setChatInactive(chat.ID, true)
+		return
+	}
+	if member.Status == "restricted" && (!member.CanSendMessages || !member.CanSendMediaMessages) {
+		b.logger.Info("leaving chat without send permissions", fields...)
+		if _, err := b.sender.Request(tgbotapi.LeaveChatConfig{ChatID: chat.ID}); err != nil {
+			b.logger.Warn("leave chat failed", zap.Int64("chatID", chat.ID), zap.Error(err))
+		}
+		b.setChatInactive(chat.ID, true)
+		return
+	}
+
+	returned := b.setChatInactive(chat.ID, false)
+	old := u.OldChatMember
+	if !old.HasLeft() && !old.WasKicked() {
+		return // promoted or restricted while staying in the chat
+	}
+	b.logger.Info("bot joined chat", fields...)
+	if chat.IsPrivate() {
+		if returned {
+			b.reply(chat.ID, fmt.Sprintf(welcomeBackText, b.api.Self.UserName))
+		}
+		return
+	}
+	b.reply(chat.ID, fmt.Sprintf(b.startTextFor(chat.ID), b.api.Self.UserName))
+}
+
+// setChatInactive records the chat's activity for this bot and reports
+// whether it changed.
+func (b *Bot) setChatInactive(chatID int64, inactive bool) bool {
+	changed, err := b.store.SetChatInactive(b.api.Self.UserName, chatID, inactive)
+	if err != nil {
+		b.logger.Warn("save chat activity failed", zap.Int64("chatID", chatID), zap.Error(err))
+	}
+	return changed
+}
@@ -0,0 +1,110 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+)
+
+// menuScreen is what one step of an inline menu shows.
+type menuScreen struct {
+	text     string
+	keyboard tgbotapi.InlineKeyboardMarkup
+	// notice is a toast shown for the button press, e.g. "saved".
+	notice string
+}
+
+// menuRequest is one navigation step. All menu state lives in the payload of
+// the pressed button, so any screen can be rebuilt from it alone; message is
+// the menu being edited and is nil when the menu is first opened.
+type menuRequest struct {
+	userID  int64
+	payload callback.Payload
+	message *tgbotapi.Message
+}
+
+// menuRenderer builds the screen for a request. It logs its own failures and
+// returns a menuAlert telling the user what went wrong.
+type menuRenderer func(ctx context.Context, req menuRequest) (menuScreen, error)
+
+// menuAlert is a renderer error shown to the user verbatim.
+type menuAlert string
+
+func (a menuAlert) Error() string { return string(a) }
+
+// menuRenderers maps each menu's callback action to the renderer of its screens.
+func (b *Bot) menuRenderers() map[callback.Action]menuRenderer {
+	return map[callback.Action]menuRenderer{
+		callback.ActionSettings:     b.settingsMenu,
+		callback.ActionPage:         b.searchMenu,
+		callback.ActionBrowse:       b.playlistsMenu,
+		callback.ActionGenre:        b.genresMenu,
+		callback.ActionChatSettings: b.chatSettingsMenu,
+		callback.ActionPodcast:      b.podcastMenu,
+		callback.ActionDiscover:     b.discoverMenu,
+	}
+}
+
+// openMenu sends the screen for action and args as a new message; later
+// steps edit that message in place (see navigateMenu).
+func (b *Bot) openMenu(ctx context.Context, chatID, userID int64, action callback.Action, args ...string) {
+	ctx, cancel := context.WithTimeout(ctx, b.inlineTimeout)
+	defer cancel()
+
+	screen, err := b.menus[action](ctx, menuRequest{
+		userID:  userID,
+		payload: callback.Payload{Action: action, Args: args},
+	})
+	if err != nil {
+		b.reply(chatID, alertText(err))
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, screen.text)
+	msg.ReplyMarkup = screen.keyboard
+	if _, err := b.sender.Send(msg); err != nil {
+		b.logger.Warn("send menu failed", zap.String("action", string(action)), zap.Int64("chatID", chatID), zap.Error(err))
+	}
+}
+
+// navigateMenu answers a menu button by editing its message into the next screen.
+func (b *Bot) navigateMenu(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload, render menuRenderer) {
+	if cb.Message == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.inlineTimeout)
+	defer cancel()
+
+	screen, err := render(ctx, menuRequest{userID: cb.From.ID, payload: p, message: cb.Message})
+	if err != nil {
+		b.sendAlert(cb, alertText(err))
+		return
+	}
+
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, screen.notice)); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+	b.presses.replaced(messageKey(cb.Message))
+	edit := tgbotapi.NewEditMessageTextAndMarkup(cb.Message.Chat.ID, cb.Message.MessageID, screen.text, screen.keyboard)
+	if _, err := b.sender.Request(edit); err != nil {
+		// Pressing the button of the current screen changes nothing; Telegram refuses such edits.
+		if strings.Contains(err.Error(), "message is not modified") {
+			return
+		}
+		b.logger.Warn("edit menu failed", zap.String("action", string(p.Action)), zap.Error(err))
+	}
+}
+
+func alertText(err error) string {
+	var alert menuAlert
+	if errors.As(err, &alert) {
+		return string(alert)
+	}
+	return presentError(err, errGeneric).String()
+}
@@ -0,0 +1,108 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// updateHandler processes one update from Telegram.
+type updateHandler func(ctx context.Context, u tgbotapi.Update)
+
+// middleware wraps an updateHandler with behaviour shared by all updates.
+type middleware func(next updateHandler) updateHandler
+
+// chain wraps h in mws; the first middleware sees the update first.
+func chain(h updateHandler, mws ...middleware) updateHandler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// panicText is the reply to an update whose handler crashed.
+const panicText = "Что-то пошло не так, попробуйте ещё раз."
+
+// recoverPanics keeps a panicking handler from taking the whole bot down and
+// tells the user the request failed.
+func (b *Bot) recoverPanics(next updateHandler) updateHandler {
+	return func(ctx context.Context, u tgbotapi.Update) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			fields := []zap.Field{zap.Int("updateID", u.UpdateID), zap.String("kind", updateKind(u))}
+			if from := u.SentFrom(); from != nil {
+				fields = append(fields, zap.Int64("userID", from.ID))
+			}
+			if chat := updateChat(u); chat != nil {
+				fields = append(fields, zap.Int64("chatID", chat.ID))
+			}
+			b.logPanic(r, "update handler", fields...)
+			switch {
+			case u.CallbackQuery != nil:
+				b.sendAlert(u.CallbackQuery, panicText)
+			case u.Message != nil && (u.Message.Chat.IsPrivate() || u.Message.IsCommand()):
+				b.reply(u.Message.Chat.ID, panicText)
+			}
+		}()
+		next(ctx, u)
+	}
+}
+
+// logPanic logs a recovered panic value with the stack and counts it in /stats.
+func (b *Bot) logPanic(r any, where string, fields ...zap.Field) {
+	fields = append(fields, zap.String("panic", fmt.Sprint(r)), zap.Stack("stack"))
+	b.logger.Error(where+" panicked", fields...)
+	b.store.RecordPanic(time.Now())
+}
+
+// logUpdates logs every update with its sender and handling time at debug level.
+func (b *Bot) logUpdates(next updateHandler) updateHandler {
+	return func(ctx context.Context, u tgbotapi.Update) {
+		start := time.Now()
+		next(ctx, u)
+		fields := []zap.Field{
+			zap.Int("updateID", u.UpdateID),
+			zap.String("kind", updateKind(u)),
+			zap.Duration("took", time.Since(start)),
+		}
+		if from := u.SentFrom(); from != nil {
+			fields = append(fields, zap.Int64("userID", from.ID))
+		}
+		b.logger.Debug("update handled", fields...)
+	}
+}
+
+// updateChat is the chat an update comes from, like u.FromChat, which
+// panics on callbacks from messages sent inline: those carry no message.
+func updateChat(u tgbotapi.Update) *tgbotapi.Chat {
+	if u.CallbackQuery != nil && u.CallbackQuery.Message == nil {
+		return nil
+	}
+	return u.FromChat()
+}
+
+// updateKind names the payload an update carries.
+func updateKind(u tgbotapi.Update) string {
+	switch {
+	case u.InlineQuery != nil:
+		return "inline_query"
+	case u.Message != nil && u.Message.IsCommand():
+		return "command"
+	case u.Message != nil:
+		return "message"
+	case u.CallbackQuery != nil:
+		return "callback_query"
+	case u.PollAnswer != nil:
+		return "poll_answer"
+	case u.MyChatMember != nil:
+		return "my_chat_member"
+	default:
+		return "other"
+	}
+}
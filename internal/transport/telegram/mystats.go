@@ -0,0 +1,106 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/chart"
+	"ym-bot/internal/storage"
+)
+
+const (
+	// myStatsTop is how many artists and tracks /mystats lists.
+	myStatsTop = 5
+	// maxPhotoCaption is Telegram's limit on media captions.
+	maxPhotoCaption = 1024
+
+	myStatsUsage = "Использование: /mystats — статистика за всё время; /mystats <год> — итоги года картинкой."
+)
+
+// handleMyStats shows the user's download statistics: all time as text, or
+// with a year argument as a "year in review" card.
+func (b *Bot) handleMyStats(_ context.Context, m *tgbotapi.Message) {
+	arg := strings.TrimSpace(m.CommandArguments())
+	if arg == "" {
+		st := b.store.UserStats(m.From.ID, time.Time{}, time.Time{}, myStatsTop)
+		if st.Downloads == 0 {
+			b.reply(m.Chat.ID, "Вы ещё ничего не скачивали.")
+			return
+		}
+		text := "📊 Ваша статистика с " + st.First.Format("02.01.2006") + "\n" + renderUserStats(st) +
+			fmt.Sprintf("\n/mystats %d — итоги года картинкой.", time.Now().Year())
+		b.reply(m.Chat.ID, text)
+		return
+	}
+
+	year, err := strconv.Atoi(arg)
+	if err != nil || year < 2000 || year > time.Now().Year() {
+		b.reply(m.Chat.ID, myStatsUsage)
+		return
+	}
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	st := b.store.UserStats(m.From.ID, from, from.AddDate(1, 0, 0), myStatsTop)
+	if st.Downloads == 0 {
+		b.reply(m.Chat.ID, fmt.Sprintf("В %d году вы ничего не скачивали.", year))
+		return
+	}
+	caption := fmt.Sprintf("🎧 Ваш %d год в музыке\n", year) + renderUserStats(st)
+	png, err := yearCard(st, year, displayName(m.From))
+	if err != nil {
+		b.logger.Warn("render year card failed", zap.Int64("userID", m.From.ID), zap.Error(err))
+		b.reply(m.Chat.ID, caption)
+		return
+	}
+	photo := tgbotapi.NewPhoto(m.Chat.ID, tgbotapi.FileBytes{Name: fmt.Sprintf("ym-%d.png", year), Bytes: png})
+	photo.Caption = truncate(caption, maxPhotoCaption)
+	if _, err := b.sender.Send(photo); err != nil {
+		b.logger.Warn("send year card failed", zap.Int64("chatID", m.Chat.ID), zap.Error(err))
+	}
+}
+
+// renderUserStats lists the totals and top artists and tracks.
+func renderUserStats(st storage.UserStats) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Загрузок: %d · артистов: %d", st.Downloads, st.Artists)
+	if st.Minutes > 0 {
+		fmt.Fprintf(&sb, " · минут: %d", st.Minutes)
+	}
+	sb.WriteString("\n\nТоп артистов:\n")
+	for i, a := range st.TopArtists {
+		fmt.Fprintf(&sb, "%d. %s — %d\n", i+1, a.Name, a.Count)
+	}
+	sb.WriteString("\nТоп треков:\n")
+	for i, t := range st.TopTracks {
+		fmt.Fprintf(&sb, "%d. %s — %d\n", i+1, t.Title, t.Count)
+	}
+	return sb.String()
+}
+
+// yearCard draws the "year in review" card.
+func yearCard(st storage.UserStats, year int, name string) ([]byte, error) {
+	figures := []chart.Figure{
+		{Value: strconv.Itoa(st.Downloads), Label: "треков скачано"},
+		{Value: strconv.Itoa(st.Artists), Label: "артистов"},
+	}
+	if st.Minutes > 0 {
+		figures = append(figures, chart.Figure{Value: strconv.Itoa(st.Minutes), Label: "минут музыки"})
+	}
+	artists := make([]string, 0, len(st.TopArtists))
+	for i, a := range st.TopArtists {
+		artists = append(artists, fmt.Sprintf("%d. %s", i+1, a.Name))
+	}
+	tracks := make([]string, 0, len(st.TopTracks))
+	for i, t := range st.TopTracks {
+		tracks = append(tracks, fmt.Sprintf("%d. %s", i+1, t.Title))
+	}
+	return chart.Card(fmt.Sprintf("%d в музыке", year), name, figures, []chart.CardSection{
+		{Heading: "Любимые артисты", Lines: artists},
+		{Heading: "Любимые треки", Lines: tracks},
+	})
+}
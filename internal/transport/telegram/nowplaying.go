@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/cover"
+)
+
+// handleNowPlaying shows the track the bot's Yandex account is playing,
+// with a button to download it.
+func (b *Bot) handleNowPlaying(ctx context.Context, m *tgbotapi.Message) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	np, err := b.musicService.NowPlaying(ctx)
+	if errors.Is(err, yandex.ErrNothingPlaying) {
+		b.reply(m.Chat.ID, "Сейчас ничего не играет.")
+		return
+	}
+	if err != nil {
+		b.logger.Warn("now playing failed", zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось узнать, что играет, попробуйте позже.")
+		return
+	}
+
+	t := np.Track
+	var details []string
+	if d := t.DurationString(); d != "" {
+		details = append(details, "Длительность: "+d)
+	}
+	if np.Source != "" {
+		details = append(details, "Источник: "+np.Source)
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(b.trackLinkRow(t))
+	var msg tgbotapi.Chattable
+	if b.linkPreviews(m.Chat.ID) {
+		// The preview of the track page brings the cover along.
+		preview := trackPreview(m.Chat.ID, "🎧 Сейчас играет:", t, strings.Join(details, "\n"))
+		preview.ReplyMarkup = markup
+		msg = preview
+	} else {
+		text := fmt.Sprintf("🎧 Сейчас играет:\n%s — %s", t.ArtistsString(), t.FullTitle())
+		if len(details) > 0 {
+			text += "\n" + strings.Join(details, "\n")
+		}
+		if card := b.coverFile(ctx, t, cover.Card); card != nil {
+			photo := tgbotapi.NewPhoto(m.Chat.ID, card)
+			photo.Caption = text
+			photo.ReplyMarkup = markup
+			msg = photo
+		} else {
+			plain := tgbotapi.NewMessage(m.Chat.ID, text)
+			plain.ReplyMarkup = markup
+			msg = plain
+		}
+	}
+	if _, err := b.sender.Send(msg); err != nil {
+		b.logger.Warn("send now playing failed", zap.Int64("chatID", m.Chat.ID), zap.Error(err))
+	}
+}
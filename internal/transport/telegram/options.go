@@ -0,0 +1,161 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/queue"
+	"ym-bot/internal/services/cover"
+	"ym-bot/internal/settings"
+	"ym-bot/internal/storage"
+	"ym-bot/internal/webhook"
+)
+
+// Sender delivers Bot API requests; *tgbotapi.BotAPI satisfies it.
+type Sender interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+}
+
+// RateLimiter decides whether a user's update may be processed (see ratelimit.Limiter).
+type RateLimiter interface {
+	Allow(userID int64) bool
+}
+
+// WorkerPool runs download jobs with bounded concurrency (see queue.Pool).
+type WorkerPool interface {
+	Submit(key string, job func(context.Context)) (*queue.Ticket, error)
+	Cancel(key string) queue.CancelResult
+	Position(t *queue.Ticket) int
+	ETA(position int) time.Duration
+	Pause()
+	Resume()
+	Stats() queue.Stats
+}
+
+// Option customizes a Bot.
+type Option func(*Bot)
+
+// WithAPI uses a preconfigured API client, e.g. one created with
+// tgbotapi.NewBotAPIWithAPIEndpoint for a non-default Bot API server.
+func WithAPI(api *tgbotapi.BotAPI) Option {
+	return func(b *Bot) { b.api = api }
+}
+
+// WithAPIEndpoint targets a self-hosted Bot API server at baseURL
+// (e.g. http://localhost:8081), raising the upload limit to 2 GB.
+func WithAPIEndpoint(baseURL string) Option {
+	return func(b *Bot) {
+		if baseURL != "" {
+			b.apiURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithSender routes outgoing requests through s instead of the API client.
+func WithSender(s Sender) Option {
+	return func(b *Bot) { b.sender = s }
+}
+
+// WithStore sets the persistent state store.
+func WithStore(s *storage.Store) Option {
+	return func(b *Bot) { b.store = s }
+}
+
+// WithAuditLog records every download attempt in l; an in-memory log is used by default.
+func WithAuditLog(l *storage.AuditLog) Option {
+	return func(b *Bot) { b.audit = l }
+}
+
+// WithHTTPRecorder enables the /httplog admin command over r's exchanges.
+func WithHTTPRecorder(r *yandex.Recorder) Option {
+	return func(b *Bot) { b.recorder = r }
+}
+
+// WithLogger sets the bot logger.
+func WithLogger(logger *zap.Logger) Option {
+	return func(b *Bot) {
+		if logger != nil {
+			b.logger = logger
+		}
+	}
+}
+
+// WithCovers attaches album art to sent tracks and track cards.
+func WithCovers(c *cover.Service) Option {
+	return func(b *Bot) { b.covers = c }
+}
+
+// WithWebhooks notifies external systems of downloads, new users and bursts
+// of failed downloads.
+func WithWebhooks(n *webhook.Notifier) Option {
+	return func(b *Bot) { b.webhooks = n }
+}
+
+// WithRateLimiter throttles updates per user.
+func WithRateLimiter(l RateLimiter) Option {
+	return func(b *Bot) { b.limiter = l }
+}
+
+// WithWorkerPool runs downloads on p instead of the update goroutine.
+func WithWorkerPool(p WorkerPool) Option {
+	return func(b *Bot) { b.pool = p }
+}
+
+// WithSettings reads the runtime-tunable settings from s, e.g. to share
+// them between the bots of several tokens; by default each bot has its own.
+func WithSettings(s *settings.Store) Option {
+	return func(b *Bot) {
+		if s != nil {
+			b.settings = s
+		}
+	}
+}
+
+// WithReloader enables the /reload admin command.
+func WithReloader(r ConfigReloader) Option {
+	return func(b *Bot) { b.reloader = r }
+}
+
+// WithCallbackSecret sets the HMAC key for inline button payloads. By default
+// it is derived from the bot token, so rotating the token invalidates buttons.
+func WithCallbackSecret(secret string) Option {
+	return func(b *Bot) {
+		if secret != "" {
+			b.secret = []byte(secret)
+		}
+	}
+}
+
+// WithCallbackTTL sets how long inline buttons remain valid.
+func WithCallbackTTL(ttl time.Duration) Option {
+	return func(b *Bot) {
+		if ttl > 0 {
+			b.callbackTTL = ttl
+		}
+	}
+}
+
+// WithInlineTimeout bounds inline queries and chat search or browse requests.
+func WithInlineTimeout(d time.Duration) Option {
+	return func(b *Bot) {
+		if d > 0 {
+			b.inlineTimeout = d
+		}
+	}
+}
+
+// WithCallbackTimeout bounds a download job from fetching the track to
+// finishing its upload.
+func WithCallbackTimeout(d time.Duration) Option {
+	return func(b *Bot) {
+		if d > 0 {
+			b.callbackTimeout = d
+		}
+	}
+}
@@ -0,0 +1,487 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/storage"
+)
+
+// ActionParty operations.
+const (
+	partyAdd  = "a"
+	partyVote = "v"
+	partyStop = "x"
+)
+
+const (
+	// partyIdleTTL ends a party that has had nothing to play for this long.
+	partyIdleTTL = 30 * time.Minute
+	// partyGap is added to a track's duration before the next one is posted,
+	// to cover the download and upload of the current one.
+	partyGap = 5 * time.Second
+	// partyMaxQueue bounds the shared queue of a chat.
+	partyMaxQueue = 50
+)
+
+// partyEntry is a queued track and who asked for it; it is downloaded on
+// their quota.
+type partyEntry struct {
+	track  yandex.Track
+	userID int64
+	by     string
+}
+
+// partySession is the shared listening of a group chat: the queue members
+// fill through inline search and the track playing now. Tracks are posted in
+// order, each once the previous one has had time to play or was voted off.
+type partySession struct {
+	mu   sync.Mutex
+	ctx  context.Context // the bot's run context: timers stop with the bot
+	host int64
+	// chatID is where tracks go; chatInstance identifies the chat in
+	// callbacks of inline messages, which carry no chat, once learnt. It is
+	// guarded by Bot.partiesMu, which lookups by it hold.
+	chatID       int64
+	chatInstance string
+	queue        []partyEntry
+	current      partyEntry
+	playing      bool
+	// seq numbers the tracks played, so late timers and buttons of earlier
+	// tracks are told apart.
+	seq     int
+	votes   map[int64]struct{}
+	members map[int64]struct{}
+	control *tgbotapi.Message
+	next    *time.Timer
+	idle    *time.Timer
+	ended   bool
+}
+
+// handleParty starts a party in a group, shows the queue of a running one or
+// ends it with "/party stop".
+func (b *Bot) handleParty(_ context.Context, m *tgbotapi.Message) {
+	if m.Chat.IsPrivate() {
+		b.reply(m.Chat.ID, "Пати работает в группах: добавьте меня в чат и отправьте там /party.")
+		return
+	}
+	s := b.party(m.Chat.ID)
+	if strings.EqualFold(strings.TrimSpace(m.CommandArguments()), "stop") {
+		if s == nil {
+			b.reply(m.Chat.ID, "Пати в этом чате не идёт.")
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if m.From.ID != s.host && !b.isAdmin(m.From.ID) {
+			b.reply(m.Chat.ID, "Завершить пати может только тот, кто её начал.")
+			return
+		}
+		b.endParty(s, "⏹ Пати завершена.")
+		return
+	}
+	if s != nil {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		b.reply(m.Chat.ID, partyStatus(s))
+		return
+	}
+
+	s = &partySession{
+		// Timers outlive the update, so they run on the bot's context.
+		ctx:     b.runCtx,
+		host:    m.From.ID,
+		chatID:  m.Chat.ID,
+		votes:   make(map[int64]struct{}),
+		members: map[int64]struct{}{m.From.ID: {}},
+	}
+	b.partiesMu.Lock()
+	if b.parties[m.Chat.ID] != nil {
+		b.partiesMu.Unlock()
+		return
+	}
+	b.parties[m.Chat.ID] = s
+	b.partiesMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b.touchParty(s)
+	b.reply(m.Chat.ID, fmt.Sprintf("🎉 Пати началась! Ищите треки через @%s <запрос> прямо в этом чате — "+
+		"выбранные попадают в общую очередь и звучат по порядку.\n"+
+		"Трек пропускается, когда за это проголосует половина участников.\n"+
+		"/party — очередь, /party stop — завершить.", b.api.Self.UserName))
+}
+
+// party returns the session running in chatID, nil when there is none.
+func (b *Bot) party(chatID int64) *partySession {
+	b.partiesMu.Lock()
+	defer b.partiesMu.Unlock()
+	return b.parties[chatID]
+}
+
+// partyByInstance finds the session of the chat a callback of an inline
+// message came from.
+func (b *Bot) partyByInstance(instance string) *partySession {
+	if instance == "" {
+		return nil
+	}
+	b.partiesMu.Lock()
+	defer b.partiesMu.Unlock()
+	for _, s := range b.parties {
+		if s.chatInstance == instance {
+			return s
+		}
+	}
+	return nil
+}
+
+// partyMarkup marks inline results offered in groups, so a track sent to a
+// chat with a party running is queued; see handlePartyPick.
+func (b *Bot) partyMarkup(trackID string) *tgbotapi.InlineKeyboardMarkup {
+	kb := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		b.button("➕ В очередь пати", callback.ActionParty, partyAdd, trackID),
+	))
+	return &kb
+}
+
+// handlePartyPick queues the track of an inline result the bot sent to a
+// group; it reports whether m was such a message.
+func (b *Bot) handlePartyPick(ctx context.Context, m *tgbotapi.Message) bool {
+	if m.ViaBot == nil || m.ViaBot.ID != b.api.Self.ID || m.ReplyMarkup == nil {
+		return false
+	}
+	trackID := ""
+	for _, row := range m.ReplyMarkup.InlineKeyboard {
+		for _, btn := range row {
+			if btn.CallbackData == nil {
+				continue
+			}
+			if p, err := b.codec.Decode(*btn.CallbackData); err == nil && p.Action == callback.ActionParty && p.Arg(0) == partyAdd {
+				trackID = p.Arg(1)
+			}
+		}
+	}
+	if trackID == "" {
+		return false
+	}
+	s := b.party(m.Chat.ID)
+	if s == nil {
+		return true
+	}
+	t, text := b.partyTrack(ctx, s.chatID, trackID)
+	if text == "" {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		text = b.partyEnqueue(s, t, m.From)
+	}
+	if text != "" {
+		b.reply(s.chatID, text)
+	}
+	return true
+}
+
+// handlePartyCallback handles the queue button of inline results and the
+// vote and stop buttons of the track playing.
+func (b *Bot) handlePartyCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	var s *partySession
+	if cb.Message != nil {
+		s = b.party(cb.Message.Chat.ID)
+	} else {
+		s = b.partyByInstance(cb.ChatInstance)
+	}
+	if s == nil {
+		b.sendAlert(cb, "Пати в этом чате не идёт. Начните её командой /party.")
+		return
+	}
+
+	if cb.Message != nil && cb.ChatInstance != "" {
+		b.partiesMu.Lock()
+		s.chatInstance = cb.ChatInstance
+		b.partiesMu.Unlock()
+	}
+	// The track is looked up before taking the session, so a slow lookup
+	// holds up none of its other buttons and timers.
+	var added yandex.Track
+	if p.Arg(0) == partyAdd {
+		t, text := b.partyTrack(ctx, s.chatID, p.Arg(1))
+		if text != "" {
+			b.sendAlert(cb, text)
+			return
+		}
+		added = t
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		b.sendAlert(cb, "Пати уже завершена.")
+		return
+	}
+
+	switch p.Arg(0) {
+	case partyAdd:
+		text := b.partyEnqueue(s, added, cb.From)
+		if text == "" {
+			text = "Трек добавлен в очередь."
+		}
+		b.sendAlert(cb, text)
+	case partyVote:
+		if p.Arg(1) != fmt.Sprint(s.seq) || !s.playing {
+			b.sendAlert(cb, "Этот трек уже не играет.")
+			return
+		}
+		s.members[cb.From.ID] = struct{}{}
+		s.votes[cb.From.ID] = struct{}{}
+		b.touchParty(s)
+		if len(s.votes) >= partyVotesNeeded(s) {
+			b.ack(cb, "")
+			b.reply(s.chatID, fmt.Sprintf("⏭ «%s» пропущен голосованием.", s.current.track.FullTitle()))
+			b.partyAdvance(s)
+			return
+		}
+		b.ack(cb, fmt.Sprintf("Голос учтён: %d из %d.", len(s.votes), partyVotesNeeded(s)))
+		if s.control != nil {
+			b.editMarkup(s.control, b.partyControls(s))
+		}
+	case partyStop:
+		if cb.From.ID != s.host && !b.isAdmin(cb.From.ID) {
+			b.sendAlert(cb, "Завершить пати может только тот, кто её начал.")
+			return
+		}
+		b.ack(cb, "")
+		b.endParty(s, "⏹ Пати завершена.")
+	}
+}
+
+// ack answers a callback with an optional toast.
+func (b *Bot) ack(cb *tgbotapi.CallbackQuery, text string) {
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, text)); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+}
+
+// partyTrack looks trackID up for the party of chatID. It returns why the
+// track cannot be queued, "" when it can.
+func (b *Bot) partyTrack(ctx context.Context, chatID int64, trackID string) (yandex.Track, string) {
+	tracks, err := b.musicService.Tracks(ctx, []string{trackID})
+	if err != nil || len(tracks) == 0 {
+		b.logger.Warn("party track lookup failed", zap.String("trackID", trackID), zap.Error(err))
+		return yandex.Track{}, presentError(err, errGeneric).String()
+	}
+	t := tracks[0]
+	if t.Availability != yandex.Available {
+		return yandex.Track{}, presentError(t.Availability.Err(), errDownloadFailed).text
+	}
+	if t.Explicit && b.store.ChatSettings(chatID).HideExplicit {
+		return yandex.Track{}, presentError(errExplicitHidden, errGeneric).text
+	}
+	return t, ""
+}
+
+// partyEnqueue adds t, looked up by partyTrack, to the queue on from's
+// behalf and starts playing when nothing is. It returns what to tell about
+// it, "" when the track went straight on air. The caller holds s.mu.
+func (b *Bot) partyEnqueue(s *partySession, t yandex.Track, from *tgbotapi.User) string {
+	if s.ended {
+		return ""
+	}
+	if len(s.queue) >= partyMaxQueue {
+		return fmt.Sprintf("В очереди уже %d треков, дождитесь, пока она подойдёт.", partyMaxQueue)
+	}
+	if s.playing && s.current.track.ID == t.ID {
+		return "Этот трек сейчас играет."
+	}
+	for _, e := range s.queue {
+		if e.track.ID == t.ID {
+			return "Этот трек уже в очереди."
+		}
+	}
+
+	s.members[from.ID] = struct{}{}
+	s.queue = append(s.queue, partyEntry{track: t, userID: from.ID, by: displayName(from)})
+	b.touchParty(s)
+	if !s.playing {
+		b.partyAdvance(s)
+		return ""
+	}
+	return fmt.Sprintf("➕ %s — %s в очереди под номером %d.", t.ArtistsString(), t.FullTitle(), len(s.queue))
+}
+
+// partyAdvance posts the next queued track, skipping those whose requester
+// is out of quota. The caller holds s.mu.
+func (b *Bot) partyAdvance(s *partySession) {
+	if s.next != nil {
+		s.next.Stop()
+	}
+	if s.control != nil {
+		b.editMarkup(s.control, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+		s.control = nil
+	}
+	s.votes = make(map[int64]struct{})
+	b.touchParty(s)
+
+	for len(s.queue) > 0 {
+		e := s.queue[0]
+		s.queue = s.queue[1:]
+		seq := s.seq + 1
+		var failed bool
+		req := downloadRequest{
+			key:     fmt.Sprintf("party:%d:%s:%d", s.chatID, e.track.ID, time.Now().UnixNano()),
+			userID:  e.userID,
+			chatID:  s.chatID,
+			trackID: e.track.ID,
+			quiet:   true,
+			notify: func(text string) {
+				failed = true
+				b.reply(s.chatID, text)
+			},
+			// The next track is due once this one has played; straight away
+			// when it could not be delivered.
+			release: func() {
+				wait := time.Duration(e.track.DurationSeconds)*time.Second + partyGap
+				if failed {
+					wait = 0
+				}
+				b.schedulePartyTrack(s, seq, wait)
+			},
+		}
+		_, quota, err := b.queueDownload(s.ctx, req)
+		if errors.Is(err, storage.ErrQuotaExceeded) {
+			b.reply(s.chatID, fmt.Sprintf("«%s» пропущен: у %s закончился лимит загрузок. %s",
+				e.track.FullTitle(), e.by, quotaExceededText(quota, time.Now())))
+			continue
+		}
+		if err != nil {
+			b.reply(s.chatID, "Сейчас слишком много загрузок, пати на паузе. Добавьте трек, чтобы продолжить.")
+			s.playing = false
+			return
+		}
+		s.seq = seq
+		s.current, s.playing = e, true
+
+		msg := tgbotapi.NewMessage(s.chatID, partyStatus(s))
+		msg.ReplyMarkup = b.partyControls(s)
+		sent, err := b.sender.Send(msg)
+		if err != nil {
+			b.logger.Warn("send party controls failed", zap.Int64("chatID", s.chatID), zap.Error(err))
+			return
+		}
+		s.control = &sent
+		return
+	}
+
+	s.playing = false
+	b.reply(s.chatID, fmt.Sprintf("Очередь закончилась. Добавьте треков через @%s <запрос>.", b.api.Self.UserName))
+}
+
+// schedulePartyTrack moves on to the next track after wait, unless track seq
+// was skipped in the meantime.
+func (b *Bot) schedulePartyTrack(s *partySession, seq int, wait time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.over() || s.seq != seq {
+		return
+	}
+	if s.next != nil {
+		s.next.Stop()
+	}
+	s.next = time.AfterFunc(wait, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.over() && s.seq == seq {
+			b.partyAdvance(s)
+		}
+	})
+}
+
+// over reports whether s ended or the bot stopped. The caller holds s.mu.
+func (s *partySession) over() bool {
+	return s.ended || s.ctx.Err() != nil
+}
+
+// touchParty restarts the idle countdown of s. The caller holds s.mu.
+func (b *Bot) touchParty(s *partySession) {
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+	s.idle = time.AfterFunc(partyIdleTTL, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.over() {
+			return
+		}
+		if s.playing {
+			b.touchParty(s)
+			return
+		}
+		b.endParty(s, "⏹ Пати завершена: полчаса никто не добавлял треков.")
+	})
+}
+
+// endParty stops s and forgets it. The caller holds s.mu.
+func (b *Bot) endParty(s *partySession, text string) {
+	s.ended = true
+	if s.next != nil {
+		s.next.Stop()
+	}
+	if s.idle != nil {
+		s.idle.Stop()
+	}
+	if s.control != nil {
+		b.editMarkup(s.control, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+	}
+	b.partiesMu.Lock()
+	if b.parties[s.chatID] == s {
+		delete(b.parties, s.chatID)
+	}
+	b.partiesMu.Unlock()
+	b.reply(s.chatID, text)
+}
+
+// partyVotesNeeded is how many votes skip a track: half of those who have
+// queued or voted, rounded up.
+func partyVotesNeeded(s *partySession) int {
+	return max(1, (len(s.members)+1)/2)
+}
+
+// partyControls builds the vote and stop buttons of the track playing.
+func (b *Bot) partyControls(s *partySession) tgbotapi.InlineKeyboardMarkup {
+	seq := fmt.Sprint(s.seq)
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		b.button(fmt.Sprintf("⏭ Пропустить (%d/%d)", len(s.votes), partyVotesNeeded(s)), callback.ActionParty, partyVote, seq),
+		b.button("⏹ Завершить", callback.ActionParty, partyStop, seq),
+	))
+}
+
+// partyStatus describes the track playing and what is queued after it.
+func partyStatus(s *partySession) string {
+	var sb strings.Builder
+	if s.playing {
+		t := s.current.track
+		fmt.Fprintf(&sb, "🎶 Сейчас: %s — %s (%s), добавил %s", t.ArtistsString(), t.FullTitle(), t.DurationString(), s.current.by)
+	} else {
+		sb.WriteString("🎶 Сейчас ничего не играет.")
+	}
+	if len(s.queue) == 0 {
+		sb.WriteString("\nОчередь пуста.")
+		return sb.String()
+	}
+	sb.WriteString("\n\nДальше:")
+	for i, e := range s.queue {
+		if i == 10 {
+			fmt.Fprintf(&sb, "\n…и ещё %d", len(s.queue)-i)
+			break
+		}
+		fmt.Fprintf(&sb, "\n%d. %s — %s (%s)", i+1, e.track.ArtistsString(), e.track.FullTitle(), e.by)
+	}
+	return sb.String()
+}
@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+)
+
+// deepLinkTrack prefixes /start payloads that open a track.
+const deepLinkTrack = "t"
+
+// trackDeepLink is the t.me link that opens t in the bot. It carries the
+// canonical id, so it keeps working after Yandex migrates the track.
+func trackDeepLink(bot string, t yandex.Track) string {
+	if bot == "" || t.ID == "" {
+		return ""
+	}
+	return "https://t.me/" + bot + "?start=" + deepLinkTrack + t.CanonicalID()
+}
+
+// trackIDFromStart extracts the track id of a /start deep link payload.
+func trackIDFromStart(payload string) (string, bool) {
+	id, ok := strings.CutPrefix(payload, deepLinkTrack)
+	if !ok || id == "" {
+		return "", false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+	return id, true
+}
+
+// rememberTrack records that Yandex migrated t, so links made with its old
+// id still resolve.
+func (b *Bot) rememberTrack(t yandex.Track) {
+	b.store.SetTrackAlias(t.ID, t.RealID)
+}
+
+// trackLinkRow offers to download t and to open it in Yandex Music, and to
+// draw its waveform when ffmpeg is there to decode it.
+func (b *Bot) trackLinkRow(t yandex.Track) []tgbotapi.InlineKeyboardButton {
+	row := tgbotapi.NewInlineKeyboardRow(b.button("⬇️ Скачать", callback.ActionDownload, t.ID))
+	if b.musicService.CanTranscode() {
+		row = append(row, b.button("〰 Волна", callback.ActionWaveform, t.ID))
+	}
+	return append(row, tgbotapi.NewInlineKeyboardButtonURL("🌐 Яндекс Музыка", t.URL()))
+}
+
+// handleTrackLink answers a track deep link with the track and its buttons.
+func (b *Bot) handleTrackLink(ctx context.Context, m *tgbotapi.Message, id string) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	tracks, err := b.musicService.Tracks(ctx, []string{b.store.ResolveTrack(id)})
+	if err != nil {
+		b.logger.Warn("track link lookup failed", zap.String("trackID", id), zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось открыть трек, попробуйте позже.")
+		return
+	}
+	if len(tracks) == 0 {
+		b.reply(m.Chat.ID, "Трек по ссылке не найден.")
+		return
+	}
+	t := tracks[0]
+	b.rememberTrack(t)
+
+	msg := trackPreview(m.Chat.ID, "🎵 Трек по ссылке:", t, t.DurationString())
+	msg.DisableWebPagePreview = !b.linkPreviews(m.Chat.ID)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(b.trackLinkRow(t))
+	if _, err := b.sender.Send(msg); err != nil {
+		b.logger.Warn("send track link failed", zap.Int64("chatID", m.Chat.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,61 @@
+package telegram
+
+import (
+	"strings"
+	"testing"
+
+	"ym-bot/internal/client/yandex"
+)
+
+func TestTrackIDFromStart(t *testing.T) {
+	cases := []struct {
+		payload string
+		id      string
+		ok      bool
+	}{
+		{"t33311009", "33311009", true},
+		{"t", "", false},
+		{"", "", false},
+		{"33311009", "", false},
+		{"t123abc", "", false},
+		{"t-1", "", false},
+		{"t1:2", "", false},
+		{"ref_t1", "", false},
+	}
+	for _, tc := range cases {
+		id, ok := trackIDFromStart(tc.payload)
+		if id != tc.id || ok != tc.ok {
+			t.Errorf("trackIDFromStart(%q) = %q, %v, want %q, %v", tc.payload, id, ok, tc.id, tc.ok)
+		}
+	}
+}
+
+func TestTrackDeepLink(t *testing.T) {
+	cases := []struct {
+		name  string
+		bot   string
+		track yandex.Track
+		want  string
+	}{
+		{"track", "ym_bot", yandex.Track{ID: "1"}, "https://t.me/ym_bot?start=t1"},
+		{"migrated", "ym_bot", yandex.Track{ID: "1", RealID: "2"}, "https://t.me/ym_bot?start=t2"},
+		{"no bot", "", yandex.Track{ID: "1"}, ""},
+		{"no track", "ym_bot", yandex.Track{}, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			link := trackDeepLink(tc.bot, tc.track)
+			if link != tc.want {
+				t.Fatalf("trackDeepLink = %q, want %q", link, tc.want)
+			}
+			if link == "" {
+				return
+			}
+			// The link's payload leads back to the canonical id.
+			_, payload, _ := strings.Cut(link, "?start=")
+			if id, ok := trackIDFromStart(payload); !ok || id != tc.track.CanonicalID() {
+				t.Errorf("payload %q parses to %q, %v", payload, id, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/storage"
+)
+
+// picksTTL is how long a results message answers to a number.
+const picksTTL = 15 * time.Minute
+
+// searchPicks numbers the tracks listed by the latest results message of a
+// chat, so that "3" downloads the third one where buttons are awkward.
+// Picks last minutes and are kept in memory only: after a restart, a number
+// is searched for and a reply to an older list is turned down, as for any
+// expired list.
+type searchPicks struct {
+	messageID int
+	first     int // the number of trackIDs[0]
+	trackIDs  []string
+	created   time.Time
+}
+
+// rememberPicks makes the tracks of msg pickable by number, the first one
+// being offset+1. Without tracks, e.g. on a tab of albums, msg stops
+// answering to numbers.
+func (b *Bot) rememberPicks(msg *tgbotapi.Message, offset int, tracks []yandex.Track) {
+	if msg == nil || msg.Chat == nil || !msg.Chat.IsPrivate() {
+		return
+	}
+	ids := make([]string, len(tracks))
+	for i, t := range tracks {
+		ids[i] = t.ID
+	}
+
+	b.picksMu.Lock()
+	defer b.picksMu.Unlock()
+	for chatID, old := range b.picks {
+		if time.Since(old.created) > picksTTL {
+			delete(b.picks, chatID)
+		}
+	}
+	if len(ids) == 0 {
+		if p, ok := b.picks[msg.Chat.ID]; ok && p.messageID == msg.MessageID {
+			delete(b.picks, msg.Chat.ID)
+		}
+		return
+	}
+	b.picks[msg.Chat.ID] = searchPicks{messageID: msg.MessageID, first: offset + 1, trackIDs: ids, created: time.Now()}
+}
+
+func (b *Bot) picksFor(chatID int64) (searchPicks, bool) {
+	b.picksMu.Lock()
+	defer b.picksMu.Unlock()
+	p, ok := b.picks[chatID]
+	if !ok || time.Since(p.created) > picksTTL {
+		return searchPicks{}, false
+	}
+	return p, true
+}
+
+// handlePick downloads the track numbered in a message like "3" or "#3",
+// and reports whether the message was such a pick. A number outside the
+// latest list is searched for as usual (there are songs called "1979"),
+// unless it replies to the list.
+func (b *Bot) handlePick(ctx context.Context, m *tgbotapi.Message) bool {
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(m.Text), "#"))
+	if err != nil || n <= 0 {
+		return false
+	}
+	p, ok := b.picksFor(m.Chat.ID)
+	if reply := m.ReplyToMessage; reply != nil && (!ok || reply.MessageID != p.messageID) {
+		if queryFromResults(reply.Text) == "" {
+			return false
+		}
+		b.reply(m.Chat.ID, "Этот список устарел: выберите номер в последних результатах или повторите поиск.")
+		return true
+	}
+	if !ok {
+		return false
+	}
+	i := n - p.first
+	if i < 0 || i >= len(p.trackIDs) {
+		if m.ReplyToMessage == nil {
+			return false
+		}
+		b.reply(m.Chat.ID, fmt.Sprintf("В списке нет номера %d, выберите от %d до %d.", n, p.first, p.first+len(p.trackIDs)-1))
+		return true
+	}
+	b.downloadPick(ctx, m, p.trackIDs[i])
+	return true
+}
+
+// downloadPick queues trackID for the sender of m, the way a download
+// button does; the job's status message stands in for the button's alert.
+func (b *Bot) downloadPick(ctx context.Context, m *tgbotapi.Message, trackID string) {
+	userID, chatID := m.From.ID, m.Chat.ID
+	press := pressKey(userID, trackID)
+	if !b.presses.claim(press, false) {
+		b.reply(chatID, "Этот трек уже загружается.")
+		return
+	}
+	if b.askBeforeDownload(ctx, userID, trackID, chatID) {
+		b.presses.done(press)
+		return
+	}
+
+	req := downloadRequest{
+		key:     fmt.Sprintf("pick:%d:%d", chatID, m.MessageID),
+		userID:  userID,
+		chatID:  chatID,
+		trackID: trackID,
+		notify:  func(text string) { b.reply(chatID, text) },
+		release: func() { b.presses.done(press) },
+	}
+	_, quota, err := b.queueDownload(ctx, req)
+	if errors.Is(err, storage.ErrQuotaExceeded) {
+		req.release()
+		b.reply(chatID, quotaExceededText(quota, time.Now()))
+		return
+	}
+	if err != nil {
+		req.release()
+		b.logger.Warn("download queue rejected job", zap.String("trackID", trackID), zap.Error(err))
+		b.reply(chatID, "Сейчас слишком много загрузок, попробуйте через минуту.")
+	}
+}
@@ -0,0 +1,99 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/storage"
+)
+
+// canSaveToPlaylist reports whether userID may add tracks to a playlist of
+// the bot's Yandex account: admins always, others when the button is enabled.
+func (b *Bot) canSaveToPlaylist(userID int64) bool {
+	return b.settings.Load().PlaylistButton || b.isAdmin(userID)
+}
+
+// playlistKeyboard is the "save to playlist" button put under sent tracks.
+func (b *Bot) playlistKeyboard(userID int64, trackID string) (tgbotapi.InlineKeyboardMarkup, bool) {
+	if trackID == "" || !b.canSaveToPlaylist(userID) {
+		return tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		b.button("💾 В плейлист Яндекса", callback.ActionPlaylist, trackID),
+	)), true
+}
+
+// playlistLock is a user's playlist lock and the number of saves holding or
+// waiting for it, so it is dropped once none are.
+type playlistLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockPlaylist locks the playlist of userID, leaving other users' saves to
+// go on while its network calls run, and returns the unlock.
+func (b *Bot) lockPlaylist(userID int64) func() {
+	b.playlistMu.Lock()
+	l := b.playlistLocks[userID]
+	if l == nil {
+		l = &playlistLock{}
+		b.playlistLocks[userID] = l
+	}
+	l.refs++
+	b.playlistMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		b.playlistMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(b.playlistLocks, userID)
+		}
+		b.playlistMu.Unlock()
+	}
+}
+
+// handlePlaylistCallback adds the track to the user's playlist, creating it
+// on the first save. Each user gets their own playlist on the bot's account.
+func (b *Bot) handlePlaylistCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	trackID := p.Arg(0)
+	if trackID == "" {
+		return
+	}
+	if !b.canSaveToPlaylist(cb.From.ID) {
+		b.sendAlert(cb, "Сохранение в плейлисты недоступно.")
+		return
+	}
+
+	// Serialised so two quick taps do not create two playlists.
+	unlock := b.lockPlaylist(cb.From.ID)
+	defer unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	kind := b.store.Prefs(cb.From.ID).PlaylistKind
+	title := fmt.Sprintf("%s (@%s)", displayName(cb.From), b.api.Self.UserName)
+	pl, err := b.musicService.SaveToPlaylist(ctx, kind, title, trackID)
+	if err != nil {
+		b.logger.Warn("save to playlist failed", zap.Int64("userID", cb.From.ID), zap.String("trackID", trackID), zap.Error(err))
+		b.sendAlert(cb, "Не удалось сохранить трек в плейлист, попробуйте позже.")
+		return
+	}
+	if pl.Kind != kind {
+		if _, err := b.store.UpdatePrefs(cb.From.ID, func(p *storage.UserPrefs) { p.PlaylistKind = pl.Kind }); err != nil {
+			b.logger.Warn("store playlist kind failed", zap.Int64("userID", cb.From.ID), zap.Error(err))
+		}
+	}
+
+	text := fmt.Sprintf("Добавлено в «%s» (%d)", pl.Title, pl.TrackCount)
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, text)); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,116 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+)
+
+// playlistsHeader starts the /myplaylists message.
+const playlistsHeader = "📂 Плейлисты аккаунта"
+
+// handleMyPlaylists opens the playlist browser of the bot's Yandex account.
+func (b *Bot) handleMyPlaylists(ctx context.Context, m *tgbotapi.Message) {
+	b.openMenu(ctx, m.Chat.ID, m.From.ID, callback.ActionBrowse, "", "0")
+}
+
+// playlistsMenu renders the playlist browser: with an empty kind it pages
+// the playlist list, otherwise the tracks of that playlist. The account's
+// playlists are the bot admins' only, so every step re-checks the presser.
+func (b *Bot) playlistsMenu(ctx context.Context, req menuRequest) (menuScreen, error) {
+	if !b.isAdmin(req.userID) {
+		return menuScreen{}, menuAlert("Плейлисты аккаунта доступны только администраторам бота.")
+	}
+	p := req.payload
+	offset, err := strconv.Atoi(p.Arg(1))
+	if err != nil || offset < 0 {
+		return menuScreen{}, menuAlert("Кнопка устарела, откройте /myplaylists заново.")
+	}
+
+	var screen menuScreen
+	if p.Arg(0) == "" {
+		playlists, err := b.musicService.Playlists(ctx)
+		if err != nil {
+			b.logger.Warn("list playlists failed", zap.Error(err))
+			return menuScreen{}, menuAlert("Не удалось получить плейлисты, попробуйте позже.")
+		}
+		if len(playlists) == 0 {
+			return menuScreen{}, menuAlert("В аккаунте нет плейлистов.")
+		}
+		screen.text, screen.keyboard = b.renderPlaylists(playlists, offset)
+		return screen, nil
+	}
+
+	kind, err := strconv.Atoi(p.Arg(0))
+	if err != nil {
+		return menuScreen{}, menuAlert("Кнопка устарела, откройте /myplaylists заново.")
+	}
+	pl, tracks, err := b.musicService.PlaylistTracks(ctx, kind)
+	if err != nil {
+		b.logger.Warn("playlist tracks failed", zap.Int("kind", kind), zap.Error(err))
+		return menuScreen{}, menuAlert("Не удалось открыть плейлист :(")
+	}
+	screen.text, screen.keyboard = b.renderPlaylistTracks(pl, tracks, offset)
+	return screen, nil
+}
+
+func (b *Bot) renderPlaylists(playlists []yandex.Playlist, offset int) (string, tgbotapi.InlineKeyboardMarkup) {
+	offset = min(offset, max(len(playlists)-1, 0))
+	page := playlists[offset:min(offset+searchLimit, len(playlists))]
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(page)+1)
+	for _, pl := range page {
+		label := truncate(fmt.Sprintf("%s (%d)", pl.Title, pl.TrackCount), maxButtonLabel)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(label, callback.ActionBrowse, strconv.Itoa(pl.Kind), "0"),
+		))
+	}
+	if nav := b.browseNav("", offset, len(playlists)); len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	return fmt.Sprintf("%s (%d):", playlistsHeader, len(playlists)), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func (b *Bot) renderPlaylistTracks(pl yandex.Playlist, tracks []yandex.Track, offset int) (string, tgbotapi.InlineKeyboardMarkup) {
+	offset = min(offset, max(len(tracks)-1, 0))
+	page := tracks[offset:min(offset+searchLimit, len(tracks))]
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📂 %s — треков: %d", pl.Title, len(tracks))
+	if len(tracks) == 0 {
+		sb.WriteString("\n\nПлейлист пуст.")
+	}
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(page)+2)
+	for i, t := range page {
+		label := truncate(fmt.Sprintf("%d. %s — %s", offset+i+1, t.ArtistsString(), t.FullTitle()), maxButtonLabel)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(label, callback.ActionDownload, t.ID),
+		))
+	}
+	if nav := b.browseNav(strconv.Itoa(pl.Kind), offset, len(tracks)); len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		b.button("⬆ Все плейлисты", callback.ActionBrowse, "", "0"),
+	))
+	return sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// browseNav renders the ◀/▶ row for a list of total items shown from offset.
+func (b *Bot) browseNav(kind string, offset, total int) []tgbotapi.InlineKeyboardButton {
+	var nav []tgbotapi.InlineKeyboardButton
+	if offset > 0 {
+		nav = append(nav, b.button("◀ Назад", callback.ActionBrowse, kind, strconv.Itoa(max(offset-searchLimit, 0))))
+	}
+	if offset+searchLimit < total {
+		nav = append(nav, b.button("Далее ▶", callback.ActionBrowse, kind, strconv.Itoa(offset+searchLimit)))
+	}
+	return nav
+}
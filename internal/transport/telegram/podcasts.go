@@ -0,0 +1,319 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/storage"
+)
+
+const (
+	// podcastCheckInterval is how often subscribed podcasts are checked for
+	// new episodes.
+	podcastCheckInterval = 30 * time.Minute
+	// maxEpisodeAlerts caps the episodes one alert lists; a podcast that
+	// published more since the last check shows the newest ones.
+	maxEpisodeAlerts = 3
+	podcastUsage     = "Использование: /podcast <ссылка на подкаст в Яндекс Музыке>, например /podcast https://music.yandex.ru/album/12345678.\n" +
+		"Без аргументов — подписки этого чата."
+)
+
+// ActionPodcast operations, the fourth payload argument; the first three are
+// the chat id, the podcast id and the episode list offset.
+const (
+	podcastSubscribe   = "s"
+	podcastUnsubscribe = "u"
+)
+
+// handlePodcast opens a podcast's episodes by link or id, or without
+// arguments the podcasts the chat is subscribed to.
+func (b *Bot) handlePodcast(ctx context.Context, m *tgbotapi.Message) {
+	chatID := strconv.FormatInt(m.Chat.ID, 10)
+	arg := strings.TrimSpace(m.CommandArguments())
+	if arg == "" {
+		if len(b.store.Subscriptions(m.Chat.ID)) == 0 {
+			b.reply(m.Chat.ID, podcastUsage)
+			return
+		}
+		b.openMenu(ctx, m.Chat.ID, m.From.ID, callback.ActionPodcast, chatID, "", "0")
+		return
+	}
+	id, ok := yandex.ParseAlbumRef(arg)
+	if !ok {
+		b.reply(m.Chat.ID, podcastUsage)
+		return
+	}
+	b.openMenu(ctx, m.Chat.ID, m.From.ID, callback.ActionPodcast, chatID, id, "0")
+}
+
+// podcastMenu pages through a podcast's episodes, newest first, with a
+// button that subscribes the chat to new-episode alerts. Without a podcast
+// id it lists the chat's subscriptions.
+func (b *Bot) podcastMenu(ctx context.Context, req menuRequest) (menuScreen, error) {
+	p := req.payload
+	chatID, err := strconv.ParseInt(p.Arg(0), 10, 64)
+	offset, oerr := strconv.Atoi(p.Arg(2))
+	if err != nil || oerr != nil || offset < 0 {
+		return menuScreen{}, menuAlert("Кнопка устарела, откройте /podcast заново.")
+	}
+	id := p.Arg(1)
+	if id == "" {
+		return b.subscriptionsScreen(chatID), nil
+	}
+
+	podcast, err := b.musicService.Podcast(ctx, id)
+	if err != nil {
+		b.logger.Warn("podcast failed", zap.String("albumID", id), zap.Error(err))
+		switch {
+		case errors.Is(err, music.ErrNotPodcast):
+			return menuScreen{}, menuAlert("Это альбом, а не подкаст. Альбомы ищите обычным поиском.")
+		case errors.Is(err, yandex.ErrNotFound):
+			return menuScreen{}, menuAlert("Подкаст не найден, проверьте ссылку.")
+		}
+		return menuScreen{}, menuAlert(presentError(err, errGeneric).String())
+	}
+
+	var notice string
+	if op := p.Arg(3); op != "" {
+		if notice, err = b.changeSubscription(chatID, req.userID, podcast, op); err != nil {
+			return menuScreen{}, err
+		}
+	}
+	text, keyboard := b.renderPodcast(chatID, podcast, offset)
+	return menuScreen{text: text, keyboard: keyboard, notice: notice}, nil
+}
+
+// changeSubscription applies a subscribe or unsubscribe button. In groups
+// only the chat's administrators decide what it follows.
+func (b *Bot) changeSubscription(chatID, userID int64, podcast yandex.Album, op string) (string, error) {
+	if chatID != userID && !b.isChatAdmin(chatID, userID) {
+		return "", menuAlert("Подписками чата управляют только его администраторы.")
+	}
+	if op == podcastUnsubscribe {
+		if _, err := b.store.Unsubscribe(chatID, podcast.ID); err != nil {
+			b.logger.Warn("unsubscribe failed", zap.Int64("chatID", chatID), zap.String("albumID", podcast.ID), zap.Error(err))
+			return "", menuAlert("Не удалось сохранить подписку :(")
+		}
+		return "Подписка отменена", nil
+	}
+
+	// Episodes out already are not news: alerts start from the next one.
+	var seen time.Time
+	if len(podcast.Tracks) > 0 {
+		seen = podcast.Tracks[0].Published
+	}
+	_, err := b.store.Subscribe(chatID, storage.PodcastSubscription{
+		PodcastID: podcast.ID, Title: podcast.Title, Seen: seen, Bot: b.api.Self.UserName,
+	})
+	switch {
+	case errors.Is(err, storage.ErrTooManySubscriptions):
+		return "", menuAlert("Слишком много подписок: отпишитесь от какого-нибудь подкаста.")
+	case err != nil:
+		b.logger.Warn("subscribe failed", zap.Int64("chatID", chatID), zap.String("albumID", podcast.ID), zap.Error(err))
+		return "", menuAlert("Не удалось сохранить подписку :(")
+	}
+	b.logger.Info("podcast subscribed", zap.Int64("chatID", chatID), zap.Int64("userID", userID), zap.String("albumID", podcast.ID))
+	return "Подписка оформлена", nil
+}
+
+func (b *Bot) renderPodcast(chatID int64, podcast yandex.Album, offset int) (string, tgbotapi.InlineKeyboardMarkup) {
+	chat, id := strconv.FormatInt(chatID, 10), podcast.ID
+	episodes := podcast.Tracks
+	offset = min(offset, max(len(episodes)-1, 0))
+	page := episodes[offset:min(offset+searchLimit, len(episodes))]
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🎙 %s — выпусков: %d", podcast.Title, len(episodes))
+	if len(podcast.Artists) > 0 {
+		sb.WriteString("\n" + strings.Join(podcast.Artists, ", "))
+	}
+	if len(episodes) == 0 {
+		sb.WriteString("\n\nВыпусков пока нет.")
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(page)+2)
+	for _, e := range page {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate(episodeLine(e), maxButtonLabel), callback.ActionDownload, e.ID),
+		))
+	}
+	var nav []tgbotapi.InlineKeyboardButton
+	if offset > 0 {
+		nav = append(nav, b.button("◀ Новее", callback.ActionPodcast, chat, id, strconv.Itoa(max(offset-searchLimit, 0))))
+	}
+	if offset+searchLimit < len(episodes) {
+		nav = append(nav, b.button("Старше ▶", callback.ActionPodcast, chat, id, strconv.Itoa(offset+searchLimit)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	if b.store.Subscribed(chatID, id) {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button("🔕 Отписаться", callback.ActionPodcast, chat, id, strconv.Itoa(offset), podcastUnsubscribe)))
+	} else {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button("🔔 Сообщать о новых выпусках", callback.ActionPodcast, chat, id, strconv.Itoa(offset), podcastSubscribe)))
+	}
+	return sb.String(), tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// subscriptionsScreen lists the podcasts chatID follows.
+func (b *Bot) subscriptionsScreen(chatID int64) menuScreen {
+	subs := b.store.Subscriptions(chatID)
+	if len(subs) == 0 {
+		return menuScreen{text: podcastUsage}
+	}
+	chat := strconv.FormatInt(chatID, 10)
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(subs))
+	for _, s := range subs {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate("🎙 "+s.Title, maxButtonLabel), callback.ActionPodcast, chat, s.PodcastID, "0"),
+		))
+	}
+	return menuScreen{
+		text:     fmt.Sprintf("🔔 Подписки на подкасты (%d):", len(subs)),
+		keyboard: tgbotapi.NewInlineKeyboardMarkup(rows...),
+	}
+}
+
+// episodeLine is an episode's date, title and length.
+func episodeLine(e yandex.Track) string {
+	line := e.FullTitle()
+	if !e.Published.IsZero() {
+		line = e.Published.Format("02.01.06") + " · " + line
+	}
+	if d := e.DurationString(); d != "" {
+		line += " (" + d + ")"
+	}
+	return line
+}
+
+// RunPodcastAlerts checks subscribed podcasts for new episodes every
+// podcastCheckInterval until ctx is done. The bots share one store, so it
+// runs once for all of them; each alert goes out through the bot the chat
+// subscribed through.
+func RunPodcastAlerts(ctx context.Context, bots []*Bot) {
+	if len(bots) == 0 {
+		return
+	}
+	lead := bots[0]
+	byName := make(map[string]*Bot, len(bots))
+	for _, b := range bots {
+		byName[b.api.Self.UserName] = b
+	}
+	botFor := func(name string) *Bot {
+		if name == "" {
+			return lead
+		}
+		return byName[name]
+	}
+
+	ticker := time.NewTicker(podcastCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !lead.store.Maintenance().On {
+				lead.checkPodcasts(ctx, botFor)
+			}
+		}
+	}
+}
+
+// checkPodcasts alerts every subscribed chat of the episodes published since
+// it last heard of the podcast, through the bot botFor returns for the
+// subscription, unless the chat blocked or removed that bot. Each podcast is
+// fetched once for all its subscribers; chats in their quiet hours hear of
+// new episodes afterwards.
+func (b *Bot) checkPodcasts(ctx context.Context, botFor func(name string) *Bot) {
+	type subscriber struct {
+		chatID int64
+		seen   time.Time
+		via    *Bot
+	}
+	podcasts := make(map[string][]subscriber)
+	for chatID, list := range b.store.AllSubscriptions() {
+		for _, s := range list {
+			via := botFor(s.Bot)
+			if via == nil {
+				// The bot's token is no longer configured.
+				b.logger.Debug("podcast alert skipped", zap.Int64("chatID", chatID), zap.String("bot", s.Bot))
+				continue
+			}
+			if b.store.ChatActive(via.api.Self.UserName, chatID) {
+				podcasts[s.PodcastID] = append(podcasts[s.PodcastID], subscriber{chatID, s.Seen, via})
+			}
+		}
+	}
+
+	now := time.Now()
+	for id, subscribers := range podcasts {
+		if ctx.Err() != nil {
+			return
+		}
+		fetchCtx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+		podcast, err := b.musicService.Podcast(fetchCtx, id)
+		cancel()
+		if err != nil {
+			b.logger.Warn("check podcast failed", zap.String("albumID", id), zap.Error(err))
+			continue
+		}
+		for _, s := range subscribers {
+			if !b.store.ChatSettings(s.chatID).Quiet(now) {
+				s.via.alertEpisodes(s.chatID, podcast, s.seen)
+			}
+		}
+	}
+}
+
+// alertEpisodes sends chatID the episodes of podcast published after seen,
+// if any, with buttons to download them.
+func (b *Bot) alertEpisodes(chatID int64, podcast yandex.Album, seen time.Time) {
+	var fresh []yandex.Track
+	for _, e := range podcast.Tracks {
+		if e.Published.After(seen) {
+			fresh = append(fresh, e)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+
+	var sb strings.Builder
+	if len(fresh) == 1 {
+		fmt.Fprintf(&sb, "🎙 Новый выпуск подкаста «%s»:", podcast.Title)
+	} else {
+		fmt.Fprintf(&sb, "🎙 Новые выпуски подкаста «%s» (%d):", podcast.Title, len(fresh))
+	}
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, maxEpisodeAlerts+1)
+	for _, e := range fresh[:min(maxEpisodeAlerts, len(fresh))] {
+		sb.WriteString("\n• " + episodeLine(e))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate("⬇️ "+e.FullTitle(), maxButtonLabel), callback.ActionDownload, e.ID),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		b.button("🎙 Все выпуски", callback.ActionPodcast, strconv.FormatInt(chatID, 10), podcast.ID, "0"),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, sb.String())
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.sender.Send(msg); err != nil {
+		b.logger.Warn("send podcast alert failed", zap.Int64("chatID", chatID), zap.String("albumID", podcast.ID), zap.Error(err))
+		return
+	}
+	b.store.MarkPodcastSeen(chatID, podcast.ID, fresh[0].Published)
+	b.logger.Info("podcast alert sent", zap.Int64("chatID", chatID), zap.String("albumID", podcast.ID), zap.Int("episodes", len(fresh)))
+}
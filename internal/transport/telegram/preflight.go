@@ -0,0 +1,114 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+)
+
+// Download buttons on the preflight prompt: one confirms the whole file,
+// the other asks for it in parts (see deliverParts).
+const (
+	downloadConfirmed = "1"
+	downloadSplit     = "2"
+)
+
+// askBeforeDownload shows userID the estimated size of a large download with
+// Confirm/Cancel buttons and reports whether it did; tracks longer than
+// SPLIT_LONGER_THAN also get an offer to come in parts. Users can turn the
+// size prompt off in /settings; lookup failures let the download proceed.
+func (b *Bot) askBeforeDownload(ctx context.Context, userID int64, trackID string, chatID int64) bool {
+	s := b.settings.Load()
+	threshold, splitAfter, splitPart := s.PreflightSize, s.SplitAfter, s.SplitPart
+	if b.store.Prefs(userID).SkipPreflight {
+		threshold = 0
+	}
+	if !b.musicService.CanTranscode() {
+		splitAfter = 0
+	}
+	if threshold <= 0 && splitAfter <= 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(b.chatContext(ctx, chatID), 5*time.Second)
+	defer cancel()
+	pf, err := b.musicService.Preflight(ctx, trackID)
+	if err != nil {
+		b.logger.Debug("preflight failed", zap.String("trackID", trackID), zap.Error(err))
+		return false
+	}
+	length := time.Duration(pf.Track.DurationSeconds) * time.Second
+	parts := 1
+	if splitAfter > 0 && length > splitAfter {
+		parts = music.SplitParts(length, splitPart)
+	}
+	if parts < 2 && (threshold <= 0 || pf.Size < threshold) {
+		return false
+	}
+
+	text := fmt.Sprintf("%s — %s\n%s — скачать?", pf.Track.ArtistsString(), pf.Track.FullTitle(), preflightText(pf))
+	rows := [][]tgbotapi.InlineKeyboardButton{tgbotapi.NewInlineKeyboardRow(
+		b.button("✅ Скачать", callback.ActionDownload, trackID, downloadConfirmed),
+		b.button("✖ Отмена", callback.ActionDismiss),
+	)}
+	if parts > 1 {
+		text = fmt.Sprintf("%s — %s\n%s\n\nТрек длинный: можно прислать его %d частями по ~%s.",
+			pf.Track.ArtistsString(), pf.Track.FullTitle(), preflightText(pf), parts, humanDuration(length/time.Duration(parts)))
+		rows = [][]tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardRow(b.button(fmt.Sprintf("✂ Частями (%d)", parts), callback.ActionDownload, trackID, downloadSplit)),
+			tgbotapi.NewInlineKeyboardRow(
+				b.button("📄 Одним файлом", callback.ActionDownload, trackID, downloadConfirmed),
+				b.button("✖ Отмена", callback.ActionDismiss),
+			),
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.sender.Send(msg); err != nil {
+		b.logger.Warn("send preflight failed", zap.Int64("chatID", chatID), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+// handleDismissCallback removes the message carrying the button.
+func (b *Bot) handleDismissCallback(_ context.Context, cb *tgbotapi.CallbackQuery, _ callback.Payload) {
+	b.deleteMessage(cb.Message)
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, "")); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+}
+
+func (b *Bot) deleteMessage(m *tgbotapi.Message) {
+	if m == nil || m.Chat == nil {
+		return
+	}
+	if _, err := b.sender.Request(tgbotapi.NewDeleteMessage(m.Chat.ID, m.MessageID)); err != nil {
+		b.logger.Debug("delete message failed", zap.Int64("chatID", m.Chat.ID), zap.Error(err))
+	}
+}
+
+// preflightText renders "4:32 • 320kbps • ~10.4 MB".
+func preflightText(pf music.Preflight) string {
+	var parts []string
+	if d := pf.Track.DurationString(); d != "" {
+		parts = append(parts, d)
+	}
+	switch {
+	case (yandex.DownloadLink{Codec: pf.Codec}).Lossless():
+		parts = append(parts, "FLAC")
+	case pf.BitrateKbps > 0:
+		parts = append(parts, fmt.Sprintf("%dkbps", pf.BitrateKbps))
+	}
+	parts = append(parts, fmt.Sprintf("~%.1f MB", float64(pf.Size)/(1<<20)))
+	return strings.Join(parts, " • ")
+}
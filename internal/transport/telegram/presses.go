@@ -0,0 +1,151 @@
+package telegram
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	// pressWindow swallows repeated presses of a download button that arrive
+	// this soon after the first one, even if that download already finished.
+	pressWindow = 10 * time.Second
+	// pressingLabel replaces a pressed download button while its track is on the way.
+	pressingLabel = "⏳ Загружается…"
+)
+
+// pressGuard makes download presses idempotent per user and track: a press
+// is dropped while the same download runs or within pressWindow of the last one.
+type pressGuard struct {
+	mu      sync.Mutex
+	window  time.Duration
+	entries map[string]pressEntry
+	// marks holds the messages whose keyboard shows a pressed button; menu
+	// navigation drops them so a late restore cannot overwrite a newer screen.
+	marks map[string]int
+	seq   int
+	now   func() time.Time
+}
+
+type pressEntry struct {
+	at      time.Time
+	running bool
+}
+
+func newPressGuard(window time.Duration) *pressGuard {
+	return &pressGuard{
+		window:  window,
+		entries: make(map[string]pressEntry),
+		marks:   make(map[string]int),
+		now:     time.Now,
+	}
+}
+
+func pressKey(userID int64, trackID string) string {
+	return strconv.FormatInt(userID, 10) + ":" + trackID
+}
+
+// claim reports whether a press for key may start a download. Confirmed
+// presses (from a preflight prompt) skip the window, which the original
+// press just opened, but never run twice at once.
+func (g *pressGuard) claim(key string, confirmed bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if e, ok := g.entries[key]; ok && (e.running || (!confirmed && now.Sub(e.at) < g.window)) {
+		return false
+	}
+	for k, e := range g.entries {
+		if !e.running && now.Sub(e.at) >= g.window {
+			delete(g.entries, k)
+		}
+	}
+	g.entries[key] = pressEntry{at: now, running: true}
+	return true
+}
+
+// done ends the download claimed under key; the window still applies.
+func (g *pressGuard) done(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if e, ok := g.entries[key]; ok {
+		e.running = false
+		g.entries[key] = e
+	}
+}
+
+// mark records that msgKey now shows a pressed button and returns a token
+// for unmark.
+func (g *pressGuard) mark(msgKey string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.seq++
+	g.marks[msgKey] = g.seq
+	return g.seq
+}
+
+// unmark reports whether msgKey still shows the keyboard marked with token.
+func (g *pressGuard) unmark(msgKey string, token int) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.marks[msgKey] != token {
+		return false
+	}
+	delete(g.marks, msgKey)
+	return true
+}
+
+// replaced forgets msgKey after its keyboard was redrawn.
+func (g *pressGuard) replaced(msgKey string) {
+	g.mu.Lock()
+	delete(g.marks, msgKey)
+	g.mu.Unlock()
+}
+
+func messageKey(m *tgbotapi.Message) string {
+	return strconv.FormatInt(m.Chat.ID, 10) + ":" + strconv.Itoa(m.MessageID)
+}
+
+// showPressed relabels the pressed button "downloading" and returns a func
+// that puts the original keyboard back, unless the message changed meanwhile.
+func (b *Bot) showPressed(cb *tgbotapi.CallbackQuery) func() {
+	m := cb.Message
+	if m == nil || m.Chat == nil || m.ReplyMarkup == nil {
+		return func() {}
+	}
+
+	original := *m.ReplyMarkup
+	pressed := tgbotapi.InlineKeyboardMarkup{InlineKeyboard: make([][]tgbotapi.InlineKeyboardButton, len(original.InlineKeyboard))}
+	found := false
+	for i, row := range original.InlineKeyboard {
+		pressed.InlineKeyboard[i] = append([]tgbotapi.InlineKeyboardButton(nil), row...)
+		for j, btn := range row {
+			if btn.CallbackData != nil && *btn.CallbackData == cb.Data {
+				pressed.InlineKeyboard[i][j].Text = pressingLabel
+				found = true
+			}
+		}
+	}
+	if !found {
+		return func() {}
+	}
+
+	key := messageKey(m)
+	token := b.presses.mark(key)
+	b.editMarkup(m, pressed)
+	return func() {
+		if b.presses.unmark(key, token) {
+			b.editMarkup(m, original)
+		}
+	}
+}
+
+func (b *Bot) editMarkup(m *tgbotapi.Message, kb tgbotapi.InlineKeyboardMarkup) {
+	if _, err := b.sender.Request(tgbotapi.NewEditMessageReplyMarkup(m.Chat.ID, m.MessageID, kb)); err != nil {
+		b.logger.Debug("edit markup failed", zap.Int64("chatID", m.Chat.ID), zap.Error(err))
+	}
+}
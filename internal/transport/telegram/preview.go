@@ -0,0 +1,56 @@
+package telegram
+
+import (
+	"fmt"
+	"html"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ym-bot/internal/client/yandex"
+)
+
+// linkPreviews reports whether the bot's messages in chatID keep link
+// previews: private chats follow the user's /settings, groups /groupsettings.
+// Every reply asks, so the answers are cached until any settings change.
+func (b *Bot) linkPreviews(chatID int64) bool {
+	// Taken before the settings are read, so an answer racing a change is
+	// cached under the old revision and dropped by the next call.
+	rev := b.store.SettingsRevision()
+	b.previewsMu.Lock()
+	if rev != b.previewsRev {
+		clear(b.previews)
+		b.previewsRev = rev
+	}
+	show, ok := b.previews[chatID]
+	b.previewsMu.Unlock()
+	if ok {
+		return show
+	}
+
+	// Private chat ids are the users' own ids; group ids are negative.
+	if chatID > 0 {
+		show = !b.store.Prefs(chatID).HidePreviews
+	} else {
+		show = !b.store.ChatSettings(chatID).HidePreviews
+	}
+	b.previewsMu.Lock()
+	if rev == b.previewsRev {
+		b.previews[chatID] = show
+	}
+	b.previewsMu.Unlock()
+	return show
+}
+
+// trackPreview is a text message about t whose link preview is the track's
+// page on Yandex Music, cover included. The header goes above the linked
+// "artists — title" line and details below it.
+func trackPreview(chatID int64, header string, t yandex.Track, details string) tgbotapi.MessageConfig {
+	text := html.EscapeString(header) + "\n" +
+		fmt.Sprintf(`<a href="%s">%s — %s</a>`, t.URL(), html.EscapeString(t.ArtistsString()), html.EscapeString(t.FullTitle()))
+	if details != "" {
+		text += "\n" + html.EscapeString(details)
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeHTML
+	return msg
+}
@@ -0,0 +1,99 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+)
+
+const forgetPrompt = "Удалить все ваши данные в боте? Будут стёрты история загрузок, сохранённые поиски, настройки, подписки на подкасты, отзывы и очки в /quiz, а записи о ваших загрузках в журнале бота обезличены. Отменить удаление нельзя."
+
+// handleForgetMe asks the user to confirm erasing their data.
+func (b *Bot) handleForgetMe(_ context.Context, m *tgbotapi.Message) {
+	if !m.Chat.IsPrivate() {
+		b.reply(m.Chat.ID, "Удалить свои данные можно в личном чате с ботом: отправьте там /forgetme.")
+		return
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, forgetPrompt)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		b.button("🗑 Удалить", callback.ActionForget, strconv.FormatInt(m.From.ID, 10)),
+		b.button("✖ Отмена", callback.ActionDismiss),
+	))
+	if _, err := b.sender.Send(msg); err != nil {
+		b.logger.Warn("send forget prompt failed", zap.Int64("chatID", m.Chat.ID), zap.Error(err))
+	}
+}
+
+// handleForgetCallback erases the data of the user who confirmed; the
+// button only works for the user it was sent to.
+func (b *Bot) handleForgetCallback(_ context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	if p.Arg(0) != strconv.FormatInt(cb.From.ID, 10) {
+		b.sendAlert(cb, "Эта кнопка не для вас.")
+		return
+	}
+	data, err := b.store.ForgetUser(cb.From.ID)
+	if err != nil {
+		b.logger.Error("forget user failed", zap.Int64("userID", cb.From.ID), zap.Error(err))
+		b.sendAlert(cb, "Не удалось удалить данные, попробуйте позже.")
+		return
+	}
+	audited, err := b.audit.Forget(cb.From.ID)
+	if err != nil {
+		b.logger.Error("forget user in audit log failed", zap.Int64("userID", cb.From.ID), zap.Error(err))
+	}
+	// Streamed uploads leave no file behind.
+	for _, e := range data.DeadLetters {
+		if e.Path != "" {
+			_ = os.RemoveAll(filepath.Dir(e.Path))
+		}
+	}
+	b.vibesMu.Lock()
+	delete(b.vibes, cb.From.ID)
+	b.vibesMu.Unlock()
+	b.picksMu.Lock()
+	delete(b.picks, cb.From.ID)
+	b.picksMu.Unlock()
+	b.logger.Info("user data erased", zap.Int64("userID", cb.From.ID), zap.Int("history", len(data.History)),
+		zap.Int("feedback", len(data.Feedback)), zap.Int("deadLetters", len(data.DeadLetters)), zap.Int("audit", audited))
+
+	text := "🗑 Ваши данные удалены."
+	if cb.Message != nil {
+		edit := tgbotapi.NewEditMessageText(cb.Message.Chat.ID, cb.Message.MessageID, text)
+		if _, err := b.sender.Send(edit); err != nil {
+			b.logger.Debug("edit forget prompt failed", zap.Error(err))
+		}
+	}
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, text)); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+}
+
+// handleUserData sends an admin everything stored about a user as JSON:
+// /userdata <id>.
+func (b *Bot) handleUserData(_ context.Context, m *tgbotapi.Message) {
+	userID, err := strconv.ParseInt(strings.TrimSpace(m.CommandArguments()), 10, 64)
+	if err != nil {
+		b.reply(m.Chat.ID, "Использование: /userdata <id пользователя>")
+		return
+	}
+	raw, err := json.MarshalIndent(b.store.ExportUser(userID), "", "  ")
+	if err != nil {
+		b.logger.Warn("encode user data failed", zap.Int64("userID", userID), zap.Error(err))
+		b.reply(m.Chat.ID, "Не удалось подготовить файл :(")
+		return
+	}
+	b.logger.Info("user data exported", zap.Int64("adminID", m.From.ID), zap.Int64("userID", userID))
+	doc := tgbotapi.NewDocument(m.Chat.ID, tgbotapi.FileBytes{Name: fmt.Sprintf("ym-user-%d.json", userID), Bytes: raw})
+	if _, err := b.sender.Send(doc); err != nil {
+		b.logger.Warn("send user data failed", zap.Int64("chatID", m.Chat.ID), zap.Error(err))
+	}
+}
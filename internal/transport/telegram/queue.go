@@ -0,0 +1,99 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/metrics"
+	"ym-bot/internal/storage"
+)
+
+const queueUsage = "Использование: /queue [pause|resume]"
+
+// jobStateLabels names the job states in /queue.
+var jobStateLabels = map[storage.JobState]string{
+	storage.JobQueued:    "в очереди",
+	storage.JobRunning:   "выполняются",
+	storage.JobDone:      "готово",
+	storage.JobFailed:    "ошибки",
+	storage.JobCancelled: "отменено",
+}
+
+// handleQueue shows the download queue to admins; "pause" stops workers
+// from starting jobs, e.g. before a deploy, and "resume" lets them go on.
+// The pause is kept in the store and outlives maintenance and restarts.
+func (b *Bot) handleQueue(_ context.Context, m *tgbotapi.Message) {
+	switch arg := strings.ToLower(strings.TrimSpace(m.CommandArguments())); arg {
+	case "":
+		b.reply(m.Chat.ID, b.queueReport())
+	case "pause", "resume":
+		if b.pool == nil {
+			b.reply(m.Chat.ID, "Загрузки идут без очереди, приостанавливать нечего.")
+			return
+		}
+		if err := b.store.SetQueuePaused(arg == "pause"); err != nil {
+			b.logger.Warn("save queue pause failed", zap.Error(err))
+			b.reply(m.Chat.ID, "Не удалось сохранить состояние очереди.")
+			return
+		}
+		b.syncMaintenance()
+		b.logger.Info("queue toggled", zap.Int64("userID", m.From.ID), zap.Bool("paused", arg == "pause"))
+		b.reply(m.Chat.ID, b.queueReport())
+	default:
+		b.reply(m.Chat.ID, queueUsage)
+	}
+}
+
+// queueReport describes the queue's load, this bot's jobs and the work
+// deduplication has saved.
+func (b *Bot) queueReport() string {
+	var sb strings.Builder
+	if b.pool == nil {
+		sb.WriteString("Загрузки идут без очереди.\n")
+	} else {
+		st := b.pool.Stats()
+		state := "работает"
+		switch {
+		case b.store.QueuePaused():
+			state = "на паузе (/queue resume)"
+		case st.Paused:
+			state = "на паузе на время обслуживания"
+		}
+		fmt.Fprintf(&sb, "Очередь загрузок: %s\n", state)
+		fmt.Fprintf(&sb, "Ждут: %d (мест в очереди: %d)\n", st.Waiting, st.Backlog)
+		fmt.Fprintf(&sb, "Воркеры: заняты %d из %d\n", st.Busy, st.Workers)
+		fmt.Fprintf(&sb, "С запуска: выполнено %d, прервано %d, отклонено при переполнении %d\n",
+			st.Completed, st.Interrupted, st.Rejected)
+	}
+
+	counts := b.store.JobCounts(b.api.Self.UserName)
+	parts := make([]string, 0, len(storage.JobStates))
+	for _, s := range storage.JobStates {
+		parts = append(parts, fmt.Sprintf("%s %d", jobStateLabels[s], counts[s]))
+	}
+	fmt.Fprintf(&sb, "Задачи за сутки: %s\n", strings.Join(parts, ", "))
+	fmt.Fprintf(&sb, "Повторы отсеяны: нажатия %d, обновления %d", b.duplicatePresses.Load(), b.duplicateUpdates.Load())
+	if b.pool != nil {
+		fmt.Fprintf(&sb, ", задачи %d", b.pool.Stats().Duplicates)
+	}
+	return sb.String()
+}
+
+// CollectMetrics adds the bot's job counts by state and its deduplication
+// counters, labelled with the bot's username.
+func (b *Bot) CollectMetrics(w *metrics.Writer) {
+	bot := b.api.Self.UserName
+	counts := b.store.JobCounts(bot)
+	for _, s := range storage.JobStates {
+		w.Gauge("ymbot_jobs", "Download jobs kept in the store, by state; finished ones for a day.",
+			float64(counts[s]), "bot", bot, "state", string(s))
+	}
+	w.Counter("ymbot_duplicate_presses_total", "Download button presses dropped as repeats of a running or recent download.",
+		float64(b.duplicatePresses.Load()), "bot", bot)
+	w.Counter("ymbot_duplicate_updates_total", "Updates Telegram redelivered that were skipped.",
+		float64(b.duplicateUpdates.Load()), "bot", bot)
+}
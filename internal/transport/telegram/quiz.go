@@ -0,0 +1,252 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/storage"
+)
+
+const (
+	// quizClipLength is how much of the track a round plays.
+	quizClipLength = 15 * time.Second
+	// quizOpenPeriod is how long, in seconds, the poll of a round accepts answers.
+	quizOpenPeriod = 30
+	// quizOptions is the number of titles to choose from.
+	quizOptions = 4
+	// quizChartSize is how much of the chart rounds draw from.
+	quizChartSize = 100
+	// quizLikesSample is how many liked tracks are looked up per round.
+	quizLikesSample = 12
+	// quizTopSize is how many players the score table lists.
+	quizTopSize = 10
+)
+
+// quizRound is an open /quiz poll: the chat its answers score in and the
+// option holding the right title.
+type quizRound struct {
+	chatID  int64
+	correct int
+}
+
+// handleQuiz plays a round of the song guessing game: a short clip of a
+// random chart track, or of a track liked by the bot's Yandex account with
+// "/quiz likes" (bot admins only), and a quiz poll with four titles. The clip
+// needs the whole track, so it is cut by a worker pool job on the quota of
+// the user who started the round. "/quiz top" shows the chat's scores.
+func (b *Bot) handleQuiz(ctx context.Context, m *tgbotapi.Message) {
+	arg := strings.ToLower(strings.TrimSpace(m.CommandArguments()))
+	if arg == "top" {
+		b.reply(m.Chat.ID, b.quizTable(m.Chat.ID))
+		return
+	}
+	likes := arg == "likes"
+	if likes && !b.isAdmin(m.From.ID) {
+		b.reply(m.Chat.ID, "Викторина по лайкам аккаунта доступна только администраторам бота.")
+		return
+	}
+
+	chatID := m.Chat.ID
+	b.quizMu.Lock()
+	_, busy := b.quizChats[chatID]
+	if !busy {
+		b.quizChats[chatID] = struct{}{}
+	}
+	b.quizMu.Unlock()
+	if busy {
+		b.reply(chatID, "Раунд уже идёт — ответьте на опрос выше.")
+		return
+	}
+	idle := func() {
+		b.quizMu.Lock()
+		delete(b.quizChats, chatID)
+		b.quizMu.Unlock()
+	}
+
+	tracks, err := b.quizTracks(ctx, likes, b.store.ChatSettings(chatID).HideExplicit)
+	if err != nil {
+		idle()
+		b.logger.Warn("pick quiz tracks failed", zap.Error(err))
+		b.reply(chatID, presentError(err, errGeneric).String())
+		return
+	}
+	if len(tracks) < quizOptions {
+		idle()
+		b.reply(chatID, "Не нашлось треков для викторины, попробуйте позже.")
+		return
+	}
+
+	userID := m.From.ID
+	quota, err := b.reserveDownload(userID, func(reservedAt time.Time) error {
+		job := func(ctx context.Context) {
+			opened := false
+			defer func() {
+				// Pool workers run jobs in their own goroutines; a crash would kill the bot.
+				if p := recover(); p != nil {
+					b.logPanic(p, "quiz round", zap.Int64("chatID", chatID), zap.String("trackID", tracks[0].ID))
+				}
+				if !opened {
+					b.store.ReleaseQuota(userID, reservedAt)
+					idle()
+				}
+			}()
+			ctx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+			defer cancel()
+			opened = b.playQuiz(ctx, chatID, tracks)
+		}
+		if b.pool == nil {
+			go job(b.runCtx)
+			return nil
+		}
+		_, err := b.pool.Submit(fmt.Sprintf("quiz:%d", chatID), job)
+		return err
+	})
+	if errors.Is(err, storage.ErrQuotaExceeded) {
+		idle()
+		b.reply(chatID, quotaExceededText(quota, time.Now()))
+		return
+	}
+	if err != nil {
+		idle()
+		b.logger.Warn("download queue rejected quiz round", zap.Int64("chatID", chatID), zap.Error(err))
+		b.reply(chatID, "Сейчас слишком много загрузок, попробуйте через минуту.")
+	}
+}
+
+// playQuiz sends the clip of tracks[0] and the poll with all of tracks as
+// options to chatID, reporting whether the round opened.
+func (b *Bot) playQuiz(ctx context.Context, chatID int64, tracks []yandex.Track) bool {
+	answer := tracks[0]
+	clip, err := b.musicService.Clip(b.chatContext(ctx, chatID), answer.ID, quizClipLength)
+	if errors.Is(err, music.ErrNoTranscoder) {
+		b.reply(chatID, "Викторина недоступна: для фрагментов боту нужен ffmpeg (FFMPEG_PATH).")
+		return false
+	}
+	if err != nil {
+		b.logger.Warn("quiz clip failed", zap.String("trackID", answer.ID), zap.Error(err))
+		b.reply(chatID, presentError(err, errDownloadFailed).String())
+		return false
+	}
+	defer clip.Close()
+
+	audio := tgbotapi.NewAudio(chatID, tgbotapi.FilePath(clip.Path))
+	audio.Title = "Угадай мелодию"
+	audio.Performer = "?"
+	audio.Duration = int(quizClipLength.Seconds())
+	if _, err := b.sender.Send(audio); err != nil {
+		b.logger.Warn("send quiz clip failed", zap.Int64("chatID", chatID), zap.Error(err))
+		b.reply(chatID, presentError(err, errSendFailed).String())
+		return false
+	}
+
+	rand.Shuffle(len(tracks), func(i, j int) { tracks[i], tracks[j] = tracks[j], tracks[i] })
+	options := make([]string, len(tracks))
+	correct := 0
+	for i, t := range tracks {
+		options[i] = truncate(fmt.Sprintf("%s — %s", t.ArtistsString(), t.FullTitle()), 100)
+		if t.ID == answer.ID {
+			correct = i
+		}
+	}
+	poll := tgbotapi.NewPoll(chatID, "Что это за трек?", options...)
+	poll.Type = "quiz"
+	poll.IsAnonymous = false
+	poll.CorrectOptionID = int64(correct)
+	poll.OpenPeriod = quizOpenPeriod
+	poll.Explanation = truncate(fmt.Sprintf("Это %s — %s. Счёт: /quiz top", answer.ArtistsString(), answer.FullTitle()), 200)
+	sent, err := b.sender.Send(poll)
+	if err != nil || sent.Poll == nil {
+		b.logger.Warn("send quiz poll failed", zap.Int64("chatID", chatID), zap.Error(err))
+		b.reply(chatID, presentError(err, errSendFailed).String())
+		return false
+	}
+
+	pollID := sent.Poll.ID
+	b.quizMu.Lock()
+	b.quizzes[pollID] = quizRound{chatID: chatID, correct: correct}
+	b.quizMu.Unlock()
+	// Answers may still be in flight when the poll closes.
+	time.AfterFunc(quizOpenPeriod*time.Second+10*time.Second, func() {
+		b.quizMu.Lock()
+		delete(b.quizzes, pollID)
+		delete(b.quizChats, chatID)
+		b.quizMu.Unlock()
+	})
+	return true
+}
+
+// quizTracks draws the answer and the other options of a round: available
+// tracks with distinct titles, the answer first; explicit ones are left out
+// when hideExplicit is set.
+func (b *Bot) quizTracks(ctx context.Context, likes, hideExplicit bool) ([]yandex.Track, error) {
+	var pool []yandex.Track
+	if likes {
+		ids, err := b.musicService.LikedTrackIDs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rand.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+		if pool, err = b.musicService.Tracks(ctx, ids[:min(len(ids), quizLikesSample)]); err != nil {
+			return nil, err
+		}
+	} else {
+		chart, err := b.musicService.Chart(ctx, quizChartSize)
+		if err != nil {
+			return nil, err
+		}
+		pool = append(pool, chart...)
+	}
+	rand.Shuffle(len(pool), func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+
+	picked := make([]yandex.Track, 0, quizOptions)
+	titles := make(map[string]bool)
+	for _, t := range pool {
+		title := strings.ToLower(t.Title)
+		if t.Availability != yandex.Available || titles[title] || (hideExplicit && t.Explicit) {
+			continue
+		}
+		titles[title] = true
+		picked = append(picked, t)
+		if len(picked) == quizOptions {
+			break
+		}
+	}
+	return picked, nil
+}
+
+// handlePollAnswer scores an answer to an open /quiz poll.
+func (b *Bot) handlePollAnswer(_ context.Context, a *tgbotapi.PollAnswer) {
+	b.quizMu.Lock()
+	round, ok := b.quizzes[a.PollID]
+	b.quizMu.Unlock()
+	if !ok || len(a.OptionIDs) == 0 {
+		return
+	}
+	b.store.RecordQuizAnswer(round.chatID, a.User.ID, displayName(&a.User), a.OptionIDs[0] == round.correct)
+}
+
+// quizTable renders the chat's best players.
+func (b *Bot) quizTable(chatID int64) string {
+	scores := b.store.QuizScores(chatID)
+	if len(scores) == 0 {
+		return "В этом чате ещё не играли. Начните раунд командой /quiz."
+	}
+	var sb strings.Builder
+	sb.WriteString("🏆 Счёт викторины:")
+	for i, s := range scores {
+		if i == quizTopSize {
+			break
+		}
+		fmt.Fprintf(&sb, "\n%d. %s — %d из %d", i+1, s.Name, s.Correct, s.Answers)
+	}
+	return sb.String()
+}
@@ -0,0 +1,132 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/storage"
+)
+
+const quotaUsage = "Использование: /quota [userID [лимит|unlimited|reset]]"
+
+func (b *Bot) currentDailyLimit() int {
+	return b.settings.Load().DailyLimit
+}
+
+// reserveDownload takes one download off userID's daily quota and calls
+// submit with the time it was reserved at; when submit fails, the download
+// is given back. Past the limit it returns storage.ErrQuotaExceeded with the
+// status to tell the user, otherwise submit's error.
+func (b *Bot) reserveDownload(userID int64, submit func(reservedAt time.Time) error) (storage.QuotaStatus, error) {
+	now := time.Now()
+	quota, err := b.store.ConsumeQuota(userID, b.currentDailyLimit(), now)
+	if err != nil {
+		return quota, err
+	}
+	if err := submit(now); err != nil {
+		b.store.ReleaseQuota(userID, now)
+		return quota, err
+	}
+	return quota, nil
+}
+
+// queueDownload submits req on its user's quota, see reserveDownload, and
+// returns the acknowledgement of submitDownload.
+func (b *Bot) queueDownload(ctx context.Context, req downloadRequest) (string, storage.QuotaStatus, error) {
+	var ack string
+	quota, err := b.reserveDownload(req.userID, func(reservedAt time.Time) error {
+		req.reservedAt = reservedAt
+		var err error
+		ack, err = b.submitDownload(ctx, req)
+		return err
+	})
+	return ack, quota, err
+}
+
+// handleQuota shows the caller's quota; admins may inspect or override other users.
+func (b *Bot) handleQuota(_ context.Context, m *tgbotapi.Message) {
+	args := strings.Fields(m.CommandArguments())
+	now := time.Now()
+
+	if len(args) == 0 {
+		status := b.store.QuotaStatus(m.From.ID, b.currentDailyLimit(), now)
+		b.reply(m.Chat.ID, renderQuota(status, now))
+		return
+	}
+	if !b.isAdmin(m.From.ID) {
+		b.reply(m.Chat.ID, "Команда доступна только администраторам.")
+		return
+	}
+
+	userID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || len(args) > 2 {
+		b.reply(m.Chat.ID, quotaUsage)
+		return
+	}
+
+	if len(args) == 2 {
+		switch strings.ToLower(args[1]) {
+		case "reset":
+			err = b.store.ClearQuotaOverride(userID)
+		case "unlimited":
+			err = b.store.SetQuotaOverride(userID, storage.Unlimited)
+		default:
+			limit, convErr := strconv.Atoi(args[1])
+			if convErr != nil || limit <= 0 {
+				b.reply(m.Chat.ID, quotaUsage)
+				return
+			}
+			err = b.store.SetQuotaOverride(userID, limit)
+		}
+		if err != nil {
+			b.logger.Warn("quota override failed", zap.Int64("userID", userID), zap.Error(err))
+			b.reply(m.Chat.ID, "Не удалось сохранить лимит :(")
+			return
+		}
+		b.logger.Info("quota override changed",
+			zap.Int64("adminID", m.From.ID), zap.Int64("userID", userID), zap.String("value", args[1]))
+	}
+
+	status := b.store.QuotaStatus(userID, b.currentDailyLimit(), now)
+	b.reply(m.Chat.ID, fmt.Sprintf("Пользователь %d\n%s", userID, renderQuota(status, now)))
+}
+
+func renderQuota(q storage.QuotaStatus, now time.Time) string {
+	if q.Limit == storage.Unlimited {
+		return fmt.Sprintf("Лимит не ограничен. Сегодня скачано: %d.", q.Used)
+	}
+	text := fmt.Sprintf("Осталось %d из %d треков. Сброс через %s (00:00 UTC).",
+		q.Remaining(), q.Limit, humanDuration(q.ResetAt.Sub(now)))
+	if q.Overridden {
+		text += "\nЛимит назначен администратором."
+	}
+	return text
+}
+
+func quotaExceededText(q storage.QuotaStatus, now time.Time) string {
+	return fmt.Sprintf("Дневной лимит (%d треков) исчерпан. Новые загрузки будут доступны через %s.",
+		q.Limit, humanDuration(q.ResetAt.Sub(now)))
+}
+
+// quotaHint is appended to the download acknowledgement when a limit applies.
+func quotaHint(q storage.QuotaStatus) string {
+	if q.Limit == storage.Unlimited {
+		return ""
+	}
+	return fmt.Sprintf(" Осталось на сегодня: %d из %d.", q.Remaining(), q.Limit)
+}
+
+// humanDuration renders d as "3 ч 12 мин" rounded up to a minute.
+func humanDuration(d time.Duration) string {
+	mins := int((d + time.Minute - 1) / time.Minute)
+	if mins < 60 {
+		return fmt.Sprintf("%d мин", mins)
+	}
+	return fmt.Sprintf("%d ч %d мин", mins/60, mins%60)
+}
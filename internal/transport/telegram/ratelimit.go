@@ -0,0 +1,35 @@
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// rateLimit applies the rate limiter to the update's sender. Throttled
+// callbacks get an alert; throttled inline queries and messages are dropped.
+func (b *Bot) rateLimit(next updateHandler) updateHandler {
+	return func(ctx context.Context, u tgbotapi.Update) {
+		if b.allow(u) {
+			next(ctx, u)
+			return
+		}
+		if u.CallbackQuery != nil {
+			b.sendAlert(u.CallbackQuery, errThrottled.String())
+		}
+	}
+}
+
+func (b *Bot) allow(update tgbotapi.Update) bool {
+	if b.limiter == nil {
+		return true
+	}
+
+	from := update.SentFrom()
+	if from == nil || b.isAdmin(from.ID) || b.limiter.Allow(from.ID) {
+		return true
+	}
+	b.logger.Debug("update throttled", zap.Int64("userID", from.ID), zap.Int("updateID", update.UpdateID))
+	return false
+}
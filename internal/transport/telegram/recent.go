@@ -0,0 +1,81 @@
+package telegram
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+)
+
+// handleRecent lists the user's recent searches with a button to rerun each.
+func (b *Bot) handleRecent(_ context.Context, m *tgbotapi.Message) {
+	recent := b.store.RecentSearches(m.From.ID)
+	if len(recent) == 0 {
+		b.reply(m.Chat.ID, "История поиска пуста — пришлите название трека или артиста.")
+		return
+	}
+
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(recent))
+	for _, r := range recent {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate("🔎 "+r.Query, maxButtonLabel), callback.ActionRecent, queryKey(r.Query)),
+		))
+	}
+	msg := tgbotapi.NewMessage(m.Chat.ID, "🕘 Последние поиски:")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := b.sender.Send(msg); err != nil {
+		b.logger.Warn("send recent searches failed", zap.Int64("chatID", m.Chat.ID), zap.Error(err))
+	}
+}
+
+// handleRecentCallback reruns the saved search the button refers to.
+func (b *Bot) handleRecentCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	if cb.Message == nil {
+		return
+	}
+	// Queries may not fit callback_data, so buttons carry a hash of one.
+	query := ""
+	for _, r := range b.store.RecentSearches(cb.From.ID) {
+		if queryKey(r.Query) == p.Arg(0) {
+			query = r.Query
+			break
+		}
+	}
+	if query == "" {
+		b.sendAlert(cb, "Этого запроса уже нет в истории.")
+		return
+	}
+
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, "")); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+	b.sendSearch(ctx, cb.Message.Chat.ID, cb.From.ID, query)
+}
+
+// recentSearchResults turns the user's recent searches into inline results;
+// each posts a button that reopens inline mode with its query.
+func (b *Bot) recentSearchResults(userID int64) []interface{} {
+	recent := b.store.RecentSearches(userID)
+	results := make([]interface{}, 0, len(recent))
+	for i, r := range recent {
+		query := r.Query
+		article := tgbotapi.NewInlineQueryResultArticle("r"+strconv.Itoa(i), "🔎 "+query, searchHeader+query)
+		article.Description = "Искать снова"
+		article.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{{
+			{Text: "🔎 Искать снова", SwitchInlineQueryCurrentChat: &query},
+		}}}
+		results = append(results, article)
+	}
+	return results
+}
+
+// queryKey is a short stable id for a query that fits into callback data.
+func queryKey(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:4])
+}
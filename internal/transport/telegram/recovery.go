@@ -0,0 +1,77 @@
+package telegram
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/storage"
+)
+
+// saveJob persists req so it can be resumed if the bot restarts before it finishes.
+func (b *Bot) saveJob(req downloadRequest, state storage.JobState) {
+	err := b.store.SaveJob(storage.Job{
+		Key:        req.key,
+		Bot:        b.api.Self.UserName,
+		UserID:     req.userID,
+		ChatID:     req.chatID,
+		TrackID:    req.trackID,
+		Split:      req.split,
+		Quiet:      req.quiet,
+		InlineID:   req.inlineID,
+		Group:      req.group,
+		ReservedAt: req.reservedAt,
+		State:      state,
+		UpdatedAt:  time.Now(),
+	})
+	if err != nil {
+		b.logger.Warn("save job failed", zap.String("key", req.key), zap.Error(err))
+	}
+}
+
+func (b *Bot) setJobState(key string, state storage.JobState) {
+	b.store.SetJobState(key, state, time.Now())
+}
+
+// recoverJobs re-queues downloads that were queued or running when the bot
+// last stopped. Their quota stays reserved from the original request.
+func (b *Bot) recoverJobs(ctx context.Context) {
+	jobs := b.store.PendingJobs(b.api.Self.UserName)
+	if len(jobs) == 0 {
+		return
+	}
+	b.logger.Info("recovering download jobs", zap.Int("count", len(jobs)))
+
+	for _, j := range jobs {
+		chatID := j.ChatID
+		req := downloadRequest{
+			key:        j.Key,
+			userID:     j.UserID,
+			chatID:     chatID,
+			trackID:    j.TrackID,
+			reservedAt: j.ReservedAt,
+			split:      j.Split,
+			quiet:      j.Quiet,
+			inlineID:   j.InlineID,
+			group:      j.Group,
+			notify:     func(text string) { b.reply(chatID, text) },
+		}
+		// The old status shows a stale place in line; the new run sends its own.
+		if j.StatusMessageID != 0 {
+			if _, err := b.sender.Request(tgbotapi.NewDeleteMessage(chatID, j.StatusMessageID)); err != nil {
+				b.logger.Debug("job status delete failed", zap.Int64("chatID", chatID), zap.Error(err))
+			}
+		}
+		if j.State == storage.JobRunning && !j.Quiet {
+			b.reply(chatID, "Загрузка трека прервалась из-за перезапуска бота, начинаю заново.")
+		}
+		if _, err := b.submitDownload(ctx, req); err != nil {
+			b.logger.Warn("recover job failed", zap.String("key", j.Key), zap.Error(err))
+			b.store.ReleaseQuota(j.UserID, j.ReservedAt)
+			b.saveJob(req, storage.JobFailed)
+			b.reply(chatID, "Не удалось возобновить загрузку после перезапуска, запросите трек заново.")
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"ym-bot/internal/callback"
+)
+
+// messageHandler handles a chat message, typically a slash command.
+type messageHandler func(ctx context.Context, m *tgbotapi.Message)
+
+// callbackHandler handles a press of a signed inline button.
+type callbackHandler func(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload)
+
+// commandRoute binds a slash command to its handler.
+type commandRoute struct {
+	handle messageHandler
+	// admin limits the command to AdminIDs; others get no reply at all.
+	admin bool
+	// scope is where the Telegram command menu lists the command, and
+	// description and descriptionEN what it says there (see registerCommands).
+	scope                      commandScope
+	description, descriptionEN string
+}
+
+// commandRoutes maps slash commands (without the slash) to their handlers.
+func (b *Bot) commandRoutes() map[string]commandRoute {
+	return map[string]commandRoute{
+		"start": {handle: b.handleStart, scope: scopeHidden},
+		"help": {handle: b.handleStart,
+			description: "Справка по командам", descriptionEN: "Command help"},
+		"settings": {handle: b.handleSettings, scope: scopePrivate,
+			description: "Настройки отправки и сортировки поиска", descriptionEN: "Delivery settings and search order"},
+		"groupsettings": {handle: b.handleGroupSettings, scope: scopeGroupAdmin,
+			description: "Настройки чата", descriptionEN: "Chat settings"},
+		"quota": {handle: b.handleQuota, scope: scopePrivate,
+			description: "Сколько треков осталось на сегодня", descriptionEN: "Tracks left for today"},
+		"export": {handle: b.handleExport, scope: scopePrivate,
+			description: "История загрузок файлом", descriptionEN: "Download history as a file"},
+		"mystats": {handle: b.handleMyStats,
+			description: "Моя статистика и итоги года", descriptionEN: "My stats and year in review"},
+		"feedback": {handle: b.handleFeedback,
+			description: "Написать администраторам", descriptionEN: "Write to the admins"},
+		"nowplaying": {handle: b.handleNowPlaying, admin: true,
+			description: "Что сейчас играет в Яндекс Музыке", descriptionEN: "What is playing in Yandex Music"},
+		"myplaylists": {handle: b.handleMyPlaylists, admin: true,
+			description: "Плейлисты аккаунта Яндекс Музыки", descriptionEN: "Playlists of the Yandex Music account"},
+		"playlists": {handle: b.handleDiscover,
+			description: "Подборки и плейлисты по настроению", descriptionEN: "Editorial picks and mood playlists"},
+		"genres": {handle: b.handleGenres,
+			description: "Жанры и их популярные треки", descriptionEN: "Genres and their top tracks"},
+		"recent": {handle: b.handleRecent, scope: scopePrivate,
+			description: "Последние поиски", descriptionEN: "Recent searches"},
+		"vibe": {handle: b.handleVibe, admin: true,
+			description: "«Моя волна»", descriptionEN: "\"My Wave\" station"},
+		"party": {handle: b.handleParty, scope: scopeGroup,
+			description: "Общая очередь треков чата", descriptionEN: "The chat's shared track queue"},
+		"quiz": {handle: b.handleQuiz,
+			description: "Угадай мелодию", descriptionEN: "Guess the song"},
+		"karaoke": {handle: b.handleKaraoke,
+			description: "Караоке: строки песни в такт", descriptionEN: "Karaoke: song lines in time"},
+		"podcast": {handle: b.handlePodcast,
+			description: "Подкасты и подписки на выпуски", descriptionEN: "Podcasts and episode alerts"},
+		"id": {handle: b.handleTrackID,
+			description: "Скачать трек по id Яндекс Музыки", descriptionEN: "Download a track by Yandex Music id"},
+		"isrc": {handle: b.handleISRC,
+			description: "Скачать трек по коду ISRC", descriptionEN: "Download a track by ISRC"},
+		"forgetme": {handle: b.handleForgetMe, scope: scopePrivate,
+			description: "Удалить мои данные", descriptionEN: "Erase my data"},
+		"reload": {handle: b.handleReload, admin: true,
+			description: "Перечитать конфигурацию", descriptionEN: "Reload the configuration"},
+		"set": {handle: b.handleSet, admin: true,
+			description: "Изменить настройку до перезагрузки", descriptionEN: "Override a setting until reload"},
+		"redeliver": {handle: b.handleRedeliver, admin: true,
+			description: "Повторить недоставленные треки", descriptionEN: "Retry undelivered tracks"},
+		"maintenance": {handle: b.handleMaintenance, admin: true,
+			description: "Режим обслуживания", descriptionEN: "Maintenance mode"},
+		"queue": {handle: b.handleQueue, admin: true,
+			description: "Очередь загрузок", descriptionEN: "Download queue"},
+		"stats": {handle: b.handleStats, admin: true,
+			description: "Статистика бота", descriptionEN: "Bot statistics"},
+		"audit": {handle: b.handleAudit, admin: true,
+			description: "Загрузки пользователя или трека", descriptionEN: "Downloads of a user or a track"},
+		"httplog": {handle: b.handleHTTPLog, admin: true,
+			description: "Последние запросы к Яндексу", descriptionEN: "Recent Yandex requests"},
+		"broadcast": {handle: b.handleBroadcast, admin: true,
+			description: "Рассылка всем пользователям", descriptionEN: "Message all users"},
+		"userdata": {handle: b.handleUserData, admin: true,
+			description: "Данные пользователя файлом", descriptionEN: "A user's stored data as a file"},
+	}
+}
+
+// callbackRoutes maps button actions that are not menus to their handlers.
+func (b *Bot) callbackRoutes() map[callback.Action]callbackHandler {
+	return map[callback.Action]callbackHandler{
+		callback.ActionDownload: b.handleDownloadCallback,
+		callback.ActionCancel:   b.handleCancelCallback,
+		callback.ActionImport:   b.handleImportCallback,
+		callback.ActionFeedback: b.handleFeedbackCallback,
+		callback.ActionDismiss:  b.handleDismissCallback,
+		callback.ActionForget:   b.handleForgetCallback,
+		callback.ActionPlaylist: b.handlePlaylistCallback,
+		callback.ActionShare:    b.handleShareCallback,
+		callback.ActionWaveform: b.handleWaveformCallback,
+		callback.ActionLyrics:   b.handleLyricsCallback,
+		callback.ActionKaraoke:  b.handleKaraokeCallback,
+		callback.ActionRecent:   b.handleRecentCallback,
+		callback.ActionVibe:     b.handleVibeCallback,
+		callback.ActionParty:    b.handlePartyCallback,
+	}
+}
+
+// route dispatches an update to the handler for its type.
+func (b *Bot) route(ctx context.Context, u tgbotapi.Update) {
+	switch {
+	case u.InlineQuery != nil:
+		b.handleInlineQuery(ctx, u.InlineQuery)
+	case u.Message != nil:
+		b.handleMessage(ctx, u.Message)
+	case u.CallbackQuery != nil:
+		b.handleCallback(ctx, u.CallbackQuery)
+	case u.ChosenInlineResult != nil:
+		b.handleChosenInlineResult(ctx, u.ChosenInlineResult)
+	case u.PollAnswer != nil:
+		b.handlePollAnswer(ctx, u.PollAnswer)
+	case u.MyChatMember != nil:
+		b.handleMyChatMember(ctx, u.MyChatMember)
+	}
+}
+
+// runCommand looks up and runs the route for m's command; unknown commands
+// are ignored so groups with several bots stay quiet.
+func (b *Bot) runCommand(ctx context.Context, m *tgbotapi.Message) {
+	r, ok := b.commands[m.Command()]
+	if !ok || (r.admin && !b.isAdmin(m.From.ID)) {
+		return
+	}
+	r.handle(ctx, m)
+}
@@ -0,0 +1,218 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+)
+
+const (
+	// searchHeader prefixes the query line so paging callbacks can recover it from the message.
+	searchHeader = "🔎 "
+	// suggestHeader prefixes a spelling suggestion, recovered the same way by its search button.
+	suggestHeader = "💡 Возможно, вы имели в виду: "
+	// maxButtonLabel keeps track buttons readable on narrow clients.
+	maxButtonLabel = 48
+)
+
+// handleTextSearch answers a plain text message with the first page of results.
+func (b *Bot) handleTextSearch(ctx context.Context, m *tgbotapi.Message) {
+	query := strings.TrimSpace(m.Text)
+	if query == "" {
+		return
+	}
+
+	b.sendSearch(ctx, m.Chat.ID, m.From.ID, query)
+}
+
+// sendSearch runs query for userID and sends the overview of all result
+// types to chatID; tabs below it switch to the full sections.
+func (b *Bot) sendSearch(ctx context.Context, chatID, userID int64, query string) {
+	ctx, cancel := context.WithTimeout(ctx, b.inlineTimeout)
+	defer cancel()
+
+	res, err := b.musicService.SearchAll(ctx, query, b.searchOrder(userID), searchLimit)
+	if err != nil {
+		b.logger.Warn("search failed", zap.String("query", query), zap.Error(err))
+		b.reply(chatID, "Поиск сейчас недоступен, попробуйте позже.")
+		return
+	}
+	now := time.Now()
+	b.store.RecordSearch(userID, now)
+	if res.Empty() {
+		if res.Correction != "" && res.Correction != query {
+			b.suggestCorrection(chatID, query, res.Correction)
+			return
+		}
+		b.reply(chatID, "Ничего не нашлось.")
+		return
+	}
+	b.store.SaveSearch(userID, query, now)
+	if res.Corrected && res.Correction != "" {
+		// Page through what Yandex actually searched for.
+		query = res.Correction
+	}
+
+	text, keyboard := b.renderOverview(query, res)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
+	sent, err := b.sender.Send(msg)
+	if err != nil {
+		b.logger.Warn("send search results failed", zap.String("query", query), zap.Error(err))
+		return
+	}
+	b.rememberPicks(&sent, 0, res.Tracks[:min(overviewTop, len(res.Tracks))])
+}
+
+// searchMenu renders another tab or page of the results message. The query
+// is recovered from the message text, so only the offset, the tab and the
+// album or artist opened travel in the button.
+func (b *Bot) searchMenu(ctx context.Context, req menuRequest) (menuScreen, error) {
+	offset, err := strconv.Atoi(req.payload.Arg(0))
+	if err != nil || offset < 0 {
+		return menuScreen{}, menuAlert("Кнопка устарела, повторите поиск.")
+	}
+	query := ""
+	if req.message != nil {
+		query = queryFromResults(req.message.Text)
+	}
+	if query == "" {
+		return menuScreen{}, menuAlert("Запрос устарел, повторите поиск.")
+	}
+
+	switch tab := req.payload.Arg(1); tab {
+	case tabOverview, tabAlbums, tabArtists, tabPlaylists:
+		return b.sectionMenu(ctx, req, query, tab)
+	case tabAlbum:
+		return b.albumMenu(ctx, req.message, query, req.payload.Arg(2), offset)
+	case tabArtist:
+		if query, err = b.artistQuery(ctx, query, req.payload.Arg(2)); err != nil {
+			return menuScreen{}, err
+		}
+	}
+
+	res, err := b.musicService.Search(ctx, query, b.searchOrder(req.userID), searchLimit, offset)
+	if err != nil {
+		b.logger.Warn("search page failed", zap.String("query", query), zap.Int("offset", offset), zap.Error(err))
+		return menuScreen{}, menuAlert("Не удалось загрузить страницу :(")
+	}
+	tracks := res.Tracks
+	if res.Corrected && res.Correction != "" {
+		query = res.Correction
+	}
+	if len(tracks) == 0 {
+		if offset == 0 {
+			return menuScreen{}, menuAlert("Треков не нашлось.")
+		}
+		return menuScreen{}, menuAlert("Больше результатов нет.")
+	}
+	b.rememberPicks(req.message, offset, tracks)
+	keyboard := b.searchKeyboard(tracks, offset, len(tracks)+res.Collapsed)
+	return menuScreen{text: renderSearchPage(query, tracks, offset), keyboard: keyboard}, nil
+}
+
+// suggestCorrection offers a button that reruns the search with Yandex's spelling.
+func (b *Bot) suggestCorrection(chatID int64, query, correction string) {
+	text := fmt.Sprintf("%s%s\n\nПо запросу «%s» ничего не нашлось.", suggestHeader, correction, query)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		b.button(truncate("🔎 "+correction, maxButtonLabel), callback.ActionPage, "0", tabOverview),
+	))
+	if _, err := b.sender.Send(msg); err != nil {
+		b.logger.Warn("send suggestion failed", zap.String("query", query), zap.Error(err))
+	}
+}
+
+func renderSearchPage(query string, tracks []yandex.Track, offset int) string {
+	var sb strings.Builder
+	sb.WriteString(searchHeader + query + "\n")
+	restricted := false
+	for i, t := range tracks {
+		fmt.Fprintf(&sb, "\n%d. %s — %s", offset+i+1, t.ArtistsString(), t.FullTitle())
+		if d := t.DurationString(); d != "" {
+			fmt.Fprintf(&sb, " (%s)", d)
+		}
+		if mark := restrictionMark(t); mark != "" {
+			sb.WriteString(" " + mark)
+			restricted = true
+		}
+	}
+	if restricted {
+		sb.WriteString("\n\n" + restrictionLegend)
+	}
+	return sb.String()
+}
+
+const restrictionLegend = "🔒 — только с подпиской Яндекс Плюс, 🚫 — недоступен в регионе бота."
+
+// restrictionMark flags tracks whose download is expected to fail.
+func restrictionMark(t yandex.Track) string {
+	switch t.Availability {
+	case yandex.PremiumOnly:
+		return "🔒"
+	case yandex.RegionLocked:
+		return "🚫"
+	}
+	return ""
+}
+
+// queryFromResults extracts the query line written by renderSearchPage or suggestCorrection.
+func queryFromResults(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	for _, header := range []string{searchHeader, suggestHeader} {
+		if strings.HasPrefix(line, header) {
+			return strings.TrimSpace(strings.TrimPrefix(line, header))
+		}
+	}
+	return ""
+}
+
+// searchKeyboard renders the track buttons of a page that covers fetched
+// search results, duplicates collapsed out of tracks included.
+func (b *Bot) searchKeyboard(tracks []yandex.Track, offset, fetched int) tgbotapi.InlineKeyboardMarkup {
+	rows := make([][]tgbotapi.InlineKeyboardButton, 0, len(tracks)+2)
+	for i, t := range tracks {
+		label := fmt.Sprintf("%d. %s — %s", offset+i+1, t.ArtistsString(), t.FullTitle())
+		if mark := restrictionMark(t); mark != "" {
+			label = mark + " " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			b.button(truncate(label, maxButtonLabel), callback.ActionDownload, t.ID),
+		))
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if offset > 0 {
+		prev := offset - searchLimit
+		if prev < 0 {
+			prev = 0
+		}
+		nav = append(nav, b.button("◀ Назад", callback.ActionPage, strconv.Itoa(prev)))
+	}
+	if fetched >= searchLimit {
+		nav = append(nav, b.button("Далее ▶", callback.ActionPage, strconv.Itoa(offset+fetched)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, b.tabRow(tabTracks))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,153 @@
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/audio"
+	"ym-bot/internal/callback"
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/storage"
+	"ym-bot/internal/translit"
+)
+
+const (
+	settingsKeyDocument  = "document"
+	settingsKeyPreflight = "preflight"
+	settingsKeyOrder     = "order"
+	settingsKeyPreviews  = "previews"
+	settingsKeyLoudness  = "loudness"
+	settingsKeySilence   = "silence"
+	settingsKeyTranslit  = "translit"
+)
+
+// orderLabels names the search orders in the settings menu.
+var orderLabels = map[music.Order]string{
+	music.OrderRelevance: "по релевантности",
+	music.OrderPopular:   "популярные",
+	music.OrderNew:       "новые",
+	music.OrderShort:     "короткие",
+	music.OrderLong:      "длинные",
+}
+
+// translitLabels names the transliteration directions in the settings menu.
+var translitLabels = map[translit.Direction]string{
+	translit.None:       "выкл",
+	translit.ToLatin:    "в латиницу",
+	translit.ToCyrillic: "в кириллицу",
+}
+
+// handleSettings opens the settings menu for /settings.
+func (b *Bot) handleSettings(ctx context.Context, m *tgbotapi.Message) {
+	b.openMenu(ctx, m.Chat.ID, m.From.ID, callback.ActionSettings)
+}
+
+// settingsMenu shows the user's preferences; a key argument toggles that one first.
+func (b *Bot) settingsMenu(_ context.Context, req menuRequest) (menuScreen, error) {
+	key := req.payload.Arg(0)
+	if key == "" {
+		return menuScreen{text: "Настройки", keyboard: b.settingsKeyboard(b.store.Prefs(req.userID))}, nil
+	}
+
+	prefs, err := b.store.UpdatePrefs(req.userID, func(p *storage.UserPrefs) {
+		switch key {
+		case settingsKeyDocument:
+			p.SendAsDocument = !p.SendAsDocument
+		case settingsKeyPreflight:
+			p.SkipPreflight = !p.SkipPreflight
+		case settingsKeyOrder:
+			p.SearchOrder = string(nextOrder(music.Order(p.SearchOrder)))
+		case settingsKeyPreviews:
+			p.HidePreviews = !p.HidePreviews
+		case settingsKeyLoudness:
+			p.Normalize = !p.Normalize
+		case settingsKeySilence:
+			p.TrimSilence = !p.TrimSilence
+		case settingsKeyTranslit:
+			p.Transliterate = string(nextDirection(translit.Direction(p.Transliterate)))
+		}
+	})
+	if err != nil {
+		b.logger.Warn("update prefs failed", zap.Int64("userID", req.userID), zap.Error(err))
+		return menuScreen{}, menuAlert("Не удалось сохранить настройки :(")
+	}
+	return menuScreen{text: "Настройки", keyboard: b.settingsKeyboard(prefs), notice: "Сохранено"}, nil
+}
+
+func (b *Bot) settingsKeyboard(prefs storage.UserPrefs) tgbotapi.InlineKeyboardMarkup {
+	rows := [][]tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardRow(
+			b.button("Отправлять файлом: "+onOff(prefs.SendAsDocument), callback.ActionSettings, settingsKeyDocument),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			b.button("Спрашивать перед крупной загрузкой: "+onOff(!prefs.SkipPreflight), callback.ActionSettings, settingsKeyPreflight),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			b.button("Сортировка поиска: "+orderLabels[music.Order(prefs.SearchOrder)], callback.ActionSettings, settingsKeyOrder),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			b.button("Превью ссылок: "+onOff(!prefs.HidePreviews), callback.ActionSettings, settingsKeyPreviews),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			b.button("Транслитерация: "+translitLabels[translit.Direction(prefs.Transliterate)], callback.ActionSettings, settingsKeyTranslit),
+		),
+	}
+	// Processing re-encodes with ffmpeg; without one the switches would do nothing.
+	if b.musicService.CanTranscode() {
+		rows = append(rows,
+			tgbotapi.NewInlineKeyboardRow(
+				b.button("Выравнивать громкость: "+onOff(prefs.Normalize), callback.ActionSettings, settingsKeyLoudness),
+			),
+			tgbotapi.NewInlineKeyboardRow(
+				b.button("Обрезать тишину: "+onOff(prefs.TrimSilence), callback.ActionSettings, settingsKeySilence),
+			),
+		)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// searchOrder is how userID wants search results sorted.
+func (b *Bot) searchOrder(userID int64) music.Order {
+	order, _ := music.ParseOrder(b.store.Prefs(userID).SearchOrder)
+	return order
+}
+
+// audioFilters are the processing passes userID turned on in /settings.
+func (b *Bot) audioFilters(userID int64) audio.Filters {
+	prefs := b.store.Prefs(userID)
+	return audio.Filters{Loudnorm: prefs.Normalize, TrimSilence: prefs.TrimSilence}
+}
+
+// transliteration is the script userID wants artists and titles in.
+func (b *Bot) transliteration(userID int64) translit.Direction {
+	return translit.Direction(b.store.Prefs(userID).Transliterate)
+}
+
+// nextOrder cycles through music.Orders.
+func nextOrder(o music.Order) music.Order {
+	for i, known := range music.Orders {
+		if known == o {
+			return music.Orders[(i+1)%len(music.Orders)]
+		}
+	}
+	return music.OrderRelevance
+}
+
+// nextDirection cycles through translit.Directions.
+func nextDirection(d translit.Direction) translit.Direction {
+	for i, known := range translit.Directions {
+		if known == d {
+			return translit.Directions[(i+1)%len(translit.Directions)]
+		}
+	}
+	return translit.None
+}
+
+func onOff(v bool) string {
+	if v {
+		return "вкл"
+	}
+	return "выкл"
+}
@@ -0,0 +1,98 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/render"
+	"ym-bot/internal/services/cover"
+)
+
+// deliveryKeyboard is put under whole tracks sent to private chats: the
+// "save to playlist" button when the user may use it, "lyrics" when Yandex
+// has them, "share as image" and a link to Yandex Music.
+func (b *Bot) deliveryKeyboard(userID int64, t yandex.Track) (tgbotapi.InlineKeyboardMarkup, bool) {
+	if t.ID == "" {
+		return tgbotapi.InlineKeyboardMarkup{}, false
+	}
+	markup, _ := b.playlistKeyboard(userID, t.ID)
+	if t.HasLyrics {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			b.button("📝 Текст", callback.ActionLyrics, t.ID)))
+	}
+	markup.InlineKeyboard = append(markup.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+		b.button("🖼 Поделиться картинкой", callback.ActionShare, t.ID),
+		tgbotapi.NewInlineKeyboardButtonURL("🌐 Яндекс Музыка", t.URL()),
+	))
+	return markup, true
+}
+
+// handleShareCallback sends the track as an image card with a download
+// button, ready to be forwarded.
+func (b *Bot) handleShareCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	trackID := p.Arg(0)
+	if trackID == "" {
+		return
+	}
+	chatID := cb.From.ID
+	if cb.Message != nil && cb.Message.Chat != nil {
+		chatID = cb.Message.Chat.ID
+	}
+	// Rendering is not free, so repeated taps draw the card once.
+	press := "share:" + pressKey(cb.From.ID, trackID)
+	if !b.presses.claim(press, false) {
+		b.sendAlert(cb, "Картинка уже готовится.")
+		return
+	}
+	defer b.presses.done(press)
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	tracks, err := b.musicService.Tracks(ctx, []string{trackID})
+	if err != nil || len(tracks) == 0 {
+		b.logger.Warn("share track lookup failed", zap.String("trackID", trackID), zap.Error(err))
+		b.sendAlert(cb, "Не удалось найти трек, попробуйте позже.")
+		return
+	}
+	t := tracks[0]
+
+	var art []byte
+	if b.covers != nil && t.CoverURI != "" {
+		if art, err = b.covers.Fetch(ctx, t.CoverURI, cover.Card); err != nil {
+			b.logger.Debug("cover unavailable", zap.String("trackID", t.ID), zap.Error(err))
+		}
+	}
+	card, err := render.TrackCard(render.Track{
+		Title:     t.FullTitle(),
+		Artists:   t.ArtistsString(),
+		Duration:  t.DurationString(),
+		Cover:     art,
+		Watermark: "@" + b.api.Self.UserName,
+	})
+	if err != nil {
+		b.logger.Warn("render track card failed", zap.String("trackID", trackID), zap.Error(err))
+		b.sendAlert(cb, "Не удалось нарисовать картинку :(")
+		return
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "track.png", Bytes: card})
+	b.rememberTrack(t)
+	// The deep link lets whoever gets the card forwarded fetch the track.
+	photo.Caption = fmt.Sprintf("%s — %s\n%s", t.ArtistsString(), t.FullTitle(), trackDeepLink(b.api.Self.UserName, t))
+	photo.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(b.trackLinkRow(t))
+	if _, err := b.sender.Send(photo); err != nil {
+		b.logger.Warn("send track card failed", zap.Int64("chatID", chatID), zap.Error(err))
+		b.sendAlert(cb, "Не удалось отправить картинку, попробуйте позже.")
+		return
+	}
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, "")); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+}
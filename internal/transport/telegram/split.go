@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"ym-bot/internal/services/music"
+	"ym-bot/internal/storage"
+	"ym-bot/internal/translit"
+)
+
+// deliverParts is deliver for a track asked for in parts: it downloads the
+// track, cuts it and sends the parts in order. The download, the cutting
+// and each part's upload get a timeout of their own, as a long mix takes a
+// while at every step. The quota is charged once for the whole track and
+// returned only when no part reached the chat; a failed part ends the series.
+func (b *Bot) deliverParts(ctx context.Context, req downloadRequest) storage.JobState {
+	ctx = b.chatContext(ctx, req.chatID)
+	part := b.settings.Load().SplitPart
+
+	entry := storage.AuditEntry{At: time.Now(), Bot: b.api.Self.UserName, UserID: req.userID, ChatID: req.chatID, TrackID: req.trackID}
+	dlCtx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+	hidden, err := b.explicitHidden(dlCtx, req)
+	if hidden {
+		cancel()
+		b.refuseExplicit(req, entry)
+		return storage.JobFailed
+	}
+	var dl music.Download
+	if err == nil {
+		dl, err = b.musicService.DownloadTrack(dlCtx, req.trackID)
+	}
+	cancel()
+	var parts []music.Download
+	if err == nil {
+		splitCtx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+		dl = b.musicService.Process(splitCtx, dl, b.audioFilters(req.userID))
+		if script := b.transliteration(req.userID); script != translit.None {
+			dl = b.musicService.Relabel(splitCtx, dl, music.Transliterate(dl.Track, script))
+		}
+		parts, err = b.musicService.Split(splitCtx, dl, part)
+		cancel()
+	}
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		if s := b.jobFor(req.key); s == nil || !s.abortedByUser() {
+			// Shutdown: keep the reservation, the job resumes after restart.
+			return storage.JobRunning
+		}
+		b.store.ReleaseQuota(req.userID, req.reservedAt)
+		b.recordAudit(entry, storage.AuditCancelled, nil)
+		return storage.JobCancelled
+	}
+	if err != nil {
+		b.store.ReleaseQuota(req.userID, req.reservedAt)
+		ue := presentError(err, errDownloadFailed)
+		b.logger.Warn("split download failed", zap.String("trackID", req.trackID), zap.String("code", ue.code), zap.Error(err))
+		b.recordAudit(entry, storage.AuditFailed, err)
+		req.notify(ue.String())
+		return storage.JobFailed
+	}
+	// The parts share a dir, closing one removes them all.
+	defer parts[0].Close()
+
+	track := parts[0].Track
+	track.DurationSeconds = 0
+	for _, p := range parts {
+		entry.Bytes += p.Size
+		track.DurationSeconds += p.Track.DurationSeconds
+	}
+	entry.Title = fmt.Sprintf("%s — %s", track.ArtistsString(), track.FullTitle())
+	for _, p := range parts {
+		if p.Size > b.uploadLimit {
+			b.store.ReleaseQuota(req.userID, req.reservedAt)
+			b.recordAudit(entry, storage.AuditTooLarge, nil)
+			req.notify(presentError(tooLargeError{size: p.Size, limit: b.uploadLimit}, errGeneric).String())
+			return storage.JobFailed
+		}
+	}
+
+	for i, p := range parts {
+		if len(parts) > 1 {
+			p.Track.Title = fmt.Sprintf("%s (часть %d из %d)", p.Track.FullTitle(), i+1, len(parts))
+		}
+		if err := b.sendPart(ctx, req, p); err != nil {
+			b.logger.Warn("send part failed", zap.String("trackID", req.trackID), zap.Int("part", i+1), zap.Error(err))
+			b.recordAudit(entry, storage.AuditSendFailed, err)
+			if i == 0 {
+				b.store.ReleaseQuota(req.userID, req.reservedAt)
+				req.notify(presentError(err, errSendFailed).String())
+				return storage.JobFailed
+			}
+			req.notify(fmt.Sprintf("Не удалось отправить часть %d из %d, остальные части не отправлены. Запросите трек заново.", i+1, len(parts)))
+			return storage.JobFailed
+		}
+	}
+	b.recordAudit(entry, storage.AuditDelivered, nil)
+	b.store.RecordDownload(req.userID, historyEntry(req.trackID, track))
+	return storage.JobDone
+}
+
+// sendPart uploads one part of a split track.
+func (b *Bot) sendPart(ctx context.Context, req downloadRequest, p music.Download) error {
+	ctx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+	defer cancel()
+	_, err := b.sender.Send(b.buildDelivery(ctx, req.chatID, req.userID, p, false))
+	return err
+}
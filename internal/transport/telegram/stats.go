@@ -0,0 +1,105 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/chart"
+	"ym-bot/internal/storage"
+)
+
+const (
+	defaultStatsDays = 7
+	maxStatsDays     = 90
+	statsTopTracks   = 5
+)
+
+var (
+	searchesColor  = color.RGBA{R: 0x42, G: 0x85, B: 0xf4, A: 0xff}
+	downloadsColor = color.RGBA{R: 0xfb, G: 0xbc, B: 0x05, A: 0xff}
+)
+
+func (b *Bot) handleStats(_ context.Context, m *tgbotapi.Message) {
+	days, err := parseStatsPeriod(m.CommandArguments())
+	if err != nil {
+		b.reply(m.Chat.ID, "Использование: /stats [7d|30d]")
+		return
+	}
+
+	sum := b.store.Stats(time.Now(), days, statsTopTracks)
+	b.reply(m.Chat.ID, renderStats(sum, days))
+
+	if !b.settings.Load().StatsChart {
+		return
+	}
+
+	png, err := statsChart(sum, days)
+	if err != nil {
+		b.logger.Warn("render stats chart failed", zap.Error(err))
+		return
+	}
+	photo := tgbotapi.NewPhoto(m.Chat.ID, tgbotapi.FileBytes{Name: "stats.png", Bytes: png})
+	if _, err := b.sender.Send(photo); err != nil {
+		b.logger.Warn("send stats chart failed", zap.Error(err))
+	}
+}
+
+// parseStatsPeriod accepts "", "7d", "30d" or any "<n>d" up to maxStatsDays.
+func parseStatsPeriod(arg string) (int, error) {
+	arg = strings.TrimSpace(strings.ToLower(arg))
+	if arg == "" {
+		return defaultStatsDays, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(arg, "d"))
+	if err != nil || n <= 0 || n > maxStatsDays {
+		return 0, fmt.Errorf("invalid period %q", arg)
+	}
+	return n, nil
+}
+
+func renderStats(sum storage.StatsSummary, days int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Статистика за %d дн.\n", days)
+	fmt.Fprintf(&sb, "Поисков: %d\nЗагрузок: %d\nУникальных пользователей: %d\n", sum.Searches, sum.Downloads, sum.UniqueUsers)
+	if sum.Picks > 0 {
+		fmt.Fprintf(&sb, "Выбрано в inline: %d\n", sum.Picks)
+	}
+	if sum.Panics > 0 {
+		fmt.Fprintf(&sb, "Сбоев обработчиков: %d\n", sum.Panics)
+	}
+
+	if len(sum.TopTracks) > 0 {
+		sb.WriteString("\nТоп треков:\n")
+		for i, t := range sum.TopTracks {
+			title := t.Title
+			if title == "" {
+				title = t.ID
+			}
+			fmt.Fprintf(&sb, "%d. %s — %d\n", i+1, title, t.Count)
+		}
+	}
+	return sb.String()
+}
+
+func statsChart(sum storage.StatsSummary, days int) ([]byte, error) {
+	labels := make([]string, 0, len(sum.Days))
+	searches := make([]int, 0, len(sum.Days))
+	downloads := make([]int, 0, len(sum.Days))
+	for _, d := range sum.Days {
+		labels = append(labels, d.Date.Format("01-02"))
+		searches = append(searches, d.Searches)
+		downloads = append(downloads, d.Downloads)
+	}
+
+	return chart.Bars(fmt.Sprintf("last %d days", days), labels, []chart.Series{
+		{Name: "searches", Color: searchesColor, Values: searches},
+		{Name: "downloads", Color: downloadsColor, Values: downloads},
+	})
+}
@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/storage"
+)
+
+const (
+	// suggestRecent is how many of the user's latest downloads an empty query offers.
+	suggestRecent = 5
+	// suggestTotal caps audio suggestions; the chart fills what history leaves.
+	suggestTotal = searchLimit
+)
+
+// answerInlineSuggestions answers an empty inline query with the user's
+// latest downloads, then chart toppers, then recent searches.
+func (b *Bot) answerInlineSuggestions(ctx context.Context, q *tgbotapi.InlineQuery) {
+	var tracks []yandex.Track
+	if ids := recentDownloads(b.store.History(q.From.ID), suggestRecent); len(ids) > 0 {
+		recent, err := b.musicService.Tracks(ctx, ids)
+		if err != nil {
+			b.logger.Debug("recent downloads unavailable", zap.Error(err))
+		}
+		tracks = append(tracks, recent...)
+	}
+	if len(tracks) < suggestTotal {
+		chart, err := b.musicService.Chart(ctx, suggestTotal)
+		if err != nil {
+			b.logger.Debug("chart unavailable", zap.Error(err))
+		}
+		tracks = appendNew(tracks, chart, suggestTotal)
+	}
+
+	results, expires := b.inlineResults(ctx, q.From.ID, tracks, false)
+	results = append(results, b.recentSearchResults(q.From.ID)...)
+	if len(results) == 0 {
+		return
+	}
+
+	// Built from the user's own history and searches.
+	ans := tgbotapi.InlineConfig{
+		InlineQueryID: q.ID,
+		IsPersonal:    true,
+		CacheTime:     b.inlineCacheTime(true, expires),
+		Results:       results,
+	}
+	if _, err := b.sender.Request(ans); err != nil {
+		b.logger.Warn("answer inline suggestions failed", zap.Error(err))
+	}
+}
+
+// recentDownloads returns up to n distinct track ids from history, newest first.
+func recentDownloads(history []storage.HistoryEntry, n int) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for i := len(history) - 1; i >= 0 && len(ids) < n; i-- {
+		id := history[i].TrackID
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// appendNew adds tracks from more that are not in tracks yet, up to limit in total.
+func appendNew(tracks, more []yandex.Track, limit int) []yandex.Track {
+	seen := make(map[string]bool, len(tracks))
+	for _, t := range tracks {
+		seen[t.ID] = true
+	}
+	for _, t := range more {
+		if len(tracks) >= limit {
+			break
+		}
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			tracks = append(tracks, t)
+		}
+	}
+	return tracks
+}
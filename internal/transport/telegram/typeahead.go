@@ -0,0 +1,61 @@
+package telegram
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+)
+
+// typeaheadMaxRunes is the longest inline query answered with completions
+// instead of tracks: a search for one or two letters finds nothing useful.
+const typeaheadMaxRunes = 2
+
+// answerInlineTypeahead answers a very short inline query with Yandex's
+// completions of it. Picking one sends it as a search, and its button puts
+// it into the input field to search inline. It reports false when there is
+// nothing to offer, or when the query is itself among the completions, a
+// whole name such as "U2", so the query goes to the regular search.
+func (b *Bot) answerInlineTypeahead(ctx context.Context, q *tgbotapi.InlineQuery, query string) bool {
+	if utf8.RuneCountInString(query) > typeaheadMaxRunes {
+		return false
+	}
+	suggestions, err := b.musicService.Suggest(ctx, query, searchLimit)
+	if err != nil {
+		b.logger.Debug("search suggest failed", zap.String("query", query), zap.Error(err))
+		return false
+	}
+	if len(suggestions) == 0 {
+		return false
+	}
+	for _, s := range suggestions {
+		if strings.EqualFold(strings.TrimSpace(s), query) {
+			return false
+		}
+	}
+
+	results := make([]interface{}, 0, len(suggestions))
+	for i, s := range suggestions {
+		article := tgbotapi.NewInlineQueryResultArticle("s"+strconv.Itoa(i), searchHeader+s, searchHeader+s)
+		article.Description = "Продолжить поиск"
+		article.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{{
+			{Text: "🔎 Искать", SwitchInlineQueryCurrentChat: &s},
+		}}}
+		results = append(results, article)
+	}
+
+	// Completions depend on the prefix alone and carry no audio URLs.
+	ans := tgbotapi.InlineConfig{
+		InlineQueryID: q.ID,
+		CacheTime:     b.inlineCacheTime(false, time.Time{}),
+		Results:       results,
+	}
+	if _, err := b.sender.Request(ans); err != nil {
+		b.logger.Warn("answer inline typeahead failed", zap.String("query", query), zap.Error(err))
+	}
+	return true
+}
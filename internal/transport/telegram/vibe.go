@@ -0,0 +1,187 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/client/yandex"
+	"ym-bot/internal/storage"
+)
+
+// ActionVibe operations.
+const (
+	vibeNext = "n"
+	vibeSkip = "s"
+	vibeLike = "l"
+	vibeStop = "x"
+)
+
+// vibeSession is a user's listening of the personal station: the batch being
+// played and the track its control message refers to.
+type vibeSession struct {
+	mu        sync.Mutex
+	chatID    int64
+	batchID   string
+	queue     []yandex.Track
+	current   yandex.Track
+	startedAt time.Time
+}
+
+// handleVibe starts the personal station of the bot's Yandex account and
+// sends its first track. The station, its feedback and likes belong to that
+// account, so /vibe is for the bot admins only.
+func (b *Bot) handleVibe(ctx context.Context, m *tgbotapi.Message) {
+	s := &vibeSession{chatID: m.Chat.ID}
+	b.vibesMu.Lock()
+	b.vibes[m.From.ID] = s
+	b.vibesMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b.vibeFeedback(ctx, s, yandex.FeedbackRadioStarted, 0)
+	b.vibeAdvance(ctx, m.From.ID, s)
+}
+
+// handleVibeCallback reports how the current track was listened to and moves on.
+func (b *Bot) handleVibeCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	if !b.isAdmin(cb.From.ID) {
+		b.sendAlert(cb, "«Моя волна» доступна только администраторам бота.")
+		return
+	}
+	b.vibesMu.Lock()
+	s := b.vibes[cb.From.ID]
+	b.vibesMu.Unlock()
+	if s == nil || cb.Message == nil {
+		b.sendAlert(cb, "Волна остановлена, запустите её заново: /vibe")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current.ID != p.Arg(1) {
+		b.sendAlert(cb, "Эта кнопка уже неактуальна, управляйте волной из последнего сообщения.")
+		return
+	}
+
+	op := p.Arg(0)
+	if op == vibeLike {
+		if err := b.musicService.LikeTracks(ctx, []string{s.current.ID}); err != nil {
+			b.logger.Warn("like vibe track failed", zap.String("trackID", s.current.ID), zap.Error(err))
+			b.sendAlert(cb, presentError(err, errGeneric).String())
+			return
+		}
+		b.sendAlert(cb, "❤️ Трек добавлен в «Мне нравится».")
+		return
+	}
+
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, "")); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+	b.editMarkup(cb.Message, tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})
+
+	played := time.Duration(s.current.DurationSeconds) * time.Second
+	switch op {
+	case vibeNext:
+		b.vibeFeedback(ctx, s, yandex.FeedbackTrackFinished, played)
+	case vibeSkip:
+		b.vibeFeedback(ctx, s, yandex.FeedbackSkip, min(time.Since(s.startedAt), played))
+	case vibeStop:
+		b.vibesMu.Lock()
+		if b.vibes[cb.From.ID] == s {
+			delete(b.vibes, cb.From.ID)
+		}
+		b.vibesMu.Unlock()
+		b.reply(s.chatID, "⏹ Волна остановлена. Продолжить можно командой /vibe.")
+		return
+	default:
+		return
+	}
+	b.vibeAdvance(ctx, cb.From.ID, s)
+}
+
+// vibeAdvance takes the next track of the station, fetching a new batch when
+// the current one is played out, queues its download and sends the controls.
+// The caller holds s.mu.
+func (b *Bot) vibeAdvance(ctx context.Context, userID int64, s *vibeSession) {
+	if len(s.queue) == 0 {
+		batch, err := b.musicService.Wave(ctx, s.current.ID)
+		if err != nil {
+			b.logger.Warn("fetch wave failed", zap.Int64("userID", userID), zap.Error(err))
+			b.reply(s.chatID, presentError(err, errGeneric).String())
+			return
+		}
+		s.batchID, s.queue = batch.BatchID, batch.Tracks
+	}
+	if len(s.queue) == 0 {
+		b.reply(s.chatID, "«Моя волна» пока ничего не предлагает, попробуйте позже.")
+		return
+	}
+	s.current, s.queue = s.queue[0], s.queue[1:]
+	s.startedAt = time.Now()
+	b.vibeFeedback(ctx, s, yandex.FeedbackTrackStarted, 0)
+
+	t := s.current
+	text := fmt.Sprintf("🌊 Моя волна: %s — %s", t.ArtistsString(), t.FullTitle())
+	if reason := b.vibeDownload(ctx, userID, s); reason != "" {
+		text += "\n\n" + reason
+	}
+
+	msg := tgbotapi.NewMessage(s.chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			b.button("▶️ Дальше", callback.ActionVibe, vibeNext, t.ID),
+			b.button("⏭ Пропустить", callback.ActionVibe, vibeSkip, t.ID),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			b.button("❤️ Нравится", callback.ActionVibe, vibeLike, t.ID),
+			b.button("⏹ Стоп", callback.ActionVibe, vibeStop, t.ID),
+		),
+	)
+	if _, err := b.sender.Send(msg); err != nil {
+		b.logger.Warn("send vibe controls failed", zap.Int64("chatID", s.chatID), zap.Error(err))
+	}
+}
+
+// vibeDownload queues the current track for delivery, returning why it was
+// not when it could not be.
+func (b *Bot) vibeDownload(ctx context.Context, userID int64, s *vibeSession) string {
+	t := s.current
+	if t.Availability != yandex.Available {
+		return presentError(t.Availability.Err(), errDownloadFailed).text
+	}
+	req := downloadRequest{
+		key:     fmt.Sprintf("vibe:%d:%s:%d", userID, t.ID, time.Now().UnixNano()),
+		userID:  userID,
+		chatID:  s.chatID,
+		trackID: t.ID,
+		quiet:   true,
+		notify:  func(text string) { b.reply(s.chatID, text) },
+	}
+	_, quota, err := b.queueDownload(ctx, req)
+	if errors.Is(err, storage.ErrQuotaExceeded) {
+		return quotaExceededText(quota, time.Now())
+	}
+	if err != nil {
+		return "Сейчас слишком много загрузок, трек не скачан."
+	}
+	return ""
+}
+
+// vibeFeedback reports a playback event of the current track; failures only
+// cost recommendation quality, so they are logged and ignored.
+func (b *Bot) vibeFeedback(ctx context.Context, s *vibeSession, event string, played time.Duration) {
+	f := yandex.StationFeedback{Type: event, BatchID: s.batchID, Played: played}
+	if event != yandex.FeedbackRadioStarted {
+		f.Track = s.current
+	}
+	if err := b.musicService.WaveFeedback(ctx, f); err != nil {
+		b.logger.Debug("wave feedback failed", zap.String("event", event), zap.Error(err))
+	}
+}
@@ -0,0 +1,66 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/services/cover"
+)
+
+// videoPrefix switches an inline query to music videos: "@bot video: artist".
+const videoPrefix = "video:"
+
+// answerInlineVideos answers an inline query with clips instead of audio.
+func (b *Bot) answerInlineVideos(ctx context.Context, q *tgbotapi.InlineQuery, query string, offset int) {
+	if query == "" {
+		return
+	}
+
+	videos, err := b.musicService.SearchVideos(ctx, query, searchLimit, offset)
+	if err != nil {
+		b.logger.Warn("video search failed", zap.String("query", query), zap.Error(err))
+		return
+	}
+	if offset == 0 {
+		b.store.RecordSearch(q.From.ID, time.Now())
+	}
+
+	results := make([]interface{}, 0, len(videos))
+	for i, v := range videos {
+		// Clips live on provider pages, so Telegram needs text/html plus a message to send.
+		id := fmt.Sprintf("v%d", offset+i)
+		video := tgbotapi.NewInlineQueryResultVideo(id, v.URL)
+		video.MimeType = "text/html"
+		video.ThumbURL = cover.URL(v.ThumbURI, cover.Inline)
+		video.Title = v.Title
+		video.Duration = v.DurationSeconds
+		video.InputMessageContent = tgbotapi.InputTextMessageContent{
+			Text: fmt.Sprintf("🎬 %s\n%s", v.Title, v.URL),
+		}
+		results = append(results, video)
+	}
+
+	// Clips link to provider pages that do not expire.
+	ans := tgbotapi.InlineConfig{
+		InlineQueryID: q.ID,
+		CacheTime:     b.inlineCacheTime(false, time.Time{}),
+		Results:       results,
+		NextOffset:    b.inlineNextOffset(offset+len(results), len(results) == 0),
+	}
+	if _, err := b.sender.Request(ans); err != nil {
+		b.logger.Warn("answer inline videos failed", zap.String("query", query), zap.Error(err))
+	}
+}
+
+// cutPrefixFold is strings.CutPrefix ignoring ASCII case.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/callback"
+	"ym-bot/internal/render"
+	"ym-bot/internal/services/music"
+)
+
+// handleWaveformCallback draws the loudness of the track's preview clip and
+// sends it as a photo in reply to the preview message. Getting the envelope
+// means decoding the clip, so the press is acknowledged first.
+func (b *Bot) handleWaveformCallback(ctx context.Context, cb *tgbotapi.CallbackQuery, p callback.Payload) {
+	trackID := p.Arg(0)
+	if trackID == "" {
+		return
+	}
+	chatID := cb.From.ID
+	if cb.Message != nil && cb.Message.Chat != nil {
+		chatID = cb.Message.Chat.ID
+	}
+	press := "wave:" + pressKey(cb.From.ID, trackID)
+	if !b.presses.claim(press, false) {
+		b.sendAlert(cb, "Волна уже рисуется.")
+		return
+	}
+	defer b.presses.done(press)
+	if _, err := b.sender.Request(tgbotapi.NewCallback(cb.ID, "Рисую волну…")); err != nil {
+		b.logger.Warn("callback ack failed", zap.Error(err))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, b.callbackTimeout)
+	defer cancel()
+
+	t, peaks, err := b.musicService.Waveform(ctx, trackID, render.WaveformBars)
+	if err != nil {
+		b.logger.Warn("waveform failed", zap.String("trackID", trackID), zap.Error(err))
+		if errors.Is(err, music.ErrNoTranscoder) {
+			b.reply(chatID, "Волна сейчас недоступна.")
+			return
+		}
+		b.reply(chatID, presentError(err, errDownloadFailed).String())
+		return
+	}
+	start, length := music.PreviewClip(time.Duration(t.DurationSeconds) * time.Second)
+	card, err := render.WaveformCard(render.Waveform{
+		Title:     t.FullTitle(),
+		Artists:   t.ArtistsString(),
+		Peaks:     peaks,
+		Start:     start,
+		Duration:  length,
+		Watermark: "@" + b.api.Self.UserName,
+	})
+	if err != nil {
+		b.logger.Warn("render waveform failed", zap.String("trackID", trackID), zap.Error(err))
+		b.reply(chatID, "Не удалось нарисовать волну :(")
+		return
+	}
+
+	photo := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "waveform.png", Bytes: card})
+	photo.Caption = fmt.Sprintf("〰 %s — %s (%s)", t.ArtistsString(), t.FullTitle(), t.DurationString())
+	if cb.Message != nil {
+		photo.ReplyToMessageID = cb.Message.MessageID
+	}
+	if _, err := b.sender.Send(photo); err != nil {
+		b.logger.Warn("send waveform failed", zap.Int64("chatID", chatID), zap.Error(err))
+		b.reply(chatID, "Не удалось отправить волну, попробуйте позже.")
+	}
+}
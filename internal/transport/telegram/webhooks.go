@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/zap"
+
+	"ym-bot/internal/storage"
+	"ym-bot/internal/webhook"
+)
+
+// notifyAudit tells the webhooks about a finished download: delivered ones
+// are events of their own, failed ones count towards an error burst.
+func (b *Bot) notifyAudit(e storage.AuditEntry, cause error) {
+	if b.webhooks == nil {
+		return
+	}
+	switch e.Outcome {
+	case storage.AuditDelivered, storage.AuditRedelivered:
+		b.webhooks.Notify(webhook.EventDownload, b.api.Self.UserName, webhook.Download{
+			UserID:      e.UserID,
+			ChatID:      e.ChatID,
+			TrackID:     e.TrackID,
+			Title:       e.Title,
+			Bytes:       e.Bytes,
+			DurationMs:  e.Duration.Milliseconds(),
+			Redelivered: e.Outcome == storage.AuditRedelivered,
+		})
+	case storage.AuditFailed, storage.AuditSendFailed:
+		b.webhooks.RecordError(b.api.Self.UserName, cause)
+	}
+}
+
+// notifyRegistered reports a user starting the bot for the first time, that
+// is with nothing in the store about them yet.
+func (b *Bot) notifyRegistered(m *tgbotapi.Message) {
+	if b.webhooks == nil || m.From == nil || !m.Chat.IsPrivate() {
+		return
+	}
+	registered, err := b.store.RegisterUser(m.From.ID)
+	if err != nil {
+		b.logger.Warn("register user failed", zap.Int64("userID", m.From.ID), zap.Error(err))
+	}
+	if !registered {
+		return
+	}
+	b.webhooks.Notify(webhook.EventUserRegistered, b.api.Self.UserName, webhook.User{
+		UserID:       m.From.ID,
+		Username:     m.From.UserName,
+		FirstName:    m.From.FirstName,
+		LanguageCode: m.From.LanguageCode,
+	})
+}
@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Levels holds the base log level plus per-component overrides parsed from
+// directives like "info,yandex=debug,telegram=warn". It can be updated at runtime.
+type Levels struct {
+	mu        sync.RWMutex
+	base      zapcore.Level
+	overrides map[string]zapcore.Level
+}
+
+// ParseLevelSpec splits a directive string into the base level and overrides.
+func ParseLevelSpec(spec string) (zapcore.Level, map[string]zapcore.Level, error) {
+	base := zapcore.InfoLevel
+	overrides := make(map[string]zapcore.Level)
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, lvl, ok := strings.Cut(part, "=")
+		if !ok {
+			parsed, err := ParseLevel(part)
+			if err != nil {
+				return base, nil, err
+			}
+			base = parsed
+			continue
+		}
+
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			return base, nil, fmt.Errorf("empty component name in %q", part)
+		}
+		parsed, err := ParseLevel(strings.TrimSpace(lvl))
+		if err != nil {
+			return base, nil, fmt.Errorf("component %s: %w", name, err)
+		}
+		overrides[name] = parsed
+	}
+
+	return base, overrides, nil
+}
+
+// NewLevels builds Levels from a directive string.
+func NewLevels(spec string) (*Levels, error) {
+	l := &Levels{}
+	if err := l.Set(spec); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Set atomically replaces the base level and all overrides.
+func (l *Levels) Set(spec string) error {
+	base, overrides, err := ParseLevelSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.base = base
+	l.overrides = overrides
+	l.mu.Unlock()
+	return nil
+}
+
+// Named returns a child logger for component whose verbosity follows its override.
+func (l *Levels) Named(logger *zap.Logger, component string) *zap.Logger {
+	enabler := componentEnabler{levels: l, name: strings.ToLower(component)}
+	return logger.Named(component).WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+		if fc, ok := c.(*filterCore); ok {
+			c = fc.inner
+		}
+		return &filterCore{inner: c, enabler: enabler}
+	}))
+}
+
+func (l *Levels) enabled(name string, lvl zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if override, ok := l.overrides[name]; ok {
+		return lvl >= override
+	}
+	return lvl >= l.base
+}
+
+// componentEnabler resolves the effective level for a component on every check.
+type componentEnabler struct {
+	levels *Levels
+	name   string
+}
+
+func (e componentEnabler) Enabled(lvl zapcore.Level) bool {
+	return e.levels.enabled(e.name, lvl)
+}
+
+// filterCore gates an unfiltered core with a component-specific level.
+type filterCore struct {
+	inner   zapcore.Core
+	enabler zapcore.LevelEnabler
+}
+
+func (c *filterCore) Enabled(lvl zapcore.Level) bool {
+	return c.enabler.Enabled(lvl) && c.inner.Enabled(lvl)
+}
+
+func (c *filterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &filterCore{inner: c.inner.With(fields), enabler: c.enabler}
+}
+
+func (c *filterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.enabler.Enabled(ent.Level) {
+		return ce
+	}
+	return c.inner.Check(ent, ce)
+}
+
+func (c *filterCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.inner.Write(ent, fields)
+}
+
+func (c *filterCore) Sync() error {
+	return c.inner.Sync()
+}
@@ -2,43 +2,117 @@ package utils
 
 import (
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-// NewLogger builds a zap logger with human-friendly console output.
-func NewLogger(level string) (*zap.Logger, error) {
-	zapLevel, err := parseLevel(level)
+// LogOptions describes how the application logger is built.
+type LogOptions struct {
+	Level    string   // base level with optional overrides: "info,yandex=debug"
+	Encoding string   // "console" (default) or "json"
+	Outputs  []string // "stdout", "stderr" or file paths; files are rotated
+	Sampling bool
+
+	// Rotation settings applied to file outputs.
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+
+	// Report, when set, receives entries alongside the outputs, e.g. to ship
+	// them to an error tracker. It is not sampled but honours the levels.
+	Report zapcore.Core
+}
+
+// NewLogger builds a zap logger from opts; console output to stdout by default.
+// The returned Levels allows changing verbosity at runtime and deriving
+// per-component loggers via Levels.Named.
+func NewLogger(opts LogOptions) (*zap.Logger, *Levels, error) {
+	levels, err := NewLevels(opts.Level)
 	if err != nil {
-		return nil, err
-	}
-
-	cfg := zap.Config{
-		Level:            zap.NewAtomicLevelAt(zapLevel),
-		Encoding:         "console",
-		OutputPaths:      []string{"stdout"},
-		ErrorOutputPaths: []string{"stderr"},
-		EncoderConfig: zapcore.EncoderConfig{
-			TimeKey:        "ts",
-			LevelKey:       "level",
-			NameKey:        "logger",
-			CallerKey:      "caller",
-			MessageKey:     "msg",
-			StacktraceKey:  "stack",
-			LineEnding:     zapcore.DefaultLineEnding,
-			EncodeLevel:    zapcore.CapitalColorLevelEncoder,
-			EncodeTime:     zapcore.ISO8601TimeEncoder,
-			EncodeDuration: zapcore.StringDurationEncoder,
-			EncodeCaller:   zapcore.ShortCallerEncoder,
-		},
-	}
-
-	return cfg.Build()
+		return nil, nil, err
+	}
+
+	outputs := opts.Outputs
+	if len(outputs) == 0 {
+		outputs = []string{"stdout"}
+	}
+
+	sinks := make([]zapcore.WriteSyncer, 0, len(outputs))
+	onlyStd := true
+	for _, out := range outputs {
+		switch out {
+		case "stdout":
+			sinks = append(sinks, zapcore.Lock(os.Stdout))
+		case "stderr":
+			sinks = append(sinks, zapcore.Lock(os.Stderr))
+		default:
+			onlyStd = false
+			sinks = append(sinks, zapcore.AddSync(&lumberjack.Logger{
+				Filename:   out,
+				MaxSize:    opts.MaxSizeMB,
+				MaxBackups: opts.MaxBackups,
+				MaxAge:     opts.MaxAgeDays,
+				Compress:   opts.Compress,
+			}))
+		}
+	}
+
+	encCfg := zapcore.EncoderConfig{
+		TimeKey:        "ts",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stack",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.CapitalColorLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+
+	var encoder zapcore.Encoder
+	switch strings.ToLower(opts.Encoding) {
+	case "json":
+		encCfg.EncodeLevel = zapcore.LowercaseLevelEncoder
+		encCfg.EncodeDuration = zapcore.MillisDurationEncoder
+		encoder = zapcore.NewJSONEncoder(encCfg)
+	case "console", "":
+		// Escape codes only make sense on a terminal, not in log files.
+		if !onlyStd {
+			encCfg.EncodeLevel = zapcore.CapitalLevelEncoder
+		}
+		encoder = zapcore.NewConsoleEncoder(encCfg)
+	default:
+		return nil, nil, fmt.Errorf("unknown log encoding: %s", opts.Encoding)
+	}
+
+	// The sink core accepts everything; filterCore applies the effective level per component.
+	var core zapcore.Core = zapcore.NewCore(encoder, zapcore.NewMultiWriteSyncer(sinks...), zapcore.DebugLevel)
+	if opts.Sampling {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, 100, 100)
+	}
+	if opts.Report != nil {
+		core = zapcore.NewTee(core, opts.Report)
+	}
+	core = &filterCore{inner: core, enabler: componentEnabler{levels: levels}}
+
+	logger := zap.New(core,
+		zap.AddCaller(),
+		zap.AddStacktrace(zapcore.ErrorLevel),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	)
+	return logger, levels, nil
 }
 
-func parseLevel(level string) (zapcore.Level, error) {
+// ParseLevel maps a textual level to zapcore.Level.
+func ParseLevel(level string) (zapcore.Level, error) {
 	switch strings.ToLower(level) {
 	case "debug":
 		return zapcore.DebugLevel, nil
@@ -52,4 +126,3 @@ func parseLevel(level string) (zapcore.Level, error) {
 		return zapcore.InfoLevel, fmt.Errorf("unknown log level: %s", level)
 	}
 }
-
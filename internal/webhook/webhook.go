@@ -0,0 +1,323 @@
+// Package webhook notifies external systems (dashboards, moderation tools)
+// of bot events by POSTing them as JSON to configured URLs. Every request is
+// signed with the endpoint's secret: X-YM-Bot-Signature is
+// "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)), where
+// timestamp is the X-YM-Bot-Timestamp header, so receivers can reject forged
+// and replayed deliveries. Events are queued and sent in the background; a
+// full queue or an unreachable endpoint only costs notifications.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Event types.
+const (
+	EventDownload       = "download.completed"
+	EventUserRegistered = "user.registered"
+	EventErrorBurst     = "errors.burst"
+)
+
+// EventTypes lists every event type, for validating configuration.
+var EventTypes = []string{EventDownload, EventUserRegistered, EventErrorBurst}
+
+const (
+	// maxAttempts bounds deliveries of one event to one endpoint.
+	maxAttempts = 3
+	// retryDelay is the pause before the second attempt; it doubles after.
+	retryDelay = time.Second
+)
+
+// Event is the JSON body of a notification.
+type Event struct {
+	ID   string    `json:"id"`
+	Type string    `json:"type"`
+	At   time.Time `json:"at"`
+	// Bot is the username of the bot the event happened in.
+	Bot  string `json:"bot,omitempty"`
+	Data any    `json:"data"`
+}
+
+// Download is the data of EventDownload.
+type Download struct {
+	UserID  int64  `json:"user_id"`
+	ChatID  int64  `json:"chat_id"`
+	TrackID string `json:"track_id"`
+	Title   string `json:"title"`
+	Bytes   int64  `json:"bytes"`
+	// DurationMs is how long the job took.
+	DurationMs  int64 `json:"duration_ms"`
+	Redelivered bool  `json:"redelivered,omitempty"`
+}
+
+// User is the data of EventUserRegistered.
+type User struct {
+	UserID       int64  `json:"user_id"`
+	Username     string `json:"username,omitempty"`
+	FirstName    string `json:"first_name,omitempty"`
+	LanguageCode string `json:"language_code,omitempty"`
+}
+
+// ErrorBurst is the data of EventErrorBurst.
+type ErrorBurst struct {
+	Count     int       `json:"count"`
+	Window    string    `json:"window"`
+	Since     time.Time `json:"since"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Endpoint is a URL notified of Events, all of them when empty.
+type Endpoint struct {
+	URL    string
+	Secret string
+	Events []string
+}
+
+func (e Endpoint) wants(eventType string) bool {
+	return len(e.Events) == 0 || slices.Contains(e.Events, eventType)
+}
+
+// Notifier posts events to its endpoints from a background goroutine.
+type Notifier struct {
+	endpoints  []Endpoint
+	httpClient *http.Client
+	logger     *zap.Logger
+
+	queue chan Event
+	// mu orders Notify's pending.Add against Flush's pending.Wait: once
+	// closed is set, no more events are added.
+	mu      sync.Mutex
+	closed  bool
+	pending sync.WaitGroup
+
+	burstMu     sync.Mutex
+	burstCount  int
+	burstWindow time.Duration
+	failures    []time.Time
+	burstUntil  time.Time
+}
+
+// Option configures a Notifier.
+type Option func(*Notifier)
+
+// WithHTTPClient sets the client events are posted with.
+func WithHTTPClient(c *http.Client) Option {
+	return func(n *Notifier) {
+		if c != nil {
+			n.httpClient = c
+		}
+	}
+}
+
+// WithLogger sets where failed deliveries are logged.
+func WithLogger(logger *zap.Logger) Option {
+	return func(n *Notifier) {
+		if logger != nil {
+			n.logger = logger
+		}
+	}
+}
+
+// WithQueueSize sets how many events may wait to be sent; later ones are dropped.
+func WithQueueSize(size int) Option {
+	return func(n *Notifier) {
+		if size > 0 {
+			n.queue = make(chan Event, size)
+		}
+	}
+}
+
+// WithErrorBurst fires EventErrorBurst when count errors are recorded within
+// window; count 0 never fires.
+func WithErrorBurst(count int, window time.Duration) Option {
+	return func(n *Notifier) {
+		n.burstCount, n.burstWindow = count, window
+	}
+}
+
+// New starts a notifier for endpoints.
+func New(endpoints []Endpoint, opts ...Option) *Notifier {
+	n := &Notifier{
+		endpoints:  endpoints,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		logger:     zap.NewNop(),
+		queue:      make(chan Event, 100),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	go n.run()
+	return n
+}
+
+// Notify queues an event of eventType carrying data. It never blocks: when
+// the queue is full the event is dropped, and after Flush so is every event.
+func (n *Notifier) Notify(eventType, bot string, data any) {
+	if !slices.ContainsFunc(n.endpoints, func(e Endpoint) bool { return e.wants(eventType) }) {
+		return
+	}
+	ev := Event{ID: newEventID(), Type: eventType, At: time.Now().UTC(), Bot: bot, Data: data}
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		return
+	}
+	n.pending.Add(1)
+	n.mu.Unlock()
+	select {
+	case n.queue <- ev:
+	default:
+		n.pending.Done()
+		n.logger.Warn("webhook queue full, event dropped", zap.String("type", eventType))
+	}
+}
+
+// RecordError counts a failure towards an error burst and fires
+// EventErrorBurst when the configured number falls within the window. After
+// firing it stays quiet for a window, so an outage raises one event per
+// window rather than one per error.
+func (n *Notifier) RecordError(bot string, cause error) {
+	burst, ok := n.countFailure(time.Now())
+	if !ok {
+		return
+	}
+	if cause != nil {
+		burst.LastError = cause.Error()
+	}
+	n.Notify(EventErrorBurst, bot, burst)
+}
+
+// countFailure records a failure at now and returns the burst it completes,
+// if any.
+func (n *Notifier) countFailure(now time.Time) (ErrorBurst, bool) {
+	if n.burstCount <= 0 {
+		return ErrorBurst{}, false
+	}
+	n.burstMu.Lock()
+	defer n.burstMu.Unlock()
+	cutoff := now.Add(-n.burstWindow)
+	i := 0
+	for i < len(n.failures) && n.failures[i].Before(cutoff) {
+		i++
+	}
+	n.failures = append(n.failures[i:], now)
+	if len(n.failures) < n.burstCount || now.Before(n.burstUntil) {
+		return ErrorBurst{}, false
+	}
+	burst := ErrorBurst{Count: len(n.failures), Window: n.burstWindow.String(), Since: n.failures[0].UTC()}
+	n.failures = n.failures[:0]
+	n.burstUntil = now.Add(n.burstWindow)
+	return burst, true
+}
+
+// Flush stops accepting events, waits up to timeout for the queued ones to
+// be sent and reports whether they all were. It is meant for shutdown.
+func (n *Notifier) Flush(timeout time.Duration) bool {
+	n.mu.Lock()
+	n.closed = true
+	n.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (n *Notifier) run() {
+	for ev := range n.queue {
+		body, err := json.Marshal(ev)
+		if err != nil {
+			n.logger.Warn("encode webhook event failed", zap.String("type", ev.Type), zap.Error(err))
+			n.pending.Done()
+			continue
+		}
+		for _, e := range n.endpoints {
+			if e.wants(ev.Type) {
+				n.deliver(e, ev, body)
+			}
+		}
+		n.pending.Done()
+	}
+}
+
+// deliver posts body to e, retrying network errors and 5xx answers.
+func (n *Notifier) deliver(e Endpoint, ev Event, body []byte) {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(e, ev, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt == maxAttempts {
+			n.logger.Warn("webhook delivery failed", zap.String("url", e.URL), zap.String("type", ev.Type),
+				zap.String("id", ev.ID), zap.Int("attempts", attempt), zap.Error(err))
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post sends one attempt and reports whether a failure is worth retrying.
+func (n *Notifier) post(e Endpoint, ev Event, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ym-bot-webhook/1.0")
+	req.Header.Set("X-YM-Bot-Event", ev.Type)
+	req.Header.Set("X-YM-Bot-Delivery", ev.ID)
+	req.Header.Set("X-YM-Bot-Timestamp", ts)
+	if e.Secret != "" {
+		req.Header.Set("X-YM-Bot-Signature", Sign(e.Secret, ts, body))
+	}
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return false, nil
+}
+
+// Sign returns the X-YM-Bot-Signature value of body sent at timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newEventID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
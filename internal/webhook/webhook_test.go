@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	got := Sign("topsecret", "1700000000", []byte(`{"id":"1"}`))
+	want := "sha256=5ae2fe9589b5395efc54aa255a5cd86f4f6884285427ae96ebfc427963e60e81"
+	if got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+	if Sign("other", "1700000000", []byte(`{"id":"1"}`)) == want {
+		t.Error("signature does not depend on the secret")
+	}
+	if Sign("topsecret", "1700000001", []byte(`{"id":"1"}`)) == want {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+// TestErrorBurst walks failures through the burst window and the quiet
+// period after a burst.
+func TestErrorBurst(t *testing.T) {
+	n := New(nil, WithErrorBurst(3, time.Minute))
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		after time.Duration
+		fires bool
+		count int
+		since time.Duration
+	}{
+		{0, false, 0, 0},
+		{30 * time.Second, false, 0, 0},
+		// The first failure has left the window.
+		{70 * time.Second, false, 0, 0},
+		{80 * time.Second, true, 3, 30 * time.Second},
+		// Quiet for a window after the burst, though three failures are in it.
+		{90 * time.Second, false, 0, 0},
+		{100 * time.Second, false, 0, 0},
+		{110 * time.Second, false, 0, 0},
+		{150 * time.Second, true, 4, 90 * time.Second},
+	}
+	for _, st := range steps {
+		burst, ok := n.countFailure(t0.Add(st.after))
+		if ok != st.fires {
+			t.Fatalf("failure at +%s: fired %v, want %v", st.after, ok, st.fires)
+		}
+		if !ok {
+			continue
+		}
+		want := ErrorBurst{Count: st.count, Window: "1m0s", Since: t0.Add(st.since)}
+		if burst != want {
+			t.Errorf("failure at +%s: burst %+v, want %+v", st.after, burst, want)
+		}
+	}
+
+	off := New(nil)
+	if _, ok := off.countFailure(t0); ok {
+		t.Error("burst fired with bursts switched off")
+	}
+}
+
+// TestNotifyDuringFlush sends events against a flush, for the race
+// detector, and checks that events after it are dropped.
+func TestNotifyDuringFlush(t *testing.T) {
+	var mu sync.Mutex
+	received := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received++
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	n := New([]Endpoint{{URL: srv.URL}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				n.Notify(EventDownload, "ym_bot", Download{TrackID: "1"})
+			}
+		}()
+	}
+	if !n.Flush(5 * time.Second) {
+		t.Fatal("events not sent in time")
+	}
+	wg.Wait()
+
+	mu.Lock()
+	before := received
+	mu.Unlock()
+	n.Notify(EventDownload, "ym_bot", Download{TrackID: "2"})
+	if !n.Flush(time.Second) {
+		t.Error("second flush timed out")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if received != before {
+		t.Errorf("%d events sent after the flush, want none", received-before)
+	}
+}